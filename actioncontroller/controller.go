@@ -76,7 +76,7 @@ func (c *C) AfterAction(cb Callback, only ...string) {
 //	func WrapInTransaction(
 //		ctx *actioncontroller.Context, action actioncontroller.Action
 //	) (result actioncontroller.Result) {
-//		err := activerecord.Transaction(ctx, func() error {
+//		err := activerecord.Transaction(ctx, func(context.Context) error {
 //			result = action.Process(ctx)
 //			return result.Err()
 //		})
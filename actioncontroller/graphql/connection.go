@@ -0,0 +1,117 @@
+package graphql
+
+import (
+	graphql "github.com/vektah/gqlparser/v2/ast"
+
+	"github.com/activegraph/activegraph/actioncontroller"
+	"github.com/activegraph/activegraph/actionview"
+	"github.com/activegraph/activegraph/activerecord"
+	"github.com/activegraph/activegraph/activesupport"
+)
+
+// PageInfo is an object type of the pagination state of connections.
+var PageInfo = &graphql.Definition{
+	Kind:        graphql.Object,
+	Name:        "PageInfo",
+	Description: "Information about pagination in a connection.",
+	Fields: graphql.FieldList{
+		{
+			Name: "hasNextPage",
+			Type: &graphql.Type{NonNull: true, Elem: graphql.NamedType(Boolean.Name, nil)},
+		},
+		{
+			Name: "hasPreviousPage",
+			Type: &graphql.Type{NonNull: true, Elem: graphql.NamedType(Boolean.Name, nil)},
+		},
+		{
+			Name: "startCursor",
+			Type: graphql.NamedType(String.Name, nil),
+		},
+		{
+			Name: "endCursor",
+			Type: graphql.NamedType(String.Name, nil),
+		},
+	},
+	Interfaces: make([]string, 0),
+}
+
+// AddConnectionOp registers the Relay-style connection of the model, which is
+// paginated forward with "first" and "after" arguments, and filtered the same
+// way as the list of the model.
+func (s *Schema) AddConnectionOp(model *activerecord.Relation) *graphql.FieldDefinition {
+	var (
+		modelName      = CanonicalModelName(model.Name())
+		edgeName       = modelName + "Edge"
+		connectionName = modelName + "Connection"
+	)
+
+	s.root.Types[edgeName] = &graphql.Definition{
+		Kind: graphql.Object,
+		Name: edgeName,
+		Fields: graphql.FieldList{
+			{
+				Name: "cursor",
+				Type: &graphql.Type{NonNull: true, Elem: graphql.NamedType(String.Name, nil)},
+			},
+			{
+				Name: "node",
+				Type: &graphql.Type{NonNull: true, Elem: graphql.NamedType(modelName, nil)},
+			},
+		},
+		Interfaces: make([]string, 0),
+	}
+
+	s.root.Types[connectionName] = &graphql.Definition{
+		Kind: graphql.Object,
+		Name: connectionName,
+		Fields: graphql.FieldList{
+			{
+				Name: "edges",
+				Type: &graphql.Type{
+					NonNull: true,
+					Elem: &graphql.Type{
+						NonNull: true,
+						Elem:    graphql.NamedType(edgeName, nil),
+					},
+				},
+			},
+			{
+				Name: "pageInfo",
+				Type: &graphql.Type{NonNull: true, Elem: graphql.NamedType(PageInfo.Name, nil)},
+			},
+		},
+		Interfaces: make([]string, 0),
+	}
+
+	def := &graphql.FieldDefinition{
+		Name: activesupport.Pluralize(model.Name()) + "Connection",
+		Arguments: append(graphql.ArgumentDefinitionList{
+			{Name: "first", Type: graphql.NamedType(Int.Name, nil)},
+			{Name: "after", Type: graphql.NamedType(String.Name, nil)},
+		}, s.AddFilterArgs(model)...),
+		Type: &graphql.Type{NonNull: true, Elem: graphql.NamedType(connectionName, nil)},
+	}
+
+	s.root.Query.Fields = append(s.root.Query.Fields, def)
+	return def
+}
+
+// connectionAction processes the index action with connection arguments in the
+// context, so collection views of the action render connections.
+type connectionAction struct {
+	actioncontroller.Action
+}
+
+func (a connectionAction) Process(ctx *actioncontroller.Context) actioncontroller.Result {
+	var args actionview.ConnectionArgs
+	if first, ok := ctx.Params["first"].(int64); ok {
+		args.First = int(first)
+	}
+	if after, ok := ctx.Params["after"].(string); ok {
+		args.After = after
+	}
+
+	actionCtx := *ctx
+	actionCtx.Context = actionview.WithConnection(ctx.Context, args)
+	return a.Action.Process(&actionCtx)
+}
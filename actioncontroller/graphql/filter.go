@@ -0,0 +1,123 @@
+package graphql
+
+import (
+	"strings"
+
+	graphql "github.com/vektah/gqlparser/v2/ast"
+
+	"github.com/activegraph/activegraph/actioncontroller"
+	"github.com/activegraph/activegraph/actionview"
+	"github.com/activegraph/activegraph/activerecord"
+	"github.com/activegraph/activegraph/activesupport"
+)
+
+// AddScalarFilter registers the input type of operators filtering values of the
+// scalar type and returns the name of the input type.
+func (s *Schema) AddScalarFilter(scalar string) string {
+	filterName := scalar + "Filter"
+	if _, ok := s.root.Types[filterName]; ok {
+		return filterName
+	}
+
+	fields := graphql.FieldList{
+		{Name: "eq", Type: graphql.NamedType(scalar, nil)},
+		{Name: "in", Type: graphql.ListType(graphql.NonNullNamedType(scalar, nil), nil)},
+	}
+	// Booleans are not ordered, so they are compared for equality only.
+	if scalar != Boolean.Name {
+		fields = append(fields,
+			&graphql.FieldDefinition{Name: "gt", Type: graphql.NamedType(scalar, nil)},
+			&graphql.FieldDefinition{Name: "lt", Type: graphql.NamedType(scalar, nil)},
+		)
+	}
+
+	s.root.Types[filterName] = &graphql.Definition{
+		Kind:       graphql.InputObject,
+		Name:       filterName,
+		Fields:     fields,
+		Interfaces: make([]string, 0),
+	}
+	return filterName
+}
+
+// AddFilterArgs registers the filter input type and the order enum of the model
+// and returns "filter" and "orderBy" arguments of list operations.
+func (s *Schema) AddFilterArgs(model *activerecord.Relation) graphql.ArgumentDefinitionList {
+	var (
+		modelName   = CanonicalModelName(model.Name())
+		filterName  = modelName + "Filter"
+		orderByName = modelName + "OrderBy"
+	)
+
+	if _, ok := s.root.Types[filterName]; !ok {
+		attrs := model.AttributesForInspect()
+		fields := make(graphql.FieldList, 0, len(attrs))
+		values := make(graphql.EnumValueList, 0, 2*len(attrs))
+
+		for _, attr := range attrs {
+			scalar := scalarconv(attr.AttributeType()).Name()
+			fields = append(fields, &graphql.FieldDefinition{
+				Name: attr.AttributeName(),
+				Type: graphql.NamedType(s.AddScalarFilter(scalar), nil),
+			})
+
+			enumName := strings.ToUpper(attr.AttributeName())
+			values = append(values,
+				&graphql.EnumValueDefinition{Name: enumName + "_ASC"},
+				&graphql.EnumValueDefinition{Name: enumName + "_DESC"},
+			)
+		}
+
+		s.root.Types[filterName] = &graphql.Definition{
+			Kind:       graphql.InputObject,
+			Name:       filterName,
+			Fields:     fields,
+			Interfaces: make([]string, 0),
+		}
+		s.root.Types[orderByName] = &graphql.Definition{
+			Kind:       graphql.Enum,
+			Name:       orderByName,
+			EnumValues: values,
+			Interfaces: make([]string, 0),
+		}
+	}
+
+	return graphql.ArgumentDefinitionList{
+		{Name: "filter", Type: graphql.NamedType(filterName, nil)},
+		{Name: "orderBy", Type: graphql.ListType(graphql.NonNullNamedType(orderByName, nil), nil)},
+	}
+}
+
+// orderconv converts the value of the order enum, e.g. "PUBLISHED_AT_DESC" into
+// the order of the relation "published_at DESC".
+func orderconv(value string) string {
+	i := strings.LastIndex(value, "_")
+	if i < 0 {
+		return strings.ToLower(value)
+	}
+	return strings.ToLower(value[:i]) + " " + value[i+1:]
+}
+
+// filterAction processes the index action with filter arguments in the context,
+// so collection views of the action filter and order collections.
+type filterAction struct {
+	actioncontroller.Action
+}
+
+func (a filterAction) Process(ctx *actioncontroller.Context) actioncontroller.Result {
+	var args actionview.FilterArgs
+	if filter, ok := ctx.Params["filter"].(activesupport.Hash); ok {
+		args.Filter = filter
+	}
+	if orderBy, ok := ctx.Params["orderBy"].([]interface{}); ok {
+		for _, value := range orderBy {
+			if value, ok := value.(string); ok {
+				args.OrderBy = append(args.OrderBy, orderconv(value))
+			}
+		}
+	}
+
+	actionCtx := *ctx
+	actionCtx.Context = actionview.WithFilter(ctx.Context, args)
+	return a.Action.Process(&actionCtx)
+}
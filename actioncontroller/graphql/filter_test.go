@@ -0,0 +1,80 @@
+package graphql_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/activegraph/activegraph/activerecord"
+	_ "github.com/activegraph/activegraph/activerecord/sqlite3"
+	. "github.com/activegraph/activegraph/activesupport"
+)
+
+// titlesOf returns titles of books from the response of the query.
+func titlesOf(resp map[string]interface{}, field string) []interface{} {
+	var titles []interface{}
+	for _, book := range resp["data"].(map[string]interface{})[field].([]interface{}) {
+		titles = append(titles, book.(map[string]interface{})["title"])
+	}
+	return titles
+}
+
+func TestMapper_Filter(t *testing.T) {
+	_, err := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter: "sqlite3", Database: t.Name() + ".db",
+	})
+	require.NoError(t, err)
+
+	defer os.Remove(t.Name() + ".db")
+	defer activerecord.RemoveConnection("primary")
+
+	initBookTable(t)
+
+	Book := activerecord.New("book")
+	for _, book := range []Hash{
+		{"title": "Solaris", "year": 1961},
+		{"title": "Eden", "year": 1959},
+		{"title": "Ubik", "year": 1969},
+		{"title": "Neuromancer", "year": 1984},
+	} {
+		Book.Create(book).Unwrap()
+	}
+	handler := newBooksHandler(t, Book)
+
+	resp := serveQuery(t, handler, `{
+		books(filter: {year: {gt: 1959, lt: 1984}}, orderBy: [YEAR_DESC]) { title }
+	}`)
+	require.Equal(t, []interface{}{"Ubik", "Solaris"}, titlesOf(resp, "books"))
+
+	resp = serveQuery(t, handler, `{
+		books(filter: {title: {in: ["Eden", "Ubik"]}}, orderBy: [TITLE_ASC]) { title }
+	}`)
+	require.Equal(t, []interface{}{"Eden", "Ubik"}, titlesOf(resp, "books"))
+
+	resp = serveQuery(t, handler, `{ books(filter: {title: {eq: "Eden"}}) { title } }`)
+	require.Equal(t, []interface{}{"Eden"}, titlesOf(resp, "books"))
+
+	// Values are bound as arguments of the query.
+	resp = serveQuery(t, handler, `{
+		books(filter: {title: {eq: "Eden'; DROP TABLE books; --"}}) { title }
+	}`)
+	require.Empty(t, titlesOf(resp, "books"))
+
+	count, err := Book.Count()
+	require.NoError(t, err)
+	require.EqualValues(t, 4, count)
+
+	// Unknown attributes, operators and directions are returned as errors.
+	for query, message := range map[string]string{
+		`{ books(filter: {author: {eq: "Lem"}}) { title } }`: `unknown field "author" of BookFilter`,
+		`{ books(filter: {title: {like: "E%"}}) { title } }`: `unknown field "like" of StringFilter`,
+		`{ books(orderBy: [YEAR_SIDEWAYS]) { title } }`:      `invalid order direction "SIDEWAYS"`,
+		`{ books(orderBy: [RANDOM]) { title } }`:             `unknown attribute "random"`,
+	} {
+		resp = serveQuery(t, handler, query)
+		require.Nil(t, resp["data"], query)
+		require.Len(t, resp["errors"], 1, query)
+		require.Contains(t, resp["errors"].([]interface{})[0].(map[string]interface{})["message"], message)
+	}
+}
@@ -3,12 +3,13 @@ package graphql
 import (
 	"fmt"
 	"net/http"
-	"strings"
 
 	graphql "github.com/vektah/gqlparser/v2/ast"
 
 	"github.com/activegraph/activegraph/actioncontroller"
+	"github.com/activegraph/activegraph/actionview"
 	"github.com/activegraph/activegraph/activerecord"
+	"github.com/activegraph/activegraph/activesupport"
 )
 
 type ErrConstraintNotFound struct {
@@ -39,7 +40,7 @@ func scalarconv(t activerecord.Type) *graphql.Type {
 }
 
 func CanonicalModelName(modelName string) string {
-	return strings.Title(modelName)
+	return activesupport.Camelize(modelName)
 }
 
 type Schema struct {
@@ -48,7 +49,8 @@ type Schema struct {
 
 func (s *Schema) AddIndexOp(model *activerecord.Relation) *graphql.FieldDefinition {
 	def := &graphql.FieldDefinition{
-		Name: model.Name() + "s",
+		Name:      activesupport.Pluralize(model.Name()),
+		Arguments: s.AddFilterArgs(model),
 		Type: &graphql.Type{
 			Elem: &graphql.Type{
 				NonNull: true,
@@ -103,15 +105,16 @@ func (s *Schema) AddCreateOp(
 		Interfaces: make([]string, 0),
 	}
 
+	name := "create" + CanonicalModelName(model.Name())
 	def := &graphql.FieldDefinition{
-		Name: "create" + CanonicalModelName(model.Name()),
+		Name: name,
 		Arguments: graphql.ArgumentDefinitionList{
 			{
 				Name: model.Name(),
 				Type: &graphql.Type{NonNull: true, Elem: graphql.NamedType(inputName, nil)},
 			},
 		},
-		Type: graphql.NamedType(CanonicalModelName(model.Name()), nil),
+		Type: s.AddPayload(model, name),
 	}
 
 	s.root.Mutation.Fields = append(s.root.Mutation.Fields, def)
@@ -119,15 +122,16 @@ func (s *Schema) AddCreateOp(
 }
 
 func (s *Schema) AddDestroyOp(model *activerecord.Relation) *graphql.FieldDefinition {
+	name := "delete" + CanonicalModelName(model.Name())
 	def := &graphql.FieldDefinition{
-		Name: "delete" + CanonicalModelName(model.Name()),
+		Name: name,
 		Arguments: graphql.ArgumentDefinitionList{
 			{
 				Name: model.PrimaryKey(),
 				Type: scalarconv(model.AttributeForInspect(model.PrimaryKey()).AttributeType()),
 			},
 		},
-		Type: graphql.NamedType(CanonicalModelName(model.Name()), nil),
+		Type: s.AddPayload(model, name),
 	}
 
 	s.root.Mutation.Fields = append(s.root.Mutation.Fields, def)
@@ -174,8 +178,7 @@ func (s *Schema) AddModel(model *activerecord.Relation) *graphql.Definition {
 				assocName = assoc.AssociationName()
 				assocType = graphql.NamedType(CanonicalModelName(assoc.Relation.Name()), nil)
 			case activerecord.CollectionAssociation:
-				// TODO: take name from the reflection?
-				assocName = assoc.AssociationName() + "s"
+				assocName = activesupport.Pluralize(assoc.AssociationName())
 				assocType = &graphql.Type{
 					Elem: &graphql.Type{
 						NonNull: true,
@@ -268,6 +271,8 @@ func (m *Mapper) Map() (http.Handler, error) {
 	schema.Types["Boolean"] = Boolean
 	schema.Types["String"] = String
 	schema.Types["DateTime"] = DateTime
+	schema.Types["FieldError"] = FieldError
+	schema.Types["PageInfo"] = PageInfo
 
 	rootSchema := Schema{schema}
 	routing := NewRoutingTable()
@@ -283,13 +288,18 @@ func (m *Mapper) Map() (http.Handler, error) {
 				routing.AddOperation(op.Name, action)
 			case actioncontroller.ActionIndex:
 				op := rootSchema.AddIndexOp(model)
-				routing.AddOperation(op.Name, action)
+				routing.AddOperation(op.Name, filterAction{action})
+				op = rootSchema.AddConnectionOp(model)
+				routing.AddOperation(op.Name, connectionAction{filterAction{action}})
 			case actioncontroller.ActionCreate:
 				op := rootSchema.AddCreateOp(model, action)
-				routing.AddOperation(op.Name, action)
+				routing.AddOperation(op.Name, payloadAction{action, model.Name()})
+			case actioncontroller.ActionUpdate:
+				op := rootSchema.AddUpdateOp(model, action)
+				routing.AddOperation(op.Name, payloadAction{action, model.Name()})
 			case actioncontroller.ActionDestroy:
 				op := rootSchema.AddDestroyOp(model)
-				routing.AddOperation(op.Name, action)
+				routing.AddOperation(op.Name, payloadAction{action, model.Name()})
 			default:
 				fmt.Printf("action %q is not supported\n", action.ActionName())
 			}
@@ -302,6 +312,11 @@ func (m *Mapper) Map() (http.Handler, error) {
 			return
 		}
 
+		// Associations of all fields are loaded in batches by the loader
+		// of the request, instead of a query per record.
+		loader := actionview.NewAssociationLoader()
+		r = r.WithContext(actionview.WithAssociationLoader(r.Context(), loader))
+
 		for _, op := range r.query.Operations {
 			for _, selection := range op.SelectionSet {
 				field := selection.(*graphql.Field)
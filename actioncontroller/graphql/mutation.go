@@ -0,0 +1,180 @@
+package graphql
+
+import (
+	"errors"
+
+	graphql "github.com/vektah/gqlparser/v2/ast"
+
+	"github.com/activegraph/activegraph/actioncontroller"
+	"github.com/activegraph/activegraph/activerecord"
+	"github.com/activegraph/activegraph/activesupport"
+)
+
+// FieldError is an object type of validation errors of mutations, the field is
+// the name of the invalid attribute.
+var FieldError = &graphql.Definition{
+	Kind:        graphql.Object,
+	Name:        "FieldError",
+	Description: "A validation error of the field.",
+	Fields: graphql.FieldList{
+		{
+			Name: "field",
+			Type: &graphql.Type{NonNull: true, Elem: graphql.NamedType(String.Name, nil)},
+		},
+		{
+			Name: "message",
+			Type: &graphql.Type{NonNull: true, Elem: graphql.NamedType(String.Name, nil)},
+		},
+	},
+	Interfaces: make([]string, 0),
+}
+
+// AddPayload registers the payload type of the mutation, which contains the
+// mutated record and validation errors of the record.
+func (s *Schema) AddPayload(model *activerecord.Relation, opName string) *graphql.Type {
+	payloadName := CanonicalModelName(opName) + "Payload"
+
+	s.root.Types[payloadName] = &graphql.Definition{
+		Kind: graphql.Object,
+		Name: payloadName,
+		Fields: graphql.FieldList{
+			{
+				Name: model.Name(),
+				Type: graphql.NamedType(CanonicalModelName(model.Name()), nil),
+			},
+			{
+				Name: "errors",
+				Type: &graphql.Type{
+					NonNull: true,
+					Elem: &graphql.Type{
+						NonNull: true,
+						Elem:    graphql.NamedType(FieldError.Name, nil),
+					},
+				},
+			},
+		},
+		Interfaces: make([]string, 0),
+	}
+	return graphql.NamedType(payloadName, nil)
+}
+
+func (s *Schema) AddUpdateOp(
+	model *activerecord.Relation, action actioncontroller.Action,
+) *graphql.FieldDefinition {
+	var inputs []activerecord.Attribute
+	if constraints := action.ActionConstraints(); constraints.Request != nil {
+		inputs = constraints.Request.Attributes
+	}
+
+	inputName := "Update" + CanonicalModelName(model.Name()) + "Input"
+	inputFields := make(graphql.FieldList, 0, len(inputs))
+
+	for _, input := range inputs {
+		// Attributes are optional, only given attributes are updated.
+		inputType := scalarconv(input.AttributeType())
+		if inputType.NonNull {
+			inputType = inputType.Elem
+		}
+		inputFields = append(inputFields, &graphql.FieldDefinition{
+			Name: input.AttributeName(),
+			Type: inputType,
+		})
+	}
+
+	s.root.Types[inputName] = &graphql.Definition{
+		Kind:       graphql.InputObject,
+		Name:       inputName,
+		Fields:     inputFields,
+		Interfaces: make([]string, 0),
+	}
+
+	name := "update" + CanonicalModelName(model.Name())
+	def := &graphql.FieldDefinition{
+		Name: name,
+		Arguments: graphql.ArgumentDefinitionList{
+			{
+				Name: model.PrimaryKey(),
+				Type: scalarconv(model.AttributeForInspect(model.PrimaryKey()).AttributeType()),
+			},
+			{
+				Name: model.Name(),
+				Type: &graphql.Type{NonNull: true, Elem: graphql.NamedType(inputName, nil)},
+			},
+		},
+		Type: s.AddPayload(model, name),
+	}
+
+	s.root.Mutation.Fields = append(s.root.Mutation.Fields, def)
+	return def
+}
+
+// fieldErrors returns validation errors as hashes of FieldError type.
+func fieldErrors(errs activerecord.Errors) []activesupport.Hash {
+	var hashes []activesupport.Hash
+	for _, key := range errs.Keys() {
+		for _, err := range errs.Get(key) {
+			message := err.Error()
+
+			var invalid activerecord.ErrInvalidValue
+			if errors.As(err, &invalid) && invalid.Message != "" {
+				message = invalid.Message
+			}
+			hashes = append(hashes, activesupport.Hash{"field": key, "message": message})
+		}
+	}
+	return hashes
+}
+
+// payloadAction wraps the result of the mutation action into the payload. The
+// action is processed with the selection of the record within the payload, and
+// validation errors of the record are returned within the payload instead of
+// errors of the request.
+type payloadAction struct {
+	actioncontroller.Action
+	name string
+}
+
+func (a payloadAction) Process(ctx *actioncontroller.Context) actioncontroller.Result {
+	payloadSelection := ctx.Selection
+
+	var selection []actioncontroller.QueryAttribute
+	for _, attr := range payloadSelection {
+		if attr.AttributeName == a.name {
+			selection = attr.NestedAttributes
+		}
+	}
+
+	actionCtx := *ctx
+	actionCtx.Selection = selection
+	result := a.Action.Process(&actionCtx)
+
+	return payloadResult{result: result, name: a.name, ctx: &actionCtx}
+}
+
+type payloadResult struct {
+	result actioncontroller.Result
+	name   string
+	ctx    *actioncontroller.Context
+}
+
+func (r payloadResult) Execute(*actioncontroller.Context) (interface{}, error) {
+	payload := activesupport.Hash{r.name: nil, "errors": []activesupport.Hash{}}
+	if r.result == nil {
+		return payload, nil
+	}
+
+	data, err := r.result.Execute(r.ctx)
+
+	var invalid activerecord.ErrValidation
+	switch {
+	case errors.As(err, &invalid):
+		if errs := fieldErrors(invalid.Errors); errs != nil {
+			payload["errors"] = errs
+		}
+	case err != nil:
+		return nil, err
+	default:
+		payload[r.name] = data
+	}
+	return payload, nil
+}
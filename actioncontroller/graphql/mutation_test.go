@@ -0,0 +1,189 @@
+package graphql_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/activegraph/activegraph/actioncontroller"
+	"github.com/activegraph/activegraph/actioncontroller/graphql"
+	"github.com/activegraph/activegraph/actionview"
+	"github.com/activegraph/activegraph/activerecord"
+	_ "github.com/activegraph/activegraph/activerecord/sqlite3"
+	. "github.com/activegraph/activegraph/activesupport"
+)
+
+func initBookTable(t *testing.T) {
+	activerecord.Migrate(t.Name()+"_add_books_table", func(m *activerecord.M) {
+		m.CreateTable("books", func(t *activerecord.Table) {
+			t.String("title")
+			t.Int64("year")
+		})
+	})
+}
+
+// newBooksHandler returns the GraphQL handler of books with canonical actions.
+func newBooksHandler(t *testing.T, Book *activerecord.Relation) http.Handler {
+	permitted := []activerecord.Attribute{
+		Book.AttributeForInspect("title"),
+		Book.AttributeForInspect("year"),
+	}
+
+	BooksController := actioncontroller.New(func(c *actioncontroller.C) {
+		c.Permit(permitted, actioncontroller.ActionCreate, actioncontroller.ActionUpdate)
+
+		c.Index(func(ctx *actioncontroller.Context) actioncontroller.Result {
+			return actionview.NestedCollectionView(ctx, Book.All())
+		})
+		c.Create(func(ctx *actioncontroller.Context) actioncontroller.Result {
+			return actionview.NestedView(ctx, Book.Create(ctx.Params.Get("book")))
+		})
+		c.Update(func(ctx *actioncontroller.Context) actioncontroller.Result {
+			book := Book.Find(ctx.Params["id"])
+			if book.IsErr() {
+				return actionview.Error(book.Err())
+			}
+
+			rec := book.Unwrap()
+			if err := rec.AssignAttributes(ctx.Params.Get("book")); err != nil {
+				return actionview.Error(err)
+			}
+			return actionview.NestedView(ctx, activerecord.ReturnRecord(rec.Update()))
+		})
+	})
+
+	mapper := new(graphql.Mapper)
+	mapper.Resources(Book, BooksController)
+
+	handler, err := mapper.Map()
+	require.NoError(t, err)
+	return handler
+}
+
+// serveQuery posts the GraphQL query to the handler and returns the decoded
+// response.
+func serveQuery(t *testing.T, handler http.Handler, query string) map[string]interface{} {
+	body, err := json.Marshal(Hash{"query": query})
+	require.NoError(t, err)
+
+	r := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(string(body)))
+	r.Header.Set("Content-Type", "application/json")
+
+	rw := httptest.NewRecorder()
+	handler.ServeHTTP(rw, r)
+	require.Equal(t, http.StatusOK, rw.Code, rw.Body.String())
+
+	var resp map[string]interface{}
+	require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &resp))
+	return resp
+}
+
+// inputFieldKinds returns kinds of fields of the input type from the response of
+// the introspection query.
+func inputFieldKinds(resp map[string]interface{}, typeName string) map[string]string {
+	var (
+		schema = resp["data"].(map[string]interface{})["__schema"].(map[string]interface{})
+		kinds  = make(map[string]string)
+	)
+	for _, def := range schema["types"].([]interface{}) {
+		def := def.(map[string]interface{})
+		if def["name"] != typeName {
+			continue
+		}
+		for _, field := range def["inputFields"].([]interface{}) {
+			field := field.(map[string]interface{})
+			kinds[field["name"].(string)] = field["type"].(map[string]interface{})["kind"].(string)
+		}
+	}
+	return kinds
+}
+
+func TestMapper_Mutations(t *testing.T) {
+	_, err := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter: "sqlite3", Database: t.Name() + ".db",
+	})
+	require.NoError(t, err)
+
+	defer os.Remove(t.Name() + ".db")
+	defer activerecord.RemoveConnection("primary")
+
+	initBookTable(t)
+
+	Book := activerecord.New("book", func(r *activerecord.R) {
+		r.DefineAttribute("title", new(activerecord.String), new(activerecord.Presence))
+	})
+	handler := newBooksHandler(t, Book)
+
+	resp := serveQuery(t, handler, `mutation {
+		createBook(book: {title: "Solaris", year: 1961}) {
+			book { id title year }
+			errors { field message }
+		}
+	}`)
+	require.Equal(t, map[string]interface{}{
+		"data": map[string]interface{}{
+			"createBook": map[string]interface{}{
+				"book":   map[string]interface{}{"id": 1.0, "title": "Solaris", "year": 1961.0},
+				"errors": []interface{}{},
+			},
+		},
+	}, resp)
+
+	// Validation errors are returned within the payload without the record.
+	resp = serveQuery(t, handler, `mutation {
+		createBook(book: {title: "", year: 1964}) {
+			book { id }
+			errors { field message }
+		}
+	}`)
+	require.Equal(t, map[string]interface{}{
+		"data": map[string]interface{}{
+			"createBook": map[string]interface{}{
+				"book": nil,
+				"errors": []interface{}{
+					map[string]interface{}{"field": "title", "message": "can't be blank"},
+				},
+			},
+		},
+	}, resp)
+	count, err := Book.Count()
+	require.NoError(t, err)
+	require.EqualValues(t, 1, count)
+
+	// Attributes of the create input are required, attributes of the update input
+	// are optional, omitted attributes are kept.
+	resp = serveQuery(t, handler, `query IntrospectionQuery { __schema { types { name } } }`)
+	require.Equal(t, "NON_NULL", inputFieldKinds(resp, "CreateBookInput")["title"])
+	require.Equal(t, "SCALAR", inputFieldKinds(resp, "UpdateBookInput")["title"])
+
+	resp = serveQuery(t, handler, `mutation {
+		updateBook(id: 1, book: {year: 1962}) {
+			book { id title year }
+			errors { field message }
+		}
+	}`)
+	require.Equal(t, map[string]interface{}{
+		"data": map[string]interface{}{
+			"updateBook": map[string]interface{}{
+				"book":   map[string]interface{}{"id": 1.0, "title": "Solaris", "year": 1962.0},
+				"errors": []interface{}{},
+			},
+		},
+	}, resp)
+
+	// Other errors are returned as errors of the GraphQL response.
+	resp = serveQuery(t, handler, `mutation {
+		updateBook(id: 42, book: {title: "Eden"}) {
+			book { id }
+			errors { field message }
+		}
+	}`)
+	require.Nil(t, resp["data"])
+	require.Len(t, resp["errors"], 1)
+	require.Contains(t, resp["errors"].([]interface{})[0].(map[string]interface{})["message"], "not found by id = 42")
+}
@@ -31,6 +31,17 @@ func (e ErrUnsupportedType) Error() string {
 	return "graphql: unsupported type " + e.Type.String()
 }
 
+// ErrUnknownField is returned by Decoder when attempting to decode a field of
+// the input object, which is not defined by the input type.
+type ErrUnknownField struct {
+	*graphql.Type
+	Name string
+}
+
+func (e ErrUnknownField) Error() string {
+	return fmt.Sprintf("graphql: unknown field %q of %s", e.Name, e.Type.Name())
+}
+
 type Unmarshaler interface {
 	Unmarshal(raw string) (interface{}, error)
 }
@@ -85,6 +96,10 @@ func (d *Decoder) Decode(v *graphql.Value) (interface{}, error) {
 	case graphql.ObjectValue:
 		object := activesupport.Hash{}
 		for _, child := range v.Children {
+			// Fields missing in the input type are left untyped by the validation.
+			if child.Value.ExpectedType == nil {
+				return object, ErrUnknownField{Type: v.ExpectedType, Name: child.Name}
+			}
 			element, err := d.Decode(child.Value)
 			if err != nil {
 				return object, err
@@ -92,6 +107,8 @@ func (d *Decoder) Decode(v *graphql.Value) (interface{}, error) {
 			object[child.Name] = element
 		}
 		return object, nil
+	case graphql.EnumValue:
+		return v.Raw, nil
 	}
 
 	decoder, ok := d.types[v.ExpectedType.Name()]
@@ -0,0 +1,313 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/activegraph/activegraph/activerecord"
+	"github.com/activegraph/activegraph/activesupport"
+)
+
+// OpenAPIVersion is the version of the OpenAPI specification of documents.
+const OpenAPIVersion = "3.0.3"
+
+// schemaName returns the name of the model schema, e.g. "Book".
+func schemaName(rel *activerecord.Relation) string {
+	return activesupport.Camelize(rel.Name())
+}
+
+func schemaRef(name string) activesupport.Hash {
+	return activesupport.Hash{"$ref": "#/components/schemas/" + name}
+}
+
+// typeSchema returns the schema of values of the attribute type.
+func typeSchema(t activerecord.Type) activesupport.Hash {
+	var schema activesupport.Hash
+	nullable := false
+	if n, ok := t.(activerecord.Nil); ok {
+		t, nullable = n.Type, true
+	}
+
+	switch t.(type) {
+	case *activerecord.Int64:
+		schema = activesupport.Hash{"type": "integer", "format": "int64"}
+	case *activerecord.Float64:
+		schema = activesupport.Hash{"type": "number", "format": "double"}
+	case *activerecord.Boolean:
+		schema = activesupport.Hash{"type": "boolean"}
+	case *activerecord.DateTime:
+		schema = activesupport.Hash{"type": "string", "format": "date-time"}
+	case *activerecord.Date:
+		schema = activesupport.Hash{"type": "string", "format": "date"}
+	case *activerecord.Time:
+		schema = activesupport.Hash{"type": "string", "format": "time"}
+	case *activerecord.JSON:
+		// JSON attributes accept any values.
+		schema = activesupport.Hash{}
+	default:
+		schema = activesupport.Hash{"type": "string"}
+	}
+	if nullable {
+		schema["nullable"] = true
+	}
+	return schema
+}
+
+// attributeSchema returns the schema of the attribute with constraints of its
+// validators, and true, when the attribute is required by validators.
+func attributeSchema(rel *activerecord.Relation, attr activerecord.Attribute) (activesupport.Hash, bool) {
+	schema := typeSchema(attr.AttributeType())
+	required := false
+
+	for _, validator := range rel.ValidatorsOn(attr.AttributeName()) {
+		switch validator := validator.(type) {
+		case *activerecord.Presence:
+			required = required || !validator.AllowNil
+		case *activerecord.Length:
+			if validator.Minimum > 0 {
+				schema["minLength"] = validator.Minimum
+			}
+			if validator.Maximum > 0 {
+				schema["maxLength"] = validator.Maximum
+			}
+		case *activerecord.Format:
+			if !validator.With.IsEmpty() {
+				schema["pattern"] = string(validator.With)
+			} else {
+				schema["not"] = activesupport.Hash{"pattern": string(validator.Without)}
+			}
+		case *activerecord.Inclusion:
+			if values, ok := validator.In.(activesupport.StringSlice); ok {
+				schema["enum"] = []string(values)
+			}
+		case *activerecord.Exclusion:
+			if values, ok := validator.From.(activesupport.StringSlice); ok {
+				schema["not"] = activesupport.Hash{"enum": []string(values)}
+			}
+		}
+	}
+	// Present attributes are never null.
+	if required {
+		delete(schema, "nullable")
+	}
+	return schema, required
+}
+
+// modelSchemas returns schemas of records of the resource, of JSON objects of
+// permitted attributes accepted by create and of partial updates.
+func (res *Resource) modelSchemas() (model, input, updateInput activesupport.Hash) {
+	var (
+		properties      = make(activesupport.Hash)
+		inputProperties = make(activesupport.Hash)
+		required        []string
+		inputRequired   []string
+	)
+
+	permitted := make(map[string]struct{}, len(res.permitted))
+	for _, attrName := range res.permitted {
+		permitted[attrName] = struct{}{}
+	}
+
+	for _, attr := range res.rel.AttributesForInspect() {
+		schema, isRequired := attributeSchema(res.rel, attr)
+		properties[attr.AttributeName()] = schema
+		if isRequired {
+			required = append(required, attr.AttributeName())
+		}
+
+		if _, ok := permitted[attr.AttributeName()]; ok {
+			inputProperties[attr.AttributeName()] = schema
+			if isRequired {
+				inputRequired = append(inputRequired, attr.AttributeName())
+			}
+		}
+	}
+
+	model = activesupport.Hash{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		model["required"] = required
+	}
+	input = activesupport.Hash{
+		"type": "object", "properties": inputProperties, "additionalProperties": false,
+	}
+	updateInput = activesupport.Hash{
+		"type": "object", "properties": inputProperties, "additionalProperties": false,
+	}
+	if len(inputRequired) > 0 {
+		input["required"] = inputRequired
+	}
+	return model, input, updateInput
+}
+
+func jsonContent(schema activesupport.Hash) activesupport.Hash {
+	return activesupport.Hash{"application/json": activesupport.Hash{"schema": schema}}
+}
+
+func response(description string, schema activesupport.Hash) activesupport.Hash {
+	h := activesupport.Hash{"description": description}
+	if schema != nil {
+		h["content"] = jsonContent(schema)
+	}
+	return h
+}
+
+// paths returns path items of operations of the resource.
+func (res *Resource) paths() activesupport.Hash {
+	var (
+		name      = schemaName(res.rel)
+		modelRef  = schemaRef(name)
+		inputRef  = schemaRef(name + "Input")
+		errorRef  = schemaRef("Error")
+		invalidOp = response("Validation errors of attributes", schemaRef("ValidationErrors"))
+		notFound  = response("Record not found", errorRef)
+		badReq    = response("Invalid request", errorRef)
+	)
+
+	pk := res.rel.AttributeForInspect(res.rel.PrimaryKey())
+	idParam := activesupport.Hash{
+		"name": "id", "in": "path", "required": true,
+		"schema": typeSchema(pk.AttributeType()),
+	}
+
+	indexParams := []activesupport.Hash{
+		{
+			"name": "limit", "in": "query",
+			"description": "Number of records of the page.",
+			"schema":      activesupport.Hash{"type": "integer", "minimum": 1, "default": res.perPage},
+		},
+		{
+			"name": "after", "in": "query",
+			"description": "Cursor of the page, given in the \"next\" link of the previous page.",
+			"schema":      activesupport.Hash{"type": "string"},
+		},
+		{
+			"name": "order", "in": "query",
+			"description": "Comma-separated attributes followed by the direction ASC or DESC.",
+			"schema":      activesupport.Hash{"type": "string"},
+		},
+	}
+	for _, attr := range res.rel.AttributesForInspect() {
+		indexParams = append(indexParams, activesupport.Hash{
+			"name": attr.AttributeName(), "in": "query",
+			"description": "Filters equal values, operators are given in brackets: " +
+				attr.AttributeName() + "[in], " + attr.AttributeName() + "[gt], " +
+				attr.AttributeName() + "[lt].",
+			"schema": typeSchema(attr.AttributeType()),
+		})
+	}
+
+	collection := activesupport.Hash{
+		"get": activesupport.Hash{
+			"operationId": "list" + activesupport.Pluralize(name),
+			"parameters":  indexParams,
+			"responses": activesupport.Hash{
+				"200": response("Page of records", activesupport.Hash{"type": "array", "items": modelRef}),
+				"400": badReq,
+			},
+		},
+		"post": activesupport.Hash{
+			"operationId": "create" + name,
+			"requestBody": activesupport.Hash{"required": true, "content": jsonContent(inputRef)},
+			"responses": activesupport.Hash{
+				"201": response("Created record", modelRef),
+				"400": badReq,
+				"422": invalidOp,
+			},
+		},
+	}
+
+	update := func(operationID string, ref activesupport.Hash) activesupport.Hash {
+		return activesupport.Hash{
+			"operationId": operationID,
+			"requestBody": activesupport.Hash{"required": true, "content": jsonContent(ref)},
+			"responses": activesupport.Hash{
+				"200": response("Updated record", modelRef),
+				"400": badReq,
+				"404": notFound,
+				"422": invalidOp,
+			},
+		}
+	}
+	member := activesupport.Hash{
+		"parameters": []activesupport.Hash{idParam},
+		"get": activesupport.Hash{
+			"operationId": "show" + name,
+			"responses": activesupport.Hash{
+				"200": response("Record", modelRef),
+				"404": notFound,
+			},
+		},
+		"patch": update("update"+name, schemaRef(name+"UpdateInput")),
+		"put":   update("replace"+name, inputRef),
+		"delete": activesupport.Hash{
+			"operationId": "delete" + name,
+			"responses": activesupport.Hash{
+				"204": response("Deleted record", nil),
+				"404": notFound,
+			},
+		},
+	}
+
+	prefix := res.prefix
+	if prefix == "" {
+		prefix = "/" + res.rel.TableName()
+	}
+	return activesupport.Hash{prefix: collection, prefix + "/{id}": member}
+}
+
+// OpenAPI returns the OpenAPI 3 document of resources: paths of operations,
+// schemas of records with constraints of validators, and schemas of errors.
+func OpenAPI(title, version string, resources ...*Resource) activesupport.Hash {
+	var (
+		paths   = make(activesupport.Hash)
+		schemas = activesupport.Hash{
+			"Error": activesupport.Hash{
+				"type":       "object",
+				"properties": activesupport.Hash{"error": activesupport.Hash{"type": "string"}},
+				"required":   []string{"error"},
+			},
+			"ValidationErrors": activesupport.Hash{
+				"type": "object",
+				"properties": activesupport.Hash{
+					"errors": activesupport.Hash{
+						"type": "object",
+						"additionalProperties": activesupport.Hash{
+							"type": "array", "items": activesupport.Hash{"type": "string"},
+						},
+					},
+				},
+				"required": []string{"errors"},
+			},
+		}
+	)
+
+	for _, res := range resources {
+		for path, item := range res.paths() {
+			paths[path] = item
+		}
+		model, input, updateInput := res.modelSchemas()
+		schemas[schemaName(res.rel)] = model
+		schemas[schemaName(res.rel)+"Input"] = input
+		schemas[schemaName(res.rel)+"UpdateInput"] = updateInput
+	}
+
+	return activesupport.Hash{
+		"openapi":    OpenAPIVersion,
+		"info":       activesupport.Hash{"title": title, "version": version},
+		"paths":      paths,
+		"components": activesupport.Hash{"schemas": schemas},
+	}
+}
+
+// OpenAPIHandler returns the handler of the OpenAPI document of resources in
+// JSON format. The document is generated on each request, so it is always in
+// sync with definitions of relations.
+//
+//	books := rest.Mount(mux, Book)
+//	mux.Handle("/openapi.json", rest.OpenAPIHandler("Library", "1.0", books))
+func OpenAPIHandler(title, version string, resources ...*Resource) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(rw).Encode(OpenAPI(title, version, resources...))
+	})
+}
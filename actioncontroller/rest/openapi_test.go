@@ -0,0 +1,137 @@
+package rest_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/activegraph/activegraph/actioncontroller/rest"
+	"github.com/activegraph/activegraph/activerecord"
+	_ "github.com/activegraph/activegraph/activerecord/sqlite3"
+)
+
+// requireRefs asserts that all references of the document value point to
+// schemas of components of the document.
+func requireRefs(t *testing.T, schemas map[string]interface{}, value interface{}) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		if ref, ok := value["$ref"].(string); ok {
+			require.True(t, strings.HasPrefix(ref, "#/components/schemas/"), ref)
+			require.Contains(t, schemas, strings.TrimPrefix(ref, "#/components/schemas/"), ref)
+		}
+		for _, elem := range value {
+			requireRefs(t, schemas, elem)
+		}
+	case []interface{}:
+		for _, elem := range value {
+			requireRefs(t, schemas, elem)
+		}
+	}
+}
+
+func TestOpenAPIHandler(t *testing.T) {
+	_, err := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter: "sqlite3", Database: t.Name() + ".db",
+	})
+	require.NoError(t, err)
+
+	defer os.Remove(t.Name() + ".db")
+	defer activerecord.RemoveConnection("primary")
+
+	initBookTable(t)
+
+	Book := activerecord.New("book", func(r *activerecord.R) {
+		r.ValidatesPresence("title")
+		r.Validates("title", &activerecord.Length{Maximum: 100})
+		r.Validates("title", &activerecord.Format{With: `\A[A-Z]`})
+	})
+
+	mux := http.NewServeMux()
+	books := rest.Mount(mux, Book, rest.Permit("title", "year"))
+	mux.Handle("/openapi.json", rest.OpenAPIHandler("Library", "1.0", books))
+
+	rw := httptest.NewRecorder()
+	mux.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	require.Equal(t, http.StatusOK, rw.Code)
+	require.Equal(t, "application/json", rw.Header().Get("Content-Type"))
+
+	var doc map[string]interface{}
+	require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &doc))
+
+	require.Equal(t, rest.OpenAPIVersion, doc["openapi"])
+	require.Equal(t, map[string]interface{}{"title": "Library", "version": "1.0"}, doc["info"])
+
+	var (
+		paths   = doc["paths"].(map[string]interface{})
+		schemas = doc["components"].(map[string]interface{})["schemas"].(map[string]interface{})
+	)
+	requireRefs(t, schemas, doc)
+
+	// Paths list operations of the resource with unique identifiers and
+	// responses of all operations.
+	require.Len(t, paths, 2)
+	operations := map[string][]string{
+		"/books":      {"get", "post"},
+		"/books/{id}": {"get", "patch", "put", "delete"},
+	}
+	operationIDs := make(map[string]struct{})
+	for path, methods := range operations {
+		require.Contains(t, paths, path)
+		item := paths[path].(map[string]interface{})
+
+		for _, method := range methods {
+			op := item[method].(map[string]interface{})
+			require.NotEmpty(t, op["responses"], path+" "+method)
+
+			operationID := op["operationId"].(string)
+			require.NotContains(t, operationIDs, operationID)
+			operationIDs[operationID] = struct{}{}
+		}
+	}
+
+	// Attribute types and constraints of validators are the schema of records.
+	require.Equal(t, map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"id": map[string]interface{}{"type": "integer", "format": "int64"},
+			"title": map[string]interface{}{
+				"type": "string", "maxLength": 100.0, "pattern": `\A[A-Z]`,
+			},
+			"year": map[string]interface{}{"type": "integer", "format": "int64", "nullable": true},
+		},
+		"required": []interface{}{"title"},
+	}, schemas["Book"])
+
+	// Inputs accept permitted attributes only, all attributes of updates are
+	// optional.
+	input := schemas["BookInput"].(map[string]interface{})
+	require.Equal(t, false, input["additionalProperties"])
+	require.Equal(t, []interface{}{"title"}, input["required"])
+	require.Len(t, input["properties"], 2)
+	require.NotContains(t, input["properties"], "id")
+
+	updateInput := schemas["BookUpdateInput"].(map[string]interface{})
+	require.Equal(t, input["properties"], updateInput["properties"])
+	require.NotContains(t, updateInput, "required")
+
+	require.Equal(t, map[string]interface{}{
+		"type":       "object",
+		"properties": map[string]interface{}{"error": map[string]interface{}{"type": "string"}},
+		"required":   []interface{}{"error"},
+	}, schemas["Error"])
+
+	responses := paths["/books"].(map[string]interface{})["post"].(map[string]interface{})["responses"]
+	require.Equal(t, map[string]interface{}{
+		"description": "Validation errors of attributes",
+		"content": map[string]interface{}{
+			"application/json": map[string]interface{}{
+				"schema": map[string]interface{}{"$ref": "#/components/schemas/ValidationErrors"},
+			},
+		},
+	}, responses.(map[string]interface{})["422"])
+}
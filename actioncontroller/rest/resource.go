@@ -0,0 +1,395 @@
+// Package rest mounts CRUD HTTP handlers of relations, records are encoded with
+// the JSON serialization of activerecord.
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/activegraph/activegraph/actionview"
+	"github.com/activegraph/activegraph/activerecord"
+	"github.com/activegraph/activegraph/activesupport"
+)
+
+// DefaultPerPage is the default number of records returned by the index.
+const DefaultPerPage = 25
+
+// Resource is an HTTP handler of CRUD operations over records of the relation:
+//
+//	GET    /      index, records filtered, ordered and paginated by the query
+//	POST   /      create, the record of the JSON object
+//	GET    /:id   show
+//	PATCH  /:id   update, permitted attributes of the JSON object
+//	PUT    /:id   update
+//	DELETE /:id   delete
+//
+// Paths are relative to the prefix of the resource, see Mount.
+type Resource struct {
+	rel           *activerecord.Relation
+	prefix        string
+	permitted     activerecord.Permitted
+	serialization []activerecord.SerializationOption
+	perPage       int
+}
+
+// Option configures the resource.
+type Option func(*Resource)
+
+// Permit permits attributes assigned by create and update, by default all
+// attributes except the primary key and timestamps are permitted.
+func Permit(attrNames ...string) Option {
+	return func(res *Resource) {
+		res.permitted = activerecord.Permit(attrNames...)
+	}
+}
+
+// Serialize configures the JSON serialization of records in responses.
+func Serialize(opts ...activerecord.SerializationOption) Option {
+	return func(res *Resource) {
+		res.serialization = opts
+	}
+}
+
+// Prefix sets the path prefix of the resource, which is stripped from paths
+// of requests.
+func Prefix(prefix string) Option {
+	return func(res *Resource) {
+		res.prefix = prefix
+	}
+}
+
+// PerPage sets the default number of records returned by the index.
+func PerPage(n int) Option {
+	return func(res *Resource) {
+		res.perPage = n
+	}
+}
+
+// New returns a new resource of the relation.
+func New(rel *activerecord.Relation, opts ...Option) *Resource {
+	res := &Resource{rel: rel, perPage: DefaultPerPage}
+	for _, attrName := range rel.AttributeNames() {
+		switch attrName {
+		case rel.PrimaryKey(), "created_at", "updated_at":
+		default:
+			res.permitted = append(res.permitted, attrName)
+		}
+	}
+	for _, opt := range opts {
+		opt(res)
+	}
+	return res
+}
+
+// Mount mounts the resource of the relation on the path of the relation table,
+// e.g. "/books" and "/books/:id", and returns the mounted resource.
+//
+//	mux := http.NewServeMux()
+//	rest.Mount(mux, Book, rest.Permit("title", "year"))
+func Mount(mux *http.ServeMux, rel *activerecord.Relation, opts ...Option) *Resource {
+	prefix := "/" + rel.TableName()
+	res := New(rel, append([]Option{Prefix(prefix)}, opts...)...)
+
+	mux.Handle(prefix, res)
+	mux.Handle(prefix+"/", res)
+	return res
+}
+
+// ServeHTTP dispatches the request to the operation of the method and path.
+func (res *Resource) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	rel := res.rel.WithContext(r.Context())
+
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, res.prefix), "/")
+	if strings.Contains(id, "/") {
+		res.writeError(rw, http.StatusNotFound, fmt.Errorf("path %q not found", r.URL.Path))
+		return
+	}
+
+	switch {
+	case id == "" && r.Method == http.MethodGet:
+		res.index(rw, r, rel)
+	case id == "" && r.Method == http.MethodPost:
+		res.create(rw, r, rel)
+	case id != "" && r.Method == http.MethodGet:
+		res.show(rw, r, rel, id)
+	case id != "" && (r.Method == http.MethodPatch || r.Method == http.MethodPut):
+		res.update(rw, r, rel, id)
+	case id != "" && r.Method == http.MethodDelete:
+		res.destroy(rw, r, rel, id)
+	default:
+		res.writeError(rw, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+	}
+}
+
+// index writes records filtered and ordered by the query, records are paginated
+// with cursors: the cursor of the next page is returned in the "Link" header.
+//
+//	GET /books?year[gt]=2000&title[in]=Solaris,Eden&order=year+DESC&limit=10
+func (res *Resource) index(rw http.ResponseWriter, r *http.Request, rel *activerecord.Relation) {
+	query := r.URL.Query()
+
+	args, err := res.filterArgs(rel, query)
+	if err != nil {
+		res.writeError(rw, http.StatusBadRequest, err)
+		return
+	}
+	if rel, err = args.Apply(rel); err != nil {
+		res.writeError(rw, http.StatusBadRequest, err)
+		return
+	}
+
+	limit := res.perPage
+	if value := query.Get("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil || limit <= 0 {
+			res.writeError(rw, http.StatusBadRequest, fmt.Errorf("invalid limit %q", value))
+			return
+		}
+	}
+
+	// Retrieve an extra record to find out whether the next page exists.
+	records, err := rel.After(query.Get("after")).Limit(limit + 1).ToA()
+	if errors.Is(err, new(activerecord.ErrCursor)) {
+		res.writeError(rw, http.StatusBadRequest, err)
+		return
+	}
+	if err != nil {
+		res.writeError(rw, http.StatusInternalServerError, err)
+		return
+	}
+	if len(records) > limit {
+		records = records[:limit]
+
+		cursor, err := rel.Cursor(records[len(records)-1])
+		if err != nil {
+			res.writeError(rw, http.StatusInternalServerError, err)
+			return
+		}
+
+		next := *r.URL
+		nextQuery := next.Query()
+		nextQuery.Set("after", cursor)
+		nextQuery.Set("limit", strconv.Itoa(limit))
+		next.RawQuery = nextQuery.Encode()
+		rw.Header().Set("Link", fmt.Sprintf("<%s>; rel=\"next\"", next.RequestURI()))
+	}
+
+	hashes := make([]activesupport.Hash, 0, len(records))
+	for _, rec := range records {
+		h, err := rec.AsJSON(res.serialization...)
+		if err != nil {
+			res.writeError(rw, http.StatusInternalServerError, err)
+			return
+		}
+		hashes = append(hashes, h)
+	}
+	res.write(rw, http.StatusOK, hashes)
+}
+
+func (res *Resource) show(rw http.ResponseWriter, r *http.Request, rel *activerecord.Relation, id string) {
+	rec, err := res.find(rel, id)
+	if err != nil {
+		res.writeRecordError(rw, err)
+		return
+	}
+	res.writeRecord(rw, http.StatusOK, rec)
+}
+
+func (res *Resource) create(rw http.ResponseWriter, r *http.Request, rel *activerecord.Relation) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		res.writeError(rw, http.StatusBadRequest, err)
+		return
+	}
+
+	result := rel.FromJSON(body, res.permitted)
+	if result.IsErr() {
+		res.writeError(rw, http.StatusBadRequest, result.Err())
+		return
+	}
+
+	rec, err := result.Unwrap().Insert()
+	if err != nil {
+		res.writeRecordError(rw, err)
+		return
+	}
+	res.writeRecord(rw, http.StatusCreated, rec)
+}
+
+func (res *Resource) update(rw http.ResponseWriter, r *http.Request, rel *activerecord.Relation, id string) {
+	rec, err := res.find(rel, id)
+	if err != nil {
+		res.writeRecordError(rw, err)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		res.writeError(rw, http.StatusBadRequest, err)
+		return
+	}
+	if err = rec.FromJSON(body, res.permitted); err != nil {
+		res.writeError(rw, http.StatusBadRequest, err)
+		return
+	}
+
+	if rec, err = rec.Update(); err != nil {
+		res.writeRecordError(rw, err)
+		return
+	}
+	res.writeRecord(rw, http.StatusOK, rec)
+}
+
+func (res *Resource) destroy(rw http.ResponseWriter, r *http.Request, rel *activerecord.Relation, id string) {
+	rec, err := res.find(rel, id)
+	if err != nil {
+		res.writeRecordError(rw, err)
+		return
+	}
+	if _, err = rec.Delete(); err != nil {
+		res.writeRecordError(rw, err)
+		return
+	}
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// find returns the record with the primary key given in the path.
+func (res *Resource) find(rel *activerecord.Relation, id string) (*activerecord.ActiveRecord, error) {
+	pk := rel.AttributeForInspect(rel.PrimaryKey())
+	value, err := paramValue(pk.AttributeType(), id)
+	if err != nil {
+		return nil, &activerecord.ErrRecordNotFound{
+			Relation: rel.Name(), PrimaryKey: rel.PrimaryKey(), ID: id,
+		}
+	}
+
+	result := rel.Find(value)
+	if result.IsErr() {
+		return nil, result.Err()
+	}
+	return result.Unwrap(), nil
+}
+
+// filterArgs returns filter arguments of the query. Parameters named after
+// attributes filter equal values, operators are given in brackets, e.g.
+// "year[gt]=2000", values of the "in" operator are separated by commas. The
+// "order" parameter lists comma-separated attributes with optional directions.
+func (res *Resource) filterArgs(rel *activerecord.Relation, query url.Values) (actionview.FilterArgs, error) {
+	args := actionview.FilterArgs{Filter: make(activesupport.Hash)}
+
+	for key, values := range query {
+		switch key {
+		case "limit", "after":
+			continue
+		case "order":
+			for _, value := range values {
+				args.OrderBy = append(args.OrderBy, strings.Split(value, ",")...)
+			}
+			continue
+		}
+
+		attrName, op := key, "eq"
+		if i := strings.IndexByte(key, '['); i >= 0 && strings.HasSuffix(key, "]") {
+			attrName, op = key[:i], key[i+1:len(key)-1]
+		}
+
+		attr := rel.AttributeForInspect(attrName)
+		if attr == nil {
+			return args, &activerecord.ErrUnknownAttribute{RecordName: rel.Name(), Attr: attrName}
+		}
+
+		ops, _ := args.Filter[attrName].(activesupport.Hash)
+		if ops == nil {
+			ops = make(activesupport.Hash)
+			args.Filter[attrName] = ops
+		}
+
+		raw := values[len(values)-1]
+		if op != "in" {
+			value, err := paramValue(attr.AttributeType(), raw)
+			if err != nil {
+				return args, fmt.Errorf("parameter %q: %w", key, err)
+			}
+			ops[op] = value
+			continue
+		}
+
+		var list []interface{}
+		for _, elem := range strings.Split(raw, ",") {
+			value, err := paramValue(attr.AttributeType(), elem)
+			if err != nil {
+				return args, fmt.Errorf("parameter %q: %w", key, err)
+			}
+			list = append(list, value)
+		}
+		ops[op] = list
+	}
+	return args, nil
+}
+
+// paramValue converts the text of the parameter into the value of the type.
+func paramValue(t activerecord.Type, raw string) (interface{}, error) {
+	if n, ok := t.(activerecord.Nil); ok {
+		t = n.Type
+	}
+	switch t.(type) {
+	case *activerecord.Int64:
+		return strconv.ParseInt(raw, 10, 64)
+	case *activerecord.Float64:
+		return strconv.ParseFloat(raw, 64)
+	case *activerecord.Boolean:
+		return strconv.ParseBool(raw)
+	default:
+		return raw, nil
+	}
+}
+
+func (res *Resource) write(rw http.ResponseWriter, status int, value interface{}) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(status)
+	json.NewEncoder(rw).Encode(value)
+}
+
+func (res *Resource) writeRecord(rw http.ResponseWriter, status int, rec *activerecord.ActiveRecord) {
+	h, err := rec.AsJSON(res.serialization...)
+	if err != nil {
+		res.writeError(rw, http.StatusInternalServerError, err)
+		return
+	}
+	res.write(rw, status, h)
+}
+
+func (res *Resource) writeError(rw http.ResponseWriter, status int, err error) {
+	res.write(rw, status, activesupport.Hash{"error": err.Error()})
+}
+
+// writeRecordError writes errors of operations over records: missing records
+// are not found, validation errors are written as messages of attributes.
+//
+//	{"errors": {"title": ["'title' can't be blank"]}}
+func (res *Resource) writeRecordError(rw http.ResponseWriter, err error) {
+	var invalid activerecord.ErrValidation
+	switch {
+	case errors.Is(err, new(activerecord.ErrRecordNotFound)):
+		res.writeError(rw, http.StatusNotFound, err)
+	case errors.Is(err, new(activerecord.ErrReadOnlyRecord)):
+		res.writeError(rw, http.StatusForbidden, err)
+	case errors.As(err, &invalid):
+		messages := make(activesupport.Hash)
+		for _, key := range invalid.Errors.Keys() {
+			var attrMessages []string
+			for _, attrErr := range invalid.Errors.Get(key) {
+				attrMessages = append(attrMessages, attrErr.Error())
+			}
+			messages[key] = attrMessages
+		}
+		res.write(rw, http.StatusUnprocessableEntity, activesupport.Hash{"errors": messages})
+	default:
+		res.writeError(rw, http.StatusInternalServerError, err)
+	}
+}
@@ -0,0 +1,205 @@
+package rest_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/activegraph/activegraph/actioncontroller/rest"
+	"github.com/activegraph/activegraph/activerecord"
+	_ "github.com/activegraph/activegraph/activerecord/sqlite3"
+	. "github.com/activegraph/activegraph/activesupport"
+)
+
+func initBookTable(t *testing.T) {
+	activerecord.Migrate(t.Name()+"_add_books_table", func(m *activerecord.M) {
+		m.CreateTable("books", func(t *activerecord.Table) {
+			t.String("title")
+			t.Int64("year")
+		})
+	})
+}
+
+// serve sends the request to the handler and returns the recorded response
+// with the decoded JSON body.
+func serve(
+	t *testing.T, handler http.Handler, method, target, body string,
+) (*httptest.ResponseRecorder, interface{}) {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+
+	rw := httptest.NewRecorder()
+	handler.ServeHTTP(rw, r)
+
+	var decoded interface{}
+	if rw.Body.Len() > 0 {
+		require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &decoded), rw.Body.String())
+	}
+	return rw, decoded
+}
+
+func TestResource(t *testing.T) {
+	_, err := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter: "sqlite3", Database: t.Name() + ".db",
+	})
+	require.NoError(t, err)
+
+	defer os.Remove(t.Name() + ".db")
+	defer activerecord.RemoveConnection("primary")
+
+	initBookTable(t)
+
+	Book := activerecord.New("book", func(r *activerecord.R) {
+		r.ValidatesPresence("title")
+	})
+
+	mux := http.NewServeMux()
+	rest.Mount(mux, Book, rest.Permit("title", "year"))
+
+	// Records are created from permitted attributes only.
+	rw, body := serve(t, mux, http.MethodPost, "/books", `{"title": "Solaris", "year": 1961}`)
+	require.Equal(t, http.StatusCreated, rw.Code)
+	require.Equal(t, map[string]interface{}{"id": 1.0, "title": "Solaris", "year": 1961.0}, body)
+
+	rw, body = serve(t, mux, http.MethodPost, "/books", `{"id": 42, "title": "Eden"}`)
+	require.Equal(t, http.StatusBadRequest, rw.Code)
+	require.Contains(t, body.(map[string]interface{})["error"], "id")
+
+	// Validation errors are written as messages of attributes.
+	rw, body = serve(t, mux, http.MethodPost, "/books", `{"year": 1959}`)
+	require.Equal(t, http.StatusUnprocessableEntity, rw.Code)
+	require.Equal(t, map[string]interface{}{
+		"errors": map[string]interface{}{"title": []interface{}{"'title' can't be blank"}},
+	}, body)
+
+	for _, book := range []string{`{"title": "Eden", "year": 1959}`, `{"title": "Ubik", "year": 1969}`} {
+		rw, _ = serve(t, mux, http.MethodPost, "/books", book)
+		require.Equal(t, http.StatusCreated, rw.Code)
+	}
+
+	rw, body = serve(t, mux, http.MethodGet, "/books/2", "")
+	require.Equal(t, http.StatusOK, rw.Code)
+	require.Equal(t, map[string]interface{}{"id": 2.0, "title": "Eden", "year": 1959.0}, body)
+
+	for _, target := range []string{"/books/42", "/books/eden", "/books/1/author"} {
+		rw, _ = serve(t, mux, http.MethodGet, target, "")
+		require.Equal(t, http.StatusNotFound, rw.Code, target)
+	}
+
+	// Updates assign permitted attributes only and keep omitted attributes.
+	rw, body = serve(t, mux, http.MethodPatch, "/books/1", `{"year": 1962}`)
+	require.Equal(t, http.StatusOK, rw.Code)
+	require.Equal(t, map[string]interface{}{"id": 1.0, "title": "Solaris", "year": 1962.0}, body)
+
+	rw, _ = serve(t, mux, http.MethodPut, "/books/1", `{"id": 2}`)
+	require.Equal(t, http.StatusBadRequest, rw.Code)
+
+	rw, body = serve(t, mux, http.MethodPatch, "/books/1", `{"title": ""}`)
+	require.Equal(t, http.StatusUnprocessableEntity, rw.Code)
+	require.Contains(t, body.(map[string]interface{})["errors"], "title")
+
+	rw, _ = serve(t, mux, http.MethodPatch, "/books/42", `{"year": 1962}`)
+	require.Equal(t, http.StatusNotFound, rw.Code)
+
+	rw, _ = serve(t, mux, http.MethodDelete, "/books/3", "")
+	require.Equal(t, http.StatusNoContent, rw.Code)
+	require.Equal(t, 0, rw.Body.Len())
+
+	rw, _ = serve(t, mux, http.MethodGet, "/books/3", "")
+	require.Equal(t, http.StatusNotFound, rw.Code)
+
+	rw, _ = serve(t, mux, http.MethodDelete, "/books/3", "")
+	require.Equal(t, http.StatusNotFound, rw.Code)
+
+	// Records of read-only relations are not modified.
+	Archive := activerecord.New("book", func(r *activerecord.R) {
+		r.ReadOnly()
+	})
+	archive := rest.New(Archive, rest.Prefix("/archive"))
+
+	for _, method := range []string{http.MethodPatch, http.MethodDelete} {
+		rw, _ = serve(t, archive, method, "/archive/1", `{"year": 1970}`)
+		require.Equal(t, http.StatusForbidden, rw.Code, method)
+	}
+	rw, _ = serve(t, archive, http.MethodPost, "/archive", `{"title": "Eden"}`)
+	require.Equal(t, http.StatusForbidden, rw.Code)
+
+	rw, _ = serve(t, mux, http.MethodPost, "/books/1", `{}`)
+	require.Equal(t, http.StatusMethodNotAllowed, rw.Code)
+}
+
+func TestResource_Index(t *testing.T) {
+	_, err := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter: "sqlite3", Database: t.Name() + ".db",
+	})
+	require.NoError(t, err)
+
+	defer os.Remove(t.Name() + ".db")
+	defer activerecord.RemoveConnection("primary")
+
+	initBookTable(t)
+
+	Book := activerecord.New("book")
+	for _, book := range []Hash{
+		{"title": "Solaris", "year": 1961},
+		{"title": "Eden", "year": 1959},
+		{"title": "Ubik", "year": 1969},
+		{"title": "Neuromancer", "year": 1984},
+	} {
+		Book.Create(book).Unwrap()
+	}
+
+	mux := http.NewServeMux()
+	rest.Mount(mux, Book)
+
+	titlesOf := func(body interface{}) (titles []interface{}) {
+		for _, book := range body.([]interface{}) {
+			titles = append(titles, book.(map[string]interface{})["title"])
+		}
+		return titles
+	}
+
+	// The cursor of the next page is returned in the "Link" header.
+	rw, body := serve(t, mux, http.MethodGet, "/books?year[lt]=1984&order=year+DESC&limit=2", "")
+	require.Equal(t, http.StatusOK, rw.Code)
+	require.Equal(t, []interface{}{"Ubik", "Solaris"}, titlesOf(body))
+
+	link := regexp.MustCompile(`^<(.+)>; rel="next"$`).FindStringSubmatch(rw.Header().Get("Link"))
+	require.Len(t, link, 2, rw.Header().Get("Link"))
+	require.Contains(t, link[1], "after=")
+
+	rw, body = serve(t, mux, http.MethodGet, link[1], "")
+	require.Equal(t, http.StatusOK, rw.Code)
+	require.Equal(t, []interface{}{"Eden"}, titlesOf(body))
+	require.Empty(t, rw.Header().Get("Link"))
+
+	// The page of all records does not have the next page.
+	rw, body = serve(t, mux, http.MethodGet, "/books?title[in]=Eden,Ubik&order=title", "")
+	require.Equal(t, http.StatusOK, rw.Code)
+	require.Equal(t, []interface{}{"Eden", "Ubik"}, titlesOf(body))
+	require.Empty(t, rw.Header().Get("Link"))
+
+	rw, body = serve(t, mux, http.MethodGet, "/books?title=Nothing", "")
+	require.Equal(t, http.StatusOK, rw.Code)
+	require.Equal(t, []interface{}{}, body)
+
+	for _, target := range []string{
+		"/books?author=Lem",
+		"/books?year=sixty",
+		"/books?year[like]=1961",
+		"/books?order=RANDOM()",
+		"/books?order=year+SIDEWAYS",
+		"/books?limit=0",
+		"/books?after=invalid",
+	} {
+		rw, body = serve(t, mux, http.MethodGet, target, "")
+		require.Equal(t, http.StatusBadRequest, rw.Code, target)
+		require.Contains(t, body, "error", target)
+	}
+}
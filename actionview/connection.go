@@ -0,0 +1,145 @@
+package actionview
+
+import (
+	"context"
+
+	"github.com/activegraph/activegraph/actioncontroller"
+	"github.com/activegraph/activegraph/activerecord"
+	"github.com/activegraph/activegraph/activesupport"
+)
+
+const (
+	// DefaultPageSize is the number of records returned by connections, when
+	// the number is not given by connection arguments.
+	DefaultPageSize = 25
+
+	// MaxPageSize is the maximum number of records returned by connections.
+	MaxPageSize = 100
+)
+
+// ConnectionArgs are arguments of the forward pagination of the connection:
+// the number of records to return and the cursor of the record to paginate
+// after. Non-positive First returns DefaultPageSize records, First above
+// MaxPageSize returns MaxPageSize records.
+type ConnectionArgs struct {
+	First int
+	After string
+}
+
+// pageSize returns the number of records of the page.
+func (args ConnectionArgs) pageSize() int {
+	switch {
+	case args.First <= 0:
+		return DefaultPageSize
+	case args.First > MaxPageSize:
+		return MaxPageSize
+	default:
+		return args.First
+	}
+}
+
+type connectionKey struct{}
+
+// WithConnection returns a copy of the context with connection arguments, so
+// collection views render collections as connections, e.g. when the GraphQL
+// handler dispatches the connection of the index action.
+func WithConnection(ctx context.Context, args ConnectionArgs) context.Context {
+	return context.WithValue(ctx, connectionKey{}, args)
+}
+
+// ConnectionFromContext returns connection arguments of the context and false,
+// when the context does not have them.
+func ConnectionFromContext(ctx context.Context) (ConnectionArgs, bool) {
+	args, ok := ctx.Value(connectionKey{}).(ConnectionArgs)
+	return args, ok
+}
+
+// selectionOf returns the nested selection of the attribute with the given name.
+func selectionOf(selection []actioncontroller.QueryAttribute, name string) []actioncontroller.QueryAttribute {
+	for _, attr := range selection {
+		if attr.AttributeName == name {
+			return attr.NestedAttributes
+		}
+	}
+	return nil
+}
+
+// ConnectionView returns a Relay-style connection of the collection paginated
+// with keyset cursors of the relation order:
+//
+//	{
+//	  "edges": [{"cursor": "WzFd", "node": {"id": 1}}],
+//	  "pageInfo": {
+//	    "hasNextPage": true, "hasPreviousPage": false,
+//	    "startCursor": "WzFd", "endCursor": "WzFd"
+//	  }
+//	}
+//
+// Nodes are rendered with the selection of "edges.node", the same way as by
+// NestedCollectionView. Collections without the order are ordered by the primary
+// key, so cursors stay stable between requests. Collections are filtered and
+// ordered by filter arguments of the context before pagination. Invalid cursors
+// are returned as activerecord.ErrCursor errors.
+func ConnectionView(
+	ctx *actioncontroller.Context,
+	collection activerecord.CollectionResult,
+	args ConnectionArgs,
+) actioncontroller.Result {
+	collection = filtered(ctx, collection)
+	if collection.IsErr() {
+		return Error(collection.Err())
+	}
+
+	var (
+		records []*activerecord.ActiveRecord
+		limit   = args.pageSize()
+	)
+	rel := collection.Ok().UnwrapOr(nil)
+
+	if rel != nil {
+		// Retrieve an extra record to find out whether the next page exists.
+		var err error
+		if records, err = rel.After(args.After).Limit(limit + 1).ToA(); err != nil {
+			return Error(err)
+		}
+	}
+
+	hasNextPage := len(records) > limit
+	if hasNextPage {
+		records = records[:limit]
+	}
+
+	var (
+		edgesSelection = selectionOf(ctx.Selection, "edges")
+		nodeSelection  = selectionOf(edgesSelection, "node")
+	)
+	nodes, err := traverse(
+		ctx, AssociationLoaderFromContext(ctx), records,
+		actioncontroller.QueryAttribute{NestedAttributes: nodeSelection},
+	)
+	if err != nil {
+		return Error(err)
+	}
+
+	edges := make([]activesupport.Hash, len(records))
+	for i, rec := range records {
+		cursor, err := rel.Cursor(rec)
+		if err != nil {
+			return Error(err)
+		}
+		edges[i] = activesupport.Hash{"cursor": cursor, "node": nodes[i]}
+	}
+
+	pageInfo := activesupport.Hash{
+		"hasNextPage":     hasNextPage,
+		"hasPreviousPage": args.After != "",
+		"startCursor":     nil,
+		"endCursor":       nil,
+	}
+	if len(edges) > 0 {
+		pageInfo["startCursor"] = edges[0]["cursor"]
+		pageInfo["endCursor"] = edges[len(edges)-1]["cursor"]
+	}
+
+	return content(activesupport.Hash{"edges": edges, "pageInfo": pageInfo})
+}
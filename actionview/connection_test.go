@@ -0,0 +1,150 @@
+package actionview_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/activegraph/activegraph/actioncontroller"
+	"github.com/activegraph/activegraph/actionview"
+	"github.com/activegraph/activegraph/activerecord"
+	_ "github.com/activegraph/activegraph/activerecord/sqlite3"
+	. "github.com/activegraph/activegraph/activesupport"
+)
+
+// connectionOf returns the connection of the relation rendered with arguments.
+func connectionOf(rel *activerecord.Relation, args actionview.ConnectionArgs) (Hash, error) {
+	ctx := &actioncontroller.Context{
+		Context: context.Background(),
+		Selection: []actioncontroller.QueryAttribute{
+			{AttributeName: "edges", NestedAttributes: []actioncontroller.QueryAttribute{
+				{AttributeName: "cursor"},
+				{AttributeName: "node", NestedAttributes: []actioncontroller.QueryAttribute{
+					{AttributeName: "name"},
+				}},
+			}},
+		},
+	}
+
+	result, err := actionview.ConnectionView(ctx, activerecord.OkCollection(rel), args).Execute(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return result.(Hash), nil
+}
+
+func TestConnectionView(t *testing.T) {
+	_, err := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter: "sqlite3", Database: t.Name() + ".db",
+	})
+	require.NoError(t, err)
+
+	defer os.Remove(t.Name() + ".db")
+	defer activerecord.RemoveConnection("primary")
+
+	initBlogTables(t)
+
+	Author := activerecord.New("author")
+	for _, name := range []string{"Lem", "Dick", "Gibson"} {
+		Author.Create(Hash{"name": name}).Unwrap()
+	}
+
+	conn, err := connectionOf(Author, actionview.ConnectionArgs{First: 2})
+	require.NoError(t, err)
+
+	edges := conn["edges"].([]Hash)
+	require.Len(t, edges, 2)
+	require.Equal(t, Hash{"name": "Lem"}, edges[0]["node"])
+	require.Equal(t, Hash{"name": "Dick"}, edges[1]["node"])
+	require.Equal(t, Hash{
+		"hasNextPage":     true,
+		"hasPreviousPage": false,
+		"startCursor":     edges[0]["cursor"],
+		"endCursor":       edges[1]["cursor"],
+	}, conn["pageInfo"])
+
+	// The cursor of the page is passed to retrieve the next page.
+	conn, err = connectionOf(Author, actionview.ConnectionArgs{
+		First: 2, After: edges[1]["cursor"].(string),
+	})
+	require.NoError(t, err)
+
+	edges = conn["edges"].([]Hash)
+	require.Len(t, edges, 1)
+	require.Equal(t, Hash{"name": "Gibson"}, edges[0]["node"])
+	require.Equal(t, Hash{
+		"hasNextPage":     false,
+		"hasPreviousPage": true,
+		"startCursor":     edges[0]["cursor"],
+		"endCursor":       edges[0]["cursor"],
+	}, conn["pageInfo"])
+
+	// The page of all remaining records does not have the next page.
+	conn, err = connectionOf(Author, actionview.ConnectionArgs{First: 3})
+	require.NoError(t, err)
+	require.Len(t, conn["edges"], 3)
+	require.Equal(t, false, conn["pageInfo"].(Hash)["hasNextPage"])
+
+	// Pages after the last record are empty.
+	conn, err = connectionOf(Author, actionview.ConnectionArgs{
+		First: 2, After: edges[0]["cursor"].(string),
+	})
+	require.NoError(t, err)
+	require.Empty(t, conn["edges"])
+	require.Equal(t, Hash{
+		"hasNextPage":     false,
+		"hasPreviousPage": true,
+		"startCursor":     nil,
+		"endCursor":       nil,
+	}, conn["pageInfo"])
+
+	// Invalid cursors and cursors of other orders are rejected.
+	_, err = connectionOf(Author, actionview.ConnectionArgs{After: "invalid"})
+	require.ErrorIs(t, err, new(activerecord.ErrCursor))
+
+	_, err = connectionOf(Author.Order("name"), actionview.ConnectionArgs{
+		After: edges[0]["cursor"].(string),
+	})
+	require.ErrorIs(t, err, new(activerecord.ErrCursor))
+}
+
+func TestConnectionView_PageSize(t *testing.T) {
+	_, err := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter: "sqlite3", Database: t.Name() + ".db",
+	})
+	require.NoError(t, err)
+
+	defer os.Remove(t.Name() + ".db")
+	defer activerecord.RemoveConnection("primary")
+
+	initBlogTables(t)
+
+	Author := activerecord.New("author")
+	authors := make([]map[string]interface{}, actionview.MaxPageSize+1)
+	for i := range authors {
+		authors[i] = Hash{"name": fmt.Sprintf("Author %d", i)}
+	}
+	_, err = Author.InsertAll(authors...)
+	require.NoError(t, err)
+
+	tests := []struct {
+		first int
+		size  int
+	}{
+		{first: 0, size: actionview.DefaultPageSize},
+		{first: -1, size: actionview.DefaultPageSize},
+		{first: actionview.MaxPageSize, size: actionview.MaxPageSize},
+		{first: actionview.MaxPageSize + 1, size: actionview.MaxPageSize},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.first), func(t *testing.T) {
+			conn, err := connectionOf(Author, actionview.ConnectionArgs{First: tt.first})
+			require.NoError(t, err)
+			require.Len(t, conn["edges"], tt.size)
+			require.Equal(t, true, conn["pageInfo"].(Hash)["hasNextPage"])
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package actionview
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/activegraph/activegraph/actioncontroller"
+	"github.com/activegraph/activegraph/activerecord"
+	"github.com/activegraph/activegraph/activesupport"
+)
+
+// FilterArgs are arguments of filtering and ordering of collections.
+//
+// Filter maps attribute names to operators with values: "eq", "in", "gt" and
+// "lt", operators of all attributes are combined with AND. OrderBy lists
+// attribute names optionally followed by the direction "ASC" or "DESC".
+type FilterArgs struct {
+	Filter  activesupport.Hash
+	OrderBy []string
+}
+
+// ErrFilter is returned, when filter arguments do not match the relation.
+type ErrFilter struct {
+	RecordName string
+	Message    string
+}
+
+func (e *ErrFilter) Is(target error) bool {
+	_, ok := target.(*ErrFilter)
+	return ok
+}
+
+func (e *ErrFilter) Error() string {
+	return fmt.Sprintf("invalid filter of %s: %s", e.RecordName, e.Message)
+}
+
+type filterKey struct{}
+
+// WithFilter returns a copy of the context with filter arguments, so collection
+// views filter and order collections before rendering.
+func WithFilter(ctx context.Context, args FilterArgs) context.Context {
+	return context.WithValue(ctx, filterKey{}, args)
+}
+
+// FilterFromContext returns filter arguments of the context and false, when the
+// context does not have them.
+func FilterFromContext(ctx context.Context) (FilterArgs, bool) {
+	args, ok := ctx.Value(filterKey{}).(FilterArgs)
+	return args, ok
+}
+
+// Apply returns the relation filtered and ordered by arguments. Only attributes
+// of the relation are accepted, values are bound as query arguments, so the
+// arguments are safe to pass from clients:
+//
+//	args := FilterArgs{
+//		Filter:  Hash{"year": Hash{"gt": 2000}, "title": Hash{"in": []interface{}{"A", "B"}}},
+//		OrderBy: []string{"year DESC"},
+//	}
+//	books, err := args.Apply(Book.All().Unwrap())
+//	// SELECT * FROM "books" WHERE (title IN (?, ?)) AND (year > ?) ORDER BY year DESC
+func (args FilterArgs) Apply(rel *activerecord.Relation) (*activerecord.Relation, error) {
+	attrNames := make([]string, 0, len(args.Filter))
+	for attrName := range args.Filter {
+		attrNames = append(attrNames, attrName)
+	}
+	sort.Strings(attrNames)
+
+	for _, attrName := range attrNames {
+		if rel.AttributeForInspect(attrName) == nil {
+			return nil, &activerecord.ErrUnknownAttribute{RecordName: rel.Name(), Attr: attrName}
+		}
+
+		ops, ok := args.Filter[attrName].(activesupport.Hash)
+		if !ok {
+			return nil, &ErrFilter{RecordName: rel.Name(), Message: fmt.Sprintf(
+				"operators of %q are not a map", attrName,
+			)}
+		}
+
+		var err error
+		if rel, err = applyOperators(rel, attrName, ops); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, value := range args.OrderBy {
+		fields := strings.Fields(value)
+		if len(fields) == 0 || len(fields) > 2 {
+			return nil, &ErrFilter{RecordName: rel.Name(), Message: fmt.Sprintf("invalid order %q", value)}
+		}
+		if rel.AttributeForInspect(fields[0]) == nil {
+			return nil, &activerecord.ErrUnknownAttribute{RecordName: rel.Name(), Attr: fields[0]}
+		}
+
+		order := fields[0]
+		if len(fields) == 2 {
+			switch direction := strings.ToUpper(fields[1]); direction {
+			case "ASC", "DESC":
+				order += " " + direction
+			default:
+				return nil, &ErrFilter{RecordName: rel.Name(), Message: fmt.Sprintf(
+					"invalid order direction %q", fields[1],
+				)}
+			}
+		}
+		rel = rel.Order(order)
+	}
+	return rel, nil
+}
+
+// applyOperators returns the relation with conditions of operators on the
+// attribute, null values of operators are ignored.
+func applyOperators(
+	rel *activerecord.Relation, attrName string, ops activesupport.Hash,
+) (*activerecord.Relation, error) {
+	opNames := make([]string, 0, len(ops))
+	for opName := range ops {
+		opNames = append(opNames, opName)
+	}
+	sort.Strings(opNames)
+
+	for _, opName := range opNames {
+		value := ops[opName]
+		if value == nil {
+			continue
+		}
+
+		switch opName {
+		case "eq":
+			rel = rel.Where(attrName, value)
+		case "in":
+			values, ok := value.([]interface{})
+			if !ok {
+				return nil, &ErrFilter{RecordName: rel.Name(), Message: fmt.Sprintf(
+					"values of %q are not a list", attrName,
+				)}
+			}
+			rel = rel.Where(attrName, activerecord.In(values...))
+		case "gt":
+			rel = rel.Where(attrName, activerecord.GreaterThan(value))
+		case "lt":
+			rel = rel.Where(attrName, activerecord.LessThan(value))
+		default:
+			return nil, &ErrFilter{RecordName: rel.Name(), Message: fmt.Sprintf(
+				"unknown operator %q of %q", opName, attrName,
+			)}
+		}
+	}
+	return rel, nil
+}
+
+// filtered returns the collection filtered by arguments of the context.
+func filtered(
+	ctx *actioncontroller.Context, collection activerecord.CollectionResult,
+) activerecord.CollectionResult {
+	args, ok := FilterFromContext(ctx)
+	if !ok || collection.IsErr() || collection.Ok().IsNone() {
+		return collection
+	}
+	rel, err := args.Apply(collection.Unwrap())
+	if err != nil {
+		return activerecord.ErrCollection(err)
+	}
+	return activerecord.OkCollection(rel)
+}
@@ -0,0 +1,144 @@
+package actionview_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/activegraph/activegraph/actionview"
+	"github.com/activegraph/activegraph/activerecord"
+	_ "github.com/activegraph/activegraph/activerecord/sqlite3"
+	. "github.com/activegraph/activegraph/activesupport"
+)
+
+func TestFilterArgs_Apply(t *testing.T) {
+	_, err := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter: "sqlite3", Database: t.Name() + ".db",
+	})
+	require.NoError(t, err)
+
+	defer os.Remove(t.Name() + ".db")
+	defer activerecord.RemoveConnection("primary")
+
+	initBlogTables(t)
+
+	Post := activerecord.New("post")
+	for _, title := range []string{"Solaris", "Eden", "Ubik"} {
+		Post.Create(Hash{"title": title}).Unwrap()
+	}
+
+	tests := []struct {
+		name   string
+		args   actionview.FilterArgs
+		sql    string
+		titles []interface{}
+	}{
+		{
+			name:   "eq",
+			args:   actionview.FilterArgs{Filter: Hash{"title": Hash{"eq": "Eden"}}},
+			sql:    `SELECT * FROM "posts" WHERE (title = ?)`,
+			titles: []interface{}{"Eden"},
+		},
+		{
+			name:   "in",
+			args:   actionview.FilterArgs{Filter: Hash{"title": Hash{"in": []interface{}{"Eden", "Ubik"}}}},
+			sql:    `SELECT * FROM "posts" WHERE (title IN (?, ?))`,
+			titles: []interface{}{"Eden", "Ubik"},
+		},
+		{
+			name:   "gt and lt",
+			args:   actionview.FilterArgs{Filter: Hash{"id": Hash{"gt": 1, "lt": 3}}},
+			sql:    `SELECT * FROM "posts" WHERE (id > ?) AND (id < ?)`,
+			titles: []interface{}{"Eden"},
+		},
+		{
+			name:   "null operators",
+			args:   actionview.FilterArgs{Filter: Hash{"title": Hash{"eq": nil}}},
+			sql:    `SELECT * FROM "posts"`,
+			titles: []interface{}{"Solaris", "Eden", "Ubik"},
+		},
+		{
+			name:   "order",
+			args:   actionview.FilterArgs{OrderBy: []string{"title desc", "id"}},
+			sql:    `SELECT * FROM "posts" ORDER BY title DESC, id`,
+			titles: []interface{}{"Ubik", "Solaris", "Eden"},
+		},
+		{
+			name: "values are bound",
+			args: actionview.FilterArgs{Filter: Hash{"id": Hash{"eq": "1; DROP TABLE posts"}}},
+			sql:  `SELECT * FROM "posts" WHERE (id = ?)`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rel, err := tt.args.Apply(Post.All().Unwrap())
+			require.NoError(t, err)
+			require.Equal(t, tt.sql, rel.ToSQL())
+
+			posts, err := rel.ToA()
+			require.NoError(t, err)
+
+			var titles []interface{}
+			for _, post := range posts {
+				titles = append(titles, post.Attribute("title"))
+			}
+			require.Equal(t, tt.titles, titles)
+		})
+	}
+
+	invalid := []struct {
+		name string
+		args actionview.FilterArgs
+		err  interface{}
+	}{
+		{
+			name: "unknown attribute",
+			args: actionview.FilterArgs{Filter: Hash{"author": Hash{"eq": "Lem"}}},
+			err:  new(*activerecord.ErrUnknownAttribute),
+		},
+		{
+			name: "injected attribute",
+			args: actionview.FilterArgs{Filter: Hash{"id; DROP TABLE posts": Hash{"eq": 1}}},
+			err:  new(*activerecord.ErrUnknownAttribute),
+		},
+		{
+			name: "unknown operator",
+			args: actionview.FilterArgs{Filter: Hash{"id": Hash{"id; DROP": 1}}},
+			err:  new(*actionview.ErrFilter),
+		},
+		{
+			name: "operators are not a map",
+			args: actionview.FilterArgs{Filter: Hash{"id": 1}},
+			err:  new(*actionview.ErrFilter),
+		},
+		{
+			name: "in values are not a list",
+			args: actionview.FilterArgs{Filter: Hash{"id": Hash{"in": "1, 2"}}},
+			err:  new(*actionview.ErrFilter),
+		},
+		{
+			name: "unknown order attribute",
+			args: actionview.FilterArgs{OrderBy: []string{"RANDOM()"}},
+			err:  new(*activerecord.ErrUnknownAttribute),
+		},
+		{
+			name: "injected order attribute",
+			args: actionview.FilterArgs{OrderBy: []string{"id; DROP TABLE posts"}},
+			err:  new(*actionview.ErrFilter),
+		},
+		{
+			name: "bad direction",
+			args: actionview.FilterArgs{OrderBy: []string{"id SIDEWAYS"}},
+			err:  new(*actionview.ErrFilter),
+		},
+	}
+
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.args.Apply(Post.All().Unwrap())
+			require.ErrorAs(t, err, tt.err)
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package actionview
+
+import (
+	"context"
+	"sync"
+
+	"github.com/activegraph/activegraph/activerecord"
+)
+
+// AssociationLoader loads associations of records in batches: targets of all
+// records on the same level of the selection are retrieved with a single query
+// per association, instead of a query per record.
+//
+// Targets of singular associations are memoized by primary keys, so the loader
+// is created per request, e.g. by the GraphQL handler, to keep loaded records
+// consistent within the request.
+//
+// AssociationLoader is safe for concurrent use.
+type AssociationLoader struct {
+	mu      sync.Mutex
+	targets map[string]map[interface{}]*activerecord.ActiveRecord
+}
+
+// NewAssociationLoader returns a new loader without loaded records.
+func NewAssociationLoader() *AssociationLoader {
+	return &AssociationLoader{
+		targets: make(map[string]map[interface{}]*activerecord.ActiveRecord),
+	}
+}
+
+type loaderKey struct{}
+
+// WithAssociationLoader returns a copy of the context with the loader, which is
+// used by views to load associations of records.
+func WithAssociationLoader(ctx context.Context, l *AssociationLoader) context.Context {
+	return context.WithValue(ctx, loaderKey{}, l)
+}
+
+// AssociationLoaderFromContext returns the loader of the context, or a new loader,
+// when the context does not have one.
+func AssociationLoaderFromContext(ctx context.Context) *AssociationLoader {
+	if l, ok := ctx.Value(loaderKey{}).(*AssociationLoader); ok {
+		return l
+	}
+	return NewAssociationLoader()
+}
+
+// memoized returns memoized targets of the relation with given primary keys and
+// primary keys of missing targets.
+func (l *AssociationLoader) memoized(
+	name string, keys []interface{},
+) (
+	map[interface{}]*activerecord.ActiveRecord, []interface{},
+) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var (
+		found   = make(map[interface{}]*activerecord.ActiveRecord, len(keys))
+		missing []interface{}
+	)
+	for _, key := range keys {
+		if target, ok := l.targets[name][key]; ok {
+			found[key] = target
+		} else {
+			missing = append(missing, key)
+		}
+	}
+	return found, missing
+}
+
+func (l *AssociationLoader) memoize(name string, key interface{}, target *activerecord.ActiveRecord) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.targets[name] == nil {
+		l.targets[name] = make(map[interface{}]*activerecord.ActiveRecord)
+	}
+	l.targets[name][key] = target
+}
+
+// uniqueValues returns distinct non-nil values of the attribute of records.
+func uniqueValues(records []*activerecord.ActiveRecord, attrName string) []interface{} {
+	var (
+		values = make([]interface{}, 0, len(records))
+		seen   = make(map[interface{}]struct{}, len(records))
+	)
+	for _, rec := range records {
+		value := rec.Attribute(attrName)
+		if _, dup := seen[value]; value != nil && !dup {
+			seen[value] = struct{}{}
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// LoadAssociation returns targets of the singular association of records, the
+// slice of targets is aligned with records, records without the target have
+// nil targets.
+func (l *AssociationLoader) LoadAssociation(
+	ctx context.Context, records []*activerecord.ActiveRecord, assocName string,
+) (
+	[]*activerecord.ActiveRecord, error,
+) {
+	targets := make([]*activerecord.ActiveRecord, len(records))
+	if len(records) == 0 {
+		return targets, nil
+	}
+
+	reflection := records[0].ReflectOnAssociation(assocName)
+	if reflection == nil {
+		return nil, activerecord.ErrUnknownAssociation{RecordName: records[0].Name(), Assoc: assocName}
+	}
+	var (
+		rel = reflection.Relation.WithContext(ctx)
+		fk  = reflection.AssociationForeignKey()
+	)
+
+	switch reflection.Association.(type) {
+	case *activerecord.BelongsTo:
+		pk := rel.PrimaryKey()
+		found, missing := l.memoized(rel.Name(), uniqueValues(records, fk))
+
+		if len(missing) > 0 {
+			err := rel.Where(pk, activerecord.In(missing...)).Each(func(target *activerecord.ActiveRecord) error {
+				l.memoize(rel.Name(), target.Attribute(pk), target)
+				found[target.Attribute(pk)] = target
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+		for i, rec := range records {
+			targets[i] = found[rec.Attribute(fk)]
+		}
+
+	case *activerecord.HasOne:
+		found := make(map[interface{}]*activerecord.ActiveRecord, len(records))
+		err := rel.Where(fk, activerecord.In(uniqueIDs(records)...)).Each(func(target *activerecord.ActiveRecord) error {
+			found[target.Attribute(fk)] = target
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		for i, rec := range records {
+			targets[i] = found[rec.ID()]
+		}
+
+	default:
+		// Associations of unknown types are accessed record by record.
+		for i, rec := range records {
+			target, err := rec.AccessAssociation(assocName)
+			if err != nil {
+				return nil, err
+			}
+			targets[i] = target
+		}
+	}
+	return targets, nil
+}
+
+// LoadCollection returns targets of the collection association of records, the
+// slice of collections is aligned with records.
+func (l *AssociationLoader) LoadCollection(
+	ctx context.Context, records []*activerecord.ActiveRecord, collName string,
+) (
+	[][]*activerecord.ActiveRecord, error,
+) {
+	collections := make([][]*activerecord.ActiveRecord, len(records))
+	if len(records) == 0 {
+		return collections, nil
+	}
+
+	reflection := records[0].ReflectOnAssociation(collName)
+	if reflection == nil {
+		return nil, activerecord.ErrUnknownAssociation{RecordName: records[0].Name(), Assoc: collName}
+	}
+
+	if _, ok := reflection.Association.(*activerecord.HasMany); !ok {
+		// Associations of unknown types are accessed record by record.
+		for i, rec := range records {
+			collection, err := rec.AccessCollection(collName)
+			if err != nil {
+				return nil, err
+			}
+			if collections[i], err = collection.ToA(); err != nil {
+				return nil, err
+			}
+		}
+		return collections, nil
+	}
+
+	var (
+		rel   = reflection.Relation.WithContext(ctx)
+		fk    = reflection.AssociationForeignKey()
+		found = make(map[interface{}][]*activerecord.ActiveRecord, len(records))
+	)
+	err := rel.Where(fk, activerecord.In(uniqueIDs(records)...)).Each(func(target *activerecord.ActiveRecord) error {
+		found[target.Attribute(fk)] = append(found[target.Attribute(fk)], target)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i, rec := range records {
+		collections[i] = found[rec.ID()]
+	}
+	return collections, nil
+}
+
+// uniqueIDs returns distinct primary keys of records.
+func uniqueIDs(records []*activerecord.ActiveRecord) []interface{} {
+	var (
+		ids  = make([]interface{}, 0, len(records))
+		seen = make(map[interface{}]struct{}, len(records))
+	)
+	for _, rec := range records {
+		id := rec.ID()
+		if _, dup := seen[id]; id != nil && !dup {
+			seen[id] = struct{}{}
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
@@ -1,6 +1,7 @@
 package actionview
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/activegraph/activegraph/actioncontroller"
@@ -37,79 +38,95 @@ func content(content interface{}) actioncontroller.Result {
 	})
 }
 
+// traverse returns hashes of records with nested attributes of the selection.
+// Associations are loaded by the loader level by level, so records of each
+// association are retrieved with a single query for all records of the level.
 func traverse(
-	rec *activerecord.ActiveRecord,
+	ctx context.Context,
+	loader *AssociationLoader,
+	records []*activerecord.ActiveRecord,
 	selection actioncontroller.QueryAttribute,
-) (activesupport.Hash, error) {
-	recHash := rec.ToHash()
-	recHash = recHash.Slice(selection.NestedAttributeNames()...)
+) ([]activesupport.Hash, error) {
+	hashes := make([]activesupport.Hash, len(records))
+	for i, rec := range records {
+		hashes[i] = rec.ToHash().Slice(selection.NestedAttributeNames()...)
+	}
+	if len(records) == 0 {
+		return hashes, nil
+	}
 
 	for _, sel := range selection.NestedAttributes {
-		if _, ok := recHash[sel.AttributeName]; ok {
+		if records[0].HasAttribute(sel.AttributeName) {
 			continue
 		}
 
-		target := rec.ReflectOnAssociation(sel.AttributeName)
+		target := records[0].ReflectOnAssociation(sel.AttributeName)
 		if target == nil {
 			continue
 		}
 
 		switch target.Association.(type) {
 		case activerecord.SingularAssociation:
-			association, err := rec.AccessAssociation(sel.AttributeName)
+			associations, err := loader.LoadAssociation(ctx, records, sel.AttributeName)
 			if err != nil {
 				return nil, err
 			}
 
-			nestedHash, err := traverse(association, sel)
+			// Traverse distinct targets once, records could share the target.
+			var (
+				indices = make(map[*activerecord.ActiveRecord]int, len(associations))
+				targets = make([]*activerecord.ActiveRecord, 0, len(associations))
+			)
+			for _, association := range associations {
+				if _, dup := indices[association]; association != nil && !dup {
+					indices[association] = len(targets)
+					targets = append(targets, association)
+				}
+			}
+
+			nestedHashes, err := traverse(ctx, loader, targets, sel)
 			if err != nil {
 				return nil, err
 			}
-			recHash[sel.AttributeName] = nestedHash
+			for i, association := range associations {
+				if association == nil {
+					hashes[i][sel.AttributeName] = nil
+					continue
+				}
+				hashes[i][sel.AttributeName] = nestedHashes[indices[association]]
+			}
 		case activerecord.CollectionAssociation:
-			collection, err := rec.AccessCollection(sel.AttributeName)
+			collections, err := loader.LoadCollection(ctx, records, sel.AttributeName)
 			if err != nil {
 				return nil, err
 			}
-			associations, err := collection.ToA()
-			if err != nil {
-				return nil, err
+
+			var targets []*activerecord.ActiveRecord
+			for _, collection := range collections {
+				targets = append(targets, collection...)
 			}
 
-			nestedHash, err := traverseCollection(associations, sel)
+			nestedHashes, err := traverse(ctx, loader, targets, sel)
 			if err != nil {
 				return nil, err
 			}
-
-			recHash[sel.AttributeName] = nestedHash
+			for i, collection := range collections {
+				hashes[i][sel.AttributeName] = nestedHashes[:len(collection):len(collection)]
+				nestedHashes = nestedHashes[len(collection):]
+			}
 		default:
 			panic("unknown target association")
 		}
 	}
 
-	return recHash, nil
-}
-
-func traverseCollection(
-	collection []*activerecord.ActiveRecord,
-	selection actioncontroller.QueryAttribute,
-) ([]activesupport.Hash, error) {
-	collectionHash := make([]activesupport.Hash, 0, len(collection))
-
-	for _, rec := range collection {
-		recHash, err := traverse(rec, selection)
-		if err != nil {
-			return nil, err
-		}
-		collectionHash = append(collectionHash, recHash)
-	}
-	return collectionHash, nil
+	return hashes, nil
 }
 
 // NestedView returns a result with activesupport.Hash type.
 //
 // Method queries all nested attributes specified in ctx.Selection. That means
-// additionall queries to a database are implied.
+// additionall queries to a database are implied, a query per association with
+// AssociationLoader of the context.
 func NestedView(
 	ctx *actioncontroller.Context, record activerecord.RecordResult,
 ) actioncontroller.Result {
@@ -121,24 +138,30 @@ func NestedView(
 	}
 
 	result, err := traverse(
-		record.Unwrap(), actioncontroller.QueryAttribute{
-			NestedAttributes: ctx.Selection,
-		},
+		ctx, AssociationLoaderFromContext(ctx),
+		[]*activerecord.ActiveRecord{record.Unwrap()},
+		actioncontroller.QueryAttribute{NestedAttributes: ctx.Selection},
 	)
 	if err != nil {
-		Error(err)
+		return Error(err)
 	}
-	return content(result)
+	return content(result[0])
 }
 
 // NestedCollectionView returns a colleciton as a slice of activesupport.Hash.
 // Values of the record attributes are fetched as is, without conversion.
 //
-// In case of collection with error an error result is returned.
+// In case of collection with error an error result is returned. When the context
+// has connection arguments, the collection is returned as ConnectionView. When
+// the context has filter arguments, the collection is filtered and ordered.
 func NestedCollectionView(
 	ctx *actioncontroller.Context,
 	collection activerecord.CollectionResult,
 ) actioncontroller.Result {
+	if args, ok := ConnectionFromContext(ctx); ok {
+		return ConnectionView(ctx, collection, args)
+	}
+	collection = filtered(ctx, collection)
 	if collection.IsErr() {
 		return Error(collection.Err())
 	}
@@ -151,10 +174,9 @@ func NestedCollectionView(
 		return Error(err)
 	}
 
-	result, err := traverseCollection(
-		records, actioncontroller.QueryAttribute{
-			NestedAttributes: ctx.Selection,
-		},
+	result, err := traverse(
+		ctx, AssociationLoaderFromContext(ctx), records,
+		actioncontroller.QueryAttribute{NestedAttributes: ctx.Selection},
 	)
 	if err != nil {
 		return Error(err)
@@ -0,0 +1,134 @@
+package actionview_test
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/activegraph/activegraph/actioncontroller"
+	"github.com/activegraph/activegraph/actionview"
+	"github.com/activegraph/activegraph/activerecord"
+	_ "github.com/activegraph/activegraph/activerecord/sqlite3"
+	. "github.com/activegraph/activegraph/activesupport"
+)
+
+func initBlogTables(t *testing.T) {
+	activerecord.Migrate(t.Name()+"_add_blog_tables", func(m *activerecord.M) {
+		m.CreateTable("authors", func(t *activerecord.Table) {
+			t.String("name")
+		})
+		m.CreateTable("posts", func(t *activerecord.Table) {
+			t.String("title")
+			t.References("authors")
+		})
+		m.CreateTable("comments", func(t *activerecord.Table) {
+			t.String("body")
+			t.References("posts")
+			t.References("authors")
+		})
+	})
+}
+
+// queryCounter counts queries executed by database adapters.
+type queryCounter struct {
+	mu sync.Mutex
+	n  int
+}
+
+func (c *queryCounter) LogQuery(context.Context, *activerecord.QueryEvent) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.n++
+}
+
+func TestNestedCollectionView_Queries(t *testing.T) {
+	_, err := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter: "sqlite3", Database: t.Name() + ".db",
+	})
+	require.NoError(t, err)
+
+	defer os.Remove(t.Name() + ".db")
+	defer activerecord.RemoveConnection("primary")
+
+	initBlogTables(t)
+
+	Author := activerecord.New("author")
+	Post := activerecord.New("post", func(r *activerecord.R) {
+		r.BelongsTo("author")
+		r.HasMany("comments")
+	})
+	Comment := activerecord.New("comment", func(r *activerecord.R) {
+		r.BelongsTo("post")
+		r.BelongsTo("author")
+	})
+
+	var (
+		lem    = Author.Create(Hash{"name": "Lem"}).Unwrap()
+		dick   = Author.Create(Hash{"name": "Dick"}).Unwrap()
+		gibson = Author.Create(Hash{"name": "Gibson"}).Unwrap()
+	)
+
+	// Posts share the author, the last post does not have the author.
+	var (
+		solaris = Post.Create(Hash{"title": "Solaris", "author_id": lem.ID()}).Unwrap()
+		_       = Post.Create(Hash{"title": "Eden", "author_id": lem.ID()}).Unwrap()
+		ubik    = Post.Create(Hash{"title": "Ubik"}).Unwrap()
+	)
+	Comment.Create(Hash{"body": "Ocean", "post_id": solaris.ID(), "author_id": dick.ID()}).Unwrap()
+	Comment.Create(Hash{"body": "Mimoid", "post_id": solaris.ID(), "author_id": gibson.ID()}).Unwrap()
+	Comment.Create(Hash{"body": "Planet", "post_id": ubik.ID(), "author_id": lem.ID()}).Unwrap()
+
+	queries := new(queryCounter)
+	activerecord.SetQueryLogger(queries)
+	defer activerecord.SetQueryLogger(activerecord.NewWriterLogger(os.Stdout))
+
+	name := actioncontroller.QueryAttribute{AttributeName: "name"}
+	ctx := &actioncontroller.Context{
+		Context: context.Background(),
+		Selection: []actioncontroller.QueryAttribute{
+			{AttributeName: "title"},
+			{AttributeName: "author", NestedAttributes: []actioncontroller.QueryAttribute{name}},
+			{AttributeName: "comments", NestedAttributes: []actioncontroller.QueryAttribute{
+				{AttributeName: "body"},
+				{AttributeName: "author", NestedAttributes: []actioncontroller.QueryAttribute{name}},
+			}},
+		},
+	}
+
+	result, err := actionview.NestedCollectionView(ctx, activerecord.OkCollection(Post.Order("id"))).Execute(ctx)
+	require.NoError(t, err)
+
+	// A query of posts, authors of posts, comments and authors of comments, which
+	// are not loaded with authors of posts.
+	require.Equal(t, 4, queries.n)
+
+	posts := result.([]Hash)
+	require.Len(t, posts, 3)
+
+	require.Equal(t, "Solaris", posts[0]["title"])
+	require.Equal(t, Hash{"name": "Lem"}, posts[0]["author"])
+	require.Equal(t, []Hash{
+		{"body": "Ocean", "author": Hash{"name": "Dick"}},
+		{"body": "Mimoid", "author": Hash{"name": "Gibson"}},
+	}, posts[0]["comments"])
+
+	require.Equal(t, "Eden", posts[1]["title"])
+	require.Equal(t, Hash{"name": "Lem"}, posts[1]["author"])
+	require.Empty(t, posts[1]["comments"])
+
+	require.Equal(t, "Ubik", posts[2]["title"])
+	require.Nil(t, posts[2]["author"])
+	require.Equal(t, []Hash{
+		{"body": "Planet", "author": Hash{"name": "Lem"}},
+	}, posts[2]["comments"])
+
+	// Empty collections do not query associations.
+	queries.n = 0
+	result, err = actionview.NestedCollectionView(ctx, activerecord.OkCollection(Post.None())).Execute(ctx)
+	require.NoError(t, err)
+	require.Empty(t, result)
+	require.Equal(t, 0, queries.n)
+}
@@ -0,0 +1,186 @@
+package activerecord
+
+import (
+	"fmt"
+	"reflect"
+
+	. "github.com/activegraph/activegraph/activesupport"
+)
+
+// aggregation is the virtual attribute of the value object composed of values
+// of mapped attributes.
+type aggregation struct {
+	attr
+	mapping   []string
+	construct func(Hash) interface{}
+	decompose func(interface{}) (Hash, error)
+}
+
+// AggregationOption configures the value object declared with ComposedOf.
+type AggregationOption func(*aggregation)
+
+// Mapping specifies attributes composing the value object.
+func Mapping(attrNames ...string) AggregationOption {
+	return func(a *aggregation) {
+		a.mapping = append(a.mapping, attrNames...)
+	}
+}
+
+// Constructor specifies the function, which creates the value object from values
+// of mapped attributes. Without the constructor, the value object is the hash of
+// mapped attributes.
+func Constructor(fn func(Hash) interface{}) AggregationOption {
+	return func(a *aggregation) {
+		a.construct = fn
+	}
+}
+
+// Decomposer specifies the function, which returns values of mapped attributes
+// of the value object. Without the decomposer, hashes and structs are decomposed,
+// fields of structs are matched with attributes by "activerecord" tags or by
+// camel-cased names of attributes.
+func Decomposer(fn func(interface{}) (Hash, error)) AggregationOption {
+	return func(a *aggregation) {
+		a.decompose = fn
+	}
+}
+
+// values returns values of mapped attributes of the value object.
+func (a *aggregation) values(val interface{}) (Hash, error) {
+	values := make(Hash, len(a.mapping))
+	if val == nil {
+		for _, attrName := range a.mapping {
+			values[attrName] = nil
+		}
+		return values, nil
+	}
+
+	h, err := a.decompose(val)
+	if err != nil {
+		return nil, err
+	}
+	for _, attrName := range a.mapping {
+		values[attrName] = h[attrName]
+	}
+	return values, nil
+}
+
+func (a *aggregation) assignAttribute(attrs *attributes, val interface{}) error {
+	values, err := a.values(val)
+	if err != nil {
+		return err
+	}
+	for _, attrName := range a.mapping {
+		if err = attrs.AssignAttribute(attrName, values[attrName]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// accessAttribute returns the value object, when at least one of mapped
+// attributes is present.
+func (a *aggregation) accessAttribute(attrs *attributes) interface{} {
+	var (
+		values  = make(Hash, len(a.mapping))
+		present bool
+	)
+	for _, attrName := range a.mapping {
+		values[attrName] = attrs.AccessAttribute(attrName)
+		present = present || values[attrName] != nil
+	}
+	if !present {
+		return nil
+	}
+	if a.construct == nil {
+		return values
+	}
+	return a.construct(values)
+}
+
+// decomposeValue returns values of fields of hashes and structs by names of
+// mapped attributes.
+func (a *aggregation) decomposeValue(val interface{}) (Hash, error) {
+	switch val := val.(type) {
+	case Hash:
+		return val, nil
+	case map[string]interface{}:
+		return Hash(val), nil
+	}
+
+	v := reflect.Indirect(reflect.ValueOf(val))
+	if v.Kind() != reflect.Struct {
+		return nil, ErrInvalidType{AttrName: a.Name, TypeName: "struct", Value: val}
+	}
+
+	fields := make(map[string]reflect.Value, v.NumField())
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := field.Tag.Get("activerecord")
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = v.Field(i)
+	}
+
+	values := make(Hash, len(a.mapping))
+	for _, attrName := range a.mapping {
+		field, ok := fields[attrName]
+		if !ok {
+			field, ok = fields[Camelize(attrName)]
+		}
+		if !ok {
+			return nil, ErrInvalidValue{
+				AttrName: a.Name, Value: val, Message: fmt.Sprintf("does not have %q field", attrName),
+			}
+		}
+		values[attrName] = field.Interface()
+	}
+	return values, nil
+}
+
+// ComposedOf declares the value object composed of values of the mapped attributes,
+// so the group of attributes is read and written as a single value:
+//
+//	type Address struct {
+//		Street, City, Zip string
+//	}
+//
+//	Customer := activerecord.New("customer", func(r *activerecord.R) {
+//		r.ComposedOf("address",
+//			activerecord.Mapping("street", "city", "zip"),
+//			activerecord.Constructor(func(h Hash) interface{} {
+//				street, _ := h["street"].(string)
+//				city, _ := h["city"].(string)
+//				zip, _ := h["zip"].(string)
+//				return Address{street, city, zip}
+//			}),
+//		)
+//	})
+//
+//	customer := Customer.Create(Hash{"address": Address{"Nowy Swiat 1", "Warsaw", "00-001"}})
+//	customer.Unwrap().Attribute("address") // Address{"Nowy Swiat 1", "Warsaw", "00-001"}
+//	customer.Unwrap().Attribute("city")    // "Warsaw"
+//
+// Value objects are compared by values of mapped attributes in conditions:
+//
+//	Customer.Where("address", Address{"Nowy Swiat 1", "Warsaw", "00-001"})
+//	// SELECT * FROM "customers" WHERE (street = ?) AND (city = ?) AND (zip = ?)
+//
+// The value object is nil, when all mapped attributes are nil. Assignment of nil
+// assigns nil to all mapped attributes.
+func (r *R) ComposedOf(name string, opts ...AggregationOption) {
+	agg := &aggregation{attr: attr{Name: name}}
+	agg.decompose = agg.decomposeValue
+	for _, opt := range opts {
+		opt(agg)
+	}
+
+	if r.virtuals == nil {
+		r.virtuals = make(map[string]virtualAttribute)
+	}
+	r.virtuals[name] = agg
+}
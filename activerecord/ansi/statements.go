@@ -3,9 +3,13 @@ package ansi
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"errors"
 	"fmt"
+	"io"
+	"net"
 	"strings"
+	"syscall"
 
 	"github.com/activegraph/activegraph/activerecord"
 	. "github.com/activegraph/activegraph/activesupport"
@@ -16,6 +20,24 @@ type ConnectionStatements interface {
 	QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error)
 }
 
+// ExecContext executes the statement and logs it with the number of affected rows
+// using the query logger.
+func ExecContext(
+	ctx context.Context, conn ConnectionStatements, stmt string, args ...interface{},
+) (
+	sql.Result, error,
+) {
+	done := activerecord.LogQuery(ctx, stmt, args)
+	result, err := conn.ExecContext(ctx, stmt, args...)
+
+	var rows int64
+	if err == nil {
+		rows, _ = result.RowsAffected()
+	}
+	done(rows, err)
+	return result, err
+}
+
 type DatabaseStatements struct {
 	Conn ConnectionStatements
 }
@@ -54,9 +76,7 @@ func (s *DatabaseStatements) ExecInsert(ctx context.Context, op *activerecord.In
 	if err != nil {
 		return 0, err
 	}
-	fmt.Println(stmt)
-
-	result, err := s.Conn.ExecContext(ctx, stmt)
+	result, err := ExecContext(ctx, s.Conn, stmt)
 	if err != nil {
 		return 0, err
 	}
@@ -107,9 +127,7 @@ func (s *DatabaseStatements) ExecUpdate(
 	if err != nil {
 		return err
 	}
-	fmt.Println(stmt)
-
-	result, err := s.Conn.ExecContext(ctx, stmt)
+	result, err := ExecContext(ctx, s.Conn, stmt)
 	if err != nil {
 		return err
 	}
@@ -127,7 +145,7 @@ func (s *DatabaseStatements) ExecUpdate(
 func (s *DatabaseStatements) ExecDelete(ctx context.Context, op *activerecord.DeleteOperation) error {
 	const stmt = `DELETE FROM "%s" WHERE "%s" = '%v'`
 	sql := fmt.Sprintf(stmt, op.TableName, op.PrimaryKey, op.Value)
-	_, err := s.Conn.ExecContext(ctx, sql)
+	_, err := ExecContext(ctx, s.Conn, sql)
 	return err
 }
 
@@ -136,7 +154,11 @@ func (s *DatabaseStatements) ExecQuery(
 ) (
 	err error,
 ) {
-	fmt.Println(op.Text, op.Args)
+	var rows int64
+
+	done := activerecord.LogQuery(ctx, op.Text, op.Args)
+	defer func() { done(rows, err) }()
+
 	rws, err := s.Conn.QueryContext(ctx, op.Text, op.Args...)
 	if err != nil {
 		return err
@@ -144,13 +166,20 @@ func (s *DatabaseStatements) ExecQuery(
 
 	defer rws.Close()
 
+	columns := op.Columns
+	if len(columns) == 0 {
+		if columns, err = rws.Columns(); err != nil {
+			return err
+		}
+	}
+
 	for rws.Next() {
 		var (
 			// Iterate over rows and scan one-by one.
 			row = make(Hash)
 			// Initalize a list of interfaces, so the Scan operation could
 			// assign the results to the each element of the list.
-			vals = make([]interface{}, len(op.Columns))
+			vals = make([]interface{}, len(columns))
 		)
 
 		for i := range vals {
@@ -160,15 +189,82 @@ func (s *DatabaseStatements) ExecQuery(
 			return err
 		}
 		for i := range vals {
-			row[op.Columns[i]] = *(vals[i]).(*interface{})
+			row[columns[i]] = *(vals[i]).(*interface{})
 		}
+		rows++
 
 		// Terminate the querying and close the reading cursor.
 		if !cb(row) {
 			break
 		}
 	}
-	return nil
+	return rws.Err()
+}
+
+// ExecStatement executes the statement and returns the number of affected rows.
+func (s *DatabaseStatements) ExecStatement(
+	ctx context.Context, op *activerecord.QueryOperation,
+) (
+	rowsAffected int64, err error,
+) {
+	result, err := ExecContext(ctx, s.Conn, op.Text, op.Args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// ExecExplain returns the plan of the query using "EXPLAIN" statement. Each row of
+// the result is rendered as a line, where columns are separated with "|".
+func (s *DatabaseStatements) ExecExplain(
+	ctx context.Context, op *activerecord.ExplainOperation,
+) (
+	plan string, err error,
+) {
+	var (
+		buf   strings.Builder
+		lines []string
+	)
+	buf.WriteString("EXPLAIN ")
+	for _, option := range op.Options {
+		fmt.Fprintf(&buf, "%s ", option)
+	}
+	buf.WriteString(op.Query.Text)
+
+	done := activerecord.LogQuery(ctx, buf.String(), op.Query.Args)
+	defer func() { done(int64(len(lines)), err) }()
+
+	rws, err := s.Conn.QueryContext(ctx, buf.String(), op.Query.Args...)
+	if err != nil {
+		return "", err
+	}
+
+	defer rws.Close()
+
+	columns, err := rws.Columns()
+	if err != nil {
+		return "", err
+	}
+
+	for rws.Next() {
+		vals := make([]interface{}, len(columns))
+		for i := range vals {
+			vals[i] = new(sql.NullString)
+		}
+		if err = rws.Scan(vals...); err != nil {
+			return "", err
+		}
+
+		cols := make([]string, len(vals))
+		for i := range vals {
+			cols[i] = vals[i].(*sql.NullString).String
+		}
+		lines = append(lines, strings.Join(cols, " | "))
+	}
+	if err = rws.Err(); err != nil {
+		return "", err
+	}
+	return strings.Join(lines, "\n"), nil
 }
 
 type SchemaStatements struct {
@@ -222,23 +318,100 @@ func (s *SchemaStatements) CreateTable(ctx context.Context, table *activerecord.
 	}
 
 	for _, target := range table.ForeignKeys() {
-		fk := fmt.Sprintf("%s_id", strings.TrimSuffix(target, "s"))
+		fk := ForeignKey(target)
 		fmt.Fprintf(&buf, `FOREIGN KEY (%q) REFERENCES "%s" ("id"), `, fk, target)
 	}
 
 	fmt.Fprintf(&buf, `PRIMARY KEY ("%s"))`, primaryKey)
-	_, err := s.Conn.ExecContext(ctx, buf.String())
+	_, err := ExecContext(ctx, s.Conn, buf.String())
 	return err
 }
 
 func (s *SchemaStatements) AddForeignKey(ctx context.Context, owner, target string) error {
 	var buf strings.Builder
 
-	fk := fmt.Sprintf("%s_id", strings.TrimSuffix(target, "s"))
+	fk := ForeignKey(target)
 	fmt.Fprintf(&buf, `ALTER TABLE %q ADD CONSTRAINT fk_%s_on_%s `, owner, owner, target)
 
 	// TODO: id is not necessary a primary key.
 	fmt.Fprintf(&buf, `FOREIGN KEY (%q) REFERENCES %q ("id")"`, fk, target)
-	_, err := s.Conn.ExecContext(ctx, buf.String())
+	_, err := ExecContext(ctx, s.Conn, buf.String())
 	return err
 }
+
+// ConfigurePool applies limits of the connection pool from the configuration to
+// the database handle.
+func ConfigurePool(db *sql.DB, conf activerecord.DatabaseConfig) {
+	if conf.Pool > 0 {
+		db.SetMaxOpenConns(conf.Pool)
+	}
+	if conf.MaxIdle > 0 {
+		db.SetMaxIdleConns(conf.MaxIdle)
+	}
+	if conf.MaxLifetime > 0 {
+		db.SetConnMaxLifetime(conf.MaxLifetime)
+	}
+}
+
+// TxOptions returns options of the database transaction, nil options correspond
+// to the default options of the database.
+func TxOptions(opts *activerecord.TransactionOptions) (*sql.TxOptions, error) {
+	if opts == nil {
+		return nil, nil
+	}
+
+	txOpts := sql.TxOptions{ReadOnly: opts.ReadOnly}
+	switch opts.Isolation {
+	case "":
+		txOpts.Isolation = sql.LevelDefault
+	case activerecord.ReadUncommitted:
+		txOpts.Isolation = sql.LevelReadUncommitted
+	case activerecord.ReadCommitted:
+		txOpts.Isolation = sql.LevelReadCommitted
+	case activerecord.RepeatableRead:
+		txOpts.Isolation = sql.LevelRepeatableRead
+	case activerecord.Serializable:
+		txOpts.Isolation = sql.LevelSerializable
+	default:
+		return nil, fmt.Errorf("unsupported isolation level %q", opts.Isolation)
+	}
+	return &txOpts, nil
+}
+
+// BeginTransactionStmt returns a statement to begin the transaction with the
+// given options.
+func BeginTransactionStmt(opts *activerecord.TransactionOptions) string {
+	stmt := "BEGIN TRANSACTION"
+	if opts == nil {
+		return stmt
+	}
+	if opts.Isolation != "" {
+		stmt += " ISOLATION LEVEL " + string(opts.Isolation)
+	}
+	if opts.ReadOnly {
+		stmt += " READ ONLY"
+	}
+	return stmt
+}
+
+// IsConnectionError returns true when the error is caused by the broken network
+// connection to the database. Network timeouts are not considered as connection
+// errors, since they are caused by deadlines of operations.
+func IsConnectionError(err error) bool {
+	for _, target := range []error{
+		driver.ErrBadConn,
+		io.EOF,
+		io.ErrUnexpectedEOF,
+		syscall.ECONNRESET,
+		syscall.ECONNREFUSED,
+		syscall.ECONNABORTED,
+		syscall.EPIPE,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && !netErr.Timeout()
+}
@@ -1,9 +1,9 @@
 package activerecord
 
 import (
+	"errors"
 	"fmt"
 	"sort"
-	"strings"
 
 	. "github.com/activegraph/activegraph/activesupport"
 )
@@ -29,6 +29,14 @@ type Association interface {
 	// AssociationOwner() *Relation
 	AssociationName() string
 	AssociationForeignKey() string
+
+	// AssociationScope returns the relation of targets of the owner, so queries
+	// of the association could be reused and extended:
+	//
+	//	books := Author.ReflectOnAssociation("books").AssociationScope(author)
+	//	books.Where("year", 1961).ToSQL()
+	//	// SELECT * FROM "books" WHERE (author_id = ?) AND (year = ?)
+	AssociationScope(owner *ActiveRecord) CollectionResult
 }
 
 type SingularAssociation interface {
@@ -86,7 +94,7 @@ func (a *BelongsTo) AssociationName() string {
 }
 
 // ForeignKey sets the foreign key used for the association. By default this is
-// guessed to be the name of this relation in lower-case and "_id" suffixed.
+// guessed to be the singular snake-cased name of the target with "_id" suffix.
 //
 // So a relation that defines a BelongsTo("person") association will use "person_id"
 // as a default foreign key.
@@ -99,7 +107,7 @@ func (a *BelongsTo) AssociationForeignKey() string {
 		return a.foreignKey
 	}
 	// target_id
-	return a.targetName + "_" + defaultPrimaryKeyName
+	return ForeignKey(a.targetName)
 }
 
 // AccessAssociation returns a record of the target.
@@ -129,6 +137,18 @@ func (a *BelongsTo) AccessAssociation(owner *ActiveRecord) RecordResult {
 	return targets.WithContext(owner.Context()).Find(targetId)
 }
 
+// AssociationScope returns the relation of the target referenced by the foreign
+// key of the owner.
+func (a *BelongsTo) AssociationScope(owner *ActiveRecord) CollectionResult {
+	targets, err := a.reflection.Reflection(a.targetName)
+	if err != nil {
+		return ErrCollection(err)
+	}
+
+	targets = targets.WithContext(owner.Context())
+	return OkCollection(targets.Where(targets.PrimaryKey(), owner.Attribute(a.AssociationForeignKey())))
+}
+
 func (a *BelongsTo) AssignAssociation(owner *ActiveRecord, target *ActiveRecord) RecordResult {
 	err := owner.AssignAttribute(a.AssociationForeignKey(), target.ID())
 	if err != nil {
@@ -147,18 +167,64 @@ type HasMany struct {
 	reflection *Reflection
 	targetName string
 	foreignKey string
+	through    string
+}
+
+// Through declares the has-many association through the intermediate association
+// of the owner (see HasMany.Through):
+//
+//	r.HasMany("tags", activerecord.Through("taggings"))
+func Through(assocName string) func(*HasMany) {
+	return func(a *HasMany) {
+		a.Through(assocName)
+	}
 }
 
 func (a *HasMany) AssociationName() string {
 	return a.targetName
 }
 
+// ForeignKey sets the foreign key used for the association. By default this is
+// guessed to be the singular snake-cased name of the owner with "_id" suffix.
+//
+// So a "person" relation that defines a HasMany("posts") association will use
+// "person_id" as a default foreign key of posts.
+func (a *HasMany) ForeignKey(fk string) {
+	a.foreignKey = fk
+}
+
+// Through sets the intermediate association of the owner, so targets are
+// resolved through records of the intermediate association. Records of the
+// intermediate association must belong to the target:
+//
+//	Post := activerecord.New("post", func(r *activerecord.R) {
+//		r.HasMany("taggings")
+//		r.HasMany("tags", activerecord.Through("taggings"))
+//	})
+//	Tagging := activerecord.New("tagging", func(r *activerecord.R) {
+//		r.BelongsTo("post")
+//		r.BelongsTo("tag")
+//	})
+//
+// This association considers the following tables relation:
+//
+//	+----------------+    +-------------------+    +----------------+
+//	|     posts      |    |     taggings      |    |      tags      |
+//	+------+---------+    +---------+---------+    +------+---------+
+//	| id   | integer |<--*| post_id | integer |    | id   | integer |
+//	| name | string  |    | tag_id  | integer |*-->| name | string  |
+//	+------+---------+    +---------+---------+    +------+---------+
+//
+func (a *HasMany) Through(assocName string) {
+	a.through = assocName
+}
+
 func (a *HasMany) AssociationForeignKey() string {
-	// TODO: this is completely wrong.
 	if a.foreignKey != "" {
 		return a.foreignKey
 	}
-	return strings.ToLower(a.owner.Name()) + "_" + defaultPrimaryKeyName
+	// owner_id
+	return ForeignKey(a.owner.Name())
 }
 
 // AccessCollection returns a collection of the target records.
@@ -182,21 +248,70 @@ func (a *HasMany) AssociationForeignKey() string {
 //	                           +----------+---------+
 //
 func (a *HasMany) AccessCollection(owner *ActiveRecord) CollectionResult {
+	return a.AssociationScope(owner)
+}
+
+// AssociationScope returns the relation of targets referencing the owner.
+//
+// Targets of the association through the intermediate association are selected
+// by foreign keys of intermediate records referencing the owner:
+//
+//	Post.ReflectOnAssociation("tags").AssociationScope(post)
+//	// SELECT * FROM "tags" WHERE
+//	//   (id IN (SELECT taggings.tag_id FROM "taggings" WHERE (post_id = ?)))
+func (a *HasMany) AssociationScope(owner *ActiveRecord) CollectionResult {
 	targets, err := a.reflection.Reflection(a.targetName)
 	if err != nil {
-		return CollectionResult{Err[*Relation](err)}
+		return ErrCollection(err)
 	}
-
 	targets = targets.WithContext(owner.Context())
 
-	// TODO: Make "scope" accessable and understandable.
-	targets = targets.Where(a.AssociationForeignKey(), owner.ID())
-	return CollectionResult{Ok(targets)}
+	if a.through == "" {
+		return OkCollection(targets.Where(a.AssociationForeignKey(), owner.ID()))
+	}
+
+	through, source, err := a.reflectOnThrough(owner)
+	if err != nil {
+		return ErrCollection(err)
+	}
+	scope := through.AssociationScope(owner)
+	if scope.IsErr() {
+		return scope
+	}
+	intermediates := scope.Unwrap().Select(source.AssociationForeignKey())
+	return OkCollection(targets.Where(targets.PrimaryKey(), intermediates))
+}
+
+// reflectOnThrough returns the intermediate association of the owner and the
+// association of intermediate records with the target.
+func (a *HasMany) reflectOnThrough(owner *ActiveRecord) (
+	through *AssociationReflection, source *BelongsTo, err error,
+) {
+	through = owner.ReflectOnAssociation(a.through)
+	if through == nil {
+		return nil, nil, ErrUnknownAssociation{RecordName: owner.Name(), Assoc: a.through}
+	}
+
+	sourceRef := through.ReflectOnAssociation(a.targetName)
+	if sourceRef == nil {
+		return nil, nil, ErrUnknownAssociation{RecordName: through.Name(), Assoc: a.targetName}
+	}
+	source, ok := sourceRef.Association.(*BelongsTo)
+	if !ok {
+		return nil, nil, ErrAssociation{Message: fmt.Sprintf(
+			"'%s' of %s is not a belongs-to association", a.targetName, through.Name(),
+		)}
+	}
+	return through, source, nil
 }
 
 func (a *HasMany) AssignCollection(owner *ActiveRecord, targets ...*ActiveRecord) RecordResult {
+	if a.through != "" {
+		return a.assignThrough(owner, targets...)
+	}
+
 	// Perform very naive approach delete existing targets and set new targets.
-	err := owner.Collection(a.targetName + "s").DeleteAll()
+	err := owner.Collection(Pluralize(a.targetName)).DeleteAll()
 	if err != nil {
 		return ErrRecord(err)
 	}
@@ -219,6 +334,33 @@ func (a *HasMany) AssignCollection(owner *ActiveRecord, targets ...*ActiveRecord
 	return OkRecord(owner)
 }
 
+// assignThrough replaces intermediate records of the owner with records
+// referencing the owner and the given targets, targets must be persisted.
+func (a *HasMany) assignThrough(owner *ActiveRecord, targets ...*ActiveRecord) RecordResult {
+	through, source, err := a.reflectOnThrough(owner)
+	if err != nil {
+		return ErrRecord(err)
+	}
+
+	if err = through.AssociationScope(owner).DeleteAll(); err != nil {
+		return ErrRecord(err)
+	}
+
+	for _, target := range targets {
+		rec, err := through.Relation.WithContext(owner.Context()).Initialize(Hash{
+			through.AssociationForeignKey(): owner.ID(),
+			source.AssociationForeignKey():  target.ID(),
+		})
+		if err != nil {
+			return ErrRecord(err)
+		}
+		if _, err = rec.Insert(); err != nil {
+			return ErrRecord(err)
+		}
+	}
+	return OkRecord(owner)
+}
+
 func (a *HasMany) String() string {
 	return fmt.Sprintf("#<Association type: 'has_many', name: '%s'>", a.targetName)
 }
@@ -228,6 +370,8 @@ type HasOne struct {
 	reflection *Reflection
 	targetName string
 	foreignKey string
+	primaryKey string
+	order      []string
 }
 
 func (a *HasOne) AssociationOwner() *Relation {
@@ -238,8 +382,47 @@ func (a *HasOne) AssociationName() string {
 	return a.targetName
 }
 
+// ForeignKey sets the foreign key used for the association. By default this is
+// guessed to be the singular snake-cased name of the owner with "_id" suffix.
+func (a *HasOne) ForeignKey(fk string) {
+	a.foreignKey = fk
+}
+
+// PrimaryKey sets the attribute of the owner referenced by the foreign key of
+// the target. By default this is the primary key of the owner.
+func (a *HasOne) PrimaryKey(pk string) {
+	a.primaryKey = pk
+}
+
+// Order sets the order of targets, so the first target in the order is returned,
+// when the owner is referenced by many targets:
+//
+//	activerecord.New("author", func(r *activerecord.R) {
+//		r.HasOne("book", func(assoc *activerecord.HasOne) {
+//			assoc.Order("published_at DESC")
+//		})
+//	})
+//
+// Without the order, the association returns an error for many targets.
+func (a *HasOne) Order(values ...string) {
+	a.order = append(a.order, values...)
+}
+
 func (a *HasOne) AssociationForeignKey() string {
-	return a.owner.Name() + "_" + defaultPrimaryKeyName
+	if a.foreignKey != "" {
+		return a.foreignKey
+	}
+	// owner_id
+	return ForeignKey(a.owner.Name())
+}
+
+// AssociationPrimaryKey returns the attribute of the owner referenced by the
+// foreign key of the target.
+func (a *HasOne) AssociationPrimaryKey() string {
+	if a.primaryKey != "" {
+		return a.primaryKey
+	}
+	return a.owner.PrimaryKey()
 }
 
 // The association indicates that one model has a reference to this model.
@@ -260,29 +443,42 @@ func (a *HasOne) AssociationForeignKey() string {
 //	                           +----------+---------+
 //
 func (a *HasOne) AccessAssociation(owner *ActiveRecord) RecordResult {
-	// Find target association relation given it's name.
-	targets, err := a.reflection.Reflection(a.targetName)
-	if err != nil {
-		return ErrRecord(err)
+	scope := a.AssociationScope(owner)
+	if scope.IsErr() {
+		return ErrRecord(scope.Err())
 	}
 
-	targets = targets.WithContext(owner.Context())
-	targets = targets.Where(a.AssociationForeignKey(), owner.ID())
-
-	records, err := targets.Limit(2).ToA()
-	if err != nil {
-		return ErrRecord(err)
+	targets := scope.Unwrap()
+	if len(a.order) > 0 {
+		return targets.First()
 	}
-	switch len(records) {
-	case 0:
+
+	target := targets.Sole()
+	switch {
+	case errors.Is(target.Err(), &ErrRecordNotFound{}):
 		return OkRecord(nil)
-	case 1:
-		return OkRecord(records[0])
-	default:
+	case errors.Is(target.Err(), &ErrSoleRecordExceeded{}):
 		return ErrRecord(ErrAssociation{
-			fmt.Sprintf("declared 'has_one' association, but has many: %s", records),
+			fmt.Sprintf("declared 'has_one' association, but has many %s", targets.Name()),
 		})
+	default:
+		return target
+	}
+}
+
+// AssociationScope returns the relation of the target referencing the owner.
+func (a *HasOne) AssociationScope(owner *ActiveRecord) CollectionResult {
+	targets, err := a.reflection.Reflection(a.targetName)
+	if err != nil {
+		return ErrCollection(err)
+	}
+
+	targets = targets.WithContext(owner.Context())
+	targets = targets.Where(a.AssociationForeignKey(), owner.Attribute(a.AssociationPrimaryKey()))
+	if len(a.order) > 0 {
+		targets = targets.Order(a.order...)
 	}
+	return OkCollection(targets)
 }
 
 func (a *HasOne) AssignAssociation(owner *ActiveRecord, target *ActiveRecord) RecordResult {
@@ -299,7 +495,7 @@ func (a *HasOne) AssignAssociation(owner *ActiveRecord, target *ActiveRecord) Re
 	}
 
 	// Put a reference of the owner (owner_id) to the target record.
-	err = target.AssignAttribute(a.AssociationForeignKey(), owner.ID())
+	err = target.AssignAttribute(a.AssociationForeignKey(), owner.Attribute(a.AssociationPrimaryKey()))
 	if err != nil {
 		return ErrRecord(err)
 	}
@@ -492,7 +688,7 @@ func (a *associations) Collection(collName string) CollectionResult {
 	if err != nil {
 		return ErrCollection(err)
 	}
-	return CollectionResult{ca.AccessCollection(a.rec)}
+	return ca.AccessCollection(a.rec)
 }
 
 func (a *associations) AccessCollection(collName string) (*Relation, error) {
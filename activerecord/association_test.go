@@ -143,3 +143,234 @@ func TestActiveRecord_BelongsTo_AssignAssociation(t *testing.T) {
 	target.Expect("failed to update owner of the target")
 	t.Log(target)
 }
+
+func TestActiveRecord_HasMany_InflectedNames(t *testing.T) {
+	EstablishConnection(DatabaseConfig{
+		Adapter: "sqlite3", Database: t.Name(),
+	})
+
+	defer os.Remove(t.Name())
+	defer RemoveConnection("primary")
+
+	Migrate(t.Name(), func(m *M) {
+		m.CreateTable("categories", func(t *Table) { t.String("name") })
+		m.CreateTable("people", func(t *Table) { t.String("name"); t.References("categories") })
+	})
+
+	Category := New("category", func(r *R) { r.HasMany("people") })
+	Person := New("person", func(r *R) { r.BelongsTo("category") })
+
+	require.Equal(t, "categories", Category.TableName())
+	require.Equal(t, "people", Person.TableName())
+
+	hasMany := Category.ReflectOnAssociation("people")
+	require.NotNil(t, hasMany)
+	require.Equal(t, "person", hasMany.AssociationName())
+	require.Equal(t, "category_id", hasMany.AssociationForeignKey())
+
+	belongsTo := Person.ReflectOnAssociation("category")
+	require.NotNil(t, belongsTo)
+	require.Equal(t, "category_id", belongsTo.AssociationForeignKey())
+
+	category := Category.Create(Hash{"name": "Writers"})
+	person := Person.Create(Hash{"name": "Borges", "category_id": category.Unwrap().ID()})
+	person.Expect("failed to create a person")
+
+	people, err := category.Collection("people").ToA()
+	require.NoError(t, err)
+	require.Len(t, people, 1)
+}
+
+func TestActiveRecord_HasMany_ForeignKey(t *testing.T) {
+	EstablishConnection(DatabaseConfig{
+		Adapter: "sqlite3", Database: t.Name(),
+	})
+
+	defer os.Remove(t.Name())
+	defer RemoveConnection("primary")
+
+	Migrate(t.Name(), func(m *M) {
+		m.CreateTable("writers", func(t *Table) { t.String("name") })
+		m.CreateTable("books", func(t *Table) { t.String("title"); t.Int64("author_id") })
+	})
+
+	Writer := New("writer", func(r *R) {
+		r.HasMany("books", func(assoc *HasMany) { assoc.ForeignKey("author_id") })
+	})
+	Book := New("book")
+
+	// The resolved foreign key is exposed by the reflection.
+	hasMany := Writer.ReflectOnAssociation("books")
+	require.NotNil(t, hasMany)
+	require.Equal(t, "author_id", hasMany.AssociationForeignKey())
+
+	writer := Writer.Create(Hash{"name": "Lem"})
+	writer.Expect("failed to create a writer")
+
+	book := Book.Create(Hash{"title": "Solaris", "author_id": writer.Unwrap().ID()})
+	book.Expect("failed to create a book")
+
+	books, err := writer.Collection("books").ToA()
+	require.NoError(t, err)
+	require.Len(t, books, 1)
+}
+
+func TestActiveRecord_AssociationScope(t *testing.T) {
+	EstablishConnection(DatabaseConfig{
+		Adapter: "sqlite3", Database: t.Name(),
+	})
+
+	defer os.Remove(t.Name())
+	defer RemoveConnection("primary")
+
+	Migrate(t.Name(), func(m *M) {
+		m.CreateTable("owners", func(t *Table) { t.String("name") })
+		m.CreateTable("targets", func(t *Table) { t.Int64("value"); t.References("owners") })
+	})
+
+	Owner := New("owner", func(r *R) { r.HasMany("targets") })
+	Target := New("target", func(r *R) { r.BelongsTo("owner") })
+
+	owner := Owner.Create(Hash{"name": "Taleb"}).Unwrap()
+	other := Owner.Create(Hash{"name": "Kaneman"}).Unwrap()
+	for _, value := range []int{1, 2, 3} {
+		Target.Create(Hash{"value": value, "owner_id": owner.ID()}).Expect("failed to create target")
+	}
+	target := Target.Create(Hash{"value": 4, "owner_id": other.ID()}).Unwrap()
+
+	hasMany := Owner.ReflectOnAssociation("targets")
+	require.NotNil(t, hasMany)
+
+	scope := hasMany.AssociationScope(owner)
+	require.NoError(t, scope.Err())
+	require.Contains(t, scope.Unwrap().ToSQL(), "owner_id")
+
+	// Scope of the association is extended with further conditions.
+	targets, err := scope.Unwrap().Where("value", GreaterThan(1)).ToA()
+	require.NoError(t, err)
+	require.Len(t, targets, 2)
+
+	belongsTo := Target.ReflectOnAssociation("owner")
+	require.NotNil(t, belongsTo)
+
+	owners, err := belongsTo.AssociationScope(target).ToA()
+	require.NoError(t, err)
+	require.Len(t, owners, 1)
+	require.Equal(t, other.ID(), owners[0].ID())
+}
+
+func TestActiveRecord_HasOne_Options(t *testing.T) {
+	EstablishConnection(DatabaseConfig{
+		Adapter: "sqlite3", Database: t.Name(),
+	})
+
+	defer os.Remove(t.Name())
+	defer RemoveConnection("primary")
+
+	Migrate(t.Name(), func(m *M) {
+		m.CreateTable("owners", func(t *Table) { t.String("name"); t.String("code") })
+		m.CreateTable("targets", func(t *Table) { t.Int64("value"); t.String("owner_code") })
+	})
+
+	Owner := New("owner", func(r *R) {
+		r.HasOne("target", func(assoc *HasOne) {
+			assoc.ForeignKey("owner_code")
+			assoc.PrimaryKey("code")
+			assoc.Order("value DESC")
+		})
+	})
+	Target := New("target")
+
+	hasOne := Owner.ReflectOnAssociation("target")
+	require.NotNil(t, hasOne)
+	require.Equal(t, "owner_code", hasOne.AssociationForeignKey())
+	require.Equal(t, "code", hasOne.Association.(*HasOne).AssociationPrimaryKey())
+
+	owner := Owner.Create(Hash{"name": "Taleb", "code": "nnt"})
+	owner.Expect("failed to create an owner")
+
+	// Without targets, the association is empty.
+	target := owner.Association("target")
+	require.NoError(t, target.Err())
+	require.Nil(t, target.Unwrap())
+
+	owner = owner.AssignAssociation("target", Target.New(Hash{"value": 1}))
+	owner.Expect("failed to assign target to the owner")
+	Target.Create(Hash{"value": 3, "owner_code": "nnt"}).Expect("failed to create target")
+	Target.Create(Hash{"value": 2, "owner_code": "nnt"}).Expect("failed to create target")
+
+	// Many targets are ordered, so the first one is returned.
+	target = owner.Association("target")
+	require.NoError(t, target.Err())
+	require.EqualValues(t, 3, target.Unwrap().Attribute("value"))
+	require.Equal(t, "nnt", target.Unwrap().Attribute("owner_code"))
+}
+
+func TestActiveRecord_HasMany_Through(t *testing.T) {
+	EstablishConnection(DatabaseConfig{
+		Adapter: "sqlite3", Database: t.Name(),
+	})
+
+	defer os.Remove(t.Name())
+	defer RemoveConnection("primary")
+
+	Migrate(t.Name(), func(m *M) {
+		m.CreateTable("posts", func(t *Table) { t.String("title") })
+		m.CreateTable("tags", func(t *Table) { t.String("name") })
+		m.CreateTable("taggings", func(t *Table) { t.References("posts"); t.References("tags") })
+		m.CreateTable("brokens", func(t *Table) { t.String("name") })
+	})
+
+	Post := New("post", func(r *R) {
+		r.HasMany("taggings")
+		r.HasMany("tags", Through("taggings"))
+	})
+	Tag := New("tag")
+	Tagging := New("tagging", func(r *R) {
+		r.BelongsTo("post")
+		r.BelongsTo("tag")
+	})
+
+	post := Post.Create(Hash{"title": "Solaris"})
+	other := Post.Create(Hash{"title": "Eden"}).Unwrap()
+
+	scifi := Tag.Create(Hash{"name": "sci-fi"})
+	classic := Tag.Create(Hash{"name": "classic"})
+	novel := Tag.Create(Hash{"name": "novel"}).Unwrap()
+
+	post = post.AssignCollection("tags", scifi, classic)
+	post.Expect("failed to assign tags to the post")
+	Tagging.Create(Hash{"post_id": other.ID(), "tag_id": novel.ID()}).Expect("failed to tag other post")
+
+	tags, err := post.Collection("tags").Unwrap().Order("name").ToA()
+	require.NoError(t, err)
+	require.Len(t, tags, 2)
+	require.Equal(t, "classic", tags[0].Attribute("name"))
+	require.Equal(t, "sci-fi", tags[1].Attribute("name"))
+
+	scope := Post.ReflectOnAssociation("tags").AssociationScope(post.Unwrap())
+	require.Equal(t,
+		`SELECT * FROM "tags" WHERE `+
+			`(id IN (SELECT taggings.tag_id FROM "taggings" WHERE (post_id = ?)))`,
+		scope.Unwrap().ToSQL(),
+	)
+
+	// Reassignment replaces intermediate records of the post.
+	post = post.AssignCollection("tags", OkRecord(novel))
+	post.Expect("failed to reassign tags of the post")
+
+	count, err := Tagging.Count()
+	require.NoError(t, err)
+	require.Equal(t, int64(2), count)
+
+	tags, err = post.Collection("tags").ToA()
+	require.NoError(t, err)
+	require.Len(t, tags, 1)
+	require.Equal(t, novel.ID(), tags[0].ID())
+
+	Broken := New("broken", func(r *R) { r.HasMany("tags", Through("missing")) })
+	broken, err := Broken.Initialize(Hash{"id": 1})
+	require.NoError(t, err)
+	require.Equal(t, ErrUnknownAssociation{RecordName: "broken", Assoc: "missing"},
+		broken.Collection("tags").Err())
+}
@@ -31,7 +31,7 @@ type AttributeAccessors interface {
 	Attribute(attrName string) interface{}
 	// AccessAttribute(attrName string) interface{}
 	AssignAttribute(attrName string, val interface{}) error
-	AssignAttributes(newAttributes map[string]interface{}) error
+	AssignAttributes(newAttributes map[string]interface{}, permitted ...Permitted) error
 }
 
 // PrimaryKey makes any specified attribute a primary key.
@@ -44,6 +44,26 @@ func (p PrimaryKey) PrimaryKey() bool {
 	return true
 }
 
+// virtualAttribute must implement attributes, which are not stored in the
+// database, e.g. passwords stored as digests. Virtual attributes are assigned
+// and accessed as other attributes, but they are not selected, inserted or
+// serialized.
+type virtualAttribute interface {
+	Attribute
+
+	// assignAttribute assigns the value of the virtual attribute and values
+	// of attributes derived from it.
+	assignAttribute(a *attributes, val interface{}) error
+}
+
+// computedAttribute is the virtual attribute, which value is computed from
+// values of other attributes instead of being kept.
+type computedAttribute interface {
+	virtualAttribute
+
+	accessAttribute(a *attributes) interface{}
+}
+
 type attr struct {
 	Name string
 	Type Type
@@ -87,23 +107,40 @@ func (m attributesMap) copy() attributesMap {
 // attributes of the ActiveRecord.
 type attributes struct {
 	recordName string
+	tableName  string
 	primaryKey Attribute
 	keys       attributesMap
 	values     activesupport.Hash
+
+	virtuals      map[string]virtualAttribute
+	virtualValues activesupport.Hash
+
+	// original are values of attributes stored in the database, savedChanges
+	// are changes of attributes stored by the last save (see dirty.go).
+	original     activesupport.Hash
+	savedChanges map[string]AttributeChange
 }
 
 func (a *attributes) copy() *attributes {
 	return &attributes{
-		recordName: a.recordName,
-		primaryKey: a.primaryKey,
-		keys:       a.keys.copy(),
-		values:     a.values.Copy(),
+		recordName:    a.recordName,
+		tableName:     a.tableName,
+		primaryKey:    a.primaryKey,
+		keys:          a.keys.copy(),
+		values:        a.values.Copy(),
+		virtuals:      a.virtuals,
+		virtualValues: a.virtualValues.Copy(),
+		original:      a.original.Copy(),
+		savedChanges:  a.savedChanges,
 	}
 }
 
 func (a *attributes) clear() *attributes {
 	newa := a.copy()
 	newa.values = make(activesupport.Hash, len(a.keys))
+	newa.virtualValues = nil
+	newa.original = nil
+	newa.savedChanges = nil
 	return newa
 }
 
@@ -194,9 +231,14 @@ func (a *attributes) AttributeNames() []string {
 }
 
 func (a *attributes) ColumnNames() []string {
+	tableName := a.tableName
+	if tableName == "" {
+		tableName = activesupport.Pluralize(a.recordName)
+	}
+
 	names := make([]string, 0, len(a.keys))
 	for name := range a.keys {
-		names = append(names, a.recordName+"s."+name)
+		names = append(names, tableName+"."+name)
 	}
 	sort.StringSlice(names).Sort()
 	return names
@@ -222,6 +264,9 @@ func (a *attributes) HasAttributes(attrNames ...string) bool {
 //
 // Method return an error when value does not pass validation of the attribute.
 func (a *attributes) AssignAttribute(attrName string, val interface{}) error {
+	if v, ok := a.virtuals[attrName]; ok {
+		return v.assignAttribute(a, val)
+	}
 	if !a.HasAttribute(attrName) {
 		return &ErrUnknownAttribute{RecordName: a.recordName, Attr: attrName}
 	}
@@ -237,17 +282,31 @@ func (a *attributes) AssignAttribute(attrName string, val interface{}) error {
 	return nil
 }
 
+// assignVirtualAttribute sets the value of the virtual attribute.
+func (a *attributes) assignVirtualAttribute(attrName string, val interface{}) {
+	if a.virtualValues == nil {
+		a.virtualValues = make(activesupport.Hash)
+	}
+	a.virtualValues[attrName] = val
+}
+
 // AssignAttributes allows to set all the attributes by passing in a map of attributes
-// with keys matching attributet names.
+// with keys matching attributet names. When permitted attributes are given, other
+// attributes are not assigned and ErrUnpermittedAttributes is returned.
 //
 // The method either assigns all provided attributes, no attributes are assigned
 // in case of error.
-func (a *attributes) AssignAttributes(newAttributes map[string]interface{}) error {
+func (a *attributes) AssignAttributes(newAttributes map[string]interface{}, permitted ...Permitted) error {
+	if err := permitAttributes(a.recordName, newAttributes, permitted); err != nil {
+		return err
+	}
+
 	// Create a copy of attributes, either update all attributes or
 	// return the object in the previous state.
 	var (
-		keys   = a.keys.copy()
-		values = a.values.Copy()
+		keys          = a.keys.copy()
+		values        = a.values.Copy()
+		virtualValues = a.virtualValues.Copy()
 	)
 
 	for attrName, val := range newAttributes {
@@ -256,6 +315,7 @@ func (a *attributes) AssignAttributes(newAttributes map[string]interface{}) erro
 			// Return the original state of the attributes.
 			a.keys = keys
 			a.values = values
+			a.virtualValues = virtualValues
 			return err
 		}
 	}
@@ -264,6 +324,12 @@ func (a *attributes) AssignAttributes(newAttributes map[string]interface{}) erro
 
 // AccessAttribute returns the value of the attribute identified by attrName.
 func (a *attributes) AccessAttribute(attrName string) (val interface{}) {
+	if v, ok := a.virtuals[attrName]; ok {
+		if c, ok := v.(computedAttribute); ok {
+			return c.accessAttribute(a)
+		}
+		return a.virtualValues[attrName]
+	}
 	if !a.HasAttribute(attrName) {
 		return nil
 	}
@@ -278,6 +344,9 @@ func (a *attributes) Attribute(attrName string) (val interface{}) {
 // AttributePresent returns true if the specified attribute has been set by the user
 // or by a database and is not nil, otherwise false.
 func (a *attributes) AttributePresent(attrName string) bool {
+	if _, ok := a.virtuals[attrName]; ok {
+		return a.AccessAttribute(attrName) != nil
+	}
 	if !a.HasAttribute(attrName) {
 		return false
 	}
@@ -0,0 +1,319 @@
+package activerecord
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	. "github.com/activegraph/activegraph/activesupport"
+)
+
+// VersionsTableName is the name of the table of versions recorded by the
+// audit trail (see HasAuditTrail).
+const VersionsTableName = "versions"
+
+// CreateVersionsTable creates the table of versions within the migration:
+//
+//	activerecord.Migrate("20220101000000", activerecord.CreateVersionsTable)
+func CreateVersionsTable(m *M) {
+	m.CreateTable(VersionsTableName, func(t *Table) {
+		t.String("item_type")
+		t.String("item_id")
+		t.String("event")
+		t.String("actor")
+		t.String("object")
+		t.String("object_changes")
+		t.DateTime("created_at")
+	})
+}
+
+// VersionEvent is the event of the record, which created the version.
+type VersionEvent string
+
+const (
+	VersionCreate  VersionEvent = "create"
+	VersionUpdate  VersionEvent = "update"
+	VersionDestroy VersionEvent = "destroy"
+)
+
+type auditActorKey struct{}
+
+// WithAuditActor returns the context, which attributes versions recorded by the
+// audit trail to the actor, e.g. the identifier of the current user.
+func WithAuditActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, auditActorKey{}, actor)
+}
+
+func auditActorFromContext(ctx context.Context) string {
+	actor, _ := ctx.Value(auditActorKey{}).(string)
+	return actor
+}
+
+// Version is the version of the record recorded by the audit trail.
+type Version struct {
+	ID       int64
+	ItemType string
+	ItemID   string
+	Event    VersionEvent
+	Actor    string
+
+	// Object are attributes of the record before the event, it is nil for
+	// versions of created records.
+	Object Hash
+
+	// Changes are changes of attributes made by the event, it is nil for
+	// versions of destroyed records.
+	Changes map[string]AttributeChange
+
+	CreatedAt time.Time
+}
+
+// HasAuditTrail declares the audit trail of records, so each create, update
+// and destroy of the record records the version into the table of versions
+// (see CreateVersionsTable):
+//
+//	Book := activerecord.New("book", func(r *activerecord.R) {
+//		r.HasAuditTrail()
+//	})
+//
+//	ctx = activerecord.WithAuditActor(ctx, "admin")
+//	book := Book.WithContext(ctx).Create(Hash{"title": "Solaris"})
+//	versions, err := book.Unwrap().Versions()
+//
+// Versions are recorded with the connection of the record, so versions are
+// rolled back together with the transaction of the record. Updates without
+// changes of attributes do not record versions.
+func (r *R) HasAuditTrail() {
+	r.AfterSave(func(rec *ActiveRecord) error {
+		changes := rec.SavedChanges()
+		if len(changes) == 0 {
+			return nil
+		}
+
+		event := VersionUpdate
+		if change, ok := changes[rec.attributes.PrimaryKey()]; ok && change.From == nil {
+			event = VersionCreate
+		}
+
+		var object Hash
+		if event == VersionUpdate {
+			object = rec.attributes.values.Copy()
+			for attrName, change := range changes {
+				object[attrName] = change.From
+			}
+		}
+		return rec.insertVersion(event, object, changes)
+	})
+
+	r.AfterDestroy(func(rec *ActiveRecord) error {
+		return rec.insertVersion(VersionDestroy, rec.attributes.values.Copy(), nil)
+	})
+}
+
+// insertVersion inserts the version of the record into the table of versions.
+func (r *ActiveRecord) insertVersion(
+	event VersionEvent, object Hash, changes map[string]AttributeChange,
+) error {
+	columnValues := []ColumnValue{
+		{Name: "item_type", Type: new(String), Value: r.name},
+		{Name: "item_id", Type: new(String), Value: fmt.Sprint(r.ID())},
+		{Name: "event", Type: new(String), Value: string(event)},
+		{Name: "actor", Type: new(String), Value: auditActorFromContext(r.Context())},
+		{Name: "created_at", Type: new(DateTime), Value: time.Now().UTC()},
+	}
+
+	// Absent object and changes are not inserted, so they are stored as NULL.
+	if object != nil {
+		values := make(map[string]interface{}, len(object))
+		for attrName, value := range object {
+			values[attrName] = serialize(r.attributes.keys[attrName].AttributeType(), value)
+		}
+		b, err := json.Marshal(values)
+		if err != nil {
+			return err
+		}
+		columnValues = append(columnValues, ColumnValue{Name: "object", Type: new(String), Value: string(b)})
+	}
+	if changes != nil {
+		values := make(map[string][2]interface{}, len(changes))
+		for attrName, change := range changes {
+			t := r.attributes.keys[attrName].AttributeType()
+			values[attrName] = [2]interface{}{serialize(t, change.From), serialize(t, change.To)}
+		}
+		b, err := json.Marshal(values)
+		if err != nil {
+			return err
+		}
+		columnValues = append(columnValues, ColumnValue{
+			Name: "object_changes", Type: new(String), Value: string(b),
+		})
+	}
+
+	op := InsertOperation{TableName: VersionsTableName, PrimaryKey: "id", ColumnValues: columnValues}
+	_, err := r.Connection().ExecInsert(r.Context(), &op)
+	return err
+}
+
+// Versions returns versions of the record recorded by the audit trail in the
+// order of their creation.
+func (r *ActiveRecord) Versions() ([]*Version, error) {
+	var (
+		versions []*Version
+		lasterr  error
+	)
+
+	conn := r.Connection()
+	op := QueryOperation{
+		Text: fmt.Sprintf(
+			"SELECT * FROM %s WHERE item_type = ? AND item_id = ? ORDER BY id",
+			DialectOf(conn).QuoteIdentifier(VersionsTableName),
+		),
+		Args: []interface{}{r.name, fmt.Sprint(r.ID())},
+	}
+	err := conn.ExecQuery(r.Context(), &op, func(row Hash) bool {
+		var version *Version
+		if version, lasterr = r.decodeVersion(row); lasterr != nil {
+			return false
+		}
+		versions = append(versions, version)
+		return true
+	})
+	if lasterr != nil {
+		return nil, lasterr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// decodeVersion returns the version of the row of the table of versions.
+func (r *ActiveRecord) decodeVersion(row Hash) (*Version, error) {
+	var (
+		version Version
+		values  = make(Hash, len(row))
+	)
+	for _, column := range []struct {
+		name string
+		t    Type
+	}{
+		{"id", new(Int64)},
+		{"item_type", new(String)},
+		{"item_id", new(String)},
+		{"event", new(String)},
+		{"actor", new(String)},
+		{"object", new(String)},
+		{"object_changes", new(String)},
+		{"created_at", new(DateTime)},
+	} {
+		if row[column.name] == nil {
+			continue
+		}
+		value, err := column.t.Deserialize(row[column.name])
+		if err != nil {
+			return nil, fmt.Errorf("column %q of %s: %w", column.name, VersionsTableName, err)
+		}
+		values[column.name] = value
+	}
+
+	version.ID, _ = values["id"].(int64)
+	version.ItemType, _ = values["item_type"].(string)
+	version.ItemID, _ = values["item_id"].(string)
+	version.Actor, _ = values["actor"].(string)
+	version.CreatedAt, _ = values["created_at"].(time.Time)
+	event, _ := values["event"].(string)
+	version.Event = VersionEvent(event)
+
+	if object, ok := values["object"].(string); ok {
+		var raws map[string]json.RawMessage
+		if err := json.Unmarshal([]byte(object), &raws); err != nil {
+			return nil, err
+		}
+		version.Object = make(Hash, len(raws))
+		for attrName, raw := range raws {
+			value, ok, err := r.decodeVersionValue(attrName, raw)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				version.Object[attrName] = value
+			}
+		}
+	}
+
+	if changes, ok := values["object_changes"].(string); ok {
+		var raws map[string][2]json.RawMessage
+		if err := json.Unmarshal([]byte(changes), &raws); err != nil {
+			return nil, err
+		}
+		version.Changes = make(map[string]AttributeChange, len(raws))
+		for attrName, raw := range raws {
+			from, ok, err := r.decodeVersionValue(attrName, raw[0])
+			if err != nil {
+				return nil, err
+			}
+			to, _, err := r.decodeVersionValue(attrName, raw[1])
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				version.Changes[attrName] = AttributeChange{From: from, To: to}
+			}
+		}
+	}
+	return &version, nil
+}
+
+// decodeVersionValue decodes the JSON value of the attribute, attributes removed
+// from the record since the version was recorded are skipped.
+func (r *ActiveRecord) decodeVersionValue(attrName string, raw json.RawMessage) (
+	value interface{}, ok bool, err error,
+) {
+	attr, ok := r.attributes.keys[attrName]
+	if !ok {
+		return nil, false, nil
+	}
+	if value, err = decodeJSONValue(attr.AttributeType(), raw); err != nil || value == nil {
+		return nil, true, err
+	}
+	if value, err = attr.AttributeType().Deserialize(value); err != nil {
+		return nil, false, fmt.Errorf("attribute %q of %s: %w", attrName, r.name, err)
+	}
+	return value, true, nil
+}
+
+// RevertTo reverts the record to the state before the event of the version:
+// versions of updates restore attributes of the record, versions of destroys
+// insert the destroyed record back, versions of creates delete the record.
+//
+//	versions, _ := book.Versions()
+//	book, err := book.RevertTo(versions[len(versions)-1])
+//
+// The revert is saved as a regular update, so it records a new version as well.
+func (r *ActiveRecord) RevertTo(version *Version) (*ActiveRecord, error) {
+	if version.ItemType != r.name || version.ItemID != fmt.Sprint(r.ID()) {
+		return nil, ErrArgument{Message: fmt.Sprintf(
+			"version %d does not belong to %s %v", version.ID, r.name, r.ID(),
+		)}
+	}
+
+	switch version.Event {
+	case VersionCreate:
+		return r.Delete()
+	case VersionUpdate, VersionDestroy:
+		reverted := r.Copy()
+		for attrName, value := range version.Object {
+			if err := reverted.attributes.AssignAttribute(attrName, value); err != nil {
+				return nil, err
+			}
+		}
+		if version.Event == VersionDestroy {
+			reverted.attributes.original = nil
+			return reverted.Insert()
+		}
+		return reverted.Update()
+	default:
+		return nil, ErrArgument{Message: fmt.Sprintf("unknown event %q of version %d", version.Event, version.ID)}
+	}
+}
@@ -0,0 +1,45 @@
+package activerecord
+
+// FindInBatches calls the function with records of the relation in batches of
+// the given size (DefaultBatchSize, when the size is not positive). Batches are
+// retrieved in the order of the primary key, so all records are processed
+// without loading them into the memory at once:
+//
+//	err := User.Where("active", true).FindInBatches(500, func(users activerecord.Array) error {
+//		return mailer.Deliver(users)
+//	})
+//
+// The order and the limit of the relation are ignored, the iteration stops on
+// the first error returned by the function.
+func (rel *Relation) FindInBatches(batchSize int, fn func(Array) error) error {
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+
+	var (
+		primaryKey = rel.PrimaryKey()
+		batches    = rel.Unscope(OrderClause, LimitClause).Order(primaryKey).Limit(batchSize)
+		last       interface{}
+	)
+	for {
+		batch := batches
+		if last != nil {
+			batch = batches.Where(primaryKey, GreaterThan(last))
+		}
+
+		records, err := batch.ToA()
+		if err != nil {
+			return err
+		}
+		if len(records) == 0 {
+			return nil
+		}
+		if err = fn(records); err != nil {
+			return err
+		}
+		if len(records) < batchSize {
+			return nil
+		}
+		last = records[len(records)-1].ID()
+	}
+}
@@ -0,0 +1,171 @@
+package activerecord
+
+import (
+	"fmt"
+	"sort"
+
+	. "github.com/activegraph/activegraph/activesupport"
+)
+
+// ErrBinaryRecord is returned, when the binary encoding does not represent
+// records of the relation.
+type ErrBinaryRecord struct {
+	RecordName string
+	Message    string
+}
+
+func (e *ErrBinaryRecord) Is(target error) bool {
+	_, ok := target.(*ErrBinaryRecord)
+	return ok
+}
+
+func (e *ErrBinaryRecord) Error() string {
+	if e.RecordName == "" {
+		return fmt.Sprintf("invalid binary encoding of record: %s", e.Message)
+	}
+	return fmt.Sprintf("invalid binary encoding of %s: %s", e.RecordName, e.Message)
+}
+
+// binaryHash returns the hash with attributes of the record and loaded singular
+// associations, which is encoded in MessagePack format.
+func (r *ActiveRecord) binaryHash() (Hash, error) {
+	h := Hash{"attributes": r.ToHash()}
+	if len(r.associations.values) == 0 {
+		return h, nil
+	}
+
+	assocNames := make([]string, 0, len(r.associations.values))
+	for assocName := range r.associations.values {
+		assocNames = append(assocNames, assocName)
+	}
+	sort.Strings(assocNames)
+
+	assocs := make(Hash, len(assocNames))
+	for _, assocName := range assocNames {
+		target := r.associations.values[assocName]
+		if target == nil {
+			assocs[assocName] = nil
+			continue
+		}
+		th, err := target.binaryHash()
+		if err != nil {
+			return nil, err
+		}
+		assocs[assocName] = th
+	}
+	h["associations"] = assocs
+	return h, nil
+}
+
+// MarshalBinary returns the compact MessagePack encoding of attributes of the
+// record and associations loaded with Joins, so the record could be stored in
+// caches or sent to other services:
+//
+//	b, err := book.MarshalBinary()
+//	book = Book.UnmarshalRecord(b).Unwrap()
+func (r *ActiveRecord) MarshalBinary() ([]byte, error) {
+	h, err := r.binaryHash()
+	if err != nil {
+		return nil, err
+	}
+	return appendMsgpack(nil, h)
+}
+
+// MarshalBinary returns the MessagePack array of records of the relation
+// encoded the same way as by ActiveRecord.MarshalBinary.
+func (rel *Relation) MarshalBinary() ([]byte, error) {
+	records, err := rel.ToA()
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]interface{}, 0, len(records))
+	for _, rec := range records {
+		h, err := rec.binaryHash()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, h)
+	}
+	return appendMsgpack(nil, values)
+}
+
+// unmarshalRecord returns the record of the relation decoded from the hash.
+func (rel *Relation) unmarshalRecord(value interface{}) (*ActiveRecord, error) {
+	h, ok := value.(Hash)
+	if !ok {
+		return nil, &ErrBinaryRecord{RecordName: rel.name, Message: "record is not a map"}
+	}
+	attrs, ok := h["attributes"].(Hash)
+	if !ok {
+		return nil, &ErrBinaryRecord{RecordName: rel.name, Message: "attributes are missing"}
+	}
+
+	rec, err := rel.Initialize(attrs)
+	if err != nil {
+		return nil, err
+	}
+
+	assocs, _ := h["associations"].(Hash)
+	for assocName, value := range assocs {
+		assoc, err := rec.associations.find(assocName)
+		if err != nil {
+			return nil, err
+		}
+		if value == nil {
+			rec.associations.set(assocName, nil)
+			continue
+		}
+
+		targets, err := rec.associations.reflection.Reflection(assoc.AssociationName())
+		if err != nil {
+			return nil, err
+		}
+		target, err := targets.unmarshalRecord(value)
+		if err != nil {
+			return nil, err
+		}
+		rec.associations.set(assocName, target)
+	}
+	return rec, nil
+}
+
+// UnmarshalRecord returns the record of the relation decoded from the encoding
+// returned by ActiveRecord.MarshalBinary.
+func (rel *Relation) UnmarshalRecord(b []byte) RecordResult {
+	value, rest, err := readMsgpack(b)
+	if err != nil {
+		return ErrRecord(err)
+	}
+	if len(rest) > 0 {
+		return ErrRecord(&ErrBinaryRecord{RecordName: rel.name, Message: "trailing data"})
+	}
+	return ReturnRecord(rel.unmarshalRecord(value))
+}
+
+// UnmarshalRecords returns records of the relation decoded from the encoding
+// returned by Relation.MarshalBinary.
+func (rel *Relation) UnmarshalRecords(b []byte) (Array, error) {
+	value, rest, err := readMsgpack(b)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, &ErrBinaryRecord{RecordName: rel.name, Message: "trailing data"}
+	}
+
+	values, ok := value.([]interface{})
+	if !ok {
+		return nil, &ErrBinaryRecord{RecordName: rel.name, Message: "records are not an array"}
+	}
+
+	records := make(Array, 0, len(values))
+	for _, value := range values {
+		rec, err := rel.unmarshalRecord(value)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
@@ -0,0 +1,69 @@
+package activerecord_test
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/activegraph/activegraph/activerecord"
+	_ "github.com/activegraph/activegraph/activerecord/sqlite3"
+	. "github.com/activegraph/activegraph/activesupport"
+)
+
+func TestActiveRecord_MarshalBinary(t *testing.T) {
+	_, err := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter: "sqlite3", Database: t.Name() + ".db",
+	})
+	require.NoError(t, err)
+
+	defer os.Remove(t.Name() + ".db")
+	defer activerecord.RemoveConnection("primary")
+
+	initAuthorTable(t, nil)
+	initBookTable(t, nil)
+
+	Author := activerecord.New("author")
+	Book := activerecord.New("book", func(r *activerecord.R) {
+		r.BelongsTo("author")
+	})
+
+	author := Author.Create(Hash{"name": "Stanislaw Lem"}).Unwrap()
+	_, err = Book.InsertAll(
+		Hash{"title": "Solaris", "year": int64(1961), "author_id": author.ID()},
+		Hash{"title": "Eden", "year": int64(1959), "author_id": author.ID()},
+	)
+	require.NoError(t, err)
+
+	books, err := Book.Joins("author").ToA()
+	require.NoError(t, err)
+
+	b, err := books[0].MarshalBinary()
+	require.NoError(t, err)
+
+	book := Book.UnmarshalRecord(b).Unwrap()
+	require.Equal(t, books[0].ToHash(), book.ToHash())
+
+	// Loaded associations are decoded together with the record.
+	activerecord.RemoveConnection("primary")
+	bookAuthor, err := book.AccessAssociation("author")
+	require.NoError(t, err)
+	require.Equal(t, author.ToHash(), bookAuthor.ToHash())
+
+	_, err = activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter: "sqlite3", Database: t.Name() + ".db",
+	})
+	require.NoError(t, err)
+
+	b, err = Book.Order("id").MarshalBinary()
+	require.NoError(t, err)
+
+	decoded, err := Book.UnmarshalRecords(b)
+	require.NoError(t, err)
+	require.Len(t, decoded, 2)
+	require.Equal(t, books[1].ToHash(), decoded[1].ToHash())
+
+	err = Author.UnmarshalRecord(b).Err()
+	require.True(t, errors.Is(err, new(activerecord.ErrBinaryRecord)), err)
+}
@@ -0,0 +1,358 @@
+package activerecord
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	. "github.com/activegraph/activegraph/activesupport"
+)
+
+// cacheVersionFormat is the format of the cache version, the time of the last
+// update with nanoseconds precision.
+const cacheVersionFormat = "20060102150405.000000000"
+
+// CacheKey returns the stable key of the record in caches, which is built of
+// the table name and the primary key of the record:
+//
+//	book.CacheKey() // "books/1"
+//
+// Records without the primary key are keyed as "books/new".
+func (r *ActiveRecord) CacheKey() string {
+	return cacheKey(r.tableName, r.ID())
+}
+
+func cacheKey(tableName string, id interface{}) string {
+	if id == nil {
+		return tableName + "/new"
+	}
+	return fmt.Sprintf("%s/%v", tableName, id)
+}
+
+// CacheVersion returns the version of the record in caches, which is the time
+// of the last update of the record stored in the "updated_at" attribute. The
+// version is empty, when the record does not have the attribute.
+func (r *ActiveRecord) CacheVersion() string {
+	if !r.HasAttribute("updated_at") {
+		return ""
+	}
+	switch updatedAt := r.Attribute("updated_at").(type) {
+	case nil:
+		return ""
+	case time.Time:
+		return updatedAt.UTC().Format(cacheVersionFormat)
+	default:
+		return fmt.Sprint(updatedAt)
+	}
+}
+
+// CacheKeyWithVersion returns the cache key of the record followed by the cache
+// version, so the key changes each time the record is updated:
+//
+//	book.CacheKeyWithVersion() // "books/1-20230101120000.000000000"
+func (r *ActiveRecord) CacheKeyWithVersion() string {
+	if version := r.CacheVersion(); version != "" {
+		return r.CacheKey() + "-" + version
+	}
+	return r.CacheKey()
+}
+
+// EncodeRecord returns the binary encoding of the record together with its name,
+// so the record is decoded by DecodeRecord without the relation.
+func EncodeRecord(r *ActiveRecord) ([]byte, error) {
+	h, err := r.binaryHash()
+	if err != nil {
+		return nil, err
+	}
+	return appendMsgpack(nil, Hash{"name": r.Name(), "record": h})
+}
+
+// DecodeRecord returns the record decoded from the encoding returned by
+// EncodeRecord, the record is initialized by the relation of the encoded name.
+func DecodeRecord(b []byte) RecordResult {
+	value, rest, err := readMsgpack(b)
+	if err != nil {
+		return ErrRecord(err)
+	}
+
+	h, ok := value.(Hash)
+	if !ok || len(rest) > 0 {
+		return ErrRecord(&ErrBinaryRecord{Message: "record is not a map"})
+	}
+	name, ok := h["name"].(string)
+	if !ok {
+		return ErrRecord(&ErrBinaryRecord{Message: "name is missing"})
+	}
+
+	rel, err := globalReflection.Reflection(name)
+	if err != nil {
+		return ErrRecord(err)
+	}
+	return ReturnRecord(rel.unmarshalRecord(h["record"]))
+}
+
+// CacheStore stores encoded records, e.g. in Redis or memcached. Entries are
+// expired by the store after the given duration, zero duration never expires.
+type CacheStore interface {
+	// Read returns the value of the entry and false when the entry is missing.
+	Read(key string) ([]byte, bool, error)
+	Write(key string, value []byte, expiresIn time.Duration) error
+	Delete(key string) error
+}
+
+// cacheOptions are options of fetching from the cache.
+type cacheOptions struct {
+	expiresIn time.Duration
+}
+
+// CacheOption configures entries written into the cache.
+type CacheOption func(*cacheOptions)
+
+// ExpiresIn expires the cached entry after the given duration.
+func ExpiresIn(d time.Duration) CacheOption {
+	return func(o *cacheOptions) {
+		o.expiresIn = d
+	}
+}
+
+// FetchCached returns the record cached in the store with the key. When the
+// record is missing, it is computed by the function and written into the store,
+// failed results are not cached:
+//
+//	book := activerecord.FetchCached(store, "books/1", func() activerecord.RecordResult {
+//		return Book.Find(1)
+//	}, activerecord.ExpiresIn(time.Hour))
+//
+// Entries, which cannot be decoded, e.g. due to changed attributes of the relation,
+// are treated as missing ones.
+func FetchCached(store CacheStore, key string, fn func() RecordResult, opts ...CacheOption) RecordResult {
+	var o cacheOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	b, ok, err := store.Read(key)
+	if err != nil {
+		return ErrRecord(err)
+	}
+	if ok {
+		if rec := DecodeRecord(b); rec.IsOk() {
+			return rec
+		}
+	}
+
+	result := fn()
+	rec, err := result.Ok().UnwrapOr(nil), result.Err()
+	if err != nil || rec == nil {
+		return result
+	}
+
+	if b, err = EncodeRecord(rec); err != nil {
+		return ErrRecord(err)
+	}
+	if err = store.Write(key, b, o.expiresIn); err != nil {
+		return ErrRecord(err)
+	}
+	return result
+}
+
+// cacheEntry is the entry of the memory cache store.
+type cacheEntry struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+// MemoryCacheStore is the cache store keeping entries in the memory of the
+// process, it is intended for development and tests.
+//
+// MemoryCacheStore is safe for concurrent use.
+type MemoryCacheStore struct {
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+// NewMemoryCacheStore returns a new empty memory cache store.
+func NewMemoryCacheStore() *MemoryCacheStore {
+	return &MemoryCacheStore{entries: make(map[string]cacheEntry)}
+}
+
+// Read returns the value of the entry, expired entries are missing.
+func (s *MemoryCacheStore) Read(key string) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	if !entry.expiresAt.IsZero() && !time.Now().Before(entry.expiresAt) {
+		delete(s.entries, key)
+		return nil, false, nil
+	}
+	return entry.value, true, nil
+}
+
+// Write writes the entry into the store.
+func (s *MemoryCacheStore) Write(key string, value []byte, expiresIn time.Duration) error {
+	entry := cacheEntry{value: append([]byte(nil), value...)}
+	if expiresIn > 0 {
+		entry.expiresAt = time.Now().Add(expiresIn)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[key] = entry
+	return nil
+}
+
+// Delete removes the entry from the store.
+func (s *MemoryCacheStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+	return nil
+}
+
+// RecordCache caches records of relations by cache keys of records.
+type RecordCache interface {
+	// ReadRecord returns the cached record, or None, when the record is missing.
+	ReadRecord(key string) RecordResult
+	WriteRecord(r *ActiveRecord) error
+	DeleteRecord(key string) error
+}
+
+// storeRecordCache is the record cache of encoded records in the cache store.
+type storeRecordCache struct {
+	store CacheStore
+	opts  cacheOptions
+}
+
+// NewRecordCache returns the write-through cache of records encoded in the store.
+// Records are written into the cache each time they are saved, see R.CacheRecords.
+func NewRecordCache(store CacheStore, opts ...CacheOption) RecordCache {
+	c := &storeRecordCache{store: store}
+	for _, opt := range opts {
+		opt(&c.opts)
+	}
+	return c
+}
+
+// ReadRecord returns the record decoded from the store, entries, which cannot
+// be decoded, are treated as missing ones.
+func (c *storeRecordCache) ReadRecord(key string) RecordResult {
+	b, ok, err := c.store.Read(key)
+	if err != nil {
+		return ErrRecord(err)
+	}
+	if !ok {
+		return OkRecord(nil)
+	}
+	if rec := DecodeRecord(b); rec.IsOk() {
+		return rec
+	}
+	return OkRecord(nil)
+}
+
+// WriteRecord writes the record into the store under the cache key of the record.
+func (c *storeRecordCache) WriteRecord(r *ActiveRecord) error {
+	b, err := EncodeRecord(r)
+	if err != nil {
+		return err
+	}
+	return c.store.Write(r.CacheKey(), b, c.opts.expiresIn)
+}
+
+// DeleteRecord removes the record from the store.
+func (c *storeRecordCache) DeleteRecord(key string) error {
+	return c.store.Delete(key)
+}
+
+// CacheRecords caches records of the relation found by CachedFind in the cache.
+// Records are written into the cache after save and removed from the cache after
+// destroy, once the transaction of the record is committed, so the cache is kept
+// consistent with the database:
+//
+//	cache := activerecord.NewRecordCache(store, activerecord.ExpiresIn(time.Hour))
+//
+//	Book := activerecord.New("book", func(r *activerecord.R) {
+//		r.CacheRecords(cache)
+//	})
+//
+// The cache is updated after the commit, so errors of the cache could not be
+// returned by operations of records, they are reported to subscribers of
+// CacheEvent.
+func (r *R) CacheRecords(cache RecordCache) {
+	r.cache = cache
+	r.AfterSave(func(rec *ActiveRecord) error {
+		rec = rec.Copy()
+		rec.connections.afterCommit(rec.Context(), rec.spec, func() {
+			syncCache(rec.name, "write", rec.CacheKey(), func() error {
+				return cache.WriteRecord(rec)
+			})
+		})
+		return nil
+	})
+	r.AfterDestroy(func(rec *ActiveRecord) error {
+		key := rec.CacheKey()
+		rec.connections.afterCommit(rec.Context(), rec.spec, func() {
+			syncCache(rec.name, "delete", key, func() error {
+				return cache.DeleteRecord(key)
+			})
+		})
+		return nil
+	})
+}
+
+// syncCache calls the function updating the cache instrumented as CacheEvent.
+func syncCache(recordName, operation, key string, fn func() error) error {
+	payload := Hash{"record": recordName, "operation": operation, "key": key}
+	return Instrument(CacheEvent, payload, fn)
+}
+
+// cacheable returns true, when the relation has the cache and records of the
+// relation are found by primary keys alone. Relations with conditions, joins,
+// selected attributes or default scopes, e.g. the tenant scope, could exclude
+// cached records, so they find records in the database.
+func (rel *Relation) cacheable() bool {
+	if rel.cache == nil || rel.none || (!rel.unscoped && len(rel.defaultScopes) > 0) {
+		return false
+	}
+	q := rel.query
+	return len(q.whereValues) == 0 && len(q.joinValues) == 0 &&
+		len(q.selectValues) == 0 && q.source == ""
+}
+
+// CachedFind returns the record with the primary key from the record cache of
+// the relation (see R.CacheRecords). Missing records are found in the database
+// and written into the cache. Relations without the cache, and relations with
+// conditions or default scopes always find records in the database.
+//
+//	book := Book.CachedFind(ctx, 1)
+func (rel *Relation) CachedFind(ctx context.Context, id interface{}) RecordResult {
+	rel = rel.WithContext(ctx)
+	if !rel.cacheable() {
+		return rel.Find(id)
+	}
+
+	cached := rel.cache.ReadRecord(cacheKey(rel.tableName, id))
+	if cached.IsErr() {
+		return cached
+	}
+	if rec := cached.Ok().UnwrapOr(nil); rec != nil {
+		return OkRecord(rec.WithContext(ctx))
+	}
+
+	result := rel.Find(id)
+	if rec := result.Ok().UnwrapOr(nil); result.IsOk() && rec != nil {
+		// Records found within the transaction are cached after the commit,
+		// since they could be changed by the transaction.
+		rec = rec.Copy()
+		rel.connections.afterCommit(ctx, rel.spec, func() {
+			syncCache(rel.name, "write", rec.CacheKey(), func() error {
+				return rel.cache.WriteRecord(rec)
+			})
+		})
+	}
+	return result
+}
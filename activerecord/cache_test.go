@@ -0,0 +1,174 @@
+package activerecord_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/activegraph/activegraph/activerecord"
+	_ "github.com/activegraph/activegraph/activerecord/sqlite3"
+	. "github.com/activegraph/activegraph/activesupport"
+)
+
+func TestActiveRecord_CacheKey(t *testing.T) {
+	_, err := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter: "sqlite3", Database: t.Name() + ".db",
+	})
+	require.NoError(t, err)
+
+	defer os.Remove(t.Name() + ".db")
+	defer activerecord.RemoveConnection("primary")
+
+	activerecord.Migrate(t.Name()+"_add_articles_table", func(m *activerecord.M) {
+		m.CreateTable("articles", func(t *activerecord.Table) {
+			t.String("title")
+			t.DateTime("updated_at")
+		})
+	})
+
+	Article := activerecord.New("article")
+
+	article := Article.New().Unwrap()
+	require.Equal(t, "articles/new", article.CacheKey())
+	require.Equal(t, "", article.CacheVersion())
+
+	updatedAt := time.Date(2023, 1, 1, 12, 0, 0, 5, time.UTC)
+	article = Article.Create(Hash{"title": "Solaris", "updated_at": updatedAt}).Unwrap()
+	article = Article.Find(article.ID()).Unwrap()
+
+	require.Equal(t, "articles/1", article.CacheKey())
+	require.Equal(t, "20230101120000.000000005", article.CacheVersion())
+	require.Equal(t, "articles/1-20230101120000.000000005", article.CacheKeyWithVersion())
+
+	var (
+		store = activerecord.NewMemoryCacheStore()
+		calls int
+	)
+	find := func() activerecord.RecordResult {
+		calls++
+		return Article.Find(article.ID())
+	}
+
+	cached := activerecord.FetchCached(store, article.CacheKeyWithVersion(), find).Unwrap()
+	require.Equal(t, article.ToHash(), cached.ToHash())
+
+	cached = activerecord.FetchCached(store, article.CacheKeyWithVersion(), find).Unwrap()
+	require.Equal(t, article.ToHash(), cached.ToHash())
+	require.Equal(t, 1, calls)
+
+	// Failed results are not cached.
+	missing := func() activerecord.RecordResult {
+		calls++
+		return Article.Find(42)
+	}
+	require.Error(t, activerecord.FetchCached(store, "articles/42", missing).Err())
+	require.Error(t, activerecord.FetchCached(store, "articles/42", missing).Err())
+	require.Equal(t, 3, calls)
+
+	// Expired entries are computed again.
+	activerecord.FetchCached(store, "articles/1", find, activerecord.ExpiresIn(time.Nanosecond))
+	time.Sleep(time.Millisecond)
+	activerecord.FetchCached(store, "articles/1", find)
+	require.Equal(t, 5, calls)
+
+	b, err := activerecord.EncodeRecord(article)
+	require.NoError(t, err)
+	require.Equal(t, article.ToHash(), activerecord.DecodeRecord(b).Unwrap().ToHash())
+
+	err = activerecord.DecodeRecord([]byte{0x01}).Err()
+	require.True(t, errors.Is(err, new(activerecord.ErrBinaryRecord)), err)
+}
+
+func TestRelation_CachedFind(t *testing.T) {
+	_, err := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter: "sqlite3", Database: t.Name() + ".db",
+	})
+	require.NoError(t, err)
+
+	defer os.Remove(t.Name() + ".db")
+	defer activerecord.RemoveConnection("primary")
+
+	activerecord.Migrate(t.Name()+"_add_articles_table", func(m *activerecord.M) {
+		m.CreateTable("articles", func(t *activerecord.Table) {
+			t.String("title")
+		})
+	})
+
+	store := activerecord.NewMemoryCacheStore()
+	Article := activerecord.New("article", func(r *activerecord.R) {
+		r.CacheRecords(activerecord.NewRecordCache(store))
+	})
+
+	article := Article.Create(Hash{"title": "Solaris"}).Unwrap()
+	_, ok, err := store.Read("articles/1")
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	article.AssignAttribute("title", "Eden")
+	article = activerecord.OkRecord(article).Update().Unwrap()
+
+	// Changes of rolled back transactions are not written into the cache.
+	err = activerecord.Transaction(context.Background(), func(tx context.Context) error {
+		article := article.WithContext(tx)
+		require.NoError(t, article.AssignAttribute("title", "Solaris"))
+		if _, err := article.Update(); err != nil {
+			return err
+		}
+		return errors.New("rollback")
+	})
+	require.Error(t, err)
+	require.Equal(t, "Eden", Article.CachedFind(context.Background(), 1).Unwrap().Attribute("title"))
+
+	// The table is changed bypassing callbacks, so the record is still read
+	// from the cache.
+	_, err = Article.Connection().ExecStatement(context.Background(), &activerecord.QueryOperation{
+		Text: `UPDATE articles SET title = 'Fiasco'`,
+	})
+	require.NoError(t, err)
+
+	cached := Article.CachedFind(context.Background(), 1).Unwrap()
+	require.Equal(t, "Eden", cached.Attribute("title"))
+
+	require.NoError(t, store.Delete("articles/1"))
+	cached = Article.CachedFind(context.Background(), 1).Unwrap()
+	require.Equal(t, "Fiasco", cached.Attribute("title"))
+
+	_, ok, _ = store.Read("articles/1")
+	require.True(t, ok)
+
+	// Relations with conditions and default scopes find records in the database,
+	// since cached records could be excluded by them.
+	err = Article.Where("title", "Eden").CachedFind(context.Background(), 1).Err()
+	require.True(t, errors.Is(err, new(activerecord.ErrRecordNotFound)), err)
+
+	DraftArticle := activerecord.New("draft_article", func(r *activerecord.R) {
+		r.TableName("articles")
+		r.CacheRecords(activerecord.NewRecordCache(store))
+		r.DefaultScope(func(rel *activerecord.Relation) *activerecord.Relation {
+			return rel.Where("title", "Draft")
+		})
+	})
+	err = DraftArticle.CachedFind(context.Background(), 1).Err()
+	require.True(t, errors.Is(err, new(activerecord.ErrRecordNotFound)), err)
+	require.Equal(t, "Fiasco", DraftArticle.Unscoped().CachedFind(context.Background(), 1).Unwrap().Attribute("title"))
+
+	// Records are removed from the cache after the commit of the transaction.
+	err = activerecord.Transaction(context.Background(), func(tx context.Context) error {
+		if _, err := cached.WithContext(tx).Delete(); err != nil {
+			return err
+		}
+		_, ok, _ = store.Read("articles/1")
+		require.True(t, ok)
+		return nil
+	})
+	require.NoError(t, err)
+	_, ok, _ = store.Read("articles/1")
+	require.False(t, ok)
+
+	err = Article.CachedFind(context.Background(), 1).Err()
+	require.True(t, errors.Is(err, new(activerecord.ErrRecordNotFound)))
+}
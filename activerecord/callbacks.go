@@ -0,0 +1,81 @@
+package activerecord
+
+import (
+	. "github.com/activegraph/activegraph/activesupport"
+)
+
+// Callback is a function called on the life cycle event of the record, e.g.
+// after the record is saved. Errors of callbacks are returned by operations.
+type Callback func(*ActiveRecord) error
+
+// callbacks are callbacks of life cycle events of records.
+type callbacks struct {
+	beforeCreate  []Callback
+	beforeUpdate  []Callback
+	beforeDestroy []Callback
+	afterSave     []Callback
+	afterDestroy  []Callback
+}
+
+func (c callbacks) copy() callbacks {
+	return callbacks{
+		beforeCreate:  append([]Callback(nil), c.beforeCreate...),
+		beforeUpdate:  append([]Callback(nil), c.beforeUpdate...),
+		beforeDestroy: append([]Callback(nil), c.beforeDestroy...),
+		afterSave:     append([]Callback(nil), c.afterSave...),
+		afterDestroy:  append([]Callback(nil), c.afterDestroy...),
+	}
+}
+
+// run calls callbacks in order of declaration until the first error, callbacks
+// are instrumented as CallbackEvent with the given name.
+func (c callbacks) run(rec *ActiveRecord, name string, cbs []Callback) error {
+	if len(cbs) == 0 {
+		return nil
+	}
+	payload := Hash{"record": rec.Name(), "callback": name, "key": rec.CacheKey()}
+	return Instrument(CallbackEvent, payload, func() error {
+		for _, cb := range cbs {
+			if err := cb(rec); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// BeforeCreate appends the callback called after the validation of the new record
+// right before it is inserted, so the callback could assign attributes of the
+// record.
+func (r *R) BeforeCreate(cb Callback) {
+	r.callbacks.beforeCreate = append(r.callbacks.beforeCreate, cb)
+}
+
+// BeforeUpdate appends the callback called after the validation of the record
+// right before it is updated, errors of the callback cancel the update.
+func (r *R) BeforeUpdate(cb Callback) {
+	r.callbacks.beforeUpdate = append(r.callbacks.beforeUpdate, cb)
+}
+
+// BeforeDestroy appends the callback called right before the record is deleted,
+// errors of the callback cancel the delete.
+func (r *R) BeforeDestroy(cb Callback) {
+	r.callbacks.beforeDestroy = append(r.callbacks.beforeDestroy, cb)
+}
+
+// AfterSave appends the callback called after the record is inserted or updated.
+//
+//	Book := activerecord.New("book", func(r *activerecord.R) {
+//		r.AfterSave(func(book *activerecord.ActiveRecord) error {
+//			log.Printf("saved %s", book.CacheKey())
+//			return nil
+//		})
+//	})
+func (r *R) AfterSave(cb Callback) {
+	r.callbacks.afterSave = append(r.callbacks.afterSave, cb)
+}
+
+// AfterDestroy appends the callback called after the record is deleted.
+func (r *R) AfterDestroy(cb Callback) {
+	r.callbacks.afterDestroy = append(r.callbacks.afterDestroy, cb)
+}
@@ -0,0 +1,82 @@
+package activerecord
+
+// Capabilities describe features of the database supported by the connection.
+// Relations consult capabilities of the connection before building queries, so
+// relations could be used with databases, which are not relational, e.g. with
+// document stores.
+type Capabilities struct {
+	// Joins is true when the database joins tables. Otherwise associations of
+	// Joins are loaded with a separate query by primary keys, therefore
+	// conditions of the relation could not reference joined tables.
+	Joins bool
+
+	// Subqueries is true when the database selects from subqueries. Otherwise
+	// limited and grouped relations are counted by loading rows of the relation.
+	Subqueries bool
+
+	// DropColumn is true when the database removes columns with "ALTER TABLE"
+	// statement. Otherwise migrations rebuild the table without the column.
+	DropColumn bool
+
+	// AlterConstraints is true when the database adds and drops constraints of
+	// tables with "ALTER TABLE" statement. Otherwise migrations rebuild the
+	// table with changed constraints.
+	AlterConstraints bool
+
+	// TransactionalDDL is true when schema statements are executed within
+	// transactions. Otherwise migrations are applied without transactions.
+	TransactionalDDL bool
+
+	// MaterializedViews is true when the database stores results of views,
+	// which are refreshed on demand. Otherwise materialized views fail to be
+	// created with ErrNotSupported.
+	MaterializedViews bool
+}
+
+// DefaultCapabilities are capabilities of SQL databases, they are used for
+// connections, which do not implement ConnectionCapabilities interface.
+var DefaultCapabilities = Capabilities{
+	Joins:             true,
+	Subqueries:        true,
+	DropColumn:        true,
+	AlterConstraints:  true,
+	TransactionalDDL:  true,
+	MaterializedViews: true,
+}
+
+// ConnectionCapabilities is implemented by connections to databases, which do
+// not support all features of SQL databases:
+//
+//	func (c *Conn) Capabilities() activerecord.Capabilities {
+//		return activerecord.Capabilities{Joins: false, Subqueries: false}
+//	}
+type ConnectionCapabilities interface {
+	Capabilities() Capabilities
+}
+
+// CapabilitiesOf returns capabilities of the connection.
+func CapabilitiesOf(conn Conn) Capabilities {
+	if c, ok := adapterConn(conn).(ConnectionCapabilities); ok {
+		return c.Capabilities()
+	}
+	return DefaultCapabilities
+}
+
+// adapterConn returns the connection of the adapter wrapped by the connection
+// pool, transaction, query cache or reading role connections.
+func adapterConn(conn Conn) Conn {
+	for {
+		switch c := conn.(type) {
+		case *ConnectionPool:
+			conn = c.current()
+		case *pooledTx:
+			conn = c.Conn
+		case *cachedConn:
+			conn = c.Conn
+		case *readingConn:
+			conn = c.Conn
+		default:
+			return conn
+		}
+	}
+}
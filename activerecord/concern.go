@@ -0,0 +1,28 @@
+package activerecord
+
+// Concern is a reusable part of relation declarations. Concerns contribute
+// attributes, associations, default scopes, validations and callbacks to the
+// relations including them, so behavior shared by many relations is declared
+// once:
+//
+//	var SoftDeletable activerecord.Concern = func(r *activerecord.R) {
+//		r.DefineAttribute("deleted_at", activerecord.Nil{new(activerecord.DateTime)})
+//		r.DefaultScope(func(rel *activerecord.Relation) *activerecord.Relation {
+//			return rel.Where("deleted_at", nil)
+//		})
+//	}
+//
+//	Article := activerecord.New("article", func(r *activerecord.R) {
+//		r.Include(SoftDeletable, Auditable)
+//	})
+type Concern func(*R)
+
+// Include applies concerns to the relation declaration in the given order.
+// Concerns are applied at the point of inclusion, so declarations following
+// Include override the ones of concerns, e.g. attributes with the same name.
+// Concerns could include other concerns.
+func (r *R) Include(concerns ...Concern) {
+	for _, concern := range concerns {
+		concern(r)
+	}
+}
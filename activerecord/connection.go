@@ -2,9 +2,13 @@ package activerecord
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
 	"sync"
+	"time"
 
+	"github.com/activegraph/activegraph/activesupport"
 	"github.com/activegraph/activegraph/internal"
 )
 
@@ -34,9 +38,22 @@ func (e *ErrAdapterNotFound) Error() string {
 // is not established.
 type ErrConnectionNotEstablished struct {
 	Name string
+	Err  error
+}
+
+func (e *ErrConnectionNotEstablished) Is(target error) bool {
+	_, ok := target.(*ErrConnectionNotEstablished)
+	return ok
+}
+
+func (e *ErrConnectionNotEstablished) Unwrap() error {
+	return e.Err
 }
 
 func (e *ErrConnectionNotEstablished) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("connection %q has not been established: %s", e.Name, e.Err)
+	}
 	return fmt.Sprintf("connection %q has not been established", e.Name)
 }
 
@@ -47,6 +64,43 @@ type DatabaseConfig struct {
 	Username string
 	Password string
 	Database string
+	// Role is the role of the connection, replicas of the database are
+	// established with the same name and the Reading role. Default is Writing.
+	Role ConnectionRole
+	// Shard is the name of the database shard, shards of the database are
+	// established with the same name and different shard names.
+	Shard string
+
+	// Pool is the maximum number of open connections, zero means unlimited.
+	Pool int
+	// MaxIdle is the maximum number of idle connections kept by the adapter.
+	MaxIdle int
+	// MaxLifetime is the maximum time a connection may be reused.
+	MaxLifetime time.Duration
+	// CheckoutTimeout is the time to wait for an available connection, when
+	// all connections of the pool are in use. Default is 5 seconds.
+	CheckoutTimeout time.Duration
+	// StatementTimeout is the default maximum duration of database statements,
+	// zero means statements are not limited.
+	StatementTimeout time.Duration
+	// Retry is the policy of retries of idempotent operations failed with
+	// transient errors, operations are not retried by default.
+	Retry RetryPolicy
+	// VerifyInterval is the interval of connection verifications, zero means
+	// the connection is verified only after connection failures.
+	VerifyInterval time.Duration
+	// MaxLease is the maximum time a connection may be checked out from the
+	// pool, longer checkouts are reported as leaks (see OnConnectionLeak).
+	// Zero means checkouts are not tracked.
+	MaxLease time.Duration
+	// SchemaCache is the path of the schema cache dumped with SchemaCache.DumpFile,
+	// the cache is loaded, when the connection is established, so models are
+	// defined without the introspection of the database. Missing file is ignored.
+	SchemaCache string
+
+	// Options are adapter-specific parameters of the connection, like "sslmode"
+	// of PostgreSQL connections.
+	Options map[string]string
 }
 
 type ConnectionAdapter func(DatabaseConfig) (Conn, error)
@@ -56,7 +110,7 @@ type ConnectionAdapter func(DatabaseConfig) (Conn, error)
 type connectionHandler struct {
 	adapters map[string]ConnectionAdapter
 	conns    map[string]Conn
-	tx       map[uint64]Conn
+	tx       map[string]*transactionState
 	mu       sync.RWMutex
 }
 
@@ -64,7 +118,7 @@ func newConnectionHandler() *connectionHandler {
 	return &connectionHandler{
 		adapters: make(map[string]ConnectionAdapter),
 		conns:    make(map[string]Conn),
-		tx:       make(map[uint64]Conn),
+		tx:       make(map[string]*transactionState),
 	}
 }
 
@@ -82,40 +136,153 @@ func (h *connectionHandler) ConnectionSpecificationName(name string) string {
 	return fmt.Sprintf("%s/%d", name, internal.GoroutineID())
 }
 
-func (h *connectionHandler) Transaction(ctx context.Context, fn func() error) error {
-	conn, err := h.RetrieveConnection(primaryConnectionName)
-	if err != nil {
+func (h *connectionHandler) Transaction(
+	ctx context.Context, fn func(tx context.Context) error, options ...TransactionOption,
+) (err error) {
+	config := transactionConfig{attempts: 1}
+	for _, option := range options {
+		option(&config)
+	}
+
+	for attempt := 1; ; attempt++ {
+		payload := activesupport.Hash{
+			"attempt":   attempt,
+			"isolation": string(config.Isolation),
+			"read_only": config.ReadOnly,
+		}
+		err = activesupport.Instrument(TransactionEvent, payload, func() error {
+			return h.transaction(ctx, fn, &config)
+		})
+		if attempt >= config.attempts || !errors.Is(err, new(ErrSerializationFailure)) {
+			return err
+		}
+		if config.onRetry != nil {
+			config.onRetry(attempt, err)
+		}
+	}
+}
+
+func (h *connectionHandler) transaction(
+	ctx context.Context, fn func(tx context.Context) error, config *transactionConfig,
+) (err error) {
+	spec := connectionSpecFromContext(ctx)
+	writingKey := spec.key(Writing)
+	specName := h.ConnectionSpecificationName(writingKey)
+
+	outer := transactionStateFromContext(ctx, writingKey)
+	h.mu.RLock()
+	prev := h.tx[specName]
+	h.mu.RUnlock()
+	if outer == nil {
+		outer = prev
+	}
+
+	// Nested transactions are joined to the outer transaction, unless the
+	// outer transaction is not joinable, then nested transactions are
+	// executed within savepoints.
+	if outer != nil && outer.joinable {
+		return fn(ctx)
+	}
+
+	var conn Conn
+	if outer != nil {
+		conn = outer.conn
+	} else if conn, err = h.RetrieveConnection(writingKey); err != nil {
 		return err
 	}
 
-	conn, err = conn.BeginTransaction(ctx)
+	conn, err = conn.BeginTransaction(ctx, &config.TransactionOptions)
 	if err != nil {
 		return err
 	}
 
+	// Callbacks of the committed transaction are called after the transaction
+	// is removed from the handler, so they use the regular connection.
+	var committed []func()
+	defer func() {
+		for _, fn := range committed {
+			fn()
+		}
+	}()
+
 	// Return the connection back to the pool. From that moment, all database
 	// operations for this connection will be finished with an error.
 	defer conn.Close()
 
-	goroutineID := internal.GoroutineID()
+	state := &transactionState{conn: conn, joinable: !config.notJoinable}
+	tx := context.WithValue(ctx, transactionKey{writingKey}, state)
+
 	h.mu.Lock()
-	h.tx[goroutineID] = conn
+	h.tx[specName] = state
 	h.mu.Unlock()
 
 	defer func() {
+		state.finish()
+
 		h.mu.Lock()
 		defer h.mu.Unlock()
-		delete(h.tx, goroutineID)
+		if prev != nil {
+			h.tx[specName] = prev
+		} else {
+			delete(h.tx, specName)
+		}
 	}()
 
-	if err = fn(); err != nil {
+	// Operations of the transaction are executed by the primary connection,
+	// therefore queries after the transaction must read from the primary.
+	sessionFromContext(ctx).recordWrite()
+
+	completed := false
+	defer func() {
+		if p := recover(); p != nil {
+			conn.RollbackTransaction(ctx)
+			panic(p)
+		}
+		// The block is terminated with runtime.Goexit, e.g. by t.FailNow.
+		if !completed {
+			conn.RollbackTransaction(ctx)
+		}
+	}()
+
+	err = fn(tx)
+	completed = true
+
+	if err != nil {
 		if e := conn.RollbackTransaction(ctx); e != nil {
 			err = fmt.Errorf("%s: %w", e.Error(), err)
 		}
 		return err
 	}
 
-	return conn.CommitTransaction(ctx)
+	if err = conn.CommitTransaction(ctx); err != nil {
+		return err
+	}
+
+	// The savepoint is committed only with the outer transaction.
+	if outer != nil {
+		outer.addAfterCommit(state.takeAfterCommit()...)
+	} else {
+		committed = state.takeAfterCommit()
+	}
+	return nil
+}
+
+// afterCommit calls the function after the commit of the transaction of the
+// context, the function is not called when the transaction is rolled back.
+// Without the transaction, the function is called immediately.
+func (h *connectionHandler) afterCommit(ctx context.Context, spec connectionSpec, fn func()) {
+	spec = spec.merge(connectionSpecFromContext(ctx))
+	writingKey := spec.key(Writing)
+
+	state := transactionStateFromContext(ctx, writingKey)
+	if state == nil {
+		h.mu.RLock()
+		state = h.tx[h.ConnectionSpecificationName(writingKey)]
+		h.mu.RUnlock()
+	}
+	if state == nil || !state.addAfterCommit(fn) {
+		fn()
+	}
 }
 
 func (h *connectionHandler) EstablishConnection(c DatabaseConfig) (Conn, error) {
@@ -124,33 +291,67 @@ func (h *connectionHandler) EstablishConnection(c DatabaseConfig) (Conn, error)
 		return nil, &ErrAdapterNotFound{Adapter: c.Adapter}
 	}
 
-	conn, err := newConnection(c)
-	if err != nil {
-		return nil, err
-	}
-
 	if c.Name == "" {
 		c.Name = primaryConnectionName
 	}
 
+	conn, err := newConnection(c)
+	if err != nil {
+		return nil, &ErrConnectionNotEstablished{Name: c.Name, Err: err}
+	}
+
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
-	if _, dup := h.conns[c.Name]; dup {
-		return nil, fmt.Errorf("connection %q already established", c.Name)
+	key := connectionKey(c.Name, c.Shard, c.Role)
+	if _, dup := h.conns[key]; dup {
+		conn.Close()
+		return nil, fmt.Errorf("connection %q already established", key)
 	}
 
-	h.conns[c.Name] = conn
-	return conn, nil
+	pool := newConnectionPool(conn, c, newConnection)
+	if c.SchemaCache != "" {
+		err := pool.schemaCache.LoadFile(c.SchemaCache)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			conn.Close()
+			return nil, &ErrConnectionNotEstablished{Name: c.Name, Err: err}
+		}
+	}
+	h.conns[key] = pool
+	return pool, nil
+}
+
+func (h *connectionHandler) RetrieveConnectionPool(
+	name string, options ...ConnectionOption,
+) (*ConnectionPool, error) {
+	spec := connectionSpec{database: name}
+	for _, option := range options {
+		option(&spec)
+	}
+
+	key := spec.key(spec.role)
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	conn, ok := h.conns[key]
+	if !ok {
+		return nil, &ErrConnectionNotEstablished{Name: key}
+	}
+	pool, ok := conn.(*ConnectionPool)
+	if !ok {
+		return nil, &ErrConnectionNotEstablished{Name: key}
+	}
+	return pool, nil
 }
 
 func (h *connectionHandler) RetrieveConnection(name string) (Conn, error) {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
 
-	tx, ok := h.tx[internal.GoroutineID()]
+	tx, ok := h.tx[h.ConnectionSpecificationName(name)]
 	if ok {
-		return tx, nil
+		return tx.conn, nil
 	}
 
 	conn, ok := h.conns[name]
@@ -170,7 +371,288 @@ func (h *connectionHandler) RemoveConnection(name string) error {
 	}
 
 	delete(h.conns, name)
-	return conn.Close()
+	err := conn.Close()
+
+	// Remove connections of other roles and shards established for the
+	// same database.
+	for key, other := range h.conns {
+		if pool, ok := other.(*ConnectionPool); ok && pool.Name() == name {
+			delete(h.conns, key)
+			if e := pool.Close(); err == nil {
+				err = e
+			}
+		}
+	}
+	return err
+}
+
+// resolve returns the connection matching the specification. The specification
+// of the relation is overridden by the specification stored in the context.
+//
+// Within a reading role, queries are executed by the replica, while the writing
+// operations are executed by the primary database. Queries of an open transaction
+// and queries of databases without replicas are executed by the primary database.
+func (h *connectionHandler) resolve(ctx context.Context, spec connectionSpec) (Conn, error) {
+	spec = spec.merge(connectionSpecFromContext(ctx))
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	writingKey := spec.key(Writing)
+	if tx := transactionFromContext(ctx, writingKey); tx != nil {
+		return tx, nil
+	}
+	if tx, ok := h.tx[h.ConnectionSpecificationName(writingKey)]; ok {
+		return tx.conn, nil
+	}
+
+	writer, ok := h.conns[writingKey]
+	if !ok {
+		return nil, &ErrConnectionNotEstablished{Name: writingKey}
+	}
+
+	// Without explicit role, the session switches connection automatically.
+	session := sessionFromContext(ctx)
+	if spec.role == Writing || (spec.role == "" && session == nil) {
+		return writer, nil
+	}
+
+	reader, ok := h.conns[spec.key(Reading)]
+	if !ok {
+		return writer, nil
+	}
+	return &readingConn{Conn: writer, reader: reader, session: session}, nil
+}
+
+// ConnectionRole is a role of the database connection.
+type ConnectionRole string
+
+const (
+	// Writing is a role of the primary database, which executes both reading and
+	// writing operations.
+	Writing ConnectionRole = "writing"
+	// Reading is a role of the replica database, which executes only queries.
+	Reading ConnectionRole = "reading"
+)
+
+// ConnectionOption specifies the connection used by relations.
+type ConnectionOption func(*connectionSpec)
+
+// connectionSpec identifies the connection among the established connections.
+type connectionSpec struct {
+	database string
+	role     ConnectionRole
+	shard    string
+
+	// override is true, when the specification replaces specifications of
+	// relations instead of being merged with them (see WithConnection).
+	override bool
+}
+
+func (spec connectionSpec) databaseName() string {
+	if spec.database == "" {
+		return primaryConnectionName
+	}
+	return spec.database
+}
+
+// merge returns a copy of the specification with non-empty fields of the other
+// specification.
+func (spec connectionSpec) merge(other connectionSpec) connectionSpec {
+	if other.override {
+		return other
+	}
+	if other.database != "" {
+		spec.database = other.database
+	}
+	if other.role != "" {
+		spec.role = other.role
+	}
+	if other.shard != "" {
+		spec.shard = other.shard
+	}
+	return spec
+}
+
+// key returns the name of the established connection for the given role.
+func (spec connectionSpec) key(role ConnectionRole) string {
+	return connectionKey(spec.databaseName(), spec.shard, role)
+}
+
+// connectionKey returns the name of the connection in the registry of established
+// connections. Connections of the writing role to the default shard are stored by
+// the database name.
+func connectionKey(name, shard string, role ConnectionRole) string {
+	if shard != "" {
+		name += "@" + shard
+	}
+	if role == "" || role == Writing {
+		return name
+	}
+	return name + ":" + string(role)
+}
+
+// Database specifies the name of the database connection, which is a name of
+// the established connection.
+//
+//	activerecord.EstablishConnection(activerecord.DatabaseConfig{
+//		Name:     "analytics",
+//		Adapter:  "postgresql",
+//		Database: "analytics",
+//	})
+//
+//	Event := activerecord.New("event", func(r *activerecord.R) {
+//		r.ConnectsTo(activerecord.Database("analytics"))
+//	})
+func Database(name string) ConnectionOption {
+	return func(spec *connectionSpec) {
+		spec.database = name
+	}
+}
+
+// Role specifies the role of the database connection.
+func Role(role ConnectionRole) ConnectionOption {
+	return func(spec *connectionSpec) {
+		spec.role = role
+	}
+}
+
+// Shard specifies the shard of the database connection.
+//
+//	activerecord.EstablishConnection(activerecord.DatabaseConfig{
+//		Adapter: "postgresql", Host: "eu.db", Database: "somedatabase",
+//		Shard:   "eu",
+//	})
+//
+//	activerecord.ConnectedTo(ctx, activerecord.Shard("eu"),
+//		func(ctx context.Context) error {
+//			return Book.WithContext(ctx).Create(Hash{"title": "Solaris"}).Err()
+//		},
+//	)
+func Shard(name string) ConnectionOption {
+	return func(spec *connectionSpec) {
+		spec.shard = name
+	}
+}
+
+type connectionSpecKey struct{}
+
+func connectionSpecFromContext(ctx context.Context) connectionSpec {
+	spec, _ := ctx.Value(connectionSpecKey{}).(connectionSpec)
+	return spec
+}
+
+// ConnectedTo runs the function with the context, which specifies the connection
+// of relations and records used within this context.
+//
+// Replicas are configured with the Reading role for the same connection name:
+//
+//	activerecord.EstablishConnection(activerecord.DatabaseConfig{
+//		Adapter: "postgresql", Host: "primary.db", Database: "somedatabase",
+//	})
+//	activerecord.EstablishConnection(activerecord.DatabaseConfig{
+//		Adapter: "postgresql", Host: "replica.db", Database: "somedatabase",
+//		Role:    activerecord.Reading,
+//	})
+//
+//	activerecord.ConnectedTo(ctx, activerecord.Role(activerecord.Reading),
+//		func(ctx context.Context) error {
+//			// Queries are executed by the replica.
+//			books, err := Book.WithContext(ctx).Where("year", 1961).ToA()
+//			if err != nil {
+//				return err
+//			}
+//			// Writing operations are executed by the primary database.
+//			return Book.WithContext(ctx).Create(Hash{"title": "Solaris"}).Err()
+//		},
+//	)
+func ConnectedTo(
+	ctx context.Context, option ConnectionOption, fn func(context.Context) error,
+) error {
+	spec := connectionSpecFromContext(ctx)
+	option(&spec)
+	return fn(context.WithValue(ctx, connectionSpecKey{}, spec))
+}
+
+// WithConnection returns the context, which forces relations and records used
+// within this context onto the established connection with the name, regardless
+// of connections declared by relations (see R.ConnectsTo):
+//
+//	ctx = activerecord.WithConnection(ctx, "reports", activerecord.Role(activerecord.Reading))
+//	orders, err := Order.WithContext(ctx).Where("status", "paid").ToA()
+//
+// Options specify the role and the shard of the connection, by default the
+// writing connection to the default shard is used. Relations initialized with
+// the explicit connection are not affected.
+func WithConnection(ctx context.Context, name string, options ...ConnectionOption) context.Context {
+	spec := connectionSpec{database: name, override: true}
+	for _, option := range options {
+		option(&spec)
+	}
+	return context.WithValue(ctx, connectionSpecKey{}, spec)
+}
+
+// readingConn executes queries with the replica connection, and all other
+// operations with the primary connection. When the session is specified, queries
+// are executed with the primary connection within the delay after the last write.
+type readingConn struct {
+	Conn
+	reader  Conn
+	session *Session
+}
+
+func (c *readingConn) queryConn() Conn {
+	if c.session != nil && c.session.recentlyWritten() {
+		return c.Conn
+	}
+	return c.reader
+}
+
+func (c *readingConn) ExecQuery(
+	ctx context.Context, op *QueryOperation, cb func(activesupport.Hash) bool,
+) error {
+	return c.queryConn().ExecQuery(ctx, op, cb)
+}
+
+func (c *readingConn) ExecExplain(ctx context.Context, op *ExplainOperation) (string, error) {
+	return c.queryConn().ExecExplain(ctx, op)
+}
+
+func (c *readingConn) BeginTransaction(ctx context.Context, opts *TransactionOptions) (Conn, error) {
+	c.session.recordWrite()
+	return c.Conn.BeginTransaction(ctx, opts)
+}
+
+func (c *readingConn) ExecInsert(ctx context.Context, op *InsertOperation) (interface{}, error) {
+	c.session.recordWrite()
+	return c.Conn.ExecInsert(ctx, op)
+}
+
+func (c *readingConn) ExecUpdate(ctx context.Context, op *UpdateOperation) error {
+	c.session.recordWrite()
+	return c.Conn.ExecUpdate(ctx, op)
+}
+
+func (c *readingConn) ExecDelete(ctx context.Context, op *DeleteOperation) error {
+	c.session.recordWrite()
+	return c.Conn.ExecDelete(ctx, op)
+}
+
+func (c *readingConn) ExecStatement(ctx context.Context, op *QueryOperation) (int64, error) {
+	c.session.recordWrite()
+	return c.Conn.ExecStatement(ctx, op)
+}
+
+// connection returns the explicitly specified connection or resolves the
+// connection by the specification within the given context.
+func connection(ctx context.Context, h *connectionHandler, spec connectionSpec, conn Conn) Conn {
+	if conn == nil {
+		var err error
+		if conn, err = h.resolve(ctx, spec); err != nil {
+			return &errConn{err: err}
+		}
+	}
+	return withQueryCache(ctx, conn)
 }
 
 func RegisterConnectionAdapter(adapter string, ca ConnectionAdapter) {
@@ -193,20 +675,165 @@ func RegisterConnectionAdapter(adapter string, ca ConnectionAdapter) {
 //		Password: "pgpass",
 //		Database: "somedatabase",
 //	})
-func EstablishConnection(c DatabaseConfig) (Conn, error) {
-	return globalConnectionHandler.EstablishConnection(c)
+//
+// Configuration with multiple databases establishes connections to all of them,
+// and returns the primary connection:
+//
+//	configs, err := activerecord.LoadDatabaseConfigurations("config/database.yml")
+//	if err != nil {
+//		return err
+//	}
+//	activerecord.EstablishConnection(configs.Env("production"))
+func EstablishConnection(c ConnectionConfig) (Conn, error) {
+	configs, err := c.DatabaseConfigs()
+	if err != nil {
+		return nil, err
+	}
+	if len(configs) == 0 {
+		return nil, &ErrConfig{Message: "no databases configured"}
+	}
+
+	var (
+		primary     Conn
+		established []string
+	)
+	for i := range configs {
+		conn, err := globalConnectionHandler.EstablishConnection(configs[i])
+		if err != nil {
+			// Close connections established before the failed one.
+			for _, name := range established {
+				globalConnectionHandler.RemoveConnection(name)
+			}
+			return nil, err
+		}
+
+		name := configs[i].Name
+		if name == "" {
+			name = primaryConnectionName
+		}
+		if primary == nil || (name == primaryConnectionName &&
+			configs[i].Shard == "" && configs[i].Role != Reading) {
+			primary = conn
+		}
+		if configs[i].Role != Reading && configs[i].Shard == "" {
+			established = append(established, name)
+		}
+	}
+	return primary, nil
 }
 
 func RetrieveConnection(name string) (Conn, error) {
 	return globalConnectionHandler.RetrieveConnection(name)
 }
 
+// RetrieveConnectionPool returns the pool of the established connection. Each role
+// and shard of the database has a separate pool, which is selected by options:
+//
+//	pool, err := activerecord.RetrieveConnectionPool("primary", activerecord.Shard("eu"))
+func RetrieveConnectionPool(name string, options ...ConnectionOption) (*ConnectionPool, error) {
+	return globalConnectionHandler.RetrieveConnectionPool(name, options...)
+}
+
+// RemoveConnection closes and removes the connection with the given name, including
+// connections of all roles and shards established for the database.
 func RemoveConnection(name string) error {
 	return globalConnectionHandler.RemoveConnection(name)
 }
 
+// transactionKey is a key of the transaction within the context, transactions are
+// stored per each connection.
+type transactionKey struct {
+	name string
+}
+
+// transactionState is a state of the transaction stored in the context. Once the
+// transaction is finished, contexts derived from the transactional context use
+// the regular connection.
+type transactionState struct {
+	conn Conn
+	// joinable is false for transactions, which execute nested transactions
+	// within savepoints.
+	joinable bool
+
+	mu          sync.RWMutex
+	finished    bool
+	afterCommit []func()
+}
+
+func (s *transactionState) finish() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.finished = true
+}
+
+// addAfterCommit appends functions called after the commit of the transaction,
+// it returns false when the transaction is already finished.
+func (s *transactionState) addAfterCommit(fns ...func()) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.finished {
+		return false
+	}
+	s.afterCommit = append(s.afterCommit, fns...)
+	return true
+}
+
+func (s *transactionState) takeAfterCommit() []func() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fns := s.afterCommit
+	s.afterCommit = nil
+	return fns
+}
+
+func transactionStateFromContext(ctx context.Context, name string) *transactionState {
+	state, ok := ctx.Value(transactionKey{name}).(*transactionState)
+	if !ok {
+		return nil
+	}
+
+	state.mu.RLock()
+	defer state.mu.RUnlock()
+	if state.finished {
+		return nil
+	}
+	return state
+}
+
+func transactionFromContext(ctx context.Context, name string) Conn {
+	if state := transactionStateFromContext(ctx, name); state != nil {
+		return state.conn
+	}
+	return nil
+}
+
 // Transaction runs the given block in a database transaction, and returns the
-// result of the function.
-func Transaction(ctx context.Context, fn func() error) error {
-	return globalConnectionHandler.Transaction(ctx, fn)
+// result of the function. The transaction is committed when the function returns
+// no error, and rolled back on error or panic.
+//
+// All relations and records used with the transactional context execute queries
+// within the transaction:
+//
+//	err := activerecord.Transaction(ctx, func(tx context.Context) error {
+//		author := Author.WithContext(tx).Create(Hash{"name": "Max Tegmark"})
+//		if err := author.Err(); err != nil {
+//			return err
+//		}
+//		return Book.WithContext(tx).Create(Hash{"title": "Life 3.0"}).Err()
+//	})
+//
+// The transaction is started for the connection specified in the context with
+// ConnectedTo function, by default the primary connection is used. Nested
+// transactions are joined to the outer transaction, options of nested transactions
+// are ignored.
+//
+// Isolation level and access mode of the transaction are specified with options:
+//
+//	err := activerecord.Transaction(ctx, func(tx context.Context) error {
+//		// ...
+//	}, activerecord.Isolation(activerecord.Serializable), activerecord.Retry(3))
+func Transaction(
+	ctx context.Context, fn func(tx context.Context) error, options ...TransactionOption,
+) error {
+	return globalConnectionHandler.Transaction(ctx, fn, options...)
 }
@@ -0,0 +1,892 @@
+package activerecord
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/activegraph/activegraph/activesupport"
+)
+
+const (
+	// defaultCheckoutTimeout is the time to wait for a connection from the pool,
+	// when the checkout timeout is not configured.
+	defaultCheckoutTimeout = 5 * time.Second
+)
+
+// ErrConnectionTimeout is returned when the connection cannot be checked out from
+// the pool within the checkout timeout.
+type ErrConnectionTimeout struct {
+	Name    string
+	Timeout time.Duration
+}
+
+func (e *ErrConnectionTimeout) Is(target error) bool {
+	_, ok := target.(*ErrConnectionTimeout)
+	return ok
+}
+
+func (e *ErrConnectionTimeout) Error() string {
+	return fmt.Sprintf(
+		"could not obtain a connection %q from the pool within %s", e.Name, e.Timeout,
+	)
+}
+
+// PoolStats is a statistics of the connection pool.
+type PoolStats struct {
+	// Size is the maximum number of connections checked out at the same time,
+	// zero means the number of connections is not limited.
+	Size int
+	// InUse is the number of connections currently checked out.
+	InUse int
+	// WaitCount is the total number of checkouts waited for an available
+	// connection.
+	WaitCount int64
+	// WaitDuration is the total time spent waiting for available connections.
+	WaitDuration time.Duration
+	// Timeouts is the total number of checkouts failed by the timeout.
+	Timeouts int64
+	// Retries is the total number of retried operations.
+	Retries int64
+	// Reconnects is the total number of connections re-established after
+	// failed verifications.
+	Reconnects int64
+	// Leaks is the total number of checkouts exceeded the maximum lease time.
+	Leaks int64
+}
+
+// ConnectionLease describes the connection checked out from the pool.
+type ConnectionLease struct {
+	// Pool is the name of the connection pool.
+	Pool string
+	// Since is the time of the checkout.
+	Since time.Time
+	// Caller is the location ("file:line") of the application code, which
+	// checked out the connection.
+	Caller string
+	// Stack is the stack trace of the checkout.
+	Stack string
+}
+
+// lease is the tracked checkout of the connection.
+type lease struct {
+	since time.Time
+	stack []uintptr
+	timer *time.Timer
+}
+
+func (l *lease) describe(pool string) ConnectionLease {
+	var (
+		buf    strings.Builder
+		caller string
+		frames = runtime.CallersFrames(l.stack)
+	)
+	for {
+		frame, more := frames.Next()
+		if caller == "" && !isLibraryFunc(frame.Function) {
+			caller = fmt.Sprintf("%s:%d", frame.File, frame.Line)
+		}
+		fmt.Fprintf(&buf, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+		if !more {
+			break
+		}
+	}
+	return ConnectionLease{Pool: pool, Since: l.since, Caller: caller, Stack: buf.String()}
+}
+
+var (
+	leakHooksMu sync.RWMutex
+	leakHooks   []func(ConnectionLease)
+)
+
+// OnConnectionLeak registers the hook called for each connection checked out
+// from the pool longer than the MaxLease of the database configuration. Hooks
+// are called while the connection is still checked out, so the lease stack
+// points to the owner of the leaked connection:
+//
+//	activerecord.OnConnectionLeak(func(l activerecord.ConnectionLease) {
+//		log.Printf("connection leaked since %s at %s", l.Since, l.Caller)
+//	})
+//
+// When no hooks are registered, leaks are written to the standard logger.
+func OnConnectionLeak(fn func(lease ConnectionLease)) {
+	leakHooksMu.Lock()
+	defer leakHooksMu.Unlock()
+	leakHooks = append(leakHooks, fn)
+}
+
+// ConnectionVerifier is implemented by connections, which could verify that the
+// database is reachable, e.g. with a ping.
+type ConnectionVerifier interface {
+	Verify(ctx context.Context) error
+}
+
+// ConnectionPool limits the number of database operations executed concurrently
+// through the connection. Each operation checks out a connection from the pool for
+// the time of the execution, transactions hold the connection until commit or
+// rollback.
+//
+// Pool is configured with Pool, MaxIdle, MaxLifetime, and CheckoutTimeout fields
+// of the database configuration. Database statements executed through the pool,
+// including statements of transactions, are limited by the StatementTimeout:
+//
+//	activerecord.EstablishConnection(activerecord.DatabaseConfig{
+//		Adapter:          "postgresql",
+//		Database:         "somedatabase",
+//		Pool:             10,
+//		CheckoutTimeout:  2 * time.Second,
+//		StatementTimeout: 5 * time.Second,
+//	})
+//
+//	pool, _ := activerecord.RetrieveConnectionPool("primary")
+//	fmt.Println(pool.Stats().InUse)
+//
+// Connection is verified each VerifyInterval and after operations failed with
+// ErrConnectionFailed. When the verification fails, the connection is established
+// again, operations fail with ErrConnectionNotEstablished until then.
+//
+// When MaxLease is configured, checkouts are tracked with stack traces of their
+// owners, so leaked connections, e.g. transactions that are never finished, are
+// reported with OnConnectionLeak hooks, and listed with Leases.
+type ConnectionPool struct {
+	name    string
+	shard   string
+	role    ConnectionRole
+	timeout time.Duration
+	slots   chan struct{}
+
+	statementTimeout time.Duration
+	retrier          *retrier
+	maxLease         time.Duration
+
+	// Connection is re-established with the adapter and the configuration
+	// of the database, when it is broken.
+	connect  ConnectionAdapter
+	config   DatabaseConfig
+	verifyMu sync.Mutex
+	done     chan struct{}
+
+	connMu sync.RWMutex
+	conn   Conn
+	broken error
+
+	mu     sync.Mutex
+	stats  PoolStats
+	leases map[*lease]struct{}
+
+	schemaCache *SchemaCache
+}
+
+func newConnectionPool(conn Conn, c DatabaseConfig, connect ConnectionAdapter) *ConnectionPool {
+	pool := &ConnectionPool{
+		conn:    conn,
+		connect: connect,
+		config:  c,
+		done:    make(chan struct{}),
+		name:    c.Name,
+		shard:   c.Shard,
+		role:    c.Role,
+		timeout: c.CheckoutTimeout,
+
+		statementTimeout: c.StatementTimeout,
+		retrier:          newRetrier(c.Retry),
+		maxLease:         c.MaxLease,
+		leases:           make(map[*lease]struct{}),
+		schemaCache:      NewSchemaCache(),
+	}
+	if pool.role == "" {
+		pool.role = Writing
+	}
+	if pool.timeout <= 0 {
+		pool.timeout = defaultCheckoutTimeout
+	}
+	if c.Pool > 0 {
+		pool.slots = make(chan struct{}, c.Pool)
+		pool.stats.Size = c.Pool
+	}
+	if c.VerifyInterval > 0 {
+		go pool.verifyEvery(c.VerifyInterval)
+	}
+	return pool
+}
+
+// current returns the current connection of the pool.
+func (p *ConnectionPool) current() Conn {
+	p.connMu.RLock()
+	defer p.connMu.RUnlock()
+	return p.conn
+}
+
+// Verify verifies the connection to the database, and establishes the connection
+// again when the verification fails. ErrConnectionNotEstablished is returned, when
+// the connection could not be established.
+func (p *ConnectionPool) Verify(ctx context.Context) error {
+	p.verifyMu.Lock()
+	defer p.verifyMu.Unlock()
+
+	conn := p.current()
+	verifier, ok := conn.(ConnectionVerifier)
+	if !ok || verifier.Verify(ctx) == nil {
+		p.connMu.Lock()
+		p.broken = nil
+		p.connMu.Unlock()
+		return nil
+	}
+
+	newConn, err := p.connect(p.config)
+	if err != nil {
+		err = &ErrConnectionNotEstablished{Name: p.name, Err: err}
+		p.connMu.Lock()
+		p.broken = err
+		p.connMu.Unlock()
+		return err
+	}
+
+	p.connMu.Lock()
+	p.conn, p.broken = newConn, nil
+	p.connMu.Unlock()
+
+	p.mu.Lock()
+	p.stats.Reconnects++
+	p.mu.Unlock()
+
+	conn.Close()
+	return nil
+}
+
+// verifyEvery verifies the connection periodically until the pool is closed.
+func (p *ConnectionPool) verifyEvery(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), interval)
+			p.Verify(ctx)
+			cancel()
+		case <-p.done:
+			return
+		}
+	}
+}
+
+// ensure verifies the connection, when it was marked as broken by one of the
+// previous operations.
+func (p *ConnectionPool) ensure(ctx context.Context) error {
+	p.connMu.RLock()
+	broken := p.broken
+	p.connMu.RUnlock()
+
+	if broken == nil {
+		return nil
+	}
+	return p.Verify(ctx)
+}
+
+// markBroken marks the connection as broken, when the error is caused by the
+// failed connection.
+func (p *ConnectionPool) markBroken(err error) {
+	if errors.Is(err, new(ErrConnectionFailed)) {
+		p.connMu.Lock()
+		p.broken = err
+		p.connMu.Unlock()
+	}
+}
+
+// Close stops verifications and closes the connection.
+func (p *ConnectionPool) Close() error {
+	select {
+	case <-p.done:
+	default:
+		close(p.done)
+	}
+	return p.current().Close()
+}
+
+func (p *ConnectionPool) CommitTransaction(ctx context.Context) error {
+	return p.current().CommitTransaction(ctx)
+}
+
+func (p *ConnectionPool) RollbackTransaction(ctx context.Context) error {
+	return p.current().RollbackTransaction(ctx)
+}
+
+func (p *ConnectionPool) ColumnType(typeName string) (Type, error) {
+	return p.current().ColumnType(typeName)
+}
+
+// Name returns the name of the pool connection.
+func (p *ConnectionPool) Name() string {
+	return p.name
+}
+
+// SchemaCache returns the schema cache of the pool.
+func (p *ConnectionPool) SchemaCache() *SchemaCache {
+	return p.schemaCache
+}
+
+// Shard returns the name of the shard, empty for the default shard.
+func (p *ConnectionPool) Shard() string {
+	return p.shard
+}
+
+// Role returns the role of the pool connection.
+func (p *ConnectionPool) Role() ConnectionRole {
+	return p.role
+}
+
+// Stats returns the statistics of the pool.
+func (p *ConnectionPool) Stats() PoolStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.stats
+}
+
+// Leases returns connections currently checked out from the pool, when the
+// maximum lease time is configured. Otherwise checkouts are not tracked.
+func (p *ConnectionPool) Leases() []ConnectionLease {
+	p.mu.Lock()
+	leases := make([]*lease, 0, len(p.leases))
+	for l := range p.leases {
+		leases = append(leases, l)
+	}
+	p.mu.Unlock()
+
+	described := make([]ConnectionLease, len(leases))
+	for i, l := range leases {
+		described[i] = l.describe(p.name)
+	}
+	return described
+}
+
+// checkout reserves a connection in the pool, waiting for an available connection
+// until the checkout timeout or the context cancellation. The returned lease must
+// be passed to checkin, it is nil when checkouts are not tracked.
+func (p *ConnectionPool) checkout(ctx context.Context) (*lease, error) {
+	// The connection is not checked out for the cancelled context, even when
+	// the pool has free slots.
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := p.ensure(ctx); err != nil {
+		return nil, err
+	}
+	if p.slots == nil {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		p.stats.InUse++
+		return p.lease(), nil
+	}
+
+	select {
+	case p.slots <- struct{}{}:
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		p.stats.InUse++
+		return p.lease(), nil
+	default:
+	}
+
+	start := time.Now()
+	timer := time.NewTimer(p.timeout)
+	defer timer.Stop()
+
+	var err error
+	select {
+	case p.slots <- struct{}{}:
+	case <-timer.C:
+		err = &ErrConnectionTimeout{Name: p.name, Timeout: p.timeout}
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.stats.WaitCount++
+	p.stats.WaitDuration += time.Since(start)
+	if err != nil {
+		if _, ok := err.(*ErrConnectionTimeout); ok {
+			p.stats.Timeouts++
+		}
+		return nil, err
+	}
+	p.stats.InUse++
+	return p.lease(), nil
+}
+
+// lease starts tracking of the checkout, when the maximum lease time is
+// configured. Must be called with the lock held.
+func (p *ConnectionPool) lease() *lease {
+	if p.maxLease <= 0 {
+		return nil
+	}
+
+	// Skip frames of runtime.Callers, lease and checkout functions.
+	stack := make([]uintptr, 64)
+	stack = stack[:runtime.Callers(3, stack)]
+
+	l := &lease{since: time.Now(), stack: stack}
+	l.timer = time.AfterFunc(p.maxLease, func() { p.leaked(l) })
+	p.leases[l] = struct{}{}
+	return l
+}
+
+// leaked reports the checkout exceeded the maximum lease time.
+func (p *ConnectionPool) leaked(l *lease) {
+	p.mu.Lock()
+	if _, ok := p.leases[l]; !ok {
+		p.mu.Unlock()
+		return
+	}
+	p.stats.Leaks++
+	p.mu.Unlock()
+
+	leakHooksMu.RLock()
+	hooks := leakHooks
+	leakHooksMu.RUnlock()
+
+	described := l.describe(p.name)
+	if len(hooks) == 0 {
+		log.Printf("activerecord: connection %q is checked out for more than %s at %s\n%s",
+			p.name, p.maxLease, described.Caller, described.Stack)
+	}
+	for _, hook := range hooks {
+		hook(described)
+	}
+}
+
+// checkin returns the connection back to the pool.
+func (p *ConnectionPool) checkin(l *lease) {
+	p.mu.Lock()
+	p.stats.InUse--
+	if l != nil {
+		l.timer.Stop()
+		delete(p.leases, l)
+	}
+	p.mu.Unlock()
+
+	if p.slots != nil {
+		<-p.slots
+	}
+}
+
+func (p *ConnectionPool) BeginTransaction(ctx context.Context, opts *TransactionOptions) (
+	Conn, error,
+) {
+	l, err := p.checkout(ctx)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := p.current().BeginTransaction(ctx, opts)
+	if err != nil {
+		p.checkin(l)
+		return nil, err
+	}
+	return &pooledTx{Conn: conn, pool: p, lease: l}, nil
+}
+
+// rowStream passes rows of the query read in the separate goroutine to the
+// callback one by one, the next row is read after the callback returns. Queries
+// executed by the callback drain the stream, so the remaining rows are buffered
+// and the connection of the query is released.
+type rowStream struct {
+	rows  chan activesupport.Hash
+	next  chan bool
+	stop  chan struct{}
+	drain chan struct{}
+	done  chan struct{}
+
+	drainOnce sync.Once
+	buffered  []activesupport.Hash
+	err       error
+}
+
+func newRowStream() *rowStream {
+	return &rowStream{
+		rows:  make(chan activesupport.Hash),
+		next:  make(chan bool),
+		stop:  make(chan struct{}),
+		drain: make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+}
+
+// read executes the query, which sends rows to the stream.
+func (s *rowStream) read(query func(send func(activesupport.Hash) bool) error) {
+	defer close(s.done)
+	s.err = query(s.send)
+}
+
+// send passes the row to the consumer of the stream and waits for the result of
+// the callback, or buffers the row, when the stream is drained.
+func (s *rowStream) send(row activesupport.Hash) bool {
+	select {
+	case s.rows <- row:
+	case <-s.drain:
+		s.buffered = append(s.buffered, row)
+		return true
+	case <-s.stop:
+		return false
+	}
+
+	select {
+	case next := <-s.next:
+		return next
+	case <-s.drain:
+		return true
+	case <-s.stop:
+		return false
+	}
+}
+
+// consume calls the callback for each row of the stream until the callback
+// returns false, then it waits for the query to stop reading rows.
+func (s *rowStream) consume(cb func(activesupport.Hash) bool) (err error) {
+	defer func() {
+		close(s.stop)
+		<-s.done
+		if err == nil {
+			err = s.err
+		}
+	}()
+
+	for {
+		select {
+		case row := <-s.rows:
+			next := cb(row)
+			select {
+			case s.next <- next:
+			case <-s.done:
+				// The stream was drained by the callback.
+			}
+			if !next {
+				return nil
+			}
+		case <-s.done:
+			for _, row := range s.buffered {
+				if !cb(row) {
+					return nil
+				}
+			}
+			return nil
+		}
+	}
+}
+
+// drainRows buffers the remaining rows of the stream and waits for the query to
+// complete.
+func (s *rowStream) drainRows() {
+	s.drainOnce.Do(func() { close(s.drain) })
+	<-s.done
+}
+
+// heldConns are connections of pools checked out by queries, which stream rows
+// to callbacks within the context.
+type heldConns struct {
+	mu      sync.Mutex
+	streams map[*ConnectionPool]*rowStream
+}
+
+type heldConnsKey struct{}
+
+// withHeldConns returns a copy of the context, which tracks connections held by
+// queries streaming rows. Queries executed within the context by callbacks of
+// these queries reuse the checked out connection, so they do not wait for
+// another connection of the pool. When the context already tracks connections,
+// it is returned as is.
+func withHeldConns(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(heldConnsKey{}).(*heldConns); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, heldConnsKey{}, &heldConns{
+		streams: make(map[*ConnectionPool]*rowStream),
+	})
+}
+
+// hold marks the connection of the pool held by the stream until the returned
+// function is called.
+func (h *heldConns) hold(p *ConnectionPool, s *rowStream) (release func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	prev, ok := h.streams[p]
+	h.streams[p] = s
+	return func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if ok {
+			h.streams[p] = prev
+		} else {
+			delete(h.streams, p)
+		}
+	}
+}
+
+// held returns the stream holding the connection of the pool within the context.
+func (p *ConnectionPool) held(ctx context.Context) (*rowStream, bool) {
+	h, ok := ctx.Value(heldConnsKey{}).(*heldConns)
+	if !ok {
+		return nil, false
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.streams[p]
+	return s, ok
+}
+
+// exec checks out a connection from the pool and executes the database statement
+// within the statement timeout. Statements of queries holding the connection
+// within the context reuse it.
+func (p *ConnectionPool) exec(ctx context.Context, fn func(context.Context) error) error {
+	if s, ok := p.held(ctx); ok {
+		s.drainRows()
+		return p.statement(ctx, fn)
+	}
+	l, err := p.checkout(ctx)
+	if err != nil {
+		return err
+	}
+	defer p.checkin(l)
+	return p.statement(ctx, fn)
+}
+
+// statement executes the database statement within the timeout specified in the
+// context or the statement timeout of the pool.
+func (p *ConnectionPool) statement(ctx context.Context, fn func(context.Context) error) error {
+	timeout := statementTimeout(ctx, p.statementTimeout)
+	if timeout <= 0 {
+		err := fn(ctx)
+		p.markBroken(err)
+		return err
+	}
+
+	tctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	err := fn(tctx)
+	if err != nil && tctx.Err() == context.DeadlineExceeded && ctx.Err() == nil {
+		return &ErrStatementTimeout{Timeout: timeout, Err: err}
+	}
+	p.markBroken(err)
+	return err
+}
+
+func (p *ConnectionPool) ExecInsert(ctx context.Context, op *InsertOperation) (
+	id interface{}, err error,
+) {
+	err = p.exec(ctx, func(ctx context.Context) (err error) {
+		id, err = p.current().ExecInsert(ctx, op)
+		return err
+	})
+	return id, err
+}
+
+func (p *ConnectionPool) ExecUpdate(ctx context.Context, op *UpdateOperation) error {
+	return p.exec(ctx, func(ctx context.Context) error {
+		return p.current().ExecUpdate(ctx, op)
+	})
+}
+
+func (p *ConnectionPool) ExecDelete(ctx context.Context, op *DeleteOperation) error {
+	return p.exec(ctx, func(ctx context.Context) error {
+		return p.current().ExecDelete(ctx, op)
+	})
+}
+
+// retry executes the idempotent operation and retries it on transient failures
+// according to the retry policy of the pool.
+func (p *ConnectionPool) retry(ctx context.Context, fn func() (bool, error)) error {
+	return p.retrier.do(ctx, fn, func() {
+		p.mu.Lock()
+		p.stats.Retries++
+		p.mu.Unlock()
+	})
+}
+
+// ExecQuery executes the query, which is retried on transient failures until the
+// first row is returned. Rows are streamed to the callback, while the connection
+// is checked out.
+//
+// Queries of records iterated by Relation.Each reuse the checked out connection,
+// so they do not wait for another connection of the pool. Remaining rows of the
+// iterated query are buffered on the first such query, since the database does
+// not execute statements on the connection reading rows.
+func (p *ConnectionPool) ExecQuery(
+	ctx context.Context, op *QueryOperation, cb func(activesupport.Hash) bool,
+) error {
+	return p.retry(ctx, func() (bool, error) {
+		var consumed bool
+		err := p.exec(ctx, func(ctx context.Context) error {
+			return p.query(ctx, op, func(row activesupport.Hash) bool {
+				consumed = true
+				return cb(row)
+			})
+		})
+		return !consumed, err
+	})
+}
+
+// query streams rows of the query to the callback. Within the context tracking
+// held connections (see withHeldConns), rows are streamed through rowStream, so
+// queries of the callback could release the connection.
+func (p *ConnectionPool) query(
+	ctx context.Context, op *QueryOperation, cb func(activesupport.Hash) bool,
+) error {
+	h, ok := ctx.Value(heldConnsKey{}).(*heldConns)
+	if !ok {
+		return p.current().ExecQuery(ctx, op, cb)
+	}
+
+	s := newRowStream()
+	defer h.hold(p, s)()
+
+	ctx = withQueryCaller(ctx)
+	go s.read(func(send func(activesupport.Hash) bool) error {
+		return p.current().ExecQuery(ctx, op, send)
+	})
+	return s.consume(cb)
+}
+
+func (p *ConnectionPool) ExecExplain(ctx context.Context, op *ExplainOperation) (
+	plan string, err error,
+) {
+	err = p.retry(ctx, func() (bool, error) {
+		err := p.exec(ctx, func(ctx context.Context) (err error) {
+			plan, err = p.current().ExecExplain(ctx, op)
+			return err
+		})
+		return true, err
+	})
+	return plan, err
+}
+
+func (p *ConnectionPool) ExecStatement(ctx context.Context, op *QueryOperation) (
+	rows int64, err error,
+) {
+	err = p.exec(ctx, func(ctx context.Context) (err error) {
+		rows, err = p.current().ExecStatement(ctx, op)
+		return err
+	})
+	return rows, err
+}
+
+func (p *ConnectionPool) CreateTable(ctx context.Context, table *Table) error {
+	l, err := p.checkout(ctx)
+	if err != nil {
+		return err
+	}
+	defer p.checkin(l)
+	defer p.schemaCache.ClearTable(table.Name())
+	return p.current().CreateTable(ctx, table)
+}
+
+func (p *ConnectionPool) AddForeignKey(ctx context.Context, owner, target string) error {
+	l, err := p.checkout(ctx)
+	if err != nil {
+		return err
+	}
+	defer p.checkin(l)
+	defer p.schemaCache.ClearTable(owner)
+	return p.current().AddForeignKey(ctx, owner, target)
+}
+
+func (p *ConnectionPool) ColumnDefinitions(ctx context.Context, tableName string) (
+	definitions []ColumnDefinition, err error,
+) {
+	err = p.retry(ctx, func() (bool, error) {
+		l, err := p.checkout(ctx)
+		if err != nil {
+			return true, err
+		}
+		defer p.checkin(l)
+
+		definitions, err = p.current().ColumnDefinitions(ctx, tableName)
+		return true, err
+	})
+	return definitions, err
+}
+
+// pooledTx is a transaction, which returns the connection to the pool on commit,
+// rollback or close, whichever happens first.
+type pooledTx struct {
+	Conn
+	pool  *ConnectionPool
+	lease *lease
+	once  sync.Once
+}
+
+func (tx *pooledTx) release() {
+	tx.once.Do(func() { tx.pool.checkin(tx.lease) })
+}
+
+func (tx *pooledTx) ExecInsert(ctx context.Context, op *InsertOperation) (
+	id interface{}, err error,
+) {
+	err = tx.pool.statement(ctx, func(ctx context.Context) (err error) {
+		id, err = tx.Conn.ExecInsert(ctx, op)
+		return err
+	})
+	return id, err
+}
+
+func (tx *pooledTx) ExecUpdate(ctx context.Context, op *UpdateOperation) error {
+	return tx.pool.statement(ctx, func(ctx context.Context) error {
+		return tx.Conn.ExecUpdate(ctx, op)
+	})
+}
+
+func (tx *pooledTx) ExecDelete(ctx context.Context, op *DeleteOperation) error {
+	return tx.pool.statement(ctx, func(ctx context.Context) error {
+		return tx.Conn.ExecDelete(ctx, op)
+	})
+}
+
+func (tx *pooledTx) ExecQuery(
+	ctx context.Context, op *QueryOperation, cb func(activesupport.Hash) bool,
+) error {
+	return tx.pool.statement(ctx, func(ctx context.Context) error {
+		return tx.Conn.ExecQuery(ctx, op, cb)
+	})
+}
+
+func (tx *pooledTx) ExecExplain(ctx context.Context, op *ExplainOperation) (
+	plan string, err error,
+) {
+	err = tx.pool.statement(ctx, func(ctx context.Context) (err error) {
+		plan, err = tx.Conn.ExecExplain(ctx, op)
+		return err
+	})
+	return plan, err
+}
+
+func (tx *pooledTx) ExecStatement(ctx context.Context, op *QueryOperation) (
+	rows int64, err error,
+) {
+	err = tx.pool.statement(ctx, func(ctx context.Context) (err error) {
+		rows, err = tx.Conn.ExecStatement(ctx, op)
+		return err
+	})
+	return rows, err
+}
+
+func (tx *pooledTx) CommitTransaction(ctx context.Context) error {
+	defer tx.release()
+	return tx.Conn.CommitTransaction(ctx)
+}
+
+func (tx *pooledTx) RollbackTransaction(ctx context.Context) error {
+	defer tx.release()
+	return tx.Conn.RollbackTransaction(ctx)
+}
+
+func (tx *pooledTx) Close() error {
+	defer tx.release()
+	return tx.Conn.Close()
+}
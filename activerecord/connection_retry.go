@@ -0,0 +1,155 @@
+package activerecord
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+const (
+	defaultRetryBaseDelay = 50 * time.Millisecond
+	defaultRetryMaxDelay  = 2 * time.Second
+	defaultRetryBudget    = 10
+
+	// retryBudgetRefill is the number of retries returned to the budget by each
+	// operation completed without retries.
+	retryBudgetRefill = 0.1
+)
+
+// ErrConnectionFailed is returned by adapters when the connection to the database
+// is lost, e.g. the connection was reset or the database failed over to another
+// server. Operations failed with this error could be retried.
+type ErrConnectionFailed struct {
+	Err error
+}
+
+func (e *ErrConnectionFailed) Is(target error) bool {
+	_, ok := target.(*ErrConnectionFailed)
+	return ok
+}
+
+func (e *ErrConnectionFailed) Unwrap() error {
+	return e.Err
+}
+
+func (e *ErrConnectionFailed) Error() string {
+	return "connection failed: " + e.Err.Error()
+}
+
+// IsTransient returns true when the error is caused by a transient failure of the
+// database, so the failed operation could succeed when retried.
+func IsTransient(err error) bool {
+	return errors.Is(err, new(ErrConnectionFailed)) ||
+		errors.Is(err, new(ErrSerializationFailure))
+}
+
+// RetryPolicy configures retries of idempotent operations (queries outside of
+// transactions) failed with transient errors. The delay between attempts grows
+// exponentially from BaseDelay up to MaxDelay with a random jitter.
+//
+// Retries are limited with a budget shared by all operations of the connection
+// pool: each retry consumes a token from the budget, and each operation completed
+// without retries returns a tenth of the token. When the budget is exhausted,
+// operations fail without retries, so a failing database is not overloaded.
+//
+//	activerecord.EstablishConnection(activerecord.DatabaseConfig{
+//		Adapter:  "postgresql",
+//		Database: "somedatabase",
+//		Retry:    activerecord.RetryPolicy{Attempts: 3},
+//	})
+type RetryPolicy struct {
+	// Attempts is the maximum number of retries of the operation, zero means
+	// operations are not retried.
+	Attempts int
+	// BaseDelay is the delay before the first retry. Default is 50ms.
+	BaseDelay time.Duration
+	// MaxDelay is the maximum delay between retries. Default is 2 seconds.
+	MaxDelay time.Duration
+	// Budget is the maximum number of retries available in the budget.
+	// Default is 10.
+	Budget int
+}
+
+// retrier retries operations according to the retry policy.
+type retrier struct {
+	policy RetryPolicy
+
+	mu     sync.Mutex
+	tokens float64
+}
+
+func newRetrier(policy RetryPolicy) *retrier {
+	if policy.BaseDelay <= 0 {
+		policy.BaseDelay = defaultRetryBaseDelay
+	}
+	if policy.MaxDelay <= 0 {
+		policy.MaxDelay = defaultRetryMaxDelay
+	}
+	if policy.Budget <= 0 {
+		policy.Budget = defaultRetryBudget
+	}
+	return &retrier{policy: policy, tokens: float64(policy.Budget)}
+}
+
+// delay returns the delay before the given retry attempt.
+func (r *retrier) delay(attempt int) time.Duration {
+	delay := r.policy.BaseDelay << (attempt - 1)
+	if delay > r.policy.MaxDelay || delay <= 0 {
+		delay = r.policy.MaxDelay
+	}
+	// Spread retries of concurrent operations with a jitter.
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// acquire consumes a token from the budget, returns false if the budget is
+// exhausted.
+func (r *retrier) acquire() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.tokens < 1 {
+		return false
+	}
+	r.tokens--
+	return true
+}
+
+func (r *retrier) refill() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tokens += retryBudgetRefill
+	if budget := float64(r.policy.Budget); r.tokens > budget {
+		r.tokens = budget
+	}
+}
+
+// do executes the operation and retries it on transient errors. The operation
+// returns false as the first value, when it must not be retried (e.g. its
+// results were already partially consumed). The onRetry function is called
+// before each retry.
+func (r *retrier) do(
+	ctx context.Context, fn func() (retryable bool, err error), onRetry func(),
+) error {
+	for attempt := 1; ; attempt++ {
+		retryable, err := fn()
+		if err == nil && attempt == 1 {
+			r.refill()
+		}
+		if err == nil || !retryable || !IsTransient(err) {
+			return err
+		}
+		if attempt > r.policy.Attempts || !r.acquire() {
+			return err
+		}
+
+		timer := time.NewTimer(r.delay(attempt))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		}
+		onRetry()
+	}
+}
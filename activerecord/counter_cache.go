@@ -0,0 +1,172 @@
+package activerecord
+
+import (
+	"fmt"
+
+	. "github.com/activegraph/activegraph/activesupport"
+)
+
+// DefaultCountersBatchSize is the number of records, which counters are reconciled
+// at once by ReconcileCounters.
+const DefaultCountersBatchSize = 1000
+
+// counterCache is the counter cache column of the collection.
+type counterCache struct {
+	attrName   string
+	targets    *Relation
+	foreignKey string
+}
+
+// counterCaches returns counter cache columns of collections, the counter cache
+// column of the collection is named "<collection>_count", e.g. "books_count".
+func (rel *Relation) counterCaches(collNames []string) ([]counterCache, error) {
+	counters := make([]counterCache, 0, len(collNames))
+	for _, collName := range collNames {
+		if !rel.HasAssociation(collName) {
+			return nil, ErrUnknownAssociation{RecordName: rel.name, Assoc: collName}
+		}
+		ca, err := rel.findCollection(collName)
+		if err != nil {
+			return nil, err
+		}
+		hasMany, ok := ca.(*HasMany)
+		if !ok {
+			return nil, ErrArgument{Message: fmt.Sprintf(
+				"counters of %q collection of %s are not supported", collName, rel.name,
+			)}
+		}
+
+		attrName := collName + "_count"
+		if !rel.scope.HasAttribute(attrName) {
+			return nil, &ErrUnknownAttribute{RecordName: rel.name, Attr: attrName}
+		}
+
+		targets, err := rel.associations.reflection.Reflection(hasMany.targetName)
+		if err != nil {
+			return nil, err
+		}
+		counters = append(counters, counterCache{
+			attrName:   attrName,
+			targets:    targets.WithContext(rel.Context()),
+			foreignKey: hasMany.AssociationForeignKey(),
+		})
+	}
+	return counters, nil
+}
+
+// ResetCounters recomputes counter cache columns of the record with the primary
+// key from actual numbers of records in collections:
+//
+//	Author.ResetCounters(1, "books")
+//	// SELECT author_id, COUNT(*) FROM "books" WHERE (author_id IN (?)) GROUP BY author_id
+//	// UPDATE "authors" SET "id" = '1', "books_count" = '3' WHERE "id" = '1'
+//
+// The counter cache column of the collection is named "<collection>_count". Values
+// are updated without validations and callbacks.
+func (rel *Relation) ResetCounters(id interface{}, collNames ...string) error {
+	_, ids, err := rel.Where(rel.PrimaryKey(), id).resetCounters(collNames)
+	if err == nil && len(ids) == 0 {
+		return &ErrRecordNotFound{Relation: rel.name, PrimaryKey: rel.PrimaryKey(), ID: id}
+	}
+	return err
+}
+
+// ReconcileCounters recomputes counter cache columns of all records of the relation
+// in batches of the given size (DefaultCountersBatchSize, when the size is not
+// positive), so drifted counters are repaired without loading all records at once:
+//
+//	fixed, err := Author.ReconcileCounters(500, "books")
+//
+// The method returns the number of records with fixed counters, records with
+// correct counters are not updated.
+func (rel *Relation) ReconcileCounters(batchSize int, collNames ...string) (int64, error) {
+	if batchSize <= 0 {
+		batchSize = DefaultCountersBatchSize
+	}
+
+	var (
+		fixed int64
+		last  interface{}
+		batch = rel.Reorder(rel.PrimaryKey()).Limit(batchSize)
+	)
+	for {
+		next := batch
+		if last != nil {
+			next = batch.Where(rel.PrimaryKey(), GreaterThan(last))
+		}
+
+		n, ids, err := next.resetCounters(collNames)
+		fixed += n
+		if err != nil {
+			return fixed, err
+		}
+		if len(ids) < batchSize {
+			return fixed, nil
+		}
+		last = ids[len(ids)-1]
+	}
+}
+
+// resetCounters recomputes counters of records of the relation and returns the
+// number of fixed records and primary keys of all records.
+func (rel *Relation) resetCounters(collNames []string) (fixed int64, ids []interface{}, err error) {
+	counters, err := rel.counterCaches(collNames)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	attrNames := []string{rel.PrimaryKey()}
+	for _, counter := range counters {
+		attrNames = append(attrNames, counter.attrName)
+	}
+	rows, err := rel.Pluck(attrNames...)
+	if err != nil || len(rows) == 0 {
+		return 0, nil, err
+	}
+
+	ids = make([]interface{}, len(rows))
+	for i, row := range rows {
+		ids[i] = row[0]
+	}
+
+	counts := make([]map[string]int64, len(counters))
+	for i, counter := range counters {
+		grouped, err := counter.targets.
+			Where(counter.foreignKey, In(ids...)).
+			Group(counter.foreignKey).
+			GroupCount()
+		if err != nil {
+			return 0, nil, err
+		}
+		counts[i] = make(map[string]int64, len(grouped))
+		for key, count := range grouped {
+			counts[i][fmt.Sprint(key)] = count
+		}
+	}
+
+	pk := rel.scope.AttributeForInspect(rel.PrimaryKey())
+	for _, row := range rows {
+		columnValues := []ColumnValue{{Name: pk.AttributeName(), Type: pk.AttributeType(), Value: row[0]}}
+		for i, counter := range counters {
+			count := counts[i][fmt.Sprint(row[0])]
+			if value, ok := row[i+1].(int64); ok && value == count {
+				continue
+			}
+			columnValues = append(columnValues, ColumnValue{
+				Name:  counter.attrName,
+				Type:  rel.scope.AttributeForInspect(counter.attrName).AttributeType(),
+				Value: count,
+			})
+		}
+		if len(columnValues) == 1 {
+			continue
+		}
+
+		op := UpdateOperation{TableName: rel.tableName, PrimaryKey: pk.AttributeName(), ColumnValues: columnValues}
+		if err = rel.Connection().ExecUpdate(rel.Context(), &op); err != nil {
+			return fixed, ids, err
+		}
+		fixed++
+	}
+	return fixed, ids, nil
+}
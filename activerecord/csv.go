@@ -0,0 +1,107 @@
+package activerecord
+
+import (
+	"encoding/csv"
+	"io"
+)
+
+// DefaultBatchSize is the number of records retrieved by the single query, when
+// records of the relation are exported in batches.
+const DefaultBatchSize = 1000
+
+// csvExport are options of the CSV export.
+type csvExport struct {
+	columns   []string
+	batchSize int
+}
+
+// CSVOption configures the export of records into CSV.
+type CSVOption func(*csvExport)
+
+// Columns exports only given attributes of records in the given order.
+func Columns(attrNames ...string) CSVOption {
+	return func(e *csvExport) {
+		e.columns = append(e.columns, attrNames...)
+	}
+}
+
+// BatchSize sets the number of records retrieved by the single query.
+func BatchSize(size int) CSVOption {
+	return func(e *csvExport) {
+		e.batchSize = size
+	}
+}
+
+// ToCSV writes records of the relation as CSV, the first row is a header with
+// names of attributes. Records are retrieved in batches ordered by the primary
+// key, so the export does not load all records into the memory:
+//
+//	err := User.Where("active", true).ToCSV(w, activerecord.Columns("id", "email"), activerecord.BatchSize(5000))
+//
+// The order and the limit of the relation are ignored, each batch is written
+// to w before the next one is retrieved.
+func (rel *Relation) ToCSV(w io.Writer, opts ...CSVOption) error {
+	e := csvExport{batchSize: DefaultBatchSize}
+	for _, opt := range opts {
+		opt(&e)
+	}
+	if len(e.columns) == 0 {
+		e.columns = rel.scope.AttributeNames()
+	}
+	if e.batchSize <= 0 {
+		e.batchSize = DefaultBatchSize
+	}
+
+	primaryKey := rel.PrimaryKey()
+	attrNames := append(append([]string(nil), e.columns...), primaryKey)
+
+	types := make([]Type, len(e.columns))
+	for i, attrName := range e.columns {
+		attr := rel.scope.AttributeForInspect(attrName)
+		if attr == nil {
+			return &ErrUnknownAttribute{RecordName: rel.name, Attr: attrName}
+		}
+		types[i] = attr.AttributeType()
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(e.columns); err != nil {
+		return err
+	}
+
+	var (
+		batches = rel.Unscope(OrderClause, LimitClause).Order(primaryKey).Limit(e.batchSize)
+		record  = make([]string, len(e.columns))
+		last    interface{}
+	)
+	for {
+		batch := batches
+		if last != nil {
+			batch = batches.Where(primaryKey, GreaterThan(last))
+		}
+
+		rows, err := batch.Pluck(attrNames...)
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			for i := range record {
+				if record[i], err = attributeText(types[i], row[i]); err != nil {
+					return err
+				}
+			}
+			if err = cw.Write(record); err != nil {
+				return err
+			}
+		}
+
+		cw.Flush()
+		if err = cw.Error(); err != nil {
+			return err
+		}
+		if len(rows) < e.batchSize {
+			return nil
+		}
+		last = rows[len(rows)-1][len(e.columns)]
+	}
+}
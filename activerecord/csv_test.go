@@ -0,0 +1,57 @@
+package activerecord_test
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/activegraph/activegraph/activerecord"
+	_ "github.com/activegraph/activegraph/activerecord/sqlite3"
+	. "github.com/activegraph/activegraph/activesupport"
+)
+
+func TestRelation_ToCSV(t *testing.T) {
+	_, err := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter: "sqlite3", Database: t.Name() + ".db",
+	})
+	require.NoError(t, err)
+
+	defer os.Remove(t.Name() + ".db")
+	defer activerecord.RemoveConnection("primary")
+
+	initAuthorTable(t, nil)
+	initBookTable(t, nil)
+
+	Author := activerecord.New("author")
+	Book := activerecord.New("book")
+
+	author := Author.Create(Hash{"name": "Stanislaw Lem"}).Unwrap()
+	_, err = Book.InsertAll(
+		Hash{"title": "Solaris", "year": int64(1961), "author_id": author.ID()},
+		Hash{"title": "Eden", "year": int64(1959), "author_id": author.ID()},
+		Hash{"title": "Fiasco, the last novel", "year": int64(1986), "author_id": author.ID()},
+		Hash{"title": "Return from the Stars", "year": int64(1968), "author_id": author.ID()},
+		Hash{"title": "The Invincible", "year": int64(1964), "author_id": author.ID()},
+	)
+	require.NoError(t, err)
+
+	var b bytes.Buffer
+	err = Book.Where("year > ?", 1960).Order("year").ToCSV(&b,
+		activerecord.Columns("title", "id"), activerecord.BatchSize(2),
+	)
+	require.NoError(t, err)
+	require.Equal(t, "title,id\n"+
+		"Solaris,1\n"+
+		"\"Fiasco, the last novel\",3\n"+
+		"Return from the Stars,4\n"+
+		"The Invincible,5\n", b.String())
+
+	b.Reset()
+	require.NoError(t, Author.ToCSV(&b))
+	require.Equal(t, "id,name\n1,Stanislaw Lem\n", b.String())
+
+	err = Author.ToCSV(new(bytes.Buffer), activerecord.Columns("email"))
+	require.Error(t, err)
+}
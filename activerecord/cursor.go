@@ -0,0 +1,192 @@
+package activerecord
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// keysetColumn is a column of the keyset pagination with the direction of sorting.
+type keysetColumn struct {
+	Name string
+	Desc bool
+}
+
+// keyset returns columns used to paginate the relation: columns of the relation
+// order followed by the primary key, so records with equal values in ordered
+// columns are still strictly ordered.
+//
+// When the order is not composed of attribute names, keyset returns false.
+func (rel *Relation) keyset() (columns []keysetColumn, hasPK, ok bool) {
+	for _, value := range rel.build().orderValues {
+		for _, part := range strings.Split(value, ",") {
+			fields := strings.Fields(part)
+			if len(fields) == 0 || len(fields) > 2 {
+				return nil, false, false
+			}
+
+			column := keysetColumn{Name: fields[0]}
+			if len(fields) == 2 {
+				switch strings.ToUpper(fields[1]) {
+				case "ASC":
+				case "DESC":
+					column.Desc = true
+				default:
+					return nil, false, false
+				}
+			}
+			if !rel.scope.HasAttribute(column.Name) {
+				return nil, false, false
+			}
+
+			hasPK = hasPK || column.Name == rel.PrimaryKey()
+			columns = append(columns, column)
+		}
+	}
+
+	if !hasPK {
+		columns = append(columns, keysetColumn{Name: rel.PrimaryKey()})
+	}
+	return columns, hasPK, true
+}
+
+// Cursor returns an opaque cursor pointing to the given record within the ordered
+// relation. The cursor is used to retrieve the following records with After.
+//
+//	posts, _ := Post.Order("published_at DESC").Limit(20).ToA()
+//	cursor, _ := Post.Order("published_at DESC").Cursor(posts[len(posts)-1])
+func (rel *Relation) Cursor(record *ActiveRecord) (string, error) {
+	columns, _, ok := rel.keyset()
+	if !ok {
+		return "", &ErrCursor{Message: "order is not composed of attributes"}
+	}
+
+	values := make([]interface{}, len(columns))
+	for i, column := range columns {
+		value := record.Attribute(column.Name)
+		if value == nil {
+			return "", &ErrCursor{Message: fmt.Sprintf("attribute %q is nil", column.Name)}
+		}
+
+		attrType := rel.scope.AttributeForInspect(column.Name).AttributeType()
+		values[i] = serialize(attrType, value)
+	}
+
+	data, err := json.Marshal(values)
+	if err != nil {
+		return "", &ErrCursor{Message: err.Error()}
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// After returns records following the record pointed by the cursor in the order
+// of the relation. Unlike pagination with offset, cursors use conditions on the
+// ordered columns, so the query could use indices.
+//
+//	Post.Order("published_at DESC").After(cursor).Limit(20)
+//	// SELECT * FROM "posts"
+//	// WHERE ((published_at < ?) OR (published_at = ? AND id > ?))
+//	// ORDER BY published_at DESC, id LIMIT 20
+//
+// Order must be specified before calling After, the primary key is added to the
+// order, when it is not ordered yet. Empty cursor points to the beginning of the
+// relation, so the first page is ordered the same way as the following pages.
+//
+// ErrCursor is returned by queries of the relation, when the cursor is malformed
+// or does not match the order of the relation, e.g. cursors of another relation,
+// so clients could be responded with the error instead of the empty page.
+func (rel *Relation) After(cursor string) *Relation {
+	columns, hasPK, ok := rel.keyset()
+	if !ok {
+		return rel.withErr(&ErrCursor{Message: "order is not composed of attributes"})
+	}
+
+	newrel := rel.Copy()
+	if !hasPK {
+		newrel.query.Order(rel.PrimaryKey())
+	}
+	if cursor == "" {
+		return newrel
+	}
+
+	values, err := decodeCursor(cursor)
+	if err != nil {
+		return rel.withErr(&ErrCursor{Message: fmt.Sprintf("invalid cursor %q", cursor)})
+	}
+	if len(values) != len(columns) {
+		return rel.withErr(&ErrCursor{Message: fmt.Sprintf(
+			"cursor %q does not match the order of %s", cursor, rel.name,
+		)})
+	}
+
+	var (
+		conds = make([]string, len(columns))
+		args  []interface{}
+	)
+	for i, column := range columns {
+		var cond strings.Builder
+		for j := 0; j < i; j++ {
+			fmt.Fprintf(&cond, "%s = ? AND ", columns[j].Name)
+			args = append(args, values[j])
+		}
+
+		operator := ">"
+		if column.Desc {
+			operator = "<"
+		}
+		fmt.Fprintf(&cond, "%s %s ?", column.Name, operator)
+		args = append(args, values[i])
+
+		conds[i] = "(" + cond.String() + ")"
+	}
+
+	newrel.query.Where(strings.Join(conds, " OR "), args...)
+	return newrel
+}
+
+func decodeCursor(cursor string) ([]interface{}, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	var values []interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err = decoder.Decode(&values); err != nil {
+		return nil, err
+	}
+
+	// Numbers are decoded into the most precise representation, so integer
+	// keys are compared without precision loss.
+	for i, value := range values {
+		num, ok := value.(json.Number)
+		if !ok {
+			continue
+		}
+		if intval, err := num.Int64(); err == nil {
+			values[i] = intval
+		} else if values[i], err = num.Float64(); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+// ErrCursor is returned when the cursor cannot be created for the relation, or
+// the cursor is invalid.
+type ErrCursor struct {
+	Message string
+}
+
+func (e *ErrCursor) Is(target error) bool {
+	_, ok := target.(*ErrCursor)
+	return ok
+}
+
+// Error returns a string representation of the error.
+func (e *ErrCursor) Error() string {
+	return fmt.Sprintf("ErrCursor: %s", e.Message)
+}
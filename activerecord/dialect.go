@@ -0,0 +1,44 @@
+package activerecord
+
+import (
+	"strings"
+)
+
+// SchemaDialect compiles schema definition statements for the database. It is
+// implemented by connections to databases, which identifiers or column types
+// differ from the ANSI SQL:
+//
+//	func (c *Conn) QuoteIdentifier(name string) string {
+//		return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+//	}
+type SchemaDialect interface {
+	// QuoteIdentifier returns the name of the table, column or index quoted
+	// for the database.
+	QuoteIdentifier(name string) string
+
+	// NativeType returns the type of the column in the database, without
+	// column constraints.
+	NativeType(column ColumnDefinition) string
+}
+
+// DefaultDialect is the ANSI SQL dialect, it is used for connections, which do
+// not implement SchemaDialect interface.
+var DefaultDialect SchemaDialect = ansiDialect{}
+
+type ansiDialect struct{}
+
+func (ansiDialect) QuoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func (ansiDialect) NativeType(column ColumnDefinition) string {
+	return column.Type.NativeType()
+}
+
+// DialectOf returns the schema dialect of the connection.
+func DialectOf(conn Conn) SchemaDialect {
+	if d, ok := adapterConn(conn).(SchemaDialect); ok {
+		return d
+	}
+	return DefaultDialect
+}
@@ -0,0 +1,128 @@
+package activerecord
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/activegraph/activegraph/activesupport"
+)
+
+// AttributeChange is the change of the attribute value.
+type AttributeChange struct {
+	From interface{}
+	To   interface{}
+}
+
+// changes returns changes of attributes since they were loaded from the
+// database or saved last time.
+func (a *attributes) changes() map[string]AttributeChange {
+	changes := make(map[string]AttributeChange)
+	for attrName, value := range a.values {
+		original := a.original[attrName]
+		if !equalValues(a.keys[attrName], original, value) {
+			changes[attrName] = AttributeChange{From: original, To: value}
+		}
+	}
+	return changes
+}
+
+// persist marks values of attributes as stored in the database and remembers
+// their changes as saved changes.
+func (a *attributes) persist() {
+	a.savedChanges = a.changes()
+	a.original = a.values.Copy()
+}
+
+// equalValues returns true, when values are equal as values of the attribute
+// type, e.g. int and int64 values of Int64 attributes.
+func equalValues(attr Attribute, a, b interface{}) bool {
+	if reflect.DeepEqual(a, b) {
+		return true
+	}
+	if attr == nil || a == nil || b == nil {
+		return false
+	}
+	a1, err1 := attr.AttributeType().Deserialize(a)
+	b1, err2 := attr.AttributeType().Deserialize(b)
+	return err1 == nil && err2 == nil && reflect.DeepEqual(a1, b1)
+}
+
+// Changed returns true, when attributes of the record were changed since the
+// record was loaded from the database or saved last time.
+func (r *ActiveRecord) Changed() bool {
+	return len(r.attributes.changes()) > 0
+}
+
+// ChangedAttributes returns names of changed attributes in alphabetical order.
+func (r *ActiveRecord) ChangedAttributes() []string {
+	changes := r.attributes.changes()
+	names := make([]string, 0, len(changes))
+	for name := range changes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Changes returns changes of attributes, which are not saved yet:
+//
+//	book.AssignAttribute("title", "Eden")
+//	book.Changes() // {"title": {From: "Solaris", To: "Eden"}}
+func (r *ActiveRecord) Changes() map[string]AttributeChange {
+	return r.attributes.changes()
+}
+
+// SavedChanges returns changes of attributes stored by the last insert or update
+// of the record, e.g. to inspect changes within AfterSave callbacks.
+func (r *ActiveRecord) SavedChanges() map[string]AttributeChange {
+	changes := make(map[string]AttributeChange, len(r.attributes.savedChanges))
+	for name, change := range r.attributes.savedChanges {
+		changes[name] = change
+	}
+	return changes
+}
+
+// ChangeOperation is the operation of the record published within ChangeEvent.
+type ChangeOperation string
+
+const (
+	ChangeCreate  ChangeOperation = "create"
+	ChangeUpdate  ChangeOperation = "update"
+	ChangeDestroy ChangeOperation = "destroy"
+)
+
+// publishChange publishes ChangeEvent of the record after the commit of the
+// transaction of the record, so subscribers do not observe rolled back changes:
+//
+//	activesupport.Subscribe(activerecord.ChangeEvent, func(e activesupport.Event) {
+//		if e.Payload["record"] == "book" {
+//			cache.Delete(fmt.Sprintf("books/%v", e.Payload["id"]))
+//		}
+//	})
+//
+// Updates without changes of attributes are not published.
+func (r *ActiveRecord) publishChange(op ChangeOperation) {
+	var changes map[string]AttributeChange
+	if op != ChangeDestroy {
+		if changes = r.SavedChanges(); len(changes) == 0 {
+			return
+		}
+	}
+
+	attrNames := make([]string, 0, len(changes))
+	for attrName := range changes {
+		attrNames = append(attrNames, attrName)
+	}
+	sort.Strings(attrNames)
+
+	payload := activesupport.Hash{
+		"record":     r.name,
+		"id":         r.ID(),
+		"operation":  op,
+		"attributes": attrNames,
+		"changes":    changes,
+	}
+	r.connections.afterCommit(r.Context(), r.spec, func() {
+		activesupport.Publish(ChangeEvent, payload)
+	})
+}
@@ -0,0 +1,294 @@
+package activerecord
+
+import (
+	"context"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	. "github.com/activegraph/activegraph/activesupport"
+)
+
+// maxFixtureID is the maximum primary key of fixtures computed from labels, it
+// fits into signed 32-bit integer columns.
+const maxFixtureID = 1<<30 - 1
+
+// ErrFixture is returned, when the fixture cannot be loaded or it is missing.
+type ErrFixture struct {
+	TableName string
+	Label     string
+	Message   string
+}
+
+func (e *ErrFixture) Is(target error) bool {
+	_, ok := target.(*ErrFixture)
+	return ok
+}
+
+func (e *ErrFixture) Error() string {
+	if e.Label == "" {
+		return fmt.Sprintf("fixtures of %s: %s", e.TableName, e.Message)
+	}
+	return fmt.Sprintf("fixture %s of %s: %s", e.Label, e.TableName, e.Message)
+}
+
+// FixtureID returns the primary key of the fixture with the label. Keys are
+// derived from labels, so fixtures reference each other by labels regardless of
+// the order of loading.
+func FixtureID(label string) int64 {
+	return int64(crc32.ChecksumIEEE([]byte(label)) % maxFixtureID)
+}
+
+// FixtureSet is the set of fixtures loaded into tables.
+//
+// FixtureSet is safe for concurrent use.
+type FixtureSet struct {
+	mu  sync.RWMutex
+	ids map[string]map[string]interface{}
+}
+
+func newFixtureSet() *FixtureSet {
+	return &FixtureSet{ids: make(map[string]map[string]interface{})}
+}
+
+// merge adds fixtures of the other set, fixtures of the same tables are replaced.
+func (s *FixtureSet) merge(other *FixtureSet) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for tableName, ids := range other.ids {
+		s.ids[tableName] = ids
+	}
+}
+
+// ID returns the primary key of the fixture.
+func (s *FixtureSet) ID(tableName, label string) (interface{}, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	id, ok := s.ids[tableName][label]
+	return id, ok
+}
+
+// Fixture returns the record of the fixture, the record is found by the relation
+// of the table, so the relation must be declared before:
+//
+//	alice := fixtures.Fixture("users", "alice").Unwrap()
+func (s *FixtureSet) Fixture(tableName, label string) RecordResult {
+	id, ok := s.ID(tableName, label)
+	if !ok {
+		return ErrRecord(&ErrFixture{TableName: tableName, Label: label, Message: "not loaded"})
+	}
+	rel, err := globalReflection.Reflection(Singularize(tableName))
+	if err != nil {
+		return ErrRecord(err)
+	}
+	return rel.Find(id)
+}
+
+// loadedFixtures are all fixtures loaded with LoadFixtures.
+var loadedFixtures = newFixtureSet()
+
+// Fixture returns the record of the fixture loaded with LoadFixtures.
+//
+//	func TestUser(t *testing.T) {
+//		alice := activerecord.Fixture("users", "alice").Unwrap()
+//	}
+func Fixture(tableName, label string) RecordResult {
+	return loadedFixtures.Fixture(tableName, label)
+}
+
+// LoadFixtures loads YAML fixtures from the directory into tables, fixtures of
+// the table are read from "<table>.yml" file. When table names are not given,
+// all YAML files of the directory are loaded. Each file maps labels of fixtures
+// to values of columns:
+//
+//	# fixtures/users.yml
+//	alice:
+//	  name: Alice
+//
+//	# fixtures/posts.yml
+//	welcome:
+//	  title: Welcome
+//	  user: alice
+//
+// Primary keys of fixtures are derived from labels (see FixtureID), unless they
+// are given explicitly. Belongs-to associations reference fixtures by labels,
+// e.g. "user: alice" assigns the key of the "alice" fixture to "user_id" column.
+// Timestamps "created_at" and "updated_at" are set to the current time, when
+// they are missing.
+//
+// Existing rows of tables are deleted, fixtures are loaded within a transaction
+// of the connection specified in the context.
+func LoadFixtures(ctx context.Context, dir string, tableNames ...string) (*FixtureSet, error) {
+	if len(tableNames) == 0 {
+		var err error
+		if tableNames, err = fixtureTableNames(dir); err != nil {
+			return nil, err
+		}
+	}
+
+	files := make(map[string]map[string]Hash, len(tableNames))
+	for _, tableName := range tableNames {
+		fixtures, err := readFixtures(dir, tableName)
+		if err != nil {
+			return nil, err
+		}
+		files[tableName] = fixtures
+	}
+
+	set := newFixtureSet()
+	err := Transaction(ctx, func(ctx context.Context) error {
+		conn := rawConnection(ctx)
+		dialect := DialectOf(conn)
+
+		// Rows are deleted in the reverse order, so rows referencing rows of
+		// preceding tables are deleted first.
+		for i := len(tableNames) - 1; i >= 0; i-- {
+			stmt := "DELETE FROM " + dialect.QuoteIdentifier(tableNames[i])
+			if _, err := conn.ExecStatement(ctx, &QueryOperation{Text: stmt}); err != nil {
+				return err
+			}
+		}
+		for _, tableName := range tableNames {
+			ids, err := insertFixtures(ctx, conn, tableName, files[tableName])
+			if err != nil {
+				return err
+			}
+			set.ids[tableName] = ids
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	loadedFixtures.merge(set)
+	return set, nil
+}
+
+// fixtureTableNames returns names of tables of YAML files in the directory.
+func fixtureTableNames(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var tableNames []string
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".yml" && ext != ".yaml") {
+			continue
+		}
+		tableNames = append(tableNames, strings.TrimSuffix(entry.Name(), ext))
+	}
+	return tableNames, nil
+}
+
+// readFixtures returns fixtures of the table by labels.
+func readFixtures(dir, tableName string) (map[string]Hash, error) {
+	b, err := os.ReadFile(filepath.Join(dir, tableName+".yml"))
+	if os.IsNotExist(err) {
+		b, err = os.ReadFile(filepath.Join(dir, tableName+".yaml"))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var fixtures map[string]map[string]interface{}
+	if err = yaml.Unmarshal(b, &fixtures); err != nil {
+		return nil, &ErrFixture{TableName: tableName, Message: err.Error()}
+	}
+
+	rows := make(map[string]Hash, len(fixtures))
+	for label, row := range fixtures {
+		rows[label] = Hash(row)
+	}
+	return rows, nil
+}
+
+// insertFixtures inserts fixtures into the table and returns primary keys of
+// fixtures by labels.
+func insertFixtures(ctx context.Context, conn Conn, tableName string, fixtures map[string]Hash) (
+	map[string]interface{}, error,
+) {
+	columns, err := schemaColumnDefinitions(ctx, conn, tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		primaryKey string
+		types      = make(map[string]Type, len(columns))
+	)
+	for _, column := range columns {
+		types[column.Name] = column.Type
+		if column.IsPrimaryKey {
+			primaryKey = column.Name
+		}
+	}
+
+	// Fixtures are inserted in the order of labels, so the order of rows does
+	// not depend on the order of keys in the map.
+	labels := make([]string, 0, len(fixtures))
+	for label := range fixtures {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+
+	var (
+		ids = make(map[string]interface{}, len(fixtures))
+		now = time.Now().UTC()
+	)
+	for _, label := range labels {
+		row := fixtures[label].Copy()
+		if row == nil {
+			row = make(Hash)
+		}
+
+		if _, ok := row[primaryKey]; primaryKey != "" && !ok {
+			row[primaryKey] = FixtureID(label)
+		}
+		for _, timestamp := range []string{"created_at", "updated_at"} {
+			if _, ok := row[timestamp]; types[timestamp] != nil && !ok {
+				row[timestamp] = now
+			}
+		}
+
+		columnValues := make([]ColumnValue, 0, len(row))
+		for name, value := range row {
+			t, ok := types[name]
+			if !ok {
+				// References of associations by labels.
+				ref, isLabel := value.(string)
+				if t, ok = types[ForeignKey(name)]; !ok || !isLabel {
+					return nil, &ErrFixture{
+						TableName: tableName, Label: label, Message: fmt.Sprintf("unknown column %q", name),
+					}
+				}
+				name, value = ForeignKey(name), FixtureID(ref)
+			}
+
+			if value != nil {
+				if value, err = t.Deserialize(value); err != nil {
+					return nil, &ErrFixture{TableName: tableName, Label: label, Message: err.Error()}
+				}
+			}
+			columnValues = append(columnValues, ColumnValue{Name: name, Type: t, Value: value})
+		}
+
+		op := InsertOperation{TableName: tableName, PrimaryKey: primaryKey, ColumnValues: columnValues}
+		if _, err = conn.ExecInsert(ctx, &op); err != nil {
+			return nil, &ErrFixture{TableName: tableName, Label: label, Message: err.Error()}
+		}
+		if primaryKey != "" {
+			ids[label] = row[primaryKey]
+		}
+	}
+	return ids, nil
+}
@@ -0,0 +1,86 @@
+package activerecord_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/activegraph/activegraph/activerecord"
+	_ "github.com/activegraph/activegraph/activerecord/sqlite3"
+)
+
+func TestLoadFixtures(t *testing.T) {
+	_, err := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter: "sqlite3", Database: t.Name() + ".db",
+	})
+	require.NoError(t, err)
+
+	defer os.Remove(t.Name() + ".db")
+	defer activerecord.RemoveConnection("primary")
+
+	activerecord.Migrate(t.Name()+"_add_users_and_posts_tables", func(m *activerecord.M) {
+		m.CreateTable("users", func(t *activerecord.Table) {
+			t.String("name")
+			t.DateTime("created_at")
+		})
+		m.CreateTable("posts", func(t *activerecord.Table) {
+			t.String("title")
+			t.References("users")
+		})
+	})
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "users.yml"), []byte(`
+alice:
+  name: Alice
+bob:
+  id: 7
+  name: Bob
+`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "posts.yml"), []byte(`
+welcome:
+  title: Welcome
+  user: alice
+`), 0o644))
+
+	User := activerecord.New("user", func(r *activerecord.R) {
+		r.HasMany("posts")
+	})
+	Post := activerecord.New("post", func(r *activerecord.R) {
+		r.BelongsTo("user")
+	})
+
+	ctx := context.Background()
+	fixtures, err := activerecord.LoadFixtures(ctx, dir, "users", "posts")
+	require.NoError(t, err)
+
+	alice := activerecord.Fixture("users", "alice").Unwrap()
+	require.Equal(t, "Alice", alice.Attribute("name"))
+	require.Equal(t, activerecord.FixtureID("alice"), alice.ID())
+	require.NotNil(t, alice.Attribute("created_at"))
+
+	bob := fixtures.Fixture("users", "bob").Unwrap()
+	require.EqualValues(t, 7, bob.ID())
+
+	welcome := activerecord.Fixture("posts", "welcome").Unwrap()
+	user := welcome.Association("user").Unwrap()
+	require.Equal(t, alice.ID(), user.ID())
+
+	err = activerecord.Fixture("users", "carol").Err()
+	require.ErrorIs(t, err, new(activerecord.ErrFixture))
+
+	// Fixtures replace existing rows of tables.
+	_, err = activerecord.LoadFixtures(ctx, dir)
+	require.NoError(t, err)
+
+	count, err := User.Count()
+	require.NoError(t, err)
+	require.EqualValues(t, 2, count)
+
+	count, err = Post.Count()
+	require.NoError(t, err)
+	require.EqualValues(t, 1, count)
+}
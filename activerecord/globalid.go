@@ -0,0 +1,261 @@
+package activerecord
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/activegraph/activegraph/activesupport"
+)
+
+// globalIDScheme is the URI scheme of global identifiers.
+const globalIDScheme = "gid"
+
+var (
+	globalIDMu     sync.RWMutex
+	globalIDApp    = "activegraph"
+	globalIDSecret []byte
+)
+
+// SetGlobalIDApp sets the application name of global identifiers, so records of
+// different applications are not mixed up. By default the name is "activegraph".
+func SetGlobalIDApp(app string) {
+	globalIDMu.Lock()
+	defer globalIDMu.Unlock()
+	globalIDApp = app
+}
+
+// SetGlobalIDSecret sets the secret key of signed global identifiers.
+func SetGlobalIDSecret(secret []byte) {
+	globalIDMu.Lock()
+	defer globalIDMu.Unlock()
+	globalIDSecret = append([]byte(nil), secret...)
+}
+
+func currentGlobalIDConfig() (app string, secret []byte) {
+	globalIDMu.RLock()
+	defer globalIDMu.RUnlock()
+	return globalIDApp, globalIDSecret
+}
+
+// ErrGlobalID is returned, when the global identifier is malformed, expired or
+// its signature is not valid.
+type ErrGlobalID struct {
+	GlobalID string
+	Message  string
+}
+
+func (e *ErrGlobalID) Is(target error) bool {
+	_, ok := target.(*ErrGlobalID)
+	return ok
+}
+
+func (e *ErrGlobalID) Error() string {
+	return fmt.Sprintf("invalid global id %q: %s", e.GlobalID, e.Message)
+}
+
+// GlobalID is the identifier of the record unique across relations in the form
+// of URI: "gid://app/book/1".
+type GlobalID struct {
+	App       string
+	ModelName string
+	ID        string
+}
+
+// String returns the URI of the global identifier.
+func (gid GlobalID) String() string {
+	return fmt.Sprintf("%s://%s/%s/%s",
+		globalIDScheme, gid.App, url.PathEscape(gid.ModelName), url.PathEscape(gid.ID))
+}
+
+// ParseGlobalID parses the URI of the global identifier.
+func ParseGlobalID(s string) (GlobalID, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return GlobalID{}, &ErrGlobalID{GlobalID: s, Message: err.Error()}
+	}
+	if u.Scheme != globalIDScheme || u.Host == "" {
+		return GlobalID{}, &ErrGlobalID{GlobalID: s, Message: "not a gid URI"}
+	}
+
+	// Path is split before unescaping, since identifiers could contain slashes
+	// escaped by String.
+	parts := strings.Split(strings.TrimPrefix(u.EscapedPath(), "/"), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return GlobalID{}, &ErrGlobalID{GlobalID: s, Message: "model name or id is missing"}
+	}
+	for i, part := range parts {
+		if parts[i], err = url.PathUnescape(part); err != nil {
+			return GlobalID{}, &ErrGlobalID{GlobalID: s, Message: err.Error()}
+		}
+	}
+	return GlobalID{App: u.Host, ModelName: parts[0], ID: parts[1]}, nil
+}
+
+// GlobalID returns the URI identifying the record among records of all relations,
+// so the record could be referenced by a string, e.g. in arguments of jobs, and
+// located later with Locate:
+//
+//	book.GlobalID() // "gid://activegraph/book/1"
+func (r *ActiveRecord) GlobalID() string {
+	app, _ := currentGlobalIDConfig()
+	return GlobalID{App: app, ModelName: r.name, ID: fmt.Sprint(r.ID())}.String()
+}
+
+// Locate returns the record identified by the global identifier, the relation of
+// the record is found by its name, so the relation must be declared before:
+//
+//	book := activerecord.Locate(ctx, "gid://activegraph/book/1")
+//
+// Identifiers of other applications are rejected with ErrGlobalID.
+func Locate(ctx context.Context, gid string) RecordResult {
+	id, err := ParseGlobalID(gid)
+	if err != nil {
+		return ErrRecord(err)
+	}
+	if app, _ := currentGlobalIDConfig(); id.App != app {
+		return ErrRecord(&ErrGlobalID{GlobalID: gid, Message: "unknown application"})
+	}
+
+	rel, err := globalReflection.Reflection(id.ModelName)
+	if err != nil {
+		return ErrRecord(err)
+	}
+	pkAttr := rel.AttributeForInspect(rel.PrimaryKey())
+	if pkAttr == nil {
+		return ErrRecord(&ErrUnknownPrimaryKey{rel.PrimaryKey(), "not in attributes"})
+	}
+	pk, err := parseID(pkAttr.AttributeType(), id.ID)
+	if err != nil {
+		return ErrRecord(&ErrGlobalID{GlobalID: gid, Message: err.Error()})
+	}
+	return rel.WithContext(ctx).Find(pk)
+}
+
+// parseID returns the value of the primary key of the type parsed from the string.
+func parseID(t Type, s string) (interface{}, error) {
+	if n, ok := t.(Nil); ok {
+		t = n.Type
+	}
+	switch t.(type) {
+	case *Int64:
+		return strconv.ParseInt(s, 10, 64)
+	default:
+		return t.Deserialize(s)
+	}
+}
+
+// signedGlobalID is the payload of signed global identifiers.
+type signedGlobalID struct {
+	GlobalID  string `json:"gid"`
+	Purpose   string `json:"pur,omitempty"`
+	ExpiresAt int64  `json:"exp,omitempty"`
+}
+
+// signedGlobalIDOptions are options of signed global identifiers.
+type signedGlobalIDOptions struct {
+	purpose   string
+	expiresIn time.Duration
+}
+
+// SignedGlobalIDOption configures signed global identifiers.
+type SignedGlobalIDOption func(*signedGlobalIDOptions)
+
+// ForPurpose restricts the signed identifier to the purpose, so identifiers
+// issued, e.g. for password resets, cannot be used to sign in.
+func ForPurpose(purpose string) SignedGlobalIDOption {
+	return func(o *signedGlobalIDOptions) {
+		o.purpose = purpose
+	}
+}
+
+// ExpiresAfter expires the signed identifier after the given duration.
+func ExpiresAfter(d time.Duration) SignedGlobalIDOption {
+	return func(o *signedGlobalIDOptions) {
+		o.expiresIn = d
+	}
+}
+
+func globalIDSignature(secret []byte, payload string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// SignedGlobalID returns the global identifier of the record signed with the
+// secret (see SetGlobalIDSecret), so the identifier could be given to users,
+// e.g. in URLs, without exposing primary keys to tampering:
+//
+//	sgid, err := user.SignedGlobalID(
+//		activerecord.ForPurpose("password_reset"), activerecord.ExpiresAfter(time.Hour),
+//	)
+//	user := activerecord.LocateSigned(ctx, sgid, activerecord.ForPurpose("password_reset"))
+func (r *ActiveRecord) SignedGlobalID(opts ...SignedGlobalIDOption) (string, error) {
+	var o signedGlobalIDOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	_, secret := currentGlobalIDConfig()
+	if len(secret) == 0 {
+		return "", ErrArgument{Message: "secret of signed global ids is not set"}
+	}
+
+	sgid := signedGlobalID{GlobalID: r.GlobalID(), Purpose: o.purpose}
+	if o.expiresIn > 0 {
+		sgid.ExpiresAt = time.Now().Add(o.expiresIn).Unix()
+	}
+	b, err := json.Marshal(sgid)
+	if err != nil {
+		return "", err
+	}
+
+	payload := base64.RawURLEncoding.EncodeToString(b)
+	return payload + "--" + globalIDSignature(secret, payload), nil
+}
+
+// LocateSigned returns the record identified by the signed global identifier
+// returned by ActiveRecord.SignedGlobalID. Identifiers with invalid signatures,
+// expired identifiers and identifiers of other purposes are rejected with
+// ErrGlobalID.
+func LocateSigned(ctx context.Context, sgid string, opts ...SignedGlobalIDOption) RecordResult {
+	var o signedGlobalIDOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	_, secret := currentGlobalIDConfig()
+	if len(secret) == 0 {
+		return ErrRecord(ErrArgument{Message: "secret of signed global ids is not set"})
+	}
+
+	payload, signature, ok := strings.Cut(sgid, "--")
+	if !ok || !hmac.Equal([]byte(signature), []byte(globalIDSignature(secret, payload))) {
+		return ErrRecord(&ErrGlobalID{GlobalID: sgid, Message: "invalid signature"})
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return ErrRecord(&ErrGlobalID{GlobalID: sgid, Message: err.Error()})
+	}
+	var id signedGlobalID
+	if err = json.Unmarshal(b, &id); err != nil {
+		return ErrRecord(&ErrGlobalID{GlobalID: sgid, Message: err.Error()})
+	}
+
+	if id.Purpose != o.purpose {
+		return ErrRecord(&ErrGlobalID{GlobalID: sgid, Message: "purpose mismatch"})
+	}
+	if id.ExpiresAt > 0 && time.Now().Unix() >= id.ExpiresAt {
+		return ErrRecord(&ErrGlobalID{GlobalID: sgid, Message: "expired"})
+	}
+	return Locate(ctx, id.GlobalID)
+}
@@ -0,0 +1,82 @@
+package activerecord
+
+import (
+	"context"
+	"fmt"
+
+	. "github.com/activegraph/activegraph/activesupport"
+)
+
+// Indexer is the search index of records, e.g. the client of Elasticsearch or
+// Meilisearch, which stores documents of records by their primary keys.
+type Indexer interface {
+	// IndexRecords adds documents of records into the index or replaces
+	// existing documents of records.
+	IndexRecords(ctx context.Context, records ...*ActiveRecord) error
+	// DeleteRecords removes documents of records from the index.
+	DeleteRecords(ctx context.Context, records ...*ActiveRecord) error
+}
+
+// SyncsToIndex keeps the search index consistent with records of the relation.
+// Records are indexed after inserts and updates, and removed from the index after
+// destroys, once the transaction of the record is committed:
+//
+//	Book := activerecord.New("book", func(r *activerecord.R) {
+//		r.SyncsToIndex(indexer)
+//	})
+//
+// The index is updated after the commit, so errors of the indexer could not be
+// returned by operations of records, they are reported to subscribers of
+// IndexEvent. Use Reindex to rebuild the index of all records.
+func (r *R) SyncsToIndex(indexer Indexer) {
+	r.indexer = indexer
+	r.AfterSave(func(rec *ActiveRecord) error {
+		if len(rec.SavedChanges()) == 0 {
+			return nil
+		}
+		rec = rec.Copy()
+		rec.connections.afterCommit(rec.Context(), rec.spec, func() {
+			syncIndex(rec.Context(), rec.name, "index", Array{rec}, indexer.IndexRecords)
+		})
+		return nil
+	})
+	r.AfterDestroy(func(rec *ActiveRecord) error {
+		rec = rec.Copy()
+		rec.connections.afterCommit(rec.Context(), rec.spec, func() {
+			syncIndex(rec.Context(), rec.name, "delete", Array{rec}, indexer.DeleteRecords)
+		})
+		return nil
+	})
+}
+
+// syncIndex calls the indexer with records instrumented as IndexEvent.
+func syncIndex(
+	ctx context.Context,
+	recordName, operation string,
+	records Array,
+	fn func(context.Context, ...*ActiveRecord) error,
+) error {
+	ids := make([]interface{}, len(records))
+	for i, rec := range records {
+		ids[i] = rec.ID()
+	}
+	payload := Hash{"record": recordName, "operation": operation, "ids": ids}
+	return Instrument(IndexEvent, payload, func() error {
+		return fn(ctx, records...)
+	})
+}
+
+// Reindex indexes all records of the relation with the indexer of the relation
+// (see R.SyncsToIndex) in batches of DefaultBatchSize records:
+//
+//	err := activerecord.Reindex(Book.Where("published", true))
+//
+// Documents of records missing in the relation are not removed from the index.
+func Reindex(rel *Relation) error {
+	if rel.indexer == nil {
+		return ErrArgument{Message: fmt.Sprintf("%s does not sync to the index", rel.name)}
+	}
+	return rel.FindInBatches(DefaultBatchSize, func(records Array) error {
+		return syncIndex(rel.Context(), rel.name, "index", records, rel.indexer.IndexRecords)
+	})
+}
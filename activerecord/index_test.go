@@ -0,0 +1,137 @@
+package activerecord_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/activegraph/activegraph/activerecord"
+	_ "github.com/activegraph/activegraph/activerecord/sqlite3"
+	. "github.com/activegraph/activegraph/activesupport"
+)
+
+type memoryIndexer struct {
+	docs    map[interface{}]Hash
+	indexed int
+	err     error
+}
+
+func (ix *memoryIndexer) IndexRecords(ctx context.Context, records ...*activerecord.ActiveRecord) error {
+	if ix.err != nil {
+		return ix.err
+	}
+	for _, rec := range records {
+		ix.docs[rec.ID()] = rec.ToHash()
+	}
+	ix.indexed += len(records)
+	return nil
+}
+
+func (ix *memoryIndexer) DeleteRecords(ctx context.Context, records ...*activerecord.ActiveRecord) error {
+	for _, rec := range records {
+		delete(ix.docs, rec.ID())
+	}
+	return nil
+}
+
+func TestRelation_SyncsToIndex(t *testing.T) {
+	_, err := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter: "sqlite3", Database: t.Name() + ".db",
+	})
+	require.NoError(t, err)
+
+	defer os.Remove(t.Name() + ".db")
+	defer activerecord.RemoveConnection("primary")
+
+	initAuthorTable(t, nil)
+	initBookTable(t, nil)
+
+	indexer := &memoryIndexer{docs: make(map[interface{}]Hash)}
+	Author := activerecord.New("author", func(r *activerecord.R) {
+		r.SyncsToIndex(indexer)
+	})
+
+	author := Author.Create(Hash{"name": "Stanislaw Lem"}).Unwrap()
+	require.Equal(t, "Stanislaw Lem", indexer.docs[author.ID()]["name"])
+
+	// Records are indexed only after the commit of the transaction.
+	err = activerecord.Transaction(context.TODO(), func(tx context.Context) error {
+		author := author.WithContext(tx)
+		require.NoError(t, author.AssignAttribute("name", "Ursula Le Guin"))
+		if _, err := author.Update(); err != nil {
+			return err
+		}
+		require.Equal(t, "Stanislaw Lem", indexer.docs[author.ID()]["name"])
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, "Ursula Le Guin", indexer.docs[author.ID()]["name"])
+
+	err = activerecord.Transaction(context.TODO(), func(tx context.Context) error {
+		Author.WithContext(tx).Create(Hash{"name": "Philip K. Dick"})
+		return fmt.Errorf("rollback")
+	})
+	require.Error(t, err)
+	require.Len(t, indexer.docs, 1)
+
+	_, err = author.Delete()
+	require.NoError(t, err)
+	require.Len(t, indexer.docs, 0)
+
+	// Errors of the indexer are reported to subscribers.
+	var indexErr error
+	sub := Subscribe(activerecord.IndexEvent, func(e Event) {
+		indexErr = e.Err
+	})
+	defer Unsubscribe(sub)
+
+	indexer.err = errors.New("index is unavailable")
+	require.True(t, Author.Create(Hash{"name": "Stanislaw Lem"}).IsOk())
+	require.Equal(t, indexer.err, indexErr)
+	indexer.err = nil
+
+	for i := 0; i < 4; i++ {
+		Author.Create(Hash{"name": fmt.Sprintf("Author %d", i)}).Expect("failed to create author")
+	}
+	indexer.docs = make(map[interface{}]Hash)
+	indexer.indexed = 0
+
+	require.NoError(t, activerecord.Reindex(Author))
+	require.Len(t, indexer.docs, 5)
+	require.Equal(t, 5, indexer.indexed)
+
+	require.Error(t, activerecord.Reindex(activerecord.New("book")))
+}
+
+func TestRelation_FindInBatches(t *testing.T) {
+	_, err := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter: "sqlite3", Database: t.Name() + ".db",
+	})
+	require.NoError(t, err)
+
+	defer os.Remove(t.Name() + ".db")
+	defer activerecord.RemoveConnection("primary")
+
+	initAuthorTable(t, nil)
+
+	Author := activerecord.New("author")
+	for i := 0; i < 5; i++ {
+		Author.Create(Hash{"name": fmt.Sprintf("Author %d", i)}).Expect("failed to create author")
+	}
+
+	var sizes []int
+	err = Author.Order("name DESC").FindInBatches(2, func(authors activerecord.Array) error {
+		sizes = append(sizes, len(authors))
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []int{2, 2, 1}, sizes)
+
+	stop := errors.New("stop")
+	err = Author.FindInBatches(2, func(activerecord.Array) error { return stop })
+	require.Equal(t, stop, err)
+}
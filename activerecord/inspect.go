@@ -0,0 +1,141 @@
+package activerecord
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxInspectLength is the maximum number of characters of attribute values
+// rendered by String, longer values are truncated.
+const maxInspectLength = 50
+
+// inspectTimeFormat is the format of time attributes rendered by String.
+const inspectTimeFormat = "2006-01-02 15:04:05.999999999 MST"
+
+// inspectValue returns the representation of the attribute value, strings are
+// truncated to the given length (unless it is zero).
+func inspectValue(val interface{}, maxLength int) string {
+	switch val := val.(type) {
+	case nil:
+		return "nil"
+	case string:
+		return strconv.Quote(truncateInspect(val, maxLength))
+	case []byte:
+		return fmt.Sprintf("<%d bytes>", len(val))
+	case time.Time:
+		return strconv.Quote(val.Format(inspectTimeFormat))
+	case fmt.Stringer:
+		return truncateInspect(val.String(), maxLength)
+	default:
+		return truncateInspect(fmt.Sprintf("%#v", val), maxLength)
+	}
+}
+
+func truncateInspect(s string, maxLength int) string {
+	if maxLength <= 0 || utf8.RuneCountInString(s) <= maxLength {
+		return s
+	}
+	return string([]rune(s)[:maxLength]) + "..."
+}
+
+// inspectNames returns names of attributes in the order of inspection: the
+// primary key goes first, other attributes are sorted by names.
+func (r *ActiveRecord) inspectNames() []string {
+	var (
+		pk    = r.attributes.primaryKey
+		names = r.AttributeNames()
+	)
+	if pk == nil {
+		return names
+	}
+
+	ordered := make([]string, 0, len(names))
+	ordered = append(ordered, pk.AttributeName())
+	for _, name := range names {
+		if name != pk.AttributeName() {
+			ordered = append(ordered, name)
+		}
+	}
+	return ordered
+}
+
+// inspectAssociations returns summaries of loaded associations sorted by names,
+// targets are summarized by their primary keys.
+func (r *ActiveRecord) inspectAssociations() [][2]string {
+	names := make([]string, 0, len(r.associations.values))
+	for name := range r.associations.values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	summaries := make([][2]string, 0, len(names))
+	for _, name := range names {
+		summary := "nil"
+		if target := r.associations.values[name]; target != nil && target.attributes.primaryKey != nil {
+			summary = fmt.Sprintf("#<%s %s: %s>",
+				strings.Title(target.name),
+				target.attributes.primaryKey.AttributeName(),
+				inspectValue(target.ID(), maxInspectLength),
+			)
+		}
+		summaries = append(summaries, [2]string{name, summary})
+	}
+	return summaries
+}
+
+// String returns the representation of the record with attributes in a stable
+// order (primary key first), long values are truncated and loaded associations
+// are summarized:
+//
+//	book.String()
+//	// #<Book id: 1, title: "Solaris", year: 1961, author: #<Author id: 1>>
+func (r *ActiveRecord) String() string {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "#<%s ", strings.Title(r.name))
+
+	attrNames := r.inspectNames()
+	for i, attrName := range attrNames {
+		fmt.Fprintf(&buf, "%s: %s", attrName, inspectValue(r.Attribute(attrName), maxInspectLength))
+		if i < len(attrNames)-1 {
+			fmt.Fprint(&buf, ", ")
+		}
+	}
+	for _, assoc := range r.inspectAssociations() {
+		fmt.Fprintf(&buf, ", %s: %s", assoc[0], assoc[1])
+	}
+
+	fmt.Fprintf(&buf, ">")
+	return buf.String()
+}
+
+// PrettyPrint writes the multi-line representation of the record for debugging,
+// each attribute is written on a separate line and values are not truncated:
+//
+//	book.PrettyPrint(os.Stdout)
+//	// #<Book
+//	//   id: 1,
+//	//   title: "Solaris",
+//	//   year: 1961,
+//	//   author: #<Author id: 1>
+//	// >
+func (r *ActiveRecord) PrettyPrint(w io.Writer) error {
+	var (
+		attrNames = r.inspectNames()
+		assocs    = r.inspectAssociations()
+		lines     = make([]string, 0, len(attrNames)+len(assocs))
+	)
+	for _, attrName := range attrNames {
+		lines = append(lines, fmt.Sprintf("  %s: %s", attrName, inspectValue(r.Attribute(attrName), 0)))
+	}
+	for _, assoc := range assocs {
+		lines = append(lines, fmt.Sprintf("  %s: %s", assoc[0], assoc[1]))
+	}
+
+	_, err := fmt.Fprintf(w, "#<%s\n%s\n>\n", strings.Title(r.name), strings.Join(lines, ",\n"))
+	return err
+}
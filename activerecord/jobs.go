@@ -0,0 +1,38 @@
+package activerecord
+
+import (
+	"context"
+
+	. "github.com/activegraph/activegraph/activesupport"
+)
+
+// JobQueue is the queue of background jobs, e.g. the client of Sidekiq-like
+// queues or the channel of workers within the process.
+type JobQueue interface {
+	// Enqueue puts the job with the given name and arguments into the queue.
+	Enqueue(ctx context.Context, job string, args Hash) error
+}
+
+// AfterCommitEnqueue enqueues the job into the queue after the commit of the
+// transaction of the record, the job is not enqueued when the transaction is
+// rolled back. Without the transaction, the job is enqueued immediately:
+//
+//	User := activerecord.New("user", func(r *activerecord.R) {
+//		r.AfterSave(func(user *activerecord.ActiveRecord) error {
+//			user.AfterCommitEnqueue(queue, "welcome_email", Hash{"user_id": user.ID()})
+//			return nil
+//		})
+//	})
+//
+// So workers never observe records, which are not committed yet. Errors of the
+// queue are reported to subscribers of EnqueueEvent.
+func (r *ActiveRecord) AfterCommitEnqueue(q JobQueue, job string, args Hash) {
+	ctx := r.Context()
+	payload := Hash{"record": r.name, "job": job, "args": args}
+
+	r.connections.afterCommit(ctx, r.spec, func() {
+		Instrument(EnqueueEvent, payload, func() error {
+			return q.Enqueue(ctx, job, args)
+		})
+	})
+}
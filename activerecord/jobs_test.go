@@ -0,0 +1,79 @@
+package activerecord_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/activegraph/activegraph/activerecord"
+	_ "github.com/activegraph/activegraph/activerecord/sqlite3"
+	. "github.com/activegraph/activegraph/activesupport"
+)
+
+type memoryQueue struct {
+	jobs []string
+	err  error
+}
+
+func (q *memoryQueue) Enqueue(ctx context.Context, job string, args Hash) error {
+	if q.err != nil {
+		return q.err
+	}
+	q.jobs = append(q.jobs, fmt.Sprintf("%s(%v)", job, args["author_id"]))
+	return nil
+}
+
+func TestActiveRecord_AfterCommitEnqueue(t *testing.T) {
+	_, err := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter: "sqlite3", Database: t.Name() + ".db",
+	})
+	require.NoError(t, err)
+
+	defer os.Remove(t.Name() + ".db")
+	defer activerecord.RemoveConnection("primary")
+
+	initAuthorTable(t, nil)
+
+	queue := new(memoryQueue)
+	Author := activerecord.New("author", func(r *activerecord.R) {
+		r.AfterSave(func(author *activerecord.ActiveRecord) error {
+			author.AfterCommitEnqueue(queue, "welcome_email", Hash{"author_id": author.ID()})
+			return nil
+		})
+	})
+
+	// Without the transaction, the job is enqueued immediately.
+	author := Author.Create(Hash{"name": "Stanislaw Lem"}).Unwrap()
+	require.Equal(t, []string{fmt.Sprintf("welcome_email(%v)", author.ID())}, queue.jobs)
+
+	err = activerecord.Transaction(context.TODO(), func(tx context.Context) error {
+		Author.WithContext(tx).Create(Hash{"name": "Ursula Le Guin"})
+		require.Len(t, queue.jobs, 1)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, queue.jobs, 2)
+
+	err = activerecord.Transaction(context.TODO(), func(tx context.Context) error {
+		Author.WithContext(tx).Create(Hash{"name": "Philip K. Dick"})
+		return fmt.Errorf("rollback")
+	})
+	require.Error(t, err)
+	require.Len(t, queue.jobs, 2)
+
+	// Errors of the queue are reported to subscribers.
+	var enqueueErr error
+	sub := Subscribe(activerecord.EnqueueEvent, func(e Event) {
+		require.Equal(t, "welcome_email", e.Payload["job"])
+		enqueueErr = e.Err
+	})
+	defer Unsubscribe(sub)
+
+	queue.err = errors.New("queue is unavailable")
+	require.True(t, Author.Create(Hash{"name": "Stanislaw Lem"}).IsOk())
+	require.Equal(t, queue.err, enqueueErr)
+}
@@ -0,0 +1,217 @@
+package activerecord
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultLoaderWait is the time loaders wait for primary keys of the batch
+	// after the first one is requested.
+	DefaultLoaderWait = time.Millisecond
+
+	// DefaultLoaderMaxBatch is the maximum number of primary keys retrieved by
+	// loaders with a single query, larger batches are split.
+	DefaultLoaderMaxBatch = 500
+)
+
+// loaderOptions are options of record loaders.
+type loaderOptions struct {
+	wait     time.Duration
+	maxBatch int
+}
+
+// LoaderOption configures record loaders.
+type LoaderOption func(*loaderOptions)
+
+// LoaderWait sets the time loaders wait for primary keys of the batch.
+func LoaderWait(d time.Duration) LoaderOption {
+	return func(o *loaderOptions) {
+		o.wait = d
+	}
+}
+
+// LoaderMaxBatch sets the maximum number of primary keys retrieved with a single
+// query, the batch is dispatched immediately, when it is full.
+func LoaderMaxBatch(n int) LoaderOption {
+	return func(o *loaderOptions) {
+		o.maxBatch = n
+	}
+}
+
+// loaders keeps record loaders of the context by names of relations.
+type loaders struct {
+	mu      sync.Mutex
+	opts    loaderOptions
+	loaders map[string]*RecordLoader
+}
+
+type loadersKey struct{}
+
+// WithLoaders returns a copy of the context with the registry of record loaders,
+// so loaders returned by Loader for the context are shared, e.g. by resolvers of
+// the same GraphQL request:
+//
+//	func (h *Handler) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+//		ctx := activerecord.WithLoaders(r.Context())
+//
+//		// Both records are retrieved by a single query.
+//		go activerecord.Loader(ctx, Author).Load(1)
+//		go activerecord.Loader(ctx, Author).Load(2)
+//	}
+//
+// When the context already has loaders, it is returned as is.
+func WithLoaders(ctx context.Context, opts ...LoaderOption) context.Context {
+	if _, ok := ctx.Value(loadersKey{}).(*loaders); ok {
+		return ctx
+	}
+	l := &loaders{
+		opts:    loaderOptions{wait: DefaultLoaderWait, maxBatch: DefaultLoaderMaxBatch},
+		loaders: make(map[string]*RecordLoader),
+	}
+	for _, opt := range opts {
+		opt(&l.opts)
+	}
+	return context.WithValue(ctx, loadersKey{}, l)
+}
+
+// Loader returns the loader of records of the relation within the context. The
+// loader is created on the first call for the relation name and finds records
+// with the relation given on that call.
+//
+// When the context does not have loaders (see WithLoaders), a new loader is
+// returned, so only calls of the returned loader are batched.
+func Loader(ctx context.Context, rel *Relation) *RecordLoader {
+	l, ok := ctx.Value(loadersKey{}).(*loaders)
+	if !ok {
+		return newRecordLoader(ctx, rel, loaderOptions{
+			wait: DefaultLoaderWait, maxBatch: DefaultLoaderMaxBatch,
+		})
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	loader, ok := l.loaders[rel.Name()]
+	if !ok {
+		loader = newRecordLoader(ctx, rel, l.opts)
+		l.loaders[rel.Name()] = loader
+	}
+	return loader
+}
+
+// loaderBatch is the batch of primary keys retrieved with a single query.
+type loaderBatch struct {
+	ids  []interface{}
+	keys map[string]struct{}
+	once sync.Once
+	done chan struct{}
+
+	records map[string]*ActiveRecord
+	err     error
+}
+
+// RecordLoader coalesces concurrent finds of records by primary keys into a
+// single query per batch: primary keys requested within the wait time are
+// retrieved with "WHERE id IN (...)". Found records are memoized, so the loader
+// is scoped to a request, see WithLoaders.
+//
+// RecordLoader is safe for concurrent use.
+type RecordLoader struct {
+	ctx  context.Context
+	rel  *Relation
+	opts loaderOptions
+
+	mu      sync.Mutex
+	batch   *loaderBatch
+	records map[string]*ActiveRecord
+}
+
+func newRecordLoader(ctx context.Context, rel *Relation, opts loaderOptions) *RecordLoader {
+	return &RecordLoader{
+		ctx:     ctx,
+		rel:     rel.WithContext(ctx),
+		opts:    opts,
+		records: make(map[string]*ActiveRecord),
+	}
+}
+
+// Load returns the record with the primary key, like Relation.Find, but the
+// record is retrieved together with records requested concurrently by other
+// calls of the loader.
+//
+//	author := activerecord.Loader(ctx, Author).Load(book.Attribute("author_id"))
+func (l *RecordLoader) Load(id interface{}) RecordResult {
+	key := fmt.Sprint(id)
+
+	l.mu.Lock()
+	if rec, ok := l.records[key]; ok {
+		l.mu.Unlock()
+		return OkRecord(rec)
+	}
+
+	b := l.batch
+	if b == nil {
+		b = &loaderBatch{keys: make(map[string]struct{}), done: make(chan struct{})}
+		l.batch = b
+		time.AfterFunc(l.opts.wait, func() { l.dispatch(b) })
+	}
+	if _, ok := b.keys[key]; !ok {
+		b.keys[key] = struct{}{}
+		b.ids = append(b.ids, id)
+	}
+	full := l.opts.maxBatch > 0 && len(b.ids) >= l.opts.maxBatch
+	l.mu.Unlock()
+
+	if full {
+		l.dispatch(b)
+	}
+
+	select {
+	case <-b.done:
+	case <-l.ctx.Done():
+		return ErrRecord(l.ctx.Err())
+	}
+
+	if b.err != nil {
+		return ErrRecord(b.err)
+	}
+	rec, ok := b.records[key]
+	if !ok {
+		return ErrRecord(&ErrRecordNotFound{Relation: l.rel.Name(), PrimaryKey: l.rel.PrimaryKey(), ID: id})
+	}
+	return OkRecord(rec)
+}
+
+// dispatch retrieves records of the batch, the batch is retrieved only once,
+// either by the timer or when it is full.
+func (l *RecordLoader) dispatch(b *loaderBatch) {
+	l.mu.Lock()
+	if l.batch == b {
+		l.batch = nil
+	}
+	l.mu.Unlock()
+
+	b.once.Do(func() {
+		defer close(b.done)
+
+		records, err := l.rel.Where(l.rel.PrimaryKey(), In(b.ids...)).ToA()
+		if err != nil {
+			b.err = err
+			return
+		}
+
+		b.records = make(map[string]*ActiveRecord, len(records))
+		for _, rec := range records {
+			b.records[fmt.Sprint(rec.ID())] = rec
+		}
+
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		for key, rec := range b.records {
+			l.records[key] = rec
+		}
+	})
+}
@@ -0,0 +1,75 @@
+package activerecord_test
+
+import (
+	"context"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/activegraph/activegraph/activerecord"
+	_ "github.com/activegraph/activegraph/activerecord/sqlite3"
+	. "github.com/activegraph/activegraph/activesupport"
+)
+
+func TestRecordLoader_Load(t *testing.T) {
+	_, err := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter: "sqlite3", Database: t.Name() + ".db",
+	})
+	require.NoError(t, err)
+
+	defer os.Remove(t.Name() + ".db")
+	defer activerecord.RemoveConnection("primary")
+
+	activerecord.Migrate(t.Name()+"_add_authors_table", func(m *activerecord.M) {
+		m.CreateTable("authors", func(t *activerecord.Table) {
+			t.String("name")
+		})
+	})
+
+	Author := activerecord.New("author")
+	var ids []interface{}
+	for _, name := range []string{"Lem", "Strugatsky", "Bradbury"} {
+		ids = append(ids, Author.Create(Hash{"name": name}).Unwrap().ID())
+	}
+
+	var queries int32
+	sub := Subscribe(activerecord.SQLEvent, func(Event) {
+		atomic.AddInt32(&queries, 1)
+	})
+	defer Unsubscribe(sub)
+
+	ctx := activerecord.WithLoaders(context.Background(), activerecord.LoaderWait(50*time.Millisecond))
+
+	var (
+		wg      sync.WaitGroup
+		results = make([]activerecord.RecordResult, len(ids)+1)
+	)
+	for i, id := range append(ids, int64(42)) {
+		wg.Add(1)
+		go func(i int, id interface{}) {
+			defer wg.Done()
+			results[i] = activerecord.Loader(ctx, Author).Load(id)
+		}(i, id)
+	}
+	wg.Wait()
+
+	require.EqualValues(t, 1, atomic.LoadInt32(&queries))
+	require.Equal(t, "Lem", results[0].Unwrap().Attribute("name"))
+	require.Equal(t, "Bradbury", results[2].Unwrap().Attribute("name"))
+	require.ErrorIs(t, results[3].Err(), new(activerecord.ErrRecordNotFound))
+
+	// Loaded records are memoized.
+	rec := activerecord.Loader(ctx, Author).Load(ids[1]).Unwrap()
+	require.Equal(t, "Strugatsky", rec.Attribute("name"))
+	require.EqualValues(t, 1, atomic.LoadInt32(&queries))
+
+	// Full batches are dispatched without waiting.
+	ctx = activerecord.WithLoaders(context.Background(), activerecord.LoaderMaxBatch(1))
+	rec = activerecord.Loader(ctx, Author).Load(ids[0]).Unwrap()
+	require.Equal(t, "Lem", rec.Attribute("name"))
+	require.EqualValues(t, 2, atomic.LoadInt32(&queries))
+}
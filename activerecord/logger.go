@@ -0,0 +1,372 @@
+package activerecord
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/activegraph/activegraph/activesupport"
+)
+
+// Names of events instrumented by relations and database adapters, subscribers
+// are registered with activesupport.Subscribe:
+//
+//	activesupport.Subscribe(activerecord.SQLEvent, func(e activesupport.Event) {
+//		metrics.Observe(e.Payload["sql"].(string), e.Duration)
+//	})
+const (
+	// SQLEvent is the statement executed by the database adapter, the payload
+	// has "sql", "binds", "rows", "caller" and "tags" keys.
+	SQLEvent = "sql.active_record"
+	// TransactionEvent is the transaction block, the payload has "attempt",
+	// "isolation" and "read_only" keys.
+	TransactionEvent = "transaction.active_record"
+	// CallbackEvent is the callback of the record, the payload has "record",
+	// "callback" and "key" keys.
+	CallbackEvent = "callback.active_record"
+	// ChangeEvent is the change of the record published after the commit of
+	// the transaction, the payload has "record", "id", "operation", "attributes"
+	// and "changes" keys.
+	ChangeEvent = "change.active_record"
+	// IndexEvent is the synchronization of records with the search index, the
+	// payload has "record", "operation" and "ids" keys.
+	IndexEvent = "index.active_record"
+	// CacheEvent is the write or the delete of the record in the record cache
+	// after the commit of the transaction, the payload has "record", "operation"
+	// and "key" keys.
+	CacheEvent = "cache.active_record"
+	// EnqueueEvent is the job enqueued after the commit of the transaction, the
+	// payload has "record", "job" and "args" keys.
+	EnqueueEvent = "enqueue.active_record"
+)
+
+// QueryEvent describes the query executed by the database adapter.
+type QueryEvent struct {
+	// SQL is the text of the executed statement.
+	SQL string
+	// Binds are arguments of the statement.
+	Binds []interface{}
+	// Duration is the execution time of the statement.
+	Duration time.Duration
+	// Rows is the number of returned rows of queries and the number of affected
+	// rows of other statements.
+	Rows int64
+	// Err is the error of the statement execution.
+	Err error
+	// Caller is the location ("file:line") of the application code that caused
+	// the execution of the statement.
+	Caller string
+	// Tags are log tags of the statement context.
+	Tags LogTags
+}
+
+// LogTag is a key-value pair attached to the queries executed within the context.
+type LogTag struct {
+	Key   string
+	Value string
+}
+
+// LogTags is a list of log tags.
+type LogTags []LogTag
+
+// Get returns the value of the tag with the given key.
+func (tags LogTags) Get(key string) (value string, ok bool) {
+	for i := range tags {
+		if tags[i].Key == key {
+			return tags[i].Value, true
+		}
+	}
+	return "", false
+}
+
+// String returns tags in the "key=value" format separated by spaces.
+func (tags LogTags) String() string {
+	pairs := make([]string, len(tags))
+	for i := range tags {
+		pairs[i] = tags[i].Key + "=" + tags[i].Value
+	}
+	return strings.Join(pairs, " ")
+}
+
+type logTagsKey struct{}
+
+// WithLogTags returns a copy of the context with the log tags defined by key-value
+// pairs. Tags are passed to the query logger and added as comments to queries
+// generated by relations, so the queries could be correlated with requests:
+//
+//	ctx = activerecord.WithLogTags(ctx, "request_id", id, "controller", "users")
+//	User.WithContext(ctx).ToA()
+//	// SELECT * FROM "users" /* request_id=42 controller=users */
+//
+// Tags of the parent context are kept, tags with the same key are replaced.
+// Keys and values are formatted with fmt.Sprint.
+func WithLogTags(ctx context.Context, keyvals ...interface{}) context.Context {
+	parent := LogTagsFromContext(ctx)
+	tags := make(LogTags, len(parent), len(parent)+(len(keyvals)+1)/2)
+	copy(tags, parent)
+
+	for i := 0; i < len(keyvals); i += 2 {
+		tag := LogTag{Key: fmt.Sprint(keyvals[i])}
+		if i+1 < len(keyvals) {
+			tag.Value = fmt.Sprint(keyvals[i+1])
+		}
+
+		replaced := false
+		for j := range tags {
+			if tags[j].Key == tag.Key {
+				tags[j], replaced = tag, true
+				break
+			}
+		}
+		if !replaced {
+			tags = append(tags, tag)
+		}
+	}
+	return context.WithValue(ctx, logTagsKey{}, tags)
+}
+
+// LogTagsFromContext returns log tags attached to the context.
+func LogTagsFromContext(ctx context.Context) LogTags {
+	tags, _ := ctx.Value(logTagsKey{}).(LogTags)
+	return tags
+}
+
+// QueryLogger logs queries executed by the database adapters.
+//
+// Applications implement the logger in order to ship query logs to their own
+// logging stack:
+//
+//	type zapLogger struct{ *zap.Logger }
+//
+//	func (l zapLogger) LogQuery(ctx context.Context, e *activerecord.QueryEvent) {
+//		l.Info(e.SQL, zap.Duration("duration", e.Duration), zap.Error(e.Err))
+//	}
+//
+//	activerecord.SetQueryLogger(zapLogger{logger})
+type QueryLogger interface {
+	LogQuery(ctx context.Context, event *QueryEvent)
+}
+
+// NopLogger discards all queries.
+type NopLogger struct{}
+
+// LogQuery implements QueryLogger interface.
+func (NopLogger) LogQuery(context.Context, *QueryEvent) {}
+
+// WriterLogger writes queries to the writer in the human-readable format.
+type WriterLogger struct {
+	// Caller enables logging of the application code location, which caused
+	// the query.
+	Caller bool
+
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewWriterLogger returns a new logger writing queries to w.
+func NewWriterLogger(w io.Writer) *WriterLogger {
+	return &WriterLogger{w: w}
+}
+
+// LogQuery implements QueryLogger interface.
+func (l *WriterLogger) LogQuery(ctx context.Context, e *QueryEvent) {
+	var buf strings.Builder
+
+	if len(e.Tags) > 0 {
+		fmt.Fprintf(&buf, "[%s] ", e.Tags)
+	}
+	buf.WriteString(e.SQL)
+	if len(e.Binds) > 0 {
+		fmt.Fprintf(&buf, " %v", e.Binds)
+	}
+	fmt.Fprintf(&buf, " (%s)", e.Duration.Round(time.Microsecond))
+	if e.Err != nil {
+		fmt.Fprintf(&buf, " error: %s", e.Err)
+	}
+	if l.Caller && e.Caller != "" {
+		fmt.Fprintf(&buf, "\n  ↳ %s", e.Caller)
+	}
+	buf.WriteString("\n")
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	io.WriteString(l.w, buf.String())
+}
+
+// DefaultSlowQueryThreshold is the default duration of statements reported to
+// slow query hooks.
+const DefaultSlowQueryThreshold = 500 * time.Millisecond
+
+var (
+	queryLoggerMu      sync.RWMutex
+	queryLogger        QueryLogger = NewWriterLogger(os.Stdout)
+	slowQueryThreshold             = DefaultSlowQueryThreshold
+	slowQueryHooks     []func(QueryEvent)
+)
+
+// SetQueryLogger sets the logger of queries executed by all database adapters,
+// nil logger discards queries. By default queries are written to stdout.
+func SetQueryLogger(logger QueryLogger) {
+	if logger == nil {
+		logger = NopLogger{}
+	}
+
+	queryLoggerMu.Lock()
+	defer queryLoggerMu.Unlock()
+	queryLogger = logger
+}
+
+// SetSlowQueryThreshold sets the minimum duration of statements reported to slow
+// query hooks, zero threshold disables reporting.
+func SetSlowQueryThreshold(d time.Duration) {
+	queryLoggerMu.Lock()
+	defer queryLoggerMu.Unlock()
+	slowQueryThreshold = d
+}
+
+// OnSlowQuery registers the hook called for each statement executed longer than
+// the slow query threshold (DefaultSlowQueryThreshold unless changed with
+// SetSlowQueryThreshold). Hooks are called synchronously after the statement is
+// finished, so they should not block.
+//
+//	activerecord.OnSlowQuery(func(e activerecord.QueryEvent) {
+//		log.Printf("slow query (%s) at %s: %s", e.Duration, e.Caller, e.SQL)
+//	})
+func OnSlowQuery(fn func(event QueryEvent)) {
+	queryLoggerMu.Lock()
+	defer queryLoggerMu.Unlock()
+	slowQueryHooks = append(slowQueryHooks, fn)
+}
+
+// currentQueryLogger returns the configured query logger and slow query hooks.
+func currentQueryLogger() (QueryLogger, time.Duration, []func(QueryEvent)) {
+	queryLoggerMu.RLock()
+	defer queryLoggerMu.RUnlock()
+
+	if slowQueryThreshold <= 0 {
+		return queryLogger, 0, nil
+	}
+	return queryLogger, slowQueryThreshold, slowQueryHooks
+}
+
+// LogQuery starts logging of the statement, the returned function must be called
+// by the database adapter when the statement is finished:
+//
+//	done := activerecord.LogQuery(ctx, stmt, args)
+//	result, err := db.ExecContext(ctx, stmt, args...)
+//	done(rowsAffected, err)
+func LogQuery(ctx context.Context, sql string, binds []interface{}) (done func(rows int64, err error)) {
+	logger, threshold, hooks := currentQueryLogger()
+	_, nop := logger.(NopLogger)
+	listening := activesupport.DefaultNotifications.Listening(SQLEvent)
+	if nop && len(hooks) == 0 && !listening {
+		return func(int64, error) {}
+	}
+
+	var (
+		start  = time.Now()
+		caller = queryCallerFromContext(ctx)
+		notify func(rows int64, err error)
+	)
+	if listening {
+		payload := activesupport.Hash{
+			"sql": sql, "binds": binds, "caller": caller, "tags": LogTagsFromContext(ctx),
+		}
+		finish := activesupport.DefaultNotifications.Start(SQLEvent, payload)
+		notify = func(rows int64, err error) {
+			payload["rows"] = rows
+			finish(err)
+		}
+	}
+	return func(rows int64, err error) {
+		event := QueryEvent{
+			SQL:      sql,
+			Binds:    binds,
+			Duration: time.Since(start),
+			Rows:     rows,
+			Err:      err,
+			Caller:   caller,
+			Tags:     LogTagsFromContext(ctx),
+		}
+		logger.LogQuery(ctx, &event)
+
+		if notify != nil {
+			notify(rows, err)
+		}
+
+		if event.Duration >= threshold {
+			for _, hook := range hooks {
+				hook(event)
+			}
+		}
+	}
+}
+
+// libraryPackages are packages skipped when looking for the caller of the query.
+var libraryPackages = []string{
+	"github.com/activegraph/activegraph/activerecord",
+	"github.com/activegraph/activegraph/activesupport",
+	"runtime",
+}
+
+// queryCaller returns the location of the first function on the call stack,
+// which does not belong to the library packages.
+func queryCaller() string {
+	pc := make([]uintptr, 32)
+	n := runtime.Callers(3, pc)
+	frames := runtime.CallersFrames(pc[:n])
+
+	for {
+		frame, more := frames.Next()
+		if !isLibraryFunc(frame.Function) {
+			return fmt.Sprintf("%s:%d", frame.File, frame.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+type queryCallerKey struct{}
+
+// withQueryCaller returns a copy of the context with the caller of the query, so
+// the caller is reported for queries executed in other goroutines.
+func withQueryCaller(ctx context.Context) context.Context {
+	return context.WithValue(ctx, queryCallerKey{}, queryCaller())
+}
+
+// queryCallerFromContext returns the caller of the query stored in the context,
+// or the caller on the call stack.
+func queryCallerFromContext(ctx context.Context) string {
+	if caller, ok := ctx.Value(queryCallerKey{}).(string); ok {
+		return caller
+	}
+	return queryCaller()
+}
+
+// isLibraryFunc returns true when the function (as reported by the runtime)
+// belongs to one of the library packages. Tests of the library packages are
+// treated as the application code.
+func isLibraryFunc(function string) bool {
+	// Package path of the function ends before the first dot after the last slash.
+	pkg := function
+	slash := strings.LastIndex(pkg, "/")
+	if dot := strings.Index(pkg[slash+1:], "."); dot >= 0 {
+		pkg = pkg[:slash+1+dot]
+	}
+	if strings.HasSuffix(pkg, "_test") {
+		return false
+	}
+
+	for _, library := range libraryPackages {
+		if pkg == library || strings.HasPrefix(pkg, library+"/") {
+			return true
+		}
+	}
+	return false
+}
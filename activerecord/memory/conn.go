@@ -0,0 +1,742 @@
+// Package memory implements the in-memory database adapter, so models could be
+// tested without a database server:
+//
+//	import _ "github.com/activegraph/activegraph/activerecord/memory"
+//
+//	activerecord.EstablishConnection(activerecord.DatabaseConfig{
+//		Adapter: "memory", Database: "test",
+//	})
+//
+// Connections to the database with the same name share the data, until the last
+// connection is closed. Connections with an empty database name always use a new
+// database.
+//
+// The adapter interprets the subset of SQL generated by relations: selects with
+// joins, filters, grouping, ordering and limits, and simple insert, update and
+// delete statements. Primary keys are the only enforced unique constraints and
+// foreign keys are not enforced.
+//
+// Transactions work on copies of modified tables, and the commit fails with
+// activerecord.ErrSerializationFailure, when the same table was modified by the
+// concurrent transaction.
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/activegraph/activegraph/activerecord"
+	"github.com/activegraph/activegraph/activerecord/ansi"
+	"github.com/activegraph/activegraph/activesupport"
+)
+
+func init() {
+	activerecord.RegisterConnectionAdapter("memory", Connect)
+}
+
+type table struct {
+	name       string
+	columns    []activerecord.ColumnDefinition
+	primaryKey string
+	rows       []map[string]interface{}
+	sequence   int64
+	version    int64
+}
+
+func (t *table) columnNames() []string {
+	names := make([]string, len(t.columns))
+	for i := range t.columns {
+		names[i] = t.columns[i].Name
+	}
+	return names
+}
+
+func (t *table) column(name string) (activerecord.ColumnDefinition, bool) {
+	for _, column := range t.columns {
+		if strings.EqualFold(column.Name, name) {
+			return column, true
+		}
+	}
+	return activerecord.ColumnDefinition{}, false
+}
+
+func (t *table) clone() *table {
+	newt := *t
+	newt.rows = make([]map[string]interface{}, len(t.rows))
+	for i, row := range t.rows {
+		newt.rows[i] = make(map[string]interface{}, len(row))
+		for k, v := range row {
+			newt.rows[i][k] = v
+		}
+	}
+	return &newt
+}
+
+// value converts the value to the representation stored in the column.
+func (t *table) value(column activerecord.ColumnDefinition, value interface{}) (interface{}, error) {
+	if column.Type != nil {
+		var err error
+		if value, err = column.Type.Serialize(value); err != nil {
+			return nil, err
+		}
+	}
+	value, err := normalize(value)
+	if err != nil {
+		return nil, err
+	}
+	if column.Type == nil || value == nil {
+		return value, nil
+	}
+
+	switch column.Type.NativeType() {
+	case "FLOAT":
+		if i, ok := value.(int64); ok {
+			return float64(i), nil
+		}
+	case "INTEGER":
+		if f, ok := value.(float64); ok && f == float64(int64(f)) {
+			return int64(f), nil
+		}
+	}
+	return value, nil
+}
+
+// find returns the index of row with the given primary key.
+func (t *table) find(pk interface{}) int {
+	for i, row := range t.rows {
+		if c, ok := compare(row[t.primaryKey], pk); ok && c == 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+// set assigns values to the columns of the row.
+func (t *table) set(row map[string]interface{}, values map[string]interface{}) error {
+	for name, value := range values {
+		column, ok := t.column(name)
+		if !ok {
+			return fmt.Errorf("memory: table %s has no column named %s", t.name, name)
+		}
+		value, err := t.value(column, value)
+		if err != nil {
+			return err
+		}
+		row[column.Name] = value
+	}
+	return nil
+}
+
+// insert inserts the row into the table and returns the primary key of the row.
+func (t *table) insert(values map[string]interface{}, onDuplicate string) (interface{}, error) {
+	row := make(map[string]interface{}, len(t.columns))
+	for _, column := range t.columns {
+		row[column.Name] = nil
+	}
+	if err := t.set(row, values); err != nil {
+		return nil, err
+	}
+
+	pk := row[t.primaryKey]
+	if pk == nil {
+		if column, _ := t.column(t.primaryKey); column.Type == nil ||
+			column.Type.NativeType() == "INTEGER" {
+			t.sequence++
+			pk = t.sequence
+			row[t.primaryKey] = pk
+		}
+	}
+	if id, ok := pk.(int64); ok && id > t.sequence {
+		t.sequence = id
+	}
+
+	for _, column := range t.columns {
+		if column.NotNull && row[column.Name] == nil {
+			return nil, fmt.Errorf("memory: NOT NULL constraint failed: %s.%s", t.name, column.Name)
+		}
+	}
+
+	if i := t.find(pk); i >= 0 {
+		switch onDuplicate {
+		case activerecord.OnDuplicateSkip:
+			return pk, nil
+		case activerecord.OnDuplicateUpdate:
+			return pk, t.set(t.rows[i], values)
+		}
+		return nil, &activerecord.ErrRecordNotUnique{
+			Err: fmt.Errorf("memory: UNIQUE constraint failed: %s.%s", t.name, t.primaryKey),
+		}
+	}
+
+	t.rows = append(t.rows, row)
+	return pk, nil
+}
+
+type database struct {
+	mu     sync.RWMutex
+	name   string
+	tables map[string]*table
+	refs   int
+}
+
+var (
+	databasesMu sync.Mutex
+	databases   = make(map[string]*database)
+)
+
+// transaction keeps tables modified within the transaction.
+type transaction struct {
+	parent   *transaction
+	readOnly bool
+	tables   map[string]*table
+	// versions are versions of the modified tables at the moment of the first
+	// modification, the zero version corresponds to the absent table.
+	versions map[string]int64
+	done     bool
+}
+
+func (tx *transaction) root() *transaction {
+	for tx.parent != nil {
+		tx = tx.parent
+	}
+	return tx
+}
+
+type Conn struct {
+	db *database
+	tx *transaction
+}
+
+func Connect(conf activerecord.DatabaseConfig) (activerecord.Conn, error) {
+	databasesMu.Lock()
+	defer databasesMu.Unlock()
+
+	db, ok := databases[conf.Database]
+	if !ok || conf.Database == "" {
+		db = &database{name: conf.Database, tables: make(map[string]*table)}
+		if conf.Database != "" {
+			databases[conf.Database] = db
+		}
+	}
+	db.refs++
+	return &Conn{db: db}, nil
+}
+
+// Verify implements activerecord.ConnectionVerifier interface, the in-memory
+// database is always available.
+func (c *Conn) Verify(ctx context.Context) error {
+	return nil
+}
+
+// Close rolls back the open transaction, or releases the database, when the
+// connection is not used by a transaction.
+func (c *Conn) Close() error {
+	if c.tx != nil {
+		if !c.tx.done {
+			return c.RollbackTransaction(context.Background())
+		}
+		return nil
+	}
+
+	databasesMu.Lock()
+	defer databasesMu.Unlock()
+
+	if c.db.refs--; c.db.refs == 0 && databases[c.db.name] == c.db {
+		delete(databases, c.db.name)
+	}
+	return nil
+}
+
+// table returns the table visible to the connection.
+func (c *Conn) table(name string) (*table, error) {
+	for tx := c.tx; tx != nil; tx = tx.parent {
+		if t, ok := tx.tables[name]; ok {
+			return t, nil
+		}
+	}
+
+	c.db.mu.RLock()
+	defer c.db.mu.RUnlock()
+
+	t, ok := c.db.tables[name]
+	if !ok {
+		return nil, activerecord.ErrTableNotExist{TableName: name}
+	}
+	return t, nil
+}
+
+// modify calls the function with the modifiable copy of the table. Tables are
+// never modified in place outside of transactions, so the readers of the
+// database see a consistent state of tables.
+func (c *Conn) modify(name string, fn func(t *table) error) error {
+	if c.tx == nil {
+		c.db.mu.Lock()
+		defer c.db.mu.Unlock()
+
+		t, ok := c.db.tables[name]
+		if !ok {
+			return activerecord.ErrTableNotExist{TableName: name}
+		}
+		newt := t.clone()
+		if err := fn(newt); err != nil {
+			return err
+		}
+		newt.version++
+		c.db.tables[name] = newt
+		return nil
+	}
+
+	if c.tx.readOnly {
+		return errors.New("memory: cannot modify the table in a read-only transaction")
+	}
+
+	t, ok := c.tx.tables[name]
+	if !ok {
+		visible, err := c.table(name)
+		if err != nil {
+			return err
+		}
+
+		root := c.tx.root()
+		if _, ok := root.versions[name]; !ok {
+			root.versions[name] = visible.version
+		}
+		t = visible.clone()
+		c.tx.tables[name] = t
+	}
+	return fn(t)
+}
+
+func (c *Conn) BeginTransaction(
+	ctx context.Context, opts *activerecord.TransactionOptions,
+) (activerecord.Conn, error) {
+	stmt := "BEGIN TRANSACTION"
+	if c.tx != nil {
+		stmt = "SAVEPOINT"
+	}
+	done := activerecord.LogQuery(ctx, stmt, nil)
+	defer done(0, nil)
+
+	tx := &transaction{
+		parent:   c.tx,
+		tables:   make(map[string]*table),
+		versions: make(map[string]int64),
+	}
+	if c.tx != nil {
+		tx.readOnly = c.tx.readOnly
+	}
+	if opts != nil && opts.ReadOnly {
+		tx.readOnly = true
+	}
+	return &Conn{db: c.db, tx: tx}, nil
+}
+
+func (c *Conn) CommitTransaction(ctx context.Context) (err error) {
+	if c.tx == nil || c.tx.done {
+		return fmt.Errorf("no transaction is open")
+	}
+	c.tx.done = true
+
+	if parent := c.tx.parent; parent != nil {
+		done := activerecord.LogQuery(ctx, "RELEASE SAVEPOINT", nil)
+		defer done(0, nil)
+
+		for name, t := range c.tx.tables {
+			parent.tables[name] = t
+		}
+		return nil
+	}
+
+	done := activerecord.LogQuery(ctx, "COMMIT TRANSACTION", nil)
+	defer func() { done(0, err) }()
+
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+
+	for name := range c.tx.tables {
+		var version int64
+		if t, ok := c.db.tables[name]; ok {
+			version = t.version
+		}
+		if version != c.tx.versions[name] {
+			return &activerecord.ErrSerializationFailure{
+				Err: fmt.Errorf("memory: table %s was modified by concurrent transaction", name),
+			}
+		}
+	}
+	for name, t := range c.tx.tables {
+		t.version = c.tx.versions[name] + 1
+		c.db.tables[name] = t
+	}
+	return nil
+}
+
+func (c *Conn) RollbackTransaction(ctx context.Context) error {
+	if c.tx == nil || c.tx.done {
+		return fmt.Errorf("no transaction is open")
+	}
+	c.tx.done = true
+
+	stmt := "ROLLBACK TRANSACTION"
+	if c.tx.parent != nil {
+		stmt = "ROLLBACK TO SAVEPOINT"
+	}
+	activerecord.LogQuery(ctx, stmt, nil)(0, nil)
+	return nil
+}
+
+func (c *Conn) ExecInsert(ctx context.Context, op *activerecord.InsertOperation) (
+	id interface{}, err error,
+) {
+	var (
+		values  = make(map[string]interface{}, len(op.ColumnValues))
+		columns = make([]string, len(op.ColumnValues))
+		binds   = make([]interface{}, len(op.ColumnValues))
+	)
+	for i, col := range op.ColumnValues {
+		values[col.Name] = col.Value
+		columns[i], binds[i] = fmt.Sprintf("%q", col.Name), col.Value
+	}
+
+	stmt := fmt.Sprintf(`INSERT INTO %q (%s) VALUES (%s)`, op.TableName,
+		strings.Join(columns, ", "),
+		strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", "),
+	)
+	done := activerecord.LogQuery(ctx, stmt, binds)
+	defer func() { done(1, err) }()
+
+	if err = ctx.Err(); err != nil {
+		return nil, err
+	}
+	err = c.modify(op.TableName, func(t *table) (err error) {
+		id, err = t.insert(values, op.OnDuplicate)
+		return err
+	})
+	return id, err
+}
+
+func (c *Conn) ExecUpdate(ctx context.Context, op *activerecord.UpdateOperation) (err error) {
+	var (
+		values = make(map[string]interface{}, len(op.ColumnValues))
+		sets   []string
+		binds  []interface{}
+		pk     interface{}
+	)
+	for _, col := range op.ColumnValues {
+		if col.Name == op.PrimaryKey {
+			pk = col.Value
+			continue
+		}
+		values[col.Name] = col.Value
+		sets = append(sets, fmt.Sprintf("%q = ?", col.Name))
+		binds = append(binds, col.Value)
+	}
+
+	stmt := fmt.Sprintf(`UPDATE %q SET %s WHERE %q = ?`,
+		op.TableName, strings.Join(sets, ", "), op.PrimaryKey,
+	)
+	done := activerecord.LogQuery(ctx, stmt, append(binds, pk))
+	defer func() { done(1, err) }()
+
+	if err = ctx.Err(); err != nil {
+		return err
+	}
+	return c.modify(op.TableName, func(t *table) error {
+		column, _ := t.column(op.PrimaryKey)
+		key, err := t.value(column, pk)
+		if err != nil {
+			return err
+		}
+
+		i := t.find(key)
+		if i < 0 {
+			return fmt.Errorf("expected single row affected, got 0 rows affected")
+		}
+		return t.set(t.rows[i], values)
+	})
+}
+
+func (c *Conn) ExecDelete(ctx context.Context, op *activerecord.DeleteOperation) (err error) {
+	stmt := fmt.Sprintf(`DELETE FROM %q WHERE %q = ?`, op.TableName, op.PrimaryKey)
+
+	done := activerecord.LogQuery(ctx, stmt, []interface{}{op.Value})
+	defer func() { done(0, err) }()
+
+	if err = ctx.Err(); err != nil {
+		return err
+	}
+	return c.modify(op.TableName, func(t *table) error {
+		column, _ := t.column(op.PrimaryKey)
+		key, err := t.value(column, op.Value)
+		if err != nil {
+			return err
+		}
+		if i := t.find(key); i >= 0 {
+			t.rows = append(t.rows[:i], t.rows[i+1:]...)
+		}
+		return nil
+	})
+}
+
+func (c *Conn) ExecQuery(
+	ctx context.Context, op *activerecord.QueryOperation, cb func(activesupport.Hash) bool,
+) (
+	err error,
+) {
+	var rows int64
+
+	done := activerecord.LogQuery(ctx, op.Text, op.Args)
+	defer func() { done(rows, err) }()
+
+	if err = ctx.Err(); err != nil {
+		return err
+	}
+
+	node, err := parse(op.Text)
+	if err != nil {
+		return err
+	}
+	stmt, ok := node.(*selectStmt)
+	if !ok {
+		return &ErrSyntax{Stmt: op.Text, Message: "expected SELECT statement"}
+	}
+
+	ev, err := newEvaluator(ctx, c, op.Args)
+	if err != nil {
+		return err
+	}
+	res, err := ev.query(stmt, nil)
+	if err != nil {
+		return err
+	}
+
+	columns := op.Columns
+	if len(columns) == 0 {
+		columns = res.columns
+	}
+
+	for _, values := range res.rows {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+
+		row := make(activesupport.Hash, len(values))
+		for i := range values {
+			if i < len(columns) {
+				row[columns[i]] = values[i]
+			}
+		}
+		rows++
+
+		if !cb(row) {
+			break
+		}
+	}
+	return nil
+}
+
+// ExecStatement executes insert, update or delete statement and returns the
+// number of affected rows.
+func (c *Conn) ExecStatement(ctx context.Context, op *activerecord.QueryOperation) (
+	rowsAffected int64, err error,
+) {
+	done := activerecord.LogQuery(ctx, op.Text, op.Args)
+	defer func() { done(rowsAffected, err) }()
+
+	if err = ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	node, err := parse(op.Text)
+	if err != nil {
+		return 0, err
+	}
+	ev, err := newEvaluator(ctx, c, op.Args)
+	if err != nil {
+		return 0, err
+	}
+
+	switch stmt := node.(type) {
+	case *insertStmt:
+		err = c.modify(stmt.table, func(t *table) error {
+			for _, exprs := range stmt.values {
+				values := make(map[string]interface{}, len(exprs))
+				for i, e := range exprs {
+					value, err := ev.eval(e, &env{}, nil)
+					if err != nil {
+						return err
+					}
+					values[stmt.columns[i]] = value
+				}
+				if _, err := t.insert(values, ""); err != nil {
+					return err
+				}
+				rowsAffected++
+			}
+			return nil
+		})
+	case *updateStmt:
+		err = c.modify(stmt.table, func(t *table) error {
+			columns := t.columnNames()
+			for _, row := range t.rows {
+				e := &env{bindings: []binding{{name: t.name, columns: columns, row: row}}}
+				if stmt.where != nil {
+					ok, err := ev.truth(stmt.where, e, nil)
+					if err != nil {
+						return err
+					}
+					if !ok {
+						continue
+					}
+				}
+
+				values := make(map[string]interface{}, len(stmt.set))
+				for _, a := range stmt.set {
+					value, err := ev.eval(a.value, e, nil)
+					if err != nil {
+						return err
+					}
+					values[a.column] = value
+				}
+				if err := t.set(row, values); err != nil {
+					return err
+				}
+				rowsAffected++
+			}
+			return nil
+		})
+	case *deleteStmt:
+		err = c.modify(stmt.table, func(t *table) error {
+			columns := t.columnNames()
+			kept := t.rows[:0]
+			for _, row := range t.rows {
+				if stmt.where != nil {
+					e := &env{bindings: []binding{{name: t.name, columns: columns, row: row}}}
+					ok, err := ev.truth(stmt.where, e, nil)
+					if err != nil {
+						return err
+					}
+					if !ok {
+						kept = append(kept, row)
+						continue
+					}
+				}
+				rowsAffected++
+			}
+			t.rows = kept
+			return nil
+		})
+	default:
+		return 0, &ErrSyntax{Stmt: op.Text, Message: "expected INSERT, UPDATE or DELETE statement"}
+	}
+	if err != nil {
+		return 0, err
+	}
+	return rowsAffected, nil
+}
+
+// ExecExplain returns the plan of the query, which lists scanned tables. Explain
+// options are ignored.
+func (c *Conn) ExecExplain(ctx context.Context, op *activerecord.ExplainOperation) (
+	string, error,
+) {
+	node, err := parse(op.Query.Text)
+	if err != nil {
+		return "", err
+	}
+	stmt, ok := node.(*selectStmt)
+	if !ok {
+		return "", &ErrSyntax{Stmt: op.Query.Text, Message: "expected SELECT statement"}
+	}
+
+	var lines []string
+	var explain func(stmt *selectStmt)
+	explain = func(stmt *selectStmt) {
+		sources := []source{stmt.from}
+		for _, j := range stmt.joins {
+			sources = append(sources, j.source)
+		}
+		for _, s := range sources {
+			if s.subquery != nil {
+				explain(s.subquery)
+			} else {
+				lines = append(lines, "SCAN "+s.table)
+			}
+		}
+		if len(stmt.orderBy) > 0 {
+			lines = append(lines, "SORT")
+		}
+	}
+	explain(stmt)
+	return strings.Join(lines, "\n"), nil
+}
+
+func (c *Conn) CreateTable(ctx context.Context, tb *activerecord.Table) error {
+	t := &table{name: tb.Name()}
+
+	columns := tb.Columns()
+	sort.SliceStable(columns, func(i, j int) bool {
+		if columns[i].IsPrimaryKey != columns[j].IsPrimaryKey {
+			return columns[i].IsPrimaryKey
+		}
+		return columns[i].Name < columns[j].Name
+	})
+	for _, column := range columns {
+		if column.IsPrimaryKey {
+			t.primaryKey = column.Name
+		}
+	}
+	t.columns = columns
+
+	if _, err := c.table(t.name); err == nil {
+		return fmt.Errorf("memory: table %s already exists", t.name)
+	}
+
+	if c.tx == nil {
+		c.db.mu.Lock()
+		defer c.db.mu.Unlock()
+
+		if _, ok := c.db.tables[t.name]; ok {
+			return fmt.Errorf("memory: table %s already exists", t.name)
+		}
+		t.version = 1
+		c.db.tables[t.name] = t
+		return nil
+	}
+
+	root := c.tx.root()
+	if _, ok := root.versions[t.name]; !ok {
+		root.versions[t.name] = 0
+	}
+	c.tx.tables[t.name] = t
+	return nil
+}
+
+// AddForeignKey verifies that both tables exist, foreign keys are not enforced.
+func (c *Conn) AddForeignKey(ctx context.Context, owner, target string) error {
+	if _, err := c.table(owner); err != nil {
+		return err
+	}
+	_, err := c.table(target)
+	return err
+}
+
+func (c *Conn) ColumnType(typeName string) (activerecord.Type, error) {
+	return new(ansi.SchemaStatements).ColumnType(typeName)
+}
+
+func (c *Conn) ColumnDefinitions(ctx context.Context, tableName string) (
+	[]activerecord.ColumnDefinition, error,
+) {
+	t, err := c.table(tableName)
+	if err != nil {
+		return nil, err
+	}
+	definitions := make([]activerecord.ColumnDefinition, len(t.columns))
+	copy(definitions, t.columns)
+	return definitions, nil
+}
@@ -0,0 +1,294 @@
+package memory_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/activegraph/activegraph/activerecord"
+	_ "github.com/activegraph/activegraph/activerecord/memory"
+	. "github.com/activegraph/activegraph/activesupport"
+)
+
+func initTables(t *testing.T) {
+	activerecord.Migrate(t.Name()+"_add_authors_and_books_tables", func(m *activerecord.M) {
+		m.CreateTable("authors", func(t *activerecord.Table) {
+			t.String("name")
+		})
+		m.CreateTable("books", func(t *activerecord.Table) {
+			t.Int64("year")
+			t.String("title")
+			t.References("authors")
+			t.ForeignKey("authors")
+		})
+	})
+}
+
+func establishConnection(t *testing.T) activerecord.Conn {
+	conn, err := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter:  "memory",
+		Database: t.Name(),
+	})
+	require.NoError(t, err)
+	return conn
+}
+
+func TestConn_Relation(t *testing.T) {
+	establishConnection(t)
+	defer activerecord.RemoveConnection("primary")
+
+	initTables(t)
+
+	Author := activerecord.New("author", func(r *activerecord.R) {
+		r.HasMany("book")
+	})
+	Book := activerecord.New("book", func(r *activerecord.R) {
+		r.BelongsTo("author")
+	})
+
+	_, err := Author.InsertAll(Hash{"name": "Stanislaw Lem"}, Hash{"name": "Isaac Asimov"})
+	require.NoError(t, err)
+
+	_, err = Book.InsertAll(
+		Hash{"title": "Solaris", "year": 1961, "author_id": 1},
+		Hash{"title": "The Cyberiad", "year": 1965, "author_id": 1},
+		Hash{"title": "The Naked Sun", "year": 1956, "author_id": 2},
+		Hash{"title": "Nine Tomorrows", "year": 1959, "author_id": 2},
+	)
+	require.NoError(t, err)
+
+	books, err := Book.Where("year", activerecord.Between(1958, 1962)).Order("year").ToA()
+	require.NoError(t, err)
+	require.Len(t, books, 2)
+	require.Equal(t, "Nine Tomorrows", books[0].Attribute("title"))
+	require.Equal(t, "Solaris", books[1].Attribute("title"))
+
+	books, err = Book.Where("title", activerecord.Like("The %")).Order("title DESC").Limit(1).ToA()
+	require.NoError(t, err)
+	require.Len(t, books, 1)
+	require.Equal(t, "The Naked Sun", books[0].Attribute("title"))
+
+	count, err := Book.Where("author_id = ?", 1).Count()
+	require.NoError(t, err)
+	require.Equal(t, int64(2), count)
+
+	titles, err := Book.Order("title").Pluck("title")
+	require.NoError(t, err)
+	require.Equal(t, [][]interface{}{
+		{"Nine Tomorrows"}, {"Solaris"}, {"The Cyberiad"}, {"The Naked Sun"},
+	}, titles)
+
+	counts, err := Book.Group("author_id").GroupCount()
+	require.NoError(t, err)
+	require.Equal(t, map[interface{}]int64{int64(1): 2, int64(2): 2}, counts)
+
+	books, err = Book.Joins("author").Where("authors.name = ?", "Isaac Asimov").Order("year").ToA()
+	require.NoError(t, err)
+	require.Len(t, books, 2)
+	require.Equal(t, "The Naked Sun", books[0].Attribute("title"))
+
+	book := Book.Find(1)
+	require.NoError(t, book.Err())
+	require.NoError(t, book.Unwrap().AssignAttribute("year", 1962))
+	_, err = book.Unwrap().Update()
+	require.NoError(t, err)
+
+	book = Book.Find(1)
+	require.NoError(t, book.Err())
+	require.Equal(t, int64(1962), book.Unwrap().Attribute("year"))
+
+	_, err = Book.Find(2).Unwrap().Delete()
+	require.NoError(t, err)
+	count, err = Book.Count()
+	require.NoError(t, err)
+	require.Equal(t, int64(3), count)
+}
+
+func TestConn_Transaction(t *testing.T) {
+	establishConnection(t)
+	defer activerecord.RemoveConnection("primary")
+
+	initTables(t)
+
+	Author := activerecord.New("author")
+
+	err := activerecord.Transaction(context.TODO(), func(tx context.Context) error {
+		return Author.WithContext(tx).Create(Hash{"name": "Ada"}).Err()
+	})
+	require.NoError(t, err)
+
+	err = activerecord.Transaction(context.TODO(), func(tx context.Context) error {
+		Author.WithContext(tx).Create(Hash{"name": "Bob"})
+		return errors.New("rollback")
+	})
+	require.Error(t, err)
+
+	names, err := Author.Pluck("name")
+	require.NoError(t, err)
+	require.Equal(t, [][]interface{}{{"Ada"}}, names)
+
+	err = activerecord.Transaction(context.TODO(), func(tx context.Context) error {
+		return Author.WithContext(tx).Create(Hash{"name": "Carl"}).Err()
+	}, activerecord.ReadOnly())
+	require.Error(t, err)
+}
+
+func TestConn_Savepoint(t *testing.T) {
+	conn := establishConnection(t)
+	defer activerecord.RemoveConnection("primary")
+
+	initTables(t)
+
+	ctx := context.TODO()
+	insert := func(conn activerecord.Conn, name string) {
+		_, err := conn.ExecInsert(ctx, &activerecord.InsertOperation{
+			TableName:    "authors",
+			ColumnValues: []activerecord.ColumnValue{{Name: "name", Value: name}},
+		})
+		require.NoError(t, err)
+	}
+	count := func(conn activerecord.Conn) int64 {
+		var count int64
+		op := activerecord.QueryOperation{Text: `SELECT COUNT(*) AS "count" FROM "authors"`}
+		err := conn.ExecQuery(ctx, &op, func(row Hash) bool {
+			count = row["count"].(int64)
+			return true
+		})
+		require.NoError(t, err)
+		return count
+	}
+
+	tx, err := conn.BeginTransaction(ctx, nil)
+	require.NoError(t, err)
+	insert(tx, "Ada")
+
+	sp, err := tx.BeginTransaction(ctx, nil)
+	require.NoError(t, err)
+	insert(sp, "Bob")
+	require.Equal(t, int64(2), count(sp))
+	require.NoError(t, sp.RollbackTransaction(ctx))
+
+	sp, err = tx.BeginTransaction(ctx, nil)
+	require.NoError(t, err)
+	insert(sp, "Carl")
+	require.NoError(t, sp.CommitTransaction(ctx))
+
+	// Changes of the transaction are invisible outside of it.
+	require.Equal(t, int64(2), count(tx))
+	require.Equal(t, int64(0), count(conn))
+
+	require.NoError(t, tx.CommitTransaction(ctx))
+	require.Equal(t, int64(2), count(conn))
+}
+
+func TestConn_SerializationFailure(t *testing.T) {
+	conn := establishConnection(t)
+	defer activerecord.RemoveConnection("primary")
+
+	initTables(t)
+
+	ctx := context.TODO()
+	insert := func(conn activerecord.Conn, name string) error {
+		_, err := conn.ExecInsert(ctx, &activerecord.InsertOperation{
+			TableName:    "authors",
+			ColumnValues: []activerecord.ColumnValue{{Name: "name", Value: name}},
+		})
+		return err
+	}
+
+	tx1, err := conn.BeginTransaction(ctx, nil)
+	require.NoError(t, err)
+	tx2, err := conn.BeginTransaction(ctx, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, insert(tx1, "Ada"))
+	require.NoError(t, insert(tx2, "Bob"))
+
+	require.NoError(t, tx1.CommitTransaction(ctx))
+
+	err = tx2.CommitTransaction(ctx)
+	require.ErrorIs(t, err, new(activerecord.ErrSerializationFailure))
+}
+
+func TestConn_Raw(t *testing.T) {
+	establishConnection(t)
+	defer activerecord.RemoveConnection("primary")
+
+	initTables(t)
+
+	ctx := context.TODO()
+
+	rows, err := activerecord.Execute(ctx,
+		`INSERT INTO "books" ("title", "year") VALUES (?, ?), (?, ?), (?, ?)`,
+		"Solaris", 1961, "The Cyberiad", 1965, "Return from the Stars", 1961,
+	)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), rows)
+
+	result, err := activerecord.SelectAll(ctx,
+		`SELECT "year", COUNT(*) AS "total" FROM "books" GROUP BY "year" HAVING COUNT(*) > ?`, 1,
+	)
+	require.NoError(t, err)
+	require.Equal(t, []Hash{{"year": int64(1961), "total": int64(2)}}, result)
+
+	rows, err = activerecord.Execute(ctx, `UPDATE "books" SET "year" = ? WHERE "year" < ?`, 1960, 1962)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), rows)
+
+	rows, err = activerecord.Execute(ctx, `DELETE FROM "books" WHERE "title" LIKE ?`, "The %")
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	_, err = activerecord.SelectAll(ctx, `SELECT FROM "books"`)
+	require.Error(t, err)
+
+	_, err = activerecord.SelectAll(ctx, `SELECT * FROM "publishers"`)
+	require.ErrorIs(t, err, activerecord.ErrTableNotExist{TableName: "publishers"})
+}
+
+func TestConn_ContextCancellation(t *testing.T) {
+	establishConnection(t)
+	defer activerecord.RemoveConnection("primary")
+
+	initTables(t)
+
+	activerecord.SetQueryLogger(nil)
+	defer activerecord.SetQueryLogger(activerecord.NewWriterLogger(os.Stdout))
+
+	var (
+		stmt strings.Builder
+		args []interface{}
+	)
+	stmt.WriteString(`INSERT INTO "authors" ("name") VALUES `)
+	for i := 0; i < 1000; i++ {
+		if i > 0 {
+			stmt.WriteString(", ")
+		}
+		stmt.WriteString("(?)")
+		args = append(args, fmt.Sprintf("Author %d", i))
+	}
+	_, err := activerecord.Execute(context.TODO(), stmt.String(), args...)
+	require.NoError(t, err)
+
+	// Evaluation of the query is aborted in the middle of the join.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err = activerecord.SelectValue(ctx, `SELECT COUNT(*) FROM "authors" AS a
+		INNER JOIN "authors" AS b ON a.name < b.name
+		INNER JOIN "authors" AS c ON b.name < c.name`)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	_, err = activerecord.Execute(ctx, `DELETE FROM "authors"`)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	count, err := activerecord.New("author").Count()
+	require.NoError(t, err)
+	require.Equal(t, int64(1000), count)
+}
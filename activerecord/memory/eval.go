@@ -0,0 +1,958 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// binding is a row of the source bound to the name of the source.
+type binding struct {
+	name    string
+	columns []string
+	row     map[string]interface{}
+}
+
+// env is a set of bound rows used to evaluate expressions.
+type env struct {
+	bindings []binding
+	outer    *env
+}
+
+func (e *env) with(b binding) *env {
+	bindings := make([]binding, len(e.bindings), len(e.bindings)+1)
+	copy(bindings, e.bindings)
+	return &env{bindings: append(bindings, b), outer: e.outer}
+}
+
+func (e *env) lookup(col *columnExpr) (interface{}, error) {
+	for env := e; env != nil; env = env.outer {
+		for _, b := range env.bindings {
+			if col.table != "" && !strings.EqualFold(b.name, col.table) {
+				continue
+			}
+			for _, column := range b.columns {
+				if strings.EqualFold(column, col.name) {
+					return b.row[column], nil
+				}
+			}
+		}
+	}
+	if col.table != "" {
+		return nil, fmt.Errorf("memory: no such column: %s.%s", col.table, col.name)
+	}
+	return nil, fmt.Errorf("memory: no such column: %s", col.name)
+}
+
+// catalog provides access to tables of the database.
+type catalog interface {
+	table(name string) (*table, error)
+}
+
+// evaluator evaluates statements against the tables of the catalog. Evaluation
+// is aborted, when the context is done.
+type evaluator struct {
+	ctx     context.Context
+	catalog catalog
+	args    []interface{}
+}
+
+func newEvaluator(ctx context.Context, c catalog, args []interface{}) (*evaluator, error) {
+	normalized := make([]interface{}, len(args))
+	for i, arg := range args {
+		value, err := normalize(arg)
+		if err != nil {
+			return nil, err
+		}
+		normalized[i] = value
+	}
+	return &evaluator{ctx: ctx, catalog: c, args: normalized}, nil
+}
+
+// normalize converts the value to one of the driver values.
+func normalize(value interface{}) (interface{}, error) {
+	value, err := driver.DefaultParameterConverter.ConvertValue(value)
+	if err != nil {
+		return nil, err
+	}
+	if t, ok := value.(time.Time); ok {
+		return t.UTC(), nil
+	}
+	return value, nil
+}
+
+// result is a result of the select statement.
+type result struct {
+	columns []string
+	rows    [][]interface{}
+}
+
+// sourceRows returns column names and rows of the source.
+func (ev *evaluator) sourceRows(s *source, outer *env) ([]string, []binding, error) {
+	if s.subquery != nil {
+		res, err := ev.query(s.subquery, outer)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		bindings := make([]binding, len(res.rows))
+		for i, values := range res.rows {
+			row := make(map[string]interface{}, len(values))
+			for j, value := range values {
+				row[res.columns[j]] = value
+			}
+			bindings[i] = binding{name: s.name(), columns: res.columns, row: row}
+		}
+		return res.columns, bindings, nil
+	}
+
+	t, err := ev.catalog.table(s.table)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	columns := t.columnNames()
+	bindings := make([]binding, len(t.rows))
+	for i, row := range t.rows {
+		bindings[i] = binding{name: s.name(), columns: columns, row: row}
+	}
+	return columns, bindings, nil
+}
+
+func isAggregate(e expr) bool {
+	switch e := e.(type) {
+	case *funcExpr:
+		switch e.name {
+		case "COUNT", "SUM", "AVG", "MIN", "MAX":
+			return true
+		}
+		for _, arg := range e.args {
+			if isAggregate(arg) {
+				return true
+			}
+		}
+	case *unaryExpr:
+		return isAggregate(e.operand)
+	case *binaryExpr:
+		return isAggregate(e.left) || isAggregate(e.right)
+	}
+	return false
+}
+
+// query evaluates the select statement, outer environment is used to resolve
+// columns of correlated subqueries.
+func (ev *evaluator) query(stmt *selectStmt, outer *env) (*result, error) {
+	columns, bindings, err := ev.sourceRows(&stmt.from, outer)
+	if err != nil {
+		return nil, err
+	}
+	sources := []binding{{name: stmt.from.name(), columns: columns}}
+
+	envs := make([]*env, len(bindings))
+	for i := range bindings {
+		envs[i] = &env{bindings: []binding{bindings[i]}, outer: outer}
+	}
+
+	for _, j := range stmt.joins {
+		columns, joined, err := ev.sourceRows(&j.source, outer)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, binding{name: j.source.name(), columns: columns})
+
+		var next []*env
+		for _, e := range envs {
+			matched := false
+			for _, b := range joined {
+				candidate := e.with(b)
+				ok, err := ev.truth(j.on, candidate, nil)
+				if err != nil {
+					return nil, err
+				}
+				if ok {
+					next, matched = append(next, candidate), true
+				}
+			}
+			if !matched && j.left {
+				next = append(next, e.with(binding{name: j.source.name(), columns: columns}))
+			}
+		}
+		envs = next
+	}
+
+	if stmt.where != nil {
+		var filtered []*env
+		for _, e := range envs {
+			ok, err := ev.truth(stmt.where, e, nil)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				filtered = append(filtered, e)
+			}
+		}
+		envs = filtered
+	}
+
+	// Group rows, when the statement is grouped or aggregated, otherwise each row
+	// is a group of its own.
+	aggregated := len(stmt.groupBy) > 0 || stmt.having != nil
+	for _, item := range stmt.items {
+		aggregated = aggregated || isAggregate(item.expr)
+	}
+
+	var groups [][]*env
+	switch {
+	case len(stmt.groupBy) > 0:
+		index := make(map[string]int)
+		for _, e := range envs {
+			key := make([]interface{}, len(stmt.groupBy))
+			for i, g := range stmt.groupBy {
+				if key[i], err = ev.eval(g, e, nil); err != nil {
+					return nil, err
+				}
+			}
+			k := fmt.Sprintf("%#v", key)
+			if i, ok := index[k]; ok {
+				groups[i] = append(groups[i], e)
+			} else {
+				index[k] = len(groups)
+				groups = append(groups, []*env{e})
+			}
+		}
+	case aggregated:
+		// Aggregates of the empty set are still evaluated, so the group must not
+		// be nil.
+		groups = [][]*env{append([]*env{}, envs...)}
+	default:
+		groups = make([][]*env, len(envs))
+		for i, e := range envs {
+			groups[i] = []*env{e}
+		}
+	}
+
+	// Columns of the result are named by aliases, column names or text of the
+	// select items.
+	res := new(result)
+	for _, item := range stmt.items {
+		star, ok := item.expr.(*starExpr)
+		if !ok {
+			res.columns = append(res.columns, itemName(item))
+			continue
+		}
+		for _, source := range sources {
+			if star.table == "" || strings.EqualFold(star.table, source.name) {
+				res.columns = append(res.columns, source.columns...)
+			}
+		}
+	}
+
+	type outputRow struct {
+		values []interface{}
+		env    *env
+		group  []*env
+	}
+
+	var rows []outputRow
+	for _, group := range groups {
+		e := &env{outer: outer}
+		if len(group) > 0 {
+			e = group[0]
+		}
+
+		var aggGroup []*env
+		if aggregated {
+			aggGroup = group
+		}
+
+		if stmt.having != nil {
+			ok, err := ev.truth(stmt.having, e, aggGroup)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+		}
+
+		var values []interface{}
+		for _, item := range stmt.items {
+			if star, ok := item.expr.(*starExpr); ok {
+				for _, b := range e.bindings {
+					if star.table != "" && !strings.EqualFold(star.table, b.name) {
+						continue
+					}
+					for _, column := range b.columns {
+						values = append(values, b.row[column])
+					}
+				}
+				continue
+			}
+
+			value, err := ev.eval(item.expr, e, aggGroup)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		rows = append(rows, outputRow{values: values, env: e, group: aggGroup})
+	}
+
+	if stmt.distinct {
+		seen := make(map[string]bool)
+		unique := rows[:0]
+		for _, row := range rows {
+			k := fmt.Sprintf("%#v", row.values)
+			if !seen[k] {
+				seen[k] = true
+				unique = append(unique, row)
+			}
+		}
+		rows = unique
+	}
+
+	if len(stmt.orderBy) > 0 {
+		keys := make([][]interface{}, len(rows))
+		for i, row := range rows {
+			output := make(map[string]interface{}, len(res.columns))
+			for j, column := range res.columns {
+				if j < len(row.values) {
+					output[column] = row.values[j]
+				}
+			}
+			e := row.env.with(binding{columns: res.columns, row: output})
+
+			keys[i] = make([]interface{}, len(stmt.orderBy))
+			for j, item := range stmt.orderBy {
+				if keys[i][j], err = ev.eval(item.expr, e, row.group); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		index := make([]int, len(rows))
+		for i := range index {
+			index[i] = i
+		}
+		sort.SliceStable(index, func(a, b int) bool {
+			for j, item := range stmt.orderBy {
+				c := compareOrder(keys[index[a]][j], keys[index[b]][j])
+				if c == 0 {
+					continue
+				}
+				if item.desc {
+					return c > 0
+				}
+				return c < 0
+			}
+			return false
+		})
+
+		sorted := make([]outputRow, len(rows))
+		for i, j := range index {
+			sorted[i] = rows[j]
+		}
+		rows = sorted
+	}
+
+	offset, limit := 0, len(rows)
+	if stmt.offset != nil {
+		if offset, err = ev.intValue(stmt.offset); err != nil {
+			return nil, err
+		}
+	}
+	if stmt.limit != nil {
+		if limit, err = ev.intValue(stmt.limit); err != nil {
+			return nil, err
+		}
+	}
+	if offset > len(rows) {
+		offset = len(rows)
+	}
+	if limit < 0 || offset+limit > len(rows) {
+		limit = len(rows) - offset
+	}
+
+	for _, row := range rows[offset : offset+limit] {
+		res.rows = append(res.rows, row.values)
+	}
+	return res, nil
+}
+
+func itemName(item selectItem) string {
+	if item.alias != "" {
+		return item.alias
+	}
+	if col, ok := item.expr.(*columnExpr); ok {
+		return col.name
+	}
+	return item.text
+}
+
+func (ev *evaluator) intValue(e expr) (int, error) {
+	value, err := ev.eval(e, &env{}, nil)
+	if err != nil {
+		return 0, err
+	}
+	n, ok := toFloat(value)
+	if !ok {
+		return 0, fmt.Errorf("memory: invalid integer %v", value)
+	}
+	return int(n), nil
+}
+
+// truth evaluates the expression as a condition, NULL is treated as false.
+//
+// Conditions are evaluated for each row of joins and filters, therefore the
+// context is checked here, so long queries are aborted in the middle.
+func (ev *evaluator) truth(e expr, env *env, group []*env) (bool, error) {
+	if err := ev.ctx.Err(); err != nil {
+		return false, err
+	}
+	value, err := ev.eval(e, env, group)
+	if err != nil {
+		return false, err
+	}
+	b := toBool(value)
+	return b != nil && *b, nil
+}
+
+func (ev *evaluator) eval(e expr, env *env, group []*env) (interface{}, error) {
+	switch e := e.(type) {
+	case *literalExpr:
+		return e.value, nil
+	case *paramExpr:
+		if e.index >= len(ev.args) {
+			return nil, fmt.Errorf("memory: missing argument %d", e.index+1)
+		}
+		return ev.args[e.index], nil
+	case *columnExpr:
+		return env.lookup(e)
+	case *starExpr:
+		return nil, fmt.Errorf("memory: unexpected *")
+	case *unaryExpr:
+		operand, err := ev.eval(e.operand, env, group)
+		if err != nil || operand == nil {
+			return nil, err
+		}
+		if e.op == "NOT" {
+			b := toBool(operand)
+			if b == nil {
+				return nil, nil
+			}
+			return !*b, nil
+		}
+		return arithmetic("-", int64(0), operand)
+	case *binaryExpr:
+		return ev.evalBinary(e, env, group)
+	case *isNullExpr:
+		operand, err := ev.eval(e.operand, env, group)
+		if err != nil {
+			return nil, err
+		}
+		return (operand == nil) != e.not, nil
+	case *betweenExpr:
+		operand, err := ev.eval(e.operand, env, group)
+		if err != nil {
+			return nil, err
+		}
+		from, err := ev.eval(e.from, env, group)
+		if err != nil {
+			return nil, err
+		}
+		to, err := ev.eval(e.to, env, group)
+		if err != nil {
+			return nil, err
+		}
+		c1, ok1 := compare(operand, from)
+		c2, ok2 := compare(operand, to)
+		if !ok1 || !ok2 {
+			return nil, nil
+		}
+		return (c1 >= 0 && c2 <= 0) != e.not, nil
+	case *inExpr:
+		return ev.evalIn(e, env, group)
+	case *likeExpr:
+		return ev.evalLike(e, env, group)
+	case *existsExpr:
+		res, err := ev.query(e.subquery, env)
+		if err != nil {
+			return nil, err
+		}
+		return len(res.rows) > 0, nil
+	case *funcExpr:
+		return ev.evalFunc(e, env, group)
+	}
+	return nil, fmt.Errorf("memory: unsupported expression %T", e)
+}
+
+func (ev *evaluator) evalBinary(e *binaryExpr, env *env, group []*env) (interface{}, error) {
+	left, err := ev.eval(e.left, env, group)
+	if err != nil {
+		return nil, err
+	}
+
+	// Logical operators follow three-valued logic.
+	if e.op == "AND" || e.op == "OR" {
+		l := toBool(left)
+		if l != nil && *l == (e.op == "OR") {
+			return *l, nil
+		}
+		right, err := ev.eval(e.right, env, group)
+		if err != nil {
+			return nil, err
+		}
+		r := toBool(right)
+		switch {
+		case r != nil && *r == (e.op == "OR"):
+			return *r, nil
+		case l == nil || r == nil:
+			return nil, nil
+		}
+		return *r, nil
+	}
+
+	right, err := ev.eval(e.right, env, group)
+	if err != nil {
+		return nil, err
+	}
+	if left == nil || right == nil {
+		return nil, nil
+	}
+
+	switch e.op {
+	case "=", "!=", "<", "<=", ">", ">=":
+		c, ok := compare(left, right)
+		if !ok {
+			return e.op == "!=", nil
+		}
+		switch e.op {
+		case "=":
+			return c == 0, nil
+		case "!=":
+			return c != 0, nil
+		case "<":
+			return c < 0, nil
+		case "<=":
+			return c <= 0, nil
+		case ">":
+			return c > 0, nil
+		default:
+			return c >= 0, nil
+		}
+	case "||":
+		return toString(left) + toString(right), nil
+	}
+	return arithmetic(e.op, left, right)
+}
+
+func (ev *evaluator) evalIn(e *inExpr, env *env, group []*env) (interface{}, error) {
+	operand, err := ev.eval(e.operand, env, group)
+	if err != nil || operand == nil {
+		return nil, err
+	}
+
+	var values []interface{}
+	if e.subquery != nil {
+		res, err := ev.query(e.subquery, env)
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range res.rows {
+			if len(row) != 1 {
+				return nil, fmt.Errorf("memory: subquery returns %d columns", len(row))
+			}
+			values = append(values, row[0])
+		}
+	} else {
+		for _, item := range e.list {
+			value, err := ev.eval(item, env, group)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+	}
+
+	for _, value := range values {
+		if c, ok := compare(operand, value); ok && c == 0 {
+			return !e.not, nil
+		}
+	}
+	return e.not, nil
+}
+
+func (ev *evaluator) evalLike(e *likeExpr, env *env, group []*env) (interface{}, error) {
+	operand, err := ev.eval(e.operand, env, group)
+	if err != nil {
+		return nil, err
+	}
+	pattern, err := ev.eval(e.pattern, env, group)
+	if err != nil {
+		return nil, err
+	}
+	if operand == nil || pattern == nil {
+		return nil, nil
+	}
+
+	var escape string
+	if e.escape != nil {
+		value, err := ev.eval(e.escape, env, group)
+		if err != nil {
+			return nil, err
+		}
+		escape = toString(value)
+	}
+
+	re, err := likePattern(toString(pattern), escape)
+	if err != nil {
+		return nil, err
+	}
+	return re.MatchString(toString(operand)) != e.not, nil
+}
+
+// likePattern converts the LIKE pattern to the regular expression.
+func likePattern(pattern, escape string) (*regexp.Regexp, error) {
+	var buf strings.Builder
+	buf.WriteString("(?s)^")
+
+	runes := []rune(pattern)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case escape != "" && string(r) == escape && i+1 < len(runes):
+			i++
+			buf.WriteString(regexp.QuoteMeta(string(runes[i])))
+		case r == '%':
+			buf.WriteString(".*")
+		case r == '_':
+			buf.WriteString(".")
+		default:
+			buf.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	buf.WriteString("$")
+	return regexp.Compile(buf.String())
+}
+
+func (ev *evaluator) evalFunc(e *funcExpr, env *env, group []*env) (interface{}, error) {
+	switch e.name {
+	case "COUNT", "SUM", "AVG", "MIN", "MAX":
+		return ev.evalAggregate(e, env, group)
+	}
+
+	args := make([]interface{}, len(e.args))
+	for i, arg := range e.args {
+		value, err := ev.eval(arg, env, group)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = value
+	}
+
+	switch e.name {
+	case "COALESCE":
+		for _, arg := range args {
+			if arg != nil {
+				return arg, nil
+			}
+		}
+		return nil, nil
+	}
+
+	if len(args) != 1 {
+		return nil, fmt.Errorf("memory: unsupported function %s with %d arguments", e.name, len(args))
+	}
+	if args[0] == nil {
+		return nil, nil
+	}
+
+	switch e.name {
+	case "LOWER":
+		return strings.ToLower(toString(args[0])), nil
+	case "UPPER":
+		return strings.ToUpper(toString(args[0])), nil
+	case "LENGTH":
+		return int64(len([]rune(toString(args[0])))), nil
+	case "ABS":
+		if i, ok := args[0].(int64); ok {
+			if i < 0 {
+				i = -i
+			}
+			return i, nil
+		}
+		f, ok := toFloat(args[0])
+		if !ok {
+			return nil, nil
+		}
+		return math.Abs(f), nil
+	}
+	return nil, fmt.Errorf("memory: unsupported function %s", e.name)
+}
+
+func (ev *evaluator) evalAggregate(e *funcExpr, env *env, group []*env) (interface{}, error) {
+	if group == nil {
+		return nil, fmt.Errorf("memory: misuse of aggregate function %s", e.name)
+	}
+	if e.star {
+		if e.name != "COUNT" {
+			return nil, fmt.Errorf("memory: %s(*) is not supported", e.name)
+		}
+		return int64(len(group)), nil
+	}
+	if len(e.args) != 1 {
+		return nil, fmt.Errorf("memory: %s requires a single argument", e.name)
+	}
+
+	var (
+		values []interface{}
+		seen   = make(map[string]bool)
+	)
+	for _, member := range group {
+		value, err := ev.eval(e.args[0], member, nil)
+		if err != nil {
+			return nil, err
+		}
+		if value == nil {
+			continue
+		}
+		if e.distinct {
+			k := fmt.Sprintf("%#v", value)
+			if seen[k] {
+				continue
+			}
+			seen[k] = true
+		}
+		values = append(values, value)
+	}
+
+	switch e.name {
+	case "COUNT":
+		return int64(len(values)), nil
+	case "MIN", "MAX":
+		var extreme interface{}
+		for _, value := range values {
+			c, ok := compare(value, extreme)
+			if extreme == nil || (ok && (c < 0) == (e.name == "MIN") && c != 0) {
+				extreme = value
+			}
+		}
+		return extreme, nil
+	}
+
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	var (
+		sum      interface{} = int64(0)
+		err      error
+		numerics int
+	)
+	for _, value := range values {
+		if _, ok := toFloat(value); !ok {
+			continue
+		}
+		if sum, err = arithmetic("+", sum, value); err != nil {
+			return nil, err
+		}
+		numerics++
+	}
+	if e.name == "SUM" {
+		return sum, nil
+	}
+	f, _ := toFloat(sum)
+	return f / float64(numerics), nil
+}
+
+func toBool(value interface{}) *bool {
+	var b bool
+	switch value := value.(type) {
+	case nil:
+		return nil
+	case bool:
+		b = value
+	case int64:
+		b = value != 0
+	case float64:
+		b = value != 0
+	case string:
+		f, err := strconv.ParseFloat(value, 64)
+		b = err == nil && f != 0
+	default:
+		b = true
+	}
+	return &b
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch value := value.(type) {
+	case int64:
+		return float64(value), true
+	case float64:
+		return value, true
+	case bool:
+		if value {
+			return 1, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+func toString(value interface{}) string {
+	switch value := value.(type) {
+	case string:
+		return value
+	case []byte:
+		return string(value)
+	case time.Time:
+		return value.Format(time.RFC3339Nano)
+	}
+	return fmt.Sprint(value)
+}
+
+func arithmetic(op string, left, right interface{}) (interface{}, error) {
+	li, lok := left.(int64)
+	ri, rok := right.(int64)
+	if lok && rok {
+		switch op {
+		case "+":
+			return li + ri, nil
+		case "-":
+			return li - ri, nil
+		case "*":
+			return li * ri, nil
+		case "/", "%":
+			if ri == 0 {
+				return nil, nil
+			}
+			if op == "/" {
+				return li / ri, nil
+			}
+			return li % ri, nil
+		}
+	}
+
+	l, lok := toFloat(left)
+	r, rok := toFloat(right)
+	if !lok || !rok {
+		return nil, fmt.Errorf("memory: invalid operands of %s: %v, %v", op, left, right)
+	}
+	switch op {
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	case "/":
+		if r == 0 {
+			return nil, nil
+		}
+		return l / r, nil
+	case "%":
+		if r == 0 {
+			return nil, nil
+		}
+		return math.Mod(l, r), nil
+	}
+	return nil, fmt.Errorf("memory: unsupported operator %s", op)
+}
+
+// compare compares two non-null values, the second result is false, when values
+// are not comparable.
+func compare(a, b interface{}) (int, bool) {
+	if a == nil || b == nil {
+		return 0, false
+	}
+
+	// Strings are compared with numbers as numbers, when possible.
+	if s, ok := a.(string); ok {
+		if _, isNum := toFloat(b); isNum {
+			if f, err := strconv.ParseFloat(s, 64); err == nil {
+				a = f
+			}
+		}
+	}
+	if s, ok := b.(string); ok {
+		if _, isNum := toFloat(a); isNum {
+			if f, err := strconv.ParseFloat(s, 64); err == nil {
+				b = f
+			}
+		}
+	}
+
+	if ai, ok := a.(int64); ok {
+		if bi, ok := b.(int64); ok {
+			switch {
+			case ai < bi:
+				return -1, true
+			case ai > bi:
+				return 1, true
+			}
+			return 0, true
+		}
+	}
+	if af, ok := toFloat(a); ok {
+		if bf, ok := toFloat(b); ok {
+			switch {
+			case af < bf:
+				return -1, true
+			case af > bf:
+				return 1, true
+			}
+			return 0, true
+		}
+	}
+
+	at, aok := a.(time.Time)
+	bt, bok := b.(time.Time)
+	switch {
+	case aok && bok:
+		switch {
+		case at.Before(bt):
+			return -1, true
+		case at.After(bt):
+			return 1, true
+		}
+		return 0, true
+	case aok:
+		a = toString(at)
+	case bok:
+		b = toString(bt)
+	}
+
+	switch a.(type) {
+	case string, []byte:
+		switch b.(type) {
+		case string, []byte:
+			return bytes.Compare([]byte(toString(a)), []byte(toString(b))), true
+		}
+	}
+	return 0, false
+}
+
+// compareOrder compares values for sorting, NULL values are the smallest and
+// incomparable values are ordered by their types.
+func compareOrder(a, b interface{}) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+	if c, ok := compare(a, b); ok {
+		return c
+	}
+	return strings.Compare(fmt.Sprintf("%T", a), fmt.Sprintf("%T", b))
+}
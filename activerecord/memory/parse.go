@@ -0,0 +1,876 @@
+package memory
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ErrSyntax is returned when the statement is not supported by the adapter.
+type ErrSyntax struct {
+	Stmt    string
+	Message string
+}
+
+func (e *ErrSyntax) Error() string {
+	return fmt.Sprintf("memory: %s in %q", e.Message, e.Stmt)
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenQuoted
+	tokenString
+	tokenNumber
+	tokenParam
+	tokenSymbol
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// keyword returns true when the token is the unquoted identifier equal to the
+// keyword ignoring the case.
+func (t token) keyword(kw string) bool {
+	return t.kind == tokenIdent && strings.EqualFold(t.text, kw)
+}
+
+func (t token) symbol(s string) bool {
+	return t.kind == tokenSymbol && t.text == s
+}
+
+// tokenize splits the statement into tokens, comments are skipped.
+func tokenize(stmt string) ([]token, error) {
+	var (
+		tokens []token
+		i      int
+	)
+	syntaxErr := func(msg string) error {
+		return &ErrSyntax{Stmt: stmt, Message: msg}
+	}
+
+	for i < len(stmt) {
+		c := stmt[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case strings.HasPrefix(stmt[i:], "--"):
+			for i < len(stmt) && stmt[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(stmt[i:], "/*"):
+			end := strings.Index(stmt[i+2:], "*/")
+			if end < 0 {
+				return nil, syntaxErr("unterminated comment")
+			}
+			i += end + 4
+		case c == '\'' || c == '"' || c == '`':
+			var (
+				buf   strings.Builder
+				start = i
+			)
+			for i++; ; i++ {
+				if i >= len(stmt) {
+					return nil, syntaxErr("unterminated quote")
+				}
+				if stmt[i] == c {
+					// Quote characters are escaped by doubling them.
+					if i+1 < len(stmt) && stmt[i+1] == c {
+						buf.WriteByte(c)
+						i++
+						continue
+					}
+					break
+				}
+				buf.WriteByte(stmt[i])
+			}
+			i++
+
+			kind := tokenQuoted
+			if c == '\'' {
+				kind = tokenString
+			}
+			tokens = append(tokens, token{kind: kind, text: buf.String(), pos: start})
+		case c == '?':
+			tokens = append(tokens, token{kind: tokenParam, text: "?", pos: i})
+			i++
+		case c >= '0' && c <= '9':
+			start := i
+			for i < len(stmt) && (stmt[i] == '.' || (stmt[i] >= '0' && stmt[i] <= '9')) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: stmt[start:i], pos: start})
+		case c == '_' || unicode.IsLetter(rune(c)):
+			start := i
+			for i < len(stmt) && (stmt[i] == '_' || stmt[i] == '$' ||
+				unicode.IsLetter(rune(stmt[i])) || unicode.IsDigit(rune(stmt[i]))) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: stmt[start:i], pos: start})
+		default:
+			symbol := string(c)
+			for _, s := range []string{"<=", ">=", "<>", "!=", "||"} {
+				if strings.HasPrefix(stmt[i:], s) {
+					symbol = s
+					break
+				}
+			}
+			if !strings.Contains("(),.*=<>!|+-/%;", symbol[:1]) {
+				return nil, syntaxErr(fmt.Sprintf("unexpected character %q", c))
+			}
+			tokens = append(tokens, token{kind: tokenSymbol, text: symbol, pos: i})
+			i += len(symbol)
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(stmt)}), nil
+}
+
+// expr is a node of the expression tree.
+type expr interface{}
+
+type (
+	literalExpr struct{ value interface{} }
+	paramExpr   struct{ index int }
+	columnExpr  struct{ table, name string }
+	starExpr    struct{ table string }
+	unaryExpr   struct {
+		op      string
+		operand expr
+	}
+	binaryExpr struct {
+		op          string
+		left, right expr
+	}
+	isNullExpr struct {
+		operand expr
+		not     bool
+	}
+	inExpr struct {
+		operand  expr
+		list     []expr
+		subquery *selectStmt
+		not      bool
+	}
+	betweenExpr struct {
+		operand, from, to expr
+		not               bool
+	}
+	likeExpr struct {
+		operand, pattern, escape expr
+		not                      bool
+	}
+	existsExpr struct{ subquery *selectStmt }
+	funcExpr   struct {
+		name     string
+		args     []expr
+		star     bool
+		distinct bool
+	}
+)
+
+type selectItem struct {
+	expr  expr
+	alias string
+	text  string
+}
+
+type source struct {
+	table    string
+	subquery *selectStmt
+	alias    string
+}
+
+func (s *source) name() string {
+	if s.alias != "" {
+		return s.alias
+	}
+	return s.table
+}
+
+type join struct {
+	source source
+	on     expr
+	left   bool
+}
+
+type orderItem struct {
+	expr expr
+	desc bool
+}
+
+type selectStmt struct {
+	distinct bool
+	items    []selectItem
+	from     source
+	joins    []join
+	where    expr
+	groupBy  []expr
+	having   expr
+	orderBy  []orderItem
+	limit    expr
+	offset   expr
+}
+
+type insertStmt struct {
+	table   string
+	columns []string
+	values  [][]expr
+}
+
+type assignment struct {
+	column string
+	value  expr
+}
+
+type updateStmt struct {
+	table string
+	set   []assignment
+	where expr
+}
+
+type deleteStmt struct {
+	table string
+	where expr
+}
+
+type parser struct {
+	stmt   string
+	tokens []token
+	pos    int
+	params int
+}
+
+func parse(stmt string) (interface{}, error) {
+	tokens, err := tokenize(stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{stmt: stmt, tokens: tokens}
+
+	var node interface{}
+	switch tok := p.peek(); {
+	case tok.keyword("SELECT"):
+		node, err = p.parseSelect()
+	case tok.keyword("INSERT"):
+		node, err = p.parseInsert()
+	case tok.keyword("UPDATE"):
+		node, err = p.parseUpdate()
+	case tok.keyword("DELETE"):
+		node, err = p.parseDelete()
+	default:
+		return nil, p.errorf("unsupported statement")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	p.accept(";")
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, p.errorf("unexpected %q", tok.text)
+	}
+	return node, nil
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return &ErrSyntax{Stmt: p.stmt, Message: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// accept consumes the next token, when it is the given symbol or keyword.
+func (p *parser) accept(text string) bool {
+	tok := p.peek()
+	if tok.symbol(text) || tok.keyword(text) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(texts ...string) error {
+	for _, text := range texts {
+		if !p.accept(text) {
+			return p.errorf("expected %s, got %q", text, p.peek().text)
+		}
+	}
+	return nil
+}
+
+// reserved are keywords, which could not be used as implicit aliases.
+var reserved = map[string]bool{
+	"WHERE": true, "INNER": true, "LEFT": true, "JOIN": true, "ON": true,
+	"GROUP": true, "ORDER": true, "LIMIT": true, "OFFSET": true, "HAVING": true,
+	"AS": true, "FROM": true, "SET": true, "VALUES": true, "OUTER": true,
+	"AND": true, "OR": true, "NOT": true, "IS": true, "IN": true, "LIKE": true,
+	"BETWEEN": true, "ESCAPE": true, "ASC": true, "DESC": true, "UNION": true,
+}
+
+func (p *parser) ident() (string, error) {
+	tok := p.next()
+	if tok.kind == tokenQuoted || (tok.kind == tokenIdent && !reserved[strings.ToUpper(tok.text)]) {
+		return tok.text, nil
+	}
+	return "", p.errorf("expected identifier, got %q", tok.text)
+}
+
+// alias returns the optional alias of the select item or the source.
+func (p *parser) alias() (string, error) {
+	if p.accept("AS") {
+		return p.ident()
+	}
+	tok := p.peek()
+	if tok.kind == tokenQuoted || (tok.kind == tokenIdent && !reserved[strings.ToUpper(tok.text)]) {
+		return p.ident()
+	}
+	return "", nil
+}
+
+func (p *parser) parseSelect() (*selectStmt, error) {
+	if err := p.expect("SELECT"); err != nil {
+		return nil, err
+	}
+
+	stmt := &selectStmt{distinct: p.accept("DISTINCT")}
+	for {
+		start := p.peek().pos
+		e, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+
+		item := selectItem{expr: e, text: strings.TrimSpace(p.stmt[start:p.peek().pos])}
+		if _, ok := e.(*starExpr); !ok {
+			if item.alias, err = p.alias(); err != nil {
+				return nil, err
+			}
+		}
+		stmt.items = append(stmt.items, item)
+
+		if !p.accept(",") {
+			break
+		}
+	}
+
+	if err := p.expect("FROM"); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if stmt.from, err = p.parseSource(); err != nil {
+		return nil, err
+	}
+
+	for {
+		var j join
+		switch {
+		case p.accept("INNER"):
+			if err = p.expect("JOIN"); err != nil {
+				return nil, err
+			}
+		case p.accept("LEFT"):
+			p.accept("OUTER")
+			if err = p.expect("JOIN"); err != nil {
+				return nil, err
+			}
+			j.left = true
+		case p.accept("JOIN"):
+		default:
+			goto joined
+		}
+
+		if j.source, err = p.parseSource(); err != nil {
+			return nil, err
+		}
+		if err = p.expect("ON"); err != nil {
+			return nil, err
+		}
+		if j.on, err = p.parseExpr(); err != nil {
+			return nil, err
+		}
+		stmt.joins = append(stmt.joins, j)
+	}
+
+joined:
+	if p.accept("WHERE") {
+		if stmt.where, err = p.parseExpr(); err != nil {
+			return nil, err
+		}
+	}
+	if p.accept("GROUP") {
+		if err = p.expect("BY"); err != nil {
+			return nil, err
+		}
+		if stmt.groupBy, err = p.parseExprList(); err != nil {
+			return nil, err
+		}
+	}
+	if p.accept("HAVING") {
+		if stmt.having, err = p.parseExpr(); err != nil {
+			return nil, err
+		}
+	}
+	if p.accept("ORDER") {
+		if err = p.expect("BY"); err != nil {
+			return nil, err
+		}
+		for {
+			var item orderItem
+			if item.expr, err = p.parseExpr(); err != nil {
+				return nil, err
+			}
+			if p.accept("DESC") {
+				item.desc = true
+			} else {
+				p.accept("ASC")
+			}
+			stmt.orderBy = append(stmt.orderBy, item)
+
+			if !p.accept(",") {
+				break
+			}
+		}
+	}
+	if p.accept("LIMIT") {
+		if stmt.limit, err = p.parseExpr(); err != nil {
+			return nil, err
+		}
+	}
+	if p.accept("OFFSET") {
+		if stmt.offset, err = p.parseExpr(); err != nil {
+			return nil, err
+		}
+	}
+	return stmt, nil
+}
+
+func (p *parser) parseSource() (s source, err error) {
+	if p.accept("(") {
+		if s.subquery, err = p.parseSelect(); err != nil {
+			return s, err
+		}
+		if err = p.expect(")"); err != nil {
+			return s, err
+		}
+	} else if s.table, err = p.ident(); err != nil {
+		return s, err
+	}
+
+	s.alias, err = p.alias()
+	return s, err
+}
+
+func (p *parser) parseInsert() (*insertStmt, error) {
+	if err := p.expect("INSERT", "INTO"); err != nil {
+		return nil, err
+	}
+
+	var (
+		stmt insertStmt
+		err  error
+	)
+	if stmt.table, err = p.ident(); err != nil {
+		return nil, err
+	}
+
+	if err = p.expect("("); err != nil {
+		return nil, err
+	}
+	for {
+		column, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+		stmt.columns = append(stmt.columns, column)
+		if !p.accept(",") {
+			break
+		}
+	}
+	if err = p.expect(")", "VALUES"); err != nil {
+		return nil, err
+	}
+
+	for {
+		if err = p.expect("("); err != nil {
+			return nil, err
+		}
+		values, err := p.parseExprList()
+		if err != nil {
+			return nil, err
+		}
+		if len(values) != len(stmt.columns) {
+			return nil, p.errorf("%d values for %d columns", len(values), len(stmt.columns))
+		}
+		if err = p.expect(")"); err != nil {
+			return nil, err
+		}
+
+		stmt.values = append(stmt.values, values)
+		if !p.accept(",") {
+			break
+		}
+	}
+	return &stmt, nil
+}
+
+func (p *parser) parseUpdate() (*updateStmt, error) {
+	if err := p.expect("UPDATE"); err != nil {
+		return nil, err
+	}
+
+	var (
+		stmt updateStmt
+		err  error
+	)
+	if stmt.table, err = p.ident(); err != nil {
+		return nil, err
+	}
+	if err = p.expect("SET"); err != nil {
+		return nil, err
+	}
+
+	for {
+		var a assignment
+		if a.column, err = p.ident(); err != nil {
+			return nil, err
+		}
+		if err = p.expect("="); err != nil {
+			return nil, err
+		}
+		if a.value, err = p.parseExpr(); err != nil {
+			return nil, err
+		}
+		stmt.set = append(stmt.set, a)
+
+		if !p.accept(",") {
+			break
+		}
+	}
+
+	if p.accept("WHERE") {
+		if stmt.where, err = p.parseExpr(); err != nil {
+			return nil, err
+		}
+	}
+	return &stmt, nil
+}
+
+func (p *parser) parseDelete() (*deleteStmt, error) {
+	if err := p.expect("DELETE", "FROM"); err != nil {
+		return nil, err
+	}
+
+	var (
+		stmt deleteStmt
+		err  error
+	)
+	if stmt.table, err = p.ident(); err != nil {
+		return nil, err
+	}
+	if p.accept("WHERE") {
+		if stmt.where, err = p.parseExpr(); err != nil {
+			return nil, err
+		}
+	}
+	return &stmt, nil
+}
+
+func (p *parser) parseExprList() ([]expr, error) {
+	var list []expr
+	for {
+		e, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, e)
+		if !p.accept(",") {
+			return list, nil
+		}
+	}
+}
+
+func (p *parser) parseExpr() (expr, error) {
+	return p.parseOr()
+}
+
+func (p *parser) parseOr() (expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("OR") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryExpr{op: "OR", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (expr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("AND") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryExpr{op: "AND", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (expr, error) {
+	if p.accept("NOT") {
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryExpr{op: "NOT", operand: operand}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (expr, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, op := range []string{"=", "!=", "<>", "<=", ">=", "<", ">"} {
+		if p.accept(op) {
+			right, err := p.parseAdditive()
+			if err != nil {
+				return nil, err
+			}
+			if op == "<>" {
+				op = "!="
+			}
+			return &binaryExpr{op: op, left: left, right: right}, nil
+		}
+	}
+
+	if p.accept("IS") {
+		not := p.accept("NOT")
+		if err = p.expect("NULL"); err != nil {
+			return nil, err
+		}
+		return &isNullExpr{operand: left, not: not}, nil
+	}
+
+	not := p.accept("NOT")
+	switch {
+	case p.accept("IN"):
+		in := &inExpr{operand: left, not: not}
+		if err = p.expect("("); err != nil {
+			return nil, err
+		}
+		if p.peek().keyword("SELECT") {
+			in.subquery, err = p.parseSelect()
+		} else {
+			in.list, err = p.parseExprList()
+		}
+		if err != nil {
+			return nil, err
+		}
+		return in, p.expect(")")
+	case p.accept("BETWEEN"):
+		between := &betweenExpr{operand: left, not: not}
+		if between.from, err = p.parseAdditive(); err != nil {
+			return nil, err
+		}
+		if err = p.expect("AND"); err != nil {
+			return nil, err
+		}
+		between.to, err = p.parseAdditive()
+		return between, err
+	case p.accept("LIKE"):
+		like := &likeExpr{operand: left, not: not}
+		if like.pattern, err = p.parseAdditive(); err != nil {
+			return nil, err
+		}
+		if p.accept("ESCAPE") {
+			like.escape, err = p.parseAdditive()
+		}
+		return like, err
+	case not:
+		return nil, p.errorf("unexpected NOT")
+	}
+	return left, nil
+}
+
+func (p *parser) parseAdditive() (expr, error) {
+	left, err := p.parseMultiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		var op string
+		switch {
+		case p.accept("+"):
+			op = "+"
+		case p.accept("-"):
+			op = "-"
+		case p.accept("||"):
+			op = "||"
+		default:
+			return left, nil
+		}
+		right, err := p.parseMultiplicative()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryExpr{op: op, left: left, right: right}
+	}
+}
+
+func (p *parser) parseMultiplicative() (expr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		var op string
+		switch {
+		case p.accept("*"):
+			op = "*"
+		case p.accept("/"):
+			op = "/"
+		case p.accept("%"):
+			op = "%"
+		default:
+			return left, nil
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryExpr{op: op, left: left, right: right}
+	}
+}
+
+func (p *parser) parseUnary() (expr, error) {
+	if p.accept("-") {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryExpr{op: "-", operand: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (expr, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokenParam:
+		p.params++
+		return &paramExpr{index: p.params - 1}, nil
+	case tokenString:
+		return &literalExpr{value: tok.text}, nil
+	case tokenNumber:
+		if strings.Contains(tok.text, ".") {
+			f, err := strconv.ParseFloat(tok.text, 64)
+			if err != nil {
+				return nil, p.errorf("invalid number %q", tok.text)
+			}
+			return &literalExpr{value: f}, nil
+		}
+		i, err := strconv.ParseInt(tok.text, 10, 64)
+		if err != nil {
+			return nil, p.errorf("invalid number %q", tok.text)
+		}
+		return &literalExpr{value: i}, nil
+	case tokenSymbol:
+		switch tok.text {
+		case "*":
+			return &starExpr{}, nil
+		case "(":
+			if p.peek().keyword("SELECT") {
+				return nil, p.errorf("scalar subqueries are not supported")
+			}
+			e, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			return e, p.expect(")")
+		}
+	case tokenIdent, tokenQuoted:
+		if tok.kind == tokenIdent {
+			switch strings.ToUpper(tok.text) {
+			case "NULL":
+				return &literalExpr{value: nil}, nil
+			case "TRUE":
+				return &literalExpr{value: true}, nil
+			case "FALSE":
+				return &literalExpr{value: false}, nil
+			case "EXISTS":
+				if err := p.expect("("); err != nil {
+					return nil, err
+				}
+				subquery, err := p.parseSelect()
+				if err != nil {
+					return nil, err
+				}
+				return &existsExpr{subquery: subquery}, p.expect(")")
+			}
+			if p.peek().symbol("(") {
+				return p.parseFunc(tok.text)
+			}
+		}
+
+		if p.accept(".") {
+			if p.accept("*") {
+				return &starExpr{table: tok.text}, nil
+			}
+			name, err := p.ident()
+			if err != nil {
+				return nil, err
+			}
+			return &columnExpr{table: tok.text, name: name}, nil
+		}
+		return &columnExpr{name: tok.text}, nil
+	}
+	return nil, p.errorf("unexpected %q", tok.text)
+}
+
+func (p *parser) parseFunc(name string) (expr, error) {
+	fn := &funcExpr{name: strings.ToUpper(name)}
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	if p.accept(")") {
+		return fn, nil
+	}
+	if p.accept("*") {
+		fn.star = true
+		return fn, p.expect(")")
+	}
+
+	fn.distinct = p.accept("DISTINCT")
+
+	var err error
+	if fn.args, err = p.parseExprList(); err != nil {
+		return nil, err
+	}
+	return fn, p.expect(")")
+}
@@ -3,8 +3,6 @@ package activerecord
 import (
 	"context"
 	"errors"
-	"fmt"
-	"strings"
 	"time"
 
 	. "github.com/activegraph/activegraph/activesupport"
@@ -89,7 +87,7 @@ func (tb *Table) References(target string, init ...References) {
 		panic(ErrMultipleVariadicArguments{Name: "init"})
 	}
 
-	ref := fmt.Sprintf("%s_id", strings.TrimSuffix(target, "s"))
+	ref := ForeignKey(target)
 	tb.DefineColumn(ref, new(Int64))
 }
 
@@ -165,7 +163,7 @@ func Migrate(id string, init func(m *M)) {
 	)
 
 	// TODO: Use the specified connection name, instead of the default.
-	err := m.connections.Transaction(context.TODO(), func() error {
+	err := m.connections.Transaction(context.TODO(), func(context.Context) error {
 		if schema.IsErr() {
 			return schema.Err()
 		}
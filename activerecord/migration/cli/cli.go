@@ -0,0 +1,193 @@
+// Package cli implements the command line interface of migrations, which
+// generates migrations and applies them to the database:
+//
+//	relsy generate migration add_price_to_products price:decimal
+//	relsy generate columns product
+//	relsy db migrate
+//	relsy db rollback -steps 2
+//	relsy db status
+//
+// Typed columns (see activerecord.Column) are generated from attributes of the
+// relation, the relation is initialized from its declaration (see
+// activerecord.Declare) or from the table in the database.
+//
+// Migrations are registered in Go code, therefore applications build their own
+// command with migrations compiled in:
+//
+//	package main
+//
+//	import (
+//		"github.com/activegraph/activegraph/activerecord/migration/cli"
+//		_ "github.com/activegraph/activegraph/activerecord/sqlite3"
+//
+//		_ "example.com/app/db/migrate"
+//	)
+//
+//	func main() {
+//		cli.Main()
+//	}
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/activegraph/activegraph/activerecord"
+	"github.com/activegraph/activegraph/activerecord/migration"
+)
+
+const (
+	// DefaultDir is the directory of generated migrations.
+	DefaultDir = "db/migrate"
+
+	// DefaultColumnsDir is the directory of generated typed columns.
+	DefaultColumnsDir = "models"
+
+	// URLVariable is the environment variable with the URL of the database,
+	// which is used, when the database is not specified with the flag.
+	URLVariable = "DATABASE_URL"
+)
+
+const usage = `usage: relsy <command> [arguments]
+
+commands:
+  generate migration <name> [column:type ...]
+  generate columns <relation>
+  db migrate [-version <version>]
+  db rollback [-steps <steps>]
+  db status
+`
+
+// ErrUsage is returned, when the command is invoked with invalid arguments.
+type ErrUsage struct {
+	Message string
+}
+
+func (e *ErrUsage) Is(target error) bool {
+	_, ok := target.(*ErrUsage)
+	return ok
+}
+
+func (e *ErrUsage) Error() string {
+	return e.Message
+}
+
+// Main runs the command with arguments of the process and exits with non-zero
+// status, when the command fails.
+func Main() {
+	if err := Run(context.Background(), os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "relsy: %s\n", err)
+		if errors.Is(err, new(ErrUsage)) {
+			fmt.Fprint(os.Stderr, usage)
+			os.Exit(2)
+		}
+		os.Exit(1)
+	}
+}
+
+// Run runs the command with given arguments, the output of the command is
+// written to w.
+func Run(ctx context.Context, args []string, w io.Writer) error {
+	if len(args) < 2 {
+		return &ErrUsage{Message: "command is missing"}
+	}
+
+	switch args[0] {
+	case "generate", "g":
+		switch args[1] {
+		case "migration":
+			return generate(args[2:], w)
+		case "columns":
+			return generateColumns(args[2:], w)
+		default:
+			return &ErrUsage{Message: fmt.Sprintf("unknown generator %q", args[1])}
+		}
+	case "db":
+		return db(ctx, args[1], args[2:], w)
+	default:
+		return &ErrUsage{Message: fmt.Sprintf("unknown command %q", args[0])}
+	}
+}
+
+// db runs the database command, the database is specified with the -database
+// flag, the URLVariable environment variable or the configuration file of the
+// current environment.
+func db(ctx context.Context, command string, args []string, w io.Writer) error {
+	var (
+		fs       = flag.NewFlagSet("db "+command, flag.ContinueOnError)
+		database = fs.String("database", os.Getenv(URLVariable), "URL of the database")
+		version  *string
+		steps    *int
+	)
+	fs.SetOutput(io.Discard)
+
+	switch command {
+	case "migrate":
+		version = fs.String("version", "", "version to migrate to")
+	case "rollback":
+		steps = fs.Int("steps", 1, "number of migrations to revert")
+	case "status":
+	default:
+		return &ErrUsage{Message: fmt.Sprintf("unknown command \"db %s\"", command)}
+	}
+	if err := fs.Parse(args); err != nil {
+		return &ErrUsage{Message: err.Error()}
+	}
+
+	conn, err := establishConnection(*database)
+	if err != nil {
+		return err
+	}
+	defer activerecord.RemoveConnection("primary")
+
+	m := migration.NewMigrator(conn)
+	switch {
+	case version != nil && *version != "":
+		return m.MigrateTo(ctx, *version)
+	case version != nil:
+		return m.Migrate(ctx)
+	case steps != nil:
+		return m.Rollback(ctx, *steps)
+	default:
+		return status(ctx, m, w)
+	}
+}
+
+// establishConnection establishes the primary connection to the database with
+// the URL, or to the database of the current environment, when the URL is empty.
+func establishConnection(database string) (activerecord.Conn, error) {
+	var config activerecord.ConnectionConfig = activerecord.Env("")
+	if database != "" {
+		config = activerecord.URL(database)
+	}
+	return activerecord.EstablishConnection(config)
+}
+
+// status writes statuses of migrations, one migration per line.
+func status(ctx context.Context, m *migration.Migrator, w io.Writer) error {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	b.WriteString("Status  Migration\n")
+	for _, status := range statuses {
+		state := "down"
+		if status.Applied {
+			state = "up"
+		}
+		fmt.Fprintf(&b, "%-6s  %s", state, status.Version)
+		if !status.Defined {
+			b.WriteString(" (not defined)")
+		}
+		b.WriteByte('\n')
+	}
+	_, err = io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,155 @@
+package cli_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/activegraph/activegraph/activerecord"
+	"github.com/activegraph/activegraph/activerecord/migration"
+	"github.com/activegraph/activegraph/activerecord/migration/cli"
+	_ "github.com/activegraph/activegraph/activerecord/sqlite3"
+)
+
+func TestRun_GenerateMigration(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "migrate")
+	args := []string{
+		"generate", "migration", "-dir", dir, "add_price_to_products", "price:decimal", "shop:references",
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, cli.Run(context.TODO(), args, &out))
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*_add_price_to_products.go"))
+	require.NoError(t, err)
+	require.Len(t, paths, 1)
+	require.Equal(t, "create "+paths[0]+"\n", out.String())
+
+	version := filepath.Base(paths[0])
+	version = version[:len(version)-len(".go")]
+
+	src, err := os.ReadFile(paths[0])
+	require.NoError(t, err)
+	require.Equal(t, `package migrate
+
+import (
+	"github.com/activegraph/activegraph/activerecord"
+	"github.com/activegraph/activegraph/activerecord/migration"
+)
+
+func init() {
+	migration.RegisterChange("`+version+`", func(m *migration.Migration) {
+		m.AddColumn("products", "price", new(activerecord.Float64))
+		m.AddColumn("products", "shop_id", new(activerecord.Int64))
+		m.AddForeignKey("products", "shop")
+	})
+}
+`, string(src))
+
+	// Migrations are generated once.
+	err = cli.Run(context.TODO(), args, &out)
+	require.Error(t, err)
+
+	err = cli.Run(context.TODO(), []string{"generate", "migration", "-dir", dir, "create_shops", "name:money"}, &out)
+	require.True(t, errors.Is(err, new(cli.ErrUsage)), err)
+}
+
+func TestRun_Database(t *testing.T) {
+	database := "sqlite3:" + t.Name() + ".db"
+	defer os.Remove(t.Name() + ".db")
+
+	migration.RegisterChange("20230101000000_create_products", func(m *migration.Migration) {
+		m.CreateTable("products", func(t *migration.Table) {
+			t.String("name")
+		})
+	})
+	migration.RegisterChange("20230102000000_add_price_to_products", func(m *migration.Migration) {
+		m.AddColumn("products", "price", new(activerecord.Float64))
+	})
+
+	run := func(args ...string) string {
+		var out bytes.Buffer
+		require.NoError(t, cli.Run(context.TODO(), append(args, "-database", database), &out))
+		return out.String()
+	}
+
+	require.Equal(t, "Status  Migration\n"+
+		"down    20230101000000_create_products\n"+
+		"down    20230102000000_add_price_to_products\n", run("db", "status"))
+
+	run("db", "migrate", "-version", "20230101000000_create_products")
+	require.Equal(t, "Status  Migration\n"+
+		"up      20230101000000_create_products\n"+
+		"down    20230102000000_add_price_to_products\n", run("db", "status"))
+
+	run("db", "migrate")
+	run("db", "rollback", "-steps", "2")
+	require.Equal(t, "Status  Migration\n"+
+		"down    20230101000000_create_products\n"+
+		"down    20230102000000_add_price_to_products\n", run("db", "status"))
+
+	err := cli.Run(context.TODO(), []string{"db", "drop"}, new(bytes.Buffer))
+	require.True(t, errors.Is(err, new(cli.ErrUsage)), err)
+}
+
+func TestRun_GenerateColumns(t *testing.T) {
+	database := "sqlite3:" + t.Name() + ".db"
+	defer os.Remove(t.Name() + ".db")
+
+	_, err := activerecord.EstablishConnection(activerecord.URL(database))
+	require.NoError(t, err)
+	activerecord.Migrate(t.Name(), func(m *activerecord.M) {
+		m.CreateTable("authors", func(t *activerecord.Table) {
+			t.String("name")
+			t.DateTime("born_at")
+		})
+	})
+	activerecord.RemoveConnection("primary")
+
+	dir := filepath.Join(t.TempDir(), "models")
+	args := []string{"generate", "columns", "-dir", dir, "-database", database, "author"}
+
+	var out bytes.Buffer
+	require.NoError(t, cli.Run(context.TODO(), args, &out))
+
+	path := filepath.Join(dir, "author_columns.go")
+	require.Equal(t, "create "+path+"\n", out.String())
+
+	src, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, `// Code generated by "relsy generate columns author"; DO NOT EDIT.
+
+package models
+
+import (
+	"time"
+
+	"github.com/activegraph/activegraph/activerecord"
+)
+
+// AuthorColumns are typed columns of the "author" relation.
+type AuthorColumns struct {
+	ID     activerecord.Column[int64]
+	BornAt activerecord.Column[*time.Time]
+	Name   activerecord.Column[*string]
+}
+
+// MustAuthorColumns returns typed columns of the relation, it panics when columns
+// do not match attributes of the relation.
+func MustAuthorColumns(rel *activerecord.Relation) AuthorColumns {
+	return activerecord.MustColumns(rel, AuthorColumns{
+		ID:     activerecord.NewColumn[int64]("id"),
+		BornAt: activerecord.NewColumn[*time.Time]("born_at"),
+		Name:   activerecord.NewColumn[*string]("name"),
+	})
+}
+`, string(src))
+
+	err = cli.Run(context.TODO(), []string{"generate", "columns", "-database", database}, &out)
+	require.True(t, errors.Is(err, new(cli.ErrUsage)), err)
+}
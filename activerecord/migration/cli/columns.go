@@ -0,0 +1,173 @@
+package cli
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/activegraph/activegraph/activerecord"
+	"github.com/activegraph/activegraph/activesupport"
+)
+
+// columnGoType returns the type of values of the typed column of the attribute
+// type, nullable attributes have columns of pointer types.
+func columnGoType(t activerecord.Type) (string, bool) {
+	if n, ok := t.(activerecord.Nil); ok {
+		typ, ok := columnGoType(n.Type)
+		return "*" + typ, ok
+	}
+
+	switch t.(type) {
+	case *activerecord.Int64:
+		return "int64", true
+	case *activerecord.String:
+		return "string", true
+	case *activerecord.Float64:
+		return "float64", true
+	case *activerecord.Boolean:
+		return "bool", true
+	case *activerecord.DateTime, *activerecord.Date, *activerecord.Time:
+		return "time.Time", true
+	default:
+		return "", false
+	}
+}
+
+// fieldName returns the name of the struct field of the attribute, identifier
+// parts are spelled in upper case: "author_id" is "AuthorID".
+func fieldName(attrName string) string {
+	var buf strings.Builder
+	for _, part := range strings.Split(attrName, "_") {
+		if part == "id" {
+			buf.WriteString("ID")
+		} else {
+			buf.WriteString(activesupport.Camelize(part))
+		}
+	}
+	return buf.String()
+}
+
+// generatedField is the typed column of the relation attribute.
+type generatedField struct {
+	name     string
+	attrName string
+	goType   string
+}
+
+// generateColumns writes typed columns of the relation into the directory of
+// models. Columns are generated for attributes of the relation, the file is
+// overwritten, so columns are regenerated after migrations of the table.
+// Attributes without typed columns, e.g. JSON attributes, are skipped.
+func generateColumns(args []string, w io.Writer) error {
+	fs := flag.NewFlagSet("generate columns", flag.ContinueOnError)
+	dir := fs.String("dir", DefaultColumnsDir, "directory of models")
+	database := fs.String("database", os.Getenv(URLVariable), "URL of the database")
+	fs.SetOutput(io.Discard)
+
+	if err := fs.Parse(args); err != nil {
+		return &ErrUsage{Message: err.Error()}
+	}
+	if fs.NArg() != 1 {
+		return &ErrUsage{Message: "name of the relation is missing"}
+	}
+
+	name := fs.Arg(0)
+	if !migrationNameRe.MatchString(name) {
+		return &ErrUsage{Message: fmt.Sprintf("invalid relation name %q", name)}
+	}
+
+	if _, err := establishConnection(*database); err != nil {
+		return err
+	}
+	defer activerecord.RemoveConnection("primary")
+
+	rel, err := activerecord.Initialize(name, nil)
+	if err != nil {
+		return err
+	}
+
+	// The primary key goes first, other attributes are sorted by names.
+	attrNames := []string{rel.PrimaryKey()}
+	for _, attrName := range rel.AttributeNames() {
+		if attrName != rel.PrimaryKey() {
+			attrNames = append(attrNames, attrName)
+		}
+	}
+
+	fields := make([]generatedField, 0, len(attrNames))
+	for _, attrName := range attrNames {
+		attr := rel.AttributeForInspect(attrName)
+		if attr == nil {
+			continue
+		}
+		goType, ok := columnGoType(attr.AttributeType())
+		if !ok {
+			fmt.Fprintf(w, "skip %s (%s)\n", attrName, attr.AttributeType())
+			continue
+		}
+		fields = append(fields, generatedField{
+			name: fieldName(attrName), attrName: attrName, goType: goType,
+		})
+	}
+
+	src, err := columnsSource(packageName(*dir, "models"), name, fields)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(*dir, 0o755); err != nil {
+		return err
+	}
+	path := filepath.Join(*dir, name+"_columns.go")
+	if err := os.WriteFile(path, src, 0o644); err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "create %s\n", path)
+	return err
+}
+
+// columnsSource returns the formatted source of typed columns of the relation.
+func columnsSource(pkg, name string, fields []generatedField) ([]byte, error) {
+	var (
+		typeName = fieldName(name) + "Columns"
+		src      bytes.Buffer
+		times    bool
+	)
+	for _, field := range fields {
+		times = times || strings.HasSuffix(field.goType, "time.Time")
+	}
+
+	fmt.Fprintf(&src, "// Code generated by \"relsy generate columns %s\"; DO NOT EDIT.\n\n", name)
+	fmt.Fprintf(&src, "package %s\n\n", pkg)
+	src.WriteString("import (\n")
+	if times {
+		src.WriteString("\"time\"\n\n")
+	}
+	src.WriteString("\"github.com/activegraph/activegraph/activerecord\"\n")
+	src.WriteString(")\n\n")
+
+	fmt.Fprintf(&src, "// %s are typed columns of the %q relation.\n", typeName, name)
+	fmt.Fprintf(&src, "type %s struct {\n", typeName)
+	for _, field := range fields {
+		fmt.Fprintf(&src, "%s activerecord.Column[%s]\n", field.name, field.goType)
+	}
+	src.WriteString("}\n\n")
+
+	fmt.Fprintf(&src, "// Must%s returns typed columns of the relation, it panics when columns\n", typeName)
+	src.WriteString("// do not match attributes of the relation.\n")
+	fmt.Fprintf(&src, "func Must%s(rel *activerecord.Relation) %s {\n", typeName, typeName)
+	fmt.Fprintf(&src, "return activerecord.MustColumns(rel, %s{\n", typeName)
+	for _, field := range fields {
+		fmt.Fprintf(&src, "%s: activerecord.NewColumn[%s](%q),\n", field.name, field.goType, field.attrName)
+	}
+	src.WriteString("})\n")
+	src.WriteString("}\n")
+
+	return format.Source(src.Bytes())
+}
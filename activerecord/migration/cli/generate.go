@@ -0,0 +1,210 @@
+package cli
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// columnType is the type of the column in the generated migration.
+type columnType struct {
+	// method is the method of the Table, which defines the column.
+	method string
+	// expr is the expression of the activerecord type.
+	expr string
+}
+
+// columnTypes are types of columns, which are specified in arguments of the
+// migration generator.
+var columnTypes = map[string]columnType{
+	"string":     {method: "String", expr: "new(activerecord.String)"},
+	"text":       {method: "String", expr: "new(activerecord.String)"},
+	"integer":    {method: "Int64", expr: "new(activerecord.Int64)"},
+	"bigint":     {method: "Int64", expr: "new(activerecord.Int64)"},
+	"float":      {method: "Float64", expr: "new(activerecord.Float64)"},
+	"decimal":    {method: "Float64", expr: "new(activerecord.Float64)"},
+	"boolean":    {method: "Boolean", expr: "new(activerecord.Boolean)"},
+	"datetime":   {method: "DateTime", expr: "new(activerecord.DateTime)"},
+	"timestamp":  {method: "DateTime", expr: "new(activerecord.DateTime)"},
+	"date":       {method: "Date", expr: "new(activerecord.Date)"},
+	"time":       {expr: "new(activerecord.Time)"},
+	"json":       {expr: "new(activerecord.JSON)"},
+	"references": {method: "References", expr: "new(activerecord.Int64)"},
+	"belongs_to": {method: "References", expr: "new(activerecord.Int64)"},
+}
+
+var (
+	migrationNameRe = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+	addColumnsRe    = regexp.MustCompile(`^add_.+_to_([a-z0-9_]+)$`)
+	removeColumnsRe = regexp.MustCompile(`^remove_.+_from_([a-z0-9_]+)$`)
+	createTableRe   = regexp.MustCompile(`^create_([a-z0-9_]+)$`)
+)
+
+// generatedColumn is the column specified as "name:type" argument.
+type generatedColumn struct {
+	name string
+	columnType
+}
+
+func parseColumn(arg string) (generatedColumn, error) {
+	name, typeName := arg, "string"
+	if i := strings.IndexByte(arg, ':'); i >= 0 {
+		name, typeName = arg[:i], arg[i+1:]
+	}
+	if !migrationNameRe.MatchString(name) {
+		return generatedColumn{}, &ErrUsage{Message: fmt.Sprintf("invalid column name %q", name)}
+	}
+	t, ok := columnTypes[typeName]
+	if !ok {
+		return generatedColumn{}, &ErrUsage{Message: fmt.Sprintf("unknown column type %q", typeName)}
+	}
+	return generatedColumn{name: name, columnType: t}, nil
+}
+
+// generate writes the migration into the directory of migrations. Operations of
+// the migration are derived from its name:
+//
+//	create_products name:string price:decimal
+//	add_price_to_products price:decimal
+//	remove_price_from_products price:decimal
+//
+// Migrations with other names are generated empty.
+func generate(args []string, w io.Writer) error {
+	fs := flag.NewFlagSet("generate migration", flag.ContinueOnError)
+	dir := fs.String("dir", DefaultDir, "directory of migrations")
+	fs.SetOutput(io.Discard)
+
+	if err := fs.Parse(args); err != nil {
+		return &ErrUsage{Message: err.Error()}
+	}
+	if fs.NArg() == 0 {
+		return &ErrUsage{Message: "name of the migration is missing"}
+	}
+
+	name := fs.Arg(0)
+	if !migrationNameRe.MatchString(name) {
+		return &ErrUsage{Message: fmt.Sprintf("invalid migration name %q", name)}
+	}
+
+	columns := make([]generatedColumn, 0, fs.NArg()-1)
+	for _, arg := range fs.Args()[1:] {
+		column, err := parseColumn(arg)
+		if err != nil {
+			return err
+		}
+		columns = append(columns, column)
+	}
+
+	existing, err := filepath.Glob(filepath.Join(*dir, "*_"+name+".go"))
+	if err != nil {
+		return err
+	}
+	if len(existing) > 0 {
+		return fmt.Errorf("migration %q already exists in %s", name, existing[0])
+	}
+
+	version := time.Now().UTC().Format("20060102150405") + "_" + name
+	src, err := migrationSource(packageName(*dir, "migrate"), version, name, columns)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(*dir, 0o755); err != nil {
+		return err
+	}
+	path := filepath.Join(*dir, version+".go")
+	if err := os.WriteFile(path, src, 0o644); err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "create %s\n", path)
+	return err
+}
+
+// packageName returns the name of the package in the directory, or the fallback
+// name, when the directory name is not an identifier.
+func packageName(dir, fallback string) string {
+	name := strings.ReplaceAll(filepath.Base(dir), "-", "_")
+	if !token.IsIdentifier(name) {
+		return fallback
+	}
+	return name
+}
+
+// migrationSource returns the formatted source of the migration.
+func migrationSource(pkg, version, name string, columns []generatedColumn) ([]byte, error) {
+	var (
+		body         bytes.Buffer
+		activerecord bool
+	)
+
+	switch {
+	case createTableRe.MatchString(name):
+		table := createTableRe.FindStringSubmatch(name)[1]
+		fmt.Fprintf(&body, "m.CreateTable(%q, func(t *migration.Table) {\n", table)
+		for _, column := range columns {
+			if column.method != "" {
+				fmt.Fprintf(&body, "t.%s(%q)\n", column.method, column.name)
+			} else {
+				fmt.Fprintf(&body, "t.Column(%q, %s)\n", column.name, column.expr)
+				activerecord = true
+			}
+		}
+		body.WriteString("})\n")
+
+	case addColumnsRe.MatchString(name):
+		table := addColumnsRe.FindStringSubmatch(name)[1]
+		for _, column := range columns {
+			if column.method == "References" {
+				fmt.Fprintf(&body, "m.AddColumn(%q, %q, %s)\n", table, column.name+"_id", column.expr)
+				fmt.Fprintf(&body, "m.AddForeignKey(%q, %q)\n", table, column.name)
+			} else {
+				fmt.Fprintf(&body, "m.AddColumn(%q, %q, %s)\n", table, column.name, column.expr)
+			}
+			activerecord = true
+		}
+
+	case removeColumnsRe.MatchString(name):
+		table := removeColumnsRe.FindStringSubmatch(name)[1]
+		for _, column := range columns {
+			if column.method == "References" {
+				fmt.Fprintf(&body, "m.RemoveForeignKey(%q, %q)\n", table, column.name)
+				fmt.Fprintf(&body, "m.RemoveColumn(%q, %q, %s)\n", table, column.name+"_id", column.expr)
+			} else {
+				fmt.Fprintf(&body, "m.RemoveColumn(%q, %q, %s)\n", table, column.name, column.expr)
+			}
+			activerecord = true
+		}
+
+	default:
+		if len(columns) > 0 {
+			return nil, &ErrUsage{Message: fmt.Sprintf(
+				"columns are not derived from the migration name %q", name,
+			)}
+		}
+	}
+
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "package %s\n\n", pkg)
+	src.WriteString("import (\n")
+	if activerecord {
+		src.WriteString("\"github.com/activegraph/activegraph/activerecord\"\n")
+	}
+	src.WriteString("\"github.com/activegraph/activegraph/activerecord/migration\"\n")
+	src.WriteString(")\n\n")
+	src.WriteString("func init() {\n")
+	fmt.Fprintf(&src, "migration.RegisterChange(%q, func(m *migration.Migration) {\n", version)
+	src.Write(body.Bytes())
+	src.WriteString("})\n")
+	src.WriteString("}\n")
+
+	return format.Source(src.Bytes())
+}
@@ -0,0 +1,53 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/activegraph/activegraph/activerecord"
+)
+
+// GuardOption configures the check of pending migrations at boot.
+type GuardOption func(*guard)
+
+type guard struct {
+	warn io.Writer
+}
+
+// Warn reports pending migrations to the writer instead of failing the check.
+func Warn(w io.Writer) GuardOption {
+	return func(g *guard) { g.warn = w }
+}
+
+// CheckPending checks, that registered migrations are applied to the database
+// of the connection. The check is intended to run at boot of the application,
+// so the application does not start against the outdated schema and does not
+// fail later on unknown attributes and missing tables:
+//
+//	conn, err := activerecord.EstablishConnection(activerecord.Env(""))
+//	if err != nil {
+//		return err
+//	}
+//	if err := migration.CheckPending(ctx, conn); err != nil {
+//		return err
+//	}
+//
+// ErrPendingMigrations is returned, when some of registered migrations are not
+// applied. With the Warn option pending migrations are written as a warning and
+// only errors of the database are returned.
+func CheckPending(ctx context.Context, conn activerecord.Conn, opts ...GuardOption) error {
+	var g guard
+	for _, opt := range opts {
+		opt(&g)
+	}
+
+	err := NewMigrator(conn).CheckPending(ctx)
+	if err == nil || g.warn == nil || !errors.Is(err, new(ErrPendingMigrations)) {
+		return err
+	}
+
+	_, err = fmt.Fprintf(g.warn, "WARNING: %s\n", err)
+	return err
+}
@@ -0,0 +1,330 @@
+// Package migration implements the DSL of schema migrations. Operations of the
+// migration are compiled to statements of the database dialect, so the schema
+// is defined once and applied to any supported database:
+//
+//	m := migration.New(func(m *migration.Migration) {
+//		m.CreateTable("authors", func(t *migration.Table) {
+//			t.String("name", migration.NotNull())
+//		})
+//		m.AddColumn("books", "author_id", new(activerecord.Int64))
+//		m.AddIndex("books", []string{"author_id"})
+//	})
+//
+//	err := m.Exec(ctx, conn)
+//
+// Statements are compiled with the activerecord.SchemaDialect of the connection.
+package migration
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/activegraph/activegraph/activerecord"
+	. "github.com/activegraph/activegraph/activesupport"
+)
+
+// Operation is the schema operation of the migration.
+type Operation interface {
+	// Exec compiles the operation for the dialect of the connection and
+	// executes resulting statements.
+	Exec(ctx context.Context, conn activerecord.Conn) error
+}
+
+// Migration is the list of schema operations executed in the order of definition.
+type Migration struct {
+	operations []Operation
+}
+
+// New returns a new migration with operations defined by the init function.
+func New(init func(m *Migration)) *Migration {
+	var m Migration
+	init(&m)
+	return &m
+}
+
+// Operations returns operations of the migration.
+func (m *Migration) Operations() []Operation {
+	return m.operations
+}
+
+// Operation adds the operation to the migration.
+func (m *Migration) Operation(op Operation) {
+	m.operations = append(m.operations, op)
+}
+
+// CreateTable adds the operation to create a table with columns defined by the
+// init function. Table has "id" primary key, unless a different primary key is
+// defined.
+func (m *Migration) CreateTable(name string, init func(t *Table)) {
+	t := Table{name: name}
+	init(&t)
+	m.Operation(&CreateTable{
+		Name:             name,
+		Columns:          t.Columns(),
+		ForeignKeys:      t.ForeignKeys(),
+		CheckConstraints: t.CheckConstraints(),
+		Options:          t.Options(),
+	})
+}
+
+// DropTable adds the operation to drop the table. Columns of the table could be
+// defined with the init function, so the operation is reversible.
+func (m *Migration) DropTable(name string, init ...func(t *Table)) {
+	switch len(init) {
+	case 0:
+		m.Operation(&DropTable{Name: name})
+	case 1:
+		t := Table{name: name}
+		init[0](&t)
+		m.Operation(&DropTable{
+			Name:             name,
+			Columns:          t.Columns(),
+			ForeignKeys:      t.ForeignKeys(),
+			CheckConstraints: t.CheckConstraints(),
+			Options:          t.Options(),
+		})
+	default:
+		panic(ErrMultipleVariadicArguments{Name: "init"})
+	}
+}
+
+// AddColumn adds the operation to add a column to the existing table.
+func (m *Migration) AddColumn(
+	table, column string, columnType activerecord.Type, opts ...ColumnOption,
+) {
+	c := newColumn(column, columnType, opts)
+	m.Operation(&AddColumn{Table: table, Column: c.ColumnDefinition})
+}
+
+// RemoveColumn adds the operation to remove a column from the table. The
+// optional type of the column makes the operation reversible.
+func (m *Migration) RemoveColumn(table, column string, columnType ...activerecord.Type) {
+	op := RemoveColumn{Table: table, Column: column}
+	switch len(columnType) {
+	case 0:
+	case 1:
+		op.Type = columnType[0]
+	default:
+		panic(ErrMultipleVariadicArguments{Name: "columnType"})
+	}
+	m.Operation(&op)
+}
+
+// AddIndex adds the operation to create an index of the table columns. By default
+// index is named "index_<table>_on_<column>[_and_<column>]".
+func (m *Migration) AddIndex(table string, columns []string, opts ...IndexOption) {
+	op := AddIndex{Table: table, Columns: columns}
+	for _, opt := range opts {
+		opt(&op)
+	}
+	m.Operation(&op)
+}
+
+// RemoveIndex adds the operation to remove the index of table columns. Index is
+// removed by the name, when it is specified with IndexName option.
+func (m *Migration) RemoveIndex(table string, columns []string, opts ...IndexOption) {
+	op := AddIndex{Table: table, Columns: columns}
+	for _, opt := range opts {
+		opt(&op)
+	}
+	m.Operation((*RemoveIndex)(&op))
+}
+
+// AddForeignKey adds the operation to create the foreign key constraint of the
+// table referencing the target table:
+//
+//	m.AddForeignKey("targets", "owners", migration.OnDelete(migration.Cascade))
+//
+// By default the constraint references "id" column of the target table with
+// "<target>_id" column, and it is named "fk_<table>_on_<target>".
+func (m *Migration) AddForeignKey(table, target string, opts ...ForeignKeyOption) {
+	m.Operation(newForeignKey(table, target, opts))
+}
+
+// RemoveForeignKey adds the operation to remove the foreign key constraint of
+// the table referencing the target table.
+func (m *Migration) RemoveForeignKey(table, target string, opts ...ForeignKeyOption) {
+	m.Operation((*RemoveForeignKey)(newForeignKey(table, target, opts)))
+}
+
+// AddCheckConstraint adds the operation to create the check constraint of the
+// table:
+//
+//	m.AddCheckConstraint("products", "price >= 0", migration.CheckName("price_nonneg"))
+//
+// By default the constraint is named "chk_<table>_<hash of expression>".
+func (m *Migration) AddCheckConstraint(table, expression string, opts ...CheckOption) {
+	m.Operation(newCheckConstraint(table, expression, opts))
+}
+
+// RemoveCheckConstraint adds the operation to remove the check constraint of
+// the table. The expression is used to create the constraint, when the
+// operation is reverted.
+func (m *Migration) RemoveCheckConstraint(table, expression string, opts ...CheckOption) {
+	m.Operation((*RemoveCheckConstraint)(newCheckConstraint(table, expression, opts)))
+}
+
+// ValidateConstraint adds the operation to validate existing rows of the table
+// against the constraint created with NotValid option.
+func (m *Migration) ValidateConstraint(table, name string) {
+	m.Operation(&ValidateConstraint{Table: table, Name: name})
+}
+
+// RenameTable adds the operation to rename the table.
+func (m *Migration) RenameTable(from, to string) {
+	m.Operation(&RenameTable{From: from, To: to})
+}
+
+// ViewOption configures the view.
+type ViewOption func(*CreateView)
+
+// Materialized specifies to store results of the view in the database, results
+// are refreshed with RefreshMaterializedView. Materialized views are supported
+// only by PostgreSQL.
+func Materialized() ViewOption {
+	return func(v *CreateView) {
+		v.Materialized = true
+	}
+}
+
+func newView(name string, query interface{}, opts []ViewOption) *CreateView {
+	v := CreateView{Name: name, Query: viewQuery(query)}
+	for _, opt := range opts {
+		opt(&v)
+	}
+	return &v
+}
+
+// viewQuery returns the SQL query of the view defined either with the SQL text
+// or with the relation. Arguments of relation conditions are inlined into the
+// query as SQL literals.
+func viewQuery(query interface{}) string {
+	switch query := query.(type) {
+	case nil:
+		return ""
+	case string:
+		return query
+	case *activerecord.Relation:
+		op := query.Operation()
+		return inlineArgs(op.Text, op.Args)
+	default:
+		panic(fmt.Sprintf("migration: query of the view must be string or relation, got %T", query))
+	}
+}
+
+// inlineArgs replaces placeholders of the statement outside of quoted literals
+// with SQL literals of arguments.
+func inlineArgs(stmt string, args []interface{}) string {
+	var (
+		buf    strings.Builder
+		quoted bool
+	)
+	for _, r := range stmt {
+		switch {
+		case r == '\'':
+			quoted = !quoted
+		case r == '?' && !quoted && len(args) > 0:
+			buf.WriteString(defaultStmt(args[0]))
+			args = args[1:]
+			continue
+		}
+		buf.WriteRune(r)
+	}
+	return buf.String()
+}
+
+// CreateView adds the operation to create the view, the query of the view is
+// either the SQL text or the relation:
+//
+//	m.CreateView("adults", activerecord.New("user").Where("age >= ?", 18))
+//	m.CreateView("sales_reports", "SELECT ...", migration.Materialized())
+//
+// Relations of views are read-only (see activerecord.R.ReadOnly).
+func (m *Migration) CreateView(name string, query interface{}, opts ...ViewOption) {
+	m.Operation(newView(name, query, opts))
+}
+
+// DropView adds the operation to drop the view. The query of the view makes the
+// operation reversible, the drop of the view with nil query is irreversible.
+func (m *Migration) DropView(name string, query interface{}, opts ...ViewOption) {
+	m.Operation((*DropView)(newView(name, query, opts)))
+}
+
+// RefreshMaterializedView adds the operation to refresh results of the
+// materialized view, concurrent refresh does not lock reads of the view.
+func (m *Migration) RefreshMaterializedView(name string, concurrently bool) {
+	m.Operation(&RefreshMaterializedView{Name: name, Concurrently: concurrently})
+}
+
+// Execute adds the operation to execute the SQL statement, the operation is
+// irreversible, use Reversible to define the statement reverting it.
+func (m *Migration) Execute(stmt string) {
+	m.Operation(&Execute{Statement: stmt})
+}
+
+// Reversible adds the operation, which executes operations of the up function,
+// and operations of the down function, when the migration is reverted. It is
+// used for operations, which could not be inverted automatically:
+//
+//	m.Reversible(func(m *migration.Migration) {
+//		m.Execute("CREATE VIEW adults AS SELECT * FROM users WHERE age >= 18")
+//	}, func(m *migration.Migration) {
+//		m.Execute("DROP VIEW adults")
+//	})
+func (m *Migration) Reversible(up, down func(m *Migration)) {
+	m.Operation(&Reversible{Up: New(up), Down: New(down)})
+}
+
+// Inverse returns the migration, which reverts operations of the migration in
+// the reverse order. ErrIrreversibleMigration is returned, when some of the
+// operations could not be inverted.
+func (m *Migration) Inverse() (*Migration, error) {
+	inverse := Migration{operations: make([]Operation, 0, len(m.operations))}
+	for i := len(m.operations) - 1; i >= 0; i-- {
+		op, ok := m.operations[i].(ReversibleOperation)
+		if !ok {
+			return nil, &ErrIrreversibleMigration{Operation: m.operations[i]}
+		}
+		inverseOp, err := op.Inverse()
+		if err != nil {
+			return nil, err
+		}
+		inverse.Operation(inverseOp)
+	}
+	return &inverse, nil
+}
+
+// transactional returns false, when operations of the migration could not be
+// executed within a transaction, e.g. concurrent creation of indexes.
+func (m *Migration) transactional() bool {
+	for _, op := range m.operations {
+		switch op := op.(type) {
+		case *Reversible:
+			if !op.Up.transactional() {
+				return false
+			}
+		case *AddIndex:
+			if op.Concurrently {
+				return false
+			}
+		case *RemoveIndex:
+			if op.Concurrently {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Exec executes operations of the migration one by one, execution stops at the
+// first failed operation. Operations are not wrapped into a transaction, pass
+// the transaction connection to apply migration atomically.
+func (m *Migration) Exec(ctx context.Context, conn activerecord.Conn) error {
+	for _, op := range m.operations {
+		if err := op.Exec(ctx, conn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
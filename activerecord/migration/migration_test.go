@@ -0,0 +1,543 @@
+package migration_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/activegraph/activegraph/activerecord"
+	"github.com/activegraph/activegraph/activerecord/migration"
+	"github.com/activegraph/activegraph/activerecord/mock"
+	"github.com/activegraph/activegraph/activerecord/mysql"
+	"github.com/activegraph/activegraph/activerecord/postgresql"
+	_ "github.com/activegraph/activegraph/activerecord/sqlite3"
+	. "github.com/activegraph/activegraph/activesupport"
+)
+
+func statements(m *migration.Migration, d activerecord.SchemaDialect) (stmts []string) {
+	for _, op := range m.Operations() {
+		stmts = append(stmts, migration.Statements(d, op)...)
+	}
+	return stmts
+}
+
+func TestMigration_Statements(t *testing.T) {
+	m := migration.New(func(m *migration.Migration) {
+		m.CreateTable("authors", func(t *migration.Table) {
+			t.String("name", migration.NotNull())
+			t.Int64("born")
+		})
+		m.AddColumn("books", "author_id", new(activerecord.Int64))
+		m.RemoveColumn("books", "author")
+		m.AddIndex("books", []string{"author_id", "title"}, migration.Unique())
+		m.RenameTable("books", "novels")
+		m.RemoveIndex("novels", []string{"author_id", "title"})
+		m.DropTable("authors")
+	})
+
+	tests := []struct {
+		dialect  activerecord.SchemaDialect
+		expected []string
+	}{
+		{
+			dialect: activerecord.DefaultDialect,
+			expected: []string{
+				`CREATE TABLE "authors" ("id" INTEGER NOT NULL, "name" VARCHAR NOT NULL, "born" INTEGER, PRIMARY KEY ("id"))`,
+				`ALTER TABLE "books" ADD COLUMN "author_id" INTEGER`,
+				`ALTER TABLE "books" DROP COLUMN "author"`,
+				`CREATE UNIQUE INDEX "index_books_on_author_id_and_title" ON "books" ("author_id", "title")`,
+				`ALTER TABLE "books" RENAME TO "novels"`,
+				`DROP INDEX "index_novels_on_author_id_and_title"`,
+				`DROP TABLE "authors"`,
+			},
+		},
+		{
+			dialect: new(mysql.Conn),
+			expected: []string{
+				"CREATE TABLE `authors` (`id` BIGINT AUTO_INCREMENT NOT NULL, `name` VARCHAR(255) NOT NULL, `born` BIGINT, PRIMARY KEY (`id`))",
+				"ALTER TABLE `books` ADD COLUMN `author_id` BIGINT",
+				"ALTER TABLE `books` DROP COLUMN `author`",
+				"CREATE UNIQUE INDEX `index_books_on_author_id_and_title` ON `books` (`author_id`, `title`)",
+				"ALTER TABLE `books` RENAME TO `novels`",
+				"DROP INDEX `index_novels_on_author_id_and_title` ON `novels`",
+				"DROP TABLE `authors`",
+			},
+		},
+		{
+			dialect: new(postgresql.Conn),
+			expected: []string{
+				`CREATE TABLE "authors" ("id" BIGSERIAL NOT NULL, "name" VARCHAR NOT NULL, "born" BIGINT, PRIMARY KEY ("id"))`,
+				`ALTER TABLE "books" ADD COLUMN "author_id" BIGINT`,
+				`ALTER TABLE "books" DROP COLUMN "author"`,
+				`CREATE UNIQUE INDEX "index_books_on_author_id_and_title" ON "books" ("author_id", "title")`,
+				`ALTER TABLE "books" RENAME TO "novels"`,
+				`DROP INDEX "index_novels_on_author_id_and_title"`,
+				`DROP TABLE "authors"`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		require.Equal(t, tt.expected, statements(m, tt.dialect))
+	}
+}
+
+func TestMigration_Inverse(t *testing.T) {
+	m := migration.New(func(m *migration.Migration) {
+		m.CreateTable("authors", func(t *migration.Table) {
+			t.String("name")
+		})
+		m.AddColumn("authors", "born", new(activerecord.Int64))
+		m.AddIndex("authors", []string{"name"})
+		m.RenameTable("authors", "writers")
+	})
+
+	inverse, err := m.Inverse()
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		`ALTER TABLE "writers" RENAME TO "authors"`,
+		`DROP INDEX "index_authors_on_name"`,
+		`ALTER TABLE "authors" DROP COLUMN "born"`,
+		`DROP TABLE "authors"`,
+	}, statements(inverse, activerecord.DefaultDialect))
+
+	m = migration.New(func(m *migration.Migration) {
+		m.DropTable("authors")
+	})
+	_, err = m.Inverse()
+
+	var errIrreversible *migration.ErrIrreversibleMigration
+	require.True(t, errors.As(err, &errIrreversible), err)
+	require.IsType(t, new(migration.DropTable), errIrreversible.Operation)
+}
+
+func TestMigration_Indexes(t *testing.T) {
+	m := migration.New(func(m *migration.Migration) {
+		m.AddIndex("users", []string{"lower(email)"}, migration.Unique(),
+			migration.Where("deleted_at IS NULL"),
+		)
+		m.AddIndex("users", []string{"name", "born"}, migration.Concurrently())
+	})
+
+	require.Equal(t, []string{
+		`CREATE UNIQUE INDEX "index_users_on_lower_email" ON "users" ((lower(email))) WHERE deleted_at IS NULL`,
+		`CREATE INDEX CONCURRENTLY "index_users_on_name_and_born" ON "users" ("name", "born")`,
+	}, statements(m, new(postgresql.Conn)))
+	require.Equal(t, []string{
+		"CREATE UNIQUE INDEX `index_users_on_lower_email` ON `users` ((lower(email))) WHERE deleted_at IS NULL",
+		"CREATE INDEX `index_users_on_name_and_born` ON `users` (`name`, `born`)",
+	}, statements(m, new(mysql.Conn)))
+
+	conn, err := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter: "sqlite3", Database: t.Name() + ".db",
+	})
+	require.NoError(t, err)
+
+	defer os.Remove(t.Name() + ".db")
+	defer activerecord.RemoveConnection("primary")
+
+	ctx := context.TODO()
+
+	err = migration.New(func(m *migration.Migration) {
+		m.CreateTable("users", func(t *migration.Table) {
+			t.String("name")
+			t.String("email")
+			t.Int64("born")
+			t.DateTime("deleted_at")
+		})
+	}).Exec(ctx, conn)
+	require.NoError(t, err)
+	require.NoError(t, m.Exec(ctx, conn))
+
+	indexes, err := activerecord.IndexDefinitions(ctx, conn, "users")
+	require.NoError(t, err)
+	require.Equal(t, []activerecord.IndexDefinition{
+		{
+			Name: "index_users_on_lower_email", Table: "users", Unique: true,
+			Columns: []string{"lower(email)"}, Where: "deleted_at IS NULL",
+		},
+		{
+			Name: "index_users_on_name_and_born", Table: "users",
+			Columns: []string{"name", "born"},
+		},
+	}, indexes)
+
+	// Partial unique index ignores deleted rows.
+	User := activerecord.New("user")
+	require.NoError(t, User.Create(Hash{"email": "ada@example.com", "deleted_at": time.Now()}).Err())
+	require.NoError(t, User.Create(Hash{"email": "Ada@example.com"}).Err())
+	require.Error(t, User.Create(Hash{"email": "ADA@example.com"}).Err())
+}
+
+func TestMigration_ForeignKeys(t *testing.T) {
+	m := migration.New(func(m *migration.Migration) {
+		m.CreateTable("targets", func(t *migration.Table) {
+			t.References("owner", migration.ForeignKeyConstraint(
+				migration.OnDelete(migration.Cascade),
+			))
+		})
+		m.AddForeignKey("books", "authors", migration.OnUpdate(migration.Restrict))
+		m.RemoveForeignKey("books", "authors")
+	})
+
+	require.Equal(t, []string{
+		`CREATE TABLE "targets" ("id" BIGSERIAL NOT NULL, "owner_id" BIGINT, PRIMARY KEY ("id"), ` +
+			`CONSTRAINT "fk_targets_on_owners" FOREIGN KEY ("owner_id") REFERENCES "owners" ("id") ON DELETE CASCADE)`,
+		`ALTER TABLE "books" ADD CONSTRAINT "fk_books_on_authors" FOREIGN KEY ("author_id") ` +
+			`REFERENCES "authors" ("id") ON UPDATE RESTRICT`,
+		`ALTER TABLE "books" DROP CONSTRAINT "fk_books_on_authors"`,
+	}, statements(m, new(postgresql.Conn)))
+	require.Equal(t, []string{
+		"CREATE TABLE `targets` (`id` BIGINT AUTO_INCREMENT NOT NULL, `owner_id` BIGINT, PRIMARY KEY (`id`), " +
+			"CONSTRAINT `fk_targets_on_owners` FOREIGN KEY (`owner_id`) REFERENCES `owners` (`id`) ON DELETE CASCADE)",
+		"ALTER TABLE `books` ADD CONSTRAINT `fk_books_on_authors` FOREIGN KEY (`author_id`) " +
+			"REFERENCES `authors` (`id`) ON UPDATE RESTRICT",
+		"ALTER TABLE `books` DROP FOREIGN KEY `fk_books_on_authors`",
+	}, statements(m, new(mysql.Conn)))
+
+	conn, err := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter: "sqlite3", Database: t.Name() + ".db",
+	})
+	require.NoError(t, err)
+
+	defer os.Remove(t.Name() + ".db")
+	defer activerecord.RemoveConnection("primary")
+
+	ctx := context.TODO()
+
+	err = migration.New(func(m *migration.Migration) {
+		m.CreateTable("owners", func(t *migration.Table) {
+			t.String("name")
+		})
+		m.CreateTable("targets", func(t *migration.Table) {
+			t.String("name")
+			t.References("owner", migration.ForeignKeyConstraint(
+				migration.OnDelete(migration.Cascade),
+			))
+		})
+		m.CreateTable("books", func(t *migration.Table) {
+			t.String("title")
+			t.References("owner")
+		})
+		m.AddIndex("books", []string{"title"})
+		// The table is rebuilt by SQLite with the new constraint.
+		m.AddForeignKey("books", "owners", migration.OnDelete(migration.SetNull))
+	}).Exec(ctx, conn)
+	require.NoError(t, err)
+
+	foreignKeys, err := activerecord.ForeignKeyDefinitions(ctx, conn, "books")
+	require.NoError(t, err)
+	require.Equal(t, []activerecord.ForeignKeyDefinition{{
+		Table: "books", Column: "owner_id", Target: "owners", PrimaryKey: "id", OnDelete: "SET NULL",
+	}}, foreignKeys)
+
+	indexes, err := activerecord.IndexDefinitions(ctx, conn, "books")
+	require.NoError(t, err)
+	require.Len(t, indexes, 1)
+
+	Owner := activerecord.New("owner")
+	Target := activerecord.New("target")
+	Book := activerecord.New("book")
+
+	owner := Owner.Create(Hash{"name": "Ada"})
+	require.NoError(t, owner.Err())
+	ownerID := owner.Unwrap().ID()
+
+	require.NoError(t, Target.Create(Hash{"name": "Engine", "owner_id": ownerID}).Err())
+	require.NoError(t, Book.Create(Hash{"title": "Notes", "owner_id": ownerID}).Err())
+	require.Error(t, Target.Create(Hash{"name": "Loom", "owner_id": 42}).Err())
+
+	// Referential actions are taken by the database.
+	_, err = activerecord.Execute(ctx, `DELETE FROM "owners"`)
+	require.NoError(t, err)
+
+	targets, err := Target.All().ToA()
+	require.NoError(t, err)
+	require.Empty(t, targets)
+
+	books, err := Book.Pluck("title", "owner_id")
+	require.NoError(t, err)
+	require.Equal(t, [][]interface{}{{"Notes", nil}}, books)
+
+	err = migration.New(func(m *migration.Migration) {
+		m.RemoveForeignKey("books", "owners")
+	}).Exec(ctx, conn)
+	require.NoError(t, err)
+
+	foreignKeys, err = activerecord.ForeignKeyDefinitions(ctx, conn, "books")
+	require.NoError(t, err)
+	require.Empty(t, foreignKeys)
+	require.NoError(t, Book.Create(Hash{"title": "Letters", "owner_id": 42}).Err())
+}
+
+func TestMigration_CheckConstraints(t *testing.T) {
+	m := migration.New(func(m *migration.Migration) {
+		m.CreateTable("products", func(t *migration.Table) {
+			t.String("status", migration.Default("draft"), migration.NotNull())
+			t.Float64("price", migration.Default(0))
+			t.CheckConstraint("price >= 0", migration.CheckName("price_nonneg"))
+		})
+		m.AddCheckConstraint("products", "status <> 'deleted'", migration.NotValid())
+		m.ValidateConstraint("products", "chk_products_268def38")
+		m.RemoveCheckConstraint("products", "price >= 0", migration.CheckName("price_nonneg"))
+	})
+
+	require.Equal(t, []string{
+		`CREATE TABLE "products" ("id" BIGSERIAL NOT NULL, "status" VARCHAR DEFAULT 'draft' NOT NULL, ` +
+			`"price" DOUBLE PRECISION DEFAULT 0, PRIMARY KEY ("id"), CONSTRAINT "price_nonneg" CHECK (price >= 0))`,
+		`ALTER TABLE "products" ADD CONSTRAINT "chk_products_268def38" CHECK (status <> 'deleted') NOT VALID`,
+		`ALTER TABLE "products" VALIDATE CONSTRAINT "chk_products_268def38"`,
+		`ALTER TABLE "products" DROP CONSTRAINT "price_nonneg"`,
+	}, statements(m, new(postgresql.Conn)))
+	require.Equal(t, []string{
+		"CREATE TABLE `products` (`id` BIGINT AUTO_INCREMENT NOT NULL, `status` VARCHAR(255) DEFAULT 'draft' NOT NULL, " +
+			"`price` DOUBLE DEFAULT 0, PRIMARY KEY (`id`), CONSTRAINT `price_nonneg` CHECK (price >= 0))",
+		"ALTER TABLE `products` ADD CONSTRAINT `chk_products_268def38` CHECK (status <> 'deleted')",
+		"ALTER TABLE `products` DROP CHECK `price_nonneg`",
+	}, statements(m, new(mysql.Conn)))
+
+	conn, err := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter: "sqlite3", Database: t.Name() + ".db",
+	})
+	require.NoError(t, err)
+
+	defer os.Remove(t.Name() + ".db")
+	defer activerecord.RemoveConnection("primary")
+
+	ctx := context.TODO()
+	require.NoError(t, m.Exec(ctx, conn))
+
+	// Constraints and defaults are kept, when the table is rebuilt.
+	checks, err := activerecord.CheckConstraintDefinitions(ctx, conn, "products")
+	require.NoError(t, err)
+	require.Equal(t, []activerecord.CheckConstraintDefinition{{
+		Name: "chk_products_268def38", Table: "products", Expression: "status <> 'deleted'",
+	}}, checks)
+
+	columns, err := conn.ColumnDefinitions(ctx, "products")
+	require.NoError(t, err)
+	require.Equal(t, "'draft'", columns[1].Default)
+
+	Product := activerecord.New("product")
+	product := Product.Create(Hash{"price": -1.5})
+	require.NoError(t, product.Err())
+
+	products, err := Product.Pluck("status", "price")
+	require.NoError(t, err)
+	require.Equal(t, [][]interface{}{{"draft", -1.5}}, products)
+
+	require.Error(t, Product.Create(Hash{"status": "deleted"}).Err())
+
+	// Existing rows are validated, when the table is rebuilt.
+	err = migration.New(func(m *migration.Migration) {
+		m.AddCheckConstraint("products", "price >= 0")
+	}).Exec(ctx, conn)
+	require.Error(t, err)
+}
+
+func TestMigration_TableOptions(t *testing.T) {
+	m := migration.New(func(m *migration.Migration) {
+		m.CreateTable("authors", func(t *migration.Table) {
+			t.Comment("Authors of books")
+			t.Engine("InnoDB")
+			t.Charset("utf8mb4")
+			t.Tablespace("archive")
+			t.String("name", migration.Comment("Author's full name"))
+		})
+		m.AddColumn("authors", "born", new(activerecord.Int64), migration.Comment("Year of birth"))
+	})
+
+	require.Equal(t, []string{
+		`CREATE TABLE "authors" ("id" BIGSERIAL NOT NULL, "name" VARCHAR, PRIMARY KEY ("id")) TABLESPACE "archive"`,
+		`COMMENT ON TABLE "authors" IS 'Authors of books'`,
+		`COMMENT ON COLUMN "authors"."name" IS 'Author''s full name'`,
+		`ALTER TABLE "authors" ADD COLUMN "born" BIGINT`,
+		`COMMENT ON COLUMN "authors"."born" IS 'Year of birth'`,
+	}, statements(m, new(postgresql.Conn)))
+	require.Equal(t, []string{
+		"CREATE TABLE `authors` (`id` BIGINT AUTO_INCREMENT NOT NULL, `name` VARCHAR(255) COMMENT 'Author''s full name', " +
+			"PRIMARY KEY (`id`)) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 TABLESPACE `archive` COMMENT='Authors of books'",
+		"ALTER TABLE `authors` ADD COLUMN `born` BIGINT COMMENT 'Year of birth'",
+	}, statements(m, new(mysql.Conn)))
+
+	conn, err := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter: "sqlite3", Database: t.Name() + ".db",
+	})
+	require.NoError(t, err)
+
+	defer os.Remove(t.Name() + ".db")
+	defer activerecord.RemoveConnection("primary")
+
+	// SQLite does not store comments and tablespaces.
+	require.NoError(t, m.Exec(context.TODO(), conn))
+
+	Author := activerecord.New("author")
+	comment, err := Author.TableComment()
+	require.NoError(t, err)
+	require.Empty(t, comment)
+	require.Empty(t, Author.AttributeComment("name"))
+
+	// Inverse of the dropped table keeps options.
+	inverse, err := migration.New(func(m *migration.Migration) {
+		m.DropTable("authors", func(t *migration.Table) {
+			t.Comment("Authors of books")
+		})
+	}).Inverse()
+	require.NoError(t, err)
+	require.Equal(t, "Authors of books", inverse.Operations()[0].(*migration.CreateTable).Options.Comment)
+}
+
+func TestMigration_Views(t *testing.T) {
+	m := migration.New(func(m *migration.Migration) {
+		m.CreateView("sales_reports", "SELECT product_id, SUM(price) AS total FROM sales GROUP BY product_id",
+			migration.Materialized(),
+		)
+		m.RefreshMaterializedView("sales_reports", true)
+		m.DropView("sales_reports", nil, migration.Materialized())
+	})
+	require.Equal(t, []string{
+		`CREATE MATERIALIZED VIEW "sales_reports" AS SELECT product_id, SUM(price) AS total FROM sales GROUP BY product_id`,
+		`REFRESH MATERIALIZED VIEW CONCURRENTLY "sales_reports"`,
+		`DROP MATERIALIZED VIEW "sales_reports"`,
+	}, statements(m, new(postgresql.Conn)))
+
+	_, err := m.Inverse()
+	require.ErrorIs(t, err, new(migration.ErrIrreversibleMigration))
+
+	conn, err := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter: "sqlite3", Database: t.Name() + ".db",
+	})
+	require.NoError(t, err)
+
+	defer os.Remove(t.Name() + ".db")
+	defer activerecord.RemoveConnection("primary")
+
+	ctx := context.TODO()
+	err = migration.New(func(m *migration.Migration) {
+		m.CreateTable("users", func(t *migration.Table) {
+			t.String("name")
+			t.Int64("age")
+		})
+	}).Exec(ctx, conn)
+	require.NoError(t, err)
+
+	User := activerecord.New("user")
+	_, err = User.InsertAll(Hash{"name": "Bill", "age": int64(42)}, Hash{"name": "Kid", "age": int64(7)})
+	require.NoError(t, err)
+
+	views := migration.New(func(m *migration.Migration) {
+		m.CreateView("adults", User.Where("age >= ?", 18).Where("name <> '?'", nil))
+	})
+	require.Equal(t, []string{
+		`CREATE VIEW "adults" AS SELECT * FROM "users" WHERE (age >= 18) AND (name <> '?')`,
+	}, statements(views, activerecord.DialectOf(conn)))
+	require.NoError(t, views.Exec(ctx, conn))
+
+	Adult := activerecord.New("adult", func(r *activerecord.R) {
+		r.ReadOnly()
+	})
+	names, err := Adult.Pluck("name")
+	require.NoError(t, err)
+	require.Equal(t, [][]interface{}{{"Bill"}}, names)
+
+	adult := Adult.First()
+	require.NoError(t, adult.Err())
+	require.True(t, adult.Unwrap().IsReadOnly())
+
+	_, err = adult.Unwrap().Delete()
+	require.ErrorIs(t, err, new(activerecord.ErrReadOnlyRecord))
+	require.ErrorIs(t, Adult.Create(Hash{"name": "Ann"}).Err(), new(activerecord.ErrReadOnlyRecord))
+
+	// SQLite does not have materialized views.
+	require.ErrorIs(t, m.Exec(ctx, conn), new(activerecord.ErrNotSupported))
+	require.ErrorIs(t, Adult.RefreshMaterializedView(false), new(activerecord.ErrNotSupported))
+
+	inverse, err := views.Inverse()
+	require.NoError(t, err)
+	require.NoError(t, inverse.Exec(ctx, conn))
+
+	_, err = conn.ColumnDefinitions(ctx, "adults")
+	require.ErrorIs(t, err, activerecord.ErrTableNotExist{TableName: "adults"})
+}
+
+func TestMigration_Exec(t *testing.T) {
+	conn, err := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter: "sqlite3", Database: t.Name() + ".db",
+	})
+	require.NoError(t, err)
+
+	defer os.Remove(t.Name() + ".db")
+	defer activerecord.RemoveConnection("primary")
+
+	ctx := context.TODO()
+
+	err = migration.New(func(m *migration.Migration) {
+		m.CreateTable("books", func(t *migration.Table) {
+			t.String("title", migration.NotNull())
+			t.String("isbn")
+		})
+		m.AddColumn("books", "year", new(activerecord.Int64))
+		m.AddIndex("books", []string{"isbn"}, migration.Unique())
+	}).Exec(ctx, conn)
+	require.NoError(t, err)
+
+	Book := activerecord.New("book")
+	book := Book.Create(Hash{"title": "Solaris", "isbn": "0-15-683750-7", "year": 1961})
+	require.NoError(t, book.Err())
+
+	book = Book.Create(Hash{"title": "Solaris", "isbn": "0-15-683750-7"})
+	require.True(t, errors.Is(book.Err(), new(activerecord.ErrRecordNotUnique)), book.Err())
+
+	err = migration.New(func(m *migration.Migration) {
+		m.RemoveColumn("books", "isbn")
+		m.RenameTable("books", "novels")
+	}).Exec(ctx, conn)
+	require.NoError(t, err)
+
+	columns, err := conn.ColumnDefinitions(ctx, "novels")
+	require.NoError(t, err)
+
+	names := make([]string, len(columns))
+	for i, column := range columns {
+		names[i] = column.Name
+	}
+	require.Equal(t, []string{"id", "title", "year"}, names)
+
+	Novel := activerecord.New("novel")
+	novels, err := Novel.Pluck("title", "year")
+	require.NoError(t, err)
+	require.Equal(t, [][]interface{}{{"Solaris", int64(1961)}}, novels)
+
+	// Rebuild of the table fails for unknown columns.
+	err = migration.New(func(m *migration.Migration) {
+		m.RemoveColumn("novels", "isbn")
+	}).Exec(ctx, conn)
+	require.Error(t, err)
+}
+
+func TestMigration_Mock(t *testing.T) {
+	m := mock.New(t.Name())
+	m.ExpectExec(`CREATE TABLE "authors" ("id" INTEGER NOT NULL, "name" VARCHAR, PRIMARY KEY ("id"))`)
+	m.ExpectExec(`CREATE INDEX "authors_name" ON "authors" ("name")`).
+		ReturnError(errors.New("index exists"))
+
+	conn, err := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter: "mock", Database: t.Name(),
+	})
+	require.NoError(t, err)
+	defer activerecord.RemoveConnection("primary")
+
+	err = migration.New(func(m *migration.Migration) {
+		m.CreateTable("authors", func(t *migration.Table) {
+			t.String("name")
+		})
+		m.AddIndex("authors", []string{"name"}, migration.IndexName("authors_name"))
+		m.RenameTable("authors", "writers")
+	}).Exec(context.TODO(), conn)
+	require.EqualError(t, err, "index exists")
+	require.NoError(t, m.ExpectationsWereMet())
+}
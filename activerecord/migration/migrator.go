@@ -0,0 +1,472 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/activegraph/activegraph/activerecord"
+	. "github.com/activegraph/activegraph/activesupport"
+)
+
+// Definition is the migration registered with the version.
+type Definition struct {
+	// Version identifies the migration, migrations are applied in the order
+	// of versions, e.g. "20230115093000_create_authors".
+	Version string
+	// Change defines operations, which are applied by the migration and
+	// inverted, when the migration is reverted. ErrIrreversibleMigration is
+	// returned on revert, when some of operations could not be inverted.
+	// When Change is defined, Up and Down are ignored.
+	Change func(m *Migration)
+	Up     func(m *Migration)
+	// Down reverts the migration, when it is nil, operations of the Up function
+	// are inverted (see Migration.Inverse).
+	Down func(m *Migration)
+}
+
+// up returns the migration applied by the definition.
+func (def Definition) up() *Migration {
+	if def.Change != nil {
+		return New(def.Change)
+	}
+	return New(def.Up)
+}
+
+// down returns the migration, which reverts the definition.
+func (def Definition) down() (*Migration, error) {
+	if def.Change == nil && def.Down != nil {
+		return New(def.Down), nil
+	}
+	return def.up().Inverse()
+}
+
+var (
+	registryMu sync.Mutex
+	registry   = make(map[string]Definition)
+)
+
+// Register registers the migration with the version, registered migrations are
+// applied by the Migrator. Register panics, when the version is registered twice:
+//
+//	func init() {
+//		migration.Register("20230115093000_create_authors", func(m *migration.Migration) {
+//			m.CreateTable("authors", func(t *migration.Table) {
+//				t.String("name")
+//			})
+//		})
+//	}
+//
+// The optional down function reverts the migration, otherwise operations of the
+// migration are inverted on rollback.
+func Register(version string, up func(m *Migration), down ...func(m *Migration)) {
+	def := Definition{Version: version, Up: up}
+	switch len(down) {
+	case 0:
+	case 1:
+		def.Down = down[0]
+	default:
+		panic(ErrMultipleVariadicArguments{Name: "down"})
+	}
+	register(def)
+}
+
+// RegisterChange registers the reversible migration with the version, the
+// migration is reverted by the inversion of its operations:
+//
+//	func init() {
+//		migration.RegisterChange("20230116093000_add_born_to_authors", func(m *migration.Migration) {
+//			m.AddColumn("authors", "born", new(activerecord.Int64))
+//		})
+//	}
+func RegisterChange(version string, change func(m *Migration)) {
+	register(Definition{Version: version, Change: change})
+}
+
+func register(def Definition) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, dup := registry[def.Version]; dup {
+		panic(fmt.Sprintf("migration: version %q is already registered", def.Version))
+	}
+	registry[def.Version] = def
+}
+
+// Registered returns registered migrations sorted by versions.
+func Registered() []Definition {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	defs := make([]Definition, 0, len(registry))
+	for _, def := range registry {
+		defs = append(defs, def)
+	}
+	return sortDefinitions(defs)
+}
+
+func sortDefinitions(defs []Definition) []Definition {
+	sort.Slice(defs, func(i, j int) bool { return defs[i].Version < defs[j].Version })
+	return defs
+}
+
+// Status is the status of the migration.
+type Status struct {
+	Version string
+	// Applied is true when the version is recorded in the schema migrations.
+	Applied bool
+	// Defined is false for applied versions, which are not known to the migrator.
+	Defined bool
+}
+
+// ErrMigration is returned when the migration fails.
+type ErrMigration struct {
+	Version string
+	Err     error
+}
+
+func (e *ErrMigration) Is(target error) bool {
+	_, ok := target.(*ErrMigration)
+	return ok
+}
+
+func (e *ErrMigration) Error() string {
+	return fmt.Sprintf("migration %s failed: %s", e.Version, e.Err)
+}
+
+func (e *ErrMigration) Unwrap() error {
+	return e.Err
+}
+
+// ErrUnknownVersion is returned when the version is not defined in the migrator.
+type ErrUnknownVersion struct {
+	Version string
+}
+
+func (e *ErrUnknownVersion) Is(target error) bool {
+	_, ok := target.(*ErrUnknownVersion)
+	return ok
+}
+
+func (e *ErrUnknownVersion) Error() string {
+	return fmt.Sprintf("migration: version %q is not defined", e.Version)
+}
+
+// ErrPendingMigrations is returned, when migrations expected by the application
+// are not applied to the database.
+type ErrPendingMigrations struct {
+	Versions []string
+}
+
+func (e *ErrPendingMigrations) Is(target error) bool {
+	_, ok := target.(*ErrPendingMigrations)
+	return ok
+}
+
+func (e *ErrPendingMigrations) Error() string {
+	return fmt.Sprintf("migration: %d pending migrations: %s",
+		len(e.Versions), strings.Join(e.Versions, ", "),
+	)
+}
+
+// Migrator applies migrations to the database and records applied versions in
+// the "schema_migrations" table.
+//
+// Each migration is executed within a transaction together with the record of
+// its version, when the database supports transactional schema statements
+// (see activerecord.Capabilities), and the migration does not create indexes
+// concurrently. Otherwise the failed migration could leave the schema partially
+// changed.
+type Migrator struct {
+	conn activerecord.Conn
+	defs []Definition
+}
+
+// NewMigrator returns a new migrator of the connection. When definitions are
+// omitted, registered migrations are used.
+func NewMigrator(conn activerecord.Conn, defs ...Definition) *Migrator {
+	if len(defs) == 0 {
+		defs = Registered()
+	} else {
+		defs = sortDefinitions(append([]Definition(nil), defs...))
+	}
+	return &Migrator{conn: conn, defs: defs}
+}
+
+// Migrate applies pending migrations in the order of versions.
+func (m *Migrator) Migrate(ctx context.Context) error {
+	applied, err := m.appliedVersions(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, def := range m.defs {
+		if _, ok := applied[def.Version]; ok {
+			continue
+		}
+		if err := m.apply(ctx, def); err != nil {
+			return &ErrMigration{Version: def.Version, Err: err}
+		}
+	}
+	return nil
+}
+
+// Rollback reverts the given number of the last applied migrations in the reverse
+// order of versions.
+func (m *Migrator) Rollback(ctx context.Context, steps int) error {
+	applied, err := m.appliedVersions(ctx)
+	if err != nil {
+		return err
+	}
+
+	versions := sortedVersions(applied)
+	for i := len(versions) - 1; i >= 0 && steps > 0; i, steps = i-1, steps-1 {
+		if err := m.revert(ctx, versions[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Redo reverts the given number of the last applied migrations and applies
+// pending migrations again.
+func (m *Migrator) Redo(ctx context.Context, steps int) error {
+	if err := m.Rollback(ctx, steps); err != nil {
+		return err
+	}
+	return m.Migrate(ctx)
+}
+
+// MigrateTo applies pending migrations up to the version inclusively and reverts
+// applied migrations after the version. The empty version reverts all migrations.
+func (m *Migrator) MigrateTo(ctx context.Context, version string) error {
+	if _, ok := m.definition(version); !ok && version != "" {
+		return &ErrUnknownVersion{Version: version}
+	}
+
+	applied, err := m.appliedVersions(ctx)
+	if err != nil {
+		return err
+	}
+
+	versions := sortedVersions(applied)
+	for i := len(versions) - 1; i >= 0 && versions[i] > version; i-- {
+		if err := m.revert(ctx, versions[i]); err != nil {
+			return err
+		}
+	}
+
+	for _, def := range m.defs {
+		if _, ok := applied[def.Version]; ok || def.Version > version {
+			continue
+		}
+		if err := m.apply(ctx, def); err != nil {
+			return &ErrMigration{Version: def.Version, Err: err}
+		}
+	}
+	return nil
+}
+
+func sortedVersions(versions map[string]struct{}) []string {
+	sorted := make([]string, 0, len(versions))
+	for version := range versions {
+		sorted = append(sorted, version)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+func (m *Migrator) definition(version string) (Definition, bool) {
+	for _, def := range m.defs {
+		if def.Version == version {
+			return def, true
+		}
+	}
+	return Definition{}, false
+}
+
+// Status returns statuses of defined and applied migrations sorted by versions.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.appliedVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.defs))
+	for _, def := range m.defs {
+		_, ok := applied[def.Version]
+		statuses = append(statuses, Status{Version: def.Version, Applied: ok, Defined: true})
+		delete(applied, def.Version)
+	}
+	for version := range applied {
+		statuses = append(statuses, Status{Version: version, Applied: true})
+	}
+
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, nil
+}
+
+// PendingMigrations returns defined migrations, which are not applied to the
+// database, sorted by versions.
+func (m *Migrator) PendingMigrations(ctx context.Context) ([]Definition, error) {
+	applied, err := m.appliedVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Definition
+	for _, def := range m.defs {
+		if _, ok := applied[def.Version]; !ok {
+			pending = append(pending, def)
+		}
+	}
+	return pending, nil
+}
+
+// CheckPending returns ErrPendingMigrations, when some of defined migrations
+// are not applied to the database.
+func (m *Migrator) CheckPending(ctx context.Context) error {
+	pending, err := m.PendingMigrations(ctx)
+	if err != nil {
+		return err
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	versions := make([]string, len(pending))
+	for i := range pending {
+		versions[i] = pending[i].Version
+	}
+	return &ErrPendingMigrations{Versions: versions}
+}
+
+func (m *Migrator) apply(ctx context.Context, def Definition) error {
+	up := def.up()
+	return m.transaction(ctx, up.transactional(), func(conn activerecord.Conn) error {
+		if err := up.Exec(ctx, conn); err != nil {
+			return err
+		}
+		return m.recordVersion(ctx, conn, def.Version)
+	})
+}
+
+func (m *Migrator) revert(ctx context.Context, version string) error {
+	def, ok := m.definition(version)
+	if !ok {
+		return &ErrUnknownVersion{Version: version}
+	}
+
+	down, err := def.down()
+	if err != nil {
+		return &ErrMigration{Version: version, Err: err}
+	}
+
+	err = m.transaction(ctx, down.transactional(), func(conn activerecord.Conn) error {
+		if err := down.Exec(ctx, conn); err != nil {
+			return err
+		}
+		return m.deleteVersion(ctx, conn, version)
+	})
+	if err != nil {
+		return &ErrMigration{Version: version, Err: err}
+	}
+	return nil
+}
+
+// transaction executes the function within a transaction, when the database
+// supports transactional schema statements.
+func (m *Migrator) transaction(
+	ctx context.Context, transactional bool, fn func(activerecord.Conn) error,
+) error {
+	conn := m.conn
+	if transactional && activerecord.CapabilitiesOf(m.conn).TransactionalDDL {
+		tx, err := m.conn.BeginTransaction(ctx, nil)
+		if err != nil {
+			return err
+		}
+		// Return the connection back to the pool.
+		defer tx.Close()
+		conn = tx
+	}
+
+	err := fn(conn)
+	if conn == m.conn {
+		return err
+	}
+	if err != nil {
+		if e := conn.RollbackTransaction(ctx); e != nil {
+			err = fmt.Errorf("%s: %w", e.Error(), err)
+		}
+		return err
+	}
+	return conn.CommitTransaction(ctx)
+}
+
+func (m *Migrator) recordVersion(ctx context.Context, conn activerecord.Conn, version string) error {
+	d := activerecord.DialectOf(conn)
+	stmt := fmt.Sprintf("INSERT INTO %s (%s, %s) VALUES (?, ?)",
+		d.QuoteIdentifier(activerecord.SchemaMigrationsName),
+		d.QuoteIdentifier("version"), d.QuoteIdentifier("created_at"),
+	)
+	_, err := conn.ExecStatement(ctx, &activerecord.QueryOperation{
+		Text: stmt, Args: []interface{}{version, time.Now().UTC()},
+	})
+	return err
+}
+
+func (m *Migrator) deleteVersion(ctx context.Context, conn activerecord.Conn, version string) error {
+	d := activerecord.DialectOf(conn)
+	stmt := fmt.Sprintf("DELETE FROM %s WHERE %s = ?",
+		d.QuoteIdentifier(activerecord.SchemaMigrationsName), d.QuoteIdentifier("version"),
+	)
+	_, err := conn.ExecStatement(ctx, &activerecord.QueryOperation{
+		Text: stmt, Args: []interface{}{version},
+	})
+	return err
+}
+
+// appliedVersions returns versions recorded in the schema migrations table, the
+// table is created, when it does not exist.
+func (m *Migrator) appliedVersions(ctx context.Context) (map[string]struct{}, error) {
+	_, err := m.conn.ColumnDefinitions(ctx, activerecord.SchemaMigrationsName)
+	if errors.Is(err, activerecord.ErrTableNotExist{TableName: activerecord.SchemaMigrationsName}) {
+		err = New(func(m *Migration) {
+			m.CreateTable(activerecord.SchemaMigrationsName, func(t *Table) {
+				t.PrimaryKey("version")
+				t.String("version")
+				t.DateTime("created_at")
+			})
+		}).Exec(ctx, m.conn)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	d := activerecord.DialectOf(m.conn)
+	op := activerecord.QueryOperation{
+		Text: fmt.Sprintf("SELECT %s FROM %s",
+			d.QuoteIdentifier("version"), d.QuoteIdentifier(activerecord.SchemaMigrationsName),
+		),
+		Columns: []string{"version"},
+	}
+
+	versions := make(map[string]struct{})
+	err = m.conn.ExecQuery(ctx, &op, func(row Hash) bool {
+		switch version := row["version"].(type) {
+		case []byte:
+			versions[string(version)] = struct{}{}
+		default:
+			versions[fmt.Sprint(version)] = struct{}{}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
@@ -0,0 +1,317 @@
+package migration_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/activegraph/activegraph/activerecord"
+	"github.com/activegraph/activegraph/activerecord/migration"
+	"github.com/activegraph/activegraph/activerecord/mock"
+	. "github.com/activegraph/activegraph/activesupport"
+)
+
+func TestMigrator_Migrate(t *testing.T) {
+	conn, err := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter: "sqlite3", Database: t.Name() + ".db",
+	})
+	require.NoError(t, err)
+
+	defer os.Remove(t.Name() + ".db")
+	defer activerecord.RemoveConnection("primary")
+
+	ctx := context.TODO()
+	defs := []migration.Definition{
+		{Version: "001_create_authors", Up: func(m *migration.Migration) {
+			m.CreateTable("authors", func(t *migration.Table) {
+				t.String("name")
+			})
+		}},
+		{Version: "002_add_born_to_authors", Up: func(m *migration.Migration) {
+			m.AddColumn("authors", "born", new(activerecord.Int64))
+		}},
+	}
+
+	migrator := migration.NewMigrator(conn, defs...)
+	statuses, err := migrator.Status(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []migration.Status{
+		{Version: "001_create_authors", Defined: true},
+		{Version: "002_add_born_to_authors", Defined: true},
+	}, statuses)
+
+	require.NoError(t, migrator.Migrate(ctx))
+	// Applied migrations are skipped.
+	require.NoError(t, migrator.Migrate(ctx))
+
+	Author := activerecord.New("author")
+	require.NoError(t, Author.Create(Hash{"name": "Stanislaw Lem", "born": 1921}).Err())
+
+	// Failed migration is rolled back together with the version.
+	migrator = migration.NewMigrator(conn, append(defs[1:], migration.Definition{
+		Version: "003_create_books", Up: func(m *migration.Migration) {
+			m.CreateTable("books", func(t *migration.Table) {
+				t.String("title")
+			})
+			m.AddColumn("publishers", "name", new(activerecord.String))
+		},
+	})...)
+
+	err = migrator.Migrate(ctx)
+	var errMigration *migration.ErrMigration
+	require.True(t, errors.As(err, &errMigration), err)
+	require.Equal(t, "003_create_books", errMigration.Version)
+
+	_, err = conn.ColumnDefinitions(ctx, "books")
+	require.ErrorIs(t, err, activerecord.ErrTableNotExist{TableName: "books"})
+
+	statuses, err = migrator.Status(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []migration.Status{
+		{Version: "001_create_authors", Applied: true},
+		{Version: "002_add_born_to_authors", Applied: true, Defined: true},
+		{Version: "003_create_books", Defined: true},
+	}, statuses)
+}
+
+func TestMigrator_Rollback(t *testing.T) {
+	conn, err := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter: "sqlite3", Database: t.Name() + ".db",
+	})
+	require.NoError(t, err)
+
+	defer os.Remove(t.Name() + ".db")
+	defer activerecord.RemoveConnection("primary")
+
+	ctx := context.TODO()
+	migrator := migration.NewMigrator(conn,
+		migration.Definition{Version: "001_create_authors", Up: func(m *migration.Migration) {
+			m.CreateTable("authors", func(t *migration.Table) {
+				t.String("name")
+			})
+		}},
+		migration.Definition{Version: "002_add_born_to_authors", Up: func(m *migration.Migration) {
+			m.AddColumn("authors", "born", new(activerecord.Int64))
+		}},
+		migration.Definition{
+			Version: "003_add_index_to_authors",
+			Up: func(m *migration.Migration) {
+				m.AddIndex("authors", []string{"name"}, migration.Unique())
+			},
+			Down: func(m *migration.Migration) {
+				m.RemoveIndex("authors", []string{"name"})
+			},
+		},
+	)
+
+	applied := func() (versions []string) {
+		statuses, err := migrator.Status(ctx)
+		require.NoError(t, err)
+		for _, status := range statuses {
+			if status.Applied {
+				versions = append(versions, status.Version)
+			}
+		}
+		return versions
+	}
+	columns := func() (names []string) {
+		definitions, err := conn.ColumnDefinitions(ctx, "authors")
+		require.NoError(t, err)
+		for _, column := range definitions {
+			names = append(names, column.Name)
+		}
+		return names
+	}
+
+	require.NoError(t, migrator.Migrate(ctx))
+	require.Equal(t, []string{
+		"001_create_authors", "002_add_born_to_authors", "003_add_index_to_authors",
+	}, applied())
+
+	Author := activerecord.New("author")
+	require.NoError(t, Author.Create(Hash{"name": "Stanislaw Lem", "born": 1921}).Err())
+	require.Error(t, Author.Create(Hash{"name": "Stanislaw Lem"}).Err())
+
+	// The last migration is reverted with the down function.
+	require.NoError(t, migrator.Rollback(ctx, 1))
+	require.Equal(t, []string{"001_create_authors", "002_add_born_to_authors"}, applied())
+	require.NoError(t, Author.Create(Hash{"name": "Stanislaw Lem"}).Err())
+	_, err = activerecord.Execute(ctx, `DELETE FROM "authors" WHERE "born" IS NULL`)
+	require.NoError(t, err)
+
+	require.NoError(t, migrator.Redo(ctx, 1))
+	require.Len(t, applied(), 3)
+	require.Error(t, Author.Create(Hash{"name": "Stanislaw Lem"}).Err())
+
+	// Operations of migrations without down functions are inverted.
+	require.NoError(t, migrator.MigrateTo(ctx, "001_create_authors"))
+	require.Equal(t, []string{"001_create_authors"}, applied())
+	require.Equal(t, []string{"id", "name"}, columns())
+
+	require.NoError(t, migrator.MigrateTo(ctx, "002_add_born_to_authors"))
+	require.Equal(t, []string{"id", "name", "born"}, columns())
+
+	require.NoError(t, migrator.MigrateTo(ctx, ""))
+	require.Empty(t, applied())
+	_, err = conn.ColumnDefinitions(ctx, "authors")
+	require.ErrorIs(t, err, activerecord.ErrTableNotExist{TableName: "authors"})
+
+	err = migrator.MigrateTo(ctx, "004_unknown")
+	require.ErrorIs(t, err, &migration.ErrUnknownVersion{Version: "004_unknown"})
+
+	// Irreversible migrations are not reverted.
+	migrator = migration.NewMigrator(conn,
+		migration.Definition{Version: "001_create_authors", Up: func(m *migration.Migration) {
+			m.CreateTable("authors", func(t *migration.Table) {
+				t.String("name")
+				t.Int64("born")
+			})
+		}},
+		migration.Definition{Version: "002_remove_born_from_authors", Up: func(m *migration.Migration) {
+			m.RemoveColumn("authors", "born")
+		}},
+	)
+	require.NoError(t, migrator.Migrate(ctx))
+
+	err = migrator.Rollback(ctx, 1)
+	var errIrreversible *migration.ErrIrreversibleMigration
+	require.True(t, errors.As(err, &errIrreversible), err)
+	require.Len(t, applied(), 2)
+}
+
+func TestMigrator_NonTransactional(t *testing.T) {
+	m := mock.New(t.Name())
+	m.Capabilities.TransactionalDDL = false
+	m.DefineTable("schema_migrations",
+		activerecord.ColumnDefinition{Name: "version", Type: new(activerecord.String), IsPrimaryKey: true},
+	)
+
+	m.ExpectQuery(`SELECT "version" FROM "schema_migrations"`).
+		Return(Hash{"version": "001_create_authors"})
+	m.ExpectExec(`ALTER TABLE "authors" ADD COLUMN "born" INTEGER`)
+	m.ExpectExec(`INSERT INTO "schema_migrations" ("version", "created_at") VALUES (?, ?)`)
+
+	conn, err := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter: "mock", Database: t.Name(),
+	})
+	require.NoError(t, err)
+	defer activerecord.RemoveConnection("primary")
+
+	migrator := migration.NewMigrator(conn,
+		migration.Definition{Version: "001_create_authors", Up: func(m *migration.Migration) {
+			m.CreateTable("authors", func(t *migration.Table) {})
+		}},
+		migration.Definition{Version: "002_add_born_to_authors", Up: func(m *migration.Migration) {
+			m.AddColumn("authors", "born", new(activerecord.Int64))
+		}},
+	)
+	require.NoError(t, migrator.Migrate(context.TODO()))
+	require.NoError(t, m.ExpectationsWereMet())
+}
+
+func TestMigrator_Change(t *testing.T) {
+	conn, err := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter: "sqlite3", Database: t.Name() + ".db",
+	})
+	require.NoError(t, err)
+
+	defer os.Remove(t.Name() + ".db")
+	defer activerecord.RemoveConnection("primary")
+
+	ctx := context.TODO()
+	migrator := migration.NewMigrator(conn,
+		migration.Definition{Version: "001_create_authors", Change: func(m *migration.Migration) {
+			m.CreateTable("authors", func(t *migration.Table) {
+				t.String("name")
+				t.Int64("born")
+			})
+		}},
+		migration.Definition{Version: "002_remove_born_from_authors", Change: func(m *migration.Migration) {
+			m.RemoveColumn("authors", "born", new(activerecord.Int64))
+			m.Reversible(func(m *migration.Migration) {
+				m.Execute(`CREATE VIEW "writers" AS SELECT "name" FROM "authors"`)
+			}, func(m *migration.Migration) {
+				m.Execute(`DROP VIEW "writers"`)
+			})
+		}},
+	)
+	require.NoError(t, migrator.Migrate(ctx))
+
+	_, err = activerecord.Execute(ctx, `SELECT "name" FROM "writers"`)
+	require.NoError(t, err)
+
+	require.NoError(t, migrator.Rollback(ctx, 1))
+	_, err = activerecord.Execute(ctx, `SELECT "name" FROM "writers"`)
+	require.Error(t, err)
+
+	columns, err := conn.ColumnDefinitions(ctx, "authors")
+	require.NoError(t, err)
+	require.Len(t, columns, 3)
+	require.Equal(t, "born", columns[2].Name)
+
+	// Statements are not inverted automatically.
+	migrator = migration.NewMigrator(conn,
+		migration.Definition{Version: "001_create_authors", Change: func(m *migration.Migration) {
+			m.Execute(`CREATE TABLE "authors" ("id" INTEGER PRIMARY KEY)`)
+		}},
+	)
+	err = migrator.Rollback(ctx, 1)
+
+	var errIrreversible *migration.ErrIrreversibleMigration
+	require.True(t, errors.As(err, &errIrreversible), err)
+	require.IsType(t, new(migration.Execute), errIrreversible.Operation)
+}
+
+func TestMigrator_PendingMigrations(t *testing.T) {
+	conn, err := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter: "sqlite3", Database: t.Name() + ".db",
+	})
+	require.NoError(t, err)
+
+	defer os.Remove(t.Name() + ".db")
+	defer activerecord.RemoveConnection("primary")
+
+	ctx := context.TODO()
+	defs := []migration.Definition{
+		{Version: "001_create_authors", Change: func(m *migration.Migration) {
+			m.CreateTable("authors", func(t *migration.Table) {
+				t.String("name")
+			})
+		}},
+		{Version: "002_add_born_to_authors", Change: func(m *migration.Migration) {
+			m.AddColumn("authors", "born", new(activerecord.Int64))
+		}},
+	}
+
+	migrator := migration.NewMigrator(conn, defs...)
+	require.NoError(t, migrator.MigrateTo(ctx, "001_create_authors"))
+
+	pending, err := migrator.PendingMigrations(ctx)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	require.Equal(t, "002_add_born_to_authors", pending[0].Version)
+
+	err = migrator.CheckPending(ctx)
+	require.ErrorIs(t, err, new(migration.ErrPendingMigrations))
+	require.EqualError(t, err, "migration: 1 pending migrations: 002_add_born_to_authors")
+
+	require.NoError(t, migrator.Migrate(ctx))
+	require.NoError(t, migrator.CheckPending(ctx))
+
+	// Registered migrations are checked at boot.
+	migration.RegisterChange("20230101000000_create_publishers", func(m *migration.Migration) {
+		m.CreateTable("publishers", func(t *migration.Table) {
+			t.String("name")
+		})
+	})
+	require.ErrorIs(t, migration.CheckPending(ctx, conn), new(migration.ErrPendingMigrations))
+
+	var warning strings.Builder
+	require.NoError(t, migration.CheckPending(ctx, conn, migration.Warn(&warning)))
+	require.Equal(t, "WARNING: migration: 1 pending migrations: 20230101000000_create_publishers\n",
+		warning.String())
+}
@@ -0,0 +1,1119 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"time"
+
+	"github.com/activegraph/activegraph/activerecord"
+)
+
+// Compiler is implemented by dialects of databases, which statements of some
+// operations differ from statements compiled with the SchemaDialect. Compile
+// returns false for operations, which are compiled by the operation itself.
+// Connections implement Compiler together with activerecord.SchemaDialect.
+type Compiler interface {
+	Compile(op Operation) (stmts []string, ok bool)
+}
+
+// ReversibleOperation is the operation, which is reverted by the inverse
+// operation, e.g. CreateTable is reverted by DropTable.
+type ReversibleOperation interface {
+	Operation
+	Inverse() (Operation, error)
+}
+
+// ErrIrreversibleMigration is returned when the reverted migration without the
+// down function contains the operation, which could not be inverted.
+type ErrIrreversibleMigration struct {
+	Operation Operation
+}
+
+func (e *ErrIrreversibleMigration) Is(target error) bool {
+	_, ok := target.(*ErrIrreversibleMigration)
+	return ok
+}
+
+func (e *ErrIrreversibleMigration) Error() string {
+	return fmt.Sprintf("migration: %T operation is irreversible", e.Operation)
+}
+
+// InlineCommentsDialect is implemented by dialects of databases, which define
+// comments of tables and columns within their definitions instead of separate
+// "COMMENT ON" statements, e.g. MySQL. Engines and charsets of tables are
+// defined only by such dialects.
+type InlineCommentsDialect interface {
+	InlineComments() bool
+}
+
+func inlineComments(d activerecord.SchemaDialect) bool {
+	c, ok := d.(InlineCommentsDialect)
+	return ok && c.InlineComments()
+}
+
+// statementOperation is the operation compiled into statements without
+// inspection of the database schema.
+type statementOperation interface {
+	Operation
+	Statements(d activerecord.SchemaDialect) []string
+}
+
+// Statements returns statements of the operation compiled for the dialect.
+func Statements(d activerecord.SchemaDialect, op Operation) []string {
+	if c, ok := d.(Compiler); ok {
+		if stmts, ok := c.Compile(op); ok {
+			return stmts
+		}
+	}
+	if op, ok := op.(statementOperation); ok {
+		return op.Statements(d)
+	}
+	return nil
+}
+
+func execOperation(ctx context.Context, conn activerecord.Conn, op Operation) error {
+	return execStatements(ctx, conn, Statements(activerecord.DialectOf(conn), op)...)
+}
+
+// execStatements executes schema statements and clears the schema cache of the
+// connection, since the schema is changed.
+func execStatements(ctx context.Context, conn activerecord.Conn, stmts ...string) error {
+	if cache := activerecord.SchemaCacheOf(conn); cache != nil {
+		defer cache.Clear()
+	}
+	for _, stmt := range stmts {
+		_, err := conn.ExecStatement(ctx, &activerecord.QueryOperation{Text: stmt})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func quoteColumns(d activerecord.SchemaDialect, columns []string) string {
+	quoted := make([]string, len(columns))
+	for i, column := range columns {
+		quoted[i] = d.QuoteIdentifier(column)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// columnStmt returns the definition of the column used in "CREATE TABLE" and
+// "ALTER TABLE" statements.
+func columnStmt(d activerecord.SchemaDialect, column activerecord.ColumnDefinition) string {
+	stmt := d.QuoteIdentifier(column.Name) + " " + d.NativeType(column)
+	if column.Default != "" {
+		stmt += " DEFAULT " + column.Default
+	}
+	if column.NotNull {
+		stmt += " NOT NULL"
+	}
+	if column.Comment != "" && inlineComments(d) {
+		stmt += " COMMENT " + quoteLiteral(column.Comment)
+	}
+	return stmt
+}
+
+// commentStmts returns "COMMENT ON" statements of the table and its columns,
+// no statements are returned for dialects with inline comments.
+func commentStmts(
+	d activerecord.SchemaDialect, table, comment string, columns []activerecord.ColumnDefinition,
+) []string {
+	if inlineComments(d) {
+		return nil
+	}
+
+	var stmts []string
+	if comment != "" {
+		stmts = append(stmts, fmt.Sprintf("COMMENT ON TABLE %s IS %s",
+			d.QuoteIdentifier(table), quoteLiteral(comment),
+		))
+	}
+	for _, column := range columns {
+		if column.Comment != "" {
+			stmts = append(stmts, fmt.Sprintf("COMMENT ON COLUMN %s.%s IS %s",
+				d.QuoteIdentifier(table), d.QuoteIdentifier(column.Name), quoteLiteral(column.Comment),
+			))
+		}
+	}
+	return stmts
+}
+
+// defaultStmt returns the SQL literal of the default value of the column.
+func defaultStmt(value interface{}) string {
+	switch value := value.(type) {
+	case nil:
+		return "NULL"
+	case bool:
+		if value {
+			return "TRUE"
+		}
+		return "FALSE"
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return fmt.Sprint(value)
+	case time.Time:
+		return quoteLiteral(value.UTC().Format("2006-01-02 15:04:05"))
+	default:
+		return quoteLiteral(fmt.Sprint(value))
+	}
+}
+
+func quoteLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+func createTableStmt(d activerecord.SchemaDialect, name string, schema *tableSchema) string {
+	var (
+		buf         strings.Builder
+		primaryKeys []string
+	)
+	fmt.Fprintf(&buf, "CREATE TABLE %s (", d.QuoteIdentifier(name))
+
+	for _, column := range schema.columns {
+		fmt.Fprintf(&buf, "%s, ", columnStmt(d, column))
+		if column.IsPrimaryKey {
+			primaryKeys = append(primaryKeys, column.Name)
+		}
+	}
+
+	fmt.Fprintf(&buf, "PRIMARY KEY (%s)", quoteColumns(d, primaryKeys))
+	for i := range schema.foreignKeys {
+		fmt.Fprintf(&buf, ", %s", schema.foreignKeys[i].constraintStmt(d))
+	}
+	for i := range schema.checks {
+		fmt.Fprintf(&buf, ", %s", schema.checks[i].constraintStmt(d))
+	}
+	buf.WriteString(")")
+
+	options := schema.options
+	if inlineComments(d) {
+		if options.Engine != "" {
+			fmt.Fprintf(&buf, " ENGINE=%s", options.Engine)
+		}
+		if options.Charset != "" {
+			fmt.Fprintf(&buf, " DEFAULT CHARSET=%s", options.Charset)
+		}
+	}
+	if options.Tablespace != "" {
+		fmt.Fprintf(&buf, " TABLESPACE %s", d.QuoteIdentifier(options.Tablespace))
+	}
+	if options.Comment != "" && inlineComments(d) {
+		fmt.Fprintf(&buf, " COMMENT=%s", quoteLiteral(options.Comment))
+	}
+	if options.Options != "" {
+		fmt.Fprintf(&buf, " %s", options.Options)
+	}
+	return buf.String()
+}
+
+// TableOptions are options of the created table.
+type TableOptions struct {
+	// Comment is the comment of the table stored in the database schema.
+	Comment string
+	// Tablespace is the tablespace of the table.
+	Tablespace string
+	// Engine and Charset are the storage engine and the default charset of the
+	// table, they are applied only by MySQL.
+	Engine  string
+	Charset string
+	// Options are appended verbatim to the "CREATE TABLE" statement.
+	Options string
+}
+
+// tableSchema is the schema of the table rebuilt by the migration.
+type tableSchema struct {
+	columns     []activerecord.ColumnDefinition
+	foreignKeys []AddForeignKey
+	checks      []AddCheckConstraint
+	indexes     []AddIndex
+	options     TableOptions
+}
+
+func loadTableSchema(ctx context.Context, conn activerecord.Conn, table string) (*tableSchema, error) {
+	columns, err := conn.ColumnDefinitions(ctx, table)
+	if err != nil {
+		return nil, err
+	}
+	schema := tableSchema{columns: columns}
+
+	foreignKeys, err := activerecord.ForeignKeyDefinitions(ctx, conn, table)
+	if err != nil && !errors.Is(err, new(activerecord.ErrNotSupported)) {
+		return nil, err
+	}
+	for _, fk := range foreignKeys {
+		schema.foreignKeys = append(schema.foreignKeys, AddForeignKey{
+			Table:      table,
+			Target:     fk.Target,
+			Column:     fk.Column,
+			PrimaryKey: fk.PrimaryKey,
+			Name:       fk.Name,
+			OnDelete:   ReferentialAction(fk.OnDelete),
+			OnUpdate:   ReferentialAction(fk.OnUpdate),
+		})
+	}
+
+	checks, err := activerecord.CheckConstraintDefinitions(ctx, conn, table)
+	if err != nil && !errors.Is(err, new(activerecord.ErrNotSupported)) {
+		return nil, err
+	}
+	for _, check := range checks {
+		schema.checks = append(schema.checks, AddCheckConstraint{
+			Table: table, Expression: check.Expression, Name: check.Name,
+		})
+	}
+
+	indexes, err := activerecord.IndexDefinitions(ctx, conn, table)
+	if err != nil && !errors.Is(err, new(activerecord.ErrNotSupported)) {
+		return nil, err
+	}
+	for _, index := range indexes {
+		// Indexes of constraints are created together with the table.
+		if strings.HasPrefix(index.Name, "sqlite_autoindex_") {
+			continue
+		}
+		schema.indexes = append(schema.indexes, AddIndex{
+			Table:   table,
+			Columns: index.Columns,
+			Name:    index.Name,
+			Unique:  index.Unique,
+			Where:   index.Where,
+		})
+	}
+	return &schema, nil
+}
+
+// rebuildTable changes the schema of the table, when the database does not
+// alter it in place: rows are copied into a new table with the changed schema,
+// which then replaces the original table. Indexes and constraints of the
+// original table are created again, when the adapter introspects them.
+func rebuildTable(
+	ctx context.Context, conn activerecord.Conn, table string, alter func(*tableSchema) error,
+) error {
+	schema, err := loadTableSchema(ctx, conn, table)
+	if err != nil {
+		return err
+	}
+	if err := alter(schema); err != nil {
+		return err
+	}
+
+	names := make([]string, len(schema.columns))
+	for i, column := range schema.columns {
+		names[i] = column.Name
+	}
+
+	d := activerecord.DialectOf(conn)
+	temp := "new_" + table
+
+	stmts := []string{
+		createTableStmt(d, temp, schema),
+		fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s",
+			d.QuoteIdentifier(temp), quoteColumns(d, names),
+			quoteColumns(d, names), d.QuoteIdentifier(table),
+		),
+		fmt.Sprintf("DROP TABLE %s", d.QuoteIdentifier(table)),
+		fmt.Sprintf("ALTER TABLE %s RENAME TO %s", d.QuoteIdentifier(temp), d.QuoteIdentifier(table)),
+	}
+	for i := range schema.indexes {
+		stmts = append(stmts, Statements(d, &schema.indexes[i])...)
+	}
+	return execStatements(ctx, conn, stmts...)
+}
+
+// CreateTable is the operation to create a table.
+type CreateTable struct {
+	Name             string
+	Columns          []activerecord.ColumnDefinition
+	ForeignKeys      []AddForeignKey
+	CheckConstraints []AddCheckConstraint
+	Options          TableOptions
+}
+
+// Statements returns the "CREATE TABLE" statement of the dialect followed by
+// statements of comments of the table and its columns.
+func (op *CreateTable) Statements(d activerecord.SchemaDialect) []string {
+	stmt := createTableStmt(d, op.Name, &tableSchema{
+		columns:     op.Columns,
+		foreignKeys: op.ForeignKeys,
+		checks:      op.CheckConstraints,
+		options:     op.Options,
+	})
+	return append([]string{stmt}, commentStmts(d, op.Name, op.Options.Comment, op.Columns)...)
+}
+
+func (op *CreateTable) Exec(ctx context.Context, conn activerecord.Conn) error {
+	return execOperation(ctx, conn, op)
+}
+
+// Inverse returns the operation to drop the table.
+func (op *CreateTable) Inverse() (Operation, error) {
+	return &DropTable{
+		Name:             op.Name,
+		Columns:          op.Columns,
+		ForeignKeys:      op.ForeignKeys,
+		CheckConstraints: op.CheckConstraints,
+		Options:          op.Options,
+	}, nil
+}
+
+// DropTable is the operation to drop the table. Columns, constraints and options
+// are used to create the table, when the operation is reverted.
+type DropTable struct {
+	Name             string
+	Columns          []activerecord.ColumnDefinition
+	ForeignKeys      []AddForeignKey
+	CheckConstraints []AddCheckConstraint
+	Options          TableOptions
+}
+
+// Statements returns the "DROP TABLE" statement of the dialect.
+func (op *DropTable) Statements(d activerecord.SchemaDialect) []string {
+	return []string{fmt.Sprintf("DROP TABLE %s", d.QuoteIdentifier(op.Name))}
+}
+
+func (op *DropTable) Exec(ctx context.Context, conn activerecord.Conn) error {
+	return execOperation(ctx, conn, op)
+}
+
+// Inverse returns the operation to create the table, the drop of the table is
+// irreversible, when columns are not defined.
+func (op *DropTable) Inverse() (Operation, error) {
+	if len(op.Columns) == 0 {
+		return nil, &ErrIrreversibleMigration{Operation: op}
+	}
+	return &CreateTable{
+		Name:             op.Name,
+		Columns:          op.Columns,
+		ForeignKeys:      op.ForeignKeys,
+		CheckConstraints: op.CheckConstraints,
+		Options:          op.Options,
+	}, nil
+}
+
+// AddColumn is the operation to add a column to the table.
+type AddColumn struct {
+	Table  string
+	Column activerecord.ColumnDefinition
+}
+
+// Statements returns the "ALTER TABLE" statement of the dialect followed by
+// the statement of the column comment.
+func (op *AddColumn) Statements(d activerecord.SchemaDialect) []string {
+	stmt := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s",
+		d.QuoteIdentifier(op.Table), columnStmt(d, op.Column),
+	)
+	columns := []activerecord.ColumnDefinition{op.Column}
+	return append([]string{stmt}, commentStmts(d, op.Table, "", columns)...)
+}
+
+func (op *AddColumn) Exec(ctx context.Context, conn activerecord.Conn) error {
+	return execOperation(ctx, conn, op)
+}
+
+// Inverse returns the operation to remove the column.
+func (op *AddColumn) Inverse() (Operation, error) {
+	return &RemoveColumn{Table: op.Table, Column: op.Column.Name}, nil
+}
+
+// RemoveColumn is the operation to remove a column from the table. Type is
+// used to add the column, when the operation is reverted.
+type RemoveColumn struct {
+	Table  string
+	Column string
+	Type   activerecord.Type
+}
+
+// Statements returns the "ALTER TABLE" statement of the dialect.
+func (op *RemoveColumn) Statements(d activerecord.SchemaDialect) []string {
+	return []string{fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s",
+		d.QuoteIdentifier(op.Table), d.QuoteIdentifier(op.Column),
+	)}
+}
+
+// Exec removes the column. When the database does not drop columns, the table
+// is rebuilt without the column, indexes and foreign keys of the column are
+// removed together with it.
+func (op *RemoveColumn) Exec(ctx context.Context, conn activerecord.Conn) error {
+	if activerecord.CapabilitiesOf(conn).DropColumn {
+		return execOperation(ctx, conn, op)
+	}
+
+	return rebuildTable(ctx, conn, op.Table, func(schema *tableSchema) error {
+		var columns []activerecord.ColumnDefinition
+		for _, column := range schema.columns {
+			if column.Name != op.Column {
+				columns = append(columns, column)
+			}
+		}
+		if len(columns) == len(schema.columns) {
+			return fmt.Errorf("migration: column %q of table %q does not exist", op.Column, op.Table)
+		}
+
+		var foreignKeys []AddForeignKey
+		for _, fk := range schema.foreignKeys {
+			if fk.Column != op.Column {
+				foreignKeys = append(foreignKeys, fk)
+			}
+		}
+
+		var indexes []AddIndex
+	next:
+		for _, index := range schema.indexes {
+			for _, column := range index.Columns {
+				if column == op.Column {
+					continue next
+				}
+			}
+			indexes = append(indexes, index)
+		}
+
+		schema.columns, schema.foreignKeys, schema.indexes = columns, foreignKeys, indexes
+		return nil
+	})
+}
+
+// Inverse returns the operation to add the column, the removal of the column is
+// irreversible, when the type of the column is not defined.
+func (op *RemoveColumn) Inverse() (Operation, error) {
+	if op.Type == nil {
+		return nil, &ErrIrreversibleMigration{Operation: op}
+	}
+	return &AddColumn{
+		Table:  op.Table,
+		Column: activerecord.ColumnDefinition{Name: op.Column, Type: op.Type},
+	}, nil
+}
+
+// AddIndex is the operation to create an index of the table.
+type AddIndex struct {
+	Table string
+	// Columns are names of indexed columns, or expressions, e.g. "lower(name)".
+	// Expressions are distinguished from names by parentheses.
+	Columns []string
+	Name    string
+	Unique  bool
+	// Where is the condition of the partial index, which includes only rows
+	// matching the condition. Partial indexes are not supported by MySQL.
+	Where string
+	// Concurrently specifies to build the index without locking writes to the
+	// table, it is supported only by PostgreSQL and ignored by other databases.
+	// Migrations with concurrent indexes are not executed within transactions.
+	Concurrently bool
+}
+
+// IndexOption configures the index.
+type IndexOption func(*AddIndex)
+
+// Unique specifies the unique index.
+func Unique() IndexOption {
+	return func(op *AddIndex) {
+		op.Unique = true
+	}
+}
+
+// IndexName specifies the name of the index.
+func IndexName(name string) IndexOption {
+	return func(op *AddIndex) {
+		op.Name = name
+	}
+}
+
+// Where specifies the condition of the partial index:
+//
+//	m.AddIndex("users", []string{"email"}, migration.Unique(), migration.Where("deleted_at IS NULL"))
+func Where(condition string) IndexOption {
+	return func(op *AddIndex) {
+		op.Where = condition
+	}
+}
+
+// Concurrently specifies to create or remove the index concurrently.
+func Concurrently() IndexOption {
+	return func(op *AddIndex) {
+		op.Concurrently = true
+	}
+}
+
+func isExpression(column string) bool {
+	return strings.ContainsRune(column, '(')
+}
+
+// IndexName returns the name of the index, when the name is not specified it is
+// built from names of the table and columns.
+func (op *AddIndex) IndexName() string {
+	if op.Name != "" {
+		return op.Name
+	}
+
+	columns := make([]string, len(op.Columns))
+	for i, column := range op.Columns {
+		// Replace all non-alphanumeric characters of expressions.
+		columns[i] = strings.Join(strings.FieldsFunc(column, func(r rune) bool {
+			return !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+		}), "_")
+	}
+	return fmt.Sprintf("index_%s_on_%s", op.Table, strings.Join(columns, "_and_"))
+}
+
+// Statements returns the "CREATE INDEX" statement of the dialect.
+func (op *AddIndex) Statements(d activerecord.SchemaDialect) []string {
+	var buf strings.Builder
+	buf.WriteString("CREATE ")
+	if op.Unique {
+		buf.WriteString("UNIQUE ")
+	}
+	buf.WriteString("INDEX ")
+	if op.Concurrently {
+		buf.WriteString("CONCURRENTLY ")
+	}
+
+	columns := make([]string, len(op.Columns))
+	for i, column := range op.Columns {
+		if isExpression(column) {
+			columns[i] = "(" + column + ")"
+		} else {
+			columns[i] = d.QuoteIdentifier(column)
+		}
+	}
+
+	fmt.Fprintf(&buf, "%s ON %s (%s)", d.QuoteIdentifier(op.IndexName()),
+		d.QuoteIdentifier(op.Table), strings.Join(columns, ", "),
+	)
+	if op.Where != "" {
+		fmt.Fprintf(&buf, " WHERE %s", op.Where)
+	}
+	return []string{buf.String()}
+}
+
+func (op *AddIndex) Exec(ctx context.Context, conn activerecord.Conn) error {
+	return execOperation(ctx, conn, op)
+}
+
+// Inverse returns the operation to remove the index.
+func (op *AddIndex) Inverse() (Operation, error) {
+	remove := RemoveIndex(*op)
+	return &remove, nil
+}
+
+// RemoveIndex is the operation to remove the index of the table. The index is
+// identified by the name, or by columns, when the name is not specified.
+type RemoveIndex AddIndex
+
+// IndexName returns the name of the removed index.
+func (op *RemoveIndex) IndexName() string {
+	return (*AddIndex)(op).IndexName()
+}
+
+// Statements returns the "DROP INDEX" statement of the dialect.
+func (op *RemoveIndex) Statements(d activerecord.SchemaDialect) []string {
+	var concurrently string
+	if op.Concurrently {
+		concurrently = "CONCURRENTLY "
+	}
+	return []string{fmt.Sprintf("DROP INDEX %s%s", concurrently, d.QuoteIdentifier(op.IndexName()))}
+}
+
+func (op *RemoveIndex) Exec(ctx context.Context, conn activerecord.Conn) error {
+	return execOperation(ctx, conn, op)
+}
+
+// Inverse returns the operation to create the index, the removal of the index
+// is irreversible, when columns are not defined.
+func (op *RemoveIndex) Inverse() (Operation, error) {
+	if len(op.Columns) == 0 {
+		return nil, &ErrIrreversibleMigration{Operation: op}
+	}
+	add := AddIndex(*op)
+	return &add, nil
+}
+
+// RenameTable is the operation to rename the table.
+type RenameTable struct {
+	From string
+	To   string
+}
+
+// Statements returns the "ALTER TABLE" statement of the dialect.
+func (op *RenameTable) Statements(d activerecord.SchemaDialect) []string {
+	return []string{fmt.Sprintf("ALTER TABLE %s RENAME TO %s",
+		d.QuoteIdentifier(op.From), d.QuoteIdentifier(op.To),
+	)}
+}
+
+func (op *RenameTable) Exec(ctx context.Context, conn activerecord.Conn) error {
+	return execOperation(ctx, conn, op)
+}
+
+// Inverse returns the operation to rename the table back.
+func (op *RenameTable) Inverse() (Operation, error) {
+	return &RenameTable{From: op.To, To: op.From}, nil
+}
+
+// ReferentialAction is the action taken by the database, when the referenced
+// row is deleted or updated.
+type ReferentialAction string
+
+const (
+	Cascade  ReferentialAction = "CASCADE"
+	Restrict ReferentialAction = "RESTRICT"
+	SetNull  ReferentialAction = "SET NULL"
+	NoAction ReferentialAction = "NO ACTION"
+)
+
+// AddForeignKey is the operation to create the foreign key constraint of the
+// table column referencing the primary key of the target table.
+type AddForeignKey struct {
+	Table      string
+	Target     string
+	Column     string
+	PrimaryKey string
+	Name       string
+	// OnDelete and OnUpdate are referential actions of the constraint, empty
+	// actions are default actions of the database.
+	OnDelete ReferentialAction
+	OnUpdate ReferentialAction
+}
+
+// ForeignKeyOption configures the foreign key constraint.
+type ForeignKeyOption func(*AddForeignKey)
+
+// OnDelete specifies the action taken, when the referenced row is deleted.
+func OnDelete(action ReferentialAction) ForeignKeyOption {
+	return func(op *AddForeignKey) {
+		op.OnDelete = action
+	}
+}
+
+// OnUpdate specifies the action taken, when the referenced key is updated.
+func OnUpdate(action ReferentialAction) ForeignKeyOption {
+	return func(op *AddForeignKey) {
+		op.OnUpdate = action
+	}
+}
+
+// ForeignKeyColumn specifies the column referencing the target table.
+func ForeignKeyColumn(name string) ForeignKeyOption {
+	return func(op *AddForeignKey) {
+		op.Column = name
+	}
+}
+
+// ForeignKeyPrimaryKey specifies the column of the target table referenced by
+// the foreign key.
+func ForeignKeyPrimaryKey(name string) ForeignKeyOption {
+	return func(op *AddForeignKey) {
+		op.PrimaryKey = name
+	}
+}
+
+// ForeignKeyName specifies the name of the foreign key constraint.
+func ForeignKeyName(name string) ForeignKeyOption {
+	return func(op *AddForeignKey) {
+		op.Name = name
+	}
+}
+
+func newForeignKey(table, target string, opts []ForeignKeyOption) *AddForeignKey {
+	op := AddForeignKey{Table: table, Target: referenceTable(target)}
+	for _, opt := range opts {
+		opt(&op)
+	}
+	if op.Column == "" {
+		op.Column = referenceColumn(target)
+	}
+	if op.PrimaryKey == "" {
+		op.PrimaryKey = "id"
+	}
+	return &op
+}
+
+// ForeignKeyName returns the name of the constraint, when the name is not
+// specified it is built from names of the table and the target table.
+func (op *AddForeignKey) ForeignKeyName() string {
+	if op.Name != "" {
+		return op.Name
+	}
+	return fmt.Sprintf("fk_%s_on_%s", op.Table, op.Target)
+}
+
+// constraintStmt returns the definition of the constraint used in "CREATE TABLE"
+// and "ALTER TABLE" statements.
+func (op *AddForeignKey) constraintStmt(d activerecord.SchemaDialect) string {
+	stmt := fmt.Sprintf("CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s (%s)",
+		d.QuoteIdentifier(op.ForeignKeyName()), d.QuoteIdentifier(op.Column),
+		d.QuoteIdentifier(op.Target), d.QuoteIdentifier(op.PrimaryKey),
+	)
+	if op.OnDelete != "" {
+		stmt += " ON DELETE " + string(op.OnDelete)
+	}
+	if op.OnUpdate != "" {
+		stmt += " ON UPDATE " + string(op.OnUpdate)
+	}
+	return stmt
+}
+
+// Statements returns the "ALTER TABLE" statement of the dialect.
+func (op *AddForeignKey) Statements(d activerecord.SchemaDialect) []string {
+	return []string{fmt.Sprintf("ALTER TABLE %s ADD %s",
+		d.QuoteIdentifier(op.Table), op.constraintStmt(d),
+	)}
+}
+
+// Exec creates the foreign key. When the database does not alter constraints,
+// the table is rebuilt with the foreign key.
+func (op *AddForeignKey) Exec(ctx context.Context, conn activerecord.Conn) error {
+	if activerecord.CapabilitiesOf(conn).AlterConstraints {
+		return execOperation(ctx, conn, op)
+	}
+	return rebuildTable(ctx, conn, op.Table, func(schema *tableSchema) error {
+		schema.foreignKeys = append(schema.foreignKeys, *op)
+		return nil
+	})
+}
+
+// Inverse returns the operation to remove the foreign key.
+func (op *AddForeignKey) Inverse() (Operation, error) {
+	remove := RemoveForeignKey(*op)
+	return &remove, nil
+}
+
+// RemoveForeignKey is the operation to remove the foreign key constraint of the
+// table. The constraint is identified by the name, or by the column and the
+// target table, when the database does not keep names of constraints.
+type RemoveForeignKey AddForeignKey
+
+// ForeignKeyName returns the name of the removed constraint.
+func (op *RemoveForeignKey) ForeignKeyName() string {
+	return (*AddForeignKey)(op).ForeignKeyName()
+}
+
+// Statements returns the "ALTER TABLE" statement of the dialect.
+func (op *RemoveForeignKey) Statements(d activerecord.SchemaDialect) []string {
+	return []string{fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s",
+		d.QuoteIdentifier(op.Table), d.QuoteIdentifier(op.ForeignKeyName()),
+	)}
+}
+
+// Exec removes the foreign key. When the database does not alter constraints,
+// the table is rebuilt without the foreign key.
+func (op *RemoveForeignKey) Exec(ctx context.Context, conn activerecord.Conn) error {
+	if activerecord.CapabilitiesOf(conn).AlterConstraints {
+		return execOperation(ctx, conn, op)
+	}
+	return rebuildTable(ctx, conn, op.Table, func(schema *tableSchema) error {
+		var foreignKeys []AddForeignKey
+		for _, fk := range schema.foreignKeys {
+			sameName := fk.Name != "" && fk.Name == op.ForeignKeyName()
+			sameColumn := fk.Name == "" && fk.Column == op.Column && fk.Target == op.Target
+			if !sameName && !sameColumn {
+				foreignKeys = append(foreignKeys, fk)
+			}
+		}
+		if len(foreignKeys) == len(schema.foreignKeys) {
+			return fmt.Errorf("migration: foreign key %q of table %q does not exist",
+				op.ForeignKeyName(), op.Table)
+		}
+		schema.foreignKeys = foreignKeys
+		return nil
+	})
+}
+
+// Inverse returns the operation to create the foreign key.
+func (op *RemoveForeignKey) Inverse() (Operation, error) {
+	add := AddForeignKey(*op)
+	return &add, nil
+}
+
+// AddCheckConstraint is the operation to create the check constraint of the
+// table, which is satisfied by every row of the table.
+type AddCheckConstraint struct {
+	Table      string
+	Expression string
+	Name       string
+	// NotValid specifies to skip validation of existing rows, so the constraint
+	// is added to large tables without the long lock. Rows are validated later
+	// with ValidateConstraint operation. It is supported only by PostgreSQL,
+	// other databases validate existing rows, when the constraint is added.
+	NotValid bool
+}
+
+// CheckOption configures the check constraint.
+type CheckOption func(*AddCheckConstraint)
+
+// CheckName specifies the name of the check constraint.
+func CheckName(name string) CheckOption {
+	return func(op *AddCheckConstraint) {
+		op.Name = name
+	}
+}
+
+// NotValid specifies to skip validation of existing rows of the table.
+func NotValid() CheckOption {
+	return func(op *AddCheckConstraint) {
+		op.NotValid = true
+	}
+}
+
+func newCheckConstraint(table, expression string, opts []CheckOption) *AddCheckConstraint {
+	op := AddCheckConstraint{Table: table, Expression: expression}
+	for _, opt := range opts {
+		opt(&op)
+	}
+	return &op
+}
+
+// ConstraintName returns the name of the constraint, when the name is not
+// specified it is built from the name of the table and the hash of expression.
+func (op *AddCheckConstraint) ConstraintName() string {
+	if op.Name != "" {
+		return op.Name
+	}
+	h := fnv.New32a()
+	h.Write([]byte(op.Expression))
+	return fmt.Sprintf("chk_%s_%08x", op.Table, h.Sum32())
+}
+
+// constraintStmt returns the definition of the constraint used in "CREATE TABLE"
+// and "ALTER TABLE" statements.
+func (op *AddCheckConstraint) constraintStmt(d activerecord.SchemaDialect) string {
+	return fmt.Sprintf("CONSTRAINT %s CHECK (%s)",
+		d.QuoteIdentifier(op.ConstraintName()), op.Expression,
+	)
+}
+
+// Statements returns the "ALTER TABLE" statement of the dialect.
+func (op *AddCheckConstraint) Statements(d activerecord.SchemaDialect) []string {
+	stmt := fmt.Sprintf("ALTER TABLE %s ADD %s", d.QuoteIdentifier(op.Table), op.constraintStmt(d))
+	if op.NotValid {
+		stmt += " NOT VALID"
+	}
+	return []string{stmt}
+}
+
+// Exec creates the check constraint. When the database does not alter
+// constraints, the table is rebuilt with the constraint.
+func (op *AddCheckConstraint) Exec(ctx context.Context, conn activerecord.Conn) error {
+	if activerecord.CapabilitiesOf(conn).AlterConstraints {
+		return execOperation(ctx, conn, op)
+	}
+	return rebuildTable(ctx, conn, op.Table, func(schema *tableSchema) error {
+		schema.checks = append(schema.checks, *op)
+		return nil
+	})
+}
+
+// Inverse returns the operation to remove the check constraint.
+func (op *AddCheckConstraint) Inverse() (Operation, error) {
+	remove := RemoveCheckConstraint(*op)
+	return &remove, nil
+}
+
+// RemoveCheckConstraint is the operation to remove the check constraint of the
+// table. The constraint is identified by the name, or by the expression, when
+// the database does not keep names of constraints.
+type RemoveCheckConstraint AddCheckConstraint
+
+// ConstraintName returns the name of the removed constraint.
+func (op *RemoveCheckConstraint) ConstraintName() string {
+	return (*AddCheckConstraint)(op).ConstraintName()
+}
+
+// Statements returns the "ALTER TABLE" statement of the dialect.
+func (op *RemoveCheckConstraint) Statements(d activerecord.SchemaDialect) []string {
+	return []string{fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s",
+		d.QuoteIdentifier(op.Table), d.QuoteIdentifier(op.ConstraintName()),
+	)}
+}
+
+// Exec removes the check constraint. When the database does not alter
+// constraints, the table is rebuilt without the constraint.
+func (op *RemoveCheckConstraint) Exec(ctx context.Context, conn activerecord.Conn) error {
+	if activerecord.CapabilitiesOf(conn).AlterConstraints {
+		return execOperation(ctx, conn, op)
+	}
+	return rebuildTable(ctx, conn, op.Table, func(schema *tableSchema) error {
+		var checks []AddCheckConstraint
+		for _, check := range schema.checks {
+			sameName := check.Name != "" && check.Name == op.ConstraintName()
+			sameExpression := check.Name == "" && check.Expression == op.Expression
+			if !sameName && !sameExpression {
+				checks = append(checks, check)
+			}
+		}
+		if len(checks) == len(schema.checks) {
+			return fmt.Errorf("migration: check constraint %q of table %q does not exist",
+				op.ConstraintName(), op.Table)
+		}
+		schema.checks = checks
+		return nil
+	})
+}
+
+// Inverse returns the operation to create the check constraint, the removal is
+// irreversible, when the expression is not defined.
+func (op *RemoveCheckConstraint) Inverse() (Operation, error) {
+	if op.Expression == "" {
+		return nil, &ErrIrreversibleMigration{Operation: op}
+	}
+	add := AddCheckConstraint(*op)
+	return &add, nil
+}
+
+// ValidateConstraint is the operation to validate existing rows of the table
+// against the constraint added with NotValid option.
+type ValidateConstraint struct {
+	Table string
+	Name  string
+}
+
+// Statements returns the "ALTER TABLE" statement of the dialect.
+func (op *ValidateConstraint) Statements(d activerecord.SchemaDialect) []string {
+	return []string{fmt.Sprintf("ALTER TABLE %s VALIDATE CONSTRAINT %s",
+		d.QuoteIdentifier(op.Table), d.QuoteIdentifier(op.Name),
+	)}
+}
+
+// Exec validates the constraint. Constraints of rebuilt tables are validated,
+// when rows are copied, so the operation does nothing, when the database does
+// not alter constraints.
+func (op *ValidateConstraint) Exec(ctx context.Context, conn activerecord.Conn) error {
+	if !activerecord.CapabilitiesOf(conn).AlterConstraints {
+		return nil
+	}
+	return execOperation(ctx, conn, op)
+}
+
+// Execute is the operation to execute the SQL statement.
+type Execute struct {
+	Statement string
+}
+
+// Statements returns the statement of the operation.
+func (op *Execute) Statements(d activerecord.SchemaDialect) []string {
+	return []string{op.Statement}
+}
+
+func (op *Execute) Exec(ctx context.Context, conn activerecord.Conn) error {
+	return execOperation(ctx, conn, op)
+}
+
+// Reversible is the operation, which executes operations of the Up migration,
+// and which is reverted by operations of the Down migration.
+type Reversible struct {
+	Up   *Migration
+	Down *Migration
+}
+
+// Statements returns statements of the Up migration.
+func (op *Reversible) Statements(d activerecord.SchemaDialect) []string {
+	var stmts []string
+	for _, op := range op.Up.Operations() {
+		stmts = append(stmts, Statements(d, op)...)
+	}
+	return stmts
+}
+
+func (op *Reversible) Exec(ctx context.Context, conn activerecord.Conn) error {
+	return op.Up.Exec(ctx, conn)
+}
+
+// Inverse returns the operation, which executes operations of the Down migration.
+func (op *Reversible) Inverse() (Operation, error) {
+	return &Reversible{Up: op.Down, Down: op.Up}, nil
+}
+
+// CreateView is the operation to create a view selecting rows with the query.
+// Results of materialized views are stored in the database and refreshed with
+// RefreshMaterializedView operation.
+type CreateView struct {
+	Name         string
+	Query        string
+	Materialized bool
+}
+
+// Statements returns the "CREATE VIEW" statement of the dialect.
+func (op *CreateView) Statements(d activerecord.SchemaDialect) []string {
+	kind := "VIEW"
+	if op.Materialized {
+		kind = "MATERIALIZED VIEW"
+	}
+	return []string{fmt.Sprintf("CREATE %s %s AS %s", kind, d.QuoteIdentifier(op.Name), op.Query)}
+}
+
+// Exec creates the view. ErrNotSupported is returned for materialized views,
+// when the database does not have them.
+func (op *CreateView) Exec(ctx context.Context, conn activerecord.Conn) error {
+	if op.Materialized && !activerecord.CapabilitiesOf(conn).MaterializedViews {
+		return &activerecord.ErrNotSupported{Feature: "materialized views"}
+	}
+	return execOperation(ctx, conn, op)
+}
+
+// Inverse returns the operation to drop the view.
+func (op *CreateView) Inverse() (Operation, error) {
+	return &DropView{Name: op.Name, Query: op.Query, Materialized: op.Materialized}, nil
+}
+
+// DropView is the operation to drop the view. Query is used to create the view,
+// when the operation is reverted.
+type DropView struct {
+	Name         string
+	Query        string
+	Materialized bool
+}
+
+// Statements returns the "DROP VIEW" statement of the dialect.
+func (op *DropView) Statements(d activerecord.SchemaDialect) []string {
+	kind := "VIEW"
+	if op.Materialized {
+		kind = "MATERIALIZED VIEW"
+	}
+	return []string{fmt.Sprintf("DROP %s %s", kind, d.QuoteIdentifier(op.Name))}
+}
+
+func (op *DropView) Exec(ctx context.Context, conn activerecord.Conn) error {
+	return execOperation(ctx, conn, op)
+}
+
+// Inverse returns the operation to create the view, the drop of the view is
+// irreversible, when the query is not defined.
+func (op *DropView) Inverse() (Operation, error) {
+	if op.Query == "" {
+		return nil, &ErrIrreversibleMigration{Operation: op}
+	}
+	return &CreateView{Name: op.Name, Query: op.Query, Materialized: op.Materialized}, nil
+}
+
+// RefreshMaterializedView is the operation to refresh results of the materialized
+// view. Concurrent refresh does not lock reads of the view, but requires the
+// unique index of the view.
+type RefreshMaterializedView struct {
+	Name         string
+	Concurrently bool
+}
+
+// Statements returns the "REFRESH MATERIALIZED VIEW" statement of the dialect.
+func (op *RefreshMaterializedView) Statements(d activerecord.SchemaDialect) []string {
+	stmt := "REFRESH MATERIALIZED VIEW "
+	if op.Concurrently {
+		stmt += "CONCURRENTLY "
+	}
+	return []string{stmt + d.QuoteIdentifier(op.Name)}
+}
+
+// Exec refreshes the materialized view. ErrNotSupported is returned, when the
+// database does not have materialized views.
+func (op *RefreshMaterializedView) Exec(ctx context.Context, conn activerecord.Conn) error {
+	if !activerecord.CapabilitiesOf(conn).MaterializedViews {
+		return &activerecord.ErrNotSupported{Feature: "materialized views"}
+	}
+	return execOperation(ctx, conn, op)
+}
+
+// Inverse returns the operation itself, since the refresh is idempotent.
+func (op *RefreshMaterializedView) Inverse() (Operation, error) {
+	return op, nil
+}
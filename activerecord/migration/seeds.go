@@ -0,0 +1,54 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/activegraph/activegraph/activerecord"
+)
+
+var (
+	seedsMu sync.Mutex
+	seeds   []func(ctx context.Context) error
+)
+
+// Seeds registers the seed function, which bootstraps reference data of the
+// database through models. Seeds are executed in the order of registration
+// every time the database is seeded, so seed functions must be idempotent:
+//
+//	func init() {
+//		migration.Seeds(func(ctx context.Context) error {
+//			Role := activerecord.New("role").WithContext(ctx)
+//			return Role.FindOrCreateBy(Hash{"name": "admin"}).Err()
+//		})
+//	}
+func Seeds(fn func(ctx context.Context) error) {
+	seedsMu.Lock()
+	defer seedsMu.Unlock()
+	seeds = append(seeds, fn)
+}
+
+// Seed executes registered seeds, each seed is executed within a transaction
+// of the connection specified in the context, so the failed seed does not leave
+// partially created data. Execution stops at the first failed seed.
+func Seed(ctx context.Context) error {
+	seedsMu.Lock()
+	fns := append([]func(context.Context) error(nil), seeds...)
+	seedsMu.Unlock()
+
+	for i, fn := range fns {
+		if err := activerecord.Transaction(ctx, fn); err != nil {
+			return fmt.Errorf("migration: seed #%d failed: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// Seed applies pending migrations and then executes registered seeds.
+func (m *Migrator) Seed(ctx context.Context) error {
+	if err := m.Migrate(ctx); err != nil {
+		return err
+	}
+	return Seed(ctx)
+}
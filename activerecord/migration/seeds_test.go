@@ -0,0 +1,70 @@
+package migration_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/activegraph/activegraph/activerecord"
+	"github.com/activegraph/activegraph/activerecord/migration"
+	. "github.com/activegraph/activegraph/activesupport"
+)
+
+func TestMigrator_Seed(t *testing.T) {
+	conn, err := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter: "sqlite3", Database: t.Name() + ".db",
+	})
+	require.NoError(t, err)
+
+	defer os.Remove(t.Name() + ".db")
+	defer activerecord.RemoveConnection("primary")
+
+	// Models are defined after the table is created by migrations.
+	Role := func(ctx context.Context) *activerecord.Relation {
+		return activerecord.New("role").WithContext(ctx)
+	}
+
+	fail := errors.New("seed failed")
+	failing := false
+
+	migration.Seeds(func(ctx context.Context) error {
+		for _, name := range []string{"admin", "editor"} {
+			if err := Role(ctx).FindOrCreateBy(Hash{"name": name}).Err(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	migration.Seeds(func(ctx context.Context) error {
+		if err := Role(ctx).Create(Hash{"name": "guest"}).Err(); err != nil {
+			return err
+		}
+		if failing {
+			return fail
+		}
+		return Role(ctx).Where("name", "guest").Sole().Err()
+	})
+
+	ctx := context.TODO()
+	migrator := migration.NewMigrator(conn,
+		migration.Definition{Version: "001_create_roles", Change: func(m *migration.Migration) {
+			m.CreateTable("roles", func(t *migration.Table) {
+				t.String("name")
+			})
+		}},
+	)
+	require.NoError(t, migrator.Seed(ctx))
+
+	// The failed seed is rolled back, seeds executed before are kept.
+	failing = true
+	_, err = activerecord.Execute(ctx, `DELETE FROM "roles" WHERE "name" = 'guest'`)
+	require.NoError(t, err)
+	require.ErrorIs(t, migrator.Seed(ctx), fail)
+
+	roles, err := Role(ctx).Order("name").Pluck("name")
+	require.NoError(t, err)
+	require.Equal(t, [][]interface{}{{"admin"}, {"editor"}}, roles)
+}
@@ -0,0 +1,232 @@
+package migration
+
+import (
+	"github.com/activegraph/activegraph/activerecord"
+	"github.com/activegraph/activegraph/activesupport"
+)
+
+// column is the column definition with constraints of the column.
+type column struct {
+	activerecord.ColumnDefinition
+	foreignKey *AddForeignKey
+}
+
+// ColumnOption configures the column definition.
+type ColumnOption func(*column)
+
+// NotNull specifies the "NOT NULL" constraint of the column.
+func NotNull() ColumnOption {
+	return func(c *column) {
+		c.NotNull = true
+	}
+}
+
+// Default specifies the default value of the column assigned by the database,
+// the value is converted to the SQL literal:
+//
+//	t.String("status", migration.Default("draft"))
+func Default(value interface{}) ColumnOption {
+	return func(c *column) {
+		c.Default = defaultStmt(value)
+	}
+}
+
+// DefaultExpression specifies the SQL expression of the default value of the
+// column, e.g. "CURRENT_TIMESTAMP".
+func DefaultExpression(expression string) ColumnOption {
+	return func(c *column) {
+		c.Default = expression
+	}
+}
+
+// Comment specifies the comment of the column stored in the database schema.
+func Comment(text string) ColumnOption {
+	return func(c *column) {
+		c.Comment = text
+	}
+}
+
+// ForeignKeyConstraint specifies the foreign key constraint of the reference
+// column, so the reference is enforced by the database:
+//
+//	t.References("owner", migration.ForeignKeyConstraint(migration.OnDelete(migration.Cascade)))
+//
+// The option is used only with Table.References.
+func ForeignKeyConstraint(opts ...ForeignKeyOption) ColumnOption {
+	return func(c *column) {
+		c.foreignKey = new(AddForeignKey)
+		for _, opt := range opts {
+			opt(c.foreignKey)
+		}
+	}
+}
+
+func newColumn(name string, columnType activerecord.Type, opts []ColumnOption) column {
+	c := column{ColumnDefinition: activerecord.ColumnDefinition{Name: name, Type: columnType}}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+// referenceTable returns the name of the table referenced by the target, the
+// target is either a singular or a plural name of the table.
+func referenceTable(target string) string {
+	return activesupport.Pluralize(activesupport.Singularize(target))
+}
+
+// referenceColumn returns the name of the column referencing the target.
+func referenceColumn(target string) string {
+	return activesupport.ForeignKey(target)
+}
+
+// Table is the definition of the table created by the migration.
+type Table struct {
+	name       string
+	primaryKey string
+	columns    []column
+	checks     []AddCheckConstraint
+	options    TableOptions
+}
+
+// Name returns the name of the table.
+func (t *Table) Name() string {
+	return t.name
+}
+
+// Columns returns columns of the table in the order of definition, the primary
+// key column is the first one.
+func (t *Table) Columns() []activerecord.ColumnDefinition {
+	primaryKey := t.primaryKey
+	if primaryKey == "" {
+		primaryKey = "id"
+	}
+
+	columns := []activerecord.ColumnDefinition{{
+		Name: primaryKey, Type: new(activerecord.Int64), IsPrimaryKey: true, NotNull: true,
+	}}
+	for _, c := range t.columns {
+		column := c.ColumnDefinition
+		if column.Name == primaryKey {
+			column.IsPrimaryKey, column.NotNull = true, true
+			columns[0] = column
+			continue
+		}
+		columns = append(columns, column)
+	}
+	return columns
+}
+
+// ForeignKeys returns foreign key constraints of the table columns.
+func (t *Table) ForeignKeys() []AddForeignKey {
+	var foreignKeys []AddForeignKey
+	for _, c := range t.columns {
+		if c.foreignKey != nil {
+			foreignKeys = append(foreignKeys, *c.foreignKey)
+		}
+	}
+	return foreignKeys
+}
+
+// CheckConstraints returns check constraints of the table.
+func (t *Table) CheckConstraints() []AddCheckConstraint {
+	return t.checks
+}
+
+// Options returns options of the table.
+func (t *Table) Options() TableOptions {
+	return t.options
+}
+
+// Comment specifies the comment of the table stored in the database schema:
+//
+//	m.CreateTable("authors", func(t *migration.Table) {
+//		t.Comment("Authors of published books")
+//		t.String("name", migration.Comment("Full name of the author"))
+//	})
+func (t *Table) Comment(text string) {
+	t.options.Comment = text
+}
+
+// Tablespace specifies the tablespace of the table.
+func (t *Table) Tablespace(name string) {
+	t.options.Tablespace = name
+}
+
+// Engine specifies the storage engine of the table, e.g. "InnoDB". The engine
+// is applied only by MySQL.
+func (t *Table) Engine(name string) {
+	t.options.Engine = name
+}
+
+// Charset specifies the default charset of the table, e.g. "utf8mb4". The
+// charset is applied only by MySQL.
+func (t *Table) Charset(name string) {
+	t.options.Charset = name
+}
+
+// TableOptions specifies options appended verbatim to the "CREATE TABLE"
+// statement, e.g. "WITHOUT ROWID" for SQLite.
+func (t *Table) TableOptions(options string) {
+	t.options.Options = options
+}
+
+// PrimaryKey specifies the primary key of the table. When the column is not
+// defined, it is created with int64 type.
+func (t *Table) PrimaryKey(name string) {
+	t.primaryKey = name
+}
+
+// Column defines the column of the table.
+func (t *Table) Column(name string, columnType activerecord.Type, opts ...ColumnOption) {
+	c := newColumn(name, columnType, opts)
+	c.foreignKey = nil
+	t.columns = append(t.columns, c)
+}
+
+func (t *Table) Int64(name string, opts ...ColumnOption) {
+	t.Column(name, new(activerecord.Int64), opts...)
+}
+
+func (t *Table) String(name string, opts ...ColumnOption) {
+	t.Column(name, new(activerecord.String), opts...)
+}
+
+func (t *Table) Float64(name string, opts ...ColumnOption) {
+	t.Column(name, new(activerecord.Float64), opts...)
+}
+
+func (t *Table) Boolean(name string, opts ...ColumnOption) {
+	t.Column(name, new(activerecord.Boolean), opts...)
+}
+
+func (t *Table) DateTime(name string, opts ...ColumnOption) {
+	t.Column(name, new(activerecord.DateTime), opts...)
+}
+
+func (t *Table) Date(name string, opts ...ColumnOption) {
+	t.Column(name, new(activerecord.Date), opts...)
+}
+
+// References defines the "<target>_id" column referencing the target table. The
+// target is either a singular or a plural name of the table, e.g. "owner" and
+// "owners" both reference "owners" table.
+func (t *Table) References(target string, opts ...ColumnOption) {
+	c := newColumn(referenceColumn(target), new(activerecord.Int64), opts)
+	if c.foreignKey != nil {
+		c.foreignKey.Table = t.name
+		c.foreignKey.Target = referenceTable(target)
+		c.foreignKey.Column = c.Name
+		if c.foreignKey.PrimaryKey == "" {
+			c.foreignKey.PrimaryKey = "id"
+		}
+	}
+	t.columns = append(t.columns, c)
+}
+
+// CheckConstraint defines the check constraint of the table:
+//
+//	t.CheckConstraint("price >= 0", migration.CheckName("price_nonneg"))
+func (t *Table) CheckConstraint(expression string, opts ...CheckOption) {
+	t.checks = append(t.checks, *newCheckConstraint(t.name, expression, opts))
+}
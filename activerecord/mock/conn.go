@@ -0,0 +1,269 @@
+package mock
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/activegraph/activegraph/activerecord"
+	"github.com/activegraph/activegraph/activerecord/ansi"
+	"github.com/activegraph/activegraph/activesupport"
+)
+
+func init() {
+	activerecord.RegisterConnectionAdapter("mock", Connect)
+}
+
+// Conn is the connection to the mock of the database.
+type Conn struct {
+	mock *Mock
+	tx   bool
+}
+
+// Connect returns a new connection to the mock created with New for the
+// configured database.
+func Connect(conf activerecord.DatabaseConfig) (activerecord.Conn, error) {
+	m, ok := lookup(conf.Database)
+	if !ok {
+		return nil, fmt.Errorf("mock: database %q is not defined", conf.Database)
+	}
+	return &Conn{mock: m}, nil
+}
+
+// Verify always succeeds, since there is no database.
+func (c *Conn) Verify(ctx context.Context) error {
+	return nil
+}
+
+// Capabilities returns capabilities of the mocked database.
+func (c *Conn) Capabilities() activerecord.Capabilities {
+	c.mock.mu.Lock()
+	defer c.mock.mu.Unlock()
+	return c.mock.Capabilities
+}
+
+func (c *Conn) Close() error {
+	return nil
+}
+
+// exec matches the statement with the next expectation and logs it.
+func (c *Conn) exec(ctx context.Context, k kind, sql string, args []interface{}) (
+	e *expectation, err error,
+) {
+	done := activerecord.LogQuery(ctx, sql, args)
+	defer func() {
+		var rows int64
+		if e != nil {
+			rows = int64(len(e.rows))
+			if e.rowsAffected != nil {
+				rows = *e.rowsAffected
+			}
+		}
+		done(rows, err)
+	}()
+
+	if err = ctx.Err(); err != nil {
+		return nil, err
+	}
+	if e, err = c.mock.match(k, Statement{SQL: sql, Args: args}); err != nil {
+		return nil, err
+	}
+	return e, e.err
+}
+
+func (c *Conn) BeginTransaction(
+	ctx context.Context, opts *activerecord.TransactionOptions,
+) (activerecord.Conn, error) {
+	stmt := ansi.BeginTransactionStmt(opts)
+	if c.tx {
+		stmt = "SAVEPOINT"
+	}
+	if _, err := c.exec(ctx, beginKind, stmt, nil); err != nil {
+		return nil, err
+	}
+	return &Conn{mock: c.mock, tx: true}, nil
+}
+
+func (c *Conn) CommitTransaction(ctx context.Context) error {
+	if !c.tx {
+		return fmt.Errorf("no transaction is open")
+	}
+	_, err := c.exec(ctx, commitKind, "COMMIT TRANSACTION", nil)
+	return err
+}
+
+func (c *Conn) RollbackTransaction(ctx context.Context) error {
+	if !c.tx {
+		return fmt.Errorf("no transaction is open")
+	}
+	_, err := c.exec(ctx, rollbackKind, "ROLLBACK TRANSACTION", nil)
+	return err
+}
+
+func (c *Conn) ExecInsert(ctx context.Context, op *activerecord.InsertOperation) (
+	id interface{}, err error,
+) {
+	var (
+		columns = make([]string, len(op.ColumnValues))
+		binds   = make([]interface{}, len(op.ColumnValues))
+	)
+	for i, col := range op.ColumnValues {
+		columns[i], binds[i] = fmt.Sprintf("%q", col.Name), col.Value
+	}
+
+	stmt := fmt.Sprintf(`INSERT INTO %q (%s) VALUES (%s)`, op.TableName,
+		strings.Join(columns, ", "),
+		strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", "),
+	)
+	e, err := c.exec(ctx, execKind, stmt, binds)
+	if err != nil {
+		return nil, err
+	}
+	return e.id, nil
+}
+
+func (c *Conn) ExecUpdate(ctx context.Context, op *activerecord.UpdateOperation) error {
+	var (
+		sets  []string
+		binds []interface{}
+		pk    interface{}
+	)
+	for _, col := range op.ColumnValues {
+		if col.Name == op.PrimaryKey {
+			pk = col.Value
+			continue
+		}
+		sets = append(sets, fmt.Sprintf("%q = ?", col.Name))
+		binds = append(binds, col.Value)
+	}
+
+	stmt := fmt.Sprintf(`UPDATE %q SET %s WHERE %q = ?`,
+		op.TableName, strings.Join(sets, ", "), op.PrimaryKey,
+	)
+	e, err := c.exec(ctx, execKind, stmt, append(binds, pk))
+	if err != nil {
+		return err
+	}
+	if e.rowsAffected != nil && *e.rowsAffected != 1 {
+		return fmt.Errorf("expected single row affected, got %d rows affected", *e.rowsAffected)
+	}
+	return nil
+}
+
+func (c *Conn) ExecDelete(ctx context.Context, op *activerecord.DeleteOperation) error {
+	stmt := fmt.Sprintf(`DELETE FROM %q WHERE %q = ?`, op.TableName, op.PrimaryKey)
+	_, err := c.exec(ctx, execKind, stmt, []interface{}{op.Value})
+	return err
+}
+
+func (c *Conn) ExecQuery(
+	ctx context.Context, op *activerecord.QueryOperation, cb func(activesupport.Hash) bool,
+) error {
+	e, err := c.exec(ctx, queryKind, op.Text, op.Args)
+	if err != nil {
+		return err
+	}
+	for _, row := range e.rows {
+		if !cb(resultRow(row, op.Columns)) {
+			break
+		}
+	}
+	return nil
+}
+
+// resultRow returns the row with the selected columns of the query. Adapters
+// name values by the selected columns, so the value of the "authors.name"
+// column is taken from the "name" key, unless the row has the qualified key.
+func resultRow(row activesupport.Hash, columns []string) activesupport.Hash {
+	if len(columns) == 0 {
+		return row.Copy()
+	}
+
+	result := make(activesupport.Hash, len(columns))
+	for _, column := range columns {
+		if value, ok := row[column]; ok {
+			result[column] = value
+		} else if i := strings.LastIndex(column, "."); i >= 0 {
+			result[column] = row[column[i+1:]]
+		}
+	}
+	return result
+}
+
+// ExecStatement returns the number of affected rows specified by the expectation.
+func (c *Conn) ExecStatement(ctx context.Context, op *activerecord.QueryOperation) (
+	int64, error,
+) {
+	e, err := c.exec(ctx, execKind, op.Text, op.Args)
+	if err != nil {
+		return 0, err
+	}
+	if e.rowsAffected == nil {
+		return 0, nil
+	}
+	return *e.rowsAffected, nil
+}
+
+// ExecExplain matches the "EXPLAIN" statement with the query expectation, each
+// row of the expectation is rendered as a line of the plan, where values are
+// separated with "|" in the order of sorted column names.
+func (c *Conn) ExecExplain(ctx context.Context, op *activerecord.ExplainOperation) (
+	string, error,
+) {
+	var buf strings.Builder
+	buf.WriteString("EXPLAIN ")
+	for _, option := range op.Options {
+		fmt.Fprintf(&buf, "%s ", option)
+	}
+	buf.WriteString(op.Query.Text)
+
+	e, err := c.exec(ctx, queryKind, buf.String(), op.Query.Args)
+	if err != nil {
+		return "", err
+	}
+
+	lines := make([]string, len(e.rows))
+	for i, row := range e.rows {
+		keys := make([]string, 0, len(row))
+		for key := range row {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		cols := make([]string, len(keys))
+		for j, key := range keys {
+			cols[j] = fmt.Sprint(row[key])
+		}
+		lines[i] = strings.Join(cols, " | ")
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// CreateTable defines the table in the mock, statements are not executed.
+func (c *Conn) CreateTable(ctx context.Context, tb *activerecord.Table) error {
+	c.mock.DefineTable(tb.Name(), tb.Columns()...)
+	return nil
+}
+
+// AddForeignKey does nothing, foreign keys are not used by the mock.
+func (c *Conn) AddForeignKey(ctx context.Context, owner, target string) error {
+	return nil
+}
+
+func (c *Conn) ColumnType(typeName string) (activerecord.Type, error) {
+	return new(ansi.SchemaStatements).ColumnType(typeName)
+}
+
+func (c *Conn) ColumnDefinitions(ctx context.Context, tableName string) (
+	[]activerecord.ColumnDefinition, error,
+) {
+	c.mock.mu.Lock()
+	defer c.mock.mu.Unlock()
+
+	columns, ok := c.mock.tables[tableName]
+	if !ok {
+		return nil, activerecord.ErrTableNotExist{TableName: tableName}
+	}
+	return columns, nil
+}
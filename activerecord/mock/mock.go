@@ -0,0 +1,390 @@
+// Package mock implements the database adapter, which executes statements
+// against expectations defined by tests instead of the database. It allows to
+// test queries generated by relations without running the database:
+//
+//	m := mock.New(t.Name())
+//	m.DefineTable("authors",
+//		activerecord.ColumnDefinition{Name: "id", Type: new(activerecord.Int64), IsPrimaryKey: true},
+//		activerecord.ColumnDefinition{Name: "name", Type: new(activerecord.String)},
+//	)
+//
+//	activerecord.EstablishConnection(activerecord.DatabaseConfig{
+//		Adapter: "mock", Database: t.Name(),
+//	})
+//	defer activerecord.RemoveConnection("primary")
+//
+//	m.ExpectQuery(`SELECT authors.id, authors.name FROM "authors" WHERE (name = ?)`).
+//		WithArgs("Ada").
+//		Return(activesupport.Hash{"id": 1, "name": "Ada"})
+//
+//	Author.Where("name", "Ada").ToA()
+//	require.NoError(t, m.ExpectationsWereMet())
+//
+// Expectations are matched in the order of definition, statements that do not
+// match the next expectation fail with ErrUnexpected. Tables are not created by
+// migrations, define them with DefineTable.
+package mock
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/activegraph/activegraph/activerecord"
+	"github.com/activegraph/activegraph/activesupport"
+)
+
+// QueryMatcher reports whether the executed statement matches the statement of
+// the expectation.
+type QueryMatcher func(expected, actual string) bool
+
+var (
+	// QueryMatcherEqual matches statements equal to the expected ones, subsequent
+	// whitespaces are treated as a single space.
+	QueryMatcherEqual QueryMatcher = func(expected, actual string) bool {
+		return normalize(expected) == normalize(actual)
+	}
+
+	// QueryMatcherRegexp matches statements with the expected regular expression.
+	QueryMatcherRegexp QueryMatcher = func(expected, actual string) bool {
+		re, err := regexp.Compile(expected)
+		return err == nil && re.MatchString(normalize(actual))
+	}
+)
+
+func normalize(stmt string) string {
+	return strings.Join(strings.Fields(stmt), " ")
+}
+
+// Statement is the statement executed by the mock connection.
+type Statement struct {
+	SQL  string
+	Args []interface{}
+}
+
+// ErrUnexpected is returned when the executed statement does not match the next
+// expectation.
+type ErrUnexpected struct {
+	Statement Statement
+	Message   string
+}
+
+func (e *ErrUnexpected) Error() string {
+	return fmt.Sprintf("mock: unexpected statement %q with args %v, %s",
+		e.Statement.SQL, e.Statement.Args, e.Message)
+}
+
+type kind int
+
+const (
+	queryKind kind = iota
+	execKind
+	beginKind
+	commitKind
+	rollbackKind
+)
+
+func (k kind) String() string {
+	switch k {
+	case queryKind:
+		return "query"
+	case execKind:
+		return "exec"
+	case beginKind:
+		return "begin"
+	case commitKind:
+		return "commit"
+	default:
+		return "rollback"
+	}
+}
+
+type expectation struct {
+	kind     kind
+	sql      string
+	args     []interface{}
+	withArgs bool
+
+	rows         []activesupport.Hash
+	id           interface{}
+	rowsAffected *int64
+	err          error
+}
+
+func (e *expectation) String() string {
+	if e.sql == "" {
+		return e.kind.String()
+	}
+	if e.withArgs {
+		return fmt.Sprintf("%s %q with args %v", e.kind, e.sql, e.args)
+	}
+	return fmt.Sprintf("%s %q", e.kind, e.sql)
+}
+
+// ExpectedQuery is the expectation of the query.
+type ExpectedQuery struct {
+	e *expectation
+}
+
+// WithArgs specifies arguments of the query, when not called arguments are not
+// verified.
+func (q *ExpectedQuery) WithArgs(args ...interface{}) *ExpectedQuery {
+	q.e.args, q.e.withArgs = args, true
+	return q
+}
+
+// Return specifies rows returned by the query, keys of rows are names of the
+// result columns. Table names of the selected columns could be omitted, e.g. the
+// "authors.name" column is taken from the "name" key.
+func (q *ExpectedQuery) Return(rows ...activesupport.Hash) *ExpectedQuery {
+	q.e.rows = rows
+	return q
+}
+
+// ReturnError specifies the error returned by the query.
+func (q *ExpectedQuery) ReturnError(err error) *ExpectedQuery {
+	q.e.err = err
+	return q
+}
+
+// ExpectedExec is the expectation of the insert, update or delete statement.
+type ExpectedExec struct {
+	e *expectation
+}
+
+// WithArgs specifies arguments of the statement, when not called arguments are
+// not verified.
+func (x *ExpectedExec) WithArgs(args ...interface{}) *ExpectedExec {
+	x.e.args, x.e.withArgs = args, true
+	return x
+}
+
+// Return specifies the number of rows affected by the statement.
+func (x *ExpectedExec) Return(rowsAffected int64) *ExpectedExec {
+	x.e.rowsAffected = &rowsAffected
+	return x
+}
+
+// ReturnID specifies the primary key of the inserted row.
+func (x *ExpectedExec) ReturnID(id interface{}) *ExpectedExec {
+	x.e.id = id
+	return x
+}
+
+// ReturnError specifies the error returned by the statement.
+func (x *ExpectedExec) ReturnError(err error) *ExpectedExec {
+	x.e.err = err
+	return x
+}
+
+// ExpectedTransaction is the expectation of the beginning, commit or rollback
+// of the transaction.
+type ExpectedTransaction struct {
+	e *expectation
+}
+
+// ReturnError specifies the error returned by the transaction statement.
+func (x *ExpectedTransaction) ReturnError(err error) *ExpectedTransaction {
+	x.e.err = err
+	return x
+}
+
+// Mock is the database of mock connections, which executes statements against
+// defined expectations.
+type Mock struct {
+	// QueryMatcher matches executed statements with expectations, by default
+	// QueryMatcherEqual is used.
+	QueryMatcher QueryMatcher
+
+	// Capabilities are capabilities of the mocked database, by default
+	// activerecord.DefaultCapabilities are used.
+	Capabilities activerecord.Capabilities
+
+	mu           sync.Mutex
+	expectations []*expectation
+	statements   []Statement
+	tables       map[string][]activerecord.ColumnDefinition
+}
+
+var (
+	mocksMu sync.Mutex
+	mocks   = make(map[string]*Mock)
+)
+
+// New returns a new mock of the database with the given name, connections with
+// the same database name are established to the returned mock. Previously
+// created mock with the same name is replaced.
+func New(database string) *Mock {
+	m := &Mock{
+		QueryMatcher: QueryMatcherEqual,
+		Capabilities: activerecord.DefaultCapabilities,
+		tables:       make(map[string][]activerecord.ColumnDefinition),
+	}
+
+	mocksMu.Lock()
+	defer mocksMu.Unlock()
+	mocks[database] = m
+	return m
+}
+
+func lookup(database string) (*Mock, bool) {
+	mocksMu.Lock()
+	defer mocksMu.Unlock()
+	m, ok := mocks[database]
+	return m, ok
+}
+
+// DefineTable defines columns of the table, which are used by relations to
+// build queries.
+func (m *Mock) DefineTable(name string, columns ...activerecord.ColumnDefinition) {
+	columns = append([]activerecord.ColumnDefinition(nil), columns...)
+	sort.SliceStable(columns, func(i, j int) bool {
+		if columns[i].IsPrimaryKey != columns[j].IsPrimaryKey {
+			return columns[i].IsPrimaryKey
+		}
+		return columns[i].Name < columns[j].Name
+	})
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tables[name] = columns
+}
+
+func (m *Mock) expect(e *expectation) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.expectations = append(m.expectations, e)
+}
+
+// ExpectQuery adds the expectation of the query.
+func (m *Mock) ExpectQuery(sql string) *ExpectedQuery {
+	e := &expectation{kind: queryKind, sql: sql}
+	m.expect(e)
+	return &ExpectedQuery{e}
+}
+
+// ExpectExec adds the expectation of the insert, update or delete statement.
+// Statements of records persistence are built with bind arguments:
+//
+//	INSERT INTO "authors" ("name") VALUES (?)
+//	UPDATE "authors" SET "name" = ? WHERE "id" = ?
+//	DELETE FROM "authors" WHERE "id" = ?
+func (m *Mock) ExpectExec(sql string) *ExpectedExec {
+	e := &expectation{kind: execKind, sql: sql}
+	m.expect(e)
+	return &ExpectedExec{e}
+}
+
+// ExpectBegin adds the expectation of the beginning of the transaction.
+func (m *Mock) ExpectBegin() *ExpectedTransaction {
+	e := &expectation{kind: beginKind}
+	m.expect(e)
+	return &ExpectedTransaction{e}
+}
+
+// ExpectCommit adds the expectation of the transaction commit.
+func (m *Mock) ExpectCommit() *ExpectedTransaction {
+	e := &expectation{kind: commitKind}
+	m.expect(e)
+	return &ExpectedTransaction{e}
+}
+
+// ExpectRollback adds the expectation of the transaction rollback.
+func (m *Mock) ExpectRollback() *ExpectedTransaction {
+	e := &expectation{kind: rollbackKind}
+	m.expect(e)
+	return &ExpectedTransaction{e}
+}
+
+// ExpectationsWereMet returns an error when some of expectations were not met.
+func (m *Mock) ExpectationsWereMet() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.expectations) == 0 {
+		return nil
+	}
+
+	unmet := make([]string, len(m.expectations))
+	for i, e := range m.expectations {
+		unmet[i] = e.String()
+	}
+	return fmt.Errorf("mock: expectations were not met: %s", strings.Join(unmet, ", "))
+}
+
+// Statements returns all statements executed by connections of the mock.
+func (m *Mock) Statements() []Statement {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Statement(nil), m.statements...)
+}
+
+// Reset removes all expectations and executed statements.
+func (m *Mock) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.expectations, m.statements = nil, nil
+}
+
+// match records the statement and matches it with the next expectation, the
+// matched expectation is removed.
+func (m *Mock) match(k kind, stmt Statement) (*expectation, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.statements = append(m.statements, stmt)
+
+	if len(m.expectations) == 0 {
+		return nil, &ErrUnexpected{Statement: stmt, Message: "all expectations were already met"}
+	}
+
+	e := m.expectations[0]
+	if e.kind != k {
+		return nil, &ErrUnexpected{Statement: stmt, Message: "expected " + e.String()}
+	}
+	if k == queryKind || k == execKind {
+		if !m.QueryMatcher(e.sql, stmt.SQL) {
+			return nil, &ErrUnexpected{Statement: stmt, Message: "expected " + e.String()}
+		}
+		if e.withArgs && !argsEqual(e.args, stmt.Args) {
+			return nil, &ErrUnexpected{Statement: stmt, Message: "expected " + e.String()}
+		}
+	}
+
+	m.expectations = m.expectations[1:]
+	return e, nil
+}
+
+func argsEqual(expected, actual []interface{}) bool {
+	if len(expected) != len(actual) {
+		return false
+	}
+	for i := range expected {
+		if !valuesEqual(expected[i], actual[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// valuesEqual compares values converted to the driver values, so the integer
+// arguments of different types are equal.
+func valuesEqual(expected, actual interface{}) bool {
+	if v, err := driver.DefaultParameterConverter.ConvertValue(expected); err == nil {
+		expected = v
+	}
+	if v, err := driver.DefaultParameterConverter.ConvertValue(actual); err == nil {
+		actual = v
+	}
+
+	if t1, ok := expected.(time.Time); ok {
+		t2, ok := actual.(time.Time)
+		return ok && t1.Equal(t2)
+	}
+	return reflect.DeepEqual(expected, actual)
+}
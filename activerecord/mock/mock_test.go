@@ -0,0 +1,171 @@
+package mock_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/activegraph/activegraph/activerecord"
+	"github.com/activegraph/activegraph/activerecord/mock"
+	. "github.com/activegraph/activegraph/activesupport"
+)
+
+func establishConnection(t *testing.T) *mock.Mock {
+	m := mock.New(t.Name())
+	m.DefineTable("authors",
+		activerecord.ColumnDefinition{Name: "id", Type: new(activerecord.Int64), IsPrimaryKey: true},
+		activerecord.ColumnDefinition{Name: "name", Type: new(activerecord.String)},
+	)
+
+	_, err := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter:  "mock",
+		Database: t.Name(),
+	})
+	require.NoError(t, err)
+	return m
+}
+
+func TestMock_Query(t *testing.T) {
+	m := establishConnection(t)
+	defer activerecord.RemoveConnection("primary")
+
+	Author := activerecord.New("author")
+
+	m.ExpectQuery(`SELECT authors.id, authors.name FROM "authors" WHERE (name = ?)`).
+		WithArgs("Ada").
+		Return(Hash{"id": 1, "name": "Ada"})
+
+	authors, err := Author.Where("name", "Ada").ToA()
+	require.NoError(t, err)
+	require.Len(t, authors, 1)
+	require.Equal(t, int64(1), authors[0].ID())
+	require.NoError(t, m.ExpectationsWereMet())
+}
+
+func TestMock_Exec(t *testing.T) {
+	m := establishConnection(t)
+	defer activerecord.RemoveConnection("primary")
+
+	Author := activerecord.New("author")
+
+	m.ExpectExec(`INSERT INTO "authors" ("name") VALUES (?)`).WithArgs("Ada").ReturnID(int64(1))
+	m.ExpectExec(`UPDATE "authors" SET "name" = ? WHERE "id" = ?`).WithArgs("Bob", 1)
+	m.ExpectExec(`DELETE FROM "authors" WHERE "id" = ?`).WithArgs(1)
+
+	author := Author.Create(Hash{"name": "Ada"})
+	require.NoError(t, author.Err())
+	require.Equal(t, int64(1), author.Unwrap().ID())
+
+	require.NoError(t, author.Unwrap().AssignAttribute("name", "Bob"))
+	_, err := author.Unwrap().Update()
+	require.NoError(t, err)
+
+	_, err = author.Unwrap().Delete()
+	require.NoError(t, err)
+	require.NoError(t, m.ExpectationsWereMet())
+
+	m.ExpectExec(`DELETE FROM "authors"`).Return(3)
+
+	rows, err := activerecord.Execute(context.TODO(), `DELETE FROM "authors"`)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), rows)
+	require.Len(t, m.Statements(), 4)
+}
+
+func TestMock_Transaction(t *testing.T) {
+	m := establishConnection(t)
+	defer activerecord.RemoveConnection("primary")
+
+	Author := activerecord.New("author")
+
+	m.ExpectBegin()
+	m.ExpectExec(`INSERT INTO "authors" ("name") VALUES (?)`).ReturnID(int64(1))
+	m.ExpectCommit()
+
+	err := activerecord.Transaction(context.TODO(), func(tx context.Context) error {
+		return Author.WithContext(tx).Create(Hash{"name": "Ada"}).Err()
+	})
+	require.NoError(t, err)
+
+	m.ExpectBegin()
+	m.ExpectExec(`INSERT INTO "authors" ("name") VALUES (?)`).ReturnError(errors.New("disk full"))
+	m.ExpectRollback()
+
+	err = activerecord.Transaction(context.TODO(), func(tx context.Context) error {
+		return Author.WithContext(tx).Create(Hash{"name": "Bob"}).Err()
+	})
+	require.EqualError(t, err, "disk full")
+	require.NoError(t, m.ExpectationsWereMet())
+}
+
+func TestMock_Unexpected(t *testing.T) {
+	m := establishConnection(t)
+	defer activerecord.RemoveConnection("primary")
+
+	Author := activerecord.New("author")
+
+	m.ExpectQuery(`SELECT COUNT(*) FROM "authors"`).Return(Hash{"count": 2})
+	m.ExpectQuery(`SELECT authors.id, authors.name FROM "authors"`)
+
+	_, err := Author.Where("name", "Ada").ToA()
+	require.ErrorAs(t, err, new(*mock.ErrUnexpected))
+	require.Error(t, m.ExpectationsWereMet())
+
+	m.Reset()
+	m.QueryMatcher = mock.QueryMatcherRegexp
+	m.ExpectQuery(`^SELECT COUNT\(\*\) FROM "authors"`).Return(Hash{"count": 2})
+
+	count, err := Author.Count()
+	require.NoError(t, err)
+	require.Equal(t, int64(2), count)
+
+	_, err = Author.Count()
+	require.ErrorAs(t, err, new(*mock.ErrUnexpected))
+}
+
+func TestMock_Capabilities(t *testing.T) {
+	m := establishConnection(t)
+	defer activerecord.RemoveConnection("primary")
+
+	m.Capabilities = activerecord.Capabilities{}
+	m.DefineTable("books",
+		activerecord.ColumnDefinition{Name: "id", Type: new(activerecord.Int64), IsPrimaryKey: true},
+		activerecord.ColumnDefinition{Name: "author_id", Type: new(activerecord.Int64)},
+		activerecord.ColumnDefinition{Name: "title", Type: new(activerecord.String)},
+	)
+
+	activerecord.New("author")
+	Book := activerecord.New("book", func(r *activerecord.R) {
+		r.BelongsTo("author")
+	})
+
+	m.ExpectQuery(`SELECT books.author_id, books.id, books.title FROM "books"`).Return(
+		Hash{"id": 1, "author_id": 1, "title": "Solaris"},
+		Hash{"id": 2, "author_id": 2, "title": "Foundation"},
+		Hash{"id": 3, "author_id": 1, "title": "The Cyberiad"},
+	)
+	m.ExpectQuery(`SELECT authors.id, authors.name FROM "authors" WHERE (id IN (?, ?))`).
+		WithArgs(1, 2).
+		Return(Hash{"id": 1, "name": "Stanislaw Lem"})
+
+	books, err := Book.Joins("author").ToA()
+	require.NoError(t, err)
+	require.Len(t, books, 2)
+	require.Equal(t, "Solaris", books[0].Attribute("title"))
+	require.Equal(t, "The Cyberiad", books[1].Attribute("title"))
+
+	author := books[1].Association("author")
+	require.NoError(t, author.Err())
+	require.Equal(t, "Stanislaw Lem", author.Unwrap().Attribute("name"))
+
+	m.ExpectQuery(`SELECT author_id FROM "books" GROUP BY author_id`).Return(
+		Hash{"author_id": 1}, Hash{"author_id": 2},
+	)
+
+	count, err := Book.Group("author_id").Count()
+	require.NoError(t, err)
+	require.Equal(t, int64(2), count)
+	require.NoError(t, m.ExpectationsWereMet())
+}
@@ -0,0 +1,341 @@
+package activerecord
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/activegraph/activegraph/activesupport"
+)
+
+// msgpackTimestamp is the extension type of timestamps in MessagePack.
+const msgpackTimestamp = -1
+
+var errMsgpackShort = errors.New("msgpack: unexpected end of data")
+
+// appendMsgpack appends the MessagePack encoding of the value to b. Keys of maps
+// are sorted, so the encoding is stable.
+func appendMsgpack(b []byte, value interface{}) ([]byte, error) {
+	switch value := value.(type) {
+	case nil:
+		return append(b, 0xc0), nil
+	case bool:
+		if value {
+			return append(b, 0xc3), nil
+		}
+		return append(b, 0xc2), nil
+	case int:
+		return appendMsgpackInt(b, int64(value)), nil
+	case int8:
+		return appendMsgpackInt(b, int64(value)), nil
+	case int16:
+		return appendMsgpackInt(b, int64(value)), nil
+	case int32:
+		return appendMsgpackInt(b, int64(value)), nil
+	case int64:
+		return appendMsgpackInt(b, value), nil
+	case uint:
+		return appendMsgpackUint(b, uint64(value)), nil
+	case uint8:
+		return appendMsgpackUint(b, uint64(value)), nil
+	case uint16:
+		return appendMsgpackUint(b, uint64(value)), nil
+	case uint32:
+		return appendMsgpackUint(b, uint64(value)), nil
+	case uint64:
+		return appendMsgpackUint(b, value), nil
+	case float32:
+		return appendMsgpackFloat(b, float64(value)), nil
+	case float64:
+		return appendMsgpackFloat(b, value), nil
+	case string:
+		return append(appendMsgpackLen(b, len(value), 0xa0, 32, 0xd9), value...), nil
+	case []byte:
+		return append(appendMsgpackLen(b, len(value), 0, 0, 0xc4), value...), nil
+	case time.Time:
+		// Timestamp 96 format: fixed length extension of nanoseconds and seconds.
+		b = append(b, 0xc7, 12, byte(0xff&msgpackTimestamp))
+		b = appendUint32(b, uint32(value.Nanosecond()))
+		return appendUint64(b, uint64(value.Unix())), nil
+	case []interface{}:
+		b = appendMsgpackLen(b, len(value), 0x90, 16, 0xdc)
+		for _, elem := range value {
+			var err error
+			if b, err = appendMsgpack(b, elem); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	case activesupport.Hash:
+		return appendMsgpack(b, map[string]interface{}(value))
+	case map[string]interface{}:
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		b = appendMsgpackLen(b, len(value), 0x80, 16, 0xde)
+		for _, key := range keys {
+			var err error
+			if b, err = appendMsgpack(b, key); err != nil {
+				return nil, err
+			}
+			if b, err = appendMsgpack(b, value[key]); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	default:
+		return nil, fmt.Errorf("msgpack: unsupported type %T", value)
+	}
+}
+
+func appendMsgpackInt(b []byte, v int64) []byte {
+	switch {
+	case v >= 0:
+		return appendMsgpackUint(b, uint64(v))
+	case v >= -32:
+		return append(b, byte(v))
+	case v >= math.MinInt8:
+		return append(b, 0xd0, byte(v))
+	case v >= math.MinInt16:
+		return appendUint16(append(b, 0xd1), uint16(v))
+	case v >= math.MinInt32:
+		return appendUint32(append(b, 0xd2), uint32(v))
+	default:
+		return appendUint64(append(b, 0xd3), uint64(v))
+	}
+}
+
+func appendMsgpackUint(b []byte, v uint64) []byte {
+	switch {
+	case v <= math.MaxInt8:
+		return append(b, byte(v))
+	case v <= math.MaxUint8:
+		return append(b, 0xcc, byte(v))
+	case v <= math.MaxUint16:
+		return appendUint16(append(b, 0xcd), uint16(v))
+	case v <= math.MaxUint32:
+		return appendUint32(append(b, 0xce), uint32(v))
+	default:
+		return appendUint64(append(b, 0xcf), v)
+	}
+}
+
+func appendMsgpackFloat(b []byte, v float64) []byte {
+	return appendUint64(append(b, 0xcb), math.Float64bits(v))
+}
+
+// appendMsgpackLen appends the header of the string, binary, array or map of
+// length n. Lengths less than fixmax are encoded into the fix prefix, otherwise
+// the header starts with code of 8-bit (strings and binaries) or 16-bit (arrays
+// and maps) length followed by the codes of longer lengths.
+func appendMsgpackLen(b []byte, n int, fix byte, fixmax int, code byte) []byte {
+	if n < fixmax {
+		return append(b, fix|byte(n))
+	}
+	if code == 0xd9 || code == 0xc4 {
+		if n <= math.MaxUint8 {
+			return append(b, code, byte(n))
+		}
+		code++
+	}
+	if n <= math.MaxUint16 {
+		return appendUint16(append(b, code), uint16(n))
+	}
+	return appendUint32(append(b, code+1), uint32(n))
+}
+
+// readMsgpack decodes the MessagePack value from b and returns the remaining
+// bytes. Integers are decoded as int64, floats as float64, maps as Hash and
+// arrays as []interface{}.
+func readMsgpack(b []byte) (interface{}, []byte, error) {
+	if len(b) == 0 {
+		return nil, nil, errMsgpackShort
+	}
+
+	code, b := b[0], b[1:]
+	switch {
+	case code <= 0x7f:
+		return int64(code), b, nil
+	case code >= 0xe0:
+		return int64(int8(code)), b, nil
+	case code&0xe0 == 0xa0:
+		return readMsgpackString(b, int(code&0x1f))
+	case code&0xf0 == 0x90:
+		return readMsgpackArray(b, int(code&0x0f))
+	case code&0xf0 == 0x80:
+		return readMsgpackMap(b, int(code&0x0f))
+	}
+
+	switch code {
+	case 0xc0:
+		return nil, b, nil
+	case 0xc2:
+		return false, b, nil
+	case 0xc3:
+		return true, b, nil
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		u, b, err := readMsgpackUint(b, 1<<(code-0xcc))
+		if err != nil {
+			return nil, nil, err
+		}
+		if u > math.MaxInt64 {
+			return u, b, nil
+		}
+		return int64(u), b, nil
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (code - 0xd0)
+		u, b, err := readMsgpackUint(b, size)
+		if err != nil {
+			return nil, nil, err
+		}
+		// Extend the sign of the integer.
+		shift := 64 - 8*size
+		return int64(u<<shift) >> shift, b, nil
+	case 0xca:
+		u, b, err := readMsgpackUint(b, 4)
+		return float64(math.Float32frombits(uint32(u))), b, err
+	case 0xcb:
+		u, b, err := readMsgpackUint(b, 8)
+		return math.Float64frombits(u), b, err
+	case 0xd9, 0xda, 0xdb:
+		n, b, err := readMsgpackUint(b, 1<<(code-0xd9))
+		if err != nil {
+			return nil, nil, err
+		}
+		return readMsgpackString(b, int(n))
+	case 0xc4, 0xc5, 0xc6:
+		n, b, err := readMsgpackUint(b, 1<<(code-0xc4))
+		if err != nil {
+			return nil, nil, err
+		}
+		if uint64(len(b)) < n {
+			return nil, nil, errMsgpackShort
+		}
+		return append([]byte(nil), b[:n]...), b[n:], nil
+	case 0xdc, 0xdd:
+		n, b, err := readMsgpackUint(b, 2<<(code-0xdc))
+		if err != nil {
+			return nil, nil, err
+		}
+		return readMsgpackArray(b, int(n))
+	case 0xde, 0xdf:
+		n, b, err := readMsgpackUint(b, 2<<(code-0xde))
+		if err != nil {
+			return nil, nil, err
+		}
+		return readMsgpackMap(b, int(n))
+	case 0xd6:
+		return readMsgpackExt(b, 4)
+	case 0xd7:
+		return readMsgpackExt(b, 8)
+	case 0xc7:
+		n, b, err := readMsgpackUint(b, 1)
+		if err != nil {
+			return nil, nil, err
+		}
+		return readMsgpackExt(b, int(n))
+	default:
+		return nil, nil, fmt.Errorf("msgpack: unsupported code 0x%x", code)
+	}
+}
+
+func readMsgpackUint(b []byte, size int) (uint64, []byte, error) {
+	if len(b) < size {
+		return 0, nil, errMsgpackShort
+	}
+	var u uint64
+	for _, c := range b[:size] {
+		u = u<<8 | uint64(c)
+	}
+	return u, b[size:], nil
+}
+
+func readMsgpackString(b []byte, n int) (interface{}, []byte, error) {
+	if len(b) < n {
+		return nil, nil, errMsgpackShort
+	}
+	return string(b[:n]), b[n:], nil
+}
+
+func readMsgpackArray(b []byte, n int) (interface{}, []byte, error) {
+	if len(b) < n {
+		return nil, nil, errMsgpackShort
+	}
+	values := make([]interface{}, n)
+	for i := range values {
+		var err error
+		if values[i], b, err = readMsgpack(b); err != nil {
+			return nil, nil, err
+		}
+	}
+	return values, b, nil
+}
+
+func readMsgpackMap(b []byte, n int) (interface{}, []byte, error) {
+	if len(b) < n {
+		return nil, nil, errMsgpackShort
+	}
+	h := make(activesupport.Hash, n)
+	for i := 0; i < n; i++ {
+		var (
+			key, value interface{}
+			err        error
+		)
+		if key, b, err = readMsgpack(b); err != nil {
+			return nil, nil, err
+		}
+		keystr, ok := key.(string)
+		if !ok {
+			return nil, nil, fmt.Errorf("msgpack: unsupported key type %T", key)
+		}
+		if value, b, err = readMsgpack(b); err != nil {
+			return nil, nil, err
+		}
+		h[keystr] = value
+	}
+	return h, b, nil
+}
+
+// readMsgpackExt decodes the extension of the given size, only timestamps are
+// supported.
+func readMsgpackExt(b []byte, size int) (interface{}, []byte, error) {
+	if len(b) < size+1 {
+		return nil, nil, errMsgpackShort
+	}
+	typ, data, b := int8(b[0]), b[1:size+1], b[size+1:]
+	if typ != msgpackTimestamp {
+		return nil, nil, fmt.Errorf("msgpack: unsupported extension type %d", typ)
+	}
+
+	switch size {
+	case 4:
+		return time.Unix(int64(binary.BigEndian.Uint32(data)), 0).UTC(), b, nil
+	case 8:
+		u := binary.BigEndian.Uint64(data)
+		return time.Unix(int64(u&0x3ffffffff), int64(u>>34)).UTC(), b, nil
+	case 12:
+		nsec := binary.BigEndian.Uint32(data[:4])
+		sec := binary.BigEndian.Uint64(data[4:])
+		return time.Unix(int64(sec), int64(nsec)).UTC(), b, nil
+	default:
+		return nil, nil, fmt.Errorf("msgpack: invalid timestamp length %d", size)
+	}
+}
+
+func appendUint16(b []byte, v uint16) []byte {
+	return append(b, byte(v>>8), byte(v))
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	return append(b, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
+
+func appendUint64(b []byte, v uint64) []byte {
+	return appendUint32(appendUint32(b, uint32(v>>32)), uint32(v))
+}
@@ -0,0 +1,44 @@
+package activerecord
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/activegraph/activegraph/activesupport"
+)
+
+func TestMsgpack(t *testing.T) {
+	values := []interface{}{
+		nil, true, false,
+		int64(0), int64(127), int64(128), int64(-1), int64(-33), int64(-129),
+		int64(70000), int64(-70000), int64(1 << 40), int64(-1 << 40),
+		1.5, "", "solaris", strings.Repeat("x", 300), strings.Repeat("y", 70000),
+		[]byte{1, 2, 3},
+		time.Date(1961, 6, 1, 12, 30, 0, 500, time.UTC),
+		[]interface{}{int64(1), "two", nil},
+		activesupport.Hash{"title": "Solaris", "year": int64(1961)},
+	}
+
+	for _, value := range values {
+		b, err := appendMsgpack(nil, value)
+		require.NoError(t, err)
+
+		decoded, rest, err := readMsgpack(b)
+		require.NoError(t, err)
+		require.Empty(t, rest)
+		require.Equal(t, value, decoded)
+	}
+
+	b, err := appendMsgpack(nil, int(42))
+	require.NoError(t, err)
+	require.Equal(t, []byte{42}, b)
+
+	_, err = appendMsgpack(nil, struct{}{})
+	require.Error(t, err)
+
+	_, _, err = readMsgpack([]byte{0xa5, 's'})
+	require.ErrorIs(t, err, errMsgpackShort)
+}
@@ -0,0 +1,852 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/activegraph/activegraph/activerecord"
+	"github.com/activegraph/activegraph/activerecord/ansi"
+	"github.com/activegraph/activegraph/activerecord/migration"
+	. "github.com/activegraph/activegraph/activesupport"
+)
+
+func init() {
+	activerecord.RegisterConnectionAdapter("mysql", Connect)
+}
+
+// duplicateEntry is an error number of the unique constraint violation.
+const duplicateEntry = 1062
+
+// lockDeadlock is an error number of the deadlock, which MySQL reports when the
+// concurrent transactions could not be serialized.
+const lockDeadlock = 1213
+
+// queryTimeout is an error number of the statement interrupted by exceeding the
+// "max_execution_time".
+const queryTimeout = 3024
+
+// Error numbers of writes rejected by the read-only server, which happens when
+// the database failed over and the primary server became a replica.
+const (
+	optionPreventsStatement = 1290
+	readOnlyMode            = 1836
+)
+
+type Conn struct {
+	ansi.ConnectionStatements
+	ansi.SchemaStatements
+	ansi.DatabaseStatements
+
+	db *sql.DB
+	tx *sql.Tx
+
+	// Nested transactions are implemented with savepoints, depth is used to
+	// name savepoints uniquely within a transaction.
+	savepoint string
+	depth     int
+}
+
+// dataSourceName returns a connection string to the database.
+func dataSourceName(conf activerecord.DatabaseConfig) string {
+	dsn := mysql.NewConfig()
+	dsn.User = conf.Username
+	dsn.Passwd = conf.Password
+	dsn.Net = "tcp"
+	dsn.Addr = conf.Host
+	dsn.DBName = conf.Database
+	dsn.ParseTime = true
+	// Report matched rows instead of changed rows, so updates without changes
+	// are not treated as missing rows.
+	dsn.ClientFoundRows = true
+	// Limit read-only statements on the server side as well, MySQL does not
+	// support timeouts of other statements.
+	dsn.Params = make(map[string]string, len(conf.Options)+1)
+	for name, value := range conf.Options {
+		dsn.Params[name] = value
+	}
+	if conf.StatementTimeout > 0 {
+		dsn.Params["max_execution_time"] = strconv.FormatInt(conf.StatementTimeout.Milliseconds(), 10)
+	}
+	return dsn.FormatDSN()
+}
+
+func Connect(conf activerecord.DatabaseConfig) (activerecord.Conn, error) {
+	db, err := sql.Open("mysql", dataSourceName(conf))
+	if err != nil {
+		return nil, err
+	}
+	ansi.ConfigurePool(db, conf)
+
+	if err = db.PingContext(context.Background()); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Conn{
+		db:                   db,
+		ConnectionStatements: db,
+		SchemaStatements:     ansi.SchemaStatements{Conn: db},
+		DatabaseStatements:   ansi.DatabaseStatements{Conn: db},
+	}, nil
+}
+
+// Verify verifies the connection to the database with a ping.
+func (c *Conn) Verify(ctx context.Context) error {
+	return c.translateErr(c.db.PingContext(ctx))
+}
+
+func (c *Conn) Close() error {
+	// Savepoints are released or rolled back on commit and rollback.
+	if c.savepoint != "" {
+		return nil
+	}
+	if c.tx != nil {
+		err := c.tx.Rollback()
+		if errors.Is(err, sql.ErrTxDone) {
+			return nil
+		}
+		return err
+	}
+	return c.db.Close()
+}
+
+// BeginTransaction begins a new transaction or creates a savepoint within the
+// open transaction. Options of the transaction are ignored for savepoints.
+func (c *Conn) BeginTransaction(
+	ctx context.Context, opts *activerecord.TransactionOptions,
+) (activerecord.Conn, error) {
+	if c.tx != nil {
+		savepoint := fmt.Sprintf("active_record_%d", c.depth+1)
+		if _, err := ansi.ExecContext(ctx, c.tx, "SAVEPOINT "+savepoint); err != nil {
+			return nil, err
+		}
+		return &Conn{
+			db:                   c.db,
+			tx:                   c.tx,
+			savepoint:            savepoint,
+			depth:                c.depth + 1,
+			ConnectionStatements: c.tx,
+			SchemaStatements:     ansi.SchemaStatements{Conn: c.tx},
+			DatabaseStatements:   ansi.DatabaseStatements{Conn: c.tx},
+		}, nil
+	}
+
+	txOpts, err := ansi.TxOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	done := activerecord.LogQuery(ctx, ansi.BeginTransactionStmt(opts), nil)
+	tx, err := c.db.BeginTx(ctx, txOpts)
+	done(0, err)
+	if err != nil {
+		return nil, c.translateErr(err)
+	}
+
+	return &Conn{
+		db:                   c.db,
+		tx:                   tx,
+		ConnectionStatements: tx,
+		SchemaStatements:     ansi.SchemaStatements{Conn: tx},
+		DatabaseStatements:   ansi.DatabaseStatements{Conn: tx},
+	}, nil
+}
+
+func (c *Conn) CommitTransaction(ctx context.Context) error {
+	if c.tx == nil {
+		return fmt.Errorf("no transaction is open")
+	}
+	if c.savepoint != "" {
+		_, err := ansi.ExecContext(ctx, c.tx, "RELEASE SAVEPOINT "+c.savepoint)
+		return err
+	}
+
+	done := activerecord.LogQuery(ctx, "COMMIT TRANSACTION", nil)
+	err := c.tx.Commit()
+	done(0, err)
+	return c.translateErr(err)
+}
+
+func (c *Conn) RollbackTransaction(ctx context.Context) error {
+	if c.tx == nil {
+		return fmt.Errorf("no transaction is open")
+	}
+	if c.savepoint != "" {
+		_, err := ansi.ExecContext(ctx, c.tx, "ROLLBACK TO SAVEPOINT "+c.savepoint)
+		return err
+	}
+
+	done := activerecord.LogQuery(ctx, "ROLLBACK TRANSACTION", nil)
+	err := c.tx.Rollback()
+	done(0, err)
+	return err
+}
+
+// quote returns the identifier quoted with backticks.
+func quote(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+// ExecInsert inserts a new row and returns the last inserted identifier. When
+// the OnDuplicate of the operation is set, conflicting rows are either skipped
+// with "INSERT IGNORE" or updated with "ON DUPLICATE KEY UPDATE" clause.
+func (c *Conn) ExecInsert(ctx context.Context, op *activerecord.InsertOperation) (
+	id interface{}, err error,
+) {
+	var (
+		columns      = make([]string, len(op.ColumnValues))
+		placeholders = make([]string, len(op.ColumnValues))
+		args         = make([]interface{}, len(op.ColumnValues))
+	)
+	for i, col := range op.ColumnValues {
+		if args[i], err = col.Type.Serialize(col.Value); err != nil {
+			return nil, err
+		}
+		columns[i] = quote(col.Name)
+		placeholders[i] = "?"
+	}
+
+	var buf strings.Builder
+	if op.OnDuplicate == activerecord.OnDuplicateSkip {
+		buf.WriteString("INSERT IGNORE INTO ")
+	} else {
+		buf.WriteString("INSERT INTO ")
+	}
+	fmt.Fprintf(&buf, "%s (%s) VALUES (%s)",
+		quote(op.TableName), strings.Join(columns, ", "), strings.Join(placeholders, ", "),
+	)
+
+	if op.OnDuplicate == activerecord.OnDuplicateUpdate && len(columns) > 0 {
+		updates := make([]string, len(columns))
+		for i, column := range columns {
+			updates[i] = fmt.Sprintf("%s = VALUES(%s)", column, column)
+		}
+		fmt.Fprintf(&buf, " ON DUPLICATE KEY UPDATE %s", strings.Join(updates, ", "))
+	}
+
+	result, err := ansi.ExecContext(ctx, c.ConnectionStatements, buf.String(), args...)
+	if err != nil {
+		return nil, c.translateErr(err)
+	}
+	return result.LastInsertId()
+}
+
+func (c *Conn) ExecUpdate(ctx context.Context, op *activerecord.UpdateOperation) error {
+	var (
+		sets []string
+		args []interface{}
+		pk   interface{}
+	)
+	for _, col := range op.ColumnValues {
+		if col.Name == op.PrimaryKey {
+			pk = col.Value
+			continue
+		}
+		val, err := col.Type.Serialize(col.Value)
+		if err != nil {
+			return err
+		}
+		sets = append(sets, quote(col.Name)+" = ?")
+		args = append(args, val)
+	}
+	if len(sets) == 0 {
+		// Nothing to update, but the row must still exist.
+		sets = append(sets, fmt.Sprintf("%s = %s", quote(op.PrimaryKey), quote(op.PrimaryKey)))
+	}
+
+	stmt := fmt.Sprintf("UPDATE %s SET %s WHERE %s = ?",
+		quote(op.TableName), strings.Join(sets, ", "), quote(op.PrimaryKey),
+	)
+	args = append(args, pk)
+
+	result, err := ansi.ExecContext(ctx, c.ConnectionStatements, stmt, args...)
+	if err != nil {
+		return c.translateErr(err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows != 1 {
+		return fmt.Errorf("expected single row affected, got %d rows affected", rows)
+	}
+	return nil
+}
+
+func (c *Conn) ExecDelete(ctx context.Context, op *activerecord.DeleteOperation) error {
+	stmt := fmt.Sprintf("DELETE FROM %s WHERE %s = ?", quote(op.TableName), quote(op.PrimaryKey))
+	_, err := ansi.ExecContext(ctx, c.ConnectionStatements, stmt, op.Value)
+	return c.translateErr(err)
+}
+
+// ExecQuery executes the query with identifiers quoted by backticks. MySQL driver
+// returns most of values as byte slices, so they are converted to the values of
+// the corresponding column types.
+func (c *Conn) ExecQuery(
+	ctx context.Context, op *activerecord.QueryOperation, cb func(Hash) bool,
+) (
+	err error,
+) {
+	var (
+		stmt = Requote(op.Text)
+		rows int64
+	)
+
+	done := activerecord.LogQuery(ctx, stmt, op.Args)
+	defer func() { done(rows, err) }()
+
+	rws, err := c.ConnectionStatements.QueryContext(ctx, stmt, op.Args...)
+	if err != nil {
+		return c.translateErr(err)
+	}
+
+	defer rws.Close()
+
+	columnTypes, err := rws.ColumnTypes()
+	if err != nil {
+		return err
+	}
+
+	columns := op.Columns
+	if len(columns) == 0 {
+		for _, columnType := range columnTypes {
+			columns = append(columns, columnType.Name())
+		}
+	}
+
+	for rws.Next() {
+		var (
+			row  = make(Hash)
+			vals = make([]interface{}, len(columns))
+		)
+		for i := range vals {
+			vals[i] = new(interface{})
+		}
+		if err = rws.Scan(vals...); err != nil {
+			return err
+		}
+		for i := range vals {
+			val, err := convertValue(columnTypes[i].DatabaseTypeName(), *(vals[i]).(*interface{}))
+			if err != nil {
+				return err
+			}
+			row[columns[i]] = val
+		}
+		rows++
+
+		// Terminate the querying and close the reading cursor.
+		if !cb(row) {
+			break
+		}
+	}
+	return rws.Err()
+}
+
+// ExecStatement executes the statement with identifiers quoted by backticks.
+func (c *Conn) ExecStatement(ctx context.Context, op *activerecord.QueryOperation) (
+	int64, error,
+) {
+	requoted := *op
+	requoted.Text = Requote(op.Text)
+
+	rows, err := c.DatabaseStatements.ExecStatement(ctx, &requoted)
+	return rows, c.translateErr(err)
+}
+
+// convertValue converts the raw value returned by the driver into the value of
+// the column type. Unsigned integers are returned as int64 values, unless they
+// overflow int64, then they are returned as uint64 values.
+func convertValue(typeName string, value interface{}) (interface{}, error) {
+	raw, ok := value.([]byte)
+	if !ok {
+		return value, nil
+	}
+
+	switch typeName {
+	case "TINYINT", "SMALLINT", "MEDIUMINT", "INT", "BIGINT", "YEAR":
+		return strconv.ParseInt(string(raw), 10, 64)
+	case "UNSIGNED TINYINT", "UNSIGNED SMALLINT", "UNSIGNED MEDIUMINT",
+		"UNSIGNED INT", "UNSIGNED BIGINT":
+		u, err := strconv.ParseUint(string(raw), 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		if u > math.MaxInt64 {
+			return u, nil
+		}
+		return int64(u), nil
+	case "FLOAT", "DOUBLE", "DECIMAL":
+		return strconv.ParseFloat(string(raw), 64)
+	default:
+		return string(raw), nil
+	}
+}
+
+// ExecExplain returns the plan of the query. MySQL supports only Analyze option,
+// the rest of options are ignored.
+func (c *Conn) ExecExplain(ctx context.Context, op *activerecord.ExplainOperation) (
+	plan string, err error,
+) {
+	var options []activerecord.ExplainOption
+	for _, option := range op.Options {
+		if option == activerecord.Analyze {
+			options = append(options, option)
+		}
+	}
+
+	query := *op.Query
+	query.Text = Requote(op.Query.Text)
+	return c.DatabaseStatements.ExecExplain(ctx, &activerecord.ExplainOperation{
+		Query: &query, Options: options,
+	})
+}
+
+// translateErr converts MySQL errors into errors of Active Record.
+func (c *Conn) translateErr(err error) error {
+	var myErr *mysql.MySQLError
+	if !errors.As(err, &myErr) {
+		if errors.Is(err, mysql.ErrInvalidConn) || ansi.IsConnectionError(err) {
+			return &activerecord.ErrConnectionFailed{Err: err}
+		}
+		return err
+	}
+	switch myErr.Number {
+	case duplicateEntry:
+		return &activerecord.ErrRecordNotUnique{Err: err}
+	case lockDeadlock:
+		return &activerecord.ErrSerializationFailure{Err: err}
+	case queryTimeout:
+		return &activerecord.ErrStatementTimeout{Err: err}
+	case optionPreventsStatement, readOnlyMode:
+		return &activerecord.ErrConnectionFailed{Err: err}
+	default:
+		return err
+	}
+}
+
+// ColumnType returns the type of the column given the column type reported by
+// the information schema (e.g. "varchar(255)" or "tinyint(1)").
+func (c *Conn) ColumnType(typeName string) (activerecord.Type, error) {
+	typeName = strings.ToLower(typeName)
+
+	// Booleans are stored as single-digit integers.
+	if typeName == "tinyint(1)" || typeName == "boolean" || typeName == "bool" {
+		return new(activerecord.Boolean), nil
+	}
+	if pos := strings.IndexAny(typeName, "( "); pos >= 0 {
+		typeName = typeName[:pos]
+	}
+
+	switch typeName {
+	case "tinyint", "smallint", "mediumint", "int", "integer", "bigint", "year":
+		return new(activerecord.Int64), nil
+	case "char", "varchar", "tinytext", "text", "mediumtext", "longtext", "enum":
+		return new(activerecord.String), nil
+	case "float", "double", "decimal", "numeric":
+		return new(activerecord.Float64), nil
+	case "datetime", "timestamp":
+		return new(activerecord.DateTime), nil
+	case "date":
+		return new(activerecord.Date), nil
+	case "time":
+		return new(activerecord.Time), nil
+	case "json":
+		return new(activerecord.JSON), nil
+	default:
+		return nil, activerecord.ErrUnsupportedType{TypeName: typeName}
+	}
+}
+
+// TableNames returns names of tables of the database sorted by names.
+func (c *Conn) TableNames(ctx context.Context) ([]string, error) {
+	const stmt = `SELECT table_name FROM information_schema.tables
+	WHERE table_schema = DATABASE() AND table_type = 'BASE TABLE'
+	ORDER BY table_name`
+
+	rws, err := c.ConnectionStatements.QueryContext(ctx, stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rws.Close()
+
+	var names []string
+	for rws.Next() {
+		var name string
+		if err := rws.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rws.Err()
+}
+
+func (c *Conn) ColumnDefinitions(ctx context.Context, tableName string) (
+	[]activerecord.ColumnDefinition, error,
+) {
+	const stmt = `SELECT column_name, column_type, is_nullable, column_key, column_comment
+	FROM information_schema.columns
+	WHERE table_schema = DATABASE() AND table_name = ?
+	ORDER BY ordinal_position`
+
+	rws, err := c.ConnectionStatements.QueryContext(ctx, stmt, tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rws.Close()
+
+	var definitions []activerecord.ColumnDefinition
+	for rws.Next() {
+		var fname, ftype, nullable, key, comment string
+		if err := rws.Scan(&fname, &ftype, &nullable, &key, &comment); err != nil {
+			return nil, err
+		}
+
+		columnType, err := c.ColumnType(ftype)
+		if err != nil {
+			return nil, err
+		}
+
+		definitions = append(definitions, activerecord.ColumnDefinition{
+			Name:         fname,
+			Type:         columnType,
+			NotNull:      nullable == "NO",
+			IsPrimaryKey: key == "PRI",
+			Comment:      comment,
+		})
+	}
+	if err = rws.Err(); err != nil {
+		return nil, err
+	}
+	if len(definitions) == 0 {
+		return nil, activerecord.ErrTableNotExist{TableName: tableName}
+	}
+	return definitions, nil
+}
+
+// TableComment returns the comment of the table.
+func (c *Conn) TableComment(ctx context.Context, tableName string) (string, error) {
+	const stmt = `SELECT table_comment FROM information_schema.tables
+	WHERE table_schema = DATABASE() AND table_name = ?`
+
+	rws, err := c.ConnectionStatements.QueryContext(ctx, stmt, tableName)
+	if err != nil {
+		return "", err
+	}
+
+	defer rws.Close()
+
+	if !rws.Next() {
+		if err = rws.Err(); err != nil {
+			return "", err
+		}
+		return "", activerecord.ErrTableNotExist{TableName: tableName}
+	}
+	var comment string
+	err = rws.Scan(&comment)
+	return comment, err
+}
+
+// IndexDefinitions returns indexes of the table, except the primary key.
+func (c *Conn) IndexDefinitions(ctx context.Context, tableName string) (
+	[]activerecord.IndexDefinition, error,
+) {
+	const stmt = `SELECT index_name, non_unique, COALESCE(column_name, expression)
+	FROM information_schema.statistics
+	WHERE table_schema = DATABASE() AND table_name = ? AND index_name <> 'PRIMARY'
+	ORDER BY index_name, seq_in_index`
+
+	rws, err := c.ConnectionStatements.QueryContext(ctx, stmt, tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rws.Close()
+
+	var definitions []activerecord.IndexDefinition
+	for rws.Next() {
+		var (
+			name, column string
+			nonUnique    int
+		)
+		if err := rws.Scan(&name, &nonUnique, &column); err != nil {
+			return nil, err
+		}
+
+		if n := len(definitions); n > 0 && definitions[n-1].Name == name {
+			definitions[n-1].Columns = append(definitions[n-1].Columns, column)
+			continue
+		}
+		definitions = append(definitions, activerecord.IndexDefinition{
+			Name:    name,
+			Table:   tableName,
+			Columns: []string{column},
+			Unique:  nonUnique == 0,
+		})
+	}
+	return definitions, rws.Err()
+}
+
+// ForeignKeyDefinitions returns foreign keys of the table sorted by names.
+func (c *Conn) ForeignKeyDefinitions(ctx context.Context, tableName string) (
+	[]activerecord.ForeignKeyDefinition, error,
+) {
+	const stmt = `SELECT k.constraint_name, k.column_name,
+		k.referenced_table_name, k.referenced_column_name, r.delete_rule, r.update_rule
+	FROM information_schema.key_column_usage k
+	JOIN information_schema.referential_constraints r
+		ON r.constraint_schema = k.constraint_schema AND r.constraint_name = k.constraint_name
+	WHERE k.table_schema = DATABASE() AND k.table_name = ? AND k.ordinal_position = 1
+	ORDER BY k.constraint_name`
+
+	rws, err := c.ConnectionStatements.QueryContext(ctx, stmt, tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rws.Close()
+
+	var definitions []activerecord.ForeignKeyDefinition
+	for rws.Next() {
+		var name, column, target, primaryKey, onDelete, onUpdate string
+		err := rws.Scan(&name, &column, &target, &primaryKey, &onDelete, &onUpdate)
+		if err != nil {
+			return nil, err
+		}
+		definitions = append(definitions, activerecord.ForeignKeyDefinition{
+			Name:       name,
+			Table:      tableName,
+			Column:     column,
+			Target:     target,
+			PrimaryKey: primaryKey,
+			OnDelete:   referentialAction(onDelete),
+			OnUpdate:   referentialAction(onUpdate),
+		})
+	}
+	return definitions, rws.Err()
+}
+
+// referentialAction returns the empty string for the default action.
+func referentialAction(action string) string {
+	if action == "NO ACTION" {
+		return ""
+	}
+	return action
+}
+
+// CheckConstraintDefinitions returns check constraints of the table sorted by names.
+func (c *Conn) CheckConstraintDefinitions(ctx context.Context, tableName string) (
+	[]activerecord.CheckConstraintDefinition, error,
+) {
+	const stmt = `SELECT tc.constraint_name, cc.check_clause
+	FROM information_schema.table_constraints tc
+	JOIN information_schema.check_constraints cc
+		ON cc.constraint_schema = tc.constraint_schema AND cc.constraint_name = tc.constraint_name
+	WHERE tc.table_schema = DATABASE() AND tc.table_name = ? AND tc.constraint_type = 'CHECK'
+	ORDER BY tc.constraint_name`
+
+	rws, err := c.ConnectionStatements.QueryContext(ctx, stmt, tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rws.Close()
+
+	var definitions []activerecord.CheckConstraintDefinition
+	for rws.Next() {
+		var name, expression string
+		if err := rws.Scan(&name, &expression); err != nil {
+			return nil, err
+		}
+		definitions = append(definitions, activerecord.CheckConstraintDefinition{
+			Name: name, Table: tableName, Expression: expression,
+		})
+	}
+	return definitions, rws.Err()
+}
+
+// nativeType returns the MySQL type of the column.
+func nativeType(column activerecord.ColumnDefinition) string {
+	switch column.Type.(type) {
+	case *activerecord.Int64:
+		// Integer primary keys are generated by the database.
+		if column.IsPrimaryKey {
+			return "BIGINT AUTO_INCREMENT"
+		}
+		return "BIGINT"
+	case *activerecord.String:
+		return "VARCHAR(255)"
+	case *activerecord.Float64:
+		return "DOUBLE"
+	case *activerecord.JSON:
+		return "JSON"
+	default:
+		return column.Type.NativeType()
+	}
+}
+
+// Capabilities returns capabilities of the MySQL database. Schema statements
+// commit the current transaction implicitly, so they are not transactional.
+// MySQL does not have materialized views.
+func (c *Conn) Capabilities() activerecord.Capabilities {
+	return activerecord.Capabilities{
+		Joins:             true,
+		Subqueries:        true,
+		DropColumn:        true,
+		AlterConstraints:  true,
+		TransactionalDDL:  false,
+		MaterializedViews: false,
+	}
+}
+
+// QuoteIdentifier returns the identifier quoted with backticks.
+func (c *Conn) QuoteIdentifier(name string) string {
+	return quote(name)
+}
+
+// NativeType returns the MySQL type of the column.
+func (c *Conn) NativeType(column activerecord.ColumnDefinition) string {
+	return nativeType(column)
+}
+
+// Compile compiles operations of migrations, which statements differ in MySQL.
+// Indexes are always created without locking of writes, so the concurrent
+// creation is ignored. Foreign keys are dropped with "DROP FOREIGN KEY". Check
+// constraints are validated, when they are added, so "NOT VALID" is ignored.
+// InlineComments returns true, MySQL defines comments of tables and columns
+// within their definitions.
+func (c *Conn) InlineComments() bool {
+	return true
+}
+
+func (c *Conn) Compile(op migration.Operation) ([]string, bool) {
+	switch op := op.(type) {
+	case *migration.AddIndex:
+		if !op.Concurrently {
+			return nil, false
+		}
+		online := *op
+		online.Concurrently = false
+		return online.Statements(c), true
+	case *migration.RemoveIndex:
+		return []string{fmt.Sprintf("DROP INDEX %s ON %s",
+			quote(op.IndexName()), quote(op.Table),
+		)}, true
+	case *migration.RemoveForeignKey:
+		return []string{fmt.Sprintf("ALTER TABLE %s DROP FOREIGN KEY %s",
+			quote(op.Table), quote(op.ForeignKeyName()),
+		)}, true
+	case *migration.AddCheckConstraint:
+		if !op.NotValid {
+			return nil, false
+		}
+		check := *op
+		check.NotValid = false
+		return check.Statements(c), true
+	case *migration.RemoveCheckConstraint:
+		return []string{fmt.Sprintf("ALTER TABLE %s DROP CHECK %s",
+			quote(op.Table), quote(op.ConstraintName()),
+		)}, true
+	case *migration.ValidateConstraint:
+		return nil, true
+	default:
+		return nil, false
+	}
+}
+
+func (c *Conn) CreateTable(ctx context.Context, table *activerecord.Table) error {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "CREATE TABLE %s (", quote(table.Name()))
+
+	var primaryKey string
+
+	for _, column := range table.Columns() {
+		columnType := nativeType(column)
+		if column.NotNull {
+			columnType += " NOT NULL"
+		}
+		if column.IsPrimaryKey {
+			primaryKey = column.Name
+		}
+		fmt.Fprintf(&buf, "%s %s, ", quote(column.Name), columnType)
+	}
+
+	for _, target := range table.ForeignKeys() {
+		fk := ForeignKey(target)
+		fmt.Fprintf(&buf, "FOREIGN KEY (%s) REFERENCES %s (`id`), ", quote(fk), quote(target))
+	}
+
+	fmt.Fprintf(&buf, "PRIMARY KEY (%s))", quote(primaryKey))
+	_, err := c.ConnectionStatements.ExecContext(ctx, buf.String())
+	return err
+}
+
+func (c *Conn) AddForeignKey(ctx context.Context, owner, target string) error {
+	fk := ForeignKey(target)
+	stmt := fmt.Sprintf(
+		"ALTER TABLE %s ADD CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s (`id`)",
+		quote(owner), quote("fk_"+owner+"_on_"+target), quote(fk), quote(target),
+	)
+	_, err := c.ConnectionStatements.ExecContext(ctx, stmt)
+	return err
+}
+
+// Requote replaces identifiers quoted with double quotes by identifiers quoted
+// with backticks, as MySQL treats double-quoted strings as string literals.
+// String literals and comments are left intact.
+func Requote(query string) string {
+	var buf strings.Builder
+	for i := 0; i < len(query); i++ {
+		switch ch := query[i]; {
+		case ch == '\'':
+			// MySQL string literals support backslash escapes.
+			j := i + 1
+			for ; j < len(query) && query[j] != '\''; j++ {
+				if query[j] == '\\' {
+					j++
+				}
+			}
+			if j >= len(query) {
+				buf.WriteString(query[i:])
+				return buf.String()
+			}
+			buf.WriteString(query[i : j+1])
+			i = j
+		case ch == '"':
+			end := strings.IndexByte(query[i+1:], '"')
+			if end < 0 {
+				buf.WriteString(query[i:])
+				return buf.String()
+			}
+			buf.WriteString(quote(query[i+1 : i+1+end]))
+			i += end + 1
+		case ch == '-' && strings.HasPrefix(query[i:], "--"):
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				buf.WriteString(query[i:])
+				return buf.String()
+			}
+			buf.WriteString(query[i : i+end])
+			i += end - 1
+		case ch == '/' && strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				buf.WriteString(query[i:])
+				return buf.String()
+			}
+			buf.WriteString(query[i : i+end+4])
+			i += end + 3
+		default:
+			buf.WriteByte(ch)
+		}
+	}
+	return buf.String()
+}
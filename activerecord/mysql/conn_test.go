@@ -0,0 +1,31 @@
+package mysql_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/activegraph/activegraph/activerecord/mysql"
+)
+
+func TestRequote(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{`SELECT * FROM "users"`, "SELECT * FROM `users`"},
+		{
+			`SELECT users.id FROM "users" INNER JOIN "books" ON users.id = books.user_id`,
+			"SELECT users.id FROM `users` INNER JOIN `books` ON users.id = books.user_id",
+		},
+		{
+			`SELECT * FROM "users" WHERE (name = 'say "hi"') AND (bio = 'it\'s "me"')`,
+			"SELECT * FROM `users` WHERE (name = 'say \"hi\"') AND (bio = 'it\\'s \"me\"')",
+		},
+		{`SELECT * FROM "a` + "`" + `b" /* "c" */`, "SELECT * FROM `a``b` /* \"c\" */"},
+	}
+
+	for _, tt := range tests {
+		require.Equal(t, tt.expected, mysql.Requote(tt.query))
+	}
+}
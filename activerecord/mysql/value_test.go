@@ -0,0 +1,33 @@
+package mysql
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertValue(t *testing.T) {
+	tests := []struct {
+		typeName string
+		raw      string
+		expected interface{}
+	}{
+		{"BIGINT", "-42", int64(-42)},
+		{"UNSIGNED INT", "42", int64(42)},
+		{"UNSIGNED BIGINT", "9223372036854775807", int64(math.MaxInt64)},
+		{"UNSIGNED BIGINT", "18446744073709551615", uint64(math.MaxUint64)},
+		{"DOUBLE", "1.5", 1.5},
+		{"VARCHAR", "Solaris", "Solaris"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.typeName+" "+tt.raw, func(t *testing.T) {
+			value, err := convertValue(tt.typeName, []byte(tt.raw))
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, value)
+		})
+	}
+
+	_, err := convertValue("UNSIGNED BIGINT", []byte("-1"))
+	require.Error(t, err)
+}
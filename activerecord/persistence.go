@@ -22,8 +22,18 @@ type Persistence interface {
 	IsPersisted() bool
 }
 
+// Behaviour of the insert operation on conflicts with the existing rows, stored
+// in the OnDuplicate field of InsertOperation.
+const (
+	// OnDuplicateSkip skips the conflicting rows.
+	OnDuplicateSkip = "skip"
+	// OnDuplicateUpdate updates the conflicting rows with the inserted values.
+	OnDuplicateUpdate = "update"
+)
+
 type InsertOperation struct {
 	TableName      string
+	PrimaryKey     string
 	ColumnValues   []ColumnValue
 	OnDuplicate    string
 	ConflictTarget string
@@ -47,12 +57,30 @@ type Dependency struct {
 	PrimaryKey string
 }
 
+// QueryOperation is an operation of the SQL query. When Columns are empty, rows
+// are returned with all columns of the query result.
 type QueryOperation struct {
 	Text    string
 	Args    []interface{}
 	Columns []string
 }
 
+// ExplainOption is an option of the query plan explanation. Databases that do
+// not support an option ignore it.
+type ExplainOption string
+
+const (
+	// Analyze executes the query and reports the actual run times.
+	Analyze ExplainOption = "ANALYZE"
+	// Verbose reports additional information about the plan.
+	Verbose ExplainOption = "VERBOSE"
+)
+
+type ExplainOperation struct {
+	Query   *QueryOperation
+	Options []ExplainOption
+}
+
 type ColumnValue struct {
 	Name  string
 	Type  Type
@@ -64,10 +92,135 @@ type ColumnDefinition struct {
 	Type         Type
 	NotNull      bool
 	IsPrimaryKey bool
+	// Default is the SQL expression of the default value of the column, e.g.
+	// "'draft'" or "CURRENT_TIMESTAMP". Empty string means no default.
+	Default string
+	// Comment is the comment of the column stored in the database schema.
+	Comment string
+}
+
+// IndexDefinition is the definition of the table index.
+type IndexDefinition struct {
+	Name  string
+	Table string
+	// Columns are names of indexed columns or expressions.
+	Columns []string
+	Unique  bool
+	// Where is the condition of the partial index.
+	Where string
+}
+
+// ForeignKeyDefinition is the definition of the foreign key constraint.
+type ForeignKeyDefinition struct {
+	Name       string
+	Table      string
+	Column     string
+	Target     string
+	PrimaryKey string
+	// OnDelete and OnUpdate are referential actions of the constraint, e.g.
+	// "CASCADE", empty actions are default actions of the database.
+	OnDelete string
+	OnUpdate string
+}
+
+// CheckConstraintDefinition is the definition of the check constraint.
+type CheckConstraintDefinition struct {
+	Name  string
+	Table string
+	// Expression is the condition satisfied by every row of the table.
+	Expression string
+}
+
+// ErrNotSupported is returned when the feature is not supported by the adapter
+// of the connection.
+type ErrNotSupported struct {
+	Feature string
+}
+
+func (e *ErrNotSupported) Is(target error) bool {
+	_, ok := target.(*ErrNotSupported)
+	return ok
+}
+
+func (e *ErrNotSupported) Error() string {
+	return fmt.Sprintf("ErrNotSupported: %s", e.Feature)
+}
+
+// SchemaIndexes is implemented by connections, which introspect indexes of
+// tables.
+type SchemaIndexes interface {
+	IndexDefinitions(ctx context.Context, tableName string) ([]IndexDefinition, error)
+}
+
+// SchemaForeignKeys is implemented by connections, which introspect foreign
+// keys of tables.
+type SchemaForeignKeys interface {
+	ForeignKeyDefinitions(ctx context.Context, tableName string) ([]ForeignKeyDefinition, error)
+}
+
+// SchemaCheckConstraints is implemented by connections, which introspect check
+// constraints of tables.
+type SchemaCheckConstraints interface {
+	CheckConstraintDefinitions(ctx context.Context, tableName string) ([]CheckConstraintDefinition, error)
+}
+
+// SchemaTableComments is implemented by connections, which introspect comments
+// of tables.
+type SchemaTableComments interface {
+	TableComment(ctx context.Context, tableName string) (string, error)
+}
+
+// IndexDefinitions returns indexes of the table sorted by names, primary keys
+// are not included. ErrNotSupported is returned, when the connection does not
+// implement SchemaIndexes interface.
+func IndexDefinitions(ctx context.Context, conn Conn, tableName string) (
+	[]IndexDefinition, error,
+) {
+	indexes, ok := adapterConn(conn).(SchemaIndexes)
+	if !ok {
+		return nil, &ErrNotSupported{Feature: "indexes introspection"}
+	}
+	return indexes.IndexDefinitions(ctx, tableName)
+}
+
+// ForeignKeyDefinitions returns foreign keys of the table. ErrNotSupported is
+// returned, when the connection does not implement SchemaForeignKeys interface.
+func ForeignKeyDefinitions(ctx context.Context, conn Conn, tableName string) (
+	[]ForeignKeyDefinition, error,
+) {
+	foreignKeys, ok := adapterConn(conn).(SchemaForeignKeys)
+	if !ok {
+		return nil, &ErrNotSupported{Feature: "foreign keys introspection"}
+	}
+	return foreignKeys.ForeignKeyDefinitions(ctx, tableName)
+}
+
+// CheckConstraintDefinitions returns check constraints of the table.
+// ErrNotSupported is returned, when the connection does not implement
+// SchemaCheckConstraints interface.
+func CheckConstraintDefinitions(ctx context.Context, conn Conn, tableName string) (
+	[]CheckConstraintDefinition, error,
+) {
+	checks, ok := adapterConn(conn).(SchemaCheckConstraints)
+	if !ok {
+		return nil, &ErrNotSupported{Feature: "check constraints introspection"}
+	}
+	return checks.CheckConstraintDefinitions(ctx, tableName)
+}
+
+// TableComment returns the comment of the table, empty comment is returned for
+// tables without comments. ErrNotSupported is returned, when the connection does
+// not implement SchemaTableComments interface.
+func TableComment(ctx context.Context, conn Conn, tableName string) (string, error) {
+	comments, ok := adapterConn(conn).(SchemaTableComments)
+	if !ok {
+		return "", &ErrNotSupported{Feature: "table comments introspection"}
+	}
+	return comments.TableComment(ctx, tableName)
 }
 
 type TransactionStatements interface {
-	BeginTransaction(ctx context.Context) (Conn, error)
+	BeginTransaction(ctx context.Context, opts *TransactionOptions) (Conn, error)
 	CommitTransaction(ctx context.Context) error
 	RollbackTransaction(ctx context.Context) error
 }
@@ -77,6 +230,8 @@ type DatabaseStatements interface {
 	ExecUpdate(ctx context.Context, op *UpdateOperation) (err error)
 	ExecDelete(ctx context.Context, op *DeleteOperation) (err error)
 	ExecQuery(ctx context.Context, op *QueryOperation, cb func(activesupport.Hash) bool) (err error)
+	ExecExplain(ctx context.Context, op *ExplainOperation) (plan string, err error)
+	ExecStatement(ctx context.Context, op *QueryOperation) (rowsAffected int64, err error)
 }
 
 type SchemaStatements interface {
@@ -100,7 +255,7 @@ type errConn struct {
 }
 
 // TransactionStatements
-func (c *errConn) BeginTransaction(ctx context.Context) (Conn, error) {
+func (c *errConn) BeginTransaction(context.Context, *TransactionOptions) (Conn, error) {
 	return nil, c.err
 }
 
@@ -117,6 +272,10 @@ func (c *errConn) ExecInsert(context.Context, *InsertOperation) (interface{}, er
 	return nil, c.err
 }
 
+func (c *errConn) ExecStatement(context.Context, *QueryOperation) (int64, error) {
+	return 0, c.err
+}
+
 func (c *errConn) ExecUpdate(context.Context, *UpdateOperation) error {
 	return c.err
 }
@@ -129,6 +288,10 @@ func (c *errConn) ExecQuery(context.Context, *QueryOperation, func(activesupport
 	return c.err
 }
 
+func (c *errConn) ExecExplain(context.Context, *ExplainOperation) (string, error) {
+	return "", c.err
+}
+
 // SchemaStatements
 func (c *errConn) ColumnType(typeName string) (Type, error) {
 	return nil, c.err
@@ -0,0 +1,136 @@
+package activerecord
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ErrPluck is returned when plucked values cannot be decoded into the requested
+// type.
+type ErrPluck struct {
+	TypeName string
+	Message  string
+}
+
+// Error returns a string representation of the error.
+func (e *ErrPluck) Error() string {
+	return fmt.Sprintf("ErrPluck: cannot decode into %s, %s", e.TypeName, e.Message)
+}
+
+// PluckAs returns values of the given attributes decoded into the values of type T.
+//
+// When T is a struct, each attribute is assigned to the field tagged with the
+// attribute name (`activerecord:"email"`), or to the field with the name that
+// matches the attribute name ignoring the case and underscores ("author_id" is
+// assigned to "AuthorID" field).
+//
+//	type Contact struct {
+//		ID    int64
+//		Email string
+//	}
+//
+//	contacts, err := activerecord.PluckAs[Contact](User.Where("active", true), "id", "email")
+//
+// When T is not a struct, exactly one attribute must be plucked.
+//
+//	emails, err := activerecord.PluckAs[string](User, "email")
+func PluckAs[T any](rel *Relation, attrNames ...string) ([]T, error) {
+	var (
+		typ     = reflect.TypeOf((*T)(nil)).Elem()
+		indices []int
+	)
+
+	if typ.Kind() == reflect.Struct {
+		indices = make([]int, len(attrNames))
+		for i, attrName := range attrNames {
+			index, ok := pluckField(typ, attrName)
+			if !ok {
+				return nil, &ErrPluck{TypeName: typ.String(), Message: fmt.Sprintf(
+					"no field for attribute %q", attrName,
+				)}
+			}
+			indices[i] = index
+		}
+	} else if len(attrNames) != 1 {
+		return nil, &ErrPluck{TypeName: typ.String(), Message: fmt.Sprintf(
+			"%d attributes plucked into non-struct type", len(attrNames),
+		)}
+	}
+
+	rows, err := rel.Pluck(attrNames...)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]T, len(rows))
+	for i, row := range rows {
+		dst := reflect.ValueOf(&values[i]).Elem()
+		for j, value := range row {
+			field := dst
+			if indices != nil {
+				field = dst.Field(indices[j])
+			}
+			if err := pluckAssign(field, value); err != nil {
+				return nil, &ErrPluck{TypeName: typ.String(), Message: fmt.Sprintf(
+					"attribute %q: %s", attrNames[j], err,
+				)}
+			}
+		}
+	}
+	return values, nil
+}
+
+// pluckField returns an index of the struct field for the given attribute.
+func pluckField(typ reflect.Type, attrName string) (int, bool) {
+	normalize := func(name string) string {
+		return strings.ToLower(strings.ReplaceAll(name, "_", ""))
+	}
+
+	// Tagged fields take precedence over the matching by field name.
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.PkgPath == "" && field.Tag.Get("activerecord") == attrName {
+			return i, true
+		}
+	}
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.PkgPath == "" && field.Tag.Get("activerecord") == "" &&
+			normalize(field.Name) == normalize(attrName) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// pluckAssign assigns the value to the destination, absent values leave the
+// destination unchanged.
+func pluckAssign(dst reflect.Value, value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	// Pointer destinations distinguish absent values from the zero values.
+	if dst.Kind() == reflect.Ptr {
+		ptr := reflect.New(dst.Type().Elem())
+		if err := pluckAssign(ptr.Elem(), value); err != nil {
+			return err
+		}
+		dst.Set(ptr)
+		return nil
+	}
+
+	val := reflect.ValueOf(value)
+	switch {
+	case val.Type().AssignableTo(dst.Type()):
+		dst.Set(val)
+	// Numbers are convertible to strings as runes, which is never expected here.
+	case val.Type().ConvertibleTo(dst.Type()) &&
+		(dst.Kind() != reflect.String || val.Kind() == reflect.String):
+		dst.Set(val.Convert(dst.Type()))
+	default:
+		return fmt.Errorf("%T is not assignable to %s", value, dst.Type())
+	}
+	return nil
+}
@@ -2,19 +2,771 @@ package postgresql
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgconn"
+	_ "github.com/jackc/pgx/v4/stdlib"
 
 	"github.com/activegraph/activegraph/activerecord"
+	"github.com/activegraph/activegraph/activerecord/ansi"
+	. "github.com/activegraph/activegraph/activesupport"
 )
 
+func init() {
+	activerecord.RegisterConnectionAdapter("postgresql", Connect)
+}
+
+// uniqueViolation is an error code of the unique constraint violation.
+const uniqueViolation = "23505"
+
+// serializationFailure is an error code of the failed serialization of the
+// concurrent transactions.
+const serializationFailure = "40001"
+
+// queryCanceled is an error code of the statement canceled by the server, e.g.
+// due to the "statement_timeout".
+const queryCanceled = "57014"
+
+// deadlockDetected is an error code of the deadlock, the transaction of which is
+// rolled back.
+const deadlockDetected = "40P01"
+
+// connectionException is a class of error codes of the failed connection.
+const connectionException = "08"
+
 type Conn struct {
+	ansi.ConnectionStatements
+	ansi.SchemaStatements
+	ansi.DatabaseStatements
+
+	db *sql.DB
+	tx *sql.Tx
+
+	// Nested transactions are implemented with savepoints, depth is used to
+	// name savepoints uniquely within a transaction.
+	savepoint string
+	depth     int
 }
 
-func (c *Conn) ExecDelete(ctx context.Context, op *activerecord.DeleteOperation) error {
-	return nil
+// dataSourceName returns a connection URL to the database.
+func dataSourceName(conf activerecord.DatabaseConfig) string {
+	dsn := url.URL{
+		Scheme: "postgres",
+		Host:   conf.Host,
+		Path:   "/" + conf.Database,
+	}
+	if dsn.Host == "" {
+		dsn.Host = "localhost"
+	}
+	if conf.Username != "" {
+		dsn.User = url.UserPassword(conf.Username, conf.Password)
+	}
+	query := url.Values{}
+	for name, value := range conf.Options {
+		query.Set(name, value)
+	}
+	// Limit statements on the server side as well, so the statement is canceled
+	// even when the client is not able to cancel it.
+	if conf.StatementTimeout > 0 {
+		query.Set("statement_timeout", strconv.FormatInt(conf.StatementTimeout.Milliseconds(), 10))
+	}
+	dsn.RawQuery = query.Encode()
+	return dsn.String()
 }
 
+func Connect(conf activerecord.DatabaseConfig) (activerecord.Conn, error) {
+	db, err := sql.Open("pgx", dataSourceName(conf))
+	if err != nil {
+		return nil, err
+	}
+	ansi.ConfigurePool(db, conf)
+
+	if err = db.PingContext(context.Background()); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Conn{
+		db:                   db,
+		ConnectionStatements: db,
+		SchemaStatements:     ansi.SchemaStatements{Conn: db},
+		DatabaseStatements:   ansi.DatabaseStatements{Conn: db},
+	}, nil
+}
+
+// Verify verifies the connection to the database with a ping.
+func (c *Conn) Verify(ctx context.Context) error {
+	return c.translateErr(c.db.PingContext(ctx))
+}
+
+func (c *Conn) Close() error {
+	// Savepoints are released or rolled back on commit and rollback.
+	if c.savepoint != "" {
+		return nil
+	}
+	if c.tx != nil {
+		err := c.tx.Rollback()
+		if errors.Is(err, sql.ErrTxDone) {
+			return nil
+		}
+		return err
+	}
+	return c.db.Close()
+}
+
+// BeginTransaction begins a new transaction or creates a savepoint within the
+// open transaction. Options of the transaction are ignored for savepoints.
+func (c *Conn) BeginTransaction(
+	ctx context.Context, opts *activerecord.TransactionOptions,
+) (activerecord.Conn, error) {
+	if c.tx != nil {
+		savepoint := fmt.Sprintf("active_record_%d", c.depth+1)
+		if _, err := ansi.ExecContext(ctx, c.tx, "SAVEPOINT "+savepoint); err != nil {
+			return nil, err
+		}
+		return &Conn{
+			db:                   c.db,
+			tx:                   c.tx,
+			savepoint:            savepoint,
+			depth:                c.depth + 1,
+			ConnectionStatements: c.tx,
+			SchemaStatements:     ansi.SchemaStatements{Conn: c.tx},
+			DatabaseStatements:   ansi.DatabaseStatements{Conn: c.tx},
+		}, nil
+	}
+
+	txOpts, err := ansi.TxOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	done := activerecord.LogQuery(ctx, ansi.BeginTransactionStmt(opts), nil)
+	tx, err := c.db.BeginTx(ctx, txOpts)
+	done(0, err)
+	if err != nil {
+		return nil, c.translateErr(err)
+	}
+
+	return &Conn{
+		db:                   c.db,
+		tx:                   tx,
+		ConnectionStatements: tx,
+		SchemaStatements:     ansi.SchemaStatements{Conn: tx},
+		DatabaseStatements:   ansi.DatabaseStatements{Conn: tx},
+	}, nil
+}
+
+func (c *Conn) CommitTransaction(ctx context.Context) error {
+	if c.tx == nil {
+		return fmt.Errorf("no transaction is open")
+	}
+	if c.savepoint != "" {
+		_, err := ansi.ExecContext(ctx, c.tx, "RELEASE SAVEPOINT "+c.savepoint)
+		return err
+	}
+
+	done := activerecord.LogQuery(ctx, "COMMIT TRANSACTION", nil)
+	err := c.tx.Commit()
+	done(0, err)
+	return c.translateErr(err)
+}
+
+func (c *Conn) RollbackTransaction(ctx context.Context) error {
+	if c.tx == nil {
+		return fmt.Errorf("no transaction is open")
+	}
+	if c.savepoint != "" {
+		_, err := ansi.ExecContext(ctx, c.tx, "ROLLBACK TO SAVEPOINT "+c.savepoint)
+		return err
+	}
+
+	done := activerecord.LogQuery(ctx, "ROLLBACK TRANSACTION", nil)
+	err := c.tx.Rollback()
+	done(0, err)
+	return err
+}
+
+// serializeColumns returns serialized values of the columns.
+func serializeColumns(columns []activerecord.ColumnValue) ([]interface{}, error) {
+	args := make([]interface{}, len(columns))
+	for i, col := range columns {
+		val, err := col.Type.Serialize(col.Value)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = val
+	}
+	return args, nil
+}
+
+// ExecInsert inserts a new row and returns the primary key using "RETURNING"
+// clause, since PostgreSQL does not report the last inserted identifier.
 func (c *Conn) ExecInsert(ctx context.Context, op *activerecord.InsertOperation) (
 	id interface{}, err error,
 ) {
-	return nil, nil
+	args, err := serializeColumns(op.ColumnValues)
+	if err != nil {
+		return nil, err
+	}
+
+	primaryKey := op.PrimaryKey
+	if primaryKey == "" {
+		primaryKey = "id"
+	}
+
+	var buf strings.Builder
+	fmt.Fprintf(&buf, `INSERT INTO %q `, op.TableName)
+
+	if len(op.ColumnValues) == 0 {
+		buf.WriteString(`DEFAULT VALUES`)
+	} else {
+		columns := make([]string, len(op.ColumnValues))
+		placeholders := make([]string, len(op.ColumnValues))
+		for i, col := range op.ColumnValues {
+			columns[i] = fmt.Sprintf("%q", col.Name)
+			placeholders[i] = fmt.Sprintf("$%d", i+1)
+		}
+		fmt.Fprintf(&buf, `(%s) VALUES (%s)`,
+			strings.Join(columns, ", "), strings.Join(placeholders, ", "),
+		)
+	}
+	fmt.Fprintf(&buf, ` RETURNING %q`, primaryKey)
+
+	var rows int64
+
+	done := activerecord.LogQuery(ctx, buf.String(), args)
+	defer func() { done(rows, err) }()
+
+	rws, err := c.ConnectionStatements.QueryContext(ctx, buf.String(), args...)
+	if err != nil {
+		return nil, c.translateErr(err)
+	}
+	defer rws.Close()
+
+	if !rws.Next() {
+		if err = rws.Err(); err != nil {
+			return nil, c.translateErr(err)
+		}
+		return nil, fmt.Errorf("expected single row inserted, got no rows")
+	}
+	if err = rws.Scan(&id); err != nil {
+		return nil, err
+	}
+	rows++
+	return id, nil
+}
+
+func (c *Conn) ExecUpdate(ctx context.Context, op *activerecord.UpdateOperation) error {
+	var (
+		columns []activerecord.ColumnValue
+		pk      interface{}
+	)
+	for _, col := range op.ColumnValues {
+		if col.Name == op.PrimaryKey {
+			pk = col.Value
+			continue
+		}
+		columns = append(columns, col)
+	}
+
+	args, err := serializeColumns(columns)
+	if err != nil {
+		return err
+	}
+
+	sets := make([]string, len(columns))
+	for i, col := range columns {
+		sets[i] = fmt.Sprintf("%q = $%d", col.Name, i+1)
+	}
+	if len(sets) == 0 {
+		// Nothing to update, but the row must still exist.
+		sets = append(sets, fmt.Sprintf("%q = %q", op.PrimaryKey, op.PrimaryKey))
+	}
+
+	stmt := fmt.Sprintf(`UPDATE %q SET %s WHERE %q = $%d`,
+		op.TableName, strings.Join(sets, ", "), op.PrimaryKey, len(args)+1,
+	)
+	args = append(args, pk)
+
+	result, err := ansi.ExecContext(ctx, c.ConnectionStatements, stmt, args...)
+	if err != nil {
+		return c.translateErr(err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows != 1 {
+		return fmt.Errorf("expected single row affected, got %d rows affected", rows)
+	}
+	return nil
+}
+
+func (c *Conn) ExecDelete(ctx context.Context, op *activerecord.DeleteOperation) error {
+	stmt := fmt.Sprintf(`DELETE FROM %q WHERE %q = $1`, op.TableName, op.PrimaryKey)
+	_, err := ansi.ExecContext(ctx, c.ConnectionStatements, stmt, op.Value)
+	return c.translateErr(err)
+}
+
+func (c *Conn) ExecQuery(
+	ctx context.Context, op *activerecord.QueryOperation, cb func(Hash) bool,
+) error {
+	rebound := *op
+	rebound.Text = Rebind(op.Text)
+	return c.translateErr(c.DatabaseStatements.ExecQuery(ctx, &rebound, cb))
+}
+
+func (c *Conn) ExecStatement(ctx context.Context, op *activerecord.QueryOperation) (
+	int64, error,
+) {
+	rebound := *op
+	rebound.Text = Rebind(op.Text)
+
+	rows, err := c.DatabaseStatements.ExecStatement(ctx, &rebound)
+	return rows, c.translateErr(err)
+}
+
+func (c *Conn) ExecExplain(ctx context.Context, op *activerecord.ExplainOperation) (
+	plan string, err error,
+) {
+	query := *op.Query
+	query.Text = Rebind(op.Query.Text)
+	return c.DatabaseStatements.ExecExplain(ctx, &activerecord.ExplainOperation{
+		Query: &query, Options: op.Options,
+	})
+}
+
+// translateErr converts PostgreSQL errors into errors of Active Record.
+func (c *Conn) translateErr(err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		if ansi.IsConnectionError(err) {
+			return &activerecord.ErrConnectionFailed{Err: err}
+		}
+		return err
+	}
+	if strings.HasPrefix(pgErr.Code, connectionException) {
+		return &activerecord.ErrConnectionFailed{Err: err}
+	}
+	switch pgErr.Code {
+	case uniqueViolation:
+		return &activerecord.ErrRecordNotUnique{Err: err}
+	case serializationFailure, deadlockDetected:
+		return &activerecord.ErrSerializationFailure{Err: err}
+	case "57P01", "57P02", "57P03":
+		// The server is shutting down or starting up, e.g. during the failover.
+		return &activerecord.ErrConnectionFailed{Err: err}
+	case queryCanceled:
+		return &activerecord.ErrStatementTimeout{Err: err}
+	default:
+		return err
+	}
+}
+
+// ColumnType returns the type of the column given either a name of the type or
+// a name of the internal type ("udt_name" of the information schema). Arrays are
+// specified with "[]" suffix or "_" prefix.
+func (c *Conn) ColumnType(typeName string) (activerecord.Type, error) {
+	typeName = strings.ToLower(typeName)
+
+	if elemName := strings.TrimSuffix(typeName, "[]"); elemName != typeName {
+		return c.arrayType(elemName)
+	}
+	if elemName := strings.TrimPrefix(typeName, "_"); elemName != typeName {
+		return c.arrayType(elemName)
+	}
+
+	switch typeName {
+	case "smallint", "integer", "bigint", "int2", "int4", "int8",
+		"smallserial", "serial", "bigserial":
+		return new(activerecord.Int64), nil
+	case "text", "varchar", "character varying", "character", "char", "bpchar":
+		return new(activerecord.String), nil
+	case "real", "double precision", "float", "float4", "float8", "numeric", "decimal":
+		return new(activerecord.Float64), nil
+	case "boolean", "bool":
+		return new(activerecord.Boolean), nil
+	case "timestamp", "timestamptz", "datetime",
+		"timestamp without time zone", "timestamp with time zone":
+		return new(activerecord.DateTime), nil
+	case "date":
+		return new(activerecord.Date), nil
+	case "time", "timetz", "time without time zone", "time with time zone":
+		return new(activerecord.Time), nil
+	case "json", "jsonb":
+		return new(activerecord.JSON), nil
+	case "uuid":
+		return new(UUID), nil
+	default:
+		return nil, activerecord.ErrUnsupportedType{TypeName: typeName}
+	}
+}
+
+func (c *Conn) arrayType(elemName string) (activerecord.Type, error) {
+	elem, err := c.ColumnType(elemName)
+	if err != nil {
+		return nil, err
+	}
+	return &ArrayType{Elem: elem}, nil
+}
+
+// TableNames returns names of tables of the database sorted by names.
+func (c *Conn) TableNames(ctx context.Context) ([]string, error) {
+	const stmt = `SELECT table_name FROM information_schema.tables
+	WHERE table_schema = current_schema() AND table_type = 'BASE TABLE'
+	ORDER BY table_name`
+
+	rws, err := c.ConnectionStatements.QueryContext(ctx, stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rws.Close()
+
+	var names []string
+	for rws.Next() {
+		var name string
+		if err := rws.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rws.Err()
+}
+
+func (c *Conn) ColumnDefinitions(ctx context.Context, tableName string) (
+	[]activerecord.ColumnDefinition, error,
+) {
+	// Columns are selected from the catalog, since information schema does not
+	// include columns of materialized views.
+	const stmt = `SELECT a.attname, t.typname,
+	CASE WHEN a.attnotnull THEN 'NO' ELSE 'YES' END,
+	COALESCE(col_description(cls.oid, a.attnum), ''),
+	EXISTS (
+		SELECT 1 FROM pg_index i
+		WHERE i.indrelid = cls.oid AND i.indisprimary AND a.attnum = ANY(i.indkey)
+	)
+	FROM pg_attribute a
+	JOIN pg_class cls ON cls.oid = a.attrelid
+	JOIN pg_namespace ns ON ns.oid = cls.relnamespace
+	JOIN pg_type t ON t.oid = a.atttypid
+	WHERE ns.nspname = current_schema() AND cls.relname = $1
+		AND cls.relkind IN ('r', 'p', 'v', 'm') AND a.attnum > 0 AND NOT a.attisdropped
+	ORDER BY a.attnum`
+
+	rws, err := c.ConnectionStatements.QueryContext(ctx, stmt, tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rws.Close()
+
+	var definitions []activerecord.ColumnDefinition
+	for rws.Next() {
+		var (
+			fname, ftype, nullable, comment string
+			pk                              bool
+		)
+		if err := rws.Scan(&fname, &ftype, &nullable, &comment, &pk); err != nil {
+			return nil, err
+		}
+
+		columnType, err := c.ColumnType(ftype)
+		if err != nil {
+			return nil, err
+		}
+
+		definitions = append(definitions, activerecord.ColumnDefinition{
+			Name:         fname,
+			Type:         columnType,
+			NotNull:      nullable == "NO",
+			IsPrimaryKey: pk,
+			Comment:      comment,
+		})
+	}
+	if err = rws.Err(); err != nil {
+		return nil, err
+	}
+	if len(definitions) == 0 {
+		return nil, activerecord.ErrTableNotExist{TableName: tableName}
+	}
+	return definitions, nil
+}
+
+// TableComment returns the comment of the table.
+func (c *Conn) TableComment(ctx context.Context, tableName string) (string, error) {
+	const stmt = `SELECT COALESCE(obj_description(cls.oid, 'pg_class'), '')
+	FROM pg_class cls
+	JOIN pg_namespace ns ON ns.oid = cls.relnamespace
+	WHERE ns.nspname = current_schema() AND cls.relname = $1`
+
+	rws, err := c.ConnectionStatements.QueryContext(ctx, stmt, tableName)
+	if err != nil {
+		return "", err
+	}
+
+	defer rws.Close()
+
+	if !rws.Next() {
+		if err = rws.Err(); err != nil {
+			return "", err
+		}
+		return "", activerecord.ErrTableNotExist{TableName: tableName}
+	}
+	var comment string
+	err = rws.Scan(&comment)
+	return comment, err
+}
+
+// IndexDefinitions returns indexes of the table, except the primary key. Columns
+// of expression indexes are definitions of expressions.
+func (c *Conn) IndexDefinitions(ctx context.Context, tableName string) (
+	[]activerecord.IndexDefinition, error,
+) {
+	const stmt = `SELECT i.relname, ix.indisunique,
+		COALESCE(pg_get_expr(ix.indpred, ix.indrelid), ''),
+		array_to_json(ARRAY(
+			SELECT pg_get_indexdef(ix.indexrelid, k + 1, true)
+			FROM generate_subscripts(ix.indkey, 1) AS k ORDER BY k
+		))::text
+	FROM pg_index ix
+	JOIN pg_class t ON t.oid = ix.indrelid
+	JOIN pg_class i ON i.oid = ix.indexrelid
+	JOIN pg_namespace n ON n.oid = t.relnamespace
+	WHERE n.nspname = current_schema() AND t.relname = $1 AND NOT ix.indisprimary
+	ORDER BY i.relname`
+
+	rws, err := c.ConnectionStatements.QueryContext(ctx, stmt, tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rws.Close()
+
+	var definitions []activerecord.IndexDefinition
+	for rws.Next() {
+		var (
+			name, where, columns string
+			unique               bool
+		)
+		if err := rws.Scan(&name, &unique, &where, &columns); err != nil {
+			return nil, err
+		}
+
+		definition := activerecord.IndexDefinition{
+			Name: name, Table: tableName, Unique: unique, Where: where,
+		}
+		if err := json.Unmarshal([]byte(columns), &definition.Columns); err != nil {
+			return nil, err
+		}
+		definitions = append(definitions, definition)
+	}
+	return definitions, rws.Err()
+}
+
+// ForeignKeyDefinitions returns foreign keys of the table sorted by names.
+func (c *Conn) ForeignKeyDefinitions(ctx context.Context, tableName string) (
+	[]activerecord.ForeignKeyDefinition, error,
+) {
+	const stmt = `SELECT con.conname, a.attname, ft.relname, fa.attname,
+		con.confdeltype, con.confupdtype
+	FROM pg_constraint con
+	JOIN pg_class t ON t.oid = con.conrelid
+	JOIN pg_class ft ON ft.oid = con.confrelid
+	JOIN pg_namespace n ON n.oid = t.relnamespace
+	JOIN pg_attribute a ON a.attrelid = con.conrelid AND a.attnum = con.conkey[1]
+	JOIN pg_attribute fa ON fa.attrelid = con.confrelid AND fa.attnum = con.confkey[1]
+	WHERE n.nspname = current_schema() AND t.relname = $1 AND con.contype = 'f'
+	ORDER BY con.conname`
+
+	rws, err := c.ConnectionStatements.QueryContext(ctx, stmt, tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rws.Close()
+
+	var definitions []activerecord.ForeignKeyDefinition
+	for rws.Next() {
+		var name, column, target, primaryKey, onDelete, onUpdate string
+		err := rws.Scan(&name, &column, &target, &primaryKey, &onDelete, &onUpdate)
+		if err != nil {
+			return nil, err
+		}
+		definitions = append(definitions, activerecord.ForeignKeyDefinition{
+			Name:       name,
+			Table:      tableName,
+			Column:     column,
+			Target:     target,
+			PrimaryKey: primaryKey,
+			OnDelete:   referentialAction(onDelete),
+			OnUpdate:   referentialAction(onUpdate),
+		})
+	}
+	return definitions, rws.Err()
+}
+
+// referentialAction returns the action of the pg_constraint action code, the
+// empty string is returned for the default action.
+func referentialAction(code string) string {
+	switch code {
+	case "r":
+		return "RESTRICT"
+	case "c":
+		return "CASCADE"
+	case "n":
+		return "SET NULL"
+	case "d":
+		return "SET DEFAULT"
+	default:
+		return ""
+	}
+}
+
+// CheckConstraintDefinitions returns check constraints of the table sorted by names.
+func (c *Conn) CheckConstraintDefinitions(ctx context.Context, tableName string) (
+	[]activerecord.CheckConstraintDefinition, error,
+) {
+	const stmt = `SELECT con.conname, pg_get_expr(con.conbin, con.conrelid)
+	FROM pg_constraint con
+	JOIN pg_class t ON t.oid = con.conrelid
+	JOIN pg_namespace n ON n.oid = t.relnamespace
+	WHERE n.nspname = current_schema() AND t.relname = $1 AND con.contype = 'c'
+	ORDER BY con.conname`
+
+	rws, err := c.ConnectionStatements.QueryContext(ctx, stmt, tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rws.Close()
+
+	var definitions []activerecord.CheckConstraintDefinition
+	for rws.Next() {
+		var name, expression string
+		if err := rws.Scan(&name, &expression); err != nil {
+			return nil, err
+		}
+		definitions = append(definitions, activerecord.CheckConstraintDefinition{
+			Name: name, Table: tableName, Expression: expression,
+		})
+	}
+	return definitions, rws.Err()
+}
+
+// nativeType returns the PostgreSQL type of the column.
+func nativeType(column activerecord.ColumnDefinition) string {
+	switch column.Type.(type) {
+	case *activerecord.Int64:
+		// Integer primary keys are generated by the sequence.
+		if column.IsPrimaryKey {
+			return "BIGSERIAL"
+		}
+		return "BIGINT"
+	case *activerecord.Float64:
+		return "DOUBLE PRECISION"
+	case *activerecord.DateTime:
+		return "TIMESTAMP"
+	case *activerecord.JSON:
+		return "JSONB"
+	default:
+		return column.Type.NativeType()
+	}
+}
+
+// QuoteIdentifier returns the identifier quoted with double quotes.
+func (c *Conn) QuoteIdentifier(name string) string {
+	return activerecord.DefaultDialect.QuoteIdentifier(name)
+}
+
+// NativeType returns the PostgreSQL type of the column.
+func (c *Conn) NativeType(column activerecord.ColumnDefinition) string {
+	return nativeType(column)
+}
+
+func (c *Conn) CreateTable(ctx context.Context, table *activerecord.Table) error {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, `CREATE TABLE %q (`, table.Name())
+
+	var primaryKey string
+
+	for _, column := range table.Columns() {
+		columnType := nativeType(column)
+		if column.NotNull {
+			columnType += " NOT NULL"
+		}
+		if column.IsPrimaryKey {
+			primaryKey = column.Name
+		}
+		fmt.Fprintf(&buf, `%q %s, `, column.Name, columnType)
+	}
+
+	for _, target := range table.ForeignKeys() {
+		fk := ForeignKey(target)
+		fmt.Fprintf(&buf, `FOREIGN KEY (%q) REFERENCES %q ("id"), `, fk, target)
+	}
+
+	fmt.Fprintf(&buf, `PRIMARY KEY (%q))`, primaryKey)
+	_, err := c.ConnectionStatements.ExecContext(ctx, buf.String())
+	return err
+}
+
+func (c *Conn) AddForeignKey(ctx context.Context, owner, target string) error {
+	fk := ForeignKey(target)
+	stmt := fmt.Sprintf(
+		`ALTER TABLE %q ADD CONSTRAINT "fk_%s_on_%s" FOREIGN KEY (%q) REFERENCES %q ("id")`,
+		owner, owner, target, fk, target,
+	)
+	_, err := c.ConnectionStatements.ExecContext(ctx, stmt)
+	return err
+}
+
+// Rebind replaces "?" placeholders of the query with positional "$n" placeholders
+// used by PostgreSQL. Question marks within string literals, quoted identifiers
+// and comments are left intact.
+func Rebind(query string) string {
+	var (
+		buf strings.Builder
+		n   int
+	)
+	for i := 0; i < len(query); i++ {
+		switch ch := query[i]; {
+		case ch == '\'' || ch == '"':
+			end := strings.IndexByte(query[i+1:], ch)
+			if end < 0 {
+				buf.WriteString(query[i:])
+				return buf.String()
+			}
+			buf.WriteString(query[i : i+end+2])
+			i += end + 1
+		case ch == '-' && strings.HasPrefix(query[i:], "--"):
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				buf.WriteString(query[i:])
+				return buf.String()
+			}
+			buf.WriteString(query[i : i+end])
+			i += end - 1
+		case ch == '/' && strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				buf.WriteString(query[i:])
+				return buf.String()
+			}
+			buf.WriteString(query[i : i+end+4])
+			i += end + 3
+		case ch == '?':
+			n++
+			fmt.Fprintf(&buf, "$%d", n)
+		default:
+			buf.WriteByte(ch)
+		}
+	}
+	return buf.String()
 }
@@ -0,0 +1,57 @@
+package postgresql_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/activegraph/activegraph/activerecord"
+	"github.com/activegraph/activegraph/activerecord/postgresql"
+)
+
+func TestRebind(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{`SELECT * FROM "users"`, `SELECT * FROM "users"`},
+		{
+			`SELECT * FROM "users" WHERE (id = ?) AND (name = ?)`,
+			`SELECT * FROM "users" WHERE (id = $1) AND (name = $2)`,
+		},
+		{
+			`SELECT * FROM "users" WHERE (name = 'what?') AND ("who?" = ?)`,
+			`SELECT * FROM "users" WHERE (name = 'what?') AND ("who?" = $1)`,
+		},
+		{
+			`SELECT * FROM "users" WHERE (id = ?) /* why? */ -- how?`,
+			`SELECT * FROM "users" WHERE (id = $1) /* why? */ -- how?`,
+		},
+		{`SELECT 'it''s?', ?`, `SELECT 'it''s?', $1`},
+	}
+
+	for _, tt := range tests {
+		require.Equal(t, tt.expected, postgresql.Rebind(tt.query))
+	}
+}
+
+func TestArrayType(t *testing.T) {
+	ints := &postgresql.ArrayType{Elem: new(activerecord.Int64)}
+	require.Equal(t, "INTEGER[]", ints.NativeType())
+
+	value, err := ints.Deserialize("{1,NULL,3}")
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{int64(1), nil, int64(3)}, value)
+
+	strs := &postgresql.ArrayType{Elem: new(activerecord.String)}
+	serialized, err := strs.Serialize([]string{`a "quoted"`, `back\slash`})
+	require.NoError(t, err)
+	require.Equal(t, `{"a \"quoted\"","back\\slash"}`, serialized)
+
+	value, err = strs.Deserialize(serialized)
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{`a "quoted"`, `back\slash`}, value)
+
+	_, err = ints.Deserialize(42)
+	require.Error(t, err)
+}
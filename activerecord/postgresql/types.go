@@ -0,0 +1,137 @@
+package postgresql
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgtype"
+
+	"github.com/activegraph/activegraph/activerecord"
+)
+
+// UUID is a universally unique identifier represented as a string in
+// "xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx" format.
+type UUID struct{}
+
+func (*UUID) NativeType() string { return "UUID" }
+
+func (*UUID) String() string { return "uuid" }
+
+func (u *UUID) Deserialize(value interface{}) (interface{}, error) {
+	switch value := value.(type) {
+	case string:
+		return value, nil
+	case []byte:
+		if len(value) == 16 {
+			return fmt.Sprintf("%x-%x-%x-%x-%x",
+				value[0:4], value[4:6], value[6:8], value[8:10], value[10:16],
+			), nil
+		}
+		return string(value), nil
+	default:
+		return nil, activerecord.ErrType{TypeName: u.String(), Value: value}
+	}
+}
+
+func (*UUID) Serialize(value interface{}) (interface{}, error) {
+	return value, nil
+}
+
+// ArrayType is a one-dimensional array of elements of the given type. Values are
+// deserialized into []interface{}, absent elements are represented with nil.
+type ArrayType struct {
+	Elem activerecord.Type
+}
+
+func (a *ArrayType) NativeType() string {
+	return a.Elem.NativeType() + "[]"
+}
+
+func (a *ArrayType) String() string {
+	return "[]" + a.Elem.String()
+}
+
+func (a *ArrayType) Deserialize(value interface{}) (interface{}, error) {
+	var text string
+	switch value := value.(type) {
+	case string:
+		text = value
+	case []byte:
+		text = string(value)
+	case []interface{}:
+		return value, nil
+	default:
+		return nil, activerecord.ErrType{TypeName: a.String(), Value: value}
+	}
+
+	array, err := pgtype.ParseUntypedTextArray(text)
+	if err != nil {
+		return nil, activerecord.ErrType{TypeName: a.String(), Value: value}
+	}
+
+	elems := make([]interface{}, len(array.Elements))
+	for i, elem := range array.Elements {
+		if !array.Quoted[i] && elem == "NULL" {
+			continue
+		}
+		if elems[i], err = a.parseElem(elem); err != nil {
+			return nil, activerecord.ErrType{TypeName: a.String(), Value: value}
+		}
+	}
+	return elems, nil
+}
+
+// parseElem converts the text representation of the element to the value
+// accepted by the element type.
+func (a *ArrayType) parseElem(text string) (interface{}, error) {
+	var (
+		value interface{} = text
+		err   error
+	)
+	switch a.Elem.(type) {
+	case *activerecord.Int64:
+		value, err = strconv.ParseInt(text, 10, 64)
+	case *activerecord.Float64:
+		value, err = strconv.ParseFloat(text, 64)
+	case *activerecord.Boolean:
+		value, err = strconv.ParseBool(text)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return a.Elem.Deserialize(value)
+}
+
+// Serialize converts the slice into the text representation of PostgreSQL array.
+func (a *ArrayType) Serialize(value interface{}) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	val := reflect.ValueOf(value)
+	if val.Kind() != reflect.Slice && val.Kind() != reflect.Array {
+		return nil, activerecord.ErrType{TypeName: a.String(), Value: value}
+	}
+
+	elems := make([]string, val.Len())
+	for i := range elems {
+		elem := val.Index(i).Interface()
+		if elem == nil {
+			elems[i] = "NULL"
+			continue
+		}
+
+		serialized, err := a.Elem.Serialize(elem)
+		if err != nil {
+			return nil, err
+		}
+
+		text := fmt.Sprintf("%v", serialized)
+		text = strings.ReplaceAll(text, `\`, `\\`)
+		text = strings.ReplaceAll(text, `"`, `\"`)
+		elems[i] = `"` + text + `"`
+	}
+	return "{" + strings.Join(elems, ",") + "}", nil
+}
@@ -0,0 +1,153 @@
+package activerecord
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Condition builds a predicate for the given column. Conditions are accepted by
+// the Where method in place of a plain value, when a comparison other than
+// equality is required.
+//
+//	Product.Where("price", activerecord.GreaterThan(100))
+//	// SELECT * FROM "products" WHERE (price > ?)
+type Condition interface {
+	Predicate(column string, t Type) Predicate
+}
+
+// ConditionFunc is a function adapter for Condition interface.
+type ConditionFunc func(column string, t Type) Predicate
+
+func (fn ConditionFunc) Predicate(column string, t Type) Predicate {
+	return fn(column, t)
+}
+
+// serialize converts the value into the database representation of the given type.
+// When the value cannot be serialized, it is passed as is, so the database driver
+// could decide on the value conversion.
+func serialize(t Type, value interface{}) interface{} {
+	if t == nil {
+		return value
+	}
+	serialized, err := t.Serialize(value)
+	if err != nil {
+		return value
+	}
+	return serialized
+}
+
+// isNull matches the absent values.
+var isNull = ConditionFunc(func(column string, t Type) Predicate {
+	return Predicate{Cond: fmt.Sprintf("%s IS NULL", column)}
+})
+
+func comparison(operator string, value interface{}) Condition {
+	return ConditionFunc(func(column string, t Type) Predicate {
+		return Predicate{
+			Cond: fmt.Sprintf("%s %s ?", column, operator),
+			Args: []interface{}{serialize(t, value)},
+		}
+	})
+}
+
+// in matches values equal to one of the given values, an empty list of values
+// does not match any values.
+func in(values []interface{}) Condition {
+	return ConditionFunc(func(column string, t Type) Predicate {
+		if len(values) == 0 {
+			return Predicate{Cond: "1=0"}
+		}
+
+		args := make([]interface{}, len(values))
+		for i := range values {
+			args[i] = serialize(t, values[i])
+		}
+		return Predicate{
+			Cond: fmt.Sprintf("%s IN (%s)", column,
+				strings.TrimSuffix(strings.Repeat("?, ", len(values)), ", "),
+			),
+			Args: args,
+		}
+	})
+}
+
+// In returns a condition that matches values equal to one of the given values,
+// an empty list of values does not match any values.
+//
+//	Book.Where("author_id", activerecord.In(1, 2, 3))
+//	// SELECT * FROM "books" WHERE (author_id IN (?, ?, ?))
+func In(values ...interface{}) Condition {
+	return in(values)
+}
+
+// Between returns a condition that matches values within the given range, both
+// bounds are included into the range.
+//
+//	Order.Where("created_at", activerecord.Between(yesterday, today))
+//	// SELECT * FROM "orders" WHERE (created_at BETWEEN ? AND ?)
+func Between(from, to interface{}) Condition {
+	return ConditionFunc(func(column string, t Type) Predicate {
+		return Predicate{
+			Cond: fmt.Sprintf("%s BETWEEN ? AND ?", column),
+			Args: []interface{}{serialize(t, from), serialize(t, to)},
+		}
+	})
+}
+
+// GreaterThan returns a condition that matches values greater than the given one.
+func GreaterThan(value interface{}) Condition {
+	return comparison(">", value)
+}
+
+// GreaterThanOrEq returns a condition that matches values greater than or equal
+// to the given one.
+func GreaterThanOrEq(value interface{}) Condition {
+	return comparison(">=", value)
+}
+
+// LessThan returns a condition that matches values less than the given one.
+func LessThan(value interface{}) Condition {
+	return comparison("<", value)
+}
+
+// LessThanOrEq returns a condition that matches values less than or equal to
+// the given one.
+func LessThanOrEq(value interface{}) Condition {
+	return comparison("<=", value)
+}
+
+// CaseInsensitive returns a condition that matches values equal to the given one
+// ignoring the case of letters.
+//
+//	User.Where("email", activerecord.CaseInsensitive("Foo@Bar.com"))
+//	// SELECT * FROM "users" WHERE (LOWER(email) = LOWER(?))
+func CaseInsensitive(value interface{}) Condition {
+	return ConditionFunc(func(column string, t Type) Predicate {
+		return Predicate{
+			Cond: fmt.Sprintf("LOWER(%s) = LOWER(?)", column),
+			Args: []interface{}{serialize(t, value)},
+		}
+	})
+}
+
+// Like returns a condition that matches values to the LIKE pattern. Use
+// SanitizeSQLLike to escape wildcards of user input included into the pattern.
+func Like(pattern string) Condition {
+	return ConditionFunc(func(column string, t Type) Predicate {
+		return Predicate{
+			Cond: fmt.Sprintf("%s LIKE ? ESCAPE '%s'", column, likeEscape),
+			Args: []interface{}{pattern},
+		}
+	})
+}
+
+// ILike returns a condition that matches values to the LIKE pattern ignoring
+// the case of letters.
+func ILike(pattern string) Condition {
+	return ConditionFunc(func(column string, t Type) Predicate {
+		return Predicate{
+			Cond: fmt.Sprintf("LOWER(%s) LIKE LOWER(?) ESCAPE '%s'", column, likeEscape),
+			Args: []interface{}{pattern},
+		}
+	})
+}
@@ -0,0 +1,225 @@
+package activerecord
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	. "github.com/activegraph/activegraph/activesupport"
+)
+
+// ErrPrepareEnvironment is returned, when tables are prepared in the environment
+// other than development or test.
+type ErrPrepareEnvironment struct {
+	Env string
+}
+
+func (e *ErrPrepareEnvironment) Is(target error) bool {
+	_, ok := target.(*ErrPrepareEnvironment)
+	return ok
+}
+
+func (e *ErrPrepareEnvironment) Error() string {
+	return fmt.Sprintf("tables are prepared only in development and test environments, not in %q", e.Env)
+}
+
+var (
+	declarationsMu sync.Mutex
+	declarations   = make(map[string]func(*R))
+)
+
+// Declare declares the relation without the connection to the database, so
+// the table of the relation is created by Prepare. Declared relations are
+// initialized with the declaration, when New is called without the init
+// function:
+//
+//	activerecord.Declare("author", func(r *activerecord.R) {
+//		r.DefineAttribute("name", new(activerecord.String))
+//	})
+//
+//	err := activerecord.Prepare(ctx)
+//	Author := activerecord.New("author")
+func Declare(name string, init ...func(*R)) {
+	var fn func(*R)
+	switch len(init) {
+	case 0:
+		fn = func(*R) {}
+	case 1:
+		fn = init[0]
+	default:
+		panic(&ErrMultipleVariadicArguments{Name: "init"})
+	}
+
+	declarationsMu.Lock()
+	defer declarationsMu.Unlock()
+	declarations[name] = fn
+}
+
+func declaration(name string) func(*R) {
+	declarationsMu.Lock()
+	defer declarationsMu.Unlock()
+	return declarations[name]
+}
+
+// Prepare creates tables of declared relations, which do not exist, and adds
+// columns of declared attributes missing in existing tables. Columns are never
+// changed or removed, use migrations to evolve the schema of the database.
+//
+// Tables are created with the primary key, declared attributes and foreign keys
+// of BelongsTo associations. Attributes of Nil types are nullable, columns
+// added to existing tables are always nullable.
+//
+// Prepare is intended for prototypes, ErrPrepareEnvironment is returned, when
+// the current environment is not development or test (see CurrentEnv).
+func Prepare(ctx context.Context) error {
+	if env := CurrentEnv(); env != "development" && env != "test" {
+		return &ErrPrepareEnvironment{Env: env}
+	}
+
+	declarationsMu.Lock()
+	names := make([]string, 0, len(declarations))
+	for name := range declarations {
+		names = append(names, name)
+	}
+	declarationsMu.Unlock()
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := prepare(ctx, name, declaration(name)); err != nil {
+			return fmt.Errorf("prepare %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func prepare(ctx context.Context, name string, init func(*R)) error {
+	r := R{
+		rel:         &Relation{name: name},
+		assocs:      make(associationsMap),
+		attrs:       make(attributesMap),
+		validators:  make(validatorsMap),
+		reflection:  NewReflection(),
+		connections: globalConnectionHandler,
+	}
+	init(&r)
+	if r.tableName == "" {
+		r.tableName = Pluralize(name)
+	}
+
+	conn, err := r.connections.resolve(ctx, r.spec)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cache := SchemaCacheOf(conn); cache != nil {
+			cache.ClearTable(r.tableName)
+		}
+	}()
+
+	d := DialectOf(conn)
+	columns := r.declaredColumns()
+
+	existing, err := conn.ColumnDefinitions(ctx, r.tableName)
+	if errors.Is(err, ErrTableNotExist{TableName: r.tableName}) {
+		_, err = conn.ExecStatement(ctx, &QueryOperation{
+			Text: createTableStatement(d, r.tableName, columns),
+		})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	existingNames := make(map[string]struct{}, len(existing))
+	for _, column := range existing {
+		existingNames[column.Name] = struct{}{}
+	}
+	for _, column := range columns {
+		if _, ok := existingNames[column.Name]; ok {
+			continue
+		}
+		// Existing rows do not have values of the new column.
+		column.NotNull = false
+		_, err = conn.ExecStatement(ctx, &QueryOperation{
+			Text: fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s",
+				d.QuoteIdentifier(r.tableName), columnStatement(d, column),
+			),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// declaredColumns returns columns of declared attributes and foreign keys of
+// associations, the primary key column is the first one.
+func (r *R) declaredColumns() []ColumnDefinition {
+	primaryKey := r.primaryKey
+	if primaryKey == "" {
+		primaryKey = defaultPrimaryKeyName
+	}
+
+	columns := []ColumnDefinition{declaredColumn(primaryKey, new(Int64))}
+	if attr, ok := r.attrs[primaryKey]; ok {
+		columns[0] = declaredColumn(primaryKey, attr.AttributeType())
+	}
+	columns[0].IsPrimaryKey, columns[0].NotNull = true, true
+
+	attrNames := make([]string, 0, len(r.attrs))
+	for attrName := range r.attrs {
+		if attrName != primaryKey {
+			attrNames = append(attrNames, attrName)
+		}
+	}
+	for _, assoc := range r.assocs {
+		if assoc, ok := assoc.(*BelongsTo); ok {
+			if _, defined := r.attrs[assoc.AssociationForeignKey()]; !defined {
+				attrNames = append(attrNames, assoc.AssociationForeignKey())
+			}
+		}
+	}
+	sort.Strings(attrNames)
+
+	for _, attrName := range attrNames {
+		if attr, ok := r.attrs[attrName]; ok {
+			columns = append(columns, declaredColumn(attrName, attr.AttributeType()))
+		} else {
+			columns = append(columns, declaredColumn(attrName, Nil{new(Int64)}))
+		}
+	}
+	return columns
+}
+
+func declaredColumn(name string, t Type) ColumnDefinition {
+	if t, ok := t.(Nil); ok {
+		return ColumnDefinition{Name: name, Type: t.Type}
+	}
+	return ColumnDefinition{Name: name, Type: t, NotNull: true}
+}
+
+func columnStatement(d SchemaDialect, column ColumnDefinition) string {
+	stmt := d.QuoteIdentifier(column.Name) + " " + d.NativeType(column)
+	if column.NotNull {
+		stmt += " NOT NULL"
+	}
+	return stmt
+}
+
+func createTableStatement(d SchemaDialect, tableName string, columns []ColumnDefinition) string {
+	var (
+		defs        = make([]string, 0, len(columns)+1)
+		primaryKeys []string
+	)
+	for _, column := range columns {
+		defs = append(defs, columnStatement(d, column))
+		if column.IsPrimaryKey {
+			primaryKeys = append(primaryKeys, d.QuoteIdentifier(column.Name))
+		}
+	}
+	defs = append(defs, fmt.Sprintf("PRIMARY KEY (%s)", strings.Join(primaryKeys, ", ")))
+	return fmt.Sprintf("CREATE TABLE %s (%s)", d.QuoteIdentifier(tableName), strings.Join(defs, ", "))
+}
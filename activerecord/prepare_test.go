@@ -0,0 +1,66 @@
+package activerecord_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/activegraph/activegraph/activerecord"
+	_ "github.com/activegraph/activegraph/activerecord/sqlite3"
+	. "github.com/activegraph/activegraph/activesupport"
+)
+
+func TestPrepare(t *testing.T) {
+	conn, err := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter: "sqlite3", Database: t.Name() + ".db",
+	})
+	require.NoError(t, err)
+
+	defer os.Remove(t.Name() + ".db")
+	defer activerecord.RemoveConnection("primary")
+
+	activerecord.Declare("prepared_author", func(r *activerecord.R) {
+		r.DefineAttribute("name", new(activerecord.String))
+	})
+	activerecord.Declare("prepared_book", func(r *activerecord.R) {
+		r.DefineAttribute("title", activerecord.Nil{new(activerecord.String)})
+		r.BelongsTo("prepared_author")
+	})
+
+	ctx := context.TODO()
+	require.NoError(t, activerecord.Prepare(ctx))
+
+	columns, err := conn.ColumnDefinitions(ctx, "prepared_books")
+	require.NoError(t, err)
+	require.Equal(t, []activerecord.ColumnDefinition{
+		{Name: "id", Type: new(activerecord.Int64), NotNull: true, IsPrimaryKey: true},
+		{Name: "prepared_author_id", Type: new(activerecord.Int64)},
+		{Name: "title", Type: new(activerecord.String)},
+	}, columns)
+
+	// Declared relations are initialized with declarations.
+	Author := activerecord.New("prepared_author")
+	author := Author.Create(Hash{"name": "Stanislaw Lem"})
+	require.NoError(t, author.Err())
+
+	// Missing columns are added to existing tables.
+	activerecord.Declare("prepared_author", func(r *activerecord.R) {
+		r.DefineAttribute("name", new(activerecord.String))
+		r.DefineAttribute("born", activerecord.Nil{new(activerecord.Int64)})
+	})
+	require.NoError(t, activerecord.Prepare(ctx))
+
+	Author = activerecord.New("prepared_author")
+	require.NoError(t, Author.Create(Hash{"name": "Ada Lovelace", "born": int64(1815)}).Err())
+
+	names, err := Author.Order("name").Pluck("name", "born")
+	require.NoError(t, err)
+	require.Equal(t, [][]interface{}{{"Ada Lovelace", int64(1815)}, {"Stanislaw Lem", nil}}, names)
+
+	t.Setenv(activerecord.EnvVariable, "production")
+	err = activerecord.Prepare(ctx)
+	require.True(t, errors.Is(err, new(activerecord.ErrPrepareEnvironment)), err)
+}
@@ -0,0 +1,307 @@
+package activerecord
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	. "github.com/activegraph/activegraph/activesupport"
+)
+
+// ErrProto is returned, when attributes cannot be mapped to or from fields of
+// the protobuf message.
+type ErrProto struct {
+	TypeName string
+	Message  string
+}
+
+func (e *ErrProto) Is(target error) bool {
+	_, ok := target.(*ErrProto)
+	return ok
+}
+
+func (e *ErrProto) Error() string {
+	return fmt.Sprintf("ErrProto: cannot map %s, %s", e.TypeName, e.Message)
+}
+
+// protoMapping is the mapping of field numbers to attributes.
+type protoMapping struct {
+	tags map[int]string
+}
+
+// ProtoOption configures the mapping of attributes to protobuf fields.
+type ProtoOption func(*protoMapping)
+
+// ProtoTag maps the field with the number to the attribute, when names of the
+// field and the attribute differ.
+func ProtoTag(number int, attrName string) ProtoOption {
+	return func(m *protoMapping) {
+		m.tags[number] = attrName
+	}
+}
+
+// protoField is the field of the protobuf message mapped to the attribute.
+type protoField struct {
+	attrName string
+	index    int
+}
+
+// protoFields returns fields of the generated message struct mapped to attributes.
+// Fields are described by the "protobuf" tag, e.g. `protobuf:"varint,1,opt,name=id"`,
+// fields are mapped by numbers given by ProtoTag, then by names of fields.
+func protoFields(typ reflect.Type, attrs *attributes, opts []ProtoOption) []protoField {
+	m := protoMapping{tags: make(map[int]string)}
+	for _, opt := range opts {
+		opt(&m)
+	}
+
+	var fields []protoField
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		tag, ok := field.Tag.Lookup("protobuf")
+		if field.PkgPath != "" || !ok {
+			continue
+		}
+
+		var (
+			parts    = strings.Split(tag, ",")
+			attrName string
+		)
+		if len(parts) > 1 {
+			if number, err := strconv.Atoi(parts[1]); err == nil {
+				attrName = m.tags[number]
+			}
+		}
+		for _, part := range parts {
+			if attrName == "" && strings.HasPrefix(part, "name=") {
+				attrName = strings.TrimPrefix(part, "name=")
+			}
+		}
+
+		if attrs.HasAttribute(attrName) {
+			fields = append(fields, protoField{attrName: attrName, index: i})
+		}
+	}
+	return fields
+}
+
+// protoMessage returns the struct of the pointer to the generated message.
+func protoMessage(msg interface{}) (reflect.Value, error) {
+	ptr := reflect.ValueOf(msg)
+	if ptr.Kind() != reflect.Ptr || ptr.IsNil() || ptr.Elem().Kind() != reflect.Struct {
+		return reflect.Value{}, &ErrProto{
+			TypeName: fmt.Sprintf("%T", msg), Message: "message is not a pointer to a struct",
+		}
+	}
+	return ptr.Elem(), nil
+}
+
+// isProtoTimestamp returns true for google.protobuf.Timestamp messages.
+func isProtoTimestamp(typ reflect.Type) bool {
+	if typ.Kind() != reflect.Ptr || typ.Elem().Kind() != reflect.Struct {
+		return false
+	}
+	seconds, ok := typ.Elem().FieldByName("Seconds")
+	if !ok || seconds.Type.Kind() != reflect.Int64 {
+		return false
+	}
+	nanos, ok := typ.Elem().FieldByName("Nanos")
+	return ok && nanos.Type.Kind() == reflect.Int32
+}
+
+// isProtoWrapper returns true for wrappers of scalar values, e.g. messages of
+// google.protobuf.Int64Value type.
+func isProtoWrapper(typ reflect.Type) bool {
+	if typ.Kind() != reflect.Ptr || typ.Elem().Kind() != reflect.Struct {
+		return false
+	}
+	value, ok := typ.Elem().FieldByName("Value")
+	return ok && value.Tag.Get("protobuf") != ""
+}
+
+// protoAssign assigns the value of the attribute to the field of the message.
+// Nil values reset fields to zero values, integers are checked for overflows.
+func protoAssign(dst reflect.Value, value interface{}) error {
+	if value == nil {
+		dst.Set(reflect.Zero(dst.Type()))
+		return nil
+	}
+
+	if t, ok := value.(time.Time); ok {
+		switch {
+		case dst.Kind() == reflect.String:
+			dst.SetString(t.Format(time.RFC3339Nano))
+			return nil
+		case isProtoTimestamp(dst.Type()):
+			ts := reflect.New(dst.Type().Elem())
+			ts.Elem().FieldByName("Seconds").SetInt(t.Unix())
+			ts.Elem().FieldByName("Nanos").SetInt(int64(t.Nanosecond()))
+			dst.Set(ts)
+			return nil
+		}
+	}
+
+	if isProtoWrapper(dst.Type()) {
+		wrapper := reflect.New(dst.Type().Elem())
+		if err := protoAssign(wrapper.Elem().FieldByName("Value"), value); err != nil {
+			return err
+		}
+		dst.Set(wrapper)
+		return nil
+	}
+	// Optional scalar fields are pointers.
+	if dst.Kind() == reflect.Ptr {
+		ptr := reflect.New(dst.Type().Elem())
+		if err := protoAssign(ptr.Elem(), value); err != nil {
+			return err
+		}
+		dst.Set(ptr)
+		return nil
+	}
+
+	val := reflect.ValueOf(value)
+	switch dst.Kind() {
+	case reflect.Int32, reflect.Int64:
+		var intval int64
+		switch val.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			intval = val.Int()
+		default:
+			return pluckAssign(dst, value)
+		}
+		if dst.OverflowInt(intval) {
+			return fmt.Errorf("%d overflows %s", intval, dst.Type())
+		}
+		dst.SetInt(intval)
+	case reflect.Uint32, reflect.Uint64:
+		switch val.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if val.Int() < 0 || dst.OverflowUint(uint64(val.Int())) {
+				return fmt.Errorf("%d overflows %s", val.Int(), dst.Type())
+			}
+			dst.SetUint(uint64(val.Int()))
+		default:
+			return pluckAssign(dst, value)
+		}
+	default:
+		return pluckAssign(dst, value)
+	}
+	return nil
+}
+
+// protoValue returns the value of the message field: integers are returned as
+// int64, floats as float64 and timestamps as time.Time.
+func protoValue(src reflect.Value) (interface{}, error) {
+	switch {
+	case isProtoTimestamp(src.Type()):
+		if src.IsNil() {
+			return nil, nil
+		}
+		seconds := src.Elem().FieldByName("Seconds").Int()
+		nanos := src.Elem().FieldByName("Nanos").Int()
+		return time.Unix(seconds, nanos).UTC(), nil
+	case isProtoWrapper(src.Type()):
+		if src.IsNil() {
+			return nil, nil
+		}
+		return protoValue(src.Elem().FieldByName("Value"))
+	}
+
+	switch src.Kind() {
+	case reflect.Ptr:
+		if src.IsNil() {
+			return nil, nil
+		}
+		return protoValue(src.Elem())
+	case reflect.Int32, reflect.Int64:
+		return src.Int(), nil
+	case reflect.Uint32, reflect.Uint64:
+		if src.Uint() > 1<<63-1 {
+			return nil, fmt.Errorf("%d overflows int64", src.Uint())
+		}
+		return int64(src.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return src.Float(), nil
+	case reflect.Bool:
+		return src.Bool(), nil
+	case reflect.String:
+		return src.String(), nil
+	case reflect.Slice:
+		if src.Type().Elem().Kind() == reflect.Uint8 {
+			return src.Bytes(), nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported field type %s", src.Type())
+}
+
+// ToProto assigns attributes of the record to fields of the protobuf message
+// with the same names, e.g. the attribute "author_id" is assigned to the field
+// `protobuf:"varint,2,opt,name=author_id"`:
+//
+//	var msg pb.Book
+//	err := book.ToProto(&msg, activerecord.ProtoTag(3, "title"))
+//
+// Time attributes are assigned to google.protobuf.Timestamp fields or strings in
+// RFC 3339 format, nil values reset fields. Fields without attributes are left
+// untouched.
+func (r *ActiveRecord) ToProto(msg interface{}, opts ...ProtoOption) error {
+	elem, err := protoMessage(msg)
+	if err != nil {
+		return err
+	}
+
+	for _, field := range protoFields(elem.Type(), r.attributes, opts) {
+		err := protoAssign(elem.Field(field.index), r.Attribute(field.attrName))
+		if err != nil {
+			return &ErrProto{TypeName: elem.Type().String(), Message: fmt.Sprintf(
+				"attribute %q: %s", field.attrName, err,
+			)}
+		}
+	}
+	return nil
+}
+
+// protoParams returns attributes of fields of the protobuf message.
+func protoParams(attrs *attributes, msg interface{}, opts []ProtoOption) (Hash, error) {
+	elem, err := protoMessage(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := protoFields(elem.Type(), attrs, opts)
+	params := make(Hash, len(fields))
+	for _, field := range fields {
+		value, err := protoValue(elem.Field(field.index))
+		if err != nil {
+			return nil, &ErrProto{TypeName: elem.Type().String(), Message: fmt.Sprintf(
+				"attribute %q: %s", field.attrName, err,
+			)}
+		}
+		params[field.attrName] = value
+	}
+	return params, nil
+}
+
+// FromProto builds a new record of the relation from fields of the protobuf
+// message mapped the same way as by ActiveRecord.ToProto.
+//
+//	book := Book.FromProto(req.GetBook())
+func (rel *Relation) FromProto(msg interface{}, opts ...ProtoOption) RecordResult {
+	params, err := protoParams(rel.scope, msg, opts)
+	if err != nil {
+		return ErrRecord(err)
+	}
+	return ReturnRecord(rel.Initialize(params))
+}
+
+// FromProto assigns attributes of the record from fields of the protobuf message.
+// Either all attributes are assigned, or none in case of error.
+func (r *ActiveRecord) FromProto(msg interface{}, opts ...ProtoOption) error {
+	params, err := protoParams(r.attributes, msg, opts)
+	if err != nil {
+		return err
+	}
+	return r.AssignAttributes(params)
+}
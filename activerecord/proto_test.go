@@ -0,0 +1,82 @@
+package activerecord_test
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/activegraph/activegraph/activerecord"
+	_ "github.com/activegraph/activegraph/activerecord/sqlite3"
+	. "github.com/activegraph/activegraph/activesupport"
+)
+
+// timestamp mimics the generated google.protobuf.Timestamp message.
+type timestamp struct {
+	Seconds int64 `protobuf:"varint,1,opt,name=seconds,proto3"`
+	Nanos   int32 `protobuf:"varint,2,opt,name=nanos,proto3"`
+}
+
+// articleMessage mimics the generated message of articles.
+type articleMessage struct {
+	state int
+
+	Id          int32      `protobuf:"varint,1,opt,name=id,proto3"`
+	Heading     string     `protobuf:"bytes,2,opt,name=heading,proto3"`
+	Rating      *float64   `protobuf:"fixed64,3,opt,name=rating,proto3,oneof"`
+	PublishedAt *timestamp `protobuf:"bytes,4,opt,name=published_at,proto3"`
+	Unknown     string     `protobuf:"bytes,5,opt,name=unknown,proto3"`
+}
+
+func TestActiveRecord_ToProto(t *testing.T) {
+	_, err := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter: "sqlite3", Database: t.Name() + ".db",
+	})
+	require.NoError(t, err)
+
+	defer os.Remove(t.Name() + ".db")
+	defer activerecord.RemoveConnection("primary")
+
+	activerecord.Migrate(t.Name()+"_add_articles_table", func(m *activerecord.M) {
+		m.CreateTable("articles", func(t *activerecord.Table) {
+			t.String("title")
+			t.DefineColumn("rating", &activerecord.Float64{})
+			t.DateTime("published_at")
+		})
+	})
+
+	Article := activerecord.New("article")
+
+	publishedAt := time.Date(2023, 1, 1, 12, 0, 0, 5, time.UTC)
+	article := Article.New(Hash{
+		"id": int64(1), "title": "Solaris", "rating": nil, "published_at": publishedAt,
+	}).Unwrap()
+
+	msg := articleMessage{Unknown: "untouched"}
+	err = article.ToProto(&msg, activerecord.ProtoTag(2, "title"))
+	require.NoError(t, err)
+	require.Equal(t, articleMessage{
+		Id:          1,
+		Heading:     "Solaris",
+		PublishedAt: &timestamp{Seconds: publishedAt.Unix(), Nanos: 5},
+		Unknown:     "untouched",
+	}, msg)
+
+	rating := 4.5
+	msg.Heading, msg.Rating = "Eden", &rating
+
+	article = Article.FromProto(&msg, activerecord.ProtoTag(2, "title")).Unwrap()
+	require.Equal(t, int64(1), article.ID())
+	require.Equal(t, "Eden", article.Attribute("title"))
+	require.Equal(t, 4.5, article.Attribute("rating"))
+	require.Equal(t, publishedAt, article.Attribute("published_at"))
+
+	err = article.ToProto(msg)
+	require.True(t, errors.Is(err, new(activerecord.ErrProto)))
+
+	article = Article.New(Hash{"id": int64(1) << 40}).Unwrap()
+	err = article.ToProto(&msg)
+	require.True(t, errors.Is(err, new(activerecord.ErrProto)))
+}
@@ -19,38 +19,98 @@ type QueryMethods interface {
 	Where(cond string, arg interface{}) *Relation
 	Select(attrs ...string) *Relation
 	Group(attrs ...string) *Relation
+	Order(values ...string) *Relation
 	Joins(assocs ...string) *Relation
 	Limit(num int) *Relation
 }
 
+// Clause identifies a part of the query, which could be removed from the relation
+// using Unscope method.
+type Clause string
+
+const (
+	WhereClause Clause = "where"
+	GroupClause Clause = "group"
+	OrderClause Clause = "order"
+	LimitClause Clause = "limit"
+	JoinsClause Clause = "joins"
+)
+
 type QueryBuilder struct {
 	from  string
 	limit *int
 
+	// source overrides the table in the FROM clause.
+	source string
+
 	selectValues []string
 	whereValues  []Predicate
 	groupValues  []string
+	orderValues  []string
 	joinValues   []join
+	annotations  []string
 }
 
 func (q *QueryBuilder) copy() *QueryBuilder {
 	newq := QueryBuilder{
 		from:         q.from,
 		limit:        q.limit,
+		source:       q.source,
 		selectValues: make([]string, len(q.selectValues)),
 		whereValues:  make([]Predicate, len(q.whereValues)),
 		groupValues:  make([]string, len(q.groupValues)),
+		orderValues:  make([]string, len(q.orderValues)),
 		joinValues:   make([]join, len(q.joinValues)),
+		annotations:  make([]string, len(q.annotations)),
 	}
 
 	copy(newq.selectValues, q.selectValues)
 	copy(newq.whereValues, q.whereValues)
 	copy(newq.groupValues, q.groupValues)
+	copy(newq.orderValues, q.orderValues)
 	copy(newq.joinValues, q.joinValues)
+	copy(newq.annotations, q.annotations)
 
 	return &newq
 }
 
+// merge appends clauses of the other query to the current query. Limit of the
+// other query takes precedence, when it is set.
+func (q *QueryBuilder) merge(other *QueryBuilder) *QueryBuilder {
+	if other.limit != nil {
+		q.limit = other.limit
+	}
+	if other.source != "" {
+		q.source = other.source
+	}
+
+	q.selectValues = append(q.selectValues, other.selectValues...)
+	q.whereValues = append(q.whereValues, other.whereValues...)
+	q.groupValues = append(q.groupValues, other.groupValues...)
+	q.orderValues = append(q.orderValues, other.orderValues...)
+	q.joinValues = append(q.joinValues, other.joinValues...)
+	q.annotations = append(q.annotations, other.annotations...)
+	return q
+}
+
+// unscope removes the specified clauses from the query.
+func (q *QueryBuilder) unscope(clauses ...Clause) {
+	for _, clause := range clauses {
+		switch clause {
+		case WhereClause:
+			q.whereValues = nil
+		case GroupClause:
+			q.groupValues = nil
+		case OrderClause:
+			q.orderValues = nil
+		case LimitClause:
+			q.limit = nil
+		case JoinsClause:
+			q.joinValues = nil
+		}
+	}
+}
+
 func (q *QueryBuilder) From(from string) {
 	q.from = from
 }
@@ -67,10 +127,27 @@ func (q *QueryBuilder) Group(values ...string) {
 	q.groupValues = append(q.groupValues, values...)
 }
 
+func (q *QueryBuilder) Order(values ...string) {
+	q.orderValues = append(q.orderValues, values...)
+}
+
 func (q *QueryBuilder) Join(rel *Relation, assoc Association) {
 	q.joinValues = append(q.joinValues, join{rel, assoc})
 }
 
+// Annotate adds comments to the query, comments are sanitized, so they cannot
+// terminate the comment block.
+func (q *QueryBuilder) Annotate(comments ...string) {
+	for _, comment := range comments {
+		comment = strings.TrimSpace(comment)
+		comment = strings.TrimPrefix(comment, "/*")
+		comment = strings.TrimSuffix(comment, "*/")
+		comment = strings.ReplaceAll(comment, "*/", "* /")
+		comment = strings.ReplaceAll(comment, "/*", "/ *")
+		q.annotations = append(q.annotations, strings.TrimSpace(comment))
+	}
+}
+
 func (q *QueryBuilder) Limit(num int) {
 	q.limit = &num
 }
@@ -88,7 +165,11 @@ func (q *QueryBuilder) String() string {
 	if len(selectValues) == 0 {
 		selectValues = []string{"*"}
 	}
-	fmt.Fprintf(&buf, `SELECT %s FROM "%s"`, strings.Join(selectValues, ", "), q.from)
+	if q.source != "" {
+		fmt.Fprintf(&buf, `SELECT %s FROM %s`, strings.Join(selectValues, ", "), q.source)
+	} else {
+		fmt.Fprintf(&buf, `SELECT %s FROM "%s"`, strings.Join(selectValues, ", "), q.from)
+	}
 
 	for _, join := range q.joinValues {
 		var (
@@ -106,7 +187,7 @@ func (q *QueryBuilder) String() string {
 	}
 
 	for i, where := range q.whereValues {
-		if i < len(q.whereValues)-1 {
+		if i > 0 {
 			fmt.Fprintf(&buf, ` AND`)
 		}
 		fmt.Fprintf(&buf, ` (%s)`, where.Cond)
@@ -115,9 +196,15 @@ func (q *QueryBuilder) String() string {
 	if len(q.groupValues) > 0 {
 		fmt.Fprintf(&buf, ` GROUP BY %s`, strings.Join(q.groupValues, ", "))
 	}
+	if len(q.orderValues) > 0 {
+		fmt.Fprintf(&buf, ` ORDER BY %s`, strings.Join(q.orderValues, ", "))
+	}
 	if q.limit != nil {
 		fmt.Fprintf(&buf, ` LIMIT %d`, *q.limit)
 	}
+	for _, comment := range q.annotations {
+		fmt.Fprintf(&buf, ` /* %s */`, comment)
+	}
 
 	return buf.String()
 }
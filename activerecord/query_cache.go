@@ -0,0 +1,169 @@
+package activerecord
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/activegraph/activegraph/activesupport"
+)
+
+type queryCacheKey struct{}
+
+// queryCache keeps results of select queries per connection. Any write to the
+// database clears the whole cache.
+type queryCache struct {
+	mu   sync.Mutex
+	rows map[Conn]map[string][]activesupport.Hash
+}
+
+func (c *queryCache) key(op *QueryOperation) string {
+	return fmt.Sprintf("%s %#v", op.Text, op.Args)
+}
+
+func (c *queryCache) get(conn Conn, op *QueryOperation) ([]activesupport.Hash, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	rows, ok := c.rows[conn][c.key(op)]
+	return rows, ok
+}
+
+func (c *queryCache) set(conn Conn, op *QueryOperation, rows []activesupport.Hash) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.rows[conn] == nil {
+		c.rows[conn] = make(map[string][]activesupport.Hash)
+	}
+	c.rows[conn][c.key(op)] = rows
+}
+
+func (c *queryCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rows = make(map[Conn]map[string][]activesupport.Hash)
+}
+
+// WithQueryCache returns a copy of the context with enabled query cache. Identical
+// select queries executed with the returned context are retrieved from memory
+// after the first execution. Any write operation executed with the context clears
+// the cache.
+//
+//	func (h *Handler) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+//		ctx := activerecord.WithQueryCache(r.Context())
+//
+//		// Executes the query.
+//		Product.WithContext(ctx).Where("available", true).ToA()
+//		// Returns cached records.
+//		Product.WithContext(ctx).Where("available", true).ToA()
+//	}
+//
+// When the context already has a query cache, it is returned as is.
+func WithQueryCache(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(queryCacheKey{}).(*queryCache); ok {
+		return ctx
+	}
+	cache := &queryCache{rows: make(map[Conn]map[string][]activesupport.Hash)}
+	return context.WithValue(ctx, queryCacheKey{}, cache)
+}
+
+// withQueryCache wraps the connection with a query cache, when the cache is
+// enabled for the given context.
+func withQueryCache(ctx context.Context, conn Conn) Conn {
+	cache, ok := ctx.Value(queryCacheKey{}).(*queryCache)
+	if !ok {
+		return conn
+	}
+	if c, ok := conn.(*cachedConn); ok {
+		if c.cache == cache {
+			return c
+		}
+		conn = c.Conn
+	}
+	return &cachedConn{Conn: conn, cache: cache}
+}
+
+// cachedConn is a connection that returns results of select queries from the
+// query cache.
+type cachedConn struct {
+	Conn
+	cache *queryCache
+}
+
+func (c *cachedConn) BeginTransaction(ctx context.Context, opts *TransactionOptions) (
+	Conn, error,
+) {
+	conn, err := c.Conn.BeginTransaction(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &cachedConn{Conn: conn, cache: c.cache}, nil
+}
+
+func (c *cachedConn) ExecInsert(ctx context.Context, op *InsertOperation) (interface{}, error) {
+	c.cache.clear()
+	return c.Conn.ExecInsert(ctx, op)
+}
+
+func (c *cachedConn) ExecUpdate(ctx context.Context, op *UpdateOperation) error {
+	c.cache.clear()
+	return c.Conn.ExecUpdate(ctx, op)
+}
+
+func (c *cachedConn) ExecDelete(ctx context.Context, op *DeleteOperation) error {
+	c.cache.clear()
+	return c.Conn.ExecDelete(ctx, op)
+}
+
+func (c *cachedConn) ExecStatement(ctx context.Context, op *QueryOperation) (int64, error) {
+	c.cache.clear()
+	return c.Conn.ExecStatement(ctx, op)
+}
+
+func (c *cachedConn) ExecQuery(
+	ctx context.Context, op *QueryOperation, cb func(activesupport.Hash) bool,
+) error {
+	// Cached results are not returned for the cancelled context, as the
+	// database would not return them.
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if rows, ok := c.cache.get(c.Conn, op); ok {
+		for _, row := range rows {
+			if !cb(row.Copy()) {
+				break
+			}
+		}
+		return nil
+	}
+
+	var (
+		rows     []activesupport.Hash
+		complete = true
+	)
+	err := c.Conn.ExecQuery(ctx, op, func(row activesupport.Hash) bool {
+		rows = append(rows, row.Copy())
+		if !cb(row) {
+			complete = false
+		}
+		return complete
+	})
+
+	// Only the complete results are cached, when the reading is terminated
+	// by the callback, the rest of rows is unknown.
+	if err == nil && complete {
+		c.cache.set(c.Conn, op, rows)
+	}
+	return err
+}
+
+func (c *cachedConn) CreateTable(ctx context.Context, table *Table) error {
+	c.cache.clear()
+	return c.Conn.CreateTable(ctx, table)
+}
+
+func (c *cachedConn) AddForeignKey(ctx context.Context, owner, target string) error {
+	c.cache.clear()
+	return c.Conn.AddForeignKey(ctx, owner, target)
+}
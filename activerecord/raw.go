@@ -0,0 +1,109 @@
+package activerecord
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/activegraph/activegraph/activesupport"
+)
+
+// rawConnection returns the connection specified in the context.
+func rawConnection(ctx context.Context) Conn {
+	return connection(ctx, globalConnectionHandler, connectionSpec{}, nil)
+}
+
+// SelectAll executes the SQL query and returns all rows of the result, each row
+// maps column names to values. Queries are executed by the connection specified
+// within the context (primary one by default), including open transactions.
+//
+//	rows, err := activerecord.SelectAll(ctx,
+//		`SELECT "year", COUNT(*) AS "total" FROM "books" GROUP BY "year" HAVING COUNT(*) > ?`, 1,
+//	)
+//	// [{"year": 1961, "total": 2}]
+func SelectAll(ctx context.Context, query string, args ...interface{}) (
+	[]activesupport.Hash, error,
+) {
+	var rows []activesupport.Hash
+
+	op := QueryOperation{Text: query, Args: args}
+	err := rawConnection(ctx).ExecQuery(ctx, &op, func(row activesupport.Hash) bool {
+		rows = append(rows, row)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// SelectValue executes the SQL query and returns the value of the first row. The
+// query must return a single column, nil is returned when there are no rows.
+//
+//	count, err := activerecord.SelectValue(ctx, `SELECT COUNT(*) FROM "books"`)
+func SelectValue(ctx context.Context, query string, args ...interface{}) (
+	value interface{}, err error,
+) {
+	var lasterr error
+
+	op := QueryOperation{Text: query, Args: args}
+	err = rawConnection(ctx).ExecQuery(ctx, &op, func(row activesupport.Hash) bool {
+		if len(row) != 1 {
+			lasterr = fmt.Errorf("expected single column selected, got %d columns", len(row))
+			return false
+		}
+		for _, v := range row {
+			value = v
+		}
+		return false
+	})
+	if lasterr != nil {
+		return nil, lasterr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// Execute executes the SQL statement and returns the number of affected rows.
+//
+//	rows, err := activerecord.Execute(ctx, `UPDATE "books" SET "year" = ? WHERE "year" < ?`, 0, 0)
+func Execute(ctx context.Context, stmt string, args ...interface{}) (int64, error) {
+	op := QueryOperation{Text: stmt, Args: args}
+	return rawConnection(ctx).ExecStatement(ctx, &op)
+}
+
+// FindBySQL executes the SQL query and returns records of the relation. Columns
+// of the result are matched with attributes of the relation by name, attributes
+// missing in the result are left empty.
+//
+//	Book.FindBySQL(`SELECT * FROM "books" WHERE "year" = ? ORDER BY "title"`, 1961)
+func (rel *Relation) FindBySQL(query string, args ...interface{}) (Array, error) {
+	var (
+		records Array
+		lasterr error
+	)
+
+	op := QueryOperation{Text: query, Args: args}
+	err := rel.Connection().ExecQuery(rel.Context(), &op, func(row activesupport.Hash) bool {
+		// Columns of the relation are qualified with the table name.
+		h := make(activesupport.Hash, len(row))
+		for colName, value := range row {
+			h[rel.tableName+"."+colName] = value
+		}
+
+		var rec *ActiveRecord
+		if rec, lasterr = rel.ExtractRecord(h); lasterr != nil {
+			return false
+		}
+		records = append(records, rec)
+		return true
+	})
+	if lasterr != nil {
+		return nil, lasterr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
@@ -8,9 +8,26 @@ import (
 	. "github.com/activegraph/activegraph/activesupport"
 )
 
+// ErrRecordNotFound is returned, when the record is not found by the primary key
+// or by conditions of the relation. Use errors.As to access the relation and the
+// search criteria:
+//
+//	var notFound *activerecord.ErrRecordNotFound
+//	if errors.As(err, &notFound) {
+//		http.Error(rw, notFound.Error(), http.StatusNotFound)
+//	}
 type ErrRecordNotFound struct {
+	// Relation is the name of the relation of the record.
+	Relation string
+
+	// PrimaryKey and ID are the primary key and its value (or values), when the
+	// record is searched by the primary key.
 	PrimaryKey string
 	ID         interface{}
+
+	// Conditions are conditions of the relation, when the record is searched
+	// by conditions.
+	Conditions []string
 }
 
 func (e *ErrRecordNotFound) Is(target error) bool {
@@ -19,7 +36,33 @@ func (e *ErrRecordNotFound) Is(target error) bool {
 }
 
 func (e *ErrRecordNotFound) Error() string {
-	return fmt.Sprintf("record not found by %s = %v", e.PrimaryKey, e.ID)
+	name := "record"
+	if e.Relation != "" {
+		name = e.Relation
+	}
+	switch {
+	case e.PrimaryKey != "":
+		return fmt.Sprintf("%s not found by %s = %v", name, e.PrimaryKey, e.ID)
+	case len(e.Conditions) > 0:
+		return fmt.Sprintf("%s not found by %s", name, strings.Join(e.Conditions, " AND "))
+	default:
+		return name + " not found"
+	}
+}
+
+// ErrSoleRecordExceeded is returned when the relation is expected to have
+// a single record, but has more than one.
+type ErrSoleRecordExceeded struct {
+	RecordName string
+}
+
+func (e *ErrSoleRecordExceeded) Is(target error) bool {
+	_, ok := target.(*ErrSoleRecordExceeded)
+	return ok
+}
+
+func (e *ErrSoleRecordExceeded) Error() string {
+	return fmt.Sprintf("wanted only one %s", e.RecordName)
 }
 
 type ErrRecordNotUnique struct {
@@ -35,6 +78,21 @@ func (e *ErrRecordNotUnique) Error() string {
 	return e.Err.Error()
 }
 
+// ErrReadOnlyRecord is returned on attempt to insert, update or delete the record
+// of the read-only relation.
+type ErrReadOnlyRecord struct {
+	RecordName string
+}
+
+func (e *ErrReadOnlyRecord) Is(target error) bool {
+	_, ok := target.(*ErrReadOnlyRecord)
+	return ok
+}
+
+func (e *ErrReadOnlyRecord) Error() string {
+	return fmt.Sprintf("%s is read-only", e.RecordName)
+}
+
 type CollectionResult struct {
 	Result[*Relation]
 }
@@ -47,6 +105,37 @@ func ErrCollection(err error) CollectionResult {
 	return CollectionResult{Err[*Relation](err)}
 }
 
+// AndThen calls op if the result is Ok, otherwise returns the Err value of the
+// result. Unlike Result.AndThen, the collection result is returned, so calls
+// of relations are chained:
+//
+//	books := author.Collection("books").AndThen(func(rel *Relation) Result[*Relation] {
+//		return rel.Where("year", GreaterThan(2000)).Result
+//	})
+func (c CollectionResult) AndThen(op func(*Relation) Result[*Relation]) CollectionResult {
+	return CollectionResult{c.Result.AndThen(op)}
+}
+
+// OrElse calls op if the result is Err, otherwise returns the Ok value of the result.
+func (c CollectionResult) OrElse(op func(error) Result[*Relation]) CollectionResult {
+	return CollectionResult{c.Result.OrElse(op)}
+}
+
+// Map returns the relation returned by op for the Ok relation of the result,
+// otherwise returns the Err value of the result.
+//
+//	books := author.Collection("books").Map(func(rel *Relation) *Relation {
+//		return rel.Order("year")
+//	})
+func (c CollectionResult) Map(op func(*Relation) *Relation) CollectionResult {
+	return CollectionResult{Map(c.Result, op)}
+}
+
+// WrapErr wraps the Err value of the result with the message.
+func (c CollectionResult) WrapErr(msg string) CollectionResult {
+	return CollectionResult{WrapErr(c.Result, msg)}
+}
+
 func (c CollectionResult) ToA() (Array, error) {
 	if c.IsErr() {
 		return nil, c.Err()
@@ -67,6 +156,91 @@ func (c CollectionResult) DeleteAll() error {
 	return nil
 }
 
+// Each calls fn for each record of the collection, see Relation.Each.
+func (c CollectionResult) Each(fn func(*ActiveRecord) error) error {
+	if c.IsErr() {
+		return c.Err()
+	}
+	if rel := c.Unwrap(); rel != nil {
+		return rel.Each(fn)
+	}
+	return nil
+}
+
+// MapRecords returns values returned by fn for each record of the collection:
+//
+//	titles, err := activerecord.MapRecords(author.Collection("books"),
+//		func(book *activerecord.ActiveRecord) (string, error) {
+//			return book.Attribute("title").(string), nil
+//		},
+//	)
+func MapRecords[T any](c CollectionResult, fn func(*ActiveRecord) (T, error)) ([]T, error) {
+	var values []T
+	err := c.Each(func(rec *ActiveRecord) error {
+		value, err := fn(rec)
+		if err != nil {
+			return err
+		}
+		values = append(values, value)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// Filter returns records of the collection, for which fn returns true. Records
+// are filtered in memory, use Relation.Where to filter records in the database.
+func (c CollectionResult) Filter(fn func(*ActiveRecord) bool) (Array, error) {
+	var records Array
+	err := c.Each(func(rec *ActiveRecord) error {
+		if fn(rec) {
+			records = append(records, rec)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// First returns the first record of the collection, see Relation.First.
+func (c CollectionResult) First() RecordResult {
+	if c.IsErr() {
+		return ErrRecord(c.Err())
+	}
+	if rel := c.Unwrap(); rel != nil {
+		return rel.First()
+	}
+	return OkRecord(nil)
+}
+
+// Last returns the last record of the collection. The order of the collection
+// is reversed, so only the last record is retrieved, collections without the
+// order are ordered by the primary key.
+func (c CollectionResult) Last() RecordResult {
+	if c.IsErr() {
+		return ErrRecord(c.Err())
+	}
+	if rel := c.Unwrap(); rel != nil {
+		return rel.Last()
+	}
+	return OkRecord(nil)
+}
+
+// IsEmpty returns true, when the collection does not have records.
+func (c CollectionResult) IsEmpty() (bool, error) {
+	if c.IsErr() {
+		return false, c.Err()
+	}
+	if rel := c.Unwrap(); rel != nil {
+		return rel.isEmpty()
+	}
+	return true, nil
+}
+
 type RecordResult struct {
 	Result[*ActiveRecord]
 }
@@ -84,7 +258,7 @@ func ReturnRecord(r *ActiveRecord, err error) RecordResult {
 }
 
 func (r RecordResult) andThen(op func(*ActiveRecord) (*ActiveRecord, error)) RecordResult {
-	return RecordResult{r.AndThen(func(r *ActiveRecord) Result[*ActiveRecord] {
+	return RecordResult{r.Result.AndThen(func(r *ActiveRecord) Result[*ActiveRecord] {
 		var (
 			rec *ActiveRecord
 			err error
@@ -96,6 +270,45 @@ func (r RecordResult) andThen(op func(*ActiveRecord) (*ActiveRecord, error)) Rec
 	})}
 }
 
+// AndThen calls op if the result is Ok, otherwise returns the Err value of the
+// result. Unlike Result.AndThen, the record result is returned, so calls of
+// records are chained:
+//
+//	book := author.AndThen(func(author *ActiveRecord) Result[*ActiveRecord] {
+//		return Book.Create(Hash{"author_id": author.ID()}).Result
+//	}).Update()
+func (r RecordResult) AndThen(op func(*ActiveRecord) Result[*ActiveRecord]) RecordResult {
+	return RecordResult{r.Result.AndThen(op)}
+}
+
+// OrElse calls op if the result is Err, otherwise returns the Ok value of the
+// result, e.g. to create the missing record:
+//
+//	author := Author.Find(id).OrElse(func(error) Result[*ActiveRecord] {
+//		return Author.Create(Hash{"id": id, "name": "Borges"}).Result
+//	})
+func (r RecordResult) OrElse(op func(error) Result[*ActiveRecord]) RecordResult {
+	return RecordResult{r.Result.OrElse(op)}
+}
+
+// Map returns the record returned by op for the Ok record of the result,
+// otherwise returns the Err value of the result. Results without records are
+// returned as is.
+func (r RecordResult) Map(op func(*ActiveRecord) *ActiveRecord) RecordResult {
+	return r.andThen(func(r *ActiveRecord) (*ActiveRecord, error) {
+		return op(r), nil
+	})
+}
+
+// WrapErr wraps the Err value of the result with the message, so the original
+// error is still matched by errors.Is:
+//
+//	book := Book.Find(id).WrapErr("show book")
+//	errors.Is(book.Err(), new(ErrRecordNotFound)) // true
+func (r RecordResult) WrapErr(msg string) RecordResult {
+	return RecordResult{WrapErr(r.Result, msg)}
+}
+
 func (r RecordResult) Insert() RecordResult {
 	return r.andThen((*ActiveRecord).Insert)
 }
@@ -109,33 +322,33 @@ func (r RecordResult) Delete() RecordResult {
 }
 
 func (r RecordResult) Association(name string) RecordResult {
-	return RecordResult{r.AndThen(func(r *ActiveRecord) Result[*ActiveRecord] {
-		return r.Association(name)
-	})}
+	return r.AndThen(func(r *ActiveRecord) Result[*ActiveRecord] {
+		return r.Association(name).Result
+	})
 }
 
 func (r RecordResult) AssignAssociation(name string, target RecordResult) RecordResult {
-	return RecordResult{r.AndThen(func(r *ActiveRecord) Result[*ActiveRecord] {
+	return r.AndThen(func(r *ActiveRecord) Result[*ActiveRecord] {
 		if target.IsErr() {
-			return target
+			return target.Result
 		}
 		err := r.associations.AssignAssociation(name, target.Unwrap())
-		return ReturnRecord(r, err)
-	})}
+		return Return(r, err)
+	})
 }
 
 func (r RecordResult) AssignCollection(name string, targets ...RecordResult) RecordResult {
-	return RecordResult{r.AndThen(func(r *ActiveRecord) Result[*ActiveRecord] {
+	return r.AndThen(func(r *ActiveRecord) Result[*ActiveRecord] {
 		records := make([]*ActiveRecord, 0, len(targets))
 		for i := 0; i < len(targets); i++ {
 			if targets[i].IsErr() {
-				return targets[i]
+				return targets[i].Result
 			}
 			records = append(records, targets[i].Unwrap())
 		}
 		err := r.associations.AssignCollection(name, records...)
-		return ReturnRecord(r, err)
-	})}
+		return Return(r, err)
+	})
 }
 
 func (r RecordResult) Collection(name string) CollectionResult {
@@ -151,14 +364,22 @@ func (r RecordResult) Collection(name string) CollectionResult {
 type ActiveRecord struct {
 	name      string
 	tableName string
-	conn      Conn
 	ctx       context.Context
 
+	// Connection is resolved on each operation, unless the record was
+	// initialized by the relation with explicit connection.
+	conn        Conn
+	connections *connectionHandler
+	spec        connectionSpec
+	readOnly    bool
+
 	attributes *attributes
 	AttributeMethods
 	AttributeAccessors
 
 	validations
+	callbacks callbacks
+	tokens    secureTokens
 
 	associations *associations
 	AssociationMethods
@@ -198,12 +419,82 @@ func (r *ActiveRecord) Copy() *ActiveRecord {
 		name:         r.name,
 		tableName:    r.tableName,
 		conn:         r.conn,
+		connections:  r.connections,
+		spec:         r.spec,
+		readOnly:     r.readOnly,
 		ctx:          r.ctx,
 		attributes:   r.attributes.copy(),
 		associations: r.associations.copy(),
+		validations:  *r.validations.copy(),
+		callbacks:    r.callbacks,
+		tokens:       r.tokens,
 	}).init()
 }
 
+// AssignAttributes assigns attributes of the record and nested attributes of
+// singular associations, only permitted attributes are assigned, when they
+// are given:
+//
+//	err := book.AssignAttributes(Hash{
+//		"title":  "Solaris",
+//		"author": Hash{"name": "Lem"},
+//	}, activerecord.Permit("title", "author.name"))
+//
+// Nested attributes are assigned to the loaded target of the association,
+// otherwise a new target is built, targets are not saved. Either all attributes
+// are assigned, or none in case of error.
+func (r *ActiveRecord) AssignAttributes(newAttributes map[string]interface{}, permitted ...Permitted) error {
+	if err := permitAttributes(r.name, newAttributes, permitted); err != nil {
+		return err
+	}
+
+	var (
+		params  = make(Hash, len(newAttributes))
+		targets = make(map[string]*ActiveRecord)
+	)
+	for name, val := range newAttributes {
+		nested, ok := nestedPayload(val)
+		if !ok || !r.associations.HasAssociation(name) {
+			params[name] = val
+			continue
+		}
+
+		target, err := r.nestedTarget(name)
+		if err != nil {
+			return err
+		}
+		if err = target.AssignAttributes(nested); err != nil {
+			return err
+		}
+		targets[name] = target
+	}
+
+	if err := r.attributes.AssignAttributes(params); err != nil {
+		return err
+	}
+	for name, target := range targets {
+		r.associations.set(name, target)
+	}
+	return nil
+}
+
+// nestedTarget returns the copy of the loaded target of the singular association
+// or a new target, when the target is not loaded.
+func (r *ActiveRecord) nestedTarget(assocName string) (*ActiveRecord, error) {
+	if _, err := r.associations.findSingular(assocName); err != nil {
+		return nil, err
+	}
+	if target := r.associations.values[assocName]; target != nil {
+		return target.Copy(), nil
+	}
+
+	ref := r.associations.ReflectOnAssociation(assocName)
+	if ref == nil {
+		return nil, ErrUnknownAssociation{RecordName: r.name, Assoc: assocName}
+	}
+	return ref.Relation.WithContext(r.Context()).Initialize(nil)
+}
+
 func (r *ActiveRecord) Context() context.Context {
 	if r.ctx == nil {
 		return context.Background()
@@ -217,20 +508,9 @@ func (r *ActiveRecord) WithContext(ctx context.Context) *ActiveRecord {
 	return newr
 }
 
-func (r *ActiveRecord) String() string {
-	var buf strings.Builder
-	fmt.Fprintf(&buf, "#<%s ", strings.Title(r.name))
-
-	attrNames := r.AttributeNames()
-	for i, attrName := range attrNames {
-		fmt.Fprintf(&buf, "%s: %#v", attrName, r.Attribute(attrName))
-		if i < len(attrNames)-1 {
-			fmt.Fprint(&buf, ", ")
-		}
-	}
-
-	fmt.Fprintf(&buf, ">")
-	return buf.String()
+// Connection returns the connection of the record.
+func (r *ActiveRecord) Connection() Conn {
+	return connection(r.Context(), r.connections, r.spec, r.conn)
 }
 
 // IsValid runs all the validations, returns true if no errors are found, false othewrise.
@@ -244,10 +524,21 @@ func (r *ActiveRecord) Validate() error {
 	return r.validations.validate(r)
 }
 
+// IsReadOnly returns true, when the record belongs to the read-only relation.
+func (r *ActiveRecord) IsReadOnly() bool {
+	return r.readOnly
+}
+
 func (r *ActiveRecord) Insert() (*ActiveRecord, error) {
+	if r.readOnly {
+		return nil, &ErrReadOnlyRecord{RecordName: r.name}
+	}
 	if err := r.Validate(); err != nil {
 		return nil, err
 	}
+	if err := r.callbacks.run(r, "before_create", r.callbacks.beforeCreate); err != nil {
+		return nil, err
+	}
 
 	columnValues := make([]ColumnValue, 0, len(r.attributes.values))
 	for name, value := range r.attributes.values {
@@ -260,10 +551,11 @@ func (r *ActiveRecord) Insert() (*ActiveRecord, error) {
 	}
 	op := InsertOperation{
 		TableName:    r.tableName,
+		PrimaryKey:   r.attributes.primaryKey.AttributeName(),
 		ColumnValues: columnValues,
 	}
 
-	id, err := r.conn.ExecInsert(r.Context(), &op)
+	id, err := r.Connection().ExecInsert(r.Context(), &op)
 	if err != nil {
 		return nil, err
 	}
@@ -272,13 +564,24 @@ func (r *ActiveRecord) Insert() (*ActiveRecord, error) {
 	if err != nil {
 		return nil, err
 	}
+	r.attributes.persist()
+	if err = r.callbacks.run(r, "after_save", r.callbacks.afterSave); err != nil {
+		return nil, err
+	}
+	r.publishChange(ChangeCreate)
 	return r, nil
 }
 
 func (r *ActiveRecord) Update() (*ActiveRecord, error) {
+	if r.readOnly {
+		return nil, &ErrReadOnlyRecord{RecordName: r.name}
+	}
 	if err := r.Validate(); err != nil {
 		return nil, err
 	}
+	if err := r.callbacks.run(r, "before_update", r.callbacks.beforeUpdate); err != nil {
+		return nil, err
+	}
 
 	columnValues := make([]ColumnValue, 0, len(r.attributes.values))
 	for name, value := range r.attributes.values {
@@ -296,19 +599,38 @@ func (r *ActiveRecord) Update() (*ActiveRecord, error) {
 		ColumnValues: columnValues,
 	}
 
-	return r, r.conn.ExecUpdate(r.Context(), &op)
+	if err := r.Connection().ExecUpdate(r.Context(), &op); err != nil {
+		return r, err
+	}
+	r.attributes.persist()
+	if err := r.callbacks.run(r, "after_save", r.callbacks.afterSave); err != nil {
+		return r, err
+	}
+	r.publishChange(ChangeUpdate)
+	return r, nil
 }
 
 func (r *ActiveRecord) Delete() (*ActiveRecord, error) {
+	if r.readOnly {
+		return nil, &ErrReadOnlyRecord{RecordName: r.name}
+	}
+	if err := r.callbacks.run(r, "before_destroy", r.callbacks.beforeDestroy); err != nil {
+		return nil, err
+	}
+
 	op := DeleteOperation{
 		TableName:  r.tableName,
 		PrimaryKey: r.attributes.primaryKey.AttributeName(),
 		Value:      r.ID(),
 	}
 
-	err := r.conn.ExecDelete(r.Context(), &op)
+	err := r.Connection().ExecDelete(r.Context(), &op)
 	if err != nil {
 		return nil, err
 	}
+	if err = r.callbacks.run(r, "after_destroy", r.callbacks.afterDestroy); err != nil {
+		return nil, err
+	}
+	r.publishChange(ChangeDestroy)
 	return r, nil
 }
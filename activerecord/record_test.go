@@ -1,8 +1,12 @@
 package activerecord_test
 
 import (
+	"context"
+	"fmt"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
@@ -159,3 +163,517 @@ func TestActiveRecord_HasOne_AccessAssociation(t *testing.T) {
 	account := suppliers[0].Association("account").Unwrap()
 	require.Equal(t, accounts[0].ID(), account.ID())
 }
+
+func TestRecordResult_Combinators(t *testing.T) {
+	_, err := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter:  "sqlite3",
+		Database: t.Name(),
+	})
+	require.NoError(t, err)
+
+	defer os.Remove(t.Name())
+	defer activerecord.RemoveConnection("primary")
+
+	activerecord.Migrate(t.Name(), func(m *activerecord.M) {
+		m.CreateTable("authors", func(t *activerecord.Table) {
+			t.String("name")
+		})
+		m.CreateTable("books", func(t *activerecord.Table) {
+			t.String("title")
+			t.Int64("year")
+			t.References("authors")
+		})
+	})
+
+	Author := activerecord.New("author", func(r *activerecord.R) {
+		r.HasMany("books")
+	})
+	Book := activerecord.New("book", func(r *activerecord.R) {
+		r.BelongsTo("author")
+	})
+
+	author := Author.Find(1).OrElse(func(error) Result[*activerecord.ActiveRecord] {
+		return Author.Create(Hash{"name": "Borges"}).Result
+	})
+	require.Equal(t, "Borges", author.Expect("author is created").Attribute("name"))
+
+	book := author.AndThen(func(author *activerecord.ActiveRecord) Result[*activerecord.ActiveRecord] {
+		return Book.Create(Hash{"title": "Ficciones", "year": 1944, "author_id": author.ID()}).Result
+	}).Map(func(book *activerecord.ActiveRecord) *activerecord.ActiveRecord {
+		book.AssignAttribute("year", 1956)
+		return book
+	}).Update()
+	require.NoError(t, book.Err())
+
+	books, err := author.Collection("books").Map(func(rel *activerecord.Relation) *activerecord.Relation {
+		return rel.Where("year", 1956)
+	}).ToA()
+	require.NoError(t, err)
+	require.Len(t, books, 1)
+
+	missing := Author.Find(42).WrapErr("find author")
+	require.ErrorIs(t, missing.Err(), new(activerecord.ErrRecordNotFound))
+	require.Contains(t, missing.Err().Error(), "find author: ")
+}
+
+func TestCollectionResult_Iteration(t *testing.T) {
+	_, err := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter:  "sqlite3",
+		Database: t.Name(),
+	})
+	require.NoError(t, err)
+
+	defer os.Remove(t.Name())
+	defer activerecord.RemoveConnection("primary")
+
+	activerecord.Migrate(t.Name(), func(m *activerecord.M) {
+		m.CreateTable("authors", func(t *activerecord.Table) {
+			t.String("name")
+		})
+		m.CreateTable("books", func(t *activerecord.Table) {
+			t.String("title")
+			t.Int64("year")
+			t.References("authors")
+		})
+	})
+
+	Author := activerecord.New("author", func(r *activerecord.R) {
+		r.HasMany("books")
+	})
+	Book := activerecord.New("book", func(r *activerecord.R) {
+		r.BelongsTo("author")
+	})
+
+	author := Author.Create(Hash{"name": "Borges"})
+	for _, title := range []string{"Ficciones", "El Aleph", "El hacedor"} {
+		book := Book.Create(Hash{"title": title, "author_id": author.Unwrap().ID()})
+		require.NoError(t, book.Err())
+	}
+
+	books := author.Collection("books")
+
+	empty, err := books.IsEmpty()
+	require.NoError(t, err)
+	require.False(t, empty)
+
+	titles, err := activerecord.MapRecords(books, func(book *activerecord.ActiveRecord) (string, error) {
+		return book.Attribute("title").(string), nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"Ficciones", "El Aleph", "El hacedor"}, titles)
+
+	filtered, err := books.Filter(func(book *activerecord.ActiveRecord) bool {
+		return book.Attribute("title") != "El Aleph"
+	})
+	require.NoError(t, err)
+	require.Len(t, filtered, 2)
+
+	require.Equal(t, "Ficciones", books.First().Unwrap().Attribute("title"))
+	require.Equal(t, "El hacedor", books.Last().Unwrap().Attribute("title"))
+
+	last := Book.All().Map(func(rel *activerecord.Relation) *activerecord.Relation {
+		return rel.Order("title")
+	}).Last()
+	require.Equal(t, "Ficciones", last.Unwrap().Attribute("title"))
+
+	empty, err = Book.Where("year", 1900).All().IsEmpty()
+	require.NoError(t, err)
+	require.True(t, empty)
+	require.True(t, Book.Where("year", 1900).IsEmpty())
+}
+
+func TestActiveRecord_HasSecurePassword(t *testing.T) {
+	_, err := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter:  "sqlite3",
+		Database: t.Name(),
+	})
+	require.NoError(t, err)
+
+	defer os.Remove(t.Name())
+	defer activerecord.RemoveConnection("primary")
+
+	activerecord.Migrate(t.Name(), func(m *activerecord.M) {
+		m.CreateTable("users", func(t *activerecord.Table) {
+			t.String("email")
+			t.String("password_digest")
+		})
+	})
+
+	User := activerecord.New("user", func(r *activerecord.R) {
+		r.HasSecurePassword()
+	})
+
+	require.Error(t, User.Create(Hash{"email": "ada@example.com"}).Err())
+	require.Error(t, User.Create(Hash{
+		"email": "ada@example.com", "password": "secret", "password_confirmation": "secrets",
+	}).Err())
+
+	user := User.Create(Hash{
+		"email": "ada@example.com", "password": "secret", "password_confirmation": "secret",
+	})
+	require.NoError(t, user.Err())
+	require.Equal(t, "secret", user.Unwrap().Attribute("password"))
+	require.NotContains(t, user.Unwrap().ToHash(), "password")
+
+	found := User.Find(user.Unwrap().ID()).Unwrap()
+	require.Nil(t, found.Attribute("password"))
+	require.True(t, found.Authenticate("secret"))
+	require.False(t, found.Authenticate("notright"))
+
+	// Blank passwords keep the digest of the record.
+	require.NoError(t, found.AssignAttributes(Hash{"email": "lovelace@example.com", "password": ""}))
+	_, err = found.Update()
+	require.NoError(t, err)
+	require.True(t, User.Find(found.ID()).Unwrap().Authenticate("secret"))
+}
+
+func TestActiveRecord_HasSecureToken(t *testing.T) {
+	_, err := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter:  "sqlite3",
+		Database: t.Name(),
+	})
+	require.NoError(t, err)
+
+	defer os.Remove(t.Name())
+	defer activerecord.RemoveConnection("primary")
+
+	activerecord.Migrate(t.Name(), func(m *activerecord.M) {
+		m.CreateTable("users", func(t *activerecord.Table) {
+			t.String("name")
+			t.String("api_key")
+			t.String("invite_code")
+		})
+	})
+
+	User := activerecord.New("user", func(r *activerecord.R) {
+		r.HasSecureToken("api_key", activerecord.UniqueToken(3))
+		r.HasSecureToken("invite_code", activerecord.TokenLength(8))
+	})
+
+	user := User.Create(Hash{"name": "Ada"}).Unwrap()
+	apiKey, _ := user.Attribute("api_key").(string)
+	require.Len(t, apiKey, activerecord.DefaultTokenLength)
+	require.Len(t, user.Attribute("invite_code"), 8)
+
+	user, err = user.RegenerateToken("api_key")
+	require.NoError(t, err)
+	require.NotEqual(t, apiKey, user.Attribute("api_key"))
+	require.Equal(t, user.Attribute("api_key"), User.Find(user.ID()).Unwrap().Attribute("api_key"))
+
+	_, err = user.RegenerateToken("name")
+	require.Error(t, err)
+
+	// Explicitly assigned tokens are kept.
+	user = User.Create(Hash{"name": "Grace", "api_key": "custom"}).Unwrap()
+	require.Equal(t, "custom", user.Attribute("api_key"))
+}
+
+func TestActiveRecord_GlobalID(t *testing.T) {
+	_, err := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter:  "sqlite3",
+		Database: t.Name(),
+	})
+	require.NoError(t, err)
+
+	defer os.Remove(t.Name())
+	defer activerecord.RemoveConnection("primary")
+
+	activerecord.Migrate(t.Name(), func(m *activerecord.M) {
+		m.CreateTable("users", func(t *activerecord.Table) {
+			t.String("name")
+		})
+		m.CreateTable("documents", func(t *activerecord.Table) {
+			t.String("path")
+			t.PrimaryKey("path")
+		})
+	})
+
+	User := activerecord.New("user")
+	user := User.Create(Hash{"name": "Ada"}).Unwrap()
+
+	// Slashes of identifiers are escaped, so identifiers are parsed back.
+	Document := activerecord.New("document", func(r *activerecord.R) {
+		r.PrimaryKey("path")
+	})
+	_, err = Document.InsertAll(Hash{"path": "reports/2026/q3"})
+	require.NoError(t, err)
+	document := Document.Find("reports/2026/q3").Unwrap()
+	require.Equal(t, "gid://activegraph/document/reports%2F2026%2Fq3", document.GlobalID())
+
+	id, err := activerecord.ParseGlobalID(document.GlobalID())
+	require.NoError(t, err)
+	require.Equal(t, activerecord.GlobalID{
+		App: "activegraph", ModelName: "document", ID: "reports/2026/q3",
+	}, id)
+
+	located := activerecord.Locate(context.Background(), document.GlobalID()).Unwrap()
+	require.Equal(t, "reports/2026/q3", located.ID())
+
+	gid := user.GlobalID()
+	require.Equal(t, fmt.Sprintf("gid://activegraph/user/%v", user.ID()), gid)
+
+	located = activerecord.Locate(context.Background(), gid).Unwrap()
+	require.Equal(t, user.ID(), located.ID())
+	require.Equal(t, "Ada", located.Attribute("name"))
+
+	err = activerecord.Locate(context.Background(), "gid://other/user/1").Err()
+	require.ErrorIs(t, err, new(activerecord.ErrGlobalID))
+
+	_, err = user.SignedGlobalID()
+	require.Error(t, err)
+
+	activerecord.SetGlobalIDSecret([]byte("secret"))
+	defer activerecord.SetGlobalIDSecret(nil)
+
+	sgid, err := user.SignedGlobalID(activerecord.ForPurpose("login"))
+	require.NoError(t, err)
+
+	located = activerecord.LocateSigned(context.Background(), sgid, activerecord.ForPurpose("login")).Unwrap()
+	require.Equal(t, user.ID(), located.ID())
+
+	err = activerecord.LocateSigned(context.Background(), sgid).Err()
+	require.ErrorIs(t, err, new(activerecord.ErrGlobalID))
+
+	err = activerecord.LocateSigned(context.Background(), sgid+"x", activerecord.ForPurpose("login")).Err()
+	require.ErrorIs(t, err, new(activerecord.ErrGlobalID))
+
+	sgid, err = user.SignedGlobalID(activerecord.ExpiresAfter(time.Nanosecond))
+	require.NoError(t, err)
+	err = activerecord.LocateSigned(context.Background(), sgid).Err()
+	require.ErrorIs(t, err, new(activerecord.ErrGlobalID))
+}
+
+func TestActiveRecord_String(t *testing.T) {
+	_, err := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter:  "sqlite3",
+		Database: t.Name(),
+	})
+	require.NoError(t, err)
+
+	defer os.Remove(t.Name())
+	defer activerecord.RemoveConnection("primary")
+
+	activerecord.Migrate(t.Name(), func(m *activerecord.M) {
+		m.CreateTable("authors", func(t *activerecord.Table) {
+			t.String("name")
+		})
+		m.CreateTable("books", func(t *activerecord.Table) {
+			t.String("title")
+			t.Int64("year")
+			t.References("authors")
+		})
+	})
+
+	Author := activerecord.New("author")
+	Book := activerecord.New("book", func(r *activerecord.R) {
+		r.BelongsTo("author")
+	})
+
+	author := Author.Create(Hash{"name": "Stanislaw Lem"}).Unwrap()
+	require.Equal(t, `#<Author id: 1, name: "Stanislaw Lem">`, author.String())
+
+	title := strings.Repeat("a", 60)
+	Book.Create(Hash{"title": title, "author_id": author.ID()})
+
+	book := Book.Joins("author").First().Unwrap()
+	require.Equal(t,
+		`#<Book id: 1, author_id: 1, title: "`+strings.Repeat("a", 50)+`...", year: nil, author: #<Author id: 1>>`,
+		book.String(),
+	)
+
+	var buf strings.Builder
+	require.NoError(t, book.PrettyPrint(&buf))
+	require.Equal(t, "#<Book\n  id: 1,\n  author_id: 1,\n  title: \""+title+"\",\n  year: nil,\n  author: #<Author id: 1>\n>\n", buf.String())
+}
+
+func TestActiveRecord_AssignAttributes(t *testing.T) {
+	_, err := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter: "sqlite3", Database: t.Name(),
+	})
+	require.NoError(t, err)
+
+	defer os.Remove(t.Name())
+	defer activerecord.RemoveConnection("primary")
+
+	activerecord.Migrate(t.Name(), func(m *activerecord.M) {
+		m.CreateTable("authors", func(t *activerecord.Table) {
+			t.String("name")
+		})
+		m.CreateTable("books", func(t *activerecord.Table) {
+			t.String("title")
+			t.Int64("year")
+			t.References("authors")
+		})
+	})
+
+	activerecord.New("author")
+	Book := activerecord.New("book", func(r *activerecord.R) {
+		r.BelongsTo("author")
+	})
+
+	book := Book.New(Hash{"title": "Eden"}).Unwrap()
+	permitted := activerecord.Permit("title", "year", "author.name")
+
+	err = book.AssignAttributes(Hash{"title": "Solaris", "year": 1961, "id": 42}, permitted)
+	require.ErrorIs(t, err, &activerecord.ErrUnpermittedAttributes{})
+	require.Equal(t, "Eden", book.Attribute("title"))
+	require.Nil(t, book.Attribute("year"))
+
+	err = book.AssignAttributes(Hash{"title": "Solaris", "author": Hash{"name": "Lem", "id": 1}}, permitted)
+	require.Equal(t, &activerecord.ErrUnpermittedAttributes{
+		RecordName: "book", Attrs: []string{"author.id"},
+	}, err)
+
+	// Unknown attributes of nested payloads keep the record unchanged.
+	err = book.AssignAttributes(Hash{"title": "Solaris", "author": Hash{"nick": "Lem"}})
+	require.Equal(t, &activerecord.ErrUnknownAttribute{RecordName: "author", Attr: "nick"}, err)
+	require.Equal(t, "Eden", book.Attribute("title"))
+
+	err = book.AssignAttributes(Hash{"title": "Solaris", "year": 1961, "author": Hash{"name": "Lem"}}, permitted)
+	require.NoError(t, err)
+	require.Equal(t, "Solaris", book.Attribute("title"))
+	require.Equal(t, 1961, book.Attribute("year"))
+
+	author, err := book.AccessAssociation("author")
+	require.NoError(t, err)
+	require.Equal(t, "Lem", author.Attribute("name"))
+}
+
+func TestActiveRecord_AuditTrail(t *testing.T) {
+	_, err := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter: "sqlite3", Database: t.Name(),
+	})
+	require.NoError(t, err)
+
+	defer os.Remove(t.Name())
+	defer activerecord.RemoveConnection("primary")
+
+	activerecord.Migrate(t.Name(), func(m *activerecord.M) {
+		activerecord.CreateVersionsTable(m)
+		m.CreateTable("books", func(t *activerecord.Table) {
+			t.String("title")
+			t.Int64("year")
+		})
+	})
+
+	Book := activerecord.New("book", func(r *activerecord.R) {
+		r.HasAuditTrail()
+	})
+
+	ctx := activerecord.WithAuditActor(context.Background(), "admin")
+	book := Book.WithContext(ctx).Create(Hash{"title": "Eden", "year": 1959}).Unwrap()
+	require.False(t, book.Changed())
+
+	require.NoError(t, book.AssignAttribute("title", "Solaris"))
+	require.True(t, book.Changed())
+	require.Equal(t, []string{"title"}, book.ChangedAttributes())
+
+	book, err = book.Update()
+	require.NoError(t, err)
+	require.Equal(t, map[string]activerecord.AttributeChange{
+		"title": {From: "Eden", To: "Solaris"},
+	}, book.SavedChanges())
+
+	// Updates without changes do not record versions.
+	book, err = book.Update()
+	require.NoError(t, err)
+
+	versions, err := book.Versions()
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+
+	require.Equal(t, activerecord.VersionCreate, versions[0].Event)
+	require.Equal(t, "admin", versions[0].Actor)
+	require.Nil(t, versions[0].Object)
+	require.Equal(t, activerecord.AttributeChange{From: nil, To: "Eden"}, versions[0].Changes["title"])
+
+	require.Equal(t, activerecord.VersionUpdate, versions[1].Event)
+	require.Equal(t, "Eden", versions[1].Object["title"])
+	require.Equal(t, int64(1959), versions[1].Object["year"])
+
+	book, err = book.RevertTo(versions[1])
+	require.NoError(t, err)
+	require.Equal(t, "Eden", Book.Find(book.ID()).Unwrap().Attribute("title"))
+
+	_, err = book.Delete()
+	require.NoError(t, err)
+
+	versions, err = book.Versions()
+	require.NoError(t, err)
+	require.Len(t, versions, 4)
+	require.Equal(t, activerecord.VersionDestroy, versions[3].Event)
+
+	_, err = book.RevertTo(versions[3])
+	require.NoError(t, err)
+	require.Equal(t, "Eden", Book.Find(book.ID()).Unwrap().Attribute("title"))
+}
+
+func TestActiveRecord_ChangeEvent(t *testing.T) {
+	_, err := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter: "sqlite3", Database: t.Name(),
+	})
+	require.NoError(t, err)
+
+	defer os.Remove(t.Name())
+	defer activerecord.RemoveConnection("primary")
+
+	activerecord.Migrate(t.Name(), func(m *activerecord.M) {
+		m.CreateTable("books", func(t *activerecord.Table) {
+			t.String("title")
+		})
+	})
+
+	Book := activerecord.New("book")
+
+	var events []Hash
+	sub := Subscribe(activerecord.ChangeEvent, func(e Event) {
+		events = append(events, e.Payload)
+	})
+	defer Unsubscribe(sub)
+
+	book := Book.Create(Hash{"title": "Eden"}).Unwrap()
+	require.Len(t, events, 1)
+	require.Equal(t, "book", events[0]["record"])
+	require.Equal(t, book.ID(), events[0]["id"])
+	require.Equal(t, activerecord.ChangeCreate, events[0]["operation"])
+	require.Equal(t, []string{"id", "title"}, events[0]["attributes"])
+
+	// Changes are published after the commit of the transaction.
+	err = activerecord.Transaction(context.TODO(), func(tx context.Context) error {
+		book := book.WithContext(tx)
+		require.NoError(t, book.AssignAttribute("title", "Solaris"))
+		if _, err := book.Update(); err != nil {
+			return err
+		}
+		require.Len(t, events, 1)
+
+		// Changes of the rolled back savepoint are not published.
+		activerecord.Transaction(tx, func(tx context.Context) error {
+			Book.WithContext(tx).Create(Hash{"title": "Fiasco"})
+			return fmt.Errorf("rollback")
+		})
+		return nil
+	}, activerecord.NotJoinable())
+	require.NoError(t, err)
+
+	require.Len(t, events, 2)
+	require.Equal(t, activerecord.ChangeUpdate, events[1]["operation"])
+	require.Equal(t, map[string]activerecord.AttributeChange{
+		"title": {From: "Eden", To: "Solaris"},
+	}, events[1]["changes"])
+
+	// Changes of the rolled back transaction are not published.
+	err = activerecord.Transaction(context.TODO(), func(tx context.Context) error {
+		if _, err := book.WithContext(tx).Delete(); err != nil {
+			return err
+		}
+		return fmt.Errorf("rollback")
+	})
+	require.Error(t, err)
+	require.Len(t, events, 2)
+
+	_, err = book.Delete()
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+	require.Equal(t, activerecord.ChangeDestroy, events[2]["operation"])
+}
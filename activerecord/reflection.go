@@ -2,13 +2,19 @@ package activerecord
 
 import (
 	"fmt"
+	"sync"
 )
 
 var (
 	globalReflection = NewReflection()
 )
 
+// Reflection is the registry of relations by names.
+//
+// Reflection is safe for concurrent use, so relations could be initialized with
+// New while queries of other relations are executed.
 type Reflection struct {
+	mu     sync.RWMutex
 	rels   map[string]*Relation
 	tables map[string]string
 }
@@ -21,14 +27,23 @@ func NewReflection() *Reflection {
 }
 
 func (r *Reflection) AddReflection(name string, rel *Relation) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	r.rels[name] = rel
 	r.tables[rel.tableName] = name
 }
 
 func (r *Reflection) Reflection(name string) (*Relation, error) {
+	r.mu.RLock()
 	rel, ok := r.rels[name]
+	r.mu.RUnlock()
 	if !ok {
 		return nil, fmt.Errorf("unknown relation %q", name)
 	}
 	return rel, nil
 }
+
+// ReflectOnRelation returns the relation declared with the name.
+func ReflectOnRelation(name string) (*Relation, error) {
+	return globalReflection.Reflection(name)
+}
@@ -2,8 +2,13 @@ package activerecord
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"reflect"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	. "github.com/activegraph/activegraph/activesupport"
 )
@@ -23,10 +28,19 @@ type R struct {
 	tableName   string
 	primaryKey  string
 	attrs       attributesMap
+	virtuals    map[string]virtualAttribute
 	assocs      associationsMap
 	validators  validatorsMap
+	comments    map[string]string
+	readOnly    bool
+	scopes      []func(*Relation) *Relation
+	callbacks   callbacks
+	tokens      secureTokens
+	cache       RecordCache
+	indexer     Indexer
 	reflection  *Reflection
 	connections *connectionHandler
+	spec        connectionSpec
 }
 
 // TableName sets the table name explicitly.
@@ -38,10 +52,35 @@ func (r *R) TableName(name string) {
 	r.tableName = name
 }
 
+// ConnectsTo specifies the connection of the relation, by default relations use
+// the primary connection. The connection is resolved on each query, so records
+// of the relation are always read from and written to the specified database.
+//
+//	Event := activerecord.New("event", func(r *activerecord.R) {
+//		r.ConnectsTo(activerecord.Database("analytics"))
+//	})
+func (r *R) ConnectsTo(options ...ConnectionOption) {
+	for _, option := range options {
+		option(&r.spec)
+	}
+}
+
 func (r *R) PrimaryKey(name string) {
 	r.primaryKey = name
 }
 
+// ReadOnly marks the relation read-only, e.g. the relation of the database view.
+// Records of read-only relations are not inserted, updated or deleted,
+// ErrReadOnlyRecord is returned instead.
+//
+//	Report := activerecord.New("sales_report", func(r *activerecord.R) {
+//		r.TableName("sales_reports")
+//		r.ReadOnly()
+//	})
+func (r *R) ReadOnly() {
+	r.readOnly = true
+}
+
 func (r *R) DefineAttribute(name string, t Type, validators ...AttributeValidator) {
 	r.attrs[name] = attr{Name: name, Type: t}
 	r.validators.include(name, typeValidator{t})
@@ -63,6 +102,23 @@ func (r *R) ValidatesPresence(names ...string) {
 	r.validators.extend(names, new(Presence))
 }
 
+// DefaultScope defines a scope, which is applied to all queries of the relation.
+// Multiple default scopes are merged together.
+//
+//	Article := activerecord.New("article", func(r *activerecord.R) {
+//		r.DefaultScope(func(rel *activerecord.Relation) *activerecord.Relation {
+//			return rel.Where("deleted_at", nil)
+//		})
+//	})
+//
+//	Article.All()
+//	// SELECT * FROM "articles" WHERE (deleted_at IS NULL)
+//
+// Use Unscoped or Unscope methods of the relation to remove the default scope.
+func (r *R) DefaultScope(scope func(*Relation) *Relation) {
+	r.scopes = append(r.scopes, scope)
+}
+
 func (r *R) BelongsTo(name string, init ...func(*BelongsTo)) {
 	assoc := BelongsTo{targetName: name, owner: r.rel, reflection: r.reflection}
 
@@ -77,47 +133,117 @@ func (r *R) BelongsTo(name string, init ...func(*BelongsTo)) {
 	r.assocs[name] = &assoc
 }
 
-func (r *R) HasMany(name string) {
-	// TODO: Define library methods to pluralize words.
-	targetName := strings.TrimSuffix(name, "s")
+func (r *R) HasMany(name string, init ...func(*HasMany)) {
+	targetName := Singularize(name)
 
 	// Use plural name for the name of attribute, while target name
 	// of the association should be in singular (to find a target relation
 	// through the reflection.
-	r.assocs[name] = &HasMany{
-		targetName: targetName, owner: r.rel, reflection: r.reflection,
+	assoc := HasMany{targetName: targetName, owner: r.rel, reflection: r.reflection}
+
+	switch len(init) {
+	case 0:
+	case 1:
+		init[0](&assoc)
+	default:
+		panic(ErrMultipleVariadicArguments{Name: "init"})
 	}
+
+	r.assocs[name] = &assoc
 }
 
-func (r *R) HasOne(name string) {
-	r.assocs[name] = &HasOne{targetName: name, owner: r.rel, reflection: r.reflection}
+func (r *R) HasOne(name string, init ...func(*HasOne)) {
+	assoc := HasOne{targetName: name, owner: r.rel, reflection: r.reflection}
+
+	switch len(init) {
+	case 0:
+	case 1:
+		init[0](&assoc)
+	default:
+		panic(ErrMultipleVariadicArguments{Name: "init"})
+	}
+
+	r.assocs[name] = &assoc
 }
 
 func (r *R) init(ctx context.Context, tableName string) error {
-	conn, err := r.connections.RetrieveConnection(primaryConnectionName)
+	conn, err := r.connections.resolve(ctx, r.spec)
 	if err != nil {
 		return err
 	}
 
-	definitions, err := conn.ColumnDefinitions(ctx, tableName)
+	definitions, err := schemaColumnDefinitions(ctx, conn, tableName)
 	if err != nil {
 		return err
 	}
 
 	for _, column := range definitions {
+		if column.Comment != "" {
+			if r.comments == nil {
+				r.comments = make(map[string]string)
+			}
+			r.comments[column.Name] = column.Comment
+		}
+
+		// Attributes defined explicitly take precedence over the columns
+		// of the table.
+		if _, ok := r.attrs[column.Name]; ok {
+			continue
+		}
+
 		columnType := column.Type
 		if !column.NotNull {
 			columnType = Nil{columnType}
 		}
 		r.DefineAttribute(column.Name, columnType)
 
-		if column.IsPrimaryKey {
+		if column.IsPrimaryKey && r.primaryKey == "" {
 			r.PrimaryKey(column.Name)
 		}
 	}
+
+	// Views do not have primary keys, so the "id" column is used by default.
+	if _, ok := r.attrs[defaultPrimaryKeyName]; ok && r.primaryKey == "" {
+		r.PrimaryKey(defaultPrimaryKeyName)
+	}
 	return nil
 }
 
+// loadedRecords memoizes records of the relation, once they are retrieved from
+// the database.
+type loadedRecords struct {
+	mu      sync.Mutex
+	loaded  bool
+	records Array
+}
+
+func (lr *loadedRecords) get() (Array, bool) {
+	if lr == nil {
+		return nil, false
+	}
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+	return lr.records, lr.loaded
+}
+
+func (lr *loadedRecords) set(records Array) {
+	if lr == nil {
+		return
+	}
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+	lr.records, lr.loaded = records, true
+}
+
+func (lr *loadedRecords) reset() {
+	if lr == nil {
+		return
+	}
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+	lr.records, lr.loaded = nil, false
+}
+
 type Relation struct {
 	name      string
 	tableName string
@@ -126,11 +252,38 @@ type Relation struct {
 
 	conn        Conn
 	connections *connectionHandler
+	spec        connectionSpec
+	readOnly    bool
 
 	scope *attributes
 	query *QueryBuilder
 	ctx   context.Context
 
+	// comments are comments of table columns.
+	comments map[string]string
+
+	// Default scopes are applied to the query right before its execution,
+	// unless the relation is unscoped.
+	defaultScopes []func(*Relation) *Relation
+	unscoped      bool
+	unscoping     []Clause
+
+	// none is true when the relation does not match any records.
+	none bool
+	// err is the error of building the relation, e.g. the invalid cursor, which
+	// is returned by queries of the relation.
+	err error
+
+	callbacks callbacks
+	tokens    secureTokens
+	cache     RecordCache
+	indexer   Indexer
+
+	// Records are memoized for all relations, except the relation returned
+	// by New and Initialize functions, since it is shared between all derived
+	// relations.
+	records *loadedRecords
+
 	associations
 	validations
 	AttributeMethods
@@ -168,13 +321,21 @@ func Initialize(name string, init func(*R)) (*Relation, error) {
 		connections: globalConnectionHandler,
 	}
 
-	err := r.init(context.TODO(), name+"s")
-	if err != nil {
-		return nil, err
+	// Relations declared with Declare are initialized with the declaration.
+	if init == nil {
+		init = declaration(name)
 	}
 	if init != nil {
 		init(&r)
 	}
+	if r.tableName == "" {
+		r.tableName = Pluralize(name)
+	}
+
+	err := r.init(context.TODO(), r.tableName)
+	if err != nil {
+		return nil, err
+	}
 
 	// When the primary key was assigned to record builder, mark it explicitely
 	// wrapping with PrimaryKey structure. Otherwise, fallback to the default primary
@@ -186,15 +347,14 @@ func Initialize(name string, init func(*R)) (*Relation, error) {
 		}
 		r.attrs[r.primaryKey] = PrimaryKey{Attribute: attr}
 	}
-	if r.tableName == "" {
-		r.tableName = name + "s"
-	}
 
 	// The scope is empty by default.
 	scope, err := newAttributes(name, r.attrs.copy(), nil)
 	if err != nil {
 		return nil, err
 	}
+	scope.tableName = r.tableName
+	scope.virtuals = r.virtuals
 
 	assocs := newAssociations(name, r.assocs.copy(), r.reflection)
 	validations := newValidations(r.validators.copy())
@@ -205,7 +365,15 @@ func Initialize(name string, init func(*R)) (*Relation, error) {
 	rel.associations = *assocs
 	rel.validations = *validations
 	rel.connections = r.connections
+	rel.spec = r.spec
 	rel.query = &QueryBuilder{from: r.tableName}
+	rel.defaultScopes = r.scopes
+	rel.comments = r.comments
+	rel.readOnly = r.readOnly
+	rel.callbacks = r.callbacks.copy()
+	rel.tokens = r.tokens
+	rel.cache = r.cache
+	rel.indexer = r.indexer
 	rel.AttributeMethods = scope
 	r.reflection.AddReflection(name, rel)
 
@@ -220,17 +388,76 @@ func (rel *Relation) Name() string {
 	return rel.name
 }
 
+// TableComment returns the comment of the relation table stored in the database
+// schema. Empty comment is returned, when the database does not store comments.
+func (rel *Relation) TableComment() (string, error) {
+	comment, err := TableComment(rel.Context(), rel.Connection(), rel.tableName)
+	if errors.Is(err, new(ErrNotSupported)) {
+		return "", nil
+	}
+	return comment, err
+}
+
+// IsReadOnly returns true, when the relation is read-only (see R.ReadOnly).
+func (rel *Relation) IsReadOnly() bool {
+	return rel.readOnly
+}
+
+// RefreshMaterializedView refreshes results of the materialized view of the
+// relation, concurrent refresh does not lock reads of the view, but requires
+// the unique index of the view. ErrNotSupported is returned, when the database
+// does not have materialized views.
+//
+//	SalesReport := activerecord.New("sales_report", func(r *activerecord.R) {
+//		r.ReadOnly()
+//	})
+//	err := SalesReport.RefreshMaterializedView(true)
+func (rel *Relation) RefreshMaterializedView(concurrently bool) error {
+	conn := rel.Connection()
+	if !CapabilitiesOf(conn).MaterializedViews {
+		return &ErrNotSupported{Feature: "materialized views"}
+	}
+
+	stmt := "REFRESH MATERIALIZED VIEW "
+	if concurrently {
+		stmt += "CONCURRENTLY "
+	}
+	stmt += DialectOf(conn).QuoteIdentifier(rel.tableName)
+
+	_, err := conn.ExecStatement(rel.Context(), &QueryOperation{Text: stmt})
+	return err
+}
+
+// AttributeComment returns the comment of the attribute column stored in the
+// database schema, comments are loaded together with columns of the table.
+func (rel *Relation) AttributeComment(attrName string) string {
+	return rel.comments[attrName]
+}
+
 func (rel *Relation) Copy() *Relation {
 	scope := rel.scope.copy()
 
 	return &Relation{
 		name:             rel.name,
 		tableName:        rel.tableName,
-		conn:             rel.Connection(),
+		conn:             rel.conn,
 		connections:      rel.connections,
-		scope:            rel.scope.copy(),
+		spec:             rel.spec,
+		readOnly:         rel.readOnly,
+		scope:            scope,
 		query:            rel.query.copy(),
+		comments:         rel.comments,
 		ctx:              rel.ctx,
+		defaultScopes:    rel.defaultScopes,
+		unscoped:         rel.unscoped,
+		unscoping:        append([]Clause(nil), rel.unscoping...),
+		none:             rel.none,
+		err:              rel.err,
+		callbacks:        rel.callbacks,
+		tokens:           rel.tokens,
+		cache:            rel.cache,
+		indexer:          rel.indexer,
+		records:          new(loadedRecords),
 		associations:     *rel.associations.copy(),
 		validations:      *rel.validations.copy(),
 		AttributeMethods: scope,
@@ -242,10 +469,22 @@ func (rel *Relation) empty() *Relation {
 	return rel
 }
 
-// IsEmpty returns true if there are no records.
+// IsEmpty returns true if there are no records. Memoized records are checked
+// without the query, failed queries are reported as non-empty relations.
 func (rel *Relation) IsEmpty() bool {
-	// TODO: implement the method.
-	return false
+	empty, err := rel.isEmpty()
+	return err == nil && empty
+}
+
+func (rel *Relation) isEmpty() (bool, error) {
+	if rel.none {
+		return true, nil
+	}
+	if records, ok := rel.records.get(); ok {
+		return len(records) == 0, nil
+	}
+	records, err := rel.Limit(1).ToA()
+	return len(records) == 0, err
 }
 
 func (rel *Relation) Context() context.Context {
@@ -261,6 +500,14 @@ func (rel *Relation) WithContext(ctx context.Context) *Relation {
 	return newrel
 }
 
+// Timeout limits the duration of each database statement of the relation and its
+// records. See WithStatementTimeout for details.
+//
+//	Book.Timeout(200 * time.Millisecond).Where("year", 1961).ToA()
+func (rel *Relation) Timeout(timeout time.Duration) *Relation {
+	return rel.WithContext(WithStatementTimeout(rel.Context(), timeout))
+}
+
 func (rel *Relation) Connect(conn Conn) *Relation {
 	newrel := rel.Copy()
 	newrel.conn = conn
@@ -268,15 +515,17 @@ func (rel *Relation) Connect(conn Conn) *Relation {
 }
 
 func (rel *Relation) Connection() Conn {
-	if rel.conn != nil {
-		return rel.conn
+	if rel.err != nil {
+		return &errConn{err: rel.err}
 	}
+	return connection(rel.Context(), rel.connections, rel.spec, rel.conn)
+}
 
-	conn, err := rel.connections.RetrieveConnection(primaryConnectionName)
-	if err != nil {
-		return &errConn{err: err}
-	}
-	return conn
+// withErr returns a copy of the relation, which queries return the error.
+func (rel *Relation) withErr(err error) *Relation {
+	newrel := rel.Copy()
+	newrel.err = err
+	return newrel
 }
 
 func (rel *Relation) New(params ...map[string]interface{}) RecordResult {
@@ -300,10 +549,16 @@ func (rel *Relation) Initialize(params map[string]interface{}) (*ActiveRecord, e
 	rec := &ActiveRecord{
 		name:         rel.name,
 		tableName:    rel.tableName,
-		conn:         rel.Connection(),
+		conn:         rel.conn,
+		connections:  rel.connections,
+		spec:         rel.spec,
+		readOnly:     rel.readOnly,
+		ctx:          rel.ctx,
 		attributes:   attributes,
 		associations: rel.associations.copy(),
 		validations:  *rel.validations.copy(),
+		callbacks:    rel.callbacks,
+		tokens:       rel.tokens,
 	}
 	return rec.init(), nil
 }
@@ -330,7 +585,12 @@ func (rel *Relation) ExtractRecord(h Hash) (*ActiveRecord, error) {
 		params[attrName] = attrValue
 	}
 
-	return rel.Initialize(params)
+	rec, err := rel.Initialize(params)
+	if err != nil {
+		return nil, err
+	}
+	rec.attributes.original = rec.attributes.values.Copy()
+	return rec, nil
 }
 
 // PrimaryKey returns the attribute name of the record's primary key.
@@ -338,8 +598,29 @@ func (rel *Relation) PrimaryKey() string {
 	return rel.scope.PrimaryKey()
 }
 
+// All returns a collection of all records of the relation.
 func (rel *Relation) All() CollectionResult {
-	return OkCollection(rel)
+	return OkCollection(rel.Copy())
+}
+
+// Loaded returns true when records of the relation are retrieved from the database
+// and memoized, so kicker methods (ToA, Each) reuse them without the query.
+func (rel *Relation) Loaded() bool {
+	_, loaded := rel.records.get()
+	return loaded
+}
+
+// Reload resets the memoized records of the relation, so the next call to
+// kicker methods retrieves them from the database again.
+//
+//	products := Product.Where("available", true)
+//	products.ToA() // Queries database.
+//	products.ToA() // Returns memoized records.
+//
+//	products.Reload().ToA() // Queries database.
+func (rel *Relation) Reload() *Relation {
+	rel.records.reset()
+	return rel
 }
 
 // TODO: move to the Schema type all column-related methods.
@@ -347,25 +628,109 @@ func (rel *Relation) ColumnNames() []string {
 	return rel.scope.ColumnNames()
 }
 
-func (rel *Relation) Each(fn func(*ActiveRecord) error) error {
+// build returns a query of the relation merged with default scopes. The clauses
+// removed from the relation with Unscope are removed from default scopes
+// as well.
+func (rel *Relation) build() *QueryBuilder {
 	q := rel.query.copy()
+	if rel.none {
+		q.Where("1=0")
+	}
+	// Queries are annotated with tags of the context, so they could be
+	// correlated with requests in the database logs.
+	if tags := LogTagsFromContext(rel.Context()); len(tags) > 0 {
+		q.Annotate(tags.String())
+	}
+	if rel.unscoped || len(rel.defaultScopes) == 0 {
+		return q
+	}
+
+	scoped := rel.Unscoped()
+	scoped.query = &QueryBuilder{from: rel.query.from}
+
+	for _, scope := range rel.defaultScopes {
+		scoped = scope(scoped)
+	}
+
+	scoped.query.unscope(rel.unscoping...)
+	return scoped.query.merge(q)
+}
+
+// Predicate implements Condition interface, so the relation could be used as
+// a subquery within conditions of another relation.
+//
+// The subquery selects attribute, when the relation is limited to a single
+// attribute with Select, otherwise the primary key is selected.
+//
+//	Order.Where("user_id", User.Where("vip", true).Select("id"))
+//	// SELECT * FROM "orders" WHERE
+//	//   (user_id IN (SELECT users.id FROM "users" WHERE (vip = ?)))
+func (rel *Relation) Predicate(column string, t Type) Predicate {
+	q := rel.build()
+
+	if columnNames := rel.ColumnNames(); len(columnNames) == 1 {
+		q.Select(columnNames...)
+	} else {
+		q.Select(rel.TableName() + "." + rel.PrimaryKey())
+	}
+
+	return Predicate{
+		Cond: fmt.Sprintf("%s IN (%s)", column, q.String()),
+		Args: q.Args(),
+	}
+}
+
+// selectQuery returns a query that selects all attributes of the relation and
+// its join dependencies.
+func (rel *Relation) selectQuery() *QueryBuilder {
+	q := rel.build()
 	q.Select(rel.ColumnNames()...)
 
 	// Include all join dependencies into the query with fully-qualified column
 	// names, so each part of the request can be extracted individually.
-	for _, join := range rel.query.joinValues {
+	for _, join := range q.joinValues {
 		q.Select(join.Relation.ColumnNames()...)
 	}
+	return q
+}
 
-	var lasterr error
+func (rel *Relation) Each(fn func(*ActiveRecord) error) error {
+	if rel.none {
+		return nil
+	}
 
-	err := rel.Connection().ExecQuery(rel.Context(), q.Operation(), func(h Hash) bool {
-		rec, e := rel.ExtractRecord(h)
+	if records, ok := rel.records.get(); ok {
+		for _, rec := range records {
+			if err := fn(rec); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	q := rel.selectQuery()
+	conn := rel.Connection()
+
+	if len(q.joinValues) > 0 && !CapabilitiesOf(conn).Joins {
+		return rel.eachPreloaded(conn, fn)
+	}
+
+	var (
+		records Array
+		lasterr error
+	)
+
+	// Records are passed to the function while the query reads rows, so queries
+	// of records reuse the connection held by the query.
+	held := rel.WithContext(withHeldConns(rel.Context()))
+
+	err := conn.ExecQuery(held.Context(), q.Operation(), func(h Hash) bool {
+		rec, e := held.ExtractRecord(h)
 		if lasterr = e; e != nil {
 			return false
 		}
 
-		for _, join := range rel.query.joinValues {
+		for _, join := range q.joinValues {
 			arec, e := join.Relation.ExtractRecord(h)
 			if lasterr = e; e != nil {
 				return false
@@ -375,6 +740,8 @@ func (rel *Relation) Each(fn func(*ActiveRecord) error) error {
 			rec.associations.set(join.Relation.Name(), arec)
 		}
 
+		records = append(records, rec)
+
 		if lasterr = fn(rec); lasterr != nil {
 			return false
 		}
@@ -384,23 +751,161 @@ func (rel *Relation) Each(fn func(*ActiveRecord) error) error {
 	if lasterr != nil {
 		return lasterr
 	}
+	if err == nil {
+		rel.records.set(records)
+	}
 	return err
 }
 
+// eachPreloaded iterates over records of the relation, when the database does not
+// join tables. Records of each joined association are loaded with a separate
+// query by primary keys, records without the associated record are skipped, as
+// they are skipped by the inner join.
+func (rel *Relation) eachPreloaded(conn Conn, fn func(*ActiveRecord) error) error {
+	q := rel.build()
+	joins := q.joinValues
+	q.unscope(JoinsClause)
+	q.Select(rel.ColumnNames()...)
+
+	var (
+		records Array
+		lasterr error
+	)
+
+	err := conn.ExecQuery(rel.Context(), q.Operation(), func(h Hash) bool {
+		rec, e := rel.ExtractRecord(h)
+		if lasterr = e; e != nil {
+			return false
+		}
+		records = append(records, rec)
+		return true
+	})
+	if lasterr != nil {
+		return lasterr
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, join := range joins {
+		var (
+			fk   = join.Association.AssociationForeignKey()
+			pk   = join.Relation.PrimaryKey()
+			keys = make([]interface{}, 0, len(records))
+			seen = make(map[interface{}]struct{}, len(records))
+		)
+		for _, rec := range records {
+			key := rec.Attribute(fk)
+			if _, dup := seen[key]; key != nil && !dup {
+				seen[key] = struct{}{}
+				keys = append(keys, key)
+			}
+		}
+
+		targets := make(map[interface{}]*ActiveRecord, len(keys))
+		err = join.Relation.Connect(conn).WithContext(rel.Context()).Where(pk, in(keys)).Each(
+			func(arec *ActiveRecord) error {
+				targets[arec.Attribute(pk)] = arec
+				return nil
+			},
+		)
+		if err != nil {
+			return err
+		}
+
+		joined := records[:0]
+		for _, rec := range records {
+			if arec, ok := targets[rec.Attribute(fk)]; ok {
+				rec.associations.set(join.Relation.Name(), arec)
+				joined = append(joined, rec)
+			}
+		}
+		records = joined
+	}
+
+	for _, rec := range records {
+		if err = fn(rec); err != nil {
+			return err
+		}
+	}
+	rel.records.set(records)
+	return nil
+}
+
+// Where returns a new relation, which is the result of filtering the current
+// relation according to the condition in the arguments.
+//
+// When the condition is an attribute name, the argument is either compared for
+// equality, or is a Condition, e.g. a range of values:
+//
+//	Order.Where("status", "paid")
+//	Order.Where("created_at", activerecord.Between(from, to))
+//	Order.Where("total", activerecord.GreaterThan(100))
+//
+// The argument could be a relation as well, in this case it is embedded into the
+// query as a subquery:
+//
+//	Order.Where("user_id", User.Where("vip", true).Select("id"))
+//
+// Otherwise the condition is used as an SQL fragment with a single bind argument:
+//
+//	Order.Where("total > ?", 100)
 func (rel *Relation) Where(cond string, arg interface{}) *Relation {
 	newrel := rel.Copy()
 
+	// Value objects are compared by values of mapped attributes.
+	if agg, ok := newrel.scope.virtuals[cond].(*aggregation); ok {
+		values, err := agg.values(arg)
+		if err != nil {
+			return newrel.empty()
+		}
+		for _, attrName := range agg.mapping {
+			newrel = newrel.Where(attrName, values[attrName])
+		}
+		return newrel
+	}
+
+	condition, ok := arg.(Condition)
+	switch {
+	case ok:
+	case arg == nil:
+		condition = isNull
+	default:
+		condition = comparison("=", arg)
+	}
+
 	// When the condition is a regular column, pass it through the regular
-	// column comparison instead of query chain predicates.
-	if newrel.scope.HasAttribute(cond) {
-		// newrel.scope.AssignAttribute(cond, arg)
-		newrel.query.Where(fmt.Sprintf("%s = ?", cond), arg)
-	} else {
+	// column comparison instead of query chain predicates. Other names of
+	// columns are quoted, so they are never executed as SQL fragments.
+	switch {
+	case newrel.scope.HasAttribute(cond):
+		attrType := newrel.scope.AttributeForInspect(cond).AttributeType()
+		predicate := condition.Predicate(cond, attrType)
+		newrel.query.Where(predicate.Cond, predicate.Args...)
+	case isIdentifier(cond):
+		attrType, _ := newrel.columnType(cond)
+		predicate := condition.Predicate(Quote(cond), attrType)
+		newrel.query.Where(predicate.Cond, predicate.Args...)
+	default:
 		newrel.query.Where(cond, arg)
 	}
 	return newrel
 }
 
+// WhereLike returns records with attribute values matching the LIKE pattern.
+//
+//	Product.WhereLike("name", "%"+activerecord.SanitizeSQLLike(query)+"%")
+//	// SELECT * FROM "products" WHERE (name LIKE ? ESCAPE '!')
+func (rel *Relation) WhereLike(attrName string, pattern string) *Relation {
+	return rel.Where(attrName, Like(pattern))
+}
+
+// WhereILike returns records with attribute values matching the LIKE pattern
+// ignoring the case of letters.
+func (rel *Relation) WhereILike(attrName string, pattern string) *Relation {
+	return rel.Where(attrName, ILike(pattern))
+}
+
 // Select allows to specify a subset of fields to return.
 //
 // Method returns a new relation, where a set of attributes is limited by the
@@ -438,14 +943,60 @@ func (rel *Relation) Group(attrNames ...string) *Relation {
 	newrel := rel.Copy()
 
 	// When the attribute is not part of the scope, return an empty relation.
-	if !newrel.scope.HasAttributes(attrNames...) {
-		return newrel.empty()
+	for _, attrName := range attrNames {
+		if _, ok := newrel.columnType(attrName); !ok {
+			return newrel.empty()
+		}
 	}
 
 	newrel.query.Group(attrNames...)
 	return newrel
 }
 
+// Order specifies the order of the retrieved records. Each value is either an
+// attribute name or an attribute name followed by the direction of sorting.
+//
+//	User.Order("name")
+//	// SELECT * FROM "users" ORDER BY name
+//
+//	User.Order("created_at DESC", "name")
+//	// SELECT * FROM "users" ORDER BY created_at DESC, name
+//
+// Columns could be qualified by names of tables and wrapped into functions, e.g.
+// "LOWER(users.name) ASC NULLS LAST". Other SQL expressions are rejected, so the
+// order cannot inject SQL, queries of the relation return ErrArgument instead.
+// Use UnsafeOrder for trusted SQL expressions.
+func (rel *Relation) Order(values ...string) *Relation {
+	if value, ok := sanitizeOrder(values); !ok {
+		return rel.withErr(ErrArgument{Message: fmt.Sprintf(
+			"order %q is not a column or a function of a column, use UnsafeOrder for SQL expressions", value,
+		)})
+	}
+	return rel.UnsafeOrder(values...)
+}
+
+// UnsafeOrder specifies the order of the retrieved records with SQL expressions,
+// which are not accepted by Order:
+//
+//	Task.UnsafeOrder("CASE status WHEN 'urgent' THEN 0 ELSE 1 END", "id")
+//	// SELECT * FROM "tasks" ORDER BY CASE status WHEN 'urgent' THEN 0 ELSE 1 END, id
+//
+// Values are included into the query as is, never pass values supplied by users.
+func (rel *Relation) UnsafeOrder(values ...string) *Relation {
+	newrel := rel.Copy()
+	newrel.query.Order(values...)
+	return newrel
+}
+
+// Reorder replaces the order of the relation, including the order specified by
+// default scopes.
+//
+//	User.Order("name").Reorder("created_at DESC")
+//	// SELECT * FROM "users" ORDER BY created_at DESC
+func (rel *Relation) Reorder(values ...string) *Relation {
+	return rel.Unscope(OrderClause).Order(values...)
+}
+
 // Limit specifies a limit for the number of records to retrieve.
 //
 //	User.Limit(10) // Generated SQL has 'LIMIT 10'
@@ -455,6 +1006,35 @@ func (rel *Relation) Limit(num int) *Relation {
 	return newrel
 }
 
+// Annotate adds SQL comments to the queries generated from the relation. It is
+// useful to trace the queries back to the code that generated them.
+//
+//	User.Where("name", "Oscar").Annotate("dashboard#index")
+//	// SELECT * FROM "users" WHERE (name = ?) /* dashboard#index */
+//
+// Comments are sanitized, the comment delimiters within the text are escaped.
+// Log tags of the relation context are added to the comments as well, see
+// WithLogTags.
+func (rel *Relation) Annotate(comments ...string) *Relation {
+	newrel := rel.Copy()
+	newrel.query.Annotate(comments...)
+	return newrel
+}
+
+// From specifies the source of the records instead of the relation table, e.g.
+// a view or a derived table.
+//
+// Attributes are selected with the relation table name, therefore a derived table
+// must use the table name as an alias:
+//
+//	Product.From("(SELECT * FROM products WHERE price > 100) AS products")
+//	// SELECT * FROM (SELECT * FROM products WHERE price > 100) AS products
+func (rel *Relation) From(source string) *Relation {
+	newrel := rel.Copy()
+	newrel.query.source = source
+	return newrel
+}
+
 func (rel *Relation) Joins(assocNames ...string) *Relation {
 	newrel := rel.Copy()
 
@@ -469,26 +1049,123 @@ func (rel *Relation) Joins(assocNames ...string) *Relation {
 	return newrel
 }
 
-func (rel *Relation) Find(id interface{}) RecordResult {
-	var q QueryBuilder
-	q.From(rel.TableName())
-	q.Select(rel.scope.AttributeNames()...)
-	// TODO: consider using unified approach.
-	q.Where(fmt.Sprintf("%s = ?", rel.PrimaryKey()), id)
+// None returns a new relation, which matches no records. The relation is chainable,
+// but it never queries the database and always returns an empty result.
+//
+//	func visibleArticles(user *activerecord.ActiveRecord) *activerecord.Relation {
+//		if user == nil {
+//			return Article.None()
+//		}
+//		return Article.Where("author_id", user.ID())
+//	}
+func (rel *Relation) None() *Relation {
+	newrel := rel.Copy()
+	newrel.none = true
+	return newrel
+}
 
-	var rows []Hash
+// Unscoped returns a new relation without default scopes. Conditions specified
+// explicitly for the relation are preserved.
+//
+//	Article.Unscoped().Where("author_id", 1)
+//	// SELECT * FROM "articles" WHERE (author_id = ?)
+func (rel *Relation) Unscoped() *Relation {
+	newrel := rel.Copy()
+	newrel.unscoped = true
+	return newrel
+}
 
-	if err := rel.Connection().ExecQuery(rel.Context(), q.Operation(), func(h Hash) bool {
-		rows = append(rows, h)
-		return true
-	}); err != nil {
+// Unscope removes the specified clauses from the relation, including the clauses
+// defined by default scopes.
+//
+//	Article.Where("author_id", 1).Unscope(activerecord.WhereClause)
+//	// SELECT * FROM "articles"
+//
+//	Article.Where("author_id", 1).Order("title").Limit(10).Unscope(
+//		activerecord.OrderClause, activerecord.LimitClause,
+//	)
+//	// SELECT * FROM "articles" WHERE (author_id = ?)
+func (rel *Relation) Unscope(clauses ...Clause) *Relation {
+	newrel := rel.Copy()
+	newrel.query.unscope(clauses...)
+	newrel.unscoping = append(newrel.unscoping, clauses...)
+	return newrel
+}
+
+func (rel *Relation) Find(id interface{}) RecordResult {
+	records, err := rel.Where(rel.PrimaryKey(), id).Limit(1).ToA()
+	if err != nil {
 		return ErrRecord(err)
 	}
 
-	if len(rows) != 1 {
-		return ErrRecord(&ErrRecordNotFound{PrimaryKey: rel.PrimaryKey(), ID: id})
+	if len(records) != 1 {
+		return ErrRecord(&ErrRecordNotFound{Relation: rel.name, PrimaryKey: rel.PrimaryKey(), ID: id})
+	}
+	return OkRecord(records[0])
+}
+
+// FindOption configures the search of records by primary keys with FindAll.
+type FindOption func(*findConfig)
+
+type findConfig struct {
+	allowMissing bool
+}
+
+// AllowMissing returns found records, when records of some primary keys do
+// not exist, instead of ErrRecordNotFound.
+func AllowMissing() FindOption {
+	return func(c *findConfig) {
+		c.allowMissing = true
+	}
+}
+
+// FindAll returns the collection of records with the primary keys, records are
+// retrieved with a single query and ordered as given primary keys:
+//
+//	Person.FindAll([]interface{}{3, 1})
+//	// SELECT * FROM "people" WHERE (id IN (?, ?))
+//
+// ErrRecordNotFound with missing primary keys is returned, when any of records
+// do not exist, unless the AllowMissing option is given.
+func (rel *Relation) FindAll(ids []interface{}, opts ...FindOption) CollectionResult {
+	var config findConfig
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	found := rel.Where(rel.PrimaryKey(), In(ids...))
+	records, err := found.ToA()
+	if err != nil {
+		return ErrCollection(err)
+	}
+
+	byID := make(map[string]*ActiveRecord, len(records))
+	for _, rec := range records {
+		byID[fmt.Sprint(rec.ID())] = rec
+	}
+
+	var (
+		missing []interface{}
+		ordered = make(Array, 0, len(records))
+		seen    = make(map[string]bool, len(ids))
+	)
+	for _, id := range ids {
+		key := fmt.Sprint(id)
+		rec, ok := byID[key]
+		switch {
+		case !ok:
+			missing = append(missing, id)
+		case !seen[key]:
+			ordered = append(ordered, rec)
+		}
+		seen[key] = true
+	}
+	if len(missing) > 0 && !config.allowMissing {
+		return ErrCollection(&ErrRecordNotFound{Relation: rel.name, PrimaryKey: rel.PrimaryKey(), ID: missing})
 	}
-	return rel.New(rows[0])
+
+	found.records.set(ordered)
+	return OkCollection(found)
 }
 
 // FindBy returns a record matching the specified condition.
@@ -499,11 +1176,12 @@ func (rel *Relation) Find(id interface{}) RecordResult {
 //	person := Person.FindBy("salary > ?", 10000)
 //	// Ok(Some(#<Person id: 3, name: "Jeff", occupation: "CEO">))
 func (rel *Relation) FindBy(cond string, arg interface{}) RecordResult {
-	return rel.Where(cond, arg).First()
+	return rel.Where(cond, arg).Take()
 }
 
-// First find returns the first record.
-func (rel *Relation) First() RecordResult {
+// Take returns a record of the relation without an implicit order, so the
+// record is chosen by the database.
+func (rel *Relation) Take() RecordResult {
 	records, err := rel.Limit(1).ToA()
 	if err != nil {
 		return ErrRecord(err)
@@ -516,6 +1194,182 @@ func (rel *Relation) First() RecordResult {
 	}
 }
 
+// TakeN returns at most n records of the relation without an implicit order.
+func (rel *Relation) TakeN(n int) (Array, error) {
+	return rel.Limit(n).ToA()
+}
+
+// First returns the first record of the relation, relations without the order
+// are ordered by the primary key:
+//
+//	Person.First()
+//	// SELECT * FROM "people" ORDER BY people.id ASC LIMIT 1
+//
+//	Person.Order("created_at DESC").First()
+//	// SELECT * FROM "people" ORDER BY created_at DESC LIMIT 1
+func (rel *Relation) First() RecordResult {
+	return rel.ordered().Take()
+}
+
+// FirstN returns at most n first records of the relation, see First for the
+// order of records.
+func (rel *Relation) FirstN(n int) (Array, error) {
+	return rel.ordered().Limit(n).ToA()
+}
+
+// Last returns the last record of the relation. The order of the relation is
+// reversed, so only the last record is retrieved, relations without the order
+// are ordered by the primary key:
+//
+//	Person.Last()
+//	// SELECT * FROM "people" ORDER BY people.id DESC LIMIT 1
+//
+// Relations ordered by SQL expressions are retrieved entirely to find the last
+// record.
+func (rel *Relation) Last() RecordResult {
+	records, err := rel.LastN(1)
+	if err != nil {
+		return ErrRecord(err)
+	}
+	switch len(records) {
+	case 0:
+		return OkRecord(nil)
+	default:
+		return OkRecord(records[0])
+	}
+}
+
+// LastN returns at most n last records of the relation in the order of the
+// relation, see Last for details.
+//
+//	Person.LastN(2)
+//	// SELECT * FROM "people" ORDER BY people.id DESC LIMIT 2
+func (rel *Relation) LastN(n int) (Array, error) {
+	columns, _, ok := rel.keyset()
+	if records, loaded := rel.records.get(); loaded || !ok {
+		var err error
+		if !loaded {
+			if records, err = rel.ToA(); err != nil {
+				return nil, err
+			}
+		}
+		if len(records) > n {
+			records = records[len(records)-n:]
+		}
+		return records, nil
+	}
+
+	order := make([]string, len(columns))
+	for i, column := range columns {
+		direction := " DESC"
+		if column.Desc {
+			direction = " ASC"
+		}
+		order[i] = rel.tableName + "." + column.Name + direction
+	}
+
+	records, err := rel.Reorder(order...).Limit(n).ToA()
+	if err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(records)-1; i < j; i, j = i+1, j-1 {
+		records[i], records[j] = records[j], records[i]
+	}
+	return records, nil
+}
+
+// ordered returns the relation ordered by the primary key, when the relation
+// does not have the order.
+func (rel *Relation) ordered() *Relation {
+	if len(rel.build().orderValues) > 0 {
+		return rel
+	}
+	return rel.Order(rel.tableName + "." + rel.PrimaryKey() + " ASC")
+}
+
+// Sole returns the only record of the relation. When there are no records,
+// ErrRecordNotFound is returned, when there is more than one record,
+// ErrSoleRecordExceeded is returned.
+//
+//	Subscription.Where("user_id", 1).Where("active", true).Sole()
+//	// Ok(Some(#<Subscription id: 4, user_id: 1, active: true>))
+func (rel *Relation) Sole() RecordResult {
+	records, err := rel.Limit(2).ToA()
+	if err != nil {
+		return ErrRecord(err)
+	}
+	switch len(records) {
+	case 0:
+		return ErrRecord(&ErrRecordNotFound{Relation: rel.name, Conditions: rel.conditions()})
+	case 1:
+		return OkRecord(records[0])
+	default:
+		return ErrRecord(&ErrSoleRecordExceeded{RecordName: rel.Name()})
+	}
+}
+
+// conditions returns conditions of the relation including default scopes.
+func (rel *Relation) conditions() []string {
+	q := rel.build()
+	conds := make([]string, 0, len(q.whereValues))
+	for _, where := range q.whereValues {
+		conds = append(conds, where.Cond)
+	}
+	return conds
+}
+
+// FindSoleBy returns the only record matching the specified condition, see Sole
+// for details.
+//
+//	Subscription.FindSoleBy("user_id", 1)
+//	// Err(wanted only one subscription)
+func (rel *Relation) FindSoleBy(cond string, arg interface{}) RecordResult {
+	return rel.Where(cond, arg).Sole()
+}
+
+// whereAll returns the relation with equality conditions of all attributes.
+func (rel *Relation) whereAll(params map[string]interface{}) *Relation {
+	attrNames := make([]string, 0, len(params))
+	for attrName := range params {
+		attrNames = append(attrNames, attrName)
+	}
+	sort.Strings(attrNames)
+
+	newrel := rel
+	for _, attrName := range attrNames {
+		newrel = newrel.Where(attrName, params[attrName])
+	}
+	return newrel
+}
+
+// FindOrInitializeBy returns the first record with the specified attributes,
+// or a new unsaved record with these attributes, when it does not exist.
+//
+//	person := Person.FindOrInitializeBy(Hash{"name": "Bill"})
+//	// Ok(Some(#<Person id: nil, name: "Bill", occupation: nil>))
+func (rel *Relation) FindOrInitializeBy(params map[string]interface{}) RecordResult {
+	rec := rel.whereAll(params).First()
+	if rec.IsErr() || rec.Unwrap() != nil {
+		return rec
+	}
+	return rel.New(params)
+}
+
+// FindOrCreateBy returns the first record with the specified attributes, or
+// creates a record with these attributes, when it does not exist. The method
+// is not atomic, the unique index of attributes prevents duplicate records
+// created concurrently.
+//
+//	person := Person.FindOrCreateBy(Hash{"name": "Bill"})
+//	// Ok(Some(#<Person id: 1, name: "Bill", occupation: "retired">))
+func (rel *Relation) FindOrCreateBy(params map[string]interface{}) RecordResult {
+	rec := rel.whereAll(params).First()
+	if rec.IsErr() || rec.Unwrap() != nil {
+		return rec
+	}
+	return rel.Create(params)
+}
+
 func (rel *Relation) InsertAll(params ...map[string]interface{}) (
 	rr []*ActiveRecord, err error,
 ) {
@@ -529,9 +1383,9 @@ func (rel *Relation) InsertAll(params ...map[string]interface{}) (
 		rr = append(rr, rec)
 	}
 
-	if err = rel.connections.Transaction(rel.Context(), func() error {
+	if err = rel.connections.Transaction(rel.Context(), func(tx context.Context) error {
 		for i, rec := range rr {
-			if rr[i], err = rec.Insert(); err != nil {
+			if rr[i], err = rec.WithContext(tx).Insert(); err != nil {
 				return err
 			}
 		}
@@ -542,6 +1396,216 @@ func (rel *Relation) InsertAll(params ...map[string]interface{}) (
 	return rr, nil
 }
 
+// Count returns the number of records in the relation. The method accesses
+// database to calculate the number.
+//
+//	Person.Where("age", activerecord.GreaterThan(26)).Count()
+//	// SELECT COUNT(*) FROM "people" WHERE (age > ?)
+func (rel *Relation) Count() (int64, error) {
+	if rel.none {
+		return 0, nil
+	}
+
+	q := rel.build()
+	q.unscope(OrderClause)
+
+	var (
+		op   *QueryOperation
+		conn = rel.Connection()
+	)
+
+	// Limited and grouped relations are counted through the subquery, so the
+	// result represents a number of records returned by the relation.
+	if q.limit == nil && len(q.groupValues) == 0 {
+		q.selectValues = []string{"COUNT(*)"}
+		op = q.Operation()
+	} else {
+		if len(q.selectValues) == 0 {
+			q.Select(q.groupValues...)
+		}
+		op = q.Operation()
+
+		// Without subqueries, rows of the relation are counted one by one.
+		if !CapabilitiesOf(conn).Subqueries {
+			var rows int64
+			err := conn.ExecQuery(rel.Context(), op, func(Hash) bool {
+				rows++
+				return true
+			})
+			if err != nil {
+				return 0, err
+			}
+			return rows, nil
+		}
+		op.Text = fmt.Sprintf(`SELECT COUNT(*) FROM (%s) AS "subquery"`, op.Text)
+	}
+	op.Columns = []string{"count"}
+
+	var count interface{}
+	err := conn.ExecQuery(rel.Context(), op, func(h Hash) bool {
+		count = h["count"]
+		return true
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	num, err := new(Int64).Deserialize(count)
+	if err != nil {
+		return 0, err
+	}
+	return num.(int64), nil
+}
+
+// Pluck returns values of the given attributes for each record of the relation,
+// values are deserialized according to attribute types.
+//
+//	User.Pluck("id", "email")
+//	// SELECT users.id, users.email FROM "users"
+//	// [][]interface{}{{int64(1), "oscar@example.com"}, {int64(2), "ada@example.com"}}
+//
+// Use PluckAs to decode values into typed structs.
+func (rel *Relation) Pluck(attrNames ...string) ([][]interface{}, error) {
+	for _, attrName := range attrNames {
+		if !rel.scope.HasAttribute(attrName) {
+			return nil, &ErrUnknownAttribute{RecordName: rel.name, Attr: attrName}
+		}
+	}
+	if rel.none {
+		return nil, nil
+	}
+
+	q := rel.build()
+	q.selectValues = nil
+	for _, attrName := range attrNames {
+		q.Select(rel.tableName + "." + attrName)
+	}
+	op := q.Operation()
+	op.Columns = attrNames
+
+	var (
+		rows [][]interface{}
+		err  error
+	)
+	execErr := rel.Connection().ExecQuery(rel.Context(), op, func(h Hash) bool {
+		row := make([]interface{}, len(attrNames))
+		for i, attrName := range attrNames {
+			row[i], err = rel.scope.AttributeForInspect(attrName).AttributeType().Deserialize(h[attrName])
+			if err != nil {
+				return false
+			}
+		}
+		rows = append(rows, row)
+		return true
+	})
+	if execErr != nil {
+		return nil, execErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// GroupCount returns the number of records in each group of the grouped relation.
+// Keys of the map are values of the grouping attribute.
+//
+//	Order.Group("status").GroupCount()
+//	// SELECT status, COUNT(*) FROM "orders" GROUP BY status
+//	// map[interface{}]int64{"paid": 10, "shipped": 3}
+//
+// When the relation is grouped by multiple attributes, keys are fixed-size arrays
+// of values in the order of grouping attributes, e.g. [2]interface{} for two
+// attributes. Arrays are used instead of slices, since slices are not comparable
+// and cannot be map keys.
+//
+//	counts, _ := Order.Group("status", "region").GroupCount()
+//	counts[[2]interface{}{"paid", "eu"}]
+//
+// The ungrouped relation returns a map with a single nil key. Count of grouped
+// relations keeps returning the number of groups, so counts per group are
+// returned by the separate method.
+func (rel *Relation) GroupCount() (map[interface{}]int64, error) {
+	counts := make(map[interface{}]int64)
+	if rel.none {
+		return counts, nil
+	}
+
+	q := rel.build()
+	q.unscope(OrderClause)
+
+	groupValues := q.groupValues
+	if len(groupValues) == 0 {
+		count, err := rel.Count()
+		if err != nil {
+			return nil, err
+		}
+		counts[nil] = count
+		return counts, nil
+	}
+
+	q.selectValues = append(append([]string{}, groupValues...), "COUNT(*)")
+	op := q.Operation()
+	op.Columns = append(append([]string{}, groupValues...), "COUNT(*)")
+
+	// Multiple values are grouped into the array, so they could be used as
+	// a map key.
+	keyType := reflect.ArrayOf(len(groupValues), reflect.TypeOf((*interface{})(nil)).Elem())
+
+	var err error
+	execErr := rel.Connection().ExecQuery(rel.Context(), op, func(h Hash) bool {
+		key := reflect.New(keyType).Elem()
+		for i, attrName := range groupValues {
+			var value interface{}
+			value, err = rel.scope.AttributeForInspect(attrName).AttributeType().Deserialize(h[attrName])
+			if err != nil {
+				return false
+			}
+			key.Index(i).Set(reflect.ValueOf(&value).Elem())
+		}
+
+		var count interface{}
+		if count, err = new(Int64).Deserialize(h["COUNT(*)"]); err != nil {
+			return false
+		}
+
+		if len(groupValues) == 1 {
+			counts[key.Index(0).Interface()] = count.(int64)
+		} else {
+			counts[key.Interface()] = count.(int64)
+		}
+		return true
+	})
+	if execErr != nil {
+		return nil, execErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+// Explain returns the query plan of the relation as reported by the database.
+//
+//	plan, _ := User.Where("name", "Oscar").Explain()
+//	fmt.Println(plan)
+//	// EXPLAIN for: SELECT users.id, users.name FROM "users" WHERE (name = ?)
+//	// SCAN users
+//
+// Pass Analyze option to execute the query and report the actual timings, when
+// it is supported by the database.
+func (rel *Relation) Explain(options ...ExplainOption) (string, error) {
+	op := rel.selectQuery().Operation()
+
+	plan, err := rel.Connection().ExecExplain(rel.Context(), &ExplainOperation{
+		Query: op, Options: options,
+	})
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("EXPLAIN for: %s\n%s", op.Text, plan), nil
+}
+
 // ToA converts Relation to array. The method access database to retrieve objects.
 func (rel *Relation) ToA() (Array, error) {
 	var rr Array
@@ -561,7 +1625,13 @@ func (rel *Relation) ToA() (Array, error) {
 //	User.Where("name", "Oscar").ToSQL()
 //	// SELECT * FROM "users" WHERE "name" = ?
 func (rel *Relation) ToSQL() string {
-	return rel.query.String()
+	return rel.build().String()
+}
+
+// Operation returns the query operation of the relation with arguments of
+// conditions, e.g. to define the view selecting records of the relation.
+func (rel *Relation) Operation() *QueryOperation {
+	return rel.build().Operation()
 }
 
 func (rel *Relation) String() string {
@@ -2,8 +2,12 @@ package activerecord_test
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"os"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
@@ -139,11 +143,13 @@ func TestRelation_TransactionalInsert(t *testing.T) {
 		r.BelongsTo("author")
 	})
 
-	err = activerecord.Transaction(context.TODO(), func() error {
-		author := Author.Create(Hash{"name": "Max Tegmark"})
+	err = activerecord.Transaction(context.TODO(), func(tx context.Context) error {
+		author := Author.WithContext(tx).Create(Hash{"name": "Max Tegmark"})
 
 		book := author.AndThen(func(*activerecord.ActiveRecord) Result[*activerecord.ActiveRecord] {
-			return Book.Create(Hash{"title": "Life 3.0", "year": 2017, "author_id": 1}).Result
+			return Book.WithContext(tx).Create(Hash{
+				"title": "Life 3.0", "year": 2017, "author_id": 1,
+			}).Result
 		})
 
 		return book.Err()
@@ -159,3 +165,1314 @@ func TestRelation_TransactionalInsert(t *testing.T) {
 	require.NoError(t, err)
 	require.Len(t, book, 1)
 }
+
+func TestRelation_WhereRange(t *testing.T) {
+	conn, _ := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter:  "sqlite3",
+		Database: t.Name() + ".db",
+	})
+
+	defer os.Remove(t.Name() + ".db")
+	defer activerecord.RemoveConnection("primary")
+
+	initAuthorTable(t, conn)
+	initBookTable(t, conn)
+
+	Author := activerecord.New("author")
+	Book := activerecord.New("book")
+
+	author := Author.Create(Hash{"name": "Herman Melville"})
+	author.Expect("failed to create author")
+
+	_, err := Book.InsertAll(
+		Hash{"title": "Typee", "year": 1846, "author_id": author.Unwrap().ID()},
+		Hash{"title": "Omoo", "year": 1847, "author_id": author.Unwrap().ID()},
+		Hash{"title": "Mardi", "year": 1849, "author_id": author.Unwrap().ID()},
+		Hash{"title": "Moby Dick", "year": 1851, "author_id": author.Unwrap().ID()},
+	)
+	require.NoError(t, err)
+
+	books, err := Book.Where("year", activerecord.Between(1847, 1849)).ToA()
+	require.NoError(t, err)
+	require.Len(t, books, 2)
+
+	books, err = Book.Where("year", activerecord.GreaterThan(1847)).ToA()
+	require.NoError(t, err)
+	require.Len(t, books, 2)
+
+	books, err = Book.Where("year", activerecord.LessThanOrEq(1847)).ToA()
+	require.NoError(t, err)
+	require.Len(t, books, 2)
+
+	books, err = Book.
+		Where("year", activerecord.GreaterThanOrEq(1847)).
+		Where("year", activerecord.LessThan(1851)).ToA()
+	require.NoError(t, err)
+	require.Len(t, books, 2)
+}
+
+func TestRelation_DefaultScope(t *testing.T) {
+	conn, _ := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter:  "sqlite3",
+		Database: t.Name() + ".db",
+	})
+
+	defer os.Remove(t.Name() + ".db")
+	defer activerecord.RemoveConnection("primary")
+
+	initAuthorTable(t, conn)
+	initBookTable(t, conn)
+
+	Author := activerecord.New("author")
+	authors, err := Author.InsertAll(Hash{"name": "Herman Melville"})
+	require.NoError(t, err)
+
+	Book := activerecord.New("book", func(r *activerecord.R) {
+		r.DefaultScope(func(rel *activerecord.Relation) *activerecord.Relation {
+			return rel.Where("year", activerecord.GreaterThan(1846))
+		})
+	})
+
+	books, err := Book.InsertAll(
+		Hash{"title": "Typee", "year": 1846, "author_id": authors[0].ID()},
+		Hash{"title": "Omoo", "year": 1847, "author_id": authors[0].ID()},
+		Hash{"title": "Mardi", "year": 1849, "author_id": authors[0].ID()},
+	)
+	require.NoError(t, err)
+
+	bb, err := Book.All().ToA()
+	require.NoError(t, err)
+	require.Len(t, bb, 2)
+
+	bb, err = Book.Where("title", "Omoo").ToA()
+	require.NoError(t, err)
+	require.Len(t, bb, 1)
+
+	// Records hidden by the default scope are not accessible by primary key.
+	book := Book.Find(books[0].ID())
+	require.True(t, errors.Is(book.Err(), new(activerecord.ErrRecordNotFound)))
+
+	bb, err = Book.Unscoped().ToA()
+	require.NoError(t, err)
+	require.Len(t, bb, 3)
+
+	bb, err = Book.Unscoped().Where("title", "Typee").ToA()
+	require.NoError(t, err)
+	require.Len(t, bb, 1)
+
+	bb, err = Book.Where("title", "Omoo").Unscope(activerecord.WhereClause).ToA()
+	require.NoError(t, err)
+	require.Len(t, bb, 3)
+}
+
+func TestRelation_Include(t *testing.T) {
+	_, err := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter:  "sqlite3",
+		Database: t.Name() + ".db",
+	})
+	require.NoError(t, err)
+
+	defer os.Remove(t.Name() + ".db")
+	defer activerecord.RemoveConnection("primary")
+
+	activerecord.Migrate(t.Name(), func(m *activerecord.M) {
+		m.CreateTable("articles", func(t *activerecord.Table) {
+			t.String("title")
+			t.DateTime("deleted_at")
+		})
+	})
+
+	var saved []string
+
+	SoftDeletable := activerecord.Concern(func(r *activerecord.R) {
+		r.DefaultScope(func(rel *activerecord.Relation) *activerecord.Relation {
+			return rel.Where("deleted_at", nil)
+		})
+	})
+	Auditable := activerecord.Concern(func(r *activerecord.R) {
+		r.ValidatesPresence("title")
+		r.AfterSave(func(rec *activerecord.ActiveRecord) error {
+			saved = append(saved, rec.CacheKey())
+			return nil
+		})
+	})
+
+	Article := activerecord.New("article", func(r *activerecord.R) {
+		r.Include(SoftDeletable, Auditable)
+	})
+
+	require.Error(t, Article.Create(Hash{"title": nil}).Err())
+
+	_, err = Article.InsertAll(
+		Hash{"title": "Draft", "deleted_at": time.Now()},
+		Hash{"title": "Published"},
+	)
+	require.NoError(t, err)
+
+	articles, err := Article.All().ToA()
+	require.NoError(t, err)
+	require.Len(t, articles, 1)
+
+	require.NoError(t, Article.Create(Hash{"title": "Announcement"}).Err())
+	require.Equal(t, []string{"articles/1", "articles/2", "articles/3"}, saved)
+}
+
+func TestRelation_None(t *testing.T) {
+	conn, _ := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter:  "sqlite3",
+		Database: t.Name() + ".db",
+	})
+
+	defer os.Remove(t.Name() + ".db")
+
+	initAuthorTable(t, conn)
+
+	Author := activerecord.New("author")
+	_, err := Author.InsertAll(Hash{"name": "First"}, Hash{"name": "Second"})
+	require.NoError(t, err)
+
+	authors := Author.None().Where("name", "First")
+	require.Contains(t, authors.ToSQL(), "1=0")
+
+	// Once the connection is removed, any query to the database fails,
+	// so the none relation must not access database.
+	require.NoError(t, activerecord.RemoveConnection("primary"))
+
+	aa, err := authors.ToA()
+	require.NoError(t, err)
+	require.Empty(t, aa)
+
+	author := authors.First()
+	require.NoError(t, author.Err())
+	require.Nil(t, author.Unwrap())
+}
+
+func TestRelation_ImmutableChaining(t *testing.T) {
+	conn, _ := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter:  "sqlite3",
+		Database: t.Name() + ".db",
+	})
+
+	defer os.Remove(t.Name() + ".db")
+	defer activerecord.RemoveConnection("primary")
+
+	initAuthorTable(t, conn)
+
+	Author := activerecord.New("author")
+	_, err := Author.InsertAll(
+		Hash{"name": "Alfred"}, Hash{"name": "Bertrand"}, Hash{"name": "Carl"},
+	)
+	require.NoError(t, err)
+
+	base := Author.Where("name", activerecord.GreaterThan("A"))
+	sql := base.ToSQL()
+
+	ordered := base.Order("name DESC")
+	limited := base.Limit(1)
+	filtered := base.Where("name", "Carl")
+
+	// Derived relations must not change the shared base relation.
+	require.Equal(t, sql, base.ToSQL())
+	require.NotEqual(t, sql, ordered.ToSQL())
+	require.NotEqual(t, sql, limited.ToSQL())
+	require.NotEqual(t, sql, filtered.ToSQL())
+	require.NotContains(t, limited.ToSQL(), "ORDER BY")
+	require.NotContains(t, ordered.ToSQL(), "LIMIT")
+
+	aa, err := ordered.ToA()
+	require.NoError(t, err)
+	require.Len(t, aa, 3)
+	require.Equal(t, "Carl", aa[0].Attribute("name"))
+
+	for rel, num := range map[*activerecord.Relation]int64{
+		base: 3, ordered: 3, limited: 1, filtered: 1,
+	} {
+		count, err := rel.Count()
+		require.NoError(t, err)
+		require.Equal(t, num, count, rel.ToSQL())
+	}
+}
+
+func TestRelation_LazyEvaluation(t *testing.T) {
+	conn, _ := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter:  "sqlite3",
+		Database: t.Name() + ".db",
+	})
+
+	defer os.Remove(t.Name() + ".db")
+	defer activerecord.RemoveConnection("primary")
+
+	initAuthorTable(t, conn)
+
+	Author := activerecord.New("author")
+	authors := Author.Where("name", "Carl").Order("id").Limit(2)
+
+	// Records inserted after the relation construction are visible, since the
+	// query is executed only by kicker methods.
+	_, err := Author.InsertAll(Hash{"name": "Carl"}, Hash{"name": "Carl"}, Hash{"name": "Ada"})
+	require.NoError(t, err)
+
+	count, err := authors.Count()
+	require.NoError(t, err)
+	require.Equal(t, int64(2), count)
+
+	// Relation built without established connection fails only when the
+	// query is executed.
+	require.NoError(t, activerecord.RemoveConnection("primary"))
+	authors = Author.Where("name", "Carl").Limit(1)
+
+	_, err = authors.ToA()
+	require.Error(t, err)
+
+	_, err = activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter:  "sqlite3",
+		Database: t.Name() + ".db",
+	})
+	require.NoError(t, err)
+
+	err = authors.Each(func(*activerecord.ActiveRecord) error { return nil })
+	require.NoError(t, err)
+
+	aa, err := authors.ToA()
+	require.NoError(t, err)
+	require.Len(t, aa, 1)
+}
+
+func TestRelation_WhereSubquery(t *testing.T) {
+	conn, _ := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter:  "sqlite3",
+		Database: t.Name() + ".db",
+	})
+
+	defer os.Remove(t.Name() + ".db")
+	defer activerecord.RemoveConnection("primary")
+
+	initAuthorTable(t, conn)
+	initBookTable(t, conn)
+
+	Author := activerecord.New("author")
+	Book := activerecord.New("book")
+
+	authors, err := Author.InsertAll(Hash{"name": "Herman Melville"}, Hash{"name": "Noah Harari"})
+	require.NoError(t, err)
+
+	_, err = Book.InsertAll(
+		Hash{"title": "Omoo", "year": 1847, "author_id": authors[0].ID()},
+		Hash{"title": "Moby Dick", "year": 1851, "author_id": authors[0].ID()},
+		Hash{"title": "Sapiens", "year": 2015, "author_id": authors[1].ID()},
+	)
+	require.NoError(t, err)
+
+	melville := Author.Where("name", "Herman Melville")
+
+	books := Book.Where("author_id", melville.Select("id"))
+	require.Contains(t, books.ToSQL(), "author_id IN (SELECT authors.id FROM")
+
+	bb, err := books.ToA()
+	require.NoError(t, err)
+	require.Len(t, bb, 2)
+
+	// Without selected attribute, the primary key is used for the subquery.
+	bb, err = Book.Where("author_id", melville).Where("year", 1851).ToA()
+	require.NoError(t, err)
+	require.Len(t, bb, 1)
+
+	bb, err = Book.Where("author_id", melville.None()).ToA()
+	require.NoError(t, err)
+	require.Len(t, bb, 0)
+}
+
+func TestRelation_Explain(t *testing.T) {
+	conn, _ := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter:  "sqlite3",
+		Database: t.Name() + ".db",
+	})
+
+	defer os.Remove(t.Name() + ".db")
+	defer activerecord.RemoveConnection("primary")
+
+	initAuthorTable(t, conn)
+
+	Author := activerecord.New("author")
+
+	plan, err := Author.Where("id", 1).Explain()
+	require.NoError(t, err)
+	require.Contains(t, plan, "EXPLAIN for: SELECT")
+	require.Contains(t, plan, "USING INTEGER PRIMARY KEY")
+
+	plan, err = Author.Order("name").Explain(activerecord.Analyze)
+	require.NoError(t, err)
+	require.Contains(t, plan, "SCAN")
+}
+
+func TestRelation_Annotate(t *testing.T) {
+	conn, _ := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter:  "sqlite3",
+		Database: t.Name() + ".db",
+	})
+
+	defer os.Remove(t.Name() + ".db")
+	defer activerecord.RemoveConnection("primary")
+
+	initAuthorTable(t, conn)
+
+	Author := activerecord.New("author")
+	_, err := Author.InsertAll(Hash{"name": "Ada"})
+	require.NoError(t, err)
+
+	authors := Author.Where("name", "Ada").Annotate("dashboard#index")
+	require.Contains(t, authors.ToSQL(), "(name = ?) /* dashboard#index */")
+
+	authors = authors.Annotate("/* evil */ */ DROP TABLE authors; --")
+	require.Contains(t, authors.ToSQL(), "/* evil * / * / DROP TABLE authors; -- */")
+
+	aa, err := authors.ToA()
+	require.NoError(t, err)
+	require.Len(t, aa, 1)
+}
+
+func TestRelation_WithQueryCache(t *testing.T) {
+	conn, _ := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter:  "sqlite3",
+		Database: t.Name() + ".db",
+	})
+
+	defer os.Remove(t.Name() + ".db")
+	defer activerecord.RemoveConnection("primary")
+
+	initAuthorTable(t, conn)
+
+	Author := activerecord.New("author")
+	_, err := Author.InsertAll(Hash{"name": "Ada"})
+	require.NoError(t, err)
+
+	ctx := activerecord.WithQueryCache(context.Background())
+	authors := Author.WithContext(ctx)
+
+	count, err := authors.Count()
+	require.NoError(t, err)
+	require.Equal(t, int64(1), count)
+
+	// The write is performed without query cache, so the cached result
+	// is returned for the identical query.
+	Author.Create(Hash{"name": "Grace"}).Expect("failed to create author")
+
+	count, err = authors.Count()
+	require.NoError(t, err)
+	require.Equal(t, int64(1), count)
+
+	count, err = Author.Count()
+	require.NoError(t, err)
+	require.Equal(t, int64(2), count)
+
+	// Any write through the context with the cache invalidates the cache.
+	authors.Create(Hash{"name": "Barbara"}).Expect("failed to create author")
+
+	count, err = authors.Count()
+	require.NoError(t, err)
+	require.Equal(t, int64(3), count)
+
+	aa, err := authors.Order("name").ToA()
+	require.NoError(t, err)
+	require.Len(t, aa, 3)
+	require.Equal(t, "Ada", aa[0].Attribute("name"))
+}
+
+func TestRelation_Loaded(t *testing.T) {
+	conn, _ := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter:  "sqlite3",
+		Database: t.Name() + ".db",
+	})
+
+	defer os.Remove(t.Name() + ".db")
+	defer activerecord.RemoveConnection("primary")
+
+	initAuthorTable(t, conn)
+
+	Author := activerecord.New("author")
+	_, err := Author.InsertAll(Hash{"name": "Ada"})
+	require.NoError(t, err)
+
+	authors := Author.All().Unwrap()
+	require.False(t, authors.Loaded())
+
+	aa, err := authors.ToA()
+	require.NoError(t, err)
+	require.Len(t, aa, 1)
+	require.True(t, authors.Loaded())
+
+	// Memoized records are returned until the relation is reloaded.
+	_, err = Author.InsertAll(Hash{"name": "Grace"})
+	require.NoError(t, err)
+
+	bb, err := authors.ToA()
+	require.NoError(t, err)
+	require.Len(t, bb, 1)
+	require.Same(t, aa[0], bb[0])
+
+	bb, err = authors.Reload().ToA()
+	require.NoError(t, err)
+	require.Len(t, bb, 2)
+
+	// Derived relations do not share memoized records.
+	require.False(t, authors.Limit(1).Loaded())
+
+	// The relation defined with New is shared, therefore it never memoizes
+	// the records.
+	_, err = Author.ToA()
+	require.NoError(t, err)
+	require.False(t, Author.Loaded())
+}
+
+func TestRelation_TableNameAndFrom(t *testing.T) {
+	_, err := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter:  "sqlite3",
+		Database: t.Name() + ".db",
+	})
+	require.NoError(t, err)
+
+	defer os.Remove(t.Name() + ".db")
+	defer activerecord.RemoveConnection("primary")
+
+	activerecord.Migrate(t.Name(), func(m *activerecord.M) {
+		m.CreateTable("legacy_customers", func(t *activerecord.Table) {
+			t.String("name")
+			t.Int64("rating")
+		})
+	})
+
+	Customer := activerecord.New("customer", func(r *activerecord.R) {
+		r.TableName("legacy_customers")
+	})
+	require.Equal(t, "legacy_customers", Customer.TableName())
+	require.True(t, Customer.HasAttributes("name", "rating"))
+
+	_, err = Customer.InsertAll(
+		Hash{"name": "Ada", "rating": 5},
+		Hash{"name": "Grace", "rating": 3},
+		Hash{"name": "Barbara", "rating": 4},
+	)
+	require.NoError(t, err)
+
+	customers, err := Customer.Where("rating", activerecord.GreaterThan(3)).ToA()
+	require.NoError(t, err)
+	require.Len(t, customers, 2)
+
+	top := Customer.From(
+		"(SELECT * FROM legacy_customers WHERE rating > 4) AS legacy_customers",
+	)
+	customers, err = top.ToA()
+	require.NoError(t, err)
+	require.Len(t, customers, 1)
+	require.Equal(t, "Ada", customers[0].Attribute("name"))
+
+	count, err := top.Count()
+	require.NoError(t, err)
+	require.Equal(t, int64(1), count)
+}
+
+func TestRelation_ReorderAndUnscope(t *testing.T) {
+	conn, _ := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter:  "sqlite3",
+		Database: t.Name() + ".db",
+	})
+
+	defer os.Remove(t.Name() + ".db")
+	defer activerecord.RemoveConnection("primary")
+
+	initAuthorTable(t, conn)
+
+	Author := activerecord.New("author", func(r *activerecord.R) {
+		r.DefaultScope(func(rel *activerecord.Relation) *activerecord.Relation {
+			return rel.Where("name", activerecord.LessThan("C")).Order("name")
+		})
+	})
+	_, err := Author.InsertAll(Hash{"name": "Ada"}, Hash{"name": "Bob"}, Hash{"name": "Carl"})
+	require.NoError(t, err)
+
+	authors, err := Author.ToA()
+	require.NoError(t, err)
+	require.Len(t, authors, 2)
+	require.Equal(t, "Ada", authors[0].Attribute("name"))
+
+	authors, err = Author.Reorder("name DESC").ToA()
+	require.NoError(t, err)
+	require.Len(t, authors, 2)
+	require.Equal(t, "Bob", authors[0].Attribute("name"))
+
+	unscoped := Author.Order("id DESC").Unscope(activerecord.OrderClause, activerecord.WhereClause)
+	require.NotContains(t, unscoped.ToSQL(), "ORDER BY")
+	require.NotContains(t, unscoped.ToSQL(), "WHERE")
+
+	authors, err = unscoped.Order("name DESC").ToA()
+	require.NoError(t, err)
+	require.Len(t, authors, 3)
+	require.Equal(t, "Carl", authors[0].Attribute("name"))
+}
+
+func TestRelation_GroupCount(t *testing.T) {
+	conn, _ := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter:  "sqlite3",
+		Database: t.Name() + ".db",
+	})
+
+	defer os.Remove(t.Name() + ".db")
+	defer activerecord.RemoveConnection("primary")
+
+	initAuthorTable(t, conn)
+	initBookTable(t, conn)
+
+	Author := activerecord.New("author")
+	Book := activerecord.New("book")
+
+	_, err := Author.InsertAll(Hash{"name": "Stanislaw Lem"}, Hash{"name": "Isaac Asimov"})
+	require.NoError(t, err)
+
+	_, err = Book.InsertAll(
+		Hash{"title": "Solaris", "year": 1961, "author_id": 1},
+		Hash{"title": "The Cyberiad", "year": 1965, "author_id": 1},
+		Hash{"title": "Return from the Stars", "year": 1961, "author_id": 1},
+		Hash{"title": "The Naked Sun", "year": 1956, "author_id": 2},
+		Hash{"title": "Nine Tomorrows", "year": 1959, "author_id": 2},
+	)
+	require.NoError(t, err)
+
+	counts, err := Book.Group("author_id").GroupCount()
+	require.NoError(t, err)
+	require.Equal(t, map[interface{}]int64{int64(1): 3, int64(2): 2}, counts)
+
+	counts, err = Book.Group("author_id", "year").GroupCount()
+	require.NoError(t, err)
+	require.Len(t, counts, 4)
+	require.Equal(t, int64(2), counts[[2]interface{}{int64(1), int64(1961)}])
+	require.Equal(t, int64(1), counts[[2]interface{}{int64(2), int64(1956)}])
+
+	counts, err = Book.Where("year", activerecord.LessThan(1960)).GroupCount()
+	require.NoError(t, err)
+	require.Equal(t, map[interface{}]int64{nil: 2}, counts)
+
+	counts, err = Book.Group("year").None().GroupCount()
+	require.NoError(t, err)
+	require.Empty(t, counts)
+}
+
+func TestRelation_Pluck(t *testing.T) {
+	conn, _ := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter:  "sqlite3",
+		Database: t.Name() + ".db",
+	})
+
+	defer os.Remove(t.Name() + ".db")
+	defer activerecord.RemoveConnection("primary")
+
+	initAuthorTable(t, conn)
+	initBookTable(t, conn)
+
+	Author := activerecord.New("author")
+	Book := activerecord.New("book")
+
+	_, err := Author.InsertAll(Hash{"name": "Stanislaw Lem"})
+	require.NoError(t, err)
+	_, err = Book.InsertAll(
+		Hash{"title": "Solaris", "year": 1961, "author_id": 1},
+		Hash{"title": "The Cyberiad", "year": 1965, "author_id": 1},
+	)
+	require.NoError(t, err)
+
+	rows, err := Book.Order("year").Pluck("id", "title")
+	require.NoError(t, err)
+	require.Equal(t, [][]interface{}{{int64(1), "Solaris"}, {int64(2), "The Cyberiad"}}, rows)
+
+	_, err = Book.Pluck("isbn")
+	require.Error(t, err)
+
+	type bookRow struct {
+		Title    string
+		Year     int
+		AuthorID *int64
+		Key      int64 `activerecord:"id"`
+	}
+
+	books, err := activerecord.PluckAs[bookRow](Book.Order("year DESC"), "title", "year", "author_id", "id")
+	require.NoError(t, err)
+	require.Len(t, books, 2)
+	require.Equal(t, "The Cyberiad", books[0].Title)
+	require.Equal(t, 1965, books[0].Year)
+	require.Equal(t, int64(1), *books[0].AuthorID)
+	require.Equal(t, int64(2), books[0].Key)
+
+	// Integer cannot be decoded into a string field.
+	type idRow struct {
+		ID string
+	}
+	_, err = activerecord.PluckAs[idRow](Book, "id")
+	require.Error(t, err)
+
+	titles, err := activerecord.PluckAs[string](Book.Order("title DESC"), "title")
+	require.NoError(t, err)
+	require.Equal(t, []string{"The Cyberiad", "Solaris"}, titles)
+
+	_, err = activerecord.PluckAs[string](Book, "title", "year")
+	require.Error(t, err)
+}
+
+func TestRelation_ScanInto(t *testing.T) {
+	conn, _ := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter:  "sqlite3",
+		Database: t.Name() + ".db",
+	})
+
+	defer os.Remove(t.Name() + ".db")
+	defer activerecord.RemoveConnection("primary")
+
+	initAuthorTable(t, conn)
+	initBookTable(t, conn)
+
+	Author := activerecord.New("author", func(r *activerecord.R) {
+		r.HasMany("books")
+	})
+	Book := activerecord.New("book", func(r *activerecord.R) {
+		r.BelongsTo("author")
+	})
+
+	_, err := Author.InsertAll(Hash{"name": "Stanislaw Lem"}, Hash{"name": "Arkady Strugatsky"})
+	require.NoError(t, err)
+	_, err = Book.InsertAll(
+		Hash{"title": "Solaris", "year": 1961, "author_id": 1},
+		Hash{"title": "The Cyberiad", "year": 1965, "author_id": 1},
+		Hash{"title": "Roadside Picnic", "year": 1972, "author_id": 2},
+	)
+	require.NoError(t, err)
+
+	type bookRow struct {
+		ID     int64
+		Title  string
+		Author string `activerecord:"author_name" select:"authors.name"`
+		isbn   string
+	}
+
+	var books []bookRow
+	err = Book.Joins("author").Order("books.year DESC").ScanInto(&books)
+	require.NoError(t, err)
+	require.Equal(t, []bookRow{
+		{ID: 3, Title: "Roadside Picnic", Author: "Arkady Strugatsky"},
+		{ID: 2, Title: "The Cyberiad", Author: "Stanislaw Lem"},
+		{ID: 1, Title: "Solaris", Author: "Stanislaw Lem"},
+	}, books)
+
+	type authorRow struct {
+		AuthorID int64
+		Name     string `activerecord:"name" select:"authors.name"`
+		Books    int64  `activerecord:"books_count" select:"COUNT(*)"`
+	}
+
+	var authors []*authorRow
+	err = Book.Joins("author").Group("author_id").Order("author_id").ScanInto(&authors)
+	require.NoError(t, err)
+	require.Equal(t, []*authorRow{
+		{AuthorID: 1, Name: "Stanislaw Lem", Books: 2},
+		{AuthorID: 2, Name: "Arkady Strugatsky", Books: 1},
+	}, authors)
+
+	// Destination must be a pointer to a slice of structs.
+	err = Book.ScanInto(books)
+	require.Error(t, err)
+
+	var titles []string
+	err = Book.ScanInto(&titles)
+	require.Error(t, err)
+}
+
+func TestRelation_Sole(t *testing.T) {
+	conn, _ := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter:  "sqlite3",
+		Database: t.Name() + ".db",
+	})
+
+	defer os.Remove(t.Name() + ".db")
+	defer activerecord.RemoveConnection("primary")
+
+	initAuthorTable(t, conn)
+
+	Author := activerecord.New("author")
+	_, err := Author.InsertAll(Hash{"name": "Ada"}, Hash{"name": "Ada"}, Hash{"name": "Bob"})
+	require.NoError(t, err)
+
+	author := Author.FindSoleBy("name", "Bob")
+	require.NoError(t, author.Err())
+	require.Equal(t, "Bob", author.Unwrap().Attribute("name"))
+
+	author = Author.FindSoleBy("name", "Ada")
+	require.True(t, errors.Is(author.Err(), &activerecord.ErrSoleRecordExceeded{}))
+
+	author = Author.Where("name", "Carl").Sole()
+	require.True(t, errors.Is(author.Err(), &activerecord.ErrRecordNotFound{}))
+}
+
+func TestRelation_FindOrCreateBy(t *testing.T) {
+	conn, _ := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter:  "sqlite3",
+		Database: t.Name() + ".db",
+	})
+
+	defer os.Remove(t.Name() + ".db")
+	defer activerecord.RemoveConnection("primary")
+
+	initAuthorTable(t, conn)
+
+	Author := activerecord.New("author")
+	author := Author.FindOrCreateBy(Hash{"name": "Ada"})
+	require.NoError(t, author.Err())
+
+	same := Author.FindOrCreateBy(Hash{"name": "Ada"})
+	require.NoError(t, same.Err())
+	require.Equal(t, author.Unwrap().ID(), same.Unwrap().ID())
+
+	author = Author.FindOrInitializeBy(Hash{"name": "Bob"})
+	require.NoError(t, author.Err())
+	require.Nil(t, author.Unwrap().ID())
+	require.Equal(t, "Bob", author.Unwrap().Attribute("name"))
+
+	count, err := Author.Count()
+	require.NoError(t, err)
+	require.Equal(t, int64(1), count)
+}
+
+func TestRelation_After(t *testing.T) {
+	conn, _ := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter:  "sqlite3",
+		Database: t.Name() + ".db",
+	})
+
+	defer os.Remove(t.Name() + ".db")
+	defer activerecord.RemoveConnection("primary")
+
+	initAuthorTable(t, conn)
+
+	Author := activerecord.New("author")
+	_, err := Author.InsertAll(
+		Hash{"name": "Bob"}, Hash{"name": "Ada"}, Hash{"name": "Bob"},
+		Hash{"name": "Carl"}, Hash{"name": "Ada"},
+	)
+	require.NoError(t, err)
+
+	var (
+		ordered = Author.Order("name DESC")
+		names   []string
+		ids     []interface{}
+		page    = ordered.After("")
+	)
+	for {
+		authors, err := page.Limit(2).ToA()
+		require.NoError(t, err)
+		if len(authors) == 0 {
+			break
+		}
+		for _, author := range authors {
+			names = append(names, author.Attribute("name").(string))
+			ids = append(ids, author.ID())
+		}
+
+		cursor, err := ordered.Cursor(authors[len(authors)-1])
+		require.NoError(t, err)
+		page = ordered.After(cursor)
+	}
+
+	require.Equal(t, []string{"Carl", "Bob", "Bob", "Ada", "Ada"}, names)
+	require.Equal(t, []interface{}{int64(4), int64(1), int64(3), int64(2), int64(5)}, ids)
+
+	// Invalid cursors are returned by queries of the relation, so After
+	// is chained with other methods.
+	_, err = Author.After("invalid cursor").Limit(2).ToA()
+	require.ErrorIs(t, err, &activerecord.ErrCursor{})
+
+	err = Author.After("invalid cursor").Each(func(*activerecord.ActiveRecord) error { return nil })
+	require.ErrorIs(t, err, &activerecord.ErrCursor{})
+
+	// Cursors of another order are rejected.
+	cursor, err := Author.Cursor(Author.Find(1).Unwrap())
+	require.NoError(t, err)
+	_, err = ordered.After(cursor).Count()
+	require.ErrorIs(t, err, &activerecord.ErrCursor{})
+
+	_, err = Author.Order("LOWER(name)").After("").ToA()
+	require.ErrorIs(t, err, &activerecord.ErrCursor{})
+
+	author := Author.Find(1)
+	require.NoError(t, author.Err())
+	_, err = Author.Order("LOWER(name)").Cursor(author.Unwrap())
+	require.Error(t, err)
+}
+
+func TestRelation_WhereLike(t *testing.T) {
+	conn, _ := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter:  "sqlite3",
+		Database: t.Name() + ".db",
+	})
+
+	defer os.Remove(t.Name() + ".db")
+	defer activerecord.RemoveConnection("primary")
+
+	initProductTable(t, conn)
+
+	Product := activerecord.New("product")
+	_, err := Product.InsertAll(
+		Hash{"name": "Discount 100%"}, Hash{"name": "Discount 1000"},
+		Hash{"name": "snake_case"}, Hash{"name": "snakeXcase"}, Hash{"name": "SNAKE_CASE"},
+	)
+	require.NoError(t, err)
+
+	names := func(rel *activerecord.Relation) []string {
+		rows, err := activerecord.PluckAs[string](rel.Order("id"), "name")
+		require.NoError(t, err)
+		return rows
+	}
+
+	require.Equal(t, []string{"Discount 100%"},
+		names(Product.WhereLike("name", "%"+activerecord.SanitizeLike("100%"))))
+	require.Equal(t, []string{"Discount 100%", "Discount 1000"},
+		names(Product.WhereLike("name", "%100%")))
+	require.Equal(t, []string{"snake_case", "SNAKE_CASE"},
+		names(Product.WhereILike("name", activerecord.SanitizeLike("snake_")+"%")))
+	require.Equal(t, []string{"snake_case", "SNAKE_CASE"},
+		names(Product.Where("name", activerecord.CaseInsensitive("Snake_Case"))))
+	require.Equal(t, "a!!b!%c!_", activerecord.SanitizeLike("a!b%c_"))
+}
+
+func TestRelation_TypedQuery(t *testing.T) {
+	conn, _ := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter:  "sqlite3",
+		Database: t.Name() + ".db",
+	})
+
+	defer os.Remove(t.Name() + ".db")
+	defer activerecord.RemoveConnection("primary")
+
+	initAuthorTable(t, conn)
+	initBookTable(t, conn)
+
+	type book struct {
+		ID    int64
+		Title string
+		Year  int64
+	}
+
+	Author := activerecord.New("author")
+	Book := activerecord.New("book")
+
+	columns := activerecord.MustColumns(Book, struct {
+		Title activerecord.Column[string]
+		Year  activerecord.Column[int64]
+	}{
+		Title: activerecord.NewColumn[string]("title"),
+		Year:  activerecord.NewColumn[int64]("year"),
+	})
+
+	// Misspelled names and mismatching types are rejected.
+	_, err := activerecord.CheckColumns(Book, struct {
+		Title activerecord.Column[string]
+	}{activerecord.NewColumn[string]("titel")})
+	require.Equal(t, &activerecord.ErrColumn{
+		RecordName: "book", Column: "titel", Message: "unknown attribute",
+	}, err)
+
+	_, err = activerecord.CheckColumns(Book, struct {
+		Year activerecord.Column[string]
+	}{activerecord.NewColumn[string]("year")})
+	require.ErrorIs(t, err, &activerecord.ErrColumn{})
+
+	_, err = activerecord.CheckColumns(Book, struct {
+		Year activerecord.Column[*int64]
+	}{activerecord.NewColumn[*int64]("year")})
+	require.NoError(t, err)
+
+	require.Panics(t, func() {
+		activerecord.MustColumns(Book, struct {
+			Price activerecord.Column[int64]
+		}{activerecord.NewColumn[int64]("price")})
+	})
+
+	_, err = Author.InsertAll(Hash{"name": "Stanislaw Lem"})
+	require.NoError(t, err)
+	_, err = Book.InsertAll(
+		Hash{"title": "Solaris", "year": 1961, "author_id": 1},
+		Hash{"title": "The Cyberiad", "year": 1965, "author_id": 1},
+		Hash{"title": "Fiasco", "year": 1986, "author_id": 1},
+	)
+	require.NoError(t, err)
+
+	books, err := activerecord.Query[book](Book).
+		Where(columns.Year.Gt(1961), columns.Title.NotEq("Fiasco")).
+		ToA()
+	require.NoError(t, err)
+	require.Equal(t, []book{{ID: 2, Title: "The Cyberiad", Year: 1965}}, books)
+
+	books, err = activerecord.Query[book](Book).
+		Where(columns.Title.In("Fiasco", "Solaris")).
+		Order(columns.Year.Desc()).
+		ToA()
+	require.NoError(t, err)
+	require.Len(t, books, 2)
+	require.Equal(t, "Fiasco", books[0].Title)
+
+	count, err := activerecord.Query[book](Book).Where(columns.Title.In()).Count()
+	require.NoError(t, err)
+	require.Equal(t, int64(0), count)
+}
+
+func TestRelation_ComposedOf(t *testing.T) {
+	_, err := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter: "sqlite3", Database: t.Name() + ".db",
+	})
+	require.NoError(t, err)
+
+	defer os.Remove(t.Name() + ".db")
+	defer activerecord.RemoveConnection("primary")
+
+	activerecord.Migrate(t.Name()+"_add_customers_table", func(m *activerecord.M) {
+		m.CreateTable("customers", func(t *activerecord.Table) {
+			t.String("name")
+			t.String("street")
+			t.String("city")
+			t.String("zip")
+		})
+	})
+
+	type Address struct {
+		Street string
+		City   string
+		Zip    string `activerecord:"zip"`
+	}
+
+	Customer := activerecord.New("customer", func(r *activerecord.R) {
+		r.ComposedOf("address",
+			activerecord.Mapping("street", "city", "zip"),
+			activerecord.Constructor(func(h Hash) interface{} {
+				street, _ := h["street"].(string)
+				city, _ := h["city"].(string)
+				zip, _ := h["zip"].(string)
+				return Address{street, city, zip}
+			}),
+		)
+	})
+
+	warsaw := Address{"Nowy Swiat 1", "Warsaw", "00-001"}
+	customer := Customer.Create(Hash{"name": "Lem", "address": warsaw}).Unwrap()
+	require.Equal(t, warsaw, customer.Attribute("address"))
+	require.Equal(t, "Warsaw", customer.Attribute("city"))
+
+	Customer.Create(Hash{"name": "Bradbury", "address": Address{"Main St 1", "Waukegan", "60085"}})
+	Customer.Create(Hash{"name": "Nobody"})
+
+	customer = Customer.Where("address", warsaw).First().Unwrap()
+	require.Equal(t, "Lem", customer.Attribute("name"))
+	require.Equal(t, warsaw, customer.Attribute("address"))
+
+	customer = Customer.Where("address", nil).First().Unwrap()
+	require.Equal(t, "Nobody", customer.Attribute("name"))
+	require.Nil(t, customer.Attribute("address"))
+	require.False(t, customer.AttributePresent("address"))
+
+	// The value object is replaced as a whole.
+	require.NoError(t, customer.AssignAttribute("address", Hash{"city": "Kraków"}))
+	require.Equal(t, Address{City: "Kraków"}, customer.Attribute("address"))
+
+	err = customer.AssignAttribute("address", "Warsaw")
+	require.Error(t, err)
+}
+
+func TestRelation_ResetCounters(t *testing.T) {
+	_, err := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter: "sqlite3", Database: t.Name() + ".db",
+	})
+	require.NoError(t, err)
+
+	defer os.Remove(t.Name() + ".db")
+	defer activerecord.RemoveConnection("primary")
+
+	activerecord.Migrate(t.Name()+"_add_authors_and_books_tables", func(m *activerecord.M) {
+		m.CreateTable("authors", func(t *activerecord.Table) {
+			t.String("name")
+			t.Int64("books_count")
+		})
+		m.CreateTable("books", func(t *activerecord.Table) {
+			t.String("title")
+			t.References("authors")
+		})
+	})
+
+	Author := activerecord.New("author", func(r *activerecord.R) {
+		r.HasMany("books")
+	})
+	Book := activerecord.New("book", func(r *activerecord.R) {
+		r.BelongsTo("author")
+	})
+
+	var ids []interface{}
+	for i, name := range []string{"Lem", "Bradbury", "Strugatsky"} {
+		author := Author.Create(Hash{"name": name, "books_count": 7}).Unwrap()
+		for j := 0; j < i; j++ {
+			Book.Create(Hash{"title": fmt.Sprintf("%s %d", name, j), "author_id": author.ID()})
+		}
+		ids = append(ids, author.ID())
+	}
+
+	require.NoError(t, Author.ResetCounters(ids[1], "books"))
+	require.EqualValues(t, 1, Author.Find(ids[1]).Unwrap().Attribute("books_count"))
+	require.EqualValues(t, 7, Author.Find(ids[2]).Unwrap().Attribute("books_count"))
+
+	err = Author.ResetCounters(42, "books")
+	require.ErrorIs(t, err, new(activerecord.ErrRecordNotFound))
+
+	fixed, err := Author.ReconcileCounters(2, "books")
+	require.NoError(t, err)
+	require.EqualValues(t, 2, fixed)
+
+	counts, err := Author.Order("id").Pluck("books_count")
+	require.NoError(t, err)
+	require.Equal(t, [][]interface{}{{int64(0)}, {int64(1)}, {int64(2)}}, counts)
+
+	_, err = Author.ReconcileCounters(0, "articles")
+	require.Error(t, err)
+}
+
+func TestRelation_ConcurrentInitialize(t *testing.T) {
+	_, err := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter: "sqlite3", Database: t.Name() + ".db",
+	})
+	require.NoError(t, err)
+
+	defer os.Remove(t.Name() + ".db")
+	defer activerecord.RemoveConnection("primary")
+
+	activerecord.Migrate(t.Name()+"_add_authors_and_books_tables", func(m *activerecord.M) {
+		m.CreateTable("authors", func(t *activerecord.Table) {
+			t.String("name")
+		})
+		m.CreateTable("books", func(t *activerecord.Table) {
+			t.String("title")
+			t.References("authors")
+		})
+	})
+
+	Author := activerecord.New("author", func(r *activerecord.R) {
+		r.HasMany("books")
+	})
+	author := Author.Create(Hash{"name": "Lem"}).Unwrap()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			activerecord.New("book", func(r *activerecord.R) {
+				r.BelongsTo("author")
+			})
+		}()
+		go func() {
+			defer wg.Done()
+			rel, err := activerecord.ReflectOnRelation("author")
+			require.NoError(t, err)
+
+			_, err = rel.Find(author.ID()).Collection("books").ToA()
+			require.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	_, err = activerecord.ReflectOnRelation("book")
+	require.NoError(t, err)
+}
+
+func TestRelation_FirstLastTake(t *testing.T) {
+	_, err := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter: "sqlite3", Database: t.Name() + ".db",
+	})
+	require.NoError(t, err)
+
+	defer os.Remove(t.Name() + ".db")
+	defer activerecord.RemoveConnection("primary")
+
+	activerecord.Migrate(t.Name()+"_add_books_table", func(m *activerecord.M) {
+		m.CreateTable("books", func(t *activerecord.Table) {
+			t.String("title")
+			t.Int64("year")
+		})
+	})
+
+	Book := activerecord.New("book")
+	for _, book := range []Hash{
+		{"title": "Solaris", "year": 1961},
+		{"title": "Eden", "year": 1959},
+		{"title": "Fiasco", "year": 1986},
+	} {
+		Book.Create(book).Expect("failed to create a book")
+	}
+
+	titles := func(records activerecord.Array) (titles []string) {
+		for _, rec := range records {
+			titles = append(titles, rec.Attribute("title").(string))
+		}
+		return titles
+	}
+
+	require.Equal(t, "Solaris", Book.First().Unwrap().Attribute("title"))
+	require.Equal(t, "Fiasco", Book.Last().Unwrap().Attribute("title"))
+	require.Nil(t, Book.Where("year", 1900).First().Unwrap())
+	require.Nil(t, Book.Where("year", 1900).Last().Unwrap())
+
+	books, err := Book.FirstN(2)
+	require.NoError(t, err)
+	require.Equal(t, []string{"Solaris", "Eden"}, titles(books))
+
+	books, err = Book.LastN(2)
+	require.NoError(t, err)
+	require.Equal(t, []string{"Eden", "Fiasco"}, titles(books))
+
+	// The order of the relation is respected.
+	byYear := Book.Order("year DESC")
+	require.Equal(t, "Fiasco", byYear.First().Unwrap().Attribute("title"))
+	require.Equal(t, "Eden", byYear.Last().Unwrap().Attribute("title"))
+
+	books, err = byYear.LastN(2)
+	require.NoError(t, err)
+	require.Equal(t, []string{"Solaris", "Eden"}, titles(books))
+
+	books, err = Book.TakeN(5)
+	require.NoError(t, err)
+	require.Len(t, books, 3)
+	require.NotNil(t, Book.Take().Unwrap())
+}
+
+func TestRelation_FindAll(t *testing.T) {
+	_, err := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter: "sqlite3", Database: t.Name() + ".db",
+	})
+	require.NoError(t, err)
+
+	defer os.Remove(t.Name() + ".db")
+	defer activerecord.RemoveConnection("primary")
+
+	activerecord.Migrate(t.Name()+"_add_books_table", func(m *activerecord.M) {
+		m.CreateTable("books", func(t *activerecord.Table) {
+			t.String("title")
+		})
+	})
+
+	Book := activerecord.New("book")
+	var ids []interface{}
+	for _, title := range []string{"Solaris", "Eden", "Fiasco"} {
+		ids = append(ids, Book.Create(Hash{"title": title}).Unwrap().ID())
+	}
+
+	books, err := Book.FindAll([]interface{}{ids[2], ids[0], ids[2]}).ToA()
+	require.NoError(t, err)
+	require.Len(t, books, 2)
+	require.Equal(t, "Fiasco", books[0].Attribute("title"))
+	require.Equal(t, "Solaris", books[1].Attribute("title"))
+
+	_, err = Book.FindAll([]interface{}{ids[1], 42}).ToA()
+	require.ErrorIs(t, err, &activerecord.ErrRecordNotFound{})
+	require.Equal(t, []interface{}{42}, err.(*activerecord.ErrRecordNotFound).ID)
+
+	books, err = Book.FindAll([]interface{}{ids[1], 42}, activerecord.AllowMissing()).ToA()
+	require.NoError(t, err)
+	require.Len(t, books, 1)
+	require.Equal(t, "Eden", books[0].Attribute("title"))
+
+	books, err = Book.FindAll(nil).ToA()
+	require.NoError(t, err)
+	require.Empty(t, books)
+}
+
+func TestRelation_ErrRecordNotFound(t *testing.T) {
+	_, err := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter: "sqlite3", Database: t.Name() + ".db",
+	})
+	require.NoError(t, err)
+
+	defer os.Remove(t.Name() + ".db")
+	defer activerecord.RemoveConnection("primary")
+
+	activerecord.Migrate(t.Name()+"_add_books_table", func(m *activerecord.M) {
+		m.CreateTable("books", func(t *activerecord.Table) {
+			t.String("title")
+		})
+	})
+
+	Book := activerecord.New("book")
+
+	var notFound *activerecord.ErrRecordNotFound
+	require.True(t, errors.As(Book.Find(42).Err(), &notFound))
+	require.Equal(t, &activerecord.ErrRecordNotFound{
+		Relation: "book", PrimaryKey: "id", ID: 42,
+	}, notFound)
+	require.Equal(t, "book not found by id = 42", notFound.Error())
+
+	require.True(t, errors.As(Book.FindSoleBy("title", "Solaris").Err(), &notFound))
+	require.Equal(t, "book", notFound.Relation)
+	require.Equal(t, []string{"title = ?"}, notFound.Conditions)
+	require.Equal(t, "book not found by title = ?", notFound.Error())
+}
+
+func TestRelation_Sanitize(t *testing.T) {
+	_, err := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter: "sqlite3", Database: t.Name() + ".db",
+	})
+	require.NoError(t, err)
+
+	defer os.Remove(t.Name() + ".db")
+	defer activerecord.RemoveConnection("primary")
+
+	activerecord.Migrate(t.Name()+"_add_books_table", func(m *activerecord.M) {
+		m.CreateTable("books", func(t *activerecord.Table) {
+			t.String("title")
+			t.Int64("year")
+		})
+	})
+
+	Book := activerecord.New("book")
+	Book.Create(Hash{"title": "Solaris", "year": 1961}).Expect("failed to create a book")
+	Book.Create(Hash{"title": "Eden", "year": 1959}).Expect("failed to create a book")
+
+	require.Equal(t, `"books"."title"`, activerecord.Quote("books.title"))
+	require.Equal(t, `"my""table"`, activerecord.Quote(`my"table`))
+	require.Equal(t, "a!!b!%c!_", activerecord.SanitizeSQLLike("a!b%c_"))
+
+	// Qualified columns are compared as columns.
+	books := Book.Where("books.year", activerecord.GreaterThan(1960))
+	require.Contains(t, books.ToSQL(), `("books"."year" > ?)`)
+	require.Equal(t, "Solaris", books.First().Unwrap().Attribute("title"))
+
+	// Orders by SQL expressions are rejected by queries, trusted expressions
+	// are passed with UnsafeOrder.
+	for order, rejected := range map[string]string{
+		"year; DROP TABLE books":           "year; DROP TABLE books",
+		"RANDOM()":                         "RANDOM()",
+		"CASE WHEN year > 1960 THEN 0 END": "CASE WHEN year > 1960 THEN 0 END",
+		"year DESC, RANDOM()":              "RANDOM()",
+	} {
+		_, err = Book.Order("title", order).ToA()
+		require.Equal(t, ErrArgument{Message: fmt.Sprintf(
+			"order %q is not a column or a function of a column, use UnsafeOrder for SQL expressions", rejected,
+		)}, err, order)
+	}
+
+	_, err = Book.Order("RANDOM()").Count()
+	require.ErrorAs(t, err, new(ErrArgument))
+
+	records, err := Book.UnsafeOrder("CASE WHEN year > 1960 THEN 0 ELSE 1 END", "id").ToA()
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	require.Equal(t, "Solaris", records[0].Attribute("title"))
+
+	records, err = Book.Order("LOWER(books.title) DESC NULLS LAST", "year ASC, id").ToA()
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	require.Equal(t, "Solaris", records[0].Attribute("title"))
+
+	records, err = Book.Group("books.year").ToA()
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	require.NotContains(t, Book.Group("year) UNION SELECT * FROM (books").ToSQL(), "UNION")
+}
@@ -0,0 +1,100 @@
+package activerecord
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// identifierRe matches names of columns optionally qualified by names
+	// of tables, e.g. "books.title".
+	identifierRe = regexp.MustCompile(`\A\w+(?:\.\w+)?\z`)
+
+	// orderRe matches columns or functions of columns followed by the direction
+	// of sorting, e.g. "LOWER(name) DESC NULLS LAST".
+	orderRe = regexp.MustCompile(
+		`(?i)\A(?:\w+(?:\.\w+)?|\w+\(\s*\w+(?:\.\w+)?\s*\))(?:\s+(?:ASC|DESC))?(?:\s+NULLS\s+(?:FIRST|LAST))?\z`,
+	)
+)
+
+// Quote returns the identifier of the table or column quoted with the ANSI SQL
+// quotes, parts of qualified identifiers are quoted separately:
+//
+//	activerecord.Quote(`books.title`)  // "books"."title"
+//	activerecord.Quote(`my"table`)     // "my""table"
+//
+// Use Quote to include names supplied by users into SQL fragments.
+func Quote(identifier string) string {
+	parts := strings.Split(identifier, ".")
+	for i, part := range parts {
+		parts[i] = DefaultDialect.QuoteIdentifier(part)
+	}
+	return strings.Join(parts, ".")
+}
+
+// likeEscape is an escape character of wildcards in LIKE patterns. Backslash is
+// not used, since it is treated as an escape character of string literals by
+// some databases.
+const likeEscape = "!"
+
+var likeReplacer = strings.NewReplacer(
+	likeEscape, likeEscape+likeEscape,
+	"%", likeEscape+"%",
+	"_", likeEscape+"_",
+)
+
+// SanitizeSQLLike escapes wildcards of the LIKE pattern, so the string could be
+// safely used as a part of the pattern in Like and ILike conditions.
+//
+//	pattern := "%" + activerecord.SanitizeSQLLike("100%") + "%"
+//	Product.WhereLike("description", pattern)
+func SanitizeSQLLike(s string) string {
+	return likeReplacer.Replace(s)
+}
+
+// SanitizeLike escapes wildcards of the LIKE pattern.
+//
+// Deprecated: use SanitizeSQLLike.
+func SanitizeLike(s string) string {
+	return SanitizeSQLLike(s)
+}
+
+// isIdentifier returns true, when the string is a name of the column optionally
+// qualified by the name of the table.
+func isIdentifier(s string) bool {
+	return identifierRe.MatchString(s)
+}
+
+// sanitizeOrder returns the first rejected part of comma-separated values of the
+// order and false, when the part is not a column or a function of a column with
+// the direction of sorting.
+func sanitizeOrder(values []string) (string, bool) {
+	for _, value := range values {
+		for _, part := range strings.Split(value, ",") {
+			if part = strings.TrimSpace(part); !orderRe.MatchString(part) {
+				return part, false
+			}
+		}
+	}
+	return "", true
+}
+
+// columnType returns the type of the column of the relation or of relations of
+// joined associations, the column is optionally qualified by the name the table.
+func (rel *Relation) columnType(column string) (Type, bool) {
+	tableName, attrName := rel.tableName, column
+	if i := strings.IndexByte(column, '.'); i >= 0 {
+		tableName, attrName = column[:i], column[i+1:]
+	}
+
+	scopes := []*Relation{rel}
+	for _, join := range rel.query.joinValues {
+		scopes = append(scopes, join.Relation)
+	}
+	for _, scope := range scopes {
+		if scope.tableName == tableName && scope.scope.HasAttribute(attrName) {
+			return scope.scope.AttributeForInspect(attrName).AttributeType(), true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,153 @@
+package activerecord
+
+import (
+	"fmt"
+	"reflect"
+
+	. "github.com/activegraph/activegraph/activesupport"
+)
+
+// scanColumn is the selected column, which is scanned into the struct field.
+type scanColumn struct {
+	name  string
+	expr  string
+	index int
+	// attrType is the type of the relation attribute, values of expressions
+	// are assigned as returned by the database.
+	attrType Type
+}
+
+// scanColumns returns selected columns of struct fields. Fields with the "select"
+// tag select the SQL expression named after the "activerecord" tag, other fields
+// select attributes of the relation matched the same way as by PluckAs. Fields
+// without columns are left untouched.
+func (rel *Relation) scanColumns(typ reflect.Type) []scanColumn {
+	var columns []scanColumn
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		name := field.Tag.Get("activerecord")
+		if expr := field.Tag.Get("select"); expr != "" && name != "" {
+			columns = append(columns, scanColumn{name: name, expr: expr, index: i})
+			continue
+		}
+
+		for _, attrName := range rel.scope.AttributeNames() {
+			if index, ok := pluckField(typ, attrName); ok && index == i {
+				columns = append(columns, scanColumn{
+					name:     attrName,
+					expr:     rel.tableName + "." + attrName,
+					index:    i,
+					attrType: rel.scope.AttributeForInspect(attrName).AttributeType(),
+				})
+				break
+			}
+		}
+	}
+	return columns
+}
+
+// ScanInto scans selected columns of the relation into the slice of structs (or
+// pointers to structs), so rows are retrieved without the overhead of records.
+// Fields are mapped to attributes the same way as by PluckAs, fields with the
+// "select" tag select the SQL expression, e.g. columns of joined tables or
+// aggregations, named after the "activerecord" tag:
+//
+//	type ProductRow struct {
+//		ID       int64
+//		Name     string
+//		Category string `activerecord:"category" select:"categories.name"`
+//		Orders   int64  `activerecord:"orders" select:"COUNT(orders.id)"`
+//	}
+//
+//	var rows []ProductRow
+//	err := Product.Joins("category").Group("products.id").ScanInto(&rows)
+//	// SELECT products.id AS "id", products.name AS "name", categories.name AS "category", ...
+//
+// Values of attributes are deserialized according to attribute types, values
+// of expressions are assigned as returned by the database.
+func (rel *Relation) ScanInto(dest interface{}) error {
+	ptr := reflect.ValueOf(dest)
+	if ptr.Kind() != reflect.Ptr || ptr.Elem().Kind() != reflect.Slice {
+		return &ErrPluck{TypeName: fmt.Sprintf("%T", dest), Message: "destination is not a pointer to a slice"}
+	}
+
+	var (
+		slice    = ptr.Elem()
+		elemType = slice.Type().Elem()
+		typ      = elemType
+	)
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return &ErrPluck{TypeName: typ.String(), Message: "elements of the destination are not structs"}
+	}
+
+	columns := rel.scanColumns(typ)
+	if len(columns) == 0 {
+		return &ErrPluck{TypeName: typ.String(), Message: "no fields for selected columns"}
+	}
+
+	rows := reflect.MakeSlice(slice.Type(), 0, 0)
+	if rel.none {
+		slice.Set(rows)
+		return nil
+	}
+
+	var (
+		conn  = rel.Connection()
+		d     = DialectOf(conn)
+		q     = rel.build()
+		names = make([]string, len(columns))
+	)
+	q.selectValues = nil
+	for i, column := range columns {
+		q.Select(column.expr + " AS " + d.QuoteIdentifier(column.name))
+		names[i] = column.name
+	}
+	op := q.Operation()
+	op.Columns = names
+
+	var err error
+	execErr := conn.ExecQuery(rel.Context(), op, func(h Hash) bool {
+		elem := reflect.New(typ).Elem()
+		for _, column := range columns {
+			value := h[column.name]
+			if column.attrType != nil && value != nil {
+				if value, err = column.attrType.Deserialize(value); err != nil {
+					return false
+				}
+			}
+			// Drivers return text of expressions as bytes.
+			field := elem.Field(column.index)
+			if b, ok := value.([]byte); ok && field.Kind() == reflect.String {
+				value = string(b)
+			}
+			if err = pluckAssign(field, value); err != nil {
+				err = &ErrPluck{TypeName: typ.String(), Message: fmt.Sprintf(
+					"column %q: %s", column.name, err,
+				)}
+				return false
+			}
+		}
+
+		if elemType.Kind() == reflect.Ptr {
+			elem = elem.Addr()
+		}
+		rows = reflect.Append(rows, elem)
+		return true
+	})
+	if execErr != nil {
+		return execErr
+	}
+	if err != nil {
+		return err
+	}
+
+	slice.Set(rows)
+	return nil
+}
@@ -0,0 +1,357 @@
+package activerecord
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"sort"
+	"sync"
+)
+
+// SchemaTables is implemented by connections, which list tables of the database.
+type SchemaTables interface {
+	TableNames(ctx context.Context) ([]string, error)
+}
+
+// TableNames returns names of tables of the database sorted by names.
+// ErrNotSupported is returned, when the connection does not implement
+// SchemaTables interface.
+func TableNames(ctx context.Context, conn Conn) ([]string, error) {
+	tables, ok := adapterConn(conn).(SchemaTables)
+	if !ok {
+		return nil, &ErrNotSupported{Feature: "tables introspection"}
+	}
+	return tables.TableNames(ctx)
+}
+
+// schemaCacheTable is the cached schema of the table.
+type schemaCacheTable struct {
+	columns []ColumnDefinition
+	indexes []IndexDefinition
+	// indexed is true, when indexes of the table are loaded.
+	indexed bool
+}
+
+// SchemaCache caches definitions of columns and indexes of tables, so models
+// are defined without the introspection of the database. Each connection pool
+// has its own schema cache, which is filled on the first definition of models.
+//
+// The cache is serializable, so it could be dumped at deploy time and loaded
+// at boot with SchemaCache option of the database configuration:
+//
+//	cache := activerecord.NewSchemaCache()
+//	if err := cache.Load(ctx, conn); err != nil {
+//		return err
+//	}
+//	err := cache.DumpFile("db/schema_cache.json")
+//
+// Migrations clear the schema cache of the connection, when they change the
+// schema of the database.
+type SchemaCache struct {
+	mu     sync.RWMutex
+	tables map[string]*schemaCacheTable
+}
+
+// NewSchemaCache returns a new empty schema cache.
+func NewSchemaCache() *SchemaCache {
+	return &SchemaCache{tables: make(map[string]*schemaCacheTable)}
+}
+
+// SchemaCacheOf returns the schema cache of the connection pool, nil is returned,
+// when the connection is not established with EstablishConnection.
+func SchemaCacheOf(conn Conn) *SchemaCache {
+	for {
+		switch c := conn.(type) {
+		case *ConnectionPool:
+			return c.schemaCache
+		case *pooledTx:
+			return c.pool.schemaCache
+		case *cachedConn:
+			conn = c.Conn
+		case *readingConn:
+			conn = c.Conn
+		default:
+			return nil
+		}
+	}
+}
+
+// schemaColumnDefinitions returns columns of the table from the schema cache of
+// the connection, when the connection has the schema cache.
+func schemaColumnDefinitions(ctx context.Context, conn Conn, tableName string) (
+	[]ColumnDefinition, error,
+) {
+	if cache := SchemaCacheOf(conn); cache != nil {
+		return cache.ColumnDefinitions(ctx, conn, tableName)
+	}
+	return conn.ColumnDefinitions(ctx, tableName)
+}
+
+func (c *SchemaCache) table(tableName string) (schemaCacheTable, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	table, ok := c.tables[tableName]
+	if !ok {
+		return schemaCacheTable{}, false
+	}
+	return *table, true
+}
+
+// ColumnDefinitions returns cached columns of the table, columns are loaded
+// from the connection and cached, when the table is not cached.
+func (c *SchemaCache) ColumnDefinitions(ctx context.Context, conn Conn, tableName string) (
+	[]ColumnDefinition, error,
+) {
+	if table, ok := c.table(tableName); ok {
+		return table.columns, nil
+	}
+
+	columns, err := conn.ColumnDefinitions(ctx, tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if table, ok := c.tables[tableName]; ok {
+		return table.columns, nil
+	}
+	c.tables[tableName] = &schemaCacheTable{columns: columns}
+	return columns, nil
+}
+
+// IndexDefinitions returns cached indexes of the table, indexes are loaded from
+// the connection and cached, when indexes of the table are not cached.
+func (c *SchemaCache) IndexDefinitions(ctx context.Context, conn Conn, tableName string) (
+	[]IndexDefinition, error,
+) {
+	if table, ok := c.table(tableName); ok && table.indexed {
+		return table.indexes, nil
+	}
+
+	columns, err := c.ColumnDefinitions(ctx, conn, tableName)
+	if err != nil {
+		return nil, err
+	}
+	indexes, err := IndexDefinitions(ctx, conn, tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.tables[tableName] = &schemaCacheTable{columns: columns, indexes: indexes, indexed: true}
+	return indexes, nil
+}
+
+// Load loads definitions of all tables of the database into the cache, the
+// schema migrations table is skipped.
+func (c *SchemaCache) Load(ctx context.Context, conn Conn) error {
+	tableNames, err := TableNames(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	tables := make(map[string]*schemaCacheTable, len(tableNames))
+	for _, tableName := range tableNames {
+		if tableName == SchemaMigrationsName {
+			continue
+		}
+
+		columns, err := conn.ColumnDefinitions(ctx, tableName)
+		if err != nil {
+			return err
+		}
+		indexes, err := IndexDefinitions(ctx, conn, tableName)
+		if err != nil && !errors.Is(err, new(ErrNotSupported)) {
+			return err
+		}
+		tables[tableName] = &schemaCacheTable{columns: columns, indexes: indexes, indexed: err == nil}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tables = tables
+	return nil
+}
+
+// TableNames returns names of cached tables sorted by names.
+func (c *SchemaCache) TableNames() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	names := make([]string, 0, len(c.tables))
+	for name := range c.tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Clear removes all tables from the cache.
+func (c *SchemaCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tables = make(map[string]*schemaCacheTable)
+}
+
+// ClearTable removes the table from the cache.
+func (c *SchemaCache) ClearTable(tableName string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.tables, tableName)
+}
+
+// schemaCacheColumn is the serialized column definition, the type of the column
+// is serialized by its name.
+type schemaCacheColumn struct {
+	Name         string `json:"name"`
+	Type         string `json:"type"`
+	NotNull      bool   `json:"not_null,omitempty"`
+	IsPrimaryKey bool   `json:"primary_key,omitempty"`
+	Default      string `json:"default,omitempty"`
+	Comment      string `json:"comment,omitempty"`
+}
+
+type schemaCacheIndex struct {
+	Name    string   `json:"name"`
+	Columns []string `json:"columns"`
+	Unique  bool     `json:"unique,omitempty"`
+	Where   string   `json:"where,omitempty"`
+}
+
+type schemaCacheEntry struct {
+	Columns []schemaCacheColumn `json:"columns"`
+	Indexes []schemaCacheIndex  `json:"indexes,omitempty"`
+	Indexed bool                `json:"indexed,omitempty"`
+}
+
+// schemaTypes are types of columns restored from the serialized cache.
+var schemaTypes = map[string]func() Type{
+	new(Int64).String():    func() Type { return new(Int64) },
+	new(String).String():   func() Type { return new(String) },
+	new(Float64).String():  func() Type { return new(Float64) },
+	new(Boolean).String():  func() Type { return new(Boolean) },
+	new(DateTime).String(): func() Type { return new(DateTime) },
+	new(Date).String():     func() Type { return new(Date) },
+	new(Time).String():     func() Type { return new(Time) },
+	new(JSON).String():     func() Type { return new(JSON) },
+}
+
+// MarshalJSON returns the JSON encoding of the cache. ErrUnsupportedType is
+// returned for columns of custom types.
+func (c *SchemaCache) MarshalJSON() ([]byte, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	entries := make(map[string]schemaCacheEntry, len(c.tables))
+	for name, table := range c.tables {
+		entry := schemaCacheEntry{
+			Columns: make([]schemaCacheColumn, len(table.columns)),
+			Indexed: table.indexed,
+		}
+		for i, column := range table.columns {
+			typeName := column.Type.String()
+			if _, ok := schemaTypes[typeName]; !ok {
+				return nil, ErrUnsupportedType{TypeName: typeName}
+			}
+			entry.Columns[i] = schemaCacheColumn{
+				Name:         column.Name,
+				Type:         typeName,
+				NotNull:      column.NotNull,
+				IsPrimaryKey: column.IsPrimaryKey,
+				Default:      column.Default,
+				Comment:      column.Comment,
+			}
+		}
+		for _, index := range table.indexes {
+			entry.Indexes = append(entry.Indexes, schemaCacheIndex{
+				Name: index.Name, Columns: index.Columns, Unique: index.Unique, Where: index.Where,
+			})
+		}
+		entries[name] = entry
+	}
+	return json.Marshal(entries)
+}
+
+// UnmarshalJSON replaces tables of the cache with tables of the JSON encoding.
+func (c *SchemaCache) UnmarshalJSON(b []byte) error {
+	var entries map[string]schemaCacheEntry
+	if err := json.Unmarshal(b, &entries); err != nil {
+		return err
+	}
+
+	tables := make(map[string]*schemaCacheTable, len(entries))
+	for name, entry := range entries {
+		table := schemaCacheTable{
+			columns: make([]ColumnDefinition, len(entry.Columns)),
+			indexed: entry.Indexed,
+		}
+		for i, column := range entry.Columns {
+			newType, ok := schemaTypes[column.Type]
+			if !ok {
+				return ErrUnsupportedType{TypeName: column.Type}
+			}
+			table.columns[i] = ColumnDefinition{
+				Name:         column.Name,
+				Type:         newType(),
+				NotNull:      column.NotNull,
+				IsPrimaryKey: column.IsPrimaryKey,
+				Default:      column.Default,
+				Comment:      column.Comment,
+			}
+		}
+		for _, index := range entry.Indexes {
+			table.indexes = append(table.indexes, IndexDefinition{
+				Name:    index.Name,
+				Table:   name,
+				Columns: index.Columns,
+				Unique:  index.Unique,
+				Where:   index.Where,
+			})
+		}
+		tables[name] = &table
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tables = tables
+	return nil
+}
+
+// Dump writes the JSON encoding of the cache.
+func (c *SchemaCache) Dump(w io.Writer) error {
+	b, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
+
+// DumpFile writes the JSON encoding of the cache into the file.
+func (c *SchemaCache) DumpFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := c.Dump(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// LoadFile replaces tables of the cache with tables of the dumped file.
+func (c *SchemaCache) LoadFile(path string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, c)
+}
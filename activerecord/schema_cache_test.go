@@ -0,0 +1,59 @@
+package activerecord_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/activegraph/activegraph/activerecord"
+	_ "github.com/activegraph/activegraph/activerecord/sqlite3"
+	. "github.com/activegraph/activegraph/activesupport"
+)
+
+func TestSchemaCache(t *testing.T) {
+	conn, err := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter: "sqlite3", Database: t.Name() + ".db",
+	})
+	require.NoError(t, err)
+
+	defer os.Remove(t.Name() + ".db")
+	defer os.Remove(t.Name() + ".json")
+
+	initAuthorTable(t, conn)
+
+	ctx := context.TODO()
+	cache := activerecord.NewSchemaCache()
+	require.NoError(t, cache.Load(ctx, conn))
+	require.Equal(t, []string{"authors"}, cache.TableNames())
+	require.NoError(t, cache.DumpFile(t.Name()+".json"))
+	require.NoError(t, activerecord.RemoveConnection("primary"))
+
+	conn, err = activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter: "sqlite3", Database: t.Name() + ".db", SchemaCache: t.Name() + ".json",
+	})
+	require.NoError(t, err)
+	defer activerecord.RemoveConnection("primary")
+
+	columns, err := activerecord.SchemaCacheOf(conn).ColumnDefinitions(ctx, conn, "authors")
+	require.NoError(t, err)
+	types := make(map[string]activerecord.Type)
+	for _, column := range columns {
+		types[column.Name] = column.Type
+	}
+	require.Equal(t, map[string]activerecord.Type{
+		"id": new(activerecord.Int64), "name": new(activerecord.String),
+	}, types)
+
+	_, err = activerecord.Execute(ctx, `ALTER TABLE "authors" ADD COLUMN "born" INTEGER`)
+	require.NoError(t, err)
+
+	// Models are defined with columns of the loaded cache.
+	Author := activerecord.New("author")
+	require.Error(t, Author.New(Hash{"name": "Ada", "born": 1815}).Err())
+
+	activerecord.SchemaCacheOf(conn).ClearTable("authors")
+	Author = activerecord.New("author")
+	require.NoError(t, Author.Create(Hash{"name": "Ada", "born": 1815}).Err())
+}
@@ -0,0 +1,139 @@
+package activerecord
+
+import (
+	"fmt"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// PasswordCost is the bcrypt cost of password digests computed by relations
+// with HasSecurePassword. Tests could lower the cost to bcrypt.MinCost in order
+// to speed up creation of records.
+var PasswordCost = bcrypt.DefaultCost
+
+// maxPasswordLength is the maximum length of passwords hashed by bcrypt.
+const maxPasswordLength = 72
+
+// virtualAttr is the virtual attribute, which keeps the assigned value.
+type virtualAttr struct {
+	attr
+}
+
+func (a virtualAttr) assignAttribute(attrs *attributes, val interface{}) error {
+	attrs.assignVirtualAttribute(a.Name, val)
+	return nil
+}
+
+// passwordAttr is the virtual attribute of the password, which assigns the
+// digest of the password on assignment.
+type passwordAttr struct {
+	attr
+	digestName string
+}
+
+func (a passwordAttr) assignAttribute(attrs *attributes, val interface{}) error {
+	switch password := val.(type) {
+	case nil:
+		attrs.assignVirtualAttribute(a.Name, nil)
+		return attrs.AssignAttribute(a.digestName, nil)
+	case string:
+		attrs.assignVirtualAttribute(a.Name, password)
+		// Blank passwords keep the digest, so forms without the password
+		// do not reset the password of the existing record. Long passwords
+		// are reported by the validation.
+		if password == "" || len(password) > maxPasswordLength {
+			return nil
+		}
+		digest, err := bcrypt.GenerateFromPassword([]byte(password), PasswordCost)
+		if err != nil {
+			return ErrInvalidValue{AttrName: a.Name, Message: err.Error()}
+		}
+		return attrs.AssignAttribute(a.digestName, string(digest))
+	default:
+		return ErrInvalidType{AttrName: a.Name, TypeName: a.Type.String(), Value: val}
+	}
+}
+
+// passwordValidator validates that the record has the password digest and the
+// password matches the confirmation, when it is assigned.
+type passwordValidator struct {
+	digestName       string
+	confirmationName string
+}
+
+func (v *passwordValidator) AllowsNil() bool   { return false }
+func (v *passwordValidator) AllowsBlank() bool { return false }
+
+func (v *passwordValidator) ValidateAttribute(r *ActiveRecord, attrName string, val interface{}) error {
+	if password, ok := val.(string); ok && len(password) > maxPasswordLength {
+		return ErrInvalidValue{AttrName: attrName, Message: fmt.Sprintf(
+			"is too long (maximum is %d bytes)", maxPasswordLength,
+		)}
+	}
+	if !r.AttributePresent(v.digestName) {
+		return ErrInvalidValue{AttrName: attrName, Value: val, Message: "can't be blank"}
+	}
+	confirmation := r.Attribute(v.confirmationName)
+	if confirmation != nil && val != nil && confirmation != val {
+		return ErrInvalidValue{AttrName: attrName, Message: "doesn't match confirmation"}
+	}
+	return nil
+}
+
+// HasSecurePassword adds methods to set and authenticate against a bcrypt
+// password. The relation must have the "password_digest" attribute, which is
+// defined by the declaration, when the table does not have the column.
+//
+// The declaration adds virtual "password" and "password_confirmation" attributes,
+// which are not stored in the database. The digest is computed on assignment of
+// the password, the following validations are added:
+//
+//   - the password must be present on creation;
+//   - the password must not be longer than 72 bytes;
+//   - confirmation of the password (when it is assigned).
+//
+// Example:
+//
+//	User := activerecord.New("user", func(r *activerecord.R) {
+//		r.HasSecurePassword()
+//	})
+//
+//	user := User.Create(Hash{"password": "secret", "password_confirmation": "secret"})
+//	user.Unwrap().Authenticate("notright") // false
+//	user.Unwrap().Authenticate("secret")   // true
+func (r *R) HasSecurePassword() {
+	const (
+		passwordName     = "password"
+		confirmationName = "password_confirmation"
+		digestName       = "password_digest"
+	)
+
+	if _, ok := r.attrs[digestName]; !ok {
+		r.DefineAttribute(digestName, Nil{new(String)})
+	}
+	if r.virtuals == nil {
+		r.virtuals = make(map[string]virtualAttribute)
+	}
+
+	r.virtuals[passwordName] = passwordAttr{
+		attr:       attr{Name: passwordName, Type: Nil{new(String)}},
+		digestName: digestName,
+	}
+	r.virtuals[confirmationName] = virtualAttr{
+		attr: attr{Name: confirmationName, Type: Nil{new(String)}},
+	}
+	r.Validates(passwordName, &passwordValidator{
+		digestName: digestName, confirmationName: confirmationName,
+	})
+}
+
+// Authenticate returns true, when the password matches the password digest of
+// the record, see R.HasSecurePassword. Records without the digest are never
+// authenticated.
+func (r *ActiveRecord) Authenticate(password string) bool {
+	digest, ok := r.Attribute("password_digest").(string)
+	if !ok || digest == "" {
+		return false
+	}
+	return bcrypt.CompareHashAndPassword([]byte(digest), []byte(password)) == nil
+}
@@ -0,0 +1,170 @@
+package activerecord
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+
+	. "github.com/activegraph/activegraph/activesupport"
+)
+
+// DefaultTokenLength is the length of tokens generated by HasSecureToken.
+const DefaultTokenLength = 24
+
+// tokenAlphabet is the Base58 alphabet of tokens, it does not contain characters
+// looking alike (0, O, I, l) and characters escaped in URLs.
+const tokenAlphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// ErrSecureToken is returned, when the unique token of the record cannot be
+// generated within the given number of attempts.
+type ErrSecureToken struct {
+	RecordName string
+	Attr       string
+	Attempts   int
+}
+
+func (e *ErrSecureToken) Is(target error) bool {
+	_, ok := target.(*ErrSecureToken)
+	return ok
+}
+
+func (e *ErrSecureToken) Error() string {
+	return fmt.Sprintf("failed to generate unique %s of %s in %d attempts",
+		e.Attr, e.RecordName, e.Attempts)
+}
+
+// GenerateSecureToken returns the random token of the given length composed of
+// Base58 characters, so the token is safe to use in URLs.
+func GenerateSecureToken(length int) (string, error) {
+	var (
+		token = make([]byte, 0, length)
+		buf   = make([]byte, length)
+	)
+	for len(token) < length {
+		if _, err := rand.Read(buf); err != nil {
+			return "", err
+		}
+		for _, b := range buf {
+			// Reject bytes above the largest multiple of the alphabet length,
+			// so all characters are equally likely.
+			if int(b) < 256-256%len(tokenAlphabet) && len(token) < length {
+				token = append(token, tokenAlphabet[int(b)%len(tokenAlphabet)])
+			}
+		}
+	}
+	return string(token), nil
+}
+
+// secureToken is the declaration of the token attribute.
+type secureToken struct {
+	rel      *Relation
+	attrName string
+	length   int
+	attempts int
+}
+
+type secureTokens map[string]*secureToken
+
+// SecureTokenOption configures tokens declared with HasSecureToken.
+type SecureTokenOption func(*secureToken)
+
+// TokenLength sets the length of generated tokens, by default tokens are
+// DefaultTokenLength characters long.
+func TokenLength(length int) SecureTokenOption {
+	return func(t *secureToken) {
+		t.length = length
+	}
+}
+
+// UniqueToken checks, that generated tokens are not used by other records of the
+// relation. Tokens are generated again on collision, up to the given number of
+// attempts, then ErrSecureToken is returned.
+func UniqueToken(attempts int) SecureTokenOption {
+	return func(t *secureToken) {
+		t.attempts = attempts
+	}
+}
+
+// generate returns a new token, unique tokens are checked against records of
+// the relation including the ones hidden by default scopes.
+func (t *secureToken) generate(ctx context.Context) (string, error) {
+	for attempt := 0; ; attempt++ {
+		token, err := GenerateSecureToken(t.length)
+		if err != nil {
+			return "", err
+		}
+		if t.attempts <= 0 {
+			return token, nil
+		}
+
+		empty, err := t.rel.WithContext(ctx).Unscoped().Where(t.attrName, token).isEmpty()
+		if err != nil {
+			return "", err
+		}
+		if empty {
+			return token, nil
+		}
+		if attempt+1 >= t.attempts {
+			return "", &ErrSecureToken{RecordName: t.rel.Name(), Attr: t.attrName, Attempts: t.attempts}
+		}
+	}
+}
+
+// HasSecureToken generates the random token of the attribute before the record
+// is created, unless the token is assigned explicitly. The attribute is defined
+// by the declaration, when the table does not have the column:
+//
+//	User := activerecord.New("user", func(r *activerecord.R) {
+//		r.HasSecureToken("api_key", activerecord.UniqueToken(3))
+//	})
+//
+//	user := User.Create(Hash{"name": "Ada"})
+//	user.Unwrap().Attribute("api_key") // "pX27zsMN2ViQKta1bGfLmVJE"
+//
+// Tokens are composed of Base58 characters, see GenerateSecureToken. Tokens are
+// not guaranteed to be unique, unless the table has the unique index of the
+// attribute or the UniqueToken option is given.
+func (r *R) HasSecureToken(attrName string, opts ...SecureTokenOption) {
+	token := &secureToken{rel: r.rel, attrName: attrName, length: DefaultTokenLength}
+	for _, opt := range opts {
+		opt(token)
+	}
+
+	if _, ok := r.attrs[attrName]; !ok {
+		r.DefineAttribute(attrName, Nil{new(String)})
+	}
+	if r.tokens == nil {
+		r.tokens = make(secureTokens)
+	}
+	r.tokens[attrName] = token
+
+	r.BeforeCreate(func(rec *ActiveRecord) error {
+		if rec.AttributePresent(attrName) {
+			return nil
+		}
+		value, err := token.generate(rec.Context())
+		if err != nil {
+			return err
+		}
+		return rec.AssignAttribute(attrName, value)
+	})
+}
+
+// RegenerateToken assigns a new token to the attribute declared with
+// HasSecureToken and updates the record.
+//
+//	user, err := user.RegenerateToken("api_key")
+func (r *ActiveRecord) RegenerateToken(attrName string) (*ActiveRecord, error) {
+	token, ok := r.tokens[attrName]
+	if !ok {
+		return nil, ErrArgument{Message: fmt.Sprintf("%q is not a secure token of %s", attrName, r.name)}
+	}
+	value, err := token.generate(r.Context())
+	if err != nil {
+		return nil, err
+	}
+	if err = r.AssignAttribute(attrName, value); err != nil {
+		return nil, err
+	}
+	return r.Update()
+}
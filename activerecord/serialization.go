@@ -1,6 +1,435 @@
 package activerecord
 
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	. "github.com/activegraph/activegraph/activesupport"
+)
+
 type Ownership interface {
 	Move(dst interface{}) error
 	Borrow(src interface{}) error
 }
+
+// serialization are options of the record serialization.
+type serialization struct {
+	only     []string
+	except   []string
+	methods  map[string]func(*ActiveRecord) interface{}
+	includes map[string][]SerializationOption
+}
+
+// SerializationOption configures attributes and associations of serialized
+// records.
+type SerializationOption func(*serialization)
+
+// Only serializes only given attributes of records.
+func Only(attrNames ...string) SerializationOption {
+	return func(s *serialization) {
+		s.only = append(s.only, attrNames...)
+	}
+}
+
+// Except serializes all attributes of records except given ones.
+func Except(attrNames ...string) SerializationOption {
+	return func(s *serialization) {
+		s.except = append(s.except, attrNames...)
+	}
+}
+
+// Method serializes the value computed by the function as the attribute of
+// records with the given name:
+//
+//	rec.ToJSON(activerecord.Method("initials", func(r *activerecord.ActiveRecord) interface{} {
+//		return initials(r.Attribute("name").(string))
+//	}))
+func Method(name string, fn func(*ActiveRecord) interface{}) SerializationOption {
+	return func(s *serialization) {
+		if s.methods == nil {
+			s.methods = make(map[string]func(*ActiveRecord) interface{})
+		}
+		s.methods[name] = fn
+	}
+}
+
+// Include serializes the association of records, singular associations are
+// serialized as nested objects, and collections as arrays of objects. Options
+// are applied to serialized records of the association:
+//
+//	author.ToJSON(activerecord.Include("books", activerecord.Only("title")))
+func Include(assocName string, opts ...SerializationOption) SerializationOption {
+	return func(s *serialization) {
+		if s.includes == nil {
+			s.includes = make(map[string][]SerializationOption)
+		}
+		s.includes[assocName] = opts
+	}
+}
+
+func newSerialization(opts []SerializationOption) *serialization {
+	var s serialization
+	for _, opt := range opts {
+		opt(&s)
+	}
+	return &s
+}
+
+// attributeNames returns names of serialized attributes of the record sorted
+// by names.
+func (s *serialization) attributeNames(r *ActiveRecord) []string {
+	names := s.only
+	if len(names) == 0 {
+		names = r.AttributeNames()
+	}
+
+	except := make(map[string]struct{}, len(s.except))
+	for _, name := range s.except {
+		except[name] = struct{}{}
+	}
+
+	attrNames := make([]string, 0, len(names))
+	for _, name := range names {
+		if _, ok := except[name]; !ok && r.HasAttribute(name) {
+			attrNames = append(attrNames, name)
+		}
+	}
+	sort.Strings(attrNames)
+	return attrNames
+}
+
+// includedNames returns names of included associations sorted by names.
+func (s *serialization) includedNames() []string {
+	names := make([]string, 0, len(s.includes))
+	for name := range s.includes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// attributeText returns the text representation of the attribute value of the
+// type, the type is nil for values computed by serialization methods.
+func attributeText(t Type, value interface{}) (string, error) {
+	if n, ok := t.(Nil); ok {
+		t = n.Type
+	}
+
+	switch value := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return value, nil
+	case []byte:
+		return string(value), nil
+	case time.Time:
+		if t == nil {
+			return value.Format(iso8601), nil
+		}
+		text, err := t.Serialize(value)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprint(text), nil
+	}
+
+	if _, ok := t.(*JSON); ok {
+		b, err := json.Marshal(value)
+		return string(b), err
+	}
+	return fmt.Sprint(value), nil
+}
+
+// serialize returns the hash of serialized attributes and associations of the
+// record.
+func (s *serialization) serialize(r *ActiveRecord) (Hash, error) {
+	h := make(Hash)
+	for _, attrName := range s.attributeNames(r) {
+		h[attrName] = r.Attribute(attrName)
+	}
+	for name, fn := range s.methods {
+		h[name] = fn(r)
+	}
+
+	for _, assocName := range s.includedNames() {
+		opts := s.includes[assocName]
+
+		assoc, err := r.associations.find(assocName)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := assoc.(CollectionAssociation); ok {
+			rel, err := r.AccessCollection(assocName)
+			if err != nil {
+				return nil, err
+			}
+			records, err := rel.ToA()
+			if err != nil {
+				return nil, err
+			}
+
+			values := make([]Hash, 0, len(records))
+			for _, rec := range records {
+				value, err := rec.AsJSON(opts...)
+				if err != nil {
+					return nil, err
+				}
+				values = append(values, value)
+			}
+			h[assocName] = values
+			continue
+		}
+
+		target, err := r.AccessAssociation(assocName)
+		if err != nil {
+			return nil, err
+		}
+		if target == nil {
+			h[assocName] = nil
+			continue
+		}
+		if h[assocName], err = target.AsJSON(opts...); err != nil {
+			return nil, err
+		}
+	}
+	return h, nil
+}
+
+// AsJSON returns the hash of the record attributes and included associations
+// serialized to JSON by ToJSON.
+func (r *ActiveRecord) AsJSON(opts ...SerializationOption) (Hash, error) {
+	return newSerialization(opts).serialize(r)
+}
+
+// ToJSON returns the JSON encoding of the record attributes, keys of objects are
+// sorted, so the encoding is stable:
+//
+//	author.ToJSON(activerecord.Only("id", "name"), activerecord.Include("books"))
+//	// {"books":[{"author_id":1,"id":1,"title":"Solaris"}],"id":1,"name":"Stanislaw Lem"}
+func (r *ActiveRecord) ToJSON(opts ...SerializationOption) ([]byte, error) {
+	h, err := r.AsJSON(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(h)
+}
+
+// MarshalJSON returns the JSON encoding of all attributes of the record.
+func (r *ActiveRecord) MarshalJSON() ([]byte, error) {
+	return r.ToJSON()
+}
+
+// AsJSON returns hashes of records of the relation serialized to JSON by ToJSON.
+func (rel *Relation) AsJSON(opts ...SerializationOption) ([]Hash, error) {
+	records, err := rel.ToA()
+	if err != nil {
+		return nil, err
+	}
+
+	hashes := make([]Hash, 0, len(records))
+	for _, rec := range records {
+		h, err := rec.AsJSON(opts...)
+		if err != nil {
+			return nil, err
+		}
+		hashes = append(hashes, h)
+	}
+	return hashes, nil
+}
+
+// ToJSON returns the JSON array of records of the relation, records are
+// serialized with options the same way as by ActiveRecord.ToJSON.
+func (rel *Relation) ToJSON(opts ...SerializationOption) ([]byte, error) {
+	hashes, err := rel.AsJSON(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(hashes)
+}
+
+// ErrUnpermittedAttributes is returned, when the JSON payload or the mass
+// assignment assigns attributes, which are not permitted.
+type ErrUnpermittedAttributes struct {
+	RecordName string
+	Attrs      []string
+}
+
+func (e *ErrUnpermittedAttributes) Is(target error) bool {
+	_, ok := target.(*ErrUnpermittedAttributes)
+	return ok
+}
+
+func (e *ErrUnpermittedAttributes) Error() string {
+	return fmt.Sprintf("unpermitted attributes for %s: %s", e.RecordName, strings.Join(e.Attrs, ", "))
+}
+
+// Permitted are names of attributes, which are allowed to be assigned from the
+// JSON payload or by the mass assignment.
+type Permitted []string
+
+// Permit returns names of attributes, which are allowed to be assigned from
+// the JSON payload by FromJSON or by AssignAttributes. Attributes of nested
+// payloads are permitted with dot-separated names, e.g. "author.name", while
+// the name of the nested payload alone permits all of its attributes.
+func Permit(attrNames ...string) Permitted {
+	return Permitted(attrNames)
+}
+
+// nested returns names of permitted attributes of the nested payload.
+func (p Permitted) nested(name string) Permitted {
+	var nested Permitted
+	for _, attrName := range p {
+		if strings.HasPrefix(attrName, name+".") {
+			nested = append(nested, strings.TrimPrefix(attrName, name+"."))
+		}
+	}
+	return nested
+}
+
+// unpermitted returns names of attributes, which are not permitted, including
+// attributes of nested payloads.
+func (p Permitted) unpermitted(params map[string]interface{}) []string {
+	permitted := make(map[string]struct{}, len(p))
+	for _, attrName := range p {
+		permitted[attrName] = struct{}{}
+	}
+
+	var unpermitted []string
+	for attrName, val := range params {
+		if _, ok := permitted[attrName]; ok {
+			continue
+		}
+		nested, isNested := nestedPayload(val)
+		if np := p.nested(attrName); isNested && len(np) > 0 {
+			for _, nestedName := range np.unpermitted(nested) {
+				unpermitted = append(unpermitted, attrName+"."+nestedName)
+			}
+			continue
+		}
+		unpermitted = append(unpermitted, attrName)
+	}
+	return unpermitted
+}
+
+// permitAttributes returns ErrUnpermittedAttributes, when parameters assign
+// attributes, which are not permitted. All attributes are permitted, when the
+// permitted attributes are not given.
+func permitAttributes(recordName string, params map[string]interface{}, permitted []Permitted) error {
+	switch len(permitted) {
+	case 0:
+		return nil
+	case 1:
+	default:
+		return &ErrMultipleVariadicArguments{Name: "permitted"}
+	}
+
+	unpermitted := permitted[0].unpermitted(params)
+	if len(unpermitted) > 0 {
+		sort.Strings(unpermitted)
+		return &ErrUnpermittedAttributes{RecordName: recordName, Attrs: unpermitted}
+	}
+	return nil
+}
+
+// nestedPayload returns attributes of the nested payload.
+func nestedPayload(val interface{}) (Hash, bool) {
+	switch val := val.(type) {
+	case Hash:
+		return val, true
+	case map[string]interface{}:
+		return Hash(val), true
+	}
+	return nil, false
+}
+
+// decode returns attributes of the JSON object. Keys of the object, which are
+// not permitted, are returned as ErrUnpermittedAttributes.
+func (p Permitted) decode(recordName string, attrs *attributes, body []byte) (Hash, error) {
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(body, &object); err != nil {
+		return nil, err
+	}
+	if object == nil {
+		return nil, fmt.Errorf("JSON payload of %s is not an object", recordName)
+	}
+
+	keys := make(map[string]interface{}, len(object))
+	for attrName, raw := range object {
+		keys[attrName] = raw
+	}
+	if err := permitAttributes(recordName, keys, []Permitted{p}); err != nil {
+		return nil, err
+	}
+
+	params := make(Hash, len(object))
+	for attrName, raw := range object {
+		attr := attrs.AttributeForInspect(attrName)
+		if attr == nil {
+			return nil, &ErrUnknownAttribute{RecordName: recordName, Attr: attrName}
+		}
+		val, err := decodeJSONValue(attr.AttributeType(), raw)
+		if err != nil {
+			return nil, fmt.Errorf("attribute %q of %s: %w", attrName, recordName, err)
+		}
+		params[attrName] = val
+	}
+	return params, nil
+}
+
+// decodeJSONValue decodes the JSON value into the value accepted by the type
+// of the attribute.
+func decodeJSONValue(t Type, raw json.RawMessage) (interface{}, error) {
+	if n, ok := t.(Nil); ok {
+		t = n.Type
+	}
+	if bytes.Equal(bytes.TrimSpace(raw), []byte("null")) {
+		return nil, nil
+	}
+	if _, ok := t.(*JSON); ok {
+		return string(raw), nil
+	}
+
+	var val interface{}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	if err := dec.Decode(&val); err != nil {
+		return nil, err
+	}
+
+	num, ok := val.(json.Number)
+	if !ok {
+		return val, nil
+	}
+	if _, ok := t.(*Int64); ok {
+		return num.Int64()
+	}
+	return num.Float64()
+}
+
+// FromJSON builds a new record of the relation from the JSON object. Only
+// permitted attributes are assigned, ErrUnpermittedAttributes is returned,
+// when the object contains other keys:
+//
+//	author := Author.FromJSON(body, activerecord.Permit("name", "email"))
+func (rel *Relation) FromJSON(body []byte, permitted Permitted) RecordResult {
+	params, err := permitted.decode(rel.name, rel.scope, body)
+	if err != nil {
+		return ErrRecord(err)
+	}
+	return ReturnRecord(rel.Initialize(params))
+}
+
+// FromJSON assigns permitted attributes of the record from the JSON object.
+// Either all attributes are assigned, or none in case of error.
+func (r *ActiveRecord) FromJSON(body []byte, permitted Permitted) error {
+	params, err := permitted.decode(r.name, r.attributes, body)
+	if err != nil {
+		return err
+	}
+	return r.AssignAttributes(params)
+}
@@ -0,0 +1,169 @@
+package activerecord_test
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/activegraph/activegraph/activerecord"
+	_ "github.com/activegraph/activegraph/activerecord/sqlite3"
+	. "github.com/activegraph/activegraph/activesupport"
+)
+
+func TestActiveRecord_ToJSON(t *testing.T) {
+	_, err := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter: "sqlite3", Database: t.Name() + ".db",
+	})
+	require.NoError(t, err)
+
+	defer os.Remove(t.Name() + ".db")
+	defer activerecord.RemoveConnection("primary")
+
+	initAuthorTable(t, nil)
+	initBookTable(t, nil)
+
+	Author := activerecord.New("author", func(r *activerecord.R) {
+		r.HasMany("books")
+	})
+	Book := activerecord.New("book", func(r *activerecord.R) {
+		r.BelongsTo("author")
+	})
+
+	author := Author.Create(Hash{"name": "Stanislaw Lem"}).Unwrap()
+	_, err = Book.InsertAll(
+		Hash{"title": "Solaris", "year": int64(1961), "author_id": author.ID()},
+		Hash{"title": "Eden", "year": int64(1959), "author_id": author.ID()},
+	)
+	require.NoError(t, err)
+
+	b, err := author.ToJSON()
+	require.NoError(t, err)
+	require.JSONEq(t, `{"id":1,"name":"Stanislaw Lem"}`, string(b))
+
+	b, err = author.ToJSON(
+		activerecord.Only("name"),
+		activerecord.Method("initials", func(r *activerecord.ActiveRecord) interface{} {
+			return "S.L."
+		}),
+		activerecord.Include("books", activerecord.Except("author_id", "year")),
+	)
+	require.NoError(t, err)
+	require.Equal(t,
+		`{"books":[{"id":1,"title":"Solaris"},{"id":2,"title":"Eden"}],"initials":"S.L.","name":"Stanislaw Lem"}`,
+		string(b))
+
+	b, err = Book.Order("year").ToJSON(
+		activerecord.Only("title"), activerecord.Include("author", activerecord.Only("name")),
+	)
+	require.NoError(t, err)
+	require.Equal(t,
+		`[{"author":{"name":"Stanislaw Lem"},"title":"Eden"},{"author":{"name":"Stanislaw Lem"},"title":"Solaris"}]`,
+		string(b))
+
+	_, err = author.ToJSON(activerecord.Include("publisher"))
+	require.Error(t, err)
+}
+
+func TestRelation_FromJSON(t *testing.T) {
+	_, err := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter: "sqlite3", Database: t.Name() + ".db",
+	})
+	require.NoError(t, err)
+
+	defer os.Remove(t.Name() + ".db")
+	defer activerecord.RemoveConnection("primary")
+
+	initAuthorTable(t, nil)
+	initBookTable(t, nil)
+
+	Book := activerecord.New("book")
+	permitted := activerecord.Permit("title", "year")
+
+	book := Book.FromJSON([]byte(`{"title":"Solaris","year":1961}`), permitted).Unwrap()
+	require.Equal(t, "Solaris", book.Attribute("title"))
+	require.Equal(t, int64(1961), book.Attribute("year"))
+
+	book, err = book.Insert()
+	require.NoError(t, err)
+	require.NoError(t, book.FromJSON([]byte(`{"year":1962}`), permitted))
+	require.Equal(t, int64(1962), book.Attribute("year"))
+
+	err = book.FromJSON([]byte(`{"year":1963,"author_id":2,"id":5}`), permitted)
+	require.True(t, errors.Is(err, new(activerecord.ErrUnpermittedAttributes)), err)
+	require.Equal(t, []string{"author_id", "id"}, err.(*activerecord.ErrUnpermittedAttributes).Attrs)
+	require.Equal(t, int64(1962), book.Attribute("year"))
+
+	err = Book.FromJSON([]byte(`["Solaris"]`), permitted).Err()
+	require.Error(t, err)
+}
+
+func TestActiveRecord_ToXML(t *testing.T) {
+	_, err := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter: "sqlite3", Database: t.Name() + ".db",
+	})
+	require.NoError(t, err)
+
+	defer os.Remove(t.Name() + ".db")
+	defer activerecord.RemoveConnection("primary")
+
+	initAuthorTable(t, nil)
+	initBookTable(t, nil)
+
+	Author := activerecord.New("author", func(r *activerecord.R) {
+		r.HasMany("books")
+	})
+	Book := activerecord.New("book", func(r *activerecord.R) {
+		r.BelongsTo("author")
+	})
+
+	author := Author.Create(Hash{"name": "Stanislaw Lem & Co"}).Unwrap()
+	_, err = Book.InsertAll(
+		Hash{"title": "Solaris", "year": int64(1961), "author_id": author.ID()},
+		Hash{"title": "Eden", "author_id": author.ID()},
+	)
+	require.NoError(t, err)
+
+	b, err := author.ToXML(
+		activerecord.Method("books_count", func(r *activerecord.ActiveRecord) interface{} {
+			return 2
+		}),
+		activerecord.Include("books", activerecord.Except("author_id")),
+	)
+	require.NoError(t, err)
+	require.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<author>
+  <id type="integer">1</id>
+  <name>Stanislaw Lem &amp; Co</name>
+  <books_count type="integer">2</books_count>
+  <books type="array">
+    <book>
+      <id type="integer">1</id>
+      <title>Solaris</title>
+      <year type="integer">1961</year>
+    </book>
+    <book>
+      <id type="integer">2</id>
+      <title>Eden</title>
+      <year type="integer" nil="true"></year>
+    </book>
+  </books>
+</author>
+`, string(b))
+
+	b, err = Book.Where("title", "Eden").ToXML(
+		activerecord.Only("title"), activerecord.Include("author", activerecord.Only("name")),
+	)
+	require.NoError(t, err)
+	require.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<books type="array">
+  <book>
+    <title>Eden</title>
+    <author>
+      <name>Stanislaw Lem &amp; Co</name>
+    </author>
+  </book>
+</books>
+`, string(b))
+}
@@ -0,0 +1,86 @@
+package activerecord
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultSessionDelay is the time after the last write, when queries of the
+	// session are executed by the primary database.
+	DefaultSessionDelay = 2 * time.Second
+)
+
+// Session remembers the time of the last write to the database, and switches the
+// connection of queries to the primary database for a delay after the write, so
+// the written data is read back even when replicas are lagging behind.
+//
+// Without an explicit role, queries of the context with a session are executed by
+// replicas of the database:
+//
+//	session := activerecord.NewSession(activerecord.DefaultSessionDelay)
+//	ctx = activerecord.WithSession(ctx, session)
+//
+//	// Executed by the primary database.
+//	Book.WithContext(ctx).Create(Hash{"title": "Solaris"})
+//	// Executed by the primary database within the delay after the write.
+//	Book.WithContext(ctx).Where("title", "Solaris").ToA()
+//
+// Session is safe for concurrent use. The time of the last write could be
+// persisted between requests of the user with LastWrite and SetLastWrite methods.
+type Session struct {
+	delay time.Duration
+
+	mu        sync.Mutex
+	lastWrite time.Time
+}
+
+// NewSession returns a new session with the given delay.
+func NewSession(delay time.Duration) *Session {
+	return &Session{delay: delay}
+}
+
+// Delay returns the time after the last write, when queries are executed with
+// the primary database.
+func (s *Session) Delay() time.Duration {
+	return s.delay
+}
+
+// LastWrite returns the time of the last write, zero time when the session has
+// not written to the database yet.
+func (s *Session) LastWrite() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastWrite
+}
+
+// SetLastWrite sets the time of the last write.
+func (s *Session) SetLastWrite(t time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastWrite = t
+}
+
+func (s *Session) recordWrite() {
+	if s != nil {
+		s.SetLastWrite(time.Now())
+	}
+}
+
+func (s *Session) recentlyWritten() bool {
+	lastWrite := s.LastWrite()
+	return !lastWrite.IsZero() && time.Since(lastWrite) < s.delay
+}
+
+type sessionKey struct{}
+
+// WithSession returns a copy of the context with the session.
+func WithSession(ctx context.Context, s *Session) context.Context {
+	return context.WithValue(ctx, sessionKey{}, s)
+}
+
+func sessionFromContext(ctx context.Context) *Session {
+	s, _ := ctx.Value(sessionKey{}).(*Session)
+	return s
+}
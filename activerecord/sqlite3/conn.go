@@ -4,10 +4,14 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"net/url"
+	"sort"
 	"strings"
 
 	"github.com/activegraph/activegraph/activerecord"
 	"github.com/activegraph/activegraph/activerecord/ansi"
+	"github.com/activegraph/activegraph/activerecord/migration"
+	"github.com/activegraph/activegraph/activesupport"
 	"github.com/mattn/go-sqlite3"
 )
 
@@ -22,18 +26,34 @@ type Conn struct {
 
 	db *sql.DB
 	tx *sql.Tx
+
+	// Nested transactions are implemented with savepoints, depth is used to
+	// name savepoints uniquely within a transaction.
+	savepoint string
+	depth     int
 }
 
 func Connect(conf activerecord.DatabaseConfig) (activerecord.Conn, error) {
-	db, err := sql.Open("sqlite3", conf.Database)
+	dsn := conf.Database
+	if len(conf.Options) > 0 {
+		query := url.Values{}
+		for name, value := range conf.Options {
+			query.Set(name, value)
+		}
+		dsn = "file:" + dsn + "?" + query.Encode()
+	}
+
+	db, err := sql.Open("sqlite3", dsn)
 	if err != nil {
 		return nil, err
 	}
+	ansi.ConfigurePool(db, conf)
+
 	conn := &Conn{
 		db:                   db,
 		ConnectionStatements: db,
-		SchemaStatements:     ansi.SchemaStatements{db},
-		DatabaseStatements:   ansi.DatabaseStatements{db},
+		SchemaStatements:     ansi.SchemaStatements{Conn: db},
+		DatabaseStatements:   ansi.DatabaseStatements{Conn: db},
 	}
 
 	// Enable foreign keys support.
@@ -44,27 +64,123 @@ func Connect(conf activerecord.DatabaseConfig) (activerecord.Conn, error) {
 	return conn, nil
 }
 
+// Capabilities returns capabilities of the SQLite database. Bundled SQLite does
+// not drop columns, does not alter constraints of tables and does not have
+// materialized views.
+func (c *Conn) Capabilities() activerecord.Capabilities {
+	return activerecord.Capabilities{
+		Joins:             true,
+		Subqueries:        true,
+		DropColumn:        false,
+		AlterConstraints:  false,
+		TransactionalDDL:  true,
+		MaterializedViews: false,
+	}
+}
+
+// QuoteIdentifier returns the identifier quoted with double quotes.
+func (c *Conn) QuoteIdentifier(name string) string {
+	return activerecord.DefaultDialect.QuoteIdentifier(name)
+}
+
+// NativeType returns the SQLite type of the column.
+func (c *Conn) NativeType(column activerecord.ColumnDefinition) string {
+	return activerecord.DefaultDialect.NativeType(column)
+}
+
+// Compile compiles operations of migrations, which statements differ in SQLite.
+// Indexes are not created concurrently. SQLite does not store comments and does
+// not have tablespaces, so they are omitted.
+func (c *Conn) Compile(op migration.Operation) ([]string, bool) {
+	switch op := op.(type) {
+	case *migration.CreateTable:
+		table := *op
+		table.Columns = uncommentedColumns(op.Columns)
+		table.Options.Comment, table.Options.Tablespace = "", ""
+		return table.Statements(c), true
+	case *migration.AddColumn:
+		column := *op
+		column.Column.Comment = ""
+		return column.Statements(c), true
+	case *migration.AddIndex:
+		if !op.Concurrently {
+			return nil, false
+		}
+		index := *op
+		index.Concurrently = false
+		return index.Statements(c), true
+	case *migration.RemoveIndex:
+		if !op.Concurrently {
+			return nil, false
+		}
+		index := *op
+		index.Concurrently = false
+		return index.Statements(c), true
+	default:
+		return nil, false
+	}
+}
+
+func uncommentedColumns(columns []activerecord.ColumnDefinition) []activerecord.ColumnDefinition {
+	uncommented := make([]activerecord.ColumnDefinition, len(columns))
+	for i, column := range columns {
+		column.Comment = ""
+		uncommented[i] = column
+	}
+	return uncommented
+}
+
+// Verify verifies the connection to the database with a ping.
+func (c *Conn) Verify(ctx context.Context) error {
+	return c.db.PingContext(ctx)
+}
+
 func (c *Conn) Close() error {
+	// Savepoints are released or rolled back on commit and rollback.
+	if c.savepoint != "" {
+		return nil
+	}
 	if c.tx != nil {
 		return c.tx.Commit()
 	}
 	return c.db.Close()
 }
 
-func (c *Conn) BeginTransaction(ctx context.Context) (activerecord.Conn, error) {
+// BeginTransaction begins a new transaction or creates a savepoint within the
+// open transaction. SQLite transactions are always serializable, so the isolation
+// level and the access mode are ignored.
+func (c *Conn) BeginTransaction(
+	ctx context.Context, opts *activerecord.TransactionOptions,
+) (activerecord.Conn, error) {
+	if c.tx != nil {
+		savepoint := fmt.Sprintf("active_record_%d", c.depth+1)
+		if _, err := ansi.ExecContext(ctx, c.tx, "SAVEPOINT "+savepoint); err != nil {
+			return nil, err
+		}
+		return &Conn{
+			db:                   c.db,
+			tx:                   c.tx,
+			savepoint:            savepoint,
+			depth:                c.depth + 1,
+			ConnectionStatements: c.tx,
+			SchemaStatements:     ansi.SchemaStatements{Conn: c.tx},
+			DatabaseStatements:   ansi.DatabaseStatements{Conn: c.tx},
+		}, nil
+	}
+
+	done := activerecord.LogQuery(ctx, "BEGIN TRANSACTION", nil)
 	tx, err := c.db.BeginTx(ctx, nil)
+	done(0, err)
 	if err != nil {
 		return nil, err
 	}
 
-	fmt.Println("BEGIN TRANSACTION")
-
 	return &Conn{
 		db:                   c.db,
 		tx:                   tx,
 		ConnectionStatements: tx,
-		SchemaStatements:     ansi.SchemaStatements{tx},
-		DatabaseStatements:   ansi.DatabaseStatements{tx},
+		SchemaStatements:     ansi.SchemaStatements{Conn: tx},
+		DatabaseStatements:   ansi.DatabaseStatements{Conn: tx},
 	}, nil
 }
 
@@ -72,16 +188,28 @@ func (c *Conn) CommitTransaction(ctx context.Context) error {
 	if c.tx == nil {
 		return fmt.Errorf("no transaction is open")
 	}
-	fmt.Println("COMMIT TRANSACTION")
-	return c.tx.Commit()
+	if c.savepoint != "" {
+		_, err := ansi.ExecContext(ctx, c.tx, "RELEASE SAVEPOINT "+c.savepoint)
+		return err
+	}
+	done := activerecord.LogQuery(ctx, "COMMIT TRANSACTION", nil)
+	err := c.tx.Commit()
+	done(0, err)
+	return err
 }
 
 func (c *Conn) RollbackTransaction(ctx context.Context) error {
 	if c.tx == nil {
 		return fmt.Errorf("no transaction is open")
 	}
-	fmt.Println("ROLLBACK TRANSACTION")
-	return c.tx.Rollback()
+	if c.savepoint != "" {
+		_, err := ansi.ExecContext(ctx, c.tx, "ROLLBACK TO SAVEPOINT "+c.savepoint)
+		return err
+	}
+	done := activerecord.LogQuery(ctx, "ROLLBACK TRANSACTION", nil)
+	err := c.tx.Rollback()
+	done(0, err)
+	return err
 }
 
 func (c *Conn) ExecInsert(ctx context.Context, op *activerecord.InsertOperation) (
@@ -105,6 +233,68 @@ func (c *Conn) ExecInsert(ctx context.Context, op *activerecord.InsertOperation)
 	return id, err
 }
 
+// ExecExplain returns the query plan using "EXPLAIN QUERY PLAN" statement. SQLite
+// does not support explain options, therefore all of them are ignored.
+func (c *Conn) ExecExplain(ctx context.Context, op *activerecord.ExplainOperation) (
+	plan string, err error,
+) {
+	stmt := "EXPLAIN QUERY PLAN " + op.Query.Text
+	rws, err := c.ConnectionStatements.QueryContext(ctx, stmt, op.Query.Args...)
+	if err != nil {
+		return "", err
+	}
+
+	defer rws.Close()
+
+	var (
+		lines  []string
+		depths = make(map[int]int)
+	)
+	for rws.Next() {
+		var (
+			id, parent, notused int
+			detail              string
+		)
+		if err = rws.Scan(&id, &parent, &notused, &detail); err != nil {
+			return "", err
+		}
+
+		// Nested steps of the plan are rendered with indentation.
+		depth := 0
+		if d, ok := depths[parent]; ok {
+			depth = d + 1
+		}
+		depths[id] = depth
+		lines = append(lines, strings.Repeat("  ", depth)+detail)
+	}
+	if err = rws.Err(); err != nil {
+		return "", err
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// TableNames returns names of tables of the database sorted by names.
+func (c *Conn) TableNames(ctx context.Context) ([]string, error) {
+	const stmt = `SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name`
+
+	rws, err := c.ConnectionStatements.QueryContext(ctx, stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rws.Close()
+
+	var names []string
+	for rws.Next() {
+		var name string
+		if err := rws.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rws.Err()
+}
+
 func (c *Conn) ColumnDefinitions(ctx context.Context, tableName string) (
 	[]activerecord.ColumnDefinition, error,
 ) {
@@ -134,12 +324,19 @@ func (c *Conn) ColumnDefinitions(ctx context.Context, tableName string) (
 			return nil, err
 		}
 
-		definitions = append(definitions, activerecord.ColumnDefinition{
+		definition := activerecord.ColumnDefinition{
 			Name:         fname,
 			Type:         columnType,
 			NotNull:      notnull == 1,
 			IsPrimaryKey: pk == 1,
-		})
+		}
+		switch defaultValue := defaultValue.(type) {
+		case string:
+			definition.Default = defaultValue
+		case []byte:
+			definition.Default = string(defaultValue)
+		}
+		definitions = append(definitions, definition)
 	}
 	if len(definitions) == 0 {
 		return nil, activerecord.ErrTableNotExist{TableName: tableName}
@@ -147,6 +344,299 @@ func (c *Conn) ColumnDefinitions(ctx context.Context, tableName string) (
 	return definitions, nil
 }
 
+// IndexDefinitions returns indexes of the table, except the primary key.
+func (c *Conn) IndexDefinitions(ctx context.Context, tableName string) (
+	[]activerecord.IndexDefinition, error,
+) {
+	stmt := fmt.Sprintf("PRAGMA index_list('%s')", tableName)
+	rws, err := c.ConnectionStatements.QueryContext(ctx, stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	var definitions []activerecord.IndexDefinition
+	for rws.Next() {
+		var (
+			seq, unique, partial int
+			name, origin         string
+		)
+		if err := rws.Scan(&seq, &name, &unique, &origin, &partial); err != nil {
+			rws.Close()
+			return nil, err
+		}
+		if origin != "pk" {
+			definitions = append(definitions, activerecord.IndexDefinition{
+				Name: name, Table: tableName, Unique: unique == 1,
+			})
+		}
+	}
+	rws.Close()
+	if err = rws.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range definitions {
+		if err := c.indexColumns(ctx, &definitions[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Slice(definitions, func(i, j int) bool {
+		return definitions[i].Name < definitions[j].Name
+	})
+	return definitions, nil
+}
+
+// ForeignKeyDefinitions returns foreign keys of the table. SQLite does not keep
+// names of constraints, therefore names of foreign keys are empty.
+func (c *Conn) ForeignKeyDefinitions(ctx context.Context, tableName string) (
+	[]activerecord.ForeignKeyDefinition, error,
+) {
+	stmt := fmt.Sprintf("PRAGMA foreign_key_list('%s')", tableName)
+	rws, err := c.ConnectionStatements.QueryContext(ctx, stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rws.Close()
+
+	var definitions []activerecord.ForeignKeyDefinition
+	for rws.Next() {
+		var (
+			id, seq                          int
+			target, from, onUpdate, onDelete string
+			to                               sql.NullString
+			match                            string
+		)
+		err := rws.Scan(&id, &seq, &target, &from, &to, &onUpdate, &onDelete, &match)
+		if err != nil {
+			return nil, err
+		}
+
+		// Primary key of the target is not defined, when it is omitted in
+		// the "REFERENCES" clause.
+		primaryKey := "id"
+		if to.Valid && to.String != "" {
+			primaryKey = to.String
+		}
+		definitions = append(definitions, activerecord.ForeignKeyDefinition{
+			Table:      tableName,
+			Column:     from,
+			Target:     target,
+			PrimaryKey: primaryKey,
+			OnDelete:   referentialAction(onDelete),
+			OnUpdate:   referentialAction(onUpdate),
+		})
+	}
+	return definitions, rws.Err()
+}
+
+// referentialAction returns the empty string for the default action.
+func referentialAction(action string) string {
+	if action == "NO ACTION" {
+		return ""
+	}
+	return action
+}
+
+// CheckConstraintDefinitions returns check constraints of the table parsed from
+// the "CREATE TABLE" statement. Names of unnamed constraints are empty.
+func (c *Conn) CheckConstraintDefinitions(ctx context.Context, tableName string) (
+	[]activerecord.CheckConstraintDefinition, error,
+) {
+	rws, err := c.ConnectionStatements.QueryContext(ctx,
+		`SELECT sql FROM sqlite_master WHERE type = 'table' AND name = ?`, tableName,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rws.Close()
+
+	var definitions []activerecord.CheckConstraintDefinition
+	for rws.Next() {
+		var def string
+		if err := rws.Scan(&def); err != nil {
+			return nil, err
+		}
+		for _, check := range parseCheckConstraints(def) {
+			check.Table = tableName
+			definitions = append(definitions, check)
+		}
+	}
+	return definitions, rws.Err()
+}
+
+// parseCheckConstraints returns "CHECK" constraints of the "CREATE TABLE"
+// statement together with names of "CONSTRAINT <name> CHECK" constraints.
+func parseCheckConstraints(def string) (checks []activerecord.CheckConstraintDefinition) {
+	var (
+		quote byte
+		words []string
+		word  strings.Builder
+	)
+	flush := func() {
+		if word.Len() > 0 {
+			words = append(words, word.String())
+			word.Reset()
+		}
+	}
+
+	for i := 0; i < len(def); i++ {
+		ch := def[i]
+		switch {
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			}
+			word.WriteByte(ch)
+			continue
+		case ch == '"' || ch == '\'' || ch == '`':
+			quote = ch
+			word.WriteByte(ch)
+			continue
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r' || ch == ',':
+			flush()
+			continue
+		case ch != '(':
+			word.WriteByte(ch)
+			continue
+		}
+
+		flush()
+		n := len(words)
+		if n == 0 || !strings.EqualFold(words[n-1], "CHECK") {
+			continue
+		}
+
+		// Find the closing parenthesis of the check expression.
+		begin, level := i+1, 1
+		for i++; i < len(def) && level > 0; i++ {
+			switch ch := def[i]; {
+			case quote != 0:
+				if ch == quote {
+					quote = 0
+				}
+			case ch == '"' || ch == '\'' || ch == '`':
+				quote = ch
+			case ch == '(':
+				level++
+			case ch == ')':
+				level--
+			}
+		}
+		i--
+
+		check := activerecord.CheckConstraintDefinition{
+			Expression: strings.TrimSpace(def[begin:i]),
+		}
+		if n >= 3 && strings.EqualFold(words[n-3], "CONSTRAINT") {
+			check.Name = strings.Trim(words[n-2], "\"`'")
+		}
+		checks = append(checks, check)
+		words = words[:0]
+	}
+	return checks
+}
+
+// indexColumns loads columns and the condition of the index. Definitions of
+// indexes created by statements are parsed, since the index information does
+// not include expressions of indexes.
+func (c *Conn) indexColumns(ctx context.Context, index *activerecord.IndexDefinition) error {
+	var def sql.NullString
+	rws, err := c.ConnectionStatements.QueryContext(ctx,
+		`SELECT sql FROM sqlite_master WHERE type = 'index' AND name = ?`, index.Name,
+	)
+	if err != nil {
+		return err
+	}
+	for rws.Next() {
+		if err := rws.Scan(&def); err != nil {
+			rws.Close()
+			return err
+		}
+	}
+	rws.Close()
+	if err = rws.Err(); err != nil {
+		return err
+	}
+	if def.Valid {
+		index.Columns, index.Where = parseIndexDef(def.String)
+		return nil
+	}
+
+	// Indexes of unique constraints are created without statements.
+	rws, err = c.ConnectionStatements.QueryContext(ctx,
+		fmt.Sprintf("PRAGMA index_info('%s')", index.Name),
+	)
+	if err != nil {
+		return err
+	}
+
+	defer rws.Close()
+
+	for rws.Next() {
+		var (
+			seqno, cid int
+			name       string
+		)
+		if err := rws.Scan(&seqno, &cid, &name); err != nil {
+			return err
+		}
+		index.Columns = append(index.Columns, name)
+	}
+	return rws.Err()
+}
+
+// parseIndexDef returns columns and the condition of the "CREATE INDEX" statement.
+func parseIndexDef(def string) (columns []string, where string) {
+	start := strings.IndexByte(def, '(')
+	if start < 0 {
+		return nil, ""
+	}
+
+	var (
+		depth int
+		quote byte
+		begin = start + 1
+	)
+	for i := start; i < len(def); i++ {
+		ch := def[i]
+		switch {
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			}
+		case ch == '"' || ch == '\'' || ch == '`':
+			quote = ch
+		case ch == '(':
+			depth++
+		case ch == ')' || ch == ',' && depth == 1:
+			if ch == ')' {
+				depth--
+				if depth > 0 {
+					continue
+				}
+			}
+			column := strings.TrimSpace(def[begin:i])
+			if strings.HasPrefix(column, "(") && strings.HasSuffix(column, ")") {
+				column = column[1 : len(column)-1]
+			}
+			columns = append(columns, strings.Trim(column, "\"`"))
+			begin = i + 1
+
+			if depth == 0 {
+				rest := strings.TrimSpace(def[i+1:])
+				if len(rest) > 5 && strings.EqualFold(rest[:5], "WHERE") {
+					where = strings.TrimSpace(rest[5:])
+				}
+				return columns, where
+			}
+		}
+	}
+	return columns, where
+}
+
 func (c *Conn) AddForeignKey(ctx context.Context, owner, target string) error {
 	// SQLite does not support adding a foreign key constraint, which
 	// is implemented in ANSI schema statements, therefore we need to
@@ -184,7 +674,7 @@ func (c *Conn) AddForeignKey(ctx context.Context, owner, target string) error {
 	}
 
 	// TODO: Add all foreign keys as well.
-	fk := fmt.Sprintf("%s_id", strings.TrimSuffix(target, "s"))
+	fk := activesupport.ForeignKey(target)
 
 	fmt.Fprintf(&buf, `FOREIGN KEY (%q) REFERENCES "%s" ("id"), `, fk, target)
 	fmt.Fprintf(&buf, `PRIMARY KEY (%q))`, primaryKey)
@@ -0,0 +1,127 @@
+package activerecord
+
+import (
+	"context"
+	"fmt"
+)
+
+// ErrTenant is returned, when the record of the tenant relation is created
+// without the tenant or for another tenant.
+type ErrTenant struct {
+	RecordName string
+	Message    string
+}
+
+func (e *ErrTenant) Is(target error) bool {
+	_, ok := target.(*ErrTenant)
+	return ok
+}
+
+func (e *ErrTenant) Error() string {
+	return fmt.Sprintf("tenant of %s: %s", e.RecordName, e.Message)
+}
+
+type tenantKey struct{}
+
+// tenant is the tenant of the context, cross-tenant contexts do not have the
+// tenant identifier.
+type tenant struct {
+	id    interface{}
+	cross bool
+}
+
+// WithTenant returns the context, which scopes relations declared with
+// BelongsToTenant to records of the tenant with the primary key:
+//
+//	ctx = activerecord.WithTenant(ctx, account.ID())
+//	projects, err := Project.WithContext(ctx).ToA()
+//	// SELECT * FROM "projects" WHERE (account_id = ?)
+func WithTenant(ctx context.Context, tenantID interface{}) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant{id: tenantID})
+}
+
+// WithoutTenant returns the context, which removes scopes of tenants from
+// relations, e.g. for queries of administrators across all tenants.
+func WithoutTenant(ctx context.Context) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant{cross: true})
+}
+
+func tenantFromContext(ctx context.Context) (tenant, bool) {
+	t, ok := ctx.Value(tenantKey{}).(tenant)
+	return t, ok
+}
+
+// BelongsToTenant declares the belongs-to association with the tenant, so
+// queries of the relation are scoped to the tenant of the context (see
+// WithTenant), and the foreign key of the tenant is assigned to new records:
+//
+//	Project := activerecord.New("project", func(r *activerecord.R) {
+//		r.BelongsToTenant("account")
+//	})
+//
+// Without the tenant in the context, queries do not match any records and
+// records are not created, updated or deleted, use WithoutTenant for
+// cross-tenant operations. Records of another tenant are not updated or deleted,
+// and records are not moved to another tenant by updates of the foreign key.
+// The scope of the tenant is a default scope, so Unscoped removes it as well.
+func (r *R) BelongsToTenant(name string, init ...func(*BelongsTo)) {
+	r.BelongsTo(name, init...)
+	assoc := r.assocs[name]
+
+	r.DefaultScope(func(rel *Relation) *Relation {
+		t, ok := tenantFromContext(rel.Context())
+		switch {
+		case !ok:
+			return rel.Where(assoc.AssociationForeignKey(), In())
+		case t.cross:
+			return rel
+		default:
+			return rel.Where(assoc.AssociationForeignKey(), t.id)
+		}
+	})
+
+	r.BeforeCreate(func(rec *ActiveRecord) error {
+		t, ok := tenantFromContext(rec.Context())
+		switch {
+		case !ok:
+			return &ErrTenant{RecordName: rec.Name(), Message: "tenant is not specified"}
+		case t.cross:
+			return nil
+		}
+
+		fk := assoc.AssociationForeignKey()
+		if id := rec.Attribute(fk); id != nil && fmt.Sprint(id) != fmt.Sprint(t.id) {
+			return &ErrTenant{
+				RecordName: rec.Name(), Message: fmt.Sprintf("%s %v belongs to another tenant", fk, id),
+			}
+		}
+		return rec.AssignAttribute(fk, t.id)
+	})
+	// Records are updated and deleted by primary keys, so the foreign key of
+	// the record, both stored and assigned, is checked against the tenant.
+	guard := func(rec *ActiveRecord) error {
+		t, ok := tenantFromContext(rec.Context())
+		switch {
+		case !ok:
+			return &ErrTenant{RecordName: rec.Name(), Message: "tenant is not specified"}
+		case t.cross:
+			return nil
+		}
+
+		fk := assoc.AssociationForeignKey()
+		ids := []interface{}{rec.Attribute(fk)}
+		if id, ok := rec.attributes.original[fk]; ok {
+			ids = append(ids, id)
+		}
+		for _, id := range ids {
+			if fmt.Sprint(id) != fmt.Sprint(t.id) {
+				return &ErrTenant{
+					RecordName: rec.Name(), Message: fmt.Sprintf("%s %v belongs to another tenant", fk, id),
+				}
+			}
+		}
+		return nil
+	}
+	r.BeforeUpdate(guard)
+	r.BeforeDestroy(guard)
+}
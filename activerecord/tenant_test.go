@@ -0,0 +1,154 @@
+package activerecord_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/activegraph/activegraph/activerecord"
+	_ "github.com/activegraph/activegraph/activerecord/sqlite3"
+	. "github.com/activegraph/activegraph/activesupport"
+)
+
+func TestR_BelongsToTenant(t *testing.T) {
+	_, err := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter: "sqlite3", Database: t.Name() + ".db",
+	})
+	require.NoError(t, err)
+
+	defer os.Remove(t.Name() + ".db")
+	defer activerecord.RemoveConnection("primary")
+
+	activerecord.Migrate(t.Name()+"_add_accounts_and_projects_tables", func(m *activerecord.M) {
+		m.CreateTable("accounts", func(t *activerecord.Table) {
+			t.String("name")
+		})
+		m.CreateTable("projects", func(t *activerecord.Table) {
+			t.String("name")
+			t.References("accounts")
+		})
+	})
+
+	Account := activerecord.New("account")
+	Project := activerecord.New("project", func(r *activerecord.R) {
+		r.BelongsToTenant("account")
+	})
+
+	acme := Account.Create(Hash{"name": "Acme"}).Unwrap()
+	globex := Account.Create(Hash{"name": "Globex"}).Unwrap()
+
+	err = Project.Create(Hash{"name": "Rocket"}).Err()
+	require.ErrorIs(t, err, &activerecord.ErrTenant{})
+
+	acmeCtx := activerecord.WithTenant(context.Background(), acme.ID())
+	globexCtx := activerecord.WithTenant(context.Background(), globex.ID())
+
+	rocket := Project.WithContext(acmeCtx).Create(Hash{"name": "Rocket"})
+	require.NoError(t, rocket.Err())
+	require.Equal(t, acme.ID(), rocket.Unwrap().Attribute("account_id"))
+
+	err = Project.WithContext(acmeCtx).Create(Hash{"name": "Magnet", "account_id": globex.ID()}).Err()
+	require.ErrorIs(t, err, &activerecord.ErrTenant{})
+	require.NoError(t, Project.WithContext(globexCtx).Create(Hash{"name": "Magnet"}).Err())
+
+	projects, err := Project.WithContext(acmeCtx).ToA()
+	require.NoError(t, err)
+	require.Len(t, projects, 1)
+	require.Equal(t, "Rocket", projects[0].Attribute("name"))
+
+	err = Project.WithContext(globexCtx).Find(rocket.Unwrap().ID()).Err()
+	require.ErrorIs(t, err, &activerecord.ErrRecordNotFound{})
+
+	// Without the tenant, queries do not match any records.
+	projects, err = Project.ToA()
+	require.NoError(t, err)
+	require.Empty(t, projects)
+
+	projects, err = Project.WithContext(activerecord.WithoutTenant(context.Background())).ToA()
+	require.NoError(t, err)
+	require.Len(t, projects, 2)
+}
+
+func TestR_BelongsToTenant_UpdateDelete(t *testing.T) {
+	_, err := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter: "sqlite3", Database: t.Name() + ".db",
+	})
+	require.NoError(t, err)
+
+	defer os.Remove(t.Name() + ".db")
+	defer activerecord.RemoveConnection("primary")
+
+	activerecord.Migrate(t.Name()+"_add_accounts_and_projects_tables", func(m *activerecord.M) {
+		m.CreateTable("accounts", func(t *activerecord.Table) {
+			t.String("name")
+		})
+		m.CreateTable("projects", func(t *activerecord.Table) {
+			t.String("name")
+			t.References("accounts")
+		})
+	})
+
+	Account := activerecord.New("account")
+	Project := activerecord.New("project", func(r *activerecord.R) {
+		r.BelongsToTenant("account")
+	})
+
+	acme := Account.Create(Hash{"name": "Acme"}).Unwrap()
+	globex := Account.Create(Hash{"name": "Globex"}).Unwrap()
+
+	acmeCtx := activerecord.WithTenant(context.Background(), acme.ID())
+	globexCtx := activerecord.WithTenant(context.Background(), globex.ID())
+	withoutCtx := activerecord.WithoutTenant(context.Background())
+
+	rocket := Project.WithContext(acmeCtx).Create(Hash{"name": "Rocket"}).Unwrap()
+
+	// Records are updated and deleted by primary keys, so records of another
+	// tenant are rejected before the query.
+	_, err = rocket.WithContext(globexCtx).Update()
+	require.ErrorIs(t, err, &activerecord.ErrTenant{})
+
+	_, err = rocket.WithContext(globexCtx).Delete()
+	require.ErrorIs(t, err, &activerecord.ErrTenant{})
+
+	_, err = rocket.WithContext(context.Background()).Update()
+	require.ErrorIs(t, err, &activerecord.ErrTenant{})
+
+	_, err = rocket.WithContext(context.Background()).Delete()
+	require.ErrorIs(t, err, &activerecord.ErrTenant{})
+
+	// Records are not moved to another tenant.
+	moved := Project.WithContext(acmeCtx).Find(rocket.ID()).Unwrap()
+	require.NoError(t, moved.AssignAttributes(Hash{"account_id": globex.ID()}))
+	_, err = moved.Update()
+	require.ErrorIs(t, err, &activerecord.ErrTenant{})
+
+	rocket = Project.WithContext(withoutCtx).Find(rocket.ID()).Unwrap()
+	require.Equal(t, acme.ID(), rocket.Attribute("account_id"))
+	require.Equal(t, "Rocket", rocket.Attribute("name"))
+
+	// Records of the tenant are updated and deleted.
+	rocket = Project.WithContext(acmeCtx).Find(rocket.ID()).Unwrap()
+	require.NoError(t, rocket.AssignAttributes(Hash{"name": "Spaceship"}))
+	_, err = rocket.Update()
+	require.NoError(t, err)
+
+	found := Project.WithContext(acmeCtx).Find(rocket.ID()).Unwrap()
+	require.Equal(t, "Spaceship", found.Attribute("name"))
+
+	_, err = rocket.Delete()
+	require.NoError(t, err)
+
+	// Cross-tenant operations update and delete records of any tenant.
+	magnet := Project.WithContext(globexCtx).Create(Hash{"name": "Magnet"}).Unwrap()
+	require.NoError(t, magnet.AssignAttributes(Hash{"name": "Lodestone"}))
+	_, err = magnet.WithContext(withoutCtx).Update()
+	require.NoError(t, err)
+	_, err = magnet.WithContext(withoutCtx).Delete()
+	require.NoError(t, err)
+
+	count, err := Project.WithContext(withoutCtx).Count()
+	require.NoError(t, err)
+	require.EqualValues(t, 0, count)
+}
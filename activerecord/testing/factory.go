@@ -0,0 +1,279 @@
+// Package testing implements factories of records for tests. Factories define
+// valid attributes of records once, so tests only specify attributes relevant
+// to them:
+//
+//	artesting.DefineFactory("author", activesupport.Hash{
+//		"name": artesting.Sequence(func(n int) interface{} {
+//			return fmt.Sprintf("Author %d", n)
+//		}),
+//	})
+//	artesting.DefineFactory("book", activesupport.Hash{
+//		"title":  "Solaris",
+//		"author": artesting.Association("author"),
+//	}, artesting.Trait{Name: "classic", Attrs: activesupport.Hash{"year": 1961}})
+//
+//	book := artesting.Create("book", artesting.Override{"title": "Eden"}).Unwrap()
+//	book = artesting.Create("book", artesting.WithTraits("classic")).Unwrap()
+//
+// Records are built by relations declared with the names of factories, so the
+// relations must be declared before records are built.
+package testing
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/activegraph/activegraph/activerecord"
+	"github.com/activegraph/activegraph/activesupport"
+)
+
+// ErrUnknownFactory is returned, when the factory is not defined.
+type ErrUnknownFactory struct {
+	Name string
+}
+
+func (e *ErrUnknownFactory) Is(target error) bool {
+	_, ok := target.(*ErrUnknownFactory)
+	return ok
+}
+
+func (e *ErrUnknownFactory) Error() string {
+	return fmt.Sprintf("unknown factory %q", e.Name)
+}
+
+// ErrUnknownTrait is returned, when the trait is not defined by the factory.
+type ErrUnknownTrait struct {
+	Factory string
+	Trait   string
+}
+
+func (e *ErrUnknownTrait) Is(target error) bool {
+	_, ok := target.(*ErrUnknownTrait)
+	return ok
+}
+
+func (e *ErrUnknownTrait) Error() string {
+	return fmt.Sprintf("unknown trait %q of factory %q", e.Trait, e.Factory)
+}
+
+// Sequence is the attribute value computed from the sequence number of the
+// built record, so the records have unique values, e.g. emails. Sequence numbers
+// start from 1 for each factory.
+type Sequence func(n int) interface{}
+
+// association is the attribute value built by the factory of the association.
+type association struct {
+	factory string
+	opts    []Option
+}
+
+// Association creates the target of the singular association with the factory,
+// when the record is built. Targets given explicitly by overrides are not built.
+func Association(factory string, opts ...Option) interface{} {
+	return association{factory: factory, opts: opts}
+}
+
+// Trait is the named set of attributes, which are applied on top of factory
+// attributes with WithTraits.
+type Trait struct {
+	Name  string
+	Attrs activesupport.Hash
+}
+
+type factory struct {
+	name   string
+	attrs  activesupport.Hash
+	traits map[string]activesupport.Hash
+
+	mu  sync.Mutex
+	seq int
+}
+
+func (f *factory) next() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.seq++
+	return f.seq
+}
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = make(map[string]*factory)
+)
+
+// DefineFactory defines the factory of records of the relation with the name,
+// the factory with the same name is replaced. Attribute values could be
+// sequences (see Sequence) and associations (see Association).
+func DefineFactory(name string, attrs activesupport.Hash, traits ...Trait) {
+	f := &factory{name: name, attrs: attrs, traits: make(map[string]activesupport.Hash, len(traits))}
+	for _, trait := range traits {
+		f.traits[trait.Name] = trait.Attrs
+	}
+
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+	factories[name] = f
+}
+
+// build is the state of the built record.
+type build struct {
+	ctx    context.Context
+	traits []string
+	attrs  activesupport.Hash
+}
+
+// Option configures the built record.
+type Option interface {
+	apply(*build)
+}
+
+// Override replaces attributes of the factory and traits. Associations are
+// overridden by records.
+type Override activesupport.Hash
+
+func (o Override) apply(b *build) {
+	for k, v := range o {
+		b.attrs[k] = v
+	}
+}
+
+type optionFunc func(*build)
+
+func (fn optionFunc) apply(b *build) { fn(b) }
+
+// WithTraits applies attributes of traits of the factory, traits are applied in
+// the given order.
+func WithTraits(names ...string) Option {
+	return optionFunc(func(b *build) {
+		b.traits = append(b.traits, names...)
+	})
+}
+
+// WithContext builds records with the context, e.g. the transaction of the test.
+func WithContext(ctx context.Context) Option {
+	return optionFunc(func(b *build) {
+		b.ctx = ctx
+	})
+}
+
+// Build returns the record built by the factory without saving it. Targets of
+// belongs-to associations are created and referenced by foreign keys of the
+// record, other associations are assigned only by Create.
+func Build(name string, opts ...Option) activerecord.RecordResult {
+	rec, _, err := buildRecord(name, opts...)
+	return activerecord.ReturnRecord(rec, err)
+}
+
+// Create returns the record built by the factory and inserted into the database,
+// associations (other than belongs-to) are assigned after the insert.
+func Create(name string, opts ...Option) activerecord.RecordResult {
+	rec, assocs, err := buildRecord(name, opts...)
+	result := activerecord.ReturnRecord(rec, err).Insert()
+
+	// Associations are assigned in the order of names, so failures of
+	// associations are reported consistently.
+	names := make([]string, 0, len(assocs))
+	for assocName := range assocs {
+		names = append(names, assocName)
+	}
+	sort.Strings(names)
+	for _, assocName := range names {
+		result = result.AssignAssociation(assocName, activerecord.OkRecord(assocs[assocName]))
+	}
+	return result
+}
+
+// CreateList returns n records created by the factory with the same options.
+func CreateList(name string, n int, opts ...Option) ([]*activerecord.ActiveRecord, error) {
+	records := make([]*activerecord.ActiveRecord, 0, n)
+	for i := 0; i < n; i++ {
+		rec := Create(name, opts...)
+		if err := rec.Err(); err != nil {
+			return nil, err
+		}
+		records = append(records, rec.Unwrap())
+	}
+	return records, nil
+}
+
+// buildRecord returns the record built by the factory and targets of associations,
+// which are assigned after the record is inserted.
+func buildRecord(name string, opts ...Option) (
+	*activerecord.ActiveRecord, map[string]*activerecord.ActiveRecord, error,
+) {
+	factoriesMu.RLock()
+	f, ok := factories[name]
+	factoriesMu.RUnlock()
+	if !ok {
+		return nil, nil, &ErrUnknownFactory{Name: name}
+	}
+
+	rel, err := activerecord.ReflectOnRelation(f.name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	overrides := build{ctx: context.Background(), attrs: make(activesupport.Hash)}
+	for _, opt := range opts {
+		opt.apply(&overrides)
+	}
+
+	attrs := f.attrs.Copy()
+	for _, traitName := range overrides.traits {
+		trait, ok := f.traits[traitName]
+		if !ok {
+			return nil, nil, &ErrUnknownTrait{Factory: f.name, Trait: traitName}
+		}
+		for k, v := range trait {
+			attrs[k] = v
+		}
+	}
+	for k, v := range overrides.attrs {
+		attrs[k] = v
+	}
+
+	var (
+		n      = f.next()
+		params = make(activesupport.Hash, len(attrs))
+		assocs = make(map[string]*activerecord.ActiveRecord)
+	)
+	for k, v := range attrs {
+		switch v := v.(type) {
+		case Sequence:
+			params[k] = v(n)
+		case association:
+			target := Create(v.factory, append([]Option{WithContext(overrides.ctx)}, v.opts...)...)
+			if err := target.Err(); err != nil {
+				return nil, nil, err
+			}
+			assocs[k] = target.Unwrap()
+		case *activerecord.ActiveRecord:
+			assocs[k] = v
+		default:
+			params[k] = v
+		}
+	}
+
+	rec, err := rel.WithContext(overrides.ctx).Initialize(params)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Targets of belongs-to associations are referenced by foreign keys, so
+	// the record is valid before it is inserted.
+	for assocName, target := range assocs {
+		ref := rec.ReflectOnAssociation(assocName)
+		if ref == nil {
+			return nil, nil, activerecord.ErrUnknownAssociation{RecordName: rec.Name(), Assoc: assocName}
+		}
+		if belongsTo, ok := ref.Association.(*activerecord.BelongsTo); ok {
+			if err = rec.AssignAttribute(belongsTo.AssociationForeignKey(), target.ID()); err != nil {
+				return nil, nil, err
+			}
+			delete(assocs, assocName)
+		}
+	}
+	return rec, assocs, nil
+}
@@ -0,0 +1,84 @@
+package testing_test
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/activegraph/activegraph/activerecord"
+	_ "github.com/activegraph/activegraph/activerecord/sqlite3"
+	artesting "github.com/activegraph/activegraph/activerecord/testing"
+	. "github.com/activegraph/activegraph/activesupport"
+)
+
+func TestCreate(t *testing.T) {
+	_, err := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter: "sqlite3", Database: t.Name() + ".db",
+	})
+	require.NoError(t, err)
+
+	defer os.Remove(t.Name() + ".db")
+	defer activerecord.RemoveConnection("primary")
+
+	activerecord.Migrate(t.Name()+"_add_authors_and_books_tables", func(m *activerecord.M) {
+		m.CreateTable("authors", func(t *activerecord.Table) {
+			t.String("name")
+		})
+		m.CreateTable("books", func(t *activerecord.Table) {
+			t.String("title")
+			t.Int64("year")
+			t.References("authors")
+		})
+	})
+
+	activerecord.New("author", func(r *activerecord.R) {
+		r.HasMany("books")
+	})
+	Book := activerecord.New("book", func(r *activerecord.R) {
+		r.BelongsTo("author")
+		r.ValidatesPresence("title")
+	})
+
+	artesting.DefineFactory("author", Hash{
+		"name": artesting.Sequence(func(n int) interface{} {
+			return fmt.Sprintf("Author %d", n)
+		}),
+	})
+	artesting.DefineFactory("book", Hash{
+		"title":  "Solaris",
+		"author": artesting.Association("author"),
+	}, artesting.Trait{Name: "classic", Attrs: Hash{"year": 1961}})
+
+	book := artesting.Create("book").Unwrap()
+	require.Equal(t, "Solaris", book.Attribute("title"))
+	require.Nil(t, book.Attribute("year"))
+
+	author := book.Association("author").Unwrap()
+	require.Equal(t, "Author 1", author.Attribute("name"))
+
+	book = artesting.Create("book", artesting.WithTraits("classic"), artesting.Override{
+		"title": "Eden", "author": author,
+	}).Unwrap()
+	require.Equal(t, "Eden", book.Attribute("title"))
+	require.EqualValues(t, 1961, book.Attribute("year"))
+	require.Equal(t, author.ID(), book.Association("author").Unwrap().ID())
+
+	books, err := artesting.CreateList("book", 2)
+	require.NoError(t, err)
+	require.Len(t, books, 2)
+
+	count, err := Book.Count()
+	require.NoError(t, err)
+	require.EqualValues(t, 4, count)
+
+	err = artesting.Create("book", artesting.Override{"title": nil}).Err()
+	require.Error(t, err)
+
+	err = artesting.Create("book", artesting.WithTraits("modern")).Err()
+	require.ErrorIs(t, err, new(artesting.ErrUnknownTrait))
+
+	err = artesting.Build("publisher").Err()
+	require.ErrorIs(t, err, new(artesting.ErrUnknownFactory))
+}
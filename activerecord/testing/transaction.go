@@ -0,0 +1,39 @@
+package testing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/activegraph/activegraph/activerecord"
+)
+
+// errRollback rolls back the transaction of the test.
+var errRollback = errors.New("rollback of the test transaction")
+
+// Transactional runs the test function within a transaction, which is rolled
+// back when the function returns, so tests leave the database in the same state
+// and do not need cleanup:
+//
+//	func TestAuthor(t *testing.T) {
+//		artesting.Transactional(t, func(tx context.Context) {
+//			author := Author.WithContext(tx).Create(Hash{"name": "Ada"})
+//			require.NoError(t, author.Err())
+//		})
+//	}
+//
+// Transactions of the code under test are executed within savepoints of the
+// test transaction (see activerecord.NotJoinable), so they are committed and
+// rolled back as without the test transaction. The transaction is rolled back
+// as well, when the function panics or fails the test with t.FailNow.
+func Transactional(t testing.TB, fn func(tx context.Context)) {
+	t.Helper()
+
+	err := activerecord.Transaction(context.Background(), func(tx context.Context) error {
+		fn(tx)
+		return errRollback
+	}, activerecord.NotJoinable())
+	if !errors.Is(err, errRollback) {
+		t.Fatalf("failed to roll back the test transaction: %s", err)
+	}
+}
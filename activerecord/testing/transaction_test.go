@@ -0,0 +1,58 @@
+package testing_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/activegraph/activegraph/activerecord"
+	_ "github.com/activegraph/activegraph/activerecord/sqlite3"
+	artesting "github.com/activegraph/activegraph/activerecord/testing"
+	. "github.com/activegraph/activegraph/activesupport"
+)
+
+func TestTransactional(t *testing.T) {
+	_, err := activerecord.EstablishConnection(activerecord.DatabaseConfig{
+		Adapter: "sqlite3", Database: t.Name() + ".db",
+	})
+	require.NoError(t, err)
+
+	defer os.Remove(t.Name() + ".db")
+	defer activerecord.RemoveConnection("primary")
+
+	activerecord.Migrate(t.Name()+"_add_authors_table", func(m *activerecord.M) {
+		m.CreateTable("authors", func(t *activerecord.Table) {
+			t.String("name")
+		})
+	})
+
+	Author := activerecord.New("author")
+
+	artesting.Transactional(t, func(tx context.Context) {
+		require.NoError(t, Author.WithContext(tx).Create(Hash{"name": "Lem"}).Err())
+
+		// Transactions of the code under test are executed within savepoints.
+		fail := errors.New("failed")
+		err := activerecord.Transaction(tx, func(tx context.Context) error {
+			require.NoError(t, Author.WithContext(tx).Create(Hash{"name": "Bradbury"}).Err())
+			return fail
+		})
+		require.ErrorIs(t, err, fail)
+
+		err = activerecord.Transaction(tx, func(tx context.Context) error {
+			return Author.WithContext(tx).Create(Hash{"name": "Strugatsky"}).Err()
+		})
+		require.NoError(t, err)
+
+		names, err := Author.WithContext(tx).Order("name").Pluck("name")
+		require.NoError(t, err)
+		require.Equal(t, [][]interface{}{{"Lem"}, {"Strugatsky"}}, names)
+	})
+
+	count, err := Author.Count()
+	require.NoError(t, err)
+	require.EqualValues(t, 0, count)
+}
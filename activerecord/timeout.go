@@ -0,0 +1,51 @@
+package activerecord
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ErrStatementTimeout is returned when the database statement is not completed
+// within the statement timeout.
+type ErrStatementTimeout struct {
+	Timeout time.Duration
+	Err     error
+}
+
+func (e *ErrStatementTimeout) Is(target error) bool {
+	_, ok := target.(*ErrStatementTimeout)
+	return ok
+}
+
+func (e *ErrStatementTimeout) Unwrap() error {
+	return e.Err
+}
+
+func (e *ErrStatementTimeout) Error() string {
+	if e.Timeout == 0 {
+		return fmt.Sprintf("statement timeout: %s", e.Err)
+	}
+	return fmt.Sprintf("statement timeout of %s exceeded: %s", e.Timeout, e.Err)
+}
+
+type statementTimeoutKey struct{}
+
+// WithStatementTimeout returns a copy of the context, which limits the duration of
+// each database statement executed with the context. The timeout overrides the
+// statement timeout of the database configuration.
+//
+//	ctx = activerecord.WithStatementTimeout(ctx, 200*time.Millisecond)
+//	books, err := Book.WithContext(ctx).Where("year", 1961).ToA()
+func WithStatementTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, statementTimeoutKey{}, timeout)
+}
+
+// statementTimeout returns the timeout specified in the context, or the default
+// timeout otherwise.
+func statementTimeout(ctx context.Context, defaultTimeout time.Duration) time.Duration {
+	if timeout, ok := ctx.Value(statementTimeoutKey{}).(time.Duration); ok {
+		return timeout
+	}
+	return defaultTimeout
+}
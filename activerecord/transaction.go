@@ -0,0 +1,117 @@
+package activerecord
+
+import (
+	"fmt"
+)
+
+// IsolationLevel is an isolation level of the transaction.
+type IsolationLevel string
+
+const (
+	ReadUncommitted IsolationLevel = "READ UNCOMMITTED"
+	ReadCommitted   IsolationLevel = "READ COMMITTED"
+	RepeatableRead  IsolationLevel = "REPEATABLE READ"
+	Serializable    IsolationLevel = "SERIALIZABLE"
+)
+
+// TransactionOptions are options of the transaction passed to the connection
+// adapter. Adapters, which do not support an option, ignore it.
+type TransactionOptions struct {
+	// Isolation is the isolation level of the transaction, the default
+	// isolation level of the database is used when empty.
+	Isolation IsolationLevel
+	// ReadOnly specifies the read-only access mode of the transaction.
+	ReadOnly bool
+}
+
+// ErrSerializationFailure is returned by adapters when the transaction cannot be
+// serialized with concurrent transactions. Such transactions could be retried.
+type ErrSerializationFailure struct {
+	Err error
+}
+
+func (e *ErrSerializationFailure) Is(target error) bool {
+	_, ok := target.(*ErrSerializationFailure)
+	return ok
+}
+
+func (e *ErrSerializationFailure) Unwrap() error {
+	return e.Err
+}
+
+func (e *ErrSerializationFailure) Error() string {
+	return fmt.Sprintf("serialization failure: %s", e.Err)
+}
+
+// transactionConfig is a configuration of the transaction block.
+type transactionConfig struct {
+	TransactionOptions
+
+	attempts    int
+	onRetry     func(attempt int, err error)
+	notJoinable bool
+}
+
+// TransactionOption configures the transaction.
+type TransactionOption func(*transactionConfig)
+
+// Isolation specifies the isolation level of the transaction.
+//
+//	activerecord.Transaction(ctx, func(tx context.Context) error {
+//		// ...
+//	}, activerecord.Isolation(activerecord.Serializable))
+func Isolation(level IsolationLevel) TransactionOption {
+	return func(c *transactionConfig) {
+		c.Isolation = level
+	}
+}
+
+// ReadOnly specifies the read-only access mode of the transaction.
+func ReadOnly() TransactionOption {
+	return func(c *transactionConfig) {
+		c.ReadOnly = true
+	}
+}
+
+// Retry specifies the maximum number of attempts to execute the transaction, when
+// it fails with ErrSerializationFailure. Each attempt executes the whole block
+// in a new transaction, therefore the block must not have side effects outside
+// of the database.
+//
+//	activerecord.Transaction(ctx, func(tx context.Context) error {
+//		// ...
+//	}, activerecord.Isolation(activerecord.Serializable), activerecord.Retry(3))
+func Retry(attempts int) TransactionOption {
+	return func(c *transactionConfig) {
+		c.attempts = attempts
+	}
+}
+
+// OnRetry specifies the function called before each retry of the transaction
+// with the number of the failed attempt and its error.
+func OnRetry(fn func(attempt int, err error)) TransactionOption {
+	return func(c *transactionConfig) {
+		c.onRetry = fn
+	}
+}
+
+// NotJoinable executes transactions nested into the transaction within savepoints,
+// instead of joining them to the transaction. So the failed nested transaction
+// rolls back only its own changes:
+//
+//	activerecord.Transaction(ctx, func(tx context.Context) error {
+//		Author.WithContext(tx).Create(Hash{"name": "Stanislaw Lem"})
+//
+//		// Rolls back to the savepoint, the author is kept.
+//		activerecord.Transaction(tx, func(tx context.Context) error {
+//			return Book.WithContext(tx).Create(Hash{"title": "Solaris"}).Err()
+//		})
+//		return nil
+//	}, activerecord.NotJoinable())
+//
+// Options of nested transactions are ignored by databases for savepoints.
+func NotJoinable() TransactionOption {
+	return func(c *transactionConfig) {
+		c.notJoinable = true
+	}
+}
@@ -116,11 +116,16 @@ func (b *Boolean) NativeType() string { return "BOOLEAN" }
 func (b *Boolean) String() string { return "boolean" }
 
 func (b *Boolean) Deserialize(value interface{}) (interface{}, error) {
-	boolval, ok := value.(bool)
-	if !ok {
-		return nil, ErrType{TypeName: b.String(), Value: value}
+	switch value := value.(type) {
+	case bool:
+		return value, nil
+	// Databases without boolean type store them as integers.
+	case int64:
+		if value == 0 || value == 1 {
+			return value == 1, nil
+		}
 	}
-	return boolval, nil
+	return nil, ErrType{TypeName: b.String(), Value: value}
 }
 
 func (*Boolean) Serialize(value interface{}) (interface{}, error) {
@@ -0,0 +1,264 @@
+package activerecord
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	. "github.com/activegraph/activegraph/activesupport"
+)
+
+// Column is an attribute of the relation with a static type of values. Columns
+// are declared once along with the model, so conditions with mismatching types
+// are rejected by the compiler. Columns are checked against attributes of the
+// relation with MustColumns, so misspelled names and mismatching types fail on
+// the program initialization instead of queries:
+//
+//	var ProductColumns = activerecord.MustColumns(ProductRelation, struct {
+//		Name  activerecord.Column[string]
+//		Price activerecord.Column[int64]
+//	}{
+//		Name:  activerecord.NewColumn[string]("name"),
+//		Price: activerecord.NewColumn[int64]("price"),
+//	})
+//
+//	activerecord.Query[Product](ProductRelation).Where(ProductColumns.Price.Gt(100))
+//
+// The struct of columns is generated from attributes of the relation with the
+// "relsy generate columns product" command (see the migration/cli package).
+type Column[T any] struct {
+	name string
+}
+
+// NewColumn returns a new typed column with the given attribute name.
+func NewColumn[T any](name string) Column[T] {
+	return Column[T]{name: name}
+}
+
+// typedColumn is the typed column of any type of values.
+type typedColumn interface {
+	Name() string
+	valueType() reflect.Type
+}
+
+func (c Column[T]) valueType() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+// ErrColumn is returned, when the typed column does not match attributes of
+// the relation.
+type ErrColumn struct {
+	RecordName string
+	Column     string
+	Message    string
+}
+
+func (e *ErrColumn) Is(target error) bool {
+	_, ok := target.(*ErrColumn)
+	return ok
+}
+
+func (e *ErrColumn) Error() string {
+	return fmt.Sprintf("column %q of %s: %s", e.Column, e.RecordName, e.Message)
+}
+
+// CheckColumns checks typed columns of the struct against attributes of the relation
+// and returns the struct as is: each column must be an attribute of the relation,
+// and the attribute type must accept values of the column type. Pointer types
+// of columns are checked by types of their elements.
+func CheckColumns[C any](rel *Relation, columns C) (C, error) {
+	v := reflect.ValueOf(columns)
+	if v.Kind() != reflect.Struct {
+		return columns, ErrArgument{Message: fmt.Sprintf("columns of %s must be a struct", rel.name)}
+	}
+
+	for i := 0; i < v.NumField(); i++ {
+		if !v.Type().Field(i).IsExported() {
+			continue
+		}
+		column, ok := v.Field(i).Interface().(typedColumn)
+		if !ok || (v.Field(i).Kind() == reflect.Ptr && v.Field(i).IsNil()) {
+			continue
+		}
+
+		attr := rel.scope.AttributeForInspect(column.Name())
+		if attr == nil {
+			return columns, &ErrColumn{
+				RecordName: rel.name, Column: column.Name(), Message: "unknown attribute",
+			}
+		}
+
+		typ := column.valueType()
+		if typ.Kind() == reflect.Ptr {
+			typ = typ.Elem()
+		}
+		if _, err := attr.AttributeType().Deserialize(reflect.Zero(typ).Interface()); err != nil {
+			return columns, &ErrColumn{
+				RecordName: rel.name, Column: column.Name(), Message: fmt.Sprintf(
+					"type %s does not match %s attribute", typ, attr.AttributeType(),
+				),
+			}
+		}
+	}
+	return columns, nil
+}
+
+// MustColumns is like CheckColumns, but panics when columns do not match attributes
+// of the relation, so it could be used to declare columns in package variables.
+func MustColumns[C any](rel *Relation, columns C) C {
+	columns, err := CheckColumns(rel, columns)
+	if err != nil {
+		panic(err)
+	}
+	return columns
+}
+
+// Name returns the attribute name of the column.
+func (c Column[T]) Name() string {
+	return c.name
+}
+
+// Eq returns an expression that matches values equal to the given one.
+func (c Column[T]) Eq(value T) Expr {
+	return Expr{Column: c.name, Condition: comparison("=", value)}
+}
+
+// NotEq returns an expression that matches values not equal to the given one.
+func (c Column[T]) NotEq(value T) Expr {
+	return Expr{Column: c.name, Condition: comparison("<>", value)}
+}
+
+// Gt returns an expression that matches values greater than the given one.
+func (c Column[T]) Gt(value T) Expr {
+	return Expr{Column: c.name, Condition: GreaterThan(value)}
+}
+
+// GtEq returns an expression that matches values greater than or equal to
+// the given one.
+func (c Column[T]) GtEq(value T) Expr {
+	return Expr{Column: c.name, Condition: GreaterThanOrEq(value)}
+}
+
+// Lt returns an expression that matches values less than the given one.
+func (c Column[T]) Lt(value T) Expr {
+	return Expr{Column: c.name, Condition: LessThan(value)}
+}
+
+// LtEq returns an expression that matches values less than or equal to the
+// given one.
+func (c Column[T]) LtEq(value T) Expr {
+	return Expr{Column: c.name, Condition: LessThanOrEq(value)}
+}
+
+// Between returns an expression that matches values within the given range.
+func (c Column[T]) Between(from, to T) Expr {
+	return Expr{Column: c.name, Condition: Between(from, to)}
+}
+
+// In returns an expression that matches any of the given values.
+func (c Column[T]) In(values ...T) Expr {
+	return Expr{Column: c.name, Condition: ConditionFunc(func(column string, t Type) Predicate {
+		if len(values) == 0 {
+			return Predicate{Cond: "1=0"}
+		}
+
+		args := make([]interface{}, len(values))
+		for i := range values {
+			args[i] = serialize(t, values[i])
+		}
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(values)), ", ")
+		return Predicate{Cond: fmt.Sprintf("%s IN (%s)", column, placeholders), Args: args}
+	})}
+}
+
+// IsNull returns an expression that matches absent values.
+func (c Column[T]) IsNull() Expr {
+	return Expr{Column: c.name, Condition: isNull}
+}
+
+// Asc returns an ascending order of the column, for use in Order method.
+func (c Column[T]) Asc() string {
+	return c.name + " ASC"
+}
+
+// Desc returns a descending order of the column, for use in Order method.
+func (c Column[T]) Desc() string {
+	return c.name + " DESC"
+}
+
+// Expr is a condition on the column built by typed columns.
+type Expr struct {
+	Column    string
+	Condition Condition
+}
+
+// TypedRelation is a relation, which returns records decoded into values of type M.
+// Fields of M are mapped to attributes the same way as in PluckAs.
+//
+//	type Product struct {
+//		ID    int64
+//		Name  string
+//		Price int64
+//	}
+//
+//	products, err := activerecord.Query[Product](ProductRelation).
+//		Where(ProductColumns.Price.Gt(100)).
+//		Order(ProductColumns.Name.Asc()).
+//		ToA()
+type TypedRelation[M any] struct {
+	rel *Relation
+}
+
+// Query returns a typed relation for the given relation.
+func Query[M any](rel *Relation) TypedRelation[M] {
+	return TypedRelation[M]{rel: rel}
+}
+
+// Relation returns the underlying untyped relation.
+func (q TypedRelation[M]) Relation() *Relation {
+	return q.rel
+}
+
+// Where returns a new relation with all the given expressions.
+func (q TypedRelation[M]) Where(exprs ...Expr) TypedRelation[M] {
+	rel := q.rel
+	for _, expr := range exprs {
+		rel = rel.Where(expr.Column, expr.Condition)
+	}
+	return TypedRelation[M]{rel: rel}
+}
+
+// Order returns a new relation ordered by the given values.
+func (q TypedRelation[M]) Order(values ...string) TypedRelation[M] {
+	return TypedRelation[M]{rel: q.rel.Order(values...)}
+}
+
+// Limit returns a new relation with the limit of records.
+func (q TypedRelation[M]) Limit(num int) TypedRelation[M] {
+	return TypedRelation[M]{rel: q.rel.Limit(num)}
+}
+
+// Count returns the number of records in the relation.
+func (q TypedRelation[M]) Count() (int64, error) {
+	return q.rel.Count()
+}
+
+// ToA returns records of the relation decoded into values of type M. Only the
+// attributes with the corresponding fields of M are selected.
+func (q TypedRelation[M]) ToA() ([]M, error) {
+	typ := reflect.TypeOf((*M)(nil)).Elem()
+	if typ.Kind() != reflect.Struct {
+		return nil, &ErrPluck{TypeName: typ.String(), Message: "typed relation requires a struct"}
+	}
+
+	var attrNames []string
+	for _, attrName := range q.rel.AttributeNames() {
+		if _, ok := pluckField(typ, attrName); ok {
+			attrNames = append(attrNames, attrName)
+		}
+	}
+	if len(attrNames) == 0 {
+		return nil, &ErrPluck{TypeName: typ.String(), Message: "no fields for attributes"}
+	}
+	return PluckAs[M](q.rel, attrNames...)
+}
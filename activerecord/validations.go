@@ -3,6 +3,7 @@ package activerecord
 import (
 	"fmt"
 	"regexp"
+	"sort"
 	"strings"
 
 	. "github.com/activegraph/activegraph/activesupport"
@@ -101,6 +102,21 @@ func (e *Errors) Delete(keys ...string) {
 	}
 }
 
+// Keys returns names of attributes with errors sorted by names.
+func (e *Errors) Keys() []string {
+	keys := make([]string, 0, len(e.errors))
+	for key := range e.errors {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Get returns errors of the attribute.
+func (e *Errors) Get(key string) []error {
+	return e.errors[key]
+}
+
 func (e *Errors) FullMessages() []string {
 	messages := make([]string, len(e.errors))
 	for _, keyErrors := range e.errors {
@@ -152,6 +168,14 @@ func (v *validations) Errors() Errors {
 	return v.errors
 }
 
+// ValidatorsOn returns validators of the attribute in order of declaration.
+//
+//	Book.ValidatorsOn("title")
+//	// [&activerecord.Presence{}, &activerecord.Length{Maximum: 255}]
+func (v *validations) ValidatorsOn(attrName string) []AttributeValidator {
+	return v.validators[attrName]
+}
+
 type typeValidator struct {
 	Type
 }
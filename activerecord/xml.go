@@ -0,0 +1,218 @@
+package activerecord
+
+import (
+	"bytes"
+	"encoding/xml"
+	"sort"
+	"time"
+)
+
+// xmlTypeNames are names of types annotated in the "type" attribute of XML
+// elements, values of string types are not annotated.
+var xmlTypeNames = map[string]string{
+	"int64":    "integer",
+	"float64":  "float",
+	"boolean":  "boolean",
+	"datetime": "datetime",
+	"date":     "date",
+	"time":     "time",
+	"json":     "json",
+}
+
+func xmlTypeName(t Type) string {
+	if t, ok := t.(Nil); ok {
+		return xmlTypeName(t.Type)
+	}
+	if t == nil {
+		return ""
+	}
+	return xmlTypeNames[t.String()]
+}
+
+// xmlValueTypeName returns the type annotation of the value computed by the
+// serialization method.
+func xmlValueTypeName(value interface{}) string {
+	switch value.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return "integer"
+	case float32, float64:
+		return "float"
+	case bool:
+		return "boolean"
+	case time.Time:
+		return "datetime"
+	default:
+		return ""
+	}
+}
+
+// xmlEncoder writes serialized records as XML elements.
+type xmlEncoder struct {
+	*xml.Encoder
+}
+
+func (e xmlEncoder) writeValue(name, typeName string, text string, null bool) error {
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	if typeName != "" {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "type"}, Value: typeName})
+	}
+	if null {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "nil"}, Value: "true"})
+	}
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	if !null {
+		if err := e.EncodeToken(xml.CharData(text)); err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+func (e xmlEncoder) writeArray(name string, records []*ActiveRecord, opts []SerializationOption) error {
+	start := xml.StartElement{
+		Name: xml.Name{Local: name},
+		Attr: []xml.Attr{{Name: xml.Name{Local: "type"}, Value: "array"}},
+	}
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	for _, rec := range records {
+		if err := e.writeRecord(rec.Name(), rec, newSerialization(opts)); err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+func (e xmlEncoder) writeRecord(name string, r *ActiveRecord, s *serialization) error {
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+
+	for _, attrName := range s.attributeNames(r) {
+		var (
+			t     = r.attributes.AttributeForInspect(attrName).AttributeType()
+			value = r.Attribute(attrName)
+		)
+		text, err := attributeText(t, value)
+		if err != nil {
+			return err
+		}
+		if err = e.writeValue(attrName, xmlTypeName(t), text, value == nil); err != nil {
+			return err
+		}
+	}
+
+	methodNames := make([]string, 0, len(s.methods))
+	for methodName := range s.methods {
+		methodNames = append(methodNames, methodName)
+	}
+	sort.Strings(methodNames)
+
+	for _, methodName := range methodNames {
+		value := s.methods[methodName](r)
+		text, err := attributeText(nil, value)
+		if err != nil {
+			return err
+		}
+		if err = e.writeValue(methodName, xmlValueTypeName(value), text, value == nil); err != nil {
+			return err
+		}
+	}
+
+	for _, assocName := range s.includedNames() {
+		opts := s.includes[assocName]
+
+		assoc, err := r.associations.find(assocName)
+		if err != nil {
+			return err
+		}
+
+		if _, ok := assoc.(CollectionAssociation); ok {
+			rel, err := r.AccessCollection(assocName)
+			if err != nil {
+				return err
+			}
+			records, err := rel.ToA()
+			if err != nil {
+				return err
+			}
+			if err = e.writeArray(assocName, records, opts); err != nil {
+				return err
+			}
+			continue
+		}
+
+		target, err := r.AccessAssociation(assocName)
+		if err != nil {
+			return err
+		}
+		if target == nil {
+			err = e.writeValue(assocName, "", "", true)
+		} else {
+			err = e.writeRecord(assocName, target, newSerialization(opts))
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return e.EncodeToken(start.End())
+}
+
+// encodeXML returns the indented XML document written by the function.
+func encodeXML(write func(xmlEncoder) error) ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+
+	enc := xmlEncoder{xml.NewEncoder(&b)}
+	enc.Indent("", "  ")
+
+	if err := write(enc); err != nil {
+		return nil, err
+	}
+	if err := enc.Flush(); err != nil {
+		return nil, err
+	}
+	b.WriteByte('\n')
+	return b.Bytes(), nil
+}
+
+// ToXML returns the XML document with attributes of the record, elements are
+// named after the record and its attributes. Values, which are not strings,
+// are annotated with types, included associations are nested into the element
+// of the record, and collections are annotated as arrays:
+//
+//	author.ToXML(activerecord.Only("name"), activerecord.Include("books", activerecord.Only("title")))
+//	// <?xml version="1.0" encoding="UTF-8"?>
+//	// <author>
+//	//   <name>Stanislaw Lem</name>
+//	//   <books type="array">
+//	//     <book>
+//	//       <title>Solaris</title>
+//	//     </book>
+//	//   </books>
+//	// </author>
+//
+// Options of the serialization are the same as options of ToJSON.
+func (r *ActiveRecord) ToXML(opts ...SerializationOption) ([]byte, error) {
+	return encodeXML(func(e xmlEncoder) error {
+		return e.writeRecord(r.Name(), r, newSerialization(opts))
+	})
+}
+
+// ToXML returns the XML document with the array of records of the relation,
+// the element of the array is named after the table of the relation. Records
+// are serialized the same way as by ActiveRecord.ToXML.
+func (rel *Relation) ToXML(opts ...SerializationOption) ([]byte, error) {
+	records, err := rel.ToA()
+	if err != nil {
+		return nil, err
+	}
+	return encodeXML(func(e xmlEncoder) error {
+		return e.writeArray(rel.TableName(), records, opts)
+	})
+}
@@ -0,0 +1,241 @@
+package activesupport
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// inflection is the rule replacing the word matching the regular expression.
+type inflection struct {
+	re          *regexp.Regexp
+	replacement string
+}
+
+// Inflections is the registry of rules to pluralize and singularize words. Rules
+// added later take precedence over the earlier ones, so exceptions are added on
+// top of the general rules:
+//
+//	activesupport.DefaultInflections.Irregular("cactus", "cacti")
+//	activesupport.DefaultInflections.Uncountable("metadata")
+//
+// Inflections is safe for concurrent use.
+type Inflections struct {
+	mu           sync.RWMutex
+	plurals      []inflection
+	singulars    []inflection
+	uncountables map[string]struct{}
+}
+
+// NewInflections returns a new registry without rules.
+func NewInflections() *Inflections {
+	return &Inflections{uncountables: make(map[string]struct{})}
+}
+
+// Plural adds the rule replacing the matched singular word with its plural form,
+// the rule is a case-insensitive regular expression, the replacement refers
+// submatches as ${1}.
+func (in *Inflections) Plural(rule, replacement string) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.plurals = append(in.plurals, inflection{regexp.MustCompile("(?i)" + rule), replacement})
+}
+
+// Singular adds the rule replacing the matched plural word with its singular form.
+func (in *Inflections) Singular(rule, replacement string) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.singulars = append(in.singulars, inflection{regexp.MustCompile("(?i)" + rule), replacement})
+}
+
+// Irregular adds rules of the word with irregular singular and plural forms, e.g.
+// "person" and "people". Rules match whole words or last words of snake-cased
+// names, the first letter of the word keeps its case.
+func (in *Inflections) Irregular(singular, plural string) {
+	var (
+		head         = "(^|_)(" + regexp.QuoteMeta(singular[:1]) + ")"
+		singularTail = regexp.QuoteMeta(singular[1:])
+		pluralTail   = regexp.QuoteMeta(plural[1:])
+	)
+
+	in.Plural(head+singularTail+"$", "${1}${2}"+plural[1:])
+	in.Plural(head+pluralTail+"$", "${1}${2}"+plural[1:])
+	in.Singular(head+singularTail+"$", "${1}${2}"+singular[1:])
+	in.Singular(head+pluralTail+"$", "${1}${2}"+singular[1:])
+}
+
+// Uncountable adds words, which have the same singular and plural forms.
+func (in *Inflections) Uncountable(words ...string) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	for _, word := range words {
+		in.uncountables[strings.ToLower(word)] = struct{}{}
+	}
+}
+
+// isUncountable returns true, when the word or the last word of the snake-cased
+// name is uncountable.
+func (in *Inflections) isUncountable(word string) bool {
+	word = strings.ToLower(word)
+	if i := strings.LastIndexByte(word, '_'); i >= 0 {
+		word = word[i+1:]
+	}
+	_, ok := in.uncountables[word]
+	return ok
+}
+
+func (in *Inflections) apply(word string, rules []inflection) string {
+	if word == "" || in.isUncountable(word) {
+		return word
+	}
+	for i := len(rules) - 1; i >= 0; i-- {
+		if rules[i].re.MatchString(word) {
+			return rules[i].re.ReplaceAllString(word, rules[i].replacement)
+		}
+	}
+	return word
+}
+
+// Pluralize returns the plural form of the word.
+func (in *Inflections) Pluralize(word string) string {
+	in.mu.RLock()
+	defer in.mu.RUnlock()
+	return in.apply(word, in.plurals)
+}
+
+// Singularize returns the singular form of the word.
+func (in *Inflections) Singularize(word string) string {
+	in.mu.RLock()
+	defer in.mu.RUnlock()
+	return in.apply(word, in.singulars)
+}
+
+// DefaultInflections are inflections of English words used to derive names of
+// tables, associations and foreign keys.
+var DefaultInflections = englishInflections()
+
+func englishInflections() *Inflections {
+	in := NewInflections()
+
+	in.Plural("$", "s")
+	in.Plural("s$", "s")
+	in.Plural("^(ax|test)is$", "${1}es")
+	in.Plural("(octop|vir)us$", "${1}i")
+	in.Plural("(octop|vir)i$", "${1}i")
+	in.Plural("(alias|status)$", "${1}es")
+	in.Plural("(bu)s$", "${1}ses")
+	in.Plural("(buffal|tomat)o$", "${1}oes")
+	in.Plural("([ti])um$", "${1}a")
+	in.Plural("([ti])a$", "${1}a")
+	in.Plural("sis$", "ses")
+	in.Plural("(?:([^f])fe|([lr])f)$", "${1}${2}ves")
+	in.Plural("(hive)$", "${1}s")
+	in.Plural("([^aeiouy]|qu)y$", "${1}ies")
+	in.Plural("(x|ch|ss|sh)$", "${1}es")
+	in.Plural("(matr|vert|ind)(?:ix|ex)$", "${1}ices")
+	in.Plural("^(m|l)ouse$", "${1}ice")
+	in.Plural("^(m|l)ice$", "${1}ice")
+	in.Plural("^(ox)$", "${1}en")
+	in.Plural("^(oxen)$", "${1}")
+	in.Plural("(quiz)$", "${1}zes")
+
+	in.Singular("s$", "")
+	in.Singular("(ss)$", "${1}")
+	in.Singular("(n)ews$", "${1}ews")
+	in.Singular("([ti])a$", "${1}um")
+	in.Singular("((a)naly|(b)a|(d)iagno|(p)arenthe|(p)rogno|(s)ynop|(t)he)(sis|ses)$", "${1}sis")
+	in.Singular("(^analy)(sis|ses)$", "${1}sis")
+	in.Singular("([^f])ves$", "${1}fe")
+	in.Singular("(hive)s$", "${1}")
+	in.Singular("(tive)s$", "${1}")
+	in.Singular("([lr])ves$", "${1}f")
+	in.Singular("([^aeiouy]|qu)ies$", "${1}y")
+	in.Singular("(s)eries$", "${1}eries")
+	in.Singular("(m)ovies$", "${1}ovie")
+	in.Singular("(x|ch|ss|sh)es$", "${1}")
+	in.Singular("^(m|l)ice$", "${1}ouse")
+	in.Singular("(bus)(es)?$", "${1}")
+	in.Singular("(o)es$", "${1}")
+	in.Singular("(shoe)s$", "${1}")
+	in.Singular("(cris|test)(is|es)$", "${1}is")
+	in.Singular("^(a)x[ie]s$", "${1}xis")
+	in.Singular("(octop|vir)(us|i)$", "${1}us")
+	in.Singular("(alias|status)(es)?$", "${1}")
+	in.Singular("^(ox)en", "${1}")
+	in.Singular("(vert|ind)ices$", "${1}ex")
+	in.Singular("(matr)ices$", "${1}ix")
+	in.Singular("(quiz)zes$", "${1}")
+	in.Singular("(database)s$", "${1}")
+
+	in.Irregular("person", "people")
+	in.Irregular("man", "men")
+	in.Irregular("child", "children")
+	in.Irregular("sex", "sexes")
+	in.Irregular("move", "moves")
+	in.Irregular("zombie", "zombies")
+
+	in.Uncountable(
+		"equipment", "information", "rice", "money", "species", "series",
+		"fish", "sheep", "jeans", "police",
+	)
+	return in
+}
+
+// Pluralize returns the plural form of the word with default inflections.
+//
+//	activesupport.Pluralize("category") // "categories"
+//	activesupport.Pluralize("person")   // "people"
+func Pluralize(word string) string {
+	return DefaultInflections.Pluralize(word)
+}
+
+// Singularize returns the singular form of the word with default inflections.
+//
+//	activesupport.Singularize("categories") // "category"
+func Singularize(word string) string {
+	return DefaultInflections.Singularize(word)
+}
+
+var (
+	underscoreAcronymRe = regexp.MustCompile(`([A-Z\d]+)([A-Z][a-z])`)
+	underscoreWordRe    = regexp.MustCompile(`([a-z\d])([A-Z])`)
+)
+
+// Underscore returns the snake-cased form of the camel-cased word.
+//
+//	activesupport.Underscore("BookAuthor") // "book_author"
+//	activesupport.Underscore("HTTPServer") // "http_server"
+func Underscore(word string) string {
+	word = underscoreAcronymRe.ReplaceAllString(word, "${1}_${2}")
+	word = underscoreWordRe.ReplaceAllString(word, "${1}_${2}")
+	return strings.ToLower(strings.ReplaceAll(word, "-", "_"))
+}
+
+// Camelize returns the camel-cased form of the snake-cased word.
+//
+//	activesupport.Camelize("book_author") // "BookAuthor"
+func Camelize(word string) string {
+	var buf strings.Builder
+	for _, part := range strings.Split(word, "_") {
+		if part != "" {
+			buf.WriteString(strings.ToUpper(part[:1]) + part[1:])
+		}
+	}
+	return buf.String()
+}
+
+// Tableize returns the name of the table of the model name.
+//
+//	activesupport.Tableize("BookAuthor") // "book_authors"
+func Tableize(name string) string {
+	return Pluralize(Underscore(name))
+}
+
+// ForeignKey returns the name of the foreign key referencing the model or the
+// table with the given name.
+//
+//	activesupport.ForeignKey("author")  // "author_id"
+//	activesupport.ForeignKey("people")  // "person_id"
+func ForeignKey(name string) string {
+	return Singularize(Underscore(name)) + "_id"
+}
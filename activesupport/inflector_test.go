@@ -0,0 +1,78 @@
+package activesupport
+
+import (
+	"testing"
+)
+
+func TestInflections_Pluralize(t *testing.T) {
+	tests := []struct {
+		singular string
+		plural   string
+	}{
+		{"book", "books"},
+		{"category", "categories"},
+		{"address", "addresses"},
+		{"status", "statuses"},
+		{"analysis", "analyses"},
+		{"wife", "wives"},
+		{"matrix", "matrices"},
+		{"person", "people"},
+		{"sales_person", "sales_people"},
+		{"Child", "Children"},
+		{"human", "humans"},
+		{"sheep", "sheep"},
+		{"book_series", "book_series"},
+	}
+
+	for _, tt := range tests {
+		if plural := Pluralize(tt.singular); plural != tt.plural {
+			t.Errorf("Pluralize(%q) = %q, want %q", tt.singular, plural, tt.plural)
+		}
+		if singular := Singularize(tt.plural); singular != tt.singular {
+			t.Errorf("Singularize(%q) = %q, want %q", tt.plural, singular, tt.singular)
+		}
+		if plural := Pluralize(tt.plural); plural != tt.plural {
+			t.Errorf("Pluralize(%q) = %q, want %q", tt.plural, plural, tt.plural)
+		}
+	}
+}
+
+func TestInflections_Irregular(t *testing.T) {
+	in := englishInflections()
+	in.Irregular("cactus", "cacti")
+	in.Uncountable("metadata")
+
+	if plural := in.Pluralize("cactus"); plural != "cacti" {
+		t.Errorf("Pluralize(%q) = %q, want %q", "cactus", plural, "cacti")
+	}
+	if singular := in.Singularize("metadata"); singular != "metadata" {
+		t.Errorf("Singularize(%q) = %q, want %q", "metadata", singular, "metadata")
+	}
+}
+
+func TestUnderscore(t *testing.T) {
+	tests := []struct {
+		camel string
+		snake string
+	}{
+		{"Book", "book"},
+		{"BookAuthor", "book_author"},
+		{"HTTPServer", "http_server"},
+		{"book-author", "book_author"},
+	}
+
+	for _, tt := range tests {
+		if snake := Underscore(tt.camel); snake != tt.snake {
+			t.Errorf("Underscore(%q) = %q, want %q", tt.camel, snake, tt.snake)
+		}
+	}
+	if camel := Camelize("book_author"); camel != "BookAuthor" {
+		t.Errorf("Camelize(%q) = %q, want %q", "book_author", camel, "BookAuthor")
+	}
+	if table := Tableize("BookAuthor"); table != "book_authors" {
+		t.Errorf("Tableize(%q) = %q, want %q", "BookAuthor", table, "book_authors")
+	}
+	if fk := ForeignKey("people"); fk != "person_id" {
+		t.Errorf("ForeignKey(%q) = %q, want %q", "people", fk, "person_id")
+	}
+}
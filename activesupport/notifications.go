@@ -0,0 +1,157 @@
+package activesupport
+
+import (
+	"sync"
+	"time"
+)
+
+// Event is the instrumented event, e.g. the executed SQL query.
+type Event struct {
+	// Name is the name of the event, by convention "event.library", e.g.
+	// "sql.active_record".
+	Name string
+	// Payload describes the event, keys of the payload are specific to the
+	// event.
+	Payload Hash
+	// Time is the start time of the event.
+	Time time.Time
+	// Duration is the duration of the instrumented block, it is zero for
+	// published events.
+	Duration time.Duration
+	// Err is the error returned by the instrumented block.
+	Err error
+}
+
+// Subscription is the subscriber of events, which could be unsubscribed.
+type Subscription struct {
+	name string
+	fn   func(Event)
+}
+
+// Notifications is the registry of event subscribers.
+//
+// Notifications is safe for concurrent use.
+type Notifications struct {
+	mu          sync.RWMutex
+	subscribers map[string][]*Subscription
+}
+
+// NewNotifications returns a new registry without subscribers.
+func NewNotifications() *Notifications {
+	return &Notifications{subscribers: make(map[string][]*Subscription)}
+}
+
+// Subscribe registers the function called for each event with the name, the
+// empty name subscribes to all events. Subscribers are called synchronously
+// after the event is finished, so they should not block.
+func (n *Notifications) Subscribe(name string, fn func(Event)) *Subscription {
+	sub := &Subscription{name: name, fn: fn}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.subscribers[name] = append(n.subscribers[name], sub)
+	return sub
+}
+
+// Unsubscribe removes the subscriber, so it is not called for the following
+// events.
+func (n *Notifications) Unsubscribe(sub *Subscription) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	subs := n.subscribers[sub.name]
+	for i := range subs {
+		if subs[i] == sub {
+			n.subscribers[sub.name] = append(subs[:i:i], subs[i+1:]...)
+			break
+		}
+	}
+}
+
+// subscribed returns subscribers of events with the name.
+func (n *Notifications) subscribed(name string) []*Subscription {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	named, all := n.subscribers[name], n.subscribers[""]
+	if len(all) == 0 {
+		return named
+	}
+	return append(append(make([]*Subscription, 0, len(named)+len(all)), named...), all...)
+}
+
+// Listening returns true, when there are subscribers of events with the name,
+// so event payloads could be built only when needed.
+func (n *Notifications) Listening(name string) bool {
+	return len(n.subscribed(name)) > 0
+}
+
+// Start starts the event, the returned function must be called when the event
+// is finished. Subscribers are notified when the event is finished, so values
+// added to the payload before finish are passed to subscribers.
+func (n *Notifications) Start(name string, payload Hash) (finish func(err error)) {
+	start := time.Now()
+	return func(err error) {
+		subs := n.subscribed(name)
+		if len(subs) == 0 {
+			return
+		}
+
+		event := Event{
+			Name:     name,
+			Payload:  payload,
+			Time:     start,
+			Duration: time.Since(start),
+			Err:      err,
+		}
+		for _, sub := range subs {
+			sub.fn(event)
+		}
+	}
+}
+
+// Instrument calls the function and notifies subscribers of the event with its
+// duration and error, the error of the function is returned.
+func (n *Notifications) Instrument(name string, payload Hash, fn func() error) error {
+	finish := n.Start(name, payload)
+	err := fn()
+	finish(err)
+	return err
+}
+
+// Publish notifies subscribers of the event, which does not have a duration.
+func (n *Notifications) Publish(name string, payload Hash) {
+	n.Start(name, payload)(nil)
+}
+
+// DefaultNotifications is the registry of subscribers of events instrumented by
+// the framework.
+var DefaultNotifications = NewNotifications()
+
+// Subscribe registers the function called for each event with the name:
+//
+//	activesupport.Subscribe("sql.active_record", func(e activesupport.Event) {
+//		log.Printf("%s (%s)", e.Payload["sql"], e.Duration)
+//	})
+func Subscribe(name string, fn func(Event)) *Subscription {
+	return DefaultNotifications.Subscribe(name, fn)
+}
+
+// Unsubscribe removes the subscriber of default notifications.
+func Unsubscribe(sub *Subscription) {
+	DefaultNotifications.Unsubscribe(sub)
+}
+
+// Instrument calls the function and notifies subscribers of default notifications:
+//
+//	err := activesupport.Instrument("render.action_view", Hash{"template": name}, func() error {
+//		return tmpl.Execute(w, data)
+//	})
+func Instrument(name string, payload Hash, fn func() error) error {
+	return DefaultNotifications.Instrument(name, payload, fn)
+}
+
+// Publish notifies subscribers of default notifications of the event.
+func Publish(name string, payload Hash) {
+	DefaultNotifications.Publish(name, payload)
+}
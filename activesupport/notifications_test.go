@@ -0,0 +1,44 @@
+package activesupport
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNotifications_Instrument(t *testing.T) {
+	var (
+		n      = NewNotifications()
+		events []Event
+	)
+	if n.Listening("render") {
+		t.Fatalf("notifications without subscribers are listening")
+	}
+
+	sub := n.Subscribe("render", func(e Event) {
+		events = append(events, e)
+	})
+	n.Subscribe("sql", func(Event) {
+		t.Fatalf("subscriber of other event is notified")
+	})
+
+	err := errors.New("missing template")
+	got := n.Instrument("render", Hash{"template": "index"}, func() error {
+		return err
+	})
+	if got != err {
+		t.Fatalf("%v != %v", got, err)
+	}
+
+	if len(events) != 1 {
+		t.Fatalf("%d events != 1", len(events))
+	}
+	if e := events[0]; e.Name != "render" || e.Payload["template"] != "index" || e.Err != err {
+		t.Errorf("unexpected event %+v", e)
+	}
+
+	n.Unsubscribe(sub)
+	n.Publish("render", nil)
+	if len(events) != 1 {
+		t.Errorf("unsubscribed subscriber is notified")
+	}
+}
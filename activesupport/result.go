@@ -143,6 +143,34 @@ func (self AnyResult[T]) UnwrapOr(val T) T {
 	return self.val
 }
 
+// Map returns the result of op applied to the Ok value of res, otherwise returns
+// the Err value of res.
+func Map[T, U comparable](res Result[T], op func(T) U) Result[U] {
+	if err := res.Err(); err != nil {
+		return Err[U](err)
+	}
+	return Ok(op(res.Ok().Unwrap()))
+}
+
+// MapErr returns the result of op applied to the Err value of res, otherwise
+// returns the Ok value of res.
+func MapErr[T comparable](res Result[T], op func(error) error) Result[T] {
+	if err := res.Err(); err != nil {
+		return Err[T](op(err))
+	}
+	return res
+}
+
+// WrapErr wraps the Err value of res with the message, so the original error
+// is still matched by errors.Is and errors.As:
+//
+//	WrapErr(res, "find author") // Err("find author: record not found")
+func WrapErr[T comparable](res Result[T], msg string) Result[T] {
+	return MapErr(res, func(err error) error {
+		return fmt.Errorf("%s: %w", msg, err)
+	})
+}
+
 type FutureResult[T comparable] struct {
 	callstack func() Result[T]
 	computed  *Result[T]
@@ -1,6 +1,8 @@
 package activesupport
 
 import (
+	"errors"
+	"fmt"
 	"testing"
 )
 
@@ -16,3 +18,36 @@ func TestFutureResult_AndThen(t *testing.T) {
 		t.Fatalf("%v != %v", res.Ok(), Some(15))
 	}
 }
+
+func TestMap(t *testing.T) {
+	res := Map[int, string](Ok(10), func(val int) string {
+		return fmt.Sprint(val * 2)
+	})
+	if !res.Contains("20") {
+		t.Fatalf("%v != %v", res.Ok(), Some("20"))
+	}
+
+	err := errors.New("failure")
+	res = Map[int, string](Err[int](err), func(val int) string {
+		t.Fatalf("op is called on Err")
+		return ""
+	})
+	if res.Err() != err {
+		t.Fatalf("%v != %v", res.Err(), err)
+	}
+}
+
+func TestWrapErr(t *testing.T) {
+	err := errors.New("failure")
+
+	res := WrapErr[int](Err[int](err), "compute")
+	if !errors.Is(res.Err(), err) {
+		t.Fatalf("%v is not %v", res.Err(), err)
+	}
+	if msg := res.Err().Error(); msg != "compute: failure" {
+		t.Fatalf("%q != %q", msg, "compute: failure")
+	}
+	if res := WrapErr[int](Ok(1), "compute"); !res.Contains(1) {
+		t.Fatalf("%v != %v", res.Ok(), Some(1))
+	}
+}
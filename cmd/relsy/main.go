@@ -0,0 +1,17 @@
+// Command relsy generates migrations and applies them to the database.
+//
+// The command applies only migrations compiled into it, build the command of
+// the application importing its migrations to migrate the database (see the
+// documentation of the cli package).
+package main
+
+import (
+	"github.com/activegraph/activegraph/activerecord/migration/cli"
+	_ "github.com/activegraph/activegraph/activerecord/mysql"
+	_ "github.com/activegraph/activegraph/activerecord/postgresql"
+	_ "github.com/activegraph/activegraph/activerecord/sqlite3"
+)
+
+func main() {
+	cli.Main()
+}